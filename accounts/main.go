@@ -0,0 +1,195 @@
+/*
+	libxdg-go - An implementaion of various freedesktop specifications in go
+    Copyright (C) 2025 MiracleOS Contributors
+
+    This program is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    This program is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+*/
+
+// Package accounts is a client of AccountsService, org.freedesktop.Accounts,
+// which holds the user information greeters, lock screens and user menus show:
+// real names, avatars and account types.
+package accounts
+
+import (
+	"os"
+	"sync"
+
+	"github.com/MiracleOS-Team/libxdg-go/internal/signals"
+	"github.com/godbus/dbus/v5"
+)
+
+const (
+	busName       = "org.freedesktop.Accounts"
+	objectPath    = dbus.ObjectPath("/org/freedesktop/Accounts")
+	interfaceName = "org.freedesktop.Accounts"
+	userInterface = "org.freedesktop.Accounts.User"
+)
+
+// Account types.
+const (
+	AccountTypeStandard      = 0
+	AccountTypeAdministrator = 1
+)
+
+// User describes a user account.
+type User struct {
+	Path          dbus.ObjectPath
+	UID           uint64
+	UserName      string
+	RealName      string
+	AccountType   int32
+	HomeDirectory string
+	Shell         string
+	Email         string
+	Language      string
+	Location      string
+	// IconFile is the path of the user's avatar, which may not exist.
+	IconFile       string
+	Locked         bool
+	AutomaticLogin bool
+	SystemAccount  bool
+	LocalAccount   bool
+	LoginTime      int64
+}
+
+// DisplayName returns the real name of the user, or the user name without one.
+func (u User) DisplayName() string {
+	if u.RealName != "" {
+		return u.RealName
+	}
+	return u.UserName
+}
+
+// Icon returns the path of the user's avatar, or "" if there is none.
+func (u User) Icon() string {
+	if u.IconFile == "" {
+		return ""
+	}
+	if _, err := os.Stat(u.IconFile); err != nil {
+		return ""
+	}
+	return u.IconFile
+}
+
+// IsAdministrator reports whether the user may administer the system.
+func (u User) IsAdministrator() bool {
+	return u.AccountType == AccountTypeAdministrator
+}
+
+// Event reports a change to a user account.
+type Event struct {
+	User    User
+	Added   bool
+	Changed bool
+	Deleted bool
+}
+
+// Client reads user accounts over the system bus.
+type Client struct {
+	conn        *dbus.Conn
+	own         bool
+	obj         dbus.BusObject
+	subscribers signals.Subscribers[Event]
+	watch       *signals.Watch
+	closeOnce   sync.Once
+}
+
+// NewClient connects to the system bus.
+func NewClient() (*Client, error) {
+	conn, err := dbus.ConnectSystemBus()
+	if err != nil {
+		return nil, err
+	}
+	c, err := newClient(conn, true)
+	if err != nil {
+		conn.Close()
+	}
+	return c, err
+}
+
+// NewClientWithConn creates a client on an existing system bus connection,
+// which is left open by Close.
+func NewClientWithConn(conn *dbus.Conn) (*Client, error) {
+	return newClient(conn, false)
+}
+
+func newClient(conn *dbus.Conn, own bool) (*Client, error) {
+	c := &Client{conn: conn, own: own, obj: conn.Object(busName, objectPath)}
+	watch, err := signals.NewWatch(conn, 16, c.dispatch, c.matches()...)
+	if err != nil {
+		return nil, err
+	}
+	c.watch = watch
+	return c, nil
+}
+
+func (c *Client) matches() [][]dbus.MatchOption {
+	return [][]dbus.MatchOption{
+		{dbus.WithMatchObjectPath(objectPath), dbus.WithMatchInterface(interfaceName)},
+		{dbus.WithMatchPathNamespace(objectPath), dbus.WithMatchInterface(userInterface), dbus.WithMatchMember("Changed")},
+	}
+}
+
+// Close stops listening to AccountsService.
+func (c *Client) Close() error {
+	var err error
+	c.closeOnce.Do(func() {
+		c.watch.Close()
+		c.subscribers.Close()
+		if c.own {
+			err = c.conn.Close()
+		}
+	})
+	return err
+}
+
+// Conn returns the D-Bus connection of the client.
+func (c *Client) Conn() *dbus.Conn {
+	return c.conn
+}
+
+// Subscribe registers a consumer of the client's events. Events that don't fit
+// in the buffer are dropped. The channel is closed by cancel or by Close.
+func (c *Client) Subscribe(buffer int) (<-chan Event, func()) {
+	return c.subscribers.Subscribe(buffer)
+}
+
+func (c *Client) dispatch(sig *dbus.Signal) {
+	switch sig.Name {
+	case interfaceName + ".UserAdded", interfaceName + ".UserDeleted":
+		if len(sig.Body) != 1 {
+			return
+		}
+		path, ok := sig.Body[0].(dbus.ObjectPath)
+		if !ok {
+			return
+		}
+		if sig.Name == interfaceName+".UserDeleted" {
+			c.subscribers.Publish(Event{User: User{Path: path}, Deleted: true})
+			return
+		}
+		go func() {
+			if u, err := c.userAt(path); err == nil {
+				c.subscribers.Publish(Event{User: u, Added: true})
+			}
+		}()
+	case userInterface + ".Changed":
+		go func(path dbus.ObjectPath) {
+			if u, err := c.userAt(path); err == nil {
+				c.subscribers.Publish(Event{User: u, Changed: true})
+			}
+		}(sig.Path)
+	}
+}
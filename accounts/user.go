@@ -0,0 +1,110 @@
+/*
+	libxdg-go - An implementaion of various freedesktop specifications in go
+    Copyright (C) 2025 MiracleOS Contributors
+
+    This program is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    This program is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+*/
+
+package accounts
+
+import (
+	"os"
+
+	"github.com/godbus/dbus/v5"
+)
+
+// CurrentUser returns the account of the user running the process.
+func (c *Client) CurrentUser() (User, error) {
+	return c.User(uint64(os.Getuid()))
+}
+
+// User returns an account by UID.
+func (c *Client) User(uid uint64) (User, error) {
+	var path dbus.ObjectPath
+	if err := c.obj.Call(interfaceName+".FindUserById", 0, int64(uid)).Store(&path); err != nil {
+		return User{}, err
+	}
+	return c.userAt(path)
+}
+
+// UserByName returns an account by user name.
+func (c *Client) UserByName(name string) (User, error) {
+	var path dbus.ObjectPath
+	if err := c.obj.Call(interfaceName+".FindUserByName", 0, name).Store(&path); err != nil {
+		return User{}, err
+	}
+	return c.userAt(path)
+}
+
+// Users lists the accounts of the people who log in, as a greeter shows them.
+func (c *Client) Users() ([]User, error) {
+	var paths []dbus.ObjectPath
+	if err := c.obj.Call(interfaceName+".ListCachedUsers", 0).Store(&paths); err != nil {
+		return nil, err
+	}
+	users := make([]User, 0, len(paths))
+	for _, path := range paths {
+		if u, err := c.userAt(path); err == nil {
+			users = append(users, u)
+		}
+	}
+	return users, nil
+}
+
+func (c *Client) userAt(path dbus.ObjectPath) (User, error) {
+	var props map[string]dbus.Variant
+	err := c.conn.Object(busName, path).Call("org.freedesktop.DBus.Properties.GetAll", 0, userInterface).Store(&props)
+	if err != nil {
+		return User{}, err
+	}
+	get := func(name string, value any) {
+		if v, found := props[name]; found {
+			v.Store(value)
+		}
+	}
+	u := User{Path: path}
+	get("Uid", &u.UID)
+	get("UserName", &u.UserName)
+	get("RealName", &u.RealName)
+	get("AccountType", &u.AccountType)
+	get("HomeDirectory", &u.HomeDirectory)
+	get("Shell", &u.Shell)
+	get("Email", &u.Email)
+	get("Language", &u.Language)
+	get("Location", &u.Location)
+	get("IconFile", &u.IconFile)
+	get("Locked", &u.Locked)
+	get("AutomaticLogin", &u.AutomaticLogin)
+	get("SystemAccount", &u.SystemAccount)
+	get("LocalAccount", &u.LocalAccount)
+	get("LoginTime", &u.LoginTime)
+	return u, nil
+}
+
+// SetRealName changes the real name of an account. Users may change their
+// own; changing others' requires authorization.
+func (c *Client) SetRealName(u User, name string) error {
+	return c.conn.Object(busName, u.Path).Call(userInterface+".SetRealName", 0, name).Err
+}
+
+// SetIconFile changes the avatar of an account to a copy of an image file.
+func (c *Client) SetIconFile(u User, path string) error {
+	return c.conn.Object(busName, u.Path).Call(userInterface+".SetIconFile", 0, path).Err
+}
+
+// SetEmail changes the email address of an account.
+func (c *Client) SetEmail(u User, email string) error {
+	return c.conn.Object(busName, u.Path).Call(userInterface+".SetEmail", 0, email).Err
+}
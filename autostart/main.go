@@ -0,0 +1,224 @@
+/*
+	libxdg-go - An implementaion of various freedesktop specifications in go
+    Copyright (C) 2025 MiracleOS Contributors
+
+    This program is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    This program is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+*/
+
+// Package autostart implements the Desktop Application Autostart specification:
+// it lists the desktop entries of the autostart directories and launches those
+// meant to start with the session.
+package autostart
+
+import (
+	"errors"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+
+	basedir "github.com/MiracleOS-Team/libxdg-go/baseDir"
+	"github.com/MiracleOS-Team/libxdg-go/desktopFiles"
+	"github.com/MiracleOS-Team/libxdg-go/keyfile"
+)
+
+// keyEnabled is GNOME's extension to disable an entry without hiding it.
+const keyEnabled = "X-GNOME-Autostart-enabled"
+
+// Entry is an autostart desktop entry.
+type Entry struct {
+	// ID is the file name of the entry, e.g. "nm-applet.desktop". An entry of a
+	// directory with higher precedence overrides those of the same ID.
+	ID   string
+	Path string
+	desktopFiles.DesktopFile
+	// Enabled is false when X-GNOME-Autostart-enabled is false.
+	Enabled bool
+	// Phase is the session startup phase the entry starts in.
+	Phase Phase
+	// After are the IDs of the entries this one starts after, from
+	// X-KDE-autostart-after.
+	After []string
+}
+
+// Dirs returns the autostart directories, highest precedence first:
+// $XDG_CONFIG_HOME/autostart, then $XDG_CONFIG_DIRS/autostart in order.
+func Dirs() []string {
+	dirs := []string{filepath.Join(basedir.GetXDGDirectory("config").(string), "autostart")}
+	for _, dir := range basedir.GetXDGDirectory("configDirs").([]string) {
+		dirs = append(dirs, filepath.Join(dir, "autostart"))
+	}
+	return dirs
+}
+
+// UserDir returns the user's autostart directory, $XDG_CONFIG_HOME/autostart.
+func UserDir() string {
+	return Dirs()[0]
+}
+
+// List returns every autostart entry, by ID, overrides applied. Entries that
+// can't be read are skipped.
+func List() ([]Entry, error) {
+	seen := make(map[string]bool)
+	var entries []Entry
+	for _, dir := range Dirs() {
+		paths, err := filepath.Glob(filepath.Join(dir, "*.desktop"))
+		if err != nil {
+			return nil, err
+		}
+		for _, path := range paths {
+			id := filepath.Base(path)
+			if seen[id] {
+				continue
+			}
+			seen[id] = true
+
+			entry, err := readEntry(path)
+			if err != nil {
+				slog.Debug("Skipping unreadable autostart entry", "path", path, "error", err)
+				continue
+			}
+			entries = append(entries, entry)
+		}
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].ID < entries[j].ID })
+	return entries, nil
+}
+
+// Lookup returns the autostart entry of an ID, overrides applied.
+func Lookup(id string) (Entry, error) {
+	if !strings.HasSuffix(id, ".desktop") {
+		id += ".desktop"
+	}
+	for _, dir := range Dirs() {
+		path := filepath.Join(dir, id)
+		if _, err := os.Stat(path); err == nil {
+			return readEntry(path)
+		}
+	}
+	return Entry{}, os.ErrNotExist
+}
+
+func readEntry(path string) (Entry, error) {
+	dfile, err := desktopFiles.ReadDesktopFile(path)
+	if err != nil {
+		return Entry{}, err
+	}
+	entry := Entry{ID: filepath.Base(path), Path: path, DesktopFile: dfile, Enabled: true}
+
+	// Extension keys aren't part of DesktopFile.
+	kf, err := keyfile.LoadLenient(path)
+	if err != nil {
+		return Entry{}, err
+	}
+	// A missing group reads as an empty one.
+	group := kf.AddGroup("Desktop Entry")
+	if enabled, err := group.Bool(keyEnabled); err == nil {
+		entry.Enabled = enabled
+	}
+	entry.Phase = parsePhase(group.String(keyPhase))
+	for _, id := range strings.FieldsFunc(group.String(keyAfter), func(r rune) bool { return r == ';' || r == ',' }) {
+		if id = strings.TrimSpace(id); id != "" {
+			if !strings.HasSuffix(id, ".desktop") {
+				id += ".desktop"
+			}
+			entry.After = append(entry.After, id)
+		}
+	}
+	return entry, nil
+}
+
+// CurrentDesktops returns the names in XDG_CURRENT_DESKTOP, e.g. ["GNOME"].
+func CurrentDesktops() []string {
+	var desktops []string
+	for _, desktop := range strings.Split(os.Getenv("XDG_CURRENT_DESKTOP"), ":") {
+		if desktop != "" {
+			desktops = append(desktops, desktop)
+		}
+	}
+	return desktops
+}
+
+// ShownIn reports whether the entry applies to a session of the given desktops,
+// according to OnlyShowIn and NotShowIn.
+func (e Entry) ShownIn(desktops []string) bool {
+	for _, desktop := range desktops {
+		if slices.Contains(e.NotShowIn, desktop) {
+			return false
+		}
+	}
+	if len(e.OnlyShowIn) == 0 {
+		return true
+	}
+	for _, desktop := range desktops {
+		if slices.Contains(e.OnlyShowIn, desktop) {
+			return true
+		}
+	}
+	return false
+}
+
+// ShouldStart reports whether the entry starts in a session of the given
+// desktops: it is an enabled, not hidden application shown in them, whose
+// TryExec program is installed.
+func (e Entry) ShouldStart(desktops []string) bool {
+	if e.Hidden || !e.Enabled || e.Type != "Application" || e.ApplicationObject.Exec == "" || !e.ShownIn(desktops) {
+		return false
+	}
+	if tryExec := e.ApplicationObject.TryExec; tryExec != "" {
+		if _, err := exec.LookPath(tryExec); err != nil {
+			return false
+		}
+	}
+	return true
+}
+
+// Active returns the entries that start in the current session.
+func Active() ([]Entry, error) {
+	entries, err := List()
+	if err != nil {
+		return nil, err
+	}
+	desktops := CurrentDesktops()
+	return slices.DeleteFunc(entries, func(e Entry) bool { return !e.ShouldStart(desktops) }), nil
+}
+
+// Launch runs the entry with the executor and waits for it to exit.
+func (e Entry) Launch() error {
+	return desktopFiles.ExecuteDesktopFile(e.DesktopFile, nil, e.Path)
+}
+
+// Start launches the entries that start in the current session, without
+// waiting for them, and returns them. Launch failures are logged.
+func Start() ([]Entry, error) {
+	entries, err := Active()
+	if err != nil {
+		return nil, err
+	}
+	for _, e := range entries {
+		go func(e Entry) {
+			err := e.Launch()
+			if errors.As(err, new(*exec.ExitError)) {
+				slog.Debug("Autostart entry exited", "id", e.ID, "error", err)
+			} else if err != nil {
+				slog.Error("Failed to launch autostart entry", "id", e.ID, "error", err)
+			}
+		}(e)
+	}
+	return entries, nil
+}
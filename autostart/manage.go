@@ -0,0 +1,144 @@
+/*
+	libxdg-go - An implementaion of various freedesktop specifications in go
+    Copyright (C) 2025 MiracleOS Contributors
+
+    This program is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    This program is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+*/
+
+package autostart
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/MiracleOS-Team/libxdg-go/desktopFiles"
+	"github.com/MiracleOS-Team/libxdg-go/keyfile"
+)
+
+const entryGroup = "Desktop Entry"
+
+// userPath returns the path of the user's entry of an ID.
+func userPath(id string) string {
+	if !strings.HasSuffix(id, ".desktop") {
+		id += ".desktop"
+	}
+	return filepath.Join(UserDir(), filepath.Base(id))
+}
+
+// Disable keeps an entry from starting by setting Hidden=true in the user's
+// entry, which is created as a copy of the system one if needed.
+func Disable(id string) error {
+	return editUserEntry(id, map[string]string{"Hidden": "true"})
+}
+
+// Enable makes a disabled entry start again by setting Hidden=false and, if
+// present, X-GNOME-Autostart-enabled=true in the user's entry.
+func Enable(id string) error {
+	entry, err := Lookup(id)
+	if err != nil {
+		return err
+	}
+	if !entry.Hidden && entry.Enabled {
+		return nil
+	}
+	keys := map[string]string{"Hidden": "false"}
+	if !entry.Enabled {
+		keys[keyEnabled] = "true"
+	}
+	return editUserEntry(id, keys)
+}
+
+// editUserEntry sets keys of the user's entry of an ID, copying the entry of
+// the highest precedence directory to the user's directory first.
+func editUserEntry(id string, keys map[string]string) error {
+	entry, err := Lookup(id)
+	if err != nil {
+		return err
+	}
+	kf, err := keyfile.LoadLenient(entry.Path)
+	if err != nil {
+		return err
+	}
+	group := kf.AddGroup(entryGroup)
+	for key, value := range keys {
+		group.Set(key, value)
+	}
+	return kf.Save(userPath(entry.ID))
+}
+
+// AddCommand creates a user entry starting a command line, named after name,
+// and returns it. Its ID is derived from name and made unique.
+func AddCommand(name, command string) (Entry, error) {
+	if strings.TrimSpace(command) == "" {
+		return Entry{}, errors.New("empty command")
+	}
+	// Values are single lines.
+	name = strings.Join(strings.Fields(name), " ")
+	command = strings.Join(strings.Fields(command), " ")
+	base := strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-' || r == '_' || r == '.' {
+			return r
+		}
+		return '-'
+	}, strings.TrimSpace(name))
+	if base == "" {
+		base = "command"
+	}
+
+	path := userPath(base)
+	for n := 2; ; n++ {
+		if _, err := os.Lstat(path); errors.Is(err, os.ErrNotExist) {
+			break
+		}
+		path = userPath(fmt.Sprintf("%s-%d", base, n))
+	}
+
+	kf := keyfile.New()
+	group := kf.AddGroup(entryGroup)
+	group.SetString("Type", "Application")
+	group.SetString("Name", name)
+	group.SetString("Exec", command)
+	if err := kf.Save(path); err != nil {
+		return Entry{}, err
+	}
+	return readEntry(path)
+}
+
+// AddApplication makes an installed application start with the session by
+// copying its desktop file to the user's directory, and returns the entry.
+func AddApplication(desktopID string) (Entry, error) {
+	_, path, err := desktopFiles.FindDesktopFile(desktopID)
+	if err != nil {
+		return Entry{}, err
+	}
+	kf, err := keyfile.LoadLenient(path)
+	if err != nil {
+		return Entry{}, err
+	}
+	target := userPath(strings.TrimSuffix(desktopID, ".desktop"))
+	if err := kf.Save(target); err != nil {
+		return Entry{}, err
+	}
+	return readEntry(target)
+}
+
+// Remove deletes the user's entry of an ID. An entry of the system directories
+// it overrode applies again.
+func Remove(id string) error {
+	return os.Remove(userPath(id))
+}
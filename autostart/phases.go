@@ -0,0 +1,228 @@
+/*
+	libxdg-go - An implementaion of various freedesktop specifications in go
+    Copyright (C) 2025 MiracleOS Contributors
+
+    This program is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    This program is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+*/
+
+package autostart
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"os/exec"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/godbus/dbus/v5"
+)
+
+const (
+	keyPhase = "X-GNOME-Autostart-Phase"
+	keyAfter = "X-KDE-autostart-after"
+)
+
+// launchGrace is how long a launched program must keep running, or exit
+// successfully within, to count as started.
+const launchGrace = 500 * time.Millisecond
+
+// Phase is a stage of the session startup, as named by X-GNOME-Autostart-Phase.
+// Entries of a phase start once those of the previous phases have.
+type Phase int
+
+const (
+	PhaseEarlyInitialization Phase = iota
+	PhasePreDisplayServer
+	PhaseDisplayServer
+	PhaseInitialization
+	PhaseWindowManager
+	PhasePanel
+	PhaseDesktop
+	// PhaseApplications is the phase of entries that don't name one.
+	PhaseApplications
+)
+
+var phaseNames = []string{
+	"EarlyInitialization", "PreDisplayServer", "DisplayServer", "Initialization",
+	"WindowManager", "Panel", "Desktop", "Applications",
+}
+
+// String returns the X-GNOME-Autostart-Phase name of the phase.
+func (p Phase) String() string {
+	if p >= 0 && int(p) < len(phaseNames) {
+		return phaseNames[p]
+	}
+	return "unknown"
+}
+
+func parsePhase(name string) Phase {
+	for i, phaseName := range phaseNames {
+		if strings.EqualFold(name, phaseName) {
+			return Phase(i)
+		}
+	}
+	return PhaseApplications
+}
+
+// Result is the outcome of starting an entry.
+type Result struct {
+	Entry Entry
+	// Err is why the entry failed to start, or nil.
+	Err error
+}
+
+// StartPhased starts the entries of the current session phase by phase,
+// honouring X-KDE-autostart-after within a phase, and reports the outcome of
+// every entry. Entries without ordering constraints start concurrently.
+func StartPhased(ctx context.Context) ([]Result, error) {
+	entries, err := Active()
+	if err != nil {
+		return nil, err
+	}
+	return StartEntries(ctx, entries), nil
+}
+
+// StartEntries starts entries phase by phase, like StartPhased.
+func StartEntries(ctx context.Context, entries []Entry) []Result {
+	byPhase := make(map[Phase][]Entry)
+	for _, e := range entries {
+		byPhase[e.Phase] = append(byPhase[e.Phase], e)
+	}
+	phases := make([]Phase, 0, len(byPhase))
+	for phase := range byPhase {
+		phases = append(phases, phase)
+	}
+	sort.Slice(phases, func(i, j int) bool { return phases[i] < phases[j] })
+
+	var results []Result
+	for _, phase := range phases {
+		results = append(results, startPhase(ctx, byPhase[phase])...)
+	}
+	return results
+}
+
+// startPhase starts the entries of a phase, each once the entries it starts
+// after did, and waits for all of them.
+func startPhase(ctx context.Context, entries []Entry) []Result {
+	deps := dependencies(entries)
+	done := make(map[string]chan struct{}, len(entries))
+	for _, e := range entries {
+		done[e.ID] = make(chan struct{})
+	}
+
+	results := make([]Result, len(entries))
+	for i, e := range entries {
+		go func(i int, e Entry) {
+			defer close(done[e.ID])
+			for _, dep := range deps[e.ID] {
+				<-done[dep]
+			}
+			results[i] = Result{Entry: e, Err: e.start(ctx)}
+		}(i, e)
+	}
+	for _, e := range entries {
+		<-done[e.ID]
+	}
+	return results
+}
+
+// dependencies returns the entries each entry of a phase waits for. Entries of
+// other phases or not starting are ignored, as are the constraints of entries
+// in a cycle, which start in no particular order.
+func dependencies(entries []Entry) map[string][]string {
+	present := make(map[string]bool, len(entries))
+	for _, e := range entries {
+		present[e.ID] = true
+	}
+	deps := make(map[string][]string)
+	for _, e := range entries {
+		for _, after := range e.After {
+			if present[after] && after != e.ID {
+				deps[e.ID] = append(deps[e.ID], after)
+			}
+		}
+	}
+
+	// Kahn's algorithm: whatever can't be ordered is in a cycle.
+	pending := make(map[string]int, len(entries))
+	dependents := make(map[string][]string)
+	for id, list := range deps {
+		pending[id] = len(list)
+		for _, dep := range list {
+			dependents[dep] = append(dependents[dep], id)
+		}
+	}
+	var ready []string
+	for _, e := range entries {
+		if pending[e.ID] == 0 {
+			ready = append(ready, e.ID)
+		}
+	}
+	for len(ready) > 0 {
+		id := ready[0]
+		ready = ready[1:]
+		for _, dependent := range dependents[id] {
+			if pending[dependent]--; pending[dependent] == 0 {
+				ready = append(ready, dependent)
+			}
+		}
+	}
+	for id, n := range pending {
+		if n > 0 {
+			slog.Warn("Ignoring cyclic autostart ordering", "id", id, "after", deps[id])
+			delete(deps, id)
+		}
+	}
+	return deps
+}
+
+// start starts an entry: D-Bus activatable applications are activated on the
+// session bus, others launched with the executor. A launched program counts as
+// started if it is still running after a grace period or exited successfully.
+func (e Entry) start(ctx context.Context) error {
+	if e.DBusActivatable {
+		return e.activate(ctx)
+	}
+
+	errs := make(chan error, 1)
+	go func() { errs <- e.Launch() }()
+	select {
+	case err := <-errs:
+		return err
+	case <-time.After(launchGrace):
+		go func() {
+			if err := <-errs; err != nil && !errors.As(err, new(*exec.ExitError)) {
+				slog.Error("Autostart entry failed", "id", e.ID, "error", err)
+			}
+		}()
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// activate D-Bus activates the application of the entry, whose well-known name
+// is its ID.
+func (e Entry) activate(ctx context.Context) error {
+	conn, err := dbus.SessionBus()
+	if err != nil {
+		return err
+	}
+	name := strings.TrimSuffix(e.ID, ".desktop")
+	path := "/" + strings.ReplaceAll(strings.ReplaceAll(name, ".", "/"), "-", "_")
+	return conn.Object(name, dbus.ObjectPath(path)).CallWithContext(ctx, "org.freedesktop.Application.Activate", 0, map[string]dbus.Variant{}).Err
+}
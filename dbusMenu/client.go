@@ -0,0 +1,304 @@
+/*
+	libxdg-go - An implementaion of various freedesktop specifications in go
+    Copyright (C) 2025 MiracleOS Contributors
+
+    This program is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    This program is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+*/
+
+package dbusMenu
+
+import (
+	"errors"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/MiracleOS-Team/libxdg-go/internal/signals"
+	"github.com/godbus/dbus/v5"
+)
+
+// Event reports a change to a remote menu.
+type Event struct {
+	// ID is the item concerned: the parent of the changed layout, the item whose
+	// properties changed, or the item to show.
+	ID int32
+	// LayoutUpdated means the children of ID changed.
+	LayoutUpdated bool
+	// PropertiesUpdated means properties of ID changed.
+	PropertiesUpdated bool
+	// ActivationRequested means the application asks for the menu of ID to be
+	// shown, e.g. after a keyboard shortcut.
+	ActivationRequested bool
+}
+
+// Client mirrors a menu exported by another application.
+type Client struct {
+	conn        *dbus.Conn
+	obj         dbus.BusObject
+	owner       string
+	mu          sync.Mutex
+	root        *Item
+	items       map[int32]*Item
+	revision    uint32
+	subscribers signals.Subscribers[Event]
+	watch       *signals.Watch
+	closeOnce   sync.Once
+}
+
+// NewClient mirrors the menu exported at path by service. The connection is left
+// open by Close.
+func NewClient(conn *dbus.Conn, service string, path dbus.ObjectPath) (*Client, error) {
+	c := &Client{conn: conn, obj: conn.Object(service, path)}
+	// Signals come from the unique name of the menu's connection.
+	if err := conn.BusObject().Call("org.freedesktop.DBus.GetNameOwner", 0, service).Store(&c.owner); err != nil {
+		return nil, err
+	}
+
+	watch, err := signals.NewWatch(conn, 16, c.dispatch, c.match())
+	if err != nil {
+		return nil, err
+	}
+	c.watch = watch
+
+	if err := c.Refresh(); err != nil {
+		c.Close()
+		return nil, err
+	}
+	return c, nil
+}
+
+func (c *Client) match() []dbus.MatchOption {
+	return []dbus.MatchOption{
+		dbus.WithMatchSender(c.owner),
+		dbus.WithMatchObjectPath(c.obj.Path()),
+		dbus.WithMatchInterface(menuInterface),
+	}
+}
+
+// Close stops mirroring the menu.
+func (c *Client) Close() {
+	c.closeOnce.Do(func() {
+		c.watch.Close()
+		c.subscribers.Close()
+	})
+}
+
+// Subscribe registers a consumer of the menu's events. Events that don't fit in
+// the buffer are dropped. The channel is closed by cancel or by Close.
+func (c *Client) Subscribe(buffer int) (<-chan Event, func()) {
+	return c.subscribers.Subscribe(buffer)
+}
+
+// Root returns a copy of the menu tree.
+func (c *Client) Root() *Item {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.root.clone()
+}
+
+// Item returns a copy of an item and its submenu.
+func (c *Client) Item(id int32) (*Item, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if it, found := c.items[id]; found {
+		return it.clone(), true
+	}
+	return nil, false
+}
+
+// Revision returns the layout revision of the menu.
+func (c *Client) Revision() uint32 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.revision
+}
+
+// Refresh reads the whole menu again.
+func (c *Client) Refresh() error {
+	return c.refresh(0)
+}
+
+// refresh reads the layout below an item and replaces it in the tree.
+func (c *Client) refresh(parent int32) error {
+	var revision uint32
+	var layout []any
+	err := c.obj.Call(menuInterface+".GetLayout", 0, parent, int32(-1), []string{}).Store(&revision, &layout)
+	if err != nil {
+		return err
+	}
+	item, err := decodeLayout(layout)
+	if err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.revision = revision
+	old, found := c.items[item.ID]
+	if item.ID == 0 || !found || c.root == nil {
+		c.root = item
+		c.items = make(map[int32]*Item)
+		index(c.items, item)
+		return nil
+	}
+	// Replace the subtree in place so parents keep pointing to it.
+	removeIndex(c.items, old)
+	*old = *item
+	index(c.items, old)
+	return nil
+}
+
+func index(items map[int32]*Item, it *Item) {
+	items[it.ID] = it
+	for _, child := range it.Children {
+		index(items, child)
+	}
+}
+
+func removeIndex(items map[int32]*Item, it *Item) {
+	delete(items, it.ID)
+	for _, child := range it.Children {
+		removeIndex(items, child)
+	}
+}
+
+// decodeLayout decodes an (ia{sv}av) layout.
+func decodeLayout(layout []any) (*Item, error) {
+	if len(layout) != 3 {
+		return nil, errors.New("malformed dbusmenu layout")
+	}
+	id, ok1 := layout[0].(int32)
+	props, ok2 := layout[1].(map[string]dbus.Variant)
+	children, ok3 := layout[2].([]dbus.Variant)
+	if !ok1 || !ok2 || !ok3 {
+		return nil, errors.New("malformed dbusmenu layout")
+	}
+	it := &Item{ID: id, Properties: props}
+	it.applyProperties()
+	for _, v := range children {
+		child, ok := v.Value().([]any)
+		if !ok {
+			return nil, errors.New("malformed dbusmenu layout")
+		}
+		childItem, err := decodeLayout(child)
+		if err != nil {
+			return nil, err
+		}
+		it.Children = append(it.Children, childItem)
+	}
+	return it, nil
+}
+
+func (c *Client) dispatch(sig *dbus.Signal) {
+	if sig.Path != c.obj.Path() {
+		return
+	}
+	switch sig.Name {
+	case menuInterface + ".LayoutUpdated":
+		if len(sig.Body) < 2 {
+			return
+		}
+		parent, _ := sig.Body[1].(int32)
+		if err := c.refresh(parent); err != nil {
+			slog.Debug("Failed to read dbusmenu layout", "menu", c.obj.Path(), "error", err)
+			return
+		}
+		c.subscribers.Publish(Event{ID: parent, LayoutUpdated: true})
+	case menuInterface + ".ItemsPropertiesUpdated":
+		c.updateProperties(sig.Body)
+	case menuInterface + ".ItemActivationRequested":
+		if len(sig.Body) < 1 {
+			return
+		}
+		id, _ := sig.Body[0].(int32)
+		c.subscribers.Publish(Event{ID: id, ActivationRequested: true})
+	}
+}
+
+// updateProperties applies an ItemsPropertiesUpdated signal.
+func (c *Client) updateProperties(body []any) {
+	if len(body) < 2 {
+		return
+	}
+	updated, _ := body[0].([][]any)
+	removed, _ := body[1].([][]any)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	changed := make(map[int32]bool)
+	for _, u := range updated {
+		if len(u) < 2 {
+			continue
+		}
+		id, _ := u[0].(int32)
+		props, _ := u[1].(map[string]dbus.Variant)
+		if it, found := c.items[id]; found {
+			for name, value := range props {
+				it.Properties[name] = value
+			}
+			changed[id] = true
+		}
+	}
+	for _, r := range removed {
+		if len(r) < 2 {
+			continue
+		}
+		id, _ := r[0].(int32)
+		names, _ := r[1].([]string)
+		if it, found := c.items[id]; found {
+			for _, name := range names {
+				delete(it.Properties, name)
+			}
+			changed[id] = true
+		}
+	}
+	for id := range changed {
+		c.items[id].applyProperties()
+		c.subscribers.Publish(Event{ID: id, PropertiesUpdated: true})
+	}
+}
+
+// SendEvent sends an event, such as EventClicked, to an item.
+func (c *Client) SendEvent(id int32, eventID string, data dbus.Variant) error {
+	return c.obj.Call(menuInterface+".Event", 0, id, eventID, data, uint32(time.Now().Unix())).Err
+}
+
+// Click activates an item.
+func (c *Client) Click(id int32) error {
+	return c.SendEvent(id, EventClicked, dbus.MakeVariant(int32(0)))
+}
+
+// Opened tells the application a submenu was shown.
+func (c *Client) Opened(id int32) error {
+	return c.SendEvent(id, EventOpened, dbus.MakeVariant(""))
+}
+
+// Closed tells the application a submenu was hidden.
+func (c *Client) Closed(id int32) error {
+	return c.SendEvent(id, EventClosed, dbus.MakeVariant(""))
+}
+
+// AboutToShow tells the application a submenu is about to be shown, letting it
+// fill the submenu in; the submenu is read again if the application updated it.
+func (c *Client) AboutToShow(id int32) error {
+	var needUpdate bool
+	if err := c.obj.Call(menuInterface+".AboutToShow", 0, id).Store(&needUpdate); err != nil {
+		return err
+	}
+	if needUpdate {
+		return c.refresh(id)
+	}
+	return nil
+}
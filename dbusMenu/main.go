@@ -0,0 +1,127 @@
+/*
+	libxdg-go - An implementaion of various freedesktop specifications in go
+    Copyright (C) 2025 MiracleOS Contributors
+
+    This program is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    This program is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+*/
+
+// Package dbusMenu implements com.canonical.dbusmenu, the protocol tray items and
+// applications use to export menus over D-Bus for the shell to show.
+package dbusMenu
+
+import (
+	"maps"
+
+	"github.com/godbus/dbus/v5"
+)
+
+const (
+	menuInterface = "com.canonical.dbusmenu"
+	// protocolVersion is the version of the protocol implemented.
+	protocolVersion = uint32(3)
+)
+
+// Item types.
+const (
+	TypeStandard  = "standard"
+	TypeSeparator = "separator"
+)
+
+// Toggle types.
+const (
+	ToggleNone      = ""
+	ToggleCheckmark = "checkmark"
+	ToggleRadio     = "radio"
+)
+
+// Toggle states.
+const (
+	ToggleOff           = int32(0)
+	ToggleOn            = int32(1)
+	ToggleIndeterminate = int32(-1)
+)
+
+// Event IDs sent to items.
+const (
+	EventClicked = "clicked"
+	EventHovered = "hovered"
+	EventOpened  = "opened"
+	EventClosed  = "closed"
+)
+
+// Item is a menu item. The root item, with ID 0, holds the top level items.
+type Item struct {
+	ID       int32
+	Type     string
+	Label    string
+	Enabled  bool
+	Visible  bool
+	IconName string
+	// IconData is a PNG image, used when IconName is empty or not found.
+	IconData []byte
+	// Shortcuts are key combinations such as {"Control", "q"}.
+	Shortcuts   [][]string
+	ToggleType  string
+	ToggleState int32
+	// ChildrenDisplay is "submenu" when the item has a submenu.
+	ChildrenDisplay string
+	// Disposition is "normal", "informative", "warning" or "alert".
+	Disposition string
+	// Properties holds every property the item has, including those above.
+	Properties map[string]dbus.Variant
+	Children   []*Item
+}
+
+// HasSubmenu reports whether the item opens a submenu.
+func (it *Item) HasSubmenu() bool {
+	return it.ChildrenDisplay == "submenu" || len(it.Children) > 0
+}
+
+// clone returns a deep copy of an item tree.
+func (it *Item) clone() *Item {
+	c := *it
+	c.Properties = maps.Clone(it.Properties)
+	c.Children = make([]*Item, len(it.Children))
+	for i, child := range it.Children {
+		c.Children[i] = child.clone()
+	}
+	return &c
+}
+
+// applyProperties sets the fields of an item from its properties, with the
+// protocol defaults for missing ones.
+func (it *Item) applyProperties() {
+	it.Type, it.Label, it.IconName, it.ToggleType, it.ChildrenDisplay = TypeStandard, "", "", ToggleNone, ""
+	it.Disposition = "normal"
+	it.Enabled, it.Visible = true, true
+	it.IconData, it.Shortcuts, it.ToggleState = nil, nil, ToggleIndeterminate
+
+	get := func(name string, value any) {
+		if v, found := it.Properties[name]; found {
+			v.Store(value)
+		}
+	}
+	get("type", &it.Type)
+	get("label", &it.Label)
+	get("enabled", &it.Enabled)
+	get("visible", &it.Visible)
+	get("icon-name", &it.IconName)
+	get("icon-data", &it.IconData)
+	get("shortcut", &it.Shortcuts)
+	get("toggle-type", &it.ToggleType)
+	get("toggle-state", &it.ToggleState)
+	get("children-display", &it.ChildrenDisplay)
+	get("disposition", &it.Disposition)
+}
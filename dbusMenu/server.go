@@ -0,0 +1,487 @@
+/*
+	libxdg-go - An implementaion of various freedesktop specifications in go
+    Copyright (C) 2025 MiracleOS Contributors
+
+    This program is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    This program is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+*/
+
+package dbusMenu
+
+import (
+	"errors"
+	"maps"
+	"reflect"
+	"sync"
+
+	"github.com/godbus/dbus/v5"
+	"github.com/godbus/dbus/v5/introspect"
+	"github.com/godbus/dbus/v5/prop"
+)
+
+// ErrUnknownItem is returned when updating an item the menu doesn't have.
+var ErrUnknownItem = errors.New("unknown menu item")
+
+// MenuItem declares an item of an exported menu.
+type MenuItem struct {
+	// Name identifies the item for Server.Update. It may be empty for items that
+	// never change on their own.
+	Name string
+	// Type is TypeStandard if empty, or TypeSeparator.
+	Type     string
+	Label    string
+	Disabled bool
+	Hidden   bool
+	IconName string
+	IconData []byte
+	// Shortcuts are key combinations such as {"Control", "q"}.
+	Shortcuts   [][]string
+	ToggleType  string
+	Checked     bool
+	Disposition string
+	// OnClick is called when the user activates the item, on a D-Bus goroutine.
+	OnClick  func()
+	Children []MenuItem
+}
+
+// properties returns the dbusmenu properties of an item that differ from the
+// defaults.
+func (m MenuItem) properties() map[string]dbus.Variant {
+	props := make(map[string]dbus.Variant)
+	if m.Type != "" && m.Type != TypeStandard {
+		props["type"] = dbus.MakeVariant(m.Type)
+	}
+	if m.Label != "" {
+		props["label"] = dbus.MakeVariant(m.Label)
+	}
+	if m.Disabled {
+		props["enabled"] = dbus.MakeVariant(false)
+	}
+	if m.Hidden {
+		props["visible"] = dbus.MakeVariant(false)
+	}
+	if m.IconName != "" {
+		props["icon-name"] = dbus.MakeVariant(m.IconName)
+	}
+	if len(m.IconData) > 0 {
+		props["icon-data"] = dbus.MakeVariant(m.IconData)
+	}
+	if len(m.Shortcuts) > 0 {
+		props["shortcut"] = dbus.MakeVariant(m.Shortcuts)
+	}
+	if m.ToggleType != ToggleNone {
+		props["toggle-type"] = dbus.MakeVariant(m.ToggleType)
+		state := ToggleOff
+		if m.Checked {
+			state = ToggleOn
+		}
+		props["toggle-state"] = dbus.MakeVariant(state)
+	}
+	if len(m.Children) > 0 {
+		props["children-display"] = dbus.MakeVariant("submenu")
+	}
+	if m.Disposition != "" && m.Disposition != "normal" {
+		props["disposition"] = dbus.MakeVariant(m.Disposition)
+	}
+	return props
+}
+
+// node is an item of an exported menu.
+type node struct {
+	id       int32
+	item     MenuItem
+	props    map[string]dbus.Variant
+	children []*node
+}
+
+// layout is the (ia{sv}av) layout of an item.
+type layout struct {
+	ID         int32
+	Properties map[string]dbus.Variant
+	Children   []dbus.Variant
+}
+
+// Server exports a menu declared as MenuItems.
+type Server struct {
+	conn     *dbus.Conn
+	path     dbus.ObjectPath
+	mu       sync.Mutex
+	revision uint32
+	root     *node
+	nodes    map[int32]*node
+	names    map[string]int32
+	nextID   int32
+}
+
+// serverObject holds the exported methods of a Server.
+type serverObject struct {
+	s *Server
+}
+
+// Export exports a menu at path on conn, for instance the Menu of a published
+// tray item.
+func Export(conn *dbus.Conn, path dbus.ObjectPath, items []MenuItem) (*Server, error) {
+	s := &Server{
+		conn:   conn,
+		path:   path,
+		names:  make(map[string]int32),
+		nextID: 1,
+	}
+	s.build(items)
+
+	if err := conn.Export(serverObject{s}, path, menuInterface); err != nil {
+		return nil, err
+	}
+	props, err := prop.Export(conn, path, prop.Map{
+		menuInterface: {
+			"Version":       {Value: protocolVersion, Emit: prop.EmitFalse},
+			"TextDirection": {Value: "ltr", Emit: prop.EmitFalse},
+			"Status":        {Value: "normal", Emit: prop.EmitFalse},
+			"IconThemePath": {Value: []string{}, Emit: prop.EmitFalse},
+		},
+	})
+	if err != nil {
+		s.Close()
+		return nil, err
+	}
+	if err := conn.Export(introspect.NewIntrospectable(s.introspection(props)), path, "org.freedesktop.DBus.Introspectable"); err != nil {
+		s.Close()
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *Server) introspection(props *prop.Properties) *introspect.Node {
+	return &introspect.Node{
+		Name: string(s.path),
+		Interfaces: []introspect.Interface{
+			{
+				Name: menuInterface,
+				Methods: []introspect.Method{
+					{
+						Name: "GetLayout",
+						Args: []introspect.Arg{
+							{Name: "parentId", Type: "i", Direction: "in"},
+							{Name: "recursionDepth", Type: "i", Direction: "in"},
+							{Name: "propertyNames", Type: "as", Direction: "in"},
+							{Name: "revision", Type: "u", Direction: "out"},
+							{Name: "layout", Type: "(ia{sv}av)", Direction: "out"},
+						},
+					},
+					{
+						Name: "GetGroupProperties",
+						Args: []introspect.Arg{
+							{Name: "ids", Type: "ai", Direction: "in"},
+							{Name: "propertyNames", Type: "as", Direction: "in"},
+							{Name: "properties", Type: "a(ia{sv})", Direction: "out"},
+						},
+					},
+					{
+						Name: "GetProperty",
+						Args: []introspect.Arg{
+							{Name: "id", Type: "i", Direction: "in"},
+							{Name: "name", Type: "s", Direction: "in"},
+							{Name: "value", Type: "v", Direction: "out"},
+						},
+					},
+					{
+						Name: "Event",
+						Args: []introspect.Arg{
+							{Name: "id", Type: "i", Direction: "in"},
+							{Name: "eventId", Type: "s", Direction: "in"},
+							{Name: "data", Type: "v", Direction: "in"},
+							{Name: "timestamp", Type: "u", Direction: "in"},
+						},
+					},
+					{
+						Name: "EventGroup",
+						Args: []introspect.Arg{
+							{Name: "events", Type: "a(isvu)", Direction: "in"},
+							{Name: "idErrors", Type: "ai", Direction: "out"},
+						},
+					},
+					{
+						Name: "AboutToShow",
+						Args: []introspect.Arg{
+							{Name: "id", Type: "i", Direction: "in"},
+							{Name: "needUpdate", Type: "b", Direction: "out"},
+						},
+					},
+					{
+						Name: "AboutToShowGroup",
+						Args: []introspect.Arg{
+							{Name: "ids", Type: "ai", Direction: "in"},
+							{Name: "updatesNeeded", Type: "ai", Direction: "out"},
+							{Name: "idErrors", Type: "ai", Direction: "out"},
+						},
+					},
+				},
+				Signals: []introspect.Signal{
+					{
+						Name: "ItemsPropertiesUpdated",
+						Args: []introspect.Arg{
+							{Name: "updatedProps", Type: "a(ia{sv})"},
+							{Name: "removedProps", Type: "a(ias)"},
+						},
+					},
+					{
+						Name: "LayoutUpdated",
+						Args: []introspect.Arg{
+							{Name: "revision", Type: "u"},
+							{Name: "parent", Type: "i"},
+						},
+					},
+					{
+						Name: "ItemActivationRequested",
+						Args: []introspect.Arg{
+							{Name: "id", Type: "i"},
+							{Name: "timestamp", Type: "u"},
+						},
+					},
+				},
+				Properties: props.Introspection(menuInterface),
+			},
+			prop.IntrospectData,
+			introspect.IntrospectData,
+		},
+	}
+}
+
+// Close stops exporting the menu.
+func (s *Server) Close() {
+	s.conn.Export(nil, s.path, menuInterface)
+	s.conn.Export(nil, s.path, "org.freedesktop.DBus.Properties")
+	s.conn.Export(nil, s.path, "org.freedesktop.DBus.Introspectable")
+}
+
+// build replaces the menu tree. Named items keep their IDs.
+func (s *Server) build(items []MenuItem) {
+	s.revision++
+	s.nodes = make(map[int32]*node)
+	s.root = &node{id: 0, props: map[string]dbus.Variant{"children-display": dbus.MakeVariant("submenu")}}
+	s.nodes[0] = s.root
+	s.root.children = s.buildChildren(items)
+}
+
+func (s *Server) buildChildren(items []MenuItem) []*node {
+	nodes := make([]*node, 0, len(items))
+	for _, item := range items {
+		n := &node{item: item, props: item.properties()}
+		if id, found := s.names[item.Name]; found && item.Name != "" {
+			n.id = id
+		} else {
+			n.id = s.nextID
+			s.nextID++
+			if item.Name != "" {
+				s.names[item.Name] = n.id
+			}
+		}
+		s.nodes[n.id] = n
+		n.children = s.buildChildren(item.Children)
+		nodes = append(nodes, n)
+	}
+	return nodes
+}
+
+// SetItems replaces the whole menu.
+func (s *Server) SetItems(items []MenuItem) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.build(items)
+	s.conn.Emit(s.path, menuInterface+".LayoutUpdated", s.revision, int32(0))
+}
+
+// Update changes a named item. Property changes are sent as such; changes to
+// the children of the item update the layout.
+func (s *Server) Update(name string, update func(*MenuItem)) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	id, found := s.names[name]
+	n := s.nodes[id]
+	if !found || n == nil {
+		return ErrUnknownItem
+	}
+	item := n.item
+	update(&item)
+	if item.Name != name {
+		return errors.New("menu item names can't change")
+	}
+
+	if !reflect.DeepEqual(item.Children, n.item.Children) {
+		n.item = item
+		n.props = item.properties()
+		n.children = s.buildChildren(item.Children)
+		s.revision++
+		s.conn.Emit(s.path, menuInterface+".LayoutUpdated", s.revision, id)
+		return nil
+	}
+
+	props := item.properties()
+	updated := make(map[string]dbus.Variant)
+	var removed []string
+	for key, value := range props {
+		if old, found := n.props[key]; !found || !reflect.DeepEqual(old.Value(), value.Value()) {
+			updated[key] = value
+		}
+	}
+	for key := range n.props {
+		if _, found := props[key]; !found {
+			removed = append(removed, key)
+		}
+	}
+	n.item = item
+	n.props = props
+	if len(updated) == 0 && len(removed) == 0 {
+		return nil
+	}
+	s.conn.Emit(s.path, menuInterface+".ItemsPropertiesUpdated",
+		[]itemProperties{{id, updated}}, []itemPropertyNames{{id, removed}})
+	return nil
+}
+
+// RequestActivation asks the host to show the menu of a named item, e.g. after
+// a keyboard shortcut.
+func (s *Server) RequestActivation(name string, timestamp uint32) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	id, found := s.names[name]
+	if !found {
+		return ErrUnknownItem
+	}
+	return s.conn.Emit(s.path, menuInterface+".ItemActivationRequested", id, timestamp)
+}
+
+// itemProperties is the (ia{sv}) properties of an item.
+type itemProperties struct {
+	ID         int32
+	Properties map[string]dbus.Variant
+}
+
+// itemPropertyNames is the (ias) removed property names of an item.
+type itemPropertyNames struct {
+	ID    int32
+	Names []string
+}
+
+// filterProperties keeps the requested properties, or all if none are.
+func filterProperties(props map[string]dbus.Variant, names []string) map[string]dbus.Variant {
+	if len(names) == 0 {
+		return maps.Clone(props)
+	}
+	filtered := make(map[string]dbus.Variant)
+	for _, name := range names {
+		if v, found := props[name]; found {
+			filtered[name] = v
+		}
+	}
+	return filtered
+}
+
+// layoutOf returns the layout of a node down to depth levels, or all of them if
+// depth is negative.
+func layoutOf(n *node, depth int32, names []string) layout {
+	l := layout{ID: n.id, Properties: filterProperties(n.props, names), Children: []dbus.Variant{}}
+	if depth == 0 {
+		return l
+	}
+	for _, child := range n.children {
+		l.Children = append(l.Children, dbus.MakeVariant(layoutOf(child, depth-1, names)))
+	}
+	return l
+}
+
+func (o serverObject) GetLayout(parentID int32, recursionDepth int32, propertyNames []string) (uint32, layout, *dbus.Error) {
+	s := o.s
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	n, found := s.nodes[parentID]
+	if !found {
+		return 0, layout{}, dbus.MakeFailedError(ErrUnknownItem)
+	}
+	return s.revision, layoutOf(n, recursionDepth, propertyNames), nil
+}
+
+func (o serverObject) GetGroupProperties(ids []int32, propertyNames []string) ([]itemProperties, *dbus.Error) {
+	s := o.s
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	result := []itemProperties{}
+	for _, id := range ids {
+		if n, found := s.nodes[id]; found {
+			result = append(result, itemProperties{id, filterProperties(n.props, propertyNames)})
+		}
+	}
+	return result, nil
+}
+
+func (o serverObject) GetProperty(id int32, name string) (dbus.Variant, *dbus.Error) {
+	s := o.s
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	n, found := s.nodes[id]
+	if !found {
+		return dbus.Variant{}, dbus.MakeFailedError(ErrUnknownItem)
+	}
+	v, found := n.props[name]
+	if !found {
+		return dbus.Variant{}, dbus.MakeFailedError(errors.New("unknown menu item property: " + name))
+	}
+	return v, nil
+}
+
+func (o serverObject) Event(id int32, eventID string, data dbus.Variant, timestamp uint32) *dbus.Error {
+	if !o.s.event(id, eventID) {
+		return dbus.MakeFailedError(ErrUnknownItem)
+	}
+	return nil
+}
+
+func (o serverObject) EventGroup(events []struct {
+	ID        int32
+	EventID   string
+	Data      dbus.Variant
+	Timestamp uint32
+}) ([]int32, *dbus.Error) {
+	idErrors := []int32{}
+	for _, e := range events {
+		if !o.s.event(e.ID, e.EventID) {
+			idErrors = append(idErrors, e.ID)
+		}
+	}
+	return idErrors, nil
+}
+
+// event handles an event sent to an item, reporting whether the item exists.
+func (s *Server) event(id int32, eventID string) bool {
+	s.mu.Lock()
+	n, found := s.nodes[id]
+	var onClick func()
+	if found && eventID == EventClicked && !n.item.Disabled {
+		onClick = n.item.OnClick
+	}
+	s.mu.Unlock()
+	if onClick != nil {
+		onClick()
+	}
+	return found
+}
+
+func (o serverObject) AboutToShow(id int32) (bool, *dbus.Error) {
+	return false, nil
+}
+
+func (o serverObject) AboutToShowGroup(ids []int32) ([]int32, []int32, *dbus.Error) {
+	return []int32{}, []int32{}, nil
+}
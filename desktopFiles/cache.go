@@ -0,0 +1,642 @@
+/*
+	libxdg-go - An implementaion of various freedesktop specifications in go
+    Copyright (C) 2025 MiracleOS Contributors
+
+    This program is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    This program is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+*/
+
+package desktopFiles
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"syscall"
+
+	basedir "github.com/MiracleOS-Team/libxdg-go/baseDir"
+	"github.com/MiracleOS-Team/libxdg-go/internal/atomicfile"
+)
+
+// The cache is a single file meant to be mapped in memory. All integers are
+// little-endian uint32 but directory mtimes, which are int64. Strings are a
+// length followed by bytes, lists a count followed by string offsets or entry
+// indices, and offset 0 stands for an empty string or list. After the header:
+//
+//	magic    [8]byte
+//	locale   string offset
+//	dirs     offset, count of {path, padding, mtime int64}
+//	entries  offset, count of {fields..., lists..., flags}, sorted by ID
+//	mime     offset, count of {MIME type, entry list}, sorted by type
+//	keywords offset, count of {keyword, entry list}, sorted by keyword
+const (
+	cacheMagic      = "LXDGDEC1"
+	cacheHeaderSize = 44
+	cacheDirSize    = 16
+	cacheEntrySize  = 4 * (cacheStrings + cacheLists + 1)
+	cacheIndexSize  = 8
+	// missingDir is the mtime recorded for a directory that doesn't exist.
+	missingDir = -1
+)
+
+// String fields of cache entries.
+const (
+	fieldID = iota
+	fieldPath
+	fieldType
+	fieldVersion
+	fieldName
+	fieldGenericName
+	fieldComment
+	fieldIcon
+	fieldTryExec
+	fieldExec
+	fieldWorkDir
+	fieldStartupWMClass
+	fieldURL
+	cacheStrings
+)
+
+// List fields of cache entries.
+const (
+	listOnlyShowIn = iota
+	listNotShowIn
+	listImplements
+	listActions
+	listMimeType
+	listCategories
+	listKeywords
+	cacheLists
+)
+
+// Flags of cache entries.
+const (
+	flagNoDisplay = 1 << iota
+	flagDBusActivatable
+	flagTerminal
+	flagStartupNotify
+	flagPrefersNonDefaultGPU
+	flagSingleMainWindow
+)
+
+// ErrCacheInvalid is returned when opening a file that isn't a valid cache.
+var ErrCacheInvalid = errors.New("invalid desktop entry cache")
+
+// CachePath returns the path of the user's desktop entry cache.
+func CachePath() string {
+	return filepath.Join(basedir.GetXDGDirectory("cache").(string), "libxdg-desktop-entries.cache")
+}
+
+// Cache is a compiled cache of the desktop entries of the application
+// directories, keyed by desktop file ID as by ListApplicationsByID, with indexes
+// of their MIME types and keywords. Values are localized for the locale the
+// cache was built in.
+type Cache struct {
+	data   []byte
+	mapped bool
+}
+
+// ListApplicationsCached returns the same applications as ListApplicationsByID,
+// read from the cache, which is rebuilt first if needed.
+func ListApplicationsCached() (map[string]DesktopFile, error) {
+	c, err := LoadCache()
+	if err != nil {
+		return nil, err
+	}
+	defer c.Close()
+	return c.Applications(), nil
+}
+
+// LoadCache opens the user's cache, rebuilding it if it is missing, invalid or
+// stale. If it can't be written, a cache is built in memory.
+func LoadCache() (*Cache, error) {
+	path := CachePath()
+	c, err := OpenCache(path)
+	if err == nil && !c.Stale() {
+		return c, nil
+	}
+	if c != nil {
+		c.Close()
+	}
+
+	data, err := buildCache()
+	if err != nil {
+		return nil, err
+	}
+	if err := atomicfile.WriteFile(path, data, 0644); err != nil {
+		slog.Debug("Failed to write desktop entry cache", "path", path, "error", err)
+	}
+	return &Cache{data: data}, nil
+}
+
+// BuildCache scans the application directories and writes a cache to path.
+func BuildCache(path string) error {
+	data, err := buildCache()
+	if err != nil {
+		return err
+	}
+	return atomicfile.WriteFile(path, data, 0644)
+}
+
+// OpenCache maps the cache at path in memory. It doesn't check whether the
+// cache is stale.
+func OpenCache(path string) (*Cache, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return nil, err
+	}
+	if info.Size() < cacheHeaderSize || info.Size() > 1<<31 {
+		return nil, ErrCacheInvalid
+	}
+	data, err := syscall.Mmap(int(file.Fd()), 0, int(info.Size()), syscall.PROT_READ, syscall.MAP_SHARED)
+	if err != nil {
+		return nil, err
+	}
+	c := &Cache{data: data, mapped: true}
+	if !c.valid() {
+		c.Close()
+		return nil, ErrCacheInvalid
+	}
+	return c, nil
+}
+
+// Close unmaps the cache. Values read from it stay valid.
+func (c *Cache) Close() error {
+	if !c.mapped {
+		return nil
+	}
+	c.mapped = false
+	data := c.data
+	c.data = nil
+	return syscall.Munmap(data)
+}
+
+// valid checks the header and that the tables fit in the file.
+func (c *Cache) valid() bool {
+	if len(c.data) < cacheHeaderSize || string(c.data[:8]) != cacheMagic {
+		return false
+	}
+	for _, table := range []struct {
+		at   uint32
+		size uint64
+	}{{12, cacheDirSize}, {20, cacheEntrySize}, {28, cacheIndexSize}, {36, cacheIndexSize}} {
+		if uint64(c.u32(table.at))+uint64(c.u32(table.at+4))*table.size > uint64(len(c.data)) {
+			return false
+		}
+	}
+	return true
+}
+
+// u32 reads an integer, 0 if out of bounds.
+func (c *Cache) u32(at uint32) uint32 {
+	if uint64(at)+4 > uint64(len(c.data)) {
+		return 0
+	}
+	return binary.LittleEndian.Uint32(c.data[at:])
+}
+
+// bytesAt returns the bytes of a string, nil if out of bounds.
+func (c *Cache) bytesAt(at uint32) []byte {
+	if at == 0 {
+		return nil
+	}
+	n := uint64(c.u32(at))
+	if uint64(at)+4+n > uint64(len(c.data)) {
+		return nil
+	}
+	return c.data[at+4 : uint64(at)+4+n]
+}
+
+// str reads a string, copied out of the mapping.
+func (c *Cache) str(at uint32) string {
+	return string(c.bytesAt(at))
+}
+
+// list reads the items of a list, string offsets or entry indices.
+func (c *Cache) list(at uint32) []uint32 {
+	if at == 0 {
+		return nil
+	}
+	n := uint64(c.u32(at))
+	if uint64(at)+4+4*n > uint64(len(c.data)) {
+		return nil
+	}
+	items := make([]uint32, n)
+	for i := range items {
+		items[i] = c.u32(at + 4 + 4*uint32(i))
+	}
+	return items
+}
+
+// strings reads a list of strings.
+func (c *Cache) strings(at uint32) []string {
+	refs := c.list(at)
+	if refs == nil {
+		return nil
+	}
+	items := make([]string, len(refs))
+	for i, ref := range refs {
+		items[i] = c.str(ref)
+	}
+	return items
+}
+
+// Locale returns the locale the values of the cache are localized for.
+func (c *Cache) Locale() string {
+	return c.str(c.u32(8))
+}
+
+// Stale reports whether the cache is out of date: the locale changed, or an
+// application directory was added, removed or changed. Desktop files edited in
+// place, without replacing them, don't change their directory.
+func (c *Cache) Stale() bool {
+	if c.Locale() != getCurrentLocale() {
+		return true
+	}
+	recorded := make(map[string]bool)
+	at, count := c.u32(12), c.u32(16)
+	for i := uint32(0); i < count; i++ {
+		record := at + i*cacheDirSize
+		path := c.str(c.u32(record))
+		recorded[path] = true
+		if dirMtime(path) != int64(binary.LittleEndian.Uint64(c.data[record+8:])) {
+			return true
+		}
+	}
+	for _, dir := range applicationDirs() {
+		if !recorded[dir] {
+			return true
+		}
+	}
+	return false
+}
+
+// dirMtime returns the modification time of a directory, or missingDir.
+func dirMtime(path string) int64 {
+	info, err := os.Stat(path)
+	if err != nil {
+		return missingDir
+	}
+	return info.ModTime().UnixNano()
+}
+
+// Len returns the number of entries.
+func (c *Cache) Len() int {
+	return int(c.u32(24))
+}
+
+// field returns the offset of a field of an entry.
+func (c *Cache) field(i int, field int) uint32 {
+	return c.u32(c.u32(20) + uint32(i)*cacheEntrySize + 4*uint32(field))
+}
+
+// entry decodes an entry.
+func (c *Cache) entry(i int) (DesktopFile, string) {
+	list := func(field int) []string {
+		return c.strings(c.field(i, cacheStrings+field))
+	}
+	str := func(field int) string {
+		return c.str(c.field(i, field))
+	}
+	flags := c.field(i, cacheStrings+cacheLists)
+
+	dfile := DesktopFile{
+		Type:            str(fieldType),
+		Version:         str(fieldVersion),
+		Name:            str(fieldName),
+		GenericName:     str(fieldGenericName),
+		Comment:         str(fieldComment),
+		Icon:            str(fieldIcon),
+		NoDisplay:       flags&flagNoDisplay != 0,
+		OnlyShowIn:      list(listOnlyShowIn),
+		NotShowIn:       list(listNotShowIn),
+		DBusActivatable: flags&flagDBusActivatable != 0,
+		Implements:      list(listImplements),
+		ApplicationObject: Application{
+			TryExec:              str(fieldTryExec),
+			Exec:                 str(fieldExec),
+			Path:                 str(fieldWorkDir),
+			Terminal:             flags&flagTerminal != 0,
+			Actions:              list(listActions),
+			MimeType:             list(listMimeType),
+			Categories:           list(listCategories),
+			Keywords:             list(listKeywords),
+			StartupNotify:        flags&flagStartupNotify != 0,
+			StartupWMClass:       str(fieldStartupWMClass),
+			PrefersNonDefaultGPU: flags&flagPrefersNonDefaultGPU != 0,
+			SingleMainWindow:     flags&flagSingleMainWindow != 0,
+		},
+		LinkObject: Link{URL: str(fieldURL)},
+	}
+	return dfile, str(fieldPath)
+}
+
+// IDs returns the IDs of the entries, sorted.
+func (c *Cache) IDs() []string {
+	ids := make([]string, c.Len())
+	for i := range ids {
+		ids[i] = c.str(c.field(i, fieldID))
+	}
+	return ids
+}
+
+// Lookup returns the entry of an ID and the path of its desktop file.
+func (c *Cache) Lookup(id string) (DesktopFile, string, bool) {
+	id = strings.TrimSuffix(id, ".desktop")
+	n := c.Len()
+	i := sort.Search(n, func(i int) bool {
+		return string(c.bytesAt(c.field(i, fieldID))) >= id
+	})
+	if i == n || string(c.bytesAt(c.field(i, fieldID))) != id {
+		return DesktopFile{}, "", false
+	}
+	dfile, path := c.entry(i)
+	return dfile, path, true
+}
+
+// Applications returns the entries of type Application, as ListApplicationsByID.
+func (c *Cache) Applications() map[string]DesktopFile {
+	apps := make(map[string]DesktopFile)
+	for i := 0; i < c.Len(); i++ {
+		if string(c.bytesAt(c.field(i, fieldType))) != "Application" {
+			continue
+		}
+		apps[c.str(c.field(i, fieldID))], _ = c.entry(i)
+	}
+	return apps
+}
+
+// ForMIMEType returns the IDs of the entries listing a MIME type, sorted.
+// Aliases and parent types aren't considered.
+func (c *Cache) ForMIMEType(mimeType string) []string {
+	at, count := c.u32(28), int(c.u32(32))
+	key := func(i int) string {
+		return string(c.bytesAt(c.u32(at + uint32(i)*cacheIndexSize)))
+	}
+	i := sort.Search(count, func(i int) bool { return key(i) >= mimeType })
+	if i == count || key(i) != mimeType {
+		return nil
+	}
+	return c.ids(c.list(c.u32(at + uint32(i)*cacheIndexSize + 4)))
+}
+
+// Search returns the IDs of the entries with a keyword starting with term,
+// case-insensitively, sorted. The keywords of an entry are its Keywords and the
+// words of its Name and GenericName.
+func (c *Cache) Search(term string) []string {
+	term = strings.ToLower(strings.TrimSpace(term))
+	if term == "" {
+		return nil
+	}
+	at, count := c.u32(36), int(c.u32(40))
+	key := func(i int) string {
+		return string(c.bytesAt(c.u32(at + uint32(i)*cacheIndexSize)))
+	}
+	seen := make(map[uint32]bool)
+	var indices []uint32
+	for i := sort.Search(count, func(i int) bool { return key(i) >= term }); i < count && strings.HasPrefix(key(i), term); i++ {
+		for _, index := range c.list(c.u32(at + uint32(i)*cacheIndexSize + 4)) {
+			if !seen[index] {
+				seen[index] = true
+				indices = append(indices, index)
+			}
+		}
+	}
+	sort.Slice(indices, func(i, j int) bool { return indices[i] < indices[j] })
+	return c.ids(indices)
+}
+
+// ids returns the IDs of entries by index.
+func (c *Cache) ids(indices []uint32) []string {
+	var ids []string
+	for _, index := range indices {
+		if int(index) < c.Len() {
+			ids = append(ids, c.str(c.field(int(index), fieldID)))
+		}
+	}
+	return ids
+}
+
+// cacheWriter lays out a cache.
+type cacheWriter struct {
+	buf     bytes.Buffer
+	strings map[string]uint32
+}
+
+func (w *cacheWriter) u32(v uint32) {
+	w.buf.Write(binary.LittleEndian.AppendUint32(nil, v))
+}
+
+// align pads the buffer to a multiple of n bytes.
+func (w *cacheWriter) align(n int) {
+	for w.buf.Len()%n != 0 {
+		w.buf.WriteByte(0)
+	}
+}
+
+// str writes a string, once, and returns its offset.
+func (w *cacheWriter) str(s string) uint32 {
+	if s == "" {
+		return 0
+	}
+	if at, exists := w.strings[s]; exists {
+		return at
+	}
+	at := uint32(w.buf.Len())
+	w.u32(uint32(len(s)))
+	w.buf.WriteString(s)
+	w.strings[s] = at
+	return at
+}
+
+// list writes a list of integers and returns its offset.
+func (w *cacheWriter) list(items []uint32) uint32 {
+	if len(items) == 0 {
+		return 0
+	}
+	w.align(4)
+	at := uint32(w.buf.Len())
+	w.u32(uint32(len(items)))
+	for _, item := range items {
+		w.u32(item)
+	}
+	return at
+}
+
+// strList writes a list of strings and returns its offset.
+func (w *cacheWriter) strList(items []string) uint32 {
+	refs := make([]uint32, len(items))
+	for i, item := range items {
+		refs[i] = w.str(item)
+	}
+	return w.list(refs)
+}
+
+// buildCache scans the application directories and lays out a cache of them.
+func buildCache() ([]byte, error) {
+	dirs := make(map[string]int64)
+	for _, dir := range applicationDirs() {
+		dirs[dir] = dirMtime(dir)
+	}
+	entries, err := scanApplicationDirs(func(dir string) {
+		dirs[dir] = dirMtime(dir)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]string, 0, len(entries))
+	for id := range entries {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	dirPaths := make([]string, 0, len(dirs))
+	for dir := range dirs {
+		dirPaths = append(dirPaths, dir)
+	}
+	sort.Strings(dirPaths)
+
+	w := &cacheWriter{strings: make(map[string]uint32)}
+	w.buf.Write(make([]byte, cacheHeaderSize))
+	header := make([]uint32, 0, (cacheHeaderSize-8)/4)
+	header = append(header, w.str(getCurrentLocale()))
+
+	// Variable data first, then the fixed-size tables pointing to it.
+	mimeIndex := make(map[string][]uint32)
+	keywordIndex := make(map[string][]uint32)
+	records := make([][]uint32, len(ids))
+	for i, id := range ids {
+		entry := entries[id]
+		d, app := entry.dfile, entry.dfile.ApplicationObject
+		record := make([]uint32, cacheStrings+cacheLists+1)
+		for field, value := range map[int]string{
+			fieldID: id, fieldPath: entry.path, fieldType: d.Type, fieldVersion: d.Version,
+			fieldName: d.Name, fieldGenericName: d.GenericName, fieldComment: d.Comment,
+			fieldIcon: d.Icon, fieldTryExec: app.TryExec, fieldExec: app.Exec,
+			fieldWorkDir: app.Path, fieldStartupWMClass: app.StartupWMClass, fieldURL: d.LinkObject.URL,
+		} {
+			record[field] = w.str(value)
+		}
+		for field, value := range map[int][]string{
+			listOnlyShowIn: d.OnlyShowIn, listNotShowIn: d.NotShowIn, listImplements: d.Implements,
+			listActions: app.Actions, listMimeType: app.MimeType, listCategories: app.Categories,
+			listKeywords: app.Keywords,
+		} {
+			record[cacheStrings+field] = w.strList(value)
+		}
+		for flag, set := range map[uint32]bool{
+			flagNoDisplay: d.NoDisplay, flagDBusActivatable: d.DBusActivatable, flagTerminal: app.Terminal,
+			flagStartupNotify: app.StartupNotify, flagPrefersNonDefaultGPU: app.PrefersNonDefaultGPU,
+			flagSingleMainWindow: app.SingleMainWindow,
+		} {
+			if set {
+				record[cacheStrings+cacheLists] |= flag
+			}
+		}
+		records[i] = record
+
+		for _, mimeType := range uniqueStrings(app.MimeType) {
+			mimeIndex[mimeType] = append(mimeIndex[mimeType], uint32(i))
+		}
+		for _, keyword := range entryKeywords(d) {
+			keywordIndex[keyword] = append(keywordIndex[keyword], uint32(i))
+		}
+	}
+	indexRecords := func(index map[string][]uint32) [][2]uint32 {
+		keys := make([]string, 0, len(index))
+		for key := range index {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		table := make([][2]uint32, len(keys))
+		for i, key := range keys {
+			table[i] = [2]uint32{w.str(key), w.list(index[key])}
+		}
+		return table
+	}
+	mimeTable := indexRecords(mimeIndex)
+	keywordTable := indexRecords(keywordIndex)
+	dirRefs := make([]uint32, len(dirPaths))
+	for i, dir := range dirPaths {
+		dirRefs[i] = w.str(dir)
+	}
+
+	w.align(8)
+	header = append(header, uint32(w.buf.Len()), uint32(len(dirPaths)))
+	for i, dir := range dirPaths {
+		w.u32(dirRefs[i])
+		w.u32(0)
+		w.buf.Write(binary.LittleEndian.AppendUint64(nil, uint64(dirs[dir])))
+	}
+	header = append(header, uint32(w.buf.Len()), uint32(len(records)))
+	for _, record := range records {
+		for _, v := range record {
+			w.u32(v)
+		}
+	}
+	for _, table := range [][][2]uint32{mimeTable, keywordTable} {
+		header = append(header, uint32(w.buf.Len()), uint32(len(table)))
+		for _, record := range table {
+			w.u32(record[0])
+			w.u32(record[1])
+		}
+	}
+
+	data := w.buf.Bytes()
+	if len(data) > 1<<31 {
+		return nil, errors.New("desktop entry cache too large")
+	}
+	copy(data, cacheMagic)
+	for i, v := range header {
+		binary.LittleEndian.PutUint32(data[8+4*i:], v)
+	}
+	return data, nil
+}
+
+// entryKeywords returns the keywords an entry is indexed under.
+func entryKeywords(d DesktopFile) []string {
+	var keywords []string
+	for _, keyword := range d.ApplicationObject.Keywords {
+		if keyword = strings.ToLower(strings.TrimSpace(keyword)); keyword != "" {
+			keywords = append(keywords, keyword)
+		}
+	}
+	keywords = append(keywords, strings.Fields(strings.ToLower(d.Name))...)
+	keywords = append(keywords, strings.Fields(strings.ToLower(d.GenericName))...)
+	return uniqueStrings(keywords)
+}
+
+// uniqueStrings returns the distinct strings of a list, in order.
+func uniqueStrings(items []string) []string {
+	seen := make(map[string]bool)
+	var unique []string
+	for _, item := range items {
+		if item != "" && !seen[item] {
+			seen[item] = true
+			unique = append(unique, item)
+		}
+	}
+	return unique
+}
@@ -25,10 +25,18 @@ import (
 	"net/http"
 	"os"
 	"os/exec"
+	"path/filepath"
 	"regexp"
 	"strings"
+	"time"
+
+	"github.com/MiracleOS-Team/libxdg-go/startupNotification"
 )
 
+// startupTimeout is how long an X11 startup sequence lasts if the application
+// doesn't complete it by mapping its window.
+const startupTimeout = 15 * time.Second
+
 // downloadURL downloads the content of a URL to a temporary file and returns the file path.
 func downloadURL(url string) (string, error) {
 	resp, err := http.Get(url)
@@ -163,5 +171,75 @@ func ExecuteDesktopFile(dfile DesktopFile, urls []string, loc string) error {
 	}
 	cmd.Dir = dfile.ApplicationObject.Path
 
+	if seq := startupNotify(dfile, pathExecutable); seq != nil {
+		if dfile.ApplicationObject.StartupNotify {
+			cmd.Env = append(os.Environ(), seq.Env()...)
+		}
+		timer := time.AfterFunc(startupTimeout, func() { seq.Complete() })
+		defer func() {
+			timer.Stop()
+			seq.Complete()
+		}()
+	}
+
 	return cmd.Run()
 }
+
+// SplitExec splits an Exec value into arguments, honouring double quotes and
+// backslash escapes as desktop entries do.
+func SplitExec(command string) []string {
+	var args []string
+	var arg strings.Builder
+	inArg, quoted := false, false
+	for i := 0; i < len(command); i++ {
+		c := command[i]
+		switch {
+		case c == '\\' && i+1 < len(command):
+			i++
+			arg.WriteByte(command[i])
+			inArg = true
+		case c == '"':
+			quoted = !quoted
+			inArg = true
+		case (c == ' ' || c == '\t') && !quoted:
+			if inArg {
+				args = append(args, arg.String())
+				arg.Reset()
+				inArg = false
+			}
+		default:
+			arg.WriteByte(c)
+			inArg = true
+		}
+	}
+	if inArg {
+		args = append(args, arg.String())
+	}
+	return args
+}
+
+// startupNotify starts an X11 startup sequence for an application that
+// supports startup notification or whose window can be recognized by its
+// StartupWMClass, so that the window manager shows it as starting and focuses
+// its window. It returns nil otherwise, or when not running on X11.
+func startupNotify(dfile DesktopFile, executable string) *startupNotification.Sequence {
+	app := dfile.ApplicationObject
+	if !app.StartupNotify && app.StartupWMClass == "" {
+		return nil
+	}
+	if os.Getenv("DISPLAY") == "" {
+		return nil
+	}
+	seq, err := startupNotification.Initiate(startupNotification.Info{
+		Name:        dfile.Name,
+		Description: "Launching " + dfile.Name,
+		Bin:         filepath.Base(executable),
+		Icon:        dfile.Icon,
+		WMClass:     app.StartupWMClass,
+	})
+	if err != nil {
+		fmt.Printf("Warning: Failed to start startup notification: %v\n", err)
+		return nil
+	}
+	return seq
+}
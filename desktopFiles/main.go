@@ -24,12 +24,13 @@ import (
 	"log/slog"
 	"os"
 	"path/filepath"
-	"regexp"
 	"strings"
+	"sync"
 
 	basedir "github.com/MiracleOS-Team/libxdg-go/baseDir"
 	"github.com/MiracleOS-Team/libxdg-go/icons"
-	"gopkg.in/ini.v1"
+	"github.com/MiracleOS-Team/libxdg-go/keyfile"
+	"github.com/MiracleOS-Team/libxdg-go/locale"
 )
 
 type DesktopFile struct {
@@ -76,57 +77,15 @@ type Link struct {
 type Directory struct {
 }
 
-// Example of a locale selection function based on LC_MESSAGES
-func getCurrentLocale() string {
-	// Get the current LC_MESSAGES locale (using environment variable or similar approach)
-	// For simplicity, we assume it's set in the environment variable "LC_MESSAGES"
-	locale := os.Getenv("LC_MESSAGES")
-	if locale == "" {
-		locale = "en_US.UTF-8" // Default to English if no LC_MESSAGES is set
-	}
-	return locale
-}
-
-// Normalize the locale string (strip encoding, modifiers)
-func normalizeLocale(locale string) string {
-	// Strip the encoding and modifier parts
-	// Locale format is lang_COUNTRY.ENCODING@MODIFIER
-	re := regexp.MustCompile(`([a-zA-Z]{2,8})(_[a-zA-Z]{2,8})?(\.[a-zA-Z0-9_-]+)?(@[a-zA-Z0-9_-]+)?`)
-	match := re.FindStringSubmatch(locale)
-	if match != nil {
-		// Return normalized locale of form lang_COUNTRY@MODIFIER (stripping encoding)
-		return fmt.Sprintf("%s%s%s", match[1], match[2], match[4])
-	}
-	return locale
-}
+// getCurrentLocale returns the locale entries are translated to. It is looked
+// up once, as the fallback to localed costs a system bus connection.
+var getCurrentLocale = sync.OnceValue(locale.Messages)
 
 // TranslateFieldWithLocale attempts to find the appropriate localized value
-func TranslateFieldWithLocale(key string, locale string, section *ini.Section) string {
-	// Normalize the locale for matching (strip encoding and modifier parts)
-	normalizedLocale := normalizeLocale(locale)
-
-	// Try matching the full locale (lang_COUNTRY@MODIFIER)
-	if val := section.Key(key + "[" + normalizedLocale + "]").String(); val != "" {
+func TranslateFieldWithLocale(key string, locale string, group *keyfile.Group) string {
+	if val := group.LocaleString(key, locale); val != "" {
 		return val
 	}
-
-	// Try matching lang_COUNTRY
-	if parts := strings.Split(normalizedLocale, "@"); len(parts) > 1 {
-		if val := section.Key(key + "[" + parts[0] + "]").String(); val != "" {
-			return val
-		}
-	}
-
-	// Try matching lang (fallback)
-	if val := section.Key(key + "[" + strings.Split(normalizedLocale, "_")[0] + "]").String(); val != "" {
-		return val
-	}
-
-	// Fallback to default (no locale)
-	if val := section.Key(key).String(); val != "" {
-		return val
-	}
-
 	return key // Return the original key if no match
 }
 
@@ -147,80 +106,129 @@ func ReadDesktopFile(filePath string) (DesktopFile, error) {
 	locale := getCurrentLocale()
 
 	// Load the .desktop file
-	cfg, err := ini.Load(filePath)
+	kf, err := keyfile.LoadLenient(filePath)
 	if err != nil {
 		return dfile, fmt.Errorf("failed to load .desktop file: %w", err)
 	}
+	group := kf.Group("Desktop Entry")
+	if group == nil {
+		return dfile, nil
+	}
 
-	// Iterate over sections and print key-value pairs with locale-based translation
-	for _, section := range cfg.SectionStrings() {
-		sectionObj := cfg.Section(section)
-		for _, key := range sectionObj.KeyStrings() {
-			if !strings.HasSuffix(key, "]") {
-				if sectionObj.Name() == "Desktop Entry" {
-					switch key {
-					case "Type":
-						dfile.Type = sectionObj.Key(key).String()
-					case "Version":
-						dfile.Version = sectionObj.Key(key).String()
-					case "Name":
-						dfile.Name = TranslateFieldWithLocale(key, locale, sectionObj)
-					case "GenericName":
-						dfile.GenericName = TranslateFieldWithLocale(key, locale, sectionObj)
-					case "NoDisplay":
-						dfile.NoDisplay, err = sectionObj.Key(key).Bool()
-					case "Comment":
-						dfile.Comment = TranslateFieldWithLocale(key, locale, sectionObj)
-					case "Icon":
-						dfile.Icon, err = ParseIconString(sectionObj.Key(key).String())
-					case "Hidden":
-						dfile.Hidden, err = sectionObj.Key(key).Bool()
-					case "OnlyShowIn":
-						dfile.OnlyShowIn = sectionObj.Key(key).Strings(";")
-					case "NotShowIn":
-						dfile.NotShowIn = sectionObj.Key(key).Strings(";")
-					case "DBusActivatable":
-						dfile.DBusActivatable, err = sectionObj.Key(key).Bool()
-					case "TryExec":
-						dfile.ApplicationObject.TryExec = sectionObj.Key(key).String()
-					case "Exec":
-						dfile.ApplicationObject.Exec = sectionObj.Key(key).String()
-					case "Path":
-						dfile.ApplicationObject.Path = sectionObj.Key(key).String()
-					case "Terminal":
-						dfile.ApplicationObject.Terminal, err = sectionObj.Key(key).Bool()
-					case "Actions":
-						dfile.ApplicationObject.Actions = sectionObj.Key(key).Strings(";")
-					case "MimeType":
-						dfile.ApplicationObject.MimeType = sectionObj.Key(key).Strings(";")
-					case "Implements":
-						dfile.Implements = sectionObj.Key(key).Strings(";")
-					case "Keywords":
-						dfile.ApplicationObject.Keywords = []string{TranslateFieldWithLocale(key, locale, sectionObj)}
-					case "StartupNotify":
-						dfile.ApplicationObject.StartupNotify, err = sectionObj.Key(key).Bool()
-					case "StartupWMClass":
-						dfile.ApplicationObject.StartupWMClass = sectionObj.Key(key).String()
-					case "URL":
-						dfile.LinkObject.URL = sectionObj.Key(key).String()
-					case "PrefersNonDefaultGPU":
-						dfile.ApplicationObject.PrefersNonDefaultGPU, err = sectionObj.Key(key).Bool()
-					case "SingleMainWindow":
-						dfile.ApplicationObject.SingleMainWindow, err = sectionObj.Key(key).Bool()
-
-					}
-					if err != nil {
-						return DesktopFile{}, err
-					}
+	for _, key := range group.Keys() {
+		if strings.HasSuffix(key, "]") {
+			continue
+		}
+		switch key {
+		case "Type":
+			dfile.Type = group.String(key)
+		case "Version":
+			dfile.Version = group.String(key)
+		case "Name":
+			dfile.Name = TranslateFieldWithLocale(key, locale, group)
+		case "GenericName":
+			dfile.GenericName = TranslateFieldWithLocale(key, locale, group)
+		case "NoDisplay":
+			dfile.NoDisplay, err = group.Bool(key)
+		case "Comment":
+			dfile.Comment = TranslateFieldWithLocale(key, locale, group)
+		case "Icon":
+			// An icon missing from the theme doesn't make the entry unusable.
+			value := group.String(key)
+			if dfile.Icon, err = ParseIconString(value); err != nil {
+				slog.Debug("Failed to resolve desktop file icon", "path", filePath, "icon", value, "error", err)
+				dfile.Icon, err = value, nil
+			}
+		case "Hidden":
+			dfile.Hidden, err = group.Bool(key)
+		case "OnlyShowIn":
+			dfile.OnlyShowIn = group.Strings(key)
+		case "NotShowIn":
+			dfile.NotShowIn = group.Strings(key)
+		case "DBusActivatable":
+			dfile.DBusActivatable, err = group.Bool(key)
+		case "TryExec":
+			dfile.ApplicationObject.TryExec = group.String(key)
+		case "Exec":
+			dfile.ApplicationObject.Exec = group.String(key)
+		case "Path":
+			dfile.ApplicationObject.Path = group.String(key)
+		case "Terminal":
+			dfile.ApplicationObject.Terminal, err = group.Bool(key)
+		case "Actions":
+			dfile.ApplicationObject.Actions = group.Strings(key)
+		case "MimeType":
+			dfile.ApplicationObject.MimeType = group.Strings(key)
+		case "Implements":
+			dfile.Implements = group.Strings(key)
+		case "Keywords":
+			dfile.ApplicationObject.Keywords = group.LocaleStrings(key, locale)
+		case "StartupNotify":
+			dfile.ApplicationObject.StartupNotify, err = group.Bool(key)
+		case "StartupWMClass":
+			dfile.ApplicationObject.StartupWMClass = group.String(key)
+		case "URL":
+			dfile.LinkObject.URL = group.String(key)
+		case "PrefersNonDefaultGPU":
+			dfile.ApplicationObject.PrefersNonDefaultGPU, err = group.Bool(key)
+		case "SingleMainWindow":
+			dfile.ApplicationObject.SingleMainWindow, err = group.Bool(key)
+		}
+		if err != nil {
+			return DesktopFile{}, err
+		}
+	}
 
-				}
+	return dfile, nil
+}
 
-			}
+// applicationDirs returns the applications directories in order of precedence.
+func applicationDirs() []string {
+	dirs := []string{fmt.Sprintf("%v", basedir.GetXDGDirectory("data")) + "/applications"}
+	for _, dir := range basedir.GetXDGDirectory("dataDirs").([]string) {
+		dirs = append(dirs, dir+"/applications")
+	}
+	return dirs
+}
 
+// desktopFileCandidates returns the relative paths a desktop file ID may refer to.
+// IDs are built by replacing "/" with "-", so every "-" may stand for a subdirectory.
+func desktopFileCandidates(id string) []string {
+	candidates := []string{id}
+	for i := 0; i < len(id); i++ {
+		if id[i] != '-' || len(candidates) >= 16 {
+			continue
 		}
+		for _, c := range candidates {
+			candidates = append(candidates, c[:i]+"/"+c[i+1:])
+		}
+	}
+	return candidates
+}
+
+// FindDesktopFile looks up a desktop file by its desktop file ID (e.g. "org.gnome.Nautilus"
+// or "org.gnome.Nautilus.desktop") in the XDG data directories.
+// It returns the parsed file and its path.
+func FindDesktopFile(id string) (DesktopFile, string, error) {
+	id = strings.TrimSuffix(id, ".desktop")
+	if id == "" {
+		return DesktopFile{}, "", fmt.Errorf("empty desktop file ID")
 	}
 
-	return dfile, nil
+	for _, dir := range applicationDirs() {
+		for _, candidate := range desktopFileCandidates(id) {
+			path := filepath.Join(dir, candidate+".desktop")
+			if info, err := os.Stat(path); err != nil || info.IsDir() {
+				continue
+			}
+			dfile, err := ReadDesktopFile(path)
+			if err != nil {
+				return DesktopFile{}, path, err
+			}
+			return dfile, path, nil
+		}
+	}
+	return DesktopFile{}, "", fmt.Errorf("desktop file %s not found", id)
 }
 
 func ListAllApplications() ([]DesktopFile, error) {
@@ -230,7 +238,7 @@ func ListAllApplications() ([]DesktopFile, error) {
 		if _, err := os.Stat(dir + "/applications"); os.IsNotExist(err) {
 			continue
 		}
-		slog.Info("Processing directory: ", dir+"/applications")
+		slog.Info("Processing directory", "dir", dir+"/applications")
 		app1, err := ListApplications(dir + "/applications")
 		if err != nil {
 			return nil, err
@@ -239,7 +247,7 @@ func ListAllApplications() ([]DesktopFile, error) {
 		for nm, app := range app1 {
 			apps[nm] = app
 		}
-		slog.Info("Finished processing directory: ", dir+"/applications")
+		slog.Info("Finished processing directory", "dir", dir+"/applications")
 	}
 
 	fapps := []DesktopFile{}
@@ -261,21 +269,21 @@ func ListApplications(directory string) (map[string]DesktopFile, error) {
 		}
 
 		if !info.IsDir() && strings.HasSuffix(info.Name(), ".desktop") {
-			slog.Debug("Processing file: ", path)
+			slog.Debug("Processing file", "path", path)
 			desktopFile, parseErr := ReadDesktopFile(path)
 			if parseErr == nil && desktopFile.Type == "Application" && !desktopFile.NoDisplay && !desktopFile.Hidden {
 				dName := strings.Replace(strings.Replace(info.Name(), directory, "", 1), "/", "-", -1)
 				apps[dName] = desktopFile
 			}
 		} else if info.IsDir() && path != directory {
-			slog.Debug("Processing subdirectory: ", path)
+			slog.Debug("Processing subdirectory", "path", path)
 			tapps, err := ListApplications(path)
 			if err == nil {
 				for nm, app := range tapps {
 					apps[info.Name()+"-"+nm] = app
 				}
 			}
-			slog.Debug("Finished processing subdirectory: ", path)
+			slog.Debug("Finished processing subdirectory", "path", path)
 		}
 		return nil
 	})
@@ -286,3 +294,80 @@ func ListApplications(directory string) (map[string]DesktopFile, error) {
 
 	return apps, nil
 }
+
+// ListApplicationsByID returns the desktop files of every application directory,
+// keyed by desktop file ID (e.g. "org.gnome.Nautilus"). When several directories
+// have the same ID, the one with precedence wins; a Hidden entry removes the ID.
+// Unlike ListAllApplications, NoDisplay entries are included, as they still
+// describe applications, e.g. for matching windows.
+func ListApplicationsByID() (map[string]DesktopFile, error) {
+	entries, err := scanApplicationDirs(nil)
+	if err != nil {
+		return nil, err
+	}
+	apps := make(map[string]DesktopFile)
+	for id, entry := range entries {
+		if entry.dfile.Type == "Application" {
+			apps[id] = entry.dfile
+		}
+	}
+	return apps, nil
+}
+
+// scannedEntry is a desktop file found by scanApplicationDirs.
+type scannedEntry struct {
+	path  string
+	dfile DesktopFile
+}
+
+// scanApplicationDirs reads the desktop files of every application directory by
+// ID, the one with precedence winning. Hidden entries remove their ID, and
+// unreadable ones are skipped. visit, if not nil, is called with every
+// directory walked.
+func scanApplicationDirs(visit func(dir string)) (map[string]scannedEntry, error) {
+	entries := make(map[string]scannedEntry)
+	seen := make(map[string]bool)
+
+	for _, dir := range applicationDirs() {
+		err := filepath.WalkDir(dir, func(path string, entry os.DirEntry, err error) error {
+			if err != nil {
+				if path == dir {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if entry.IsDir() {
+				if visit != nil {
+					visit(path)
+				}
+				return nil
+			}
+			if !strings.HasSuffix(entry.Name(), ".desktop") {
+				return nil
+			}
+			rel, err := filepath.Rel(dir, path)
+			if err != nil {
+				return nil
+			}
+			id := strings.ReplaceAll(strings.TrimSuffix(rel, ".desktop"), "/", "-")
+			if seen[id] {
+				return nil
+			}
+			seen[id] = true
+
+			dfile, err := ReadDesktopFile(path)
+			if err != nil {
+				slog.Debug("Skipping unreadable desktop file", "path", path, "error", err)
+				return nil
+			}
+			if !dfile.Hidden {
+				entries[id] = scannedEntry{path: path, dfile: dfile}
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return entries, nil
+}
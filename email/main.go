@@ -0,0 +1,265 @@
+/*
+	libxdg-go - An implementaion of various freedesktop specifications in go
+    Copyright (C) 2025 MiracleOS Contributors
+
+    This program is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    This program is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+*/
+
+// Package email opens the user's mail client to compose a message, as xdg-email
+// does: it builds a mailto: URI and launches the default handler of the mailto
+// scheme with it.
+package email
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/MiracleOS-Team/libxdg-go/desktopFiles"
+	"github.com/MiracleOS-Team/libxdg-go/mime"
+)
+
+// launchGrace is how long a launch is awaited for an early failure before the
+// client is assumed to have started.
+const launchGrace = 500 * time.Millisecond
+
+// Message is a message to compose. Every field is optional.
+type Message struct {
+	To  []string
+	Cc  []string
+	Bcc []string
+	// Subject is a single line.
+	Subject string
+	Body    string
+	// Attachments are paths of local files. Mail clients not known to take
+	// attachments on their command line get them as "attach" parameters of the
+	// URI, which some of them ignore.
+	Attachments []string
+}
+
+// ComposeEmail opens the default mail client with a new message.
+func ComposeEmail(to []string, subject, body string, attachments []string) error {
+	return Compose(Message{To: to, Subject: subject, Body: body, Attachments: attachments})
+}
+
+// URI returns the mailto: URI of the message, encoded as by RFC 6068,
+// attachments excluded.
+func (m Message) URI() string {
+	return m.uri(false)
+}
+
+// uri builds the mailto: URI, with attach parameters if attach is set.
+func (m Message) uri(attach bool) string {
+	var b strings.Builder
+	b.WriteString("mailto:")
+	b.WriteString(addressList(m.To))
+
+	separator := "?"
+	add := func(name, value string) {
+		if value != "" {
+			b.WriteString(separator + name + "=" + value)
+			separator = "&"
+		}
+	}
+	add("cc", addressList(m.Cc))
+	add("bcc", addressList(m.Bcc))
+	add("subject", escape(m.Subject, false))
+	add("body", escape(crlf(m.Body), false))
+	if attach {
+		for _, path := range m.Attachments {
+			add("attach", escape(path, false))
+		}
+	}
+	return b.String()
+}
+
+// addressList encodes addresses for the path of a mailto: URI.
+func addressList(addresses []string) string {
+	encoded := make([]string, len(addresses))
+	for i, address := range addresses {
+		encoded[i] = escape(strings.TrimSpace(address), true)
+	}
+	return strings.Join(encoded, ",")
+}
+
+// crlf turns line breaks into CRLF, which RFC 6068 requires in bodies.
+func crlf(s string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(s, "\r\n", "\n"), "\n", "\r\n")
+}
+
+// escape percent-encodes a value of a mailto: URI. Only unreserved characters
+// and delimiters with no meaning in mailto: URIs are left as they are; in
+// addresses, "@" is kept too, and "," is encoded as it separates them.
+func escape(s string, address bool) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9',
+			strings.IndexByte("-._~!$()*;:@", c) >= 0:
+			b.WriteByte(c)
+		case c == '/' || c == '?' || c == ',':
+			if address {
+				fmt.Fprintf(&b, "%%%02X", c)
+			} else {
+				b.WriteByte(c)
+			}
+		default:
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
+
+// Compose opens the default mail client with a new message.
+func Compose(m Message) error {
+	m.Attachments = append([]string(nil), m.Attachments...)
+	for i, path := range m.Attachments {
+		abs, err := filepath.Abs(path)
+		if err != nil {
+			return err
+		}
+		if info, err := os.Stat(abs); err != nil {
+			return err
+		} else if info.IsDir() {
+			return fmt.Errorf("attachment %s is a directory", abs)
+		}
+		m.Attachments[i] = abs
+	}
+
+	id, dfile, err := mime.DefaultSchemeHandler("mailto")
+	if err != nil {
+		return err
+	}
+	command, program := mailClient(dfile.ApplicationObject.Exec)
+	if len(command) == 0 {
+		return fmt.Errorf("%s has no Exec key", id)
+	}
+
+	switch strings.TrimSuffix(program, "-bin") {
+	case "thunderbird", "betterbird", "icedove":
+		command = append(command, "-compose", m.thunderbirdSpec())
+		return start(id, exec.Command(command[0], command[1:]...))
+	case "claws-mail":
+		command = append(command, "--compose", m.URI())
+		if len(m.Attachments) > 0 {
+			command = append(append(command, "--attach"), m.Attachments...)
+		}
+		return start(id, exec.Command(command[0], command[1:]...))
+	}
+
+	errs := make(chan error, 1)
+	go func() { errs <- desktopFiles.ExecuteDesktopFile(dfile, []string{m.uri(true)}, "") }()
+	return await(id, errs)
+}
+
+// mailClient splits an Exec value and returns the command line up to the mail
+// client, and the name of its program. The env and flatpak run wrappers are
+// kept on the command line but skipped to find the program; for a flatpak, it
+// is the --command option or the last part of the application ID, lowercased.
+func mailClient(execValue string) ([]string, string) {
+	args := desktopFiles.SplitExec(execValue)
+	i := 0
+	if i < len(args) && filepath.Base(args[i]) == "env" {
+	options:
+		for i++; i < len(args); i++ {
+			switch arg := args[i]; {
+			case arg == "-u" || arg == "-C":
+				i++
+			case !strings.HasPrefix(arg, "-") && !strings.Contains(arg, "="):
+				break options
+			}
+		}
+	}
+	if i >= len(args) {
+		return nil, ""
+	}
+	if filepath.Base(args[i]) != "flatpak" || i+1 >= len(args) || args[i+1] != "run" {
+		return args[:i+1], filepath.Base(args[i])
+	}
+
+	var name string
+	for i += 2; i < len(args) && strings.HasPrefix(args[i], "-"); i++ {
+		if command, found := strings.CutPrefix(args[i], "--command="); found {
+			name = filepath.Base(command)
+		}
+	}
+	if i >= len(args) {
+		return nil, ""
+	}
+	if name == "" {
+		name = args[i][strings.LastIndexByte(args[i], '.')+1:]
+	}
+	return args[:i+1], strings.ToLower(name)
+}
+
+// thunderbirdSpec returns the argument of Thunderbird's -compose option, whose
+// values are percent-encoded as in mailto: URIs. Thunderbird splits it on
+// commas, so address lists are quoted and other commas encoded.
+func (m Message) thunderbirdSpec() string {
+	var fields []string
+	add := func(name, value string) {
+		if value != "" {
+			fields = append(fields, name+"="+value)
+		}
+	}
+	quote := func(addresses []string) string {
+		if len(addresses) == 0 {
+			return ""
+		}
+		return "'" + addressList(addresses) + "'"
+	}
+	add("to", quote(m.To))
+	add("cc", quote(m.Cc))
+	add("bcc", quote(m.Bcc))
+	add("subject", strings.ReplaceAll(escape(m.Subject, false), ",", "%2C"))
+	add("body", strings.ReplaceAll(escape(crlf(m.Body), false), ",", "%2C"))
+	if len(m.Attachments) > 0 {
+		uris := make([]string, len(m.Attachments))
+		for i, path := range m.Attachments {
+			uris[i] = (&url.URL{Scheme: "file", Path: path}).String()
+		}
+		add("attachment", "'"+strings.Join(uris, ",")+"'")
+	}
+	return strings.Join(fields, ",")
+}
+
+// start runs a mail client command.
+func start(id string, cmd *exec.Cmd) error {
+	errs := make(chan error, 1)
+	go func() { errs <- cmd.Run() }()
+	return await(id, errs)
+}
+
+// await waits for a launch to fail early, leaving the client running otherwise.
+func await(id string, errs chan error) error {
+	select {
+	case err := <-errs:
+		return err
+	case <-time.After(launchGrace):
+		go func() {
+			if err := <-errs; err != nil && !errors.As(err, new(*exec.ExitError)) {
+				slog.Error("Mail client failed", "application", id, "error", err)
+			}
+		}()
+		return nil
+	}
+}
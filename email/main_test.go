@@ -0,0 +1,91 @@
+/*
+	libxdg-go - An implementaion of various freedesktop specifications in go
+    Copyright (C) 2025 MiracleOS Contributors
+
+    This program is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    This program is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+*/
+
+package email
+
+import (
+	"strings"
+	"testing"
+)
+
+// splitSpec splits a -compose argument on the commas outside quotes, as
+// Thunderbird does.
+func splitSpec(spec string) []string {
+	var fields []string
+	quoted := false
+	start := 0
+	for i, c := range spec {
+		switch {
+		case c == '\'':
+			quoted = !quoted
+		case c == ',' && !quoted:
+			fields = append(fields, spec[start:i])
+			start = i + 1
+		}
+	}
+	return append(fields, spec[start:])
+}
+
+func TestThunderbirdSpec(t *testing.T) {
+	m := Message{
+		To:          []string{"alice@example.com", "bob@example.com"},
+		Cc:          []string{"carol@example.com"},
+		Subject:     "Hi, Bob",
+		Body:        "One, two\nthree",
+		Attachments: []string{"/tmp/a,b.txt"},
+	}
+	spec := m.thunderbirdSpec()
+	want := []string{
+		"to='alice@example.com,bob@example.com'",
+		"cc='carol@example.com'",
+		"subject=Hi%2C%20Bob",
+		"body=One%2C%20two%0D%0Athree",
+		"attachment='file:///tmp/a,b.txt'",
+	}
+	if got := splitSpec(spec); strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("spec %q splits into\n%q\nwant\n%q", spec, got, want)
+	}
+}
+
+func TestMailClient(t *testing.T) {
+	tests := []struct {
+		exec    string
+		command []string
+		program string
+	}{
+		{"thunderbird %u", []string{"thunderbird"}, "thunderbird"},
+		{`"/opt/Mail Apps/thunderbird" -compose %u`, []string{"/opt/Mail Apps/thunderbird"}, "thunderbird"},
+		{"env MOZ_ENABLE_WAYLAND=1 -u DISPLAY /usr/bin/thunderbird %u", []string{"env", "MOZ_ENABLE_WAYLAND=1", "-u", "DISPLAY", "/usr/bin/thunderbird"}, "thunderbird"},
+		{
+			"/usr/bin/flatpak run --branch=stable --arch=x86_64 --command=thunderbird --file-forwarding org.mozilla.Thunderbird @@u %u @@",
+			[]string{"/usr/bin/flatpak", "run", "--branch=stable", "--arch=x86_64", "--command=thunderbird", "--file-forwarding", "org.mozilla.Thunderbird"},
+			"thunderbird",
+		},
+		{"flatpak run com.claws_mail.Claws-Mail %u", []string{"flatpak", "run", "com.claws_mail.Claws-Mail"}, "claws-mail"},
+		{"evolution %U", []string{"evolution"}, "evolution"},
+		{"env FOO=1", nil, ""},
+		{"", nil, ""},
+	}
+	for _, test := range tests {
+		command, program := mailClient(test.exec)
+		if strings.Join(command, "\x00") != strings.Join(test.command, "\x00") || program != test.program {
+			t.Errorf("mailClient(%q) = %q, %q; want %q, %q", test.exec, command, program, test.command, test.program)
+		}
+	}
+}
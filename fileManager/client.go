@@ -0,0 +1,171 @@
+/*
+	libxdg-go - An implementaion of various freedesktop specifications in go
+    Copyright (C) 2025 MiracleOS Contributors
+
+    This program is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    This program is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+*/
+
+package fileManager
+
+import (
+	"errors"
+	"log/slog"
+	"net/url"
+	"os/exec"
+	"path"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/MiracleOS-Team/libxdg-go/desktopFiles"
+	"github.com/MiracleOS-Team/libxdg-go/mime"
+	"github.com/godbus/dbus/v5"
+)
+
+// launchGrace is how long the fallback file manager must keep running, or exit
+// successfully, to be considered started.
+const launchGrace = 500 * time.Millisecond
+
+// Client asks the running file manager to show files over the session bus.
+type Client struct {
+	conn *dbus.Conn
+	obj  dbus.BusObject
+}
+
+// NewClient connects to the session bus.
+func NewClient() (*Client, error) {
+	conn, err := dbus.ConnectSessionBus()
+	if err != nil {
+		return nil, err
+	}
+	return NewClientWithConn(conn)
+}
+
+// NewClientWithConn creates a client on an existing connection.
+// The connection is closed by Client.Close.
+func NewClientWithConn(conn *dbus.Conn) (*Client, error) {
+	return &Client{conn: conn, obj: conn.Object(busName, objectPath)}, nil
+}
+
+// Close disconnects the client.
+func (c *Client) Close() error {
+	return c.conn.Close()
+}
+
+// Conn returns the D-Bus connection of the client.
+func (c *Client) Conn() *dbus.Conn {
+	return c.conn
+}
+
+// ShowItems shows files or folders selected in their containing folders, as
+// "Reveal in file manager" does. Paths may be file paths or URIs. startupID is
+// an activation token for the file manager window, possibly empty.
+//
+// Without a FileManager1 service, the containing folders are opened with the
+// default inode/directory application instead.
+func (c *Client) ShowItems(paths []string, startupID string) error {
+	uris, err := toURIs(paths)
+	if err != nil {
+		return err
+	}
+	err = c.obj.Call(interfaceName+".ShowItems", 0, uris, startupID).Err
+	if noService(err) {
+		return openFolders(parents(uris))
+	}
+	return err
+}
+
+// ShowFolders opens folders. Without a FileManager1 service they are opened
+// with the default inode/directory application instead.
+func (c *Client) ShowFolders(paths []string, startupID string) error {
+	uris, err := toURIs(paths)
+	if err != nil {
+		return err
+	}
+	err = c.obj.Call(interfaceName+".ShowFolders", 0, uris, startupID).Err
+	if noService(err) {
+		return openFolders(uris)
+	}
+	return err
+}
+
+// ShowItemProperties shows the properties dialog of files or folders.
+func (c *Client) ShowItemProperties(paths []string, startupID string) error {
+	uris, err := toURIs(paths)
+	if err != nil {
+		return err
+	}
+	return c.obj.Call(interfaceName+".ShowItemProperties", 0, uris, startupID).Err
+}
+
+// Reveal shows a file selected in its folder using a new session bus connection.
+func Reveal(path string) error {
+	c, err := NewClient()
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+	return c.ShowItems([]string{path}, "")
+}
+
+// noService reports whether a call failed because no file manager provides
+// the service, rather than in the file manager.
+func noService(err error) bool {
+	var dbusErr dbus.Error
+	if !errors.As(err, &dbusErr) {
+		return false
+	}
+	return dbusErr.Name == "org.freedesktop.DBus.Error.ServiceUnknown" ||
+		dbusErr.Name == "org.freedesktop.DBus.Error.NameHasNoOwner" ||
+		strings.HasPrefix(dbusErr.Name, "org.freedesktop.DBus.Error.Spawn.")
+}
+
+// parents returns the folders containing URIs, without duplicates.
+func parents(uris []string) []string {
+	var folders []string
+	for _, uri := range uris {
+		u, err := url.Parse(uri)
+		if err != nil {
+			continue
+		}
+		u.Path = path.Dir(strings.TrimSuffix(u.Path, "/"))
+		if folder := u.String(); !slices.Contains(folders, folder) {
+			folders = append(folders, folder)
+		}
+	}
+	return folders
+}
+
+// openFolders opens folders with the default inode/directory application.
+func openFolders(uris []string) error {
+	dfile, err := mime.QueryDefault("inode/directory")
+	if err != nil {
+		return err
+	}
+
+	errs := make(chan error, 1)
+	go func() { errs <- desktopFiles.ExecuteDesktopFile(dfile, uris, "") }()
+	select {
+	case err := <-errs:
+		return err
+	case <-time.After(launchGrace):
+		go func() {
+			if err := <-errs; err != nil && !errors.As(err, new(*exec.ExitError)) {
+				slog.Error("File manager failed", "application", dfile.Name, "error", err)
+			}
+		}()
+		return nil
+	}
+}
@@ -0,0 +1,191 @@
+/*
+	libxdg-go - An implementaion of various freedesktop specifications in go
+    Copyright (C) 2025 MiracleOS Contributors
+
+    This program is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    This program is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+*/
+
+package fileManager
+
+import (
+	"errors"
+	"log/slog"
+	"sync"
+
+	"github.com/godbus/dbus/v5"
+	"github.com/godbus/dbus/v5/introspect"
+)
+
+// Handler handles a request of the FileManager1 interface: uris are the
+// file:// URIs to show, startupID the activation token for the window.
+type Handler func(uris []string, startupID string) error
+
+// DaemonConfig configures a Daemon. Requests without a handler fail with
+// org.freedesktop.DBus.Error.NotSupported.
+type DaemonConfig struct {
+	// Conn is the connection to serve on, left open by Stop. The session bus is
+	// used if nil.
+	Conn *dbus.Conn
+	// BusAddress is the address of the bus to connect to if Conn is nil, the
+	// session bus if empty.
+	BusAddress string
+
+	// ShowFolders opens folders.
+	ShowFolders Handler
+	// ShowItems shows items selected in their folders.
+	ShowItems Handler
+	// ShowItemProperties shows the properties of items.
+	ShowItemProperties Handler
+}
+
+// Daemon serves the org.freedesktop.FileManager1 interface for a file
+// manager, dispatching the requests to its handlers. Handlers run on the
+// D-Bus goroutine and should return quickly.
+type Daemon struct {
+	config  DaemonConfig
+	conn    *dbus.Conn
+	mu      sync.Mutex
+	started bool
+	stopped bool
+}
+
+// fileManagerObject holds the methods the daemon exports.
+type fileManagerObject struct {
+	d *Daemon
+}
+
+// NewDaemon creates a daemon, served by Start.
+func NewDaemon(config DaemonConfig) *Daemon {
+	return &Daemon{config: config}
+}
+
+// Start serves the interface and takes the org.freedesktop.FileManager1 name.
+func (d *Daemon) Start() error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.started {
+		return errors.New("file manager daemon already started")
+	}
+
+	conn := d.config.Conn
+	if conn == nil {
+		var err error
+		if d.config.BusAddress == "" {
+			conn, err = dbus.ConnectSessionBus()
+		} else {
+			conn, err = dbus.Connect(d.config.BusAddress)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	d.conn = conn
+
+	if err := conn.Export(fileManagerObject{d}, objectPath, interfaceName); err != nil {
+		d.disconnectBus()
+		return err
+	}
+	method := func(name string) introspect.Method {
+		return introspect.Method{
+			Name: name,
+			Args: []introspect.Arg{
+				{Name: "URIs", Type: "as", Direction: "in"},
+				{Name: "StartupId", Type: "s", Direction: "in"},
+			},
+		}
+	}
+	node := &introspect.Node{
+		Name: string(objectPath),
+		Interfaces: []introspect.Interface{
+			{
+				Name: interfaceName,
+				Methods: []introspect.Method{
+					method("ShowFolders"),
+					method("ShowItems"),
+					method("ShowItemProperties"),
+				},
+			},
+			introspect.IntrospectData,
+		},
+	}
+	if err := conn.Export(introspect.NewIntrospectable(node), objectPath, "org.freedesktop.DBus.Introspectable"); err != nil {
+		d.unexport()
+		return err
+	}
+
+	reply, err := conn.RequestName(busName, dbus.NameFlagDoNotQueue)
+	if err == nil && reply != dbus.RequestNameReplyPrimaryOwner {
+		err = errors.New("file manager service is already running (bus name taken)")
+	}
+	if err != nil {
+		d.unexport()
+		return err
+	}
+
+	d.started = true
+	slog.Info("File manager service started on DBus as org.freedesktop.FileManager1")
+	return nil
+}
+
+// unexport undoes Start.
+func (d *Daemon) unexport() {
+	d.conn.Export(nil, objectPath, interfaceName)
+	d.conn.Export(nil, objectPath, "org.freedesktop.DBus.Introspectable")
+	d.disconnectBus()
+}
+
+// disconnectBus closes the bus connection unless it was given in DaemonConfig.Conn.
+func (d *Daemon) disconnectBus() {
+	if d.conn != d.config.Conn {
+		d.conn.Close()
+	}
+}
+
+// Stop releases the name. Calling Stop more than once is a no-op.
+func (d *Daemon) Stop() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if !d.started || d.stopped {
+		return
+	}
+	d.stopped = true
+	d.conn.ReleaseName(busName)
+	d.unexport()
+}
+
+// handle runs a handler on a request.
+func handle(handler Handler, uris []string, startupID string) *dbus.Error {
+	if handler == nil {
+		return &dbus.Error{Name: "org.freedesktop.DBus.Error.NotSupported", Body: []any{"not supported by this file manager"}}
+	}
+	if err := handler(uris, startupID); err != nil {
+		return dbus.MakeFailedError(err)
+	}
+	return nil
+}
+
+func (o fileManagerObject) ShowFolders(uris []string, startupID string) *dbus.Error {
+	return handle(o.d.config.ShowFolders, uris, startupID)
+}
+
+func (o fileManagerObject) ShowItems(uris []string, startupID string) *dbus.Error {
+	return handle(o.d.config.ShowItems, uris, startupID)
+}
+
+func (o fileManagerObject) ShowItemProperties(uris []string, startupID string) *dbus.Error {
+	return handle(o.d.config.ShowItemProperties, uris, startupID)
+}
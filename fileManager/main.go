@@ -0,0 +1,65 @@
+/*
+	libxdg-go - An implementaion of various freedesktop specifications in go
+    Copyright (C) 2025 MiracleOS Contributors
+
+    This program is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    This program is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+*/
+
+// Package fileManager implements the org.freedesktop.FileManager1 interface,
+// which applications use to show files in the user's file manager.
+package fileManager
+
+import (
+	"errors"
+	"net/url"
+	"path/filepath"
+	"strings"
+
+	"github.com/godbus/dbus/v5"
+)
+
+const (
+	busName       = "org.freedesktop.FileManager1"
+	objectPath    = dbus.ObjectPath("/org/freedesktop/FileManager1")
+	interfaceName = "org.freedesktop.FileManager1"
+)
+
+// toURI turns a path into a file:// URI, leaving URIs alone.
+func toURI(path string) (string, error) {
+	if strings.Contains(path, "://") {
+		return path, nil
+	}
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return "", err
+	}
+	return (&url.URL{Scheme: "file", Path: abs}).String(), nil
+}
+
+// toURIs turns paths into file:// URIs.
+func toURIs(paths []string) ([]string, error) {
+	if len(paths) == 0 {
+		return nil, errors.New("no files to show")
+	}
+	uris := make([]string, 0, len(paths))
+	for _, path := range paths {
+		uri, err := toURI(path)
+		if err != nil {
+			return nil, err
+		}
+		uris = append(uris, uri)
+	}
+	return uris, nil
+}
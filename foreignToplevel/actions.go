@@ -0,0 +1,95 @@
+/*
+	libxdg-go - An implementaion of various freedesktop specifications in go
+    Copyright (C) 2025 MiracleOS Contributors
+
+    This program is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    This program is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+*/
+
+package foreignToplevel
+
+import (
+	"errors"
+
+	"github.com/MiracleOS-Team/libxdg-go/wayland"
+)
+
+// ErrUnknownOutput is returned by SetFullscreen for an output that doesn't exist.
+var ErrUnknownOutput = errors.New("no such output")
+
+// CloseToplevel asks the application to close a toplevel. It may ask the user
+// first, or refuse.
+func (c *Client) CloseToplevel(t Toplevel) error {
+	return c.request(t, 1, wlrHandleClose)
+}
+
+// SetMinimized minimizes or unminimizes a toplevel.
+func (c *Client) SetMinimized(t Toplevel, minimized bool) error {
+	if minimized {
+		return c.request(t, 1, wlrHandleSetMinimized)
+	}
+	return c.request(t, 1, wlrHandleUnsetMinimized)
+}
+
+// SetMaximized maximizes or unmaximizes a toplevel.
+func (c *Client) SetMaximized(t Toplevel, maximized bool) error {
+	if maximized {
+		return c.request(t, 1, wlrHandleSetMaximized)
+	}
+	return c.request(t, 1, wlrHandleUnsetMaximized)
+}
+
+// SetFullscreen makes a toplevel fullscreen, on the named output or, if output is
+// empty, on one the compositor picks; or leaves fullscreen.
+func (c *Client) SetFullscreen(t Toplevel, fullscreen bool, output string) error {
+	if !fullscreen {
+		return c.request(t, 2, wlrHandleUnsetFullscreen)
+	}
+
+	var target *wayland.Object
+	if output != "" {
+		c.mu.Lock()
+		for _, o := range c.outputs {
+			if o.name == output {
+				target = o.obj
+			}
+		}
+		c.mu.Unlock()
+		if target == nil {
+			return ErrUnknownOutput
+		}
+	}
+	return c.request(t, 2, wlrHandleSetFullscreen, target)
+}
+
+// request sends a request on the handle of a toplevel, which needs at least
+// version since of the wlr protocol, and waits for the compositor to process it
+// so protocol errors are reported.
+func (c *Client) request(t Toplevel, since uint32, opcode uint16, args ...interface{}) error {
+	c.mu.Lock()
+	handle, err := c.handleLocked(t)
+	c.mu.Unlock()
+
+	if err != nil {
+		return err
+	}
+	if handle.Version() < since {
+		return ErrUnsupported
+	}
+	if err := handle.Request(opcode, args...); err != nil {
+		return err
+	}
+	// The lock must not be held here, the events dispatched meanwhile need it.
+	return c.conn.Roundtrip()
+}
@@ -0,0 +1,143 @@
+/*
+	libxdg-go - An implementaion of various freedesktop specifications in go
+    Copyright (C) 2025 MiracleOS Contributors
+
+    This program is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    This program is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+*/
+
+package foreignToplevel
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/MiracleOS-Team/libxdg-go/wayland"
+	"github.com/godbus/dbus/v5"
+)
+
+const (
+	activationInterface = "xdg_activation_v1"
+	activationVersion   = 1
+
+	// xdg_activation_v1 requests.
+	activationGetToken = 1
+
+	// xdg_activation_token_v1 requests.
+	tokenSetAppID = 1
+	tokenCommit   = 3
+	tokenDestroy  = 4
+
+	// xdg_activation_token_v1 events.
+	tokenDone = 0
+)
+
+// ActivationToken asks the compositor for an xdg-activation token for the
+// application appID, which may be empty. The application receiving it, usually
+// through ActivationEnv when launching it or through D-Bus activation, may use it
+// to focus its window. It fails with ErrUnsupported if the compositor doesn't
+// implement xdg-activation-v1.
+func (c *Client) ActivationToken(appID string) (string, error) {
+	c.mu.Lock()
+	activation, err := c.activationLocked()
+	c.mu.Unlock()
+	if err != nil {
+		return "", err
+	}
+
+	done := make(chan string, 1)
+	token := c.conn.NewObject("xdg_activation_token_v1", func(e *wayland.Event) {
+		if e.Opcode == tokenDone {
+			done <- e.String()
+		}
+	})
+	if err := activation.Request(activationGetToken, token); err != nil {
+		return "", err
+	}
+	defer func() {
+		token.Request(tokenDestroy)
+		token.Forget()
+	}()
+
+	if appID != "" {
+		if err := token.Request(tokenSetAppID, appID); err != nil {
+			return "", err
+		}
+	}
+	if err := token.Request(tokenCommit); err != nil {
+		return "", err
+	}
+
+	select {
+	case value := <-done:
+		return value, nil
+	case <-c.conn.Done():
+		return "", c.conn.Err()
+	}
+}
+
+// activationLocked returns the xdg_activation_v1 global, binding it on first use.
+// c.mu must be held.
+func (c *Client) activationLocked() (*wayland.Object, error) {
+	if c.activation != nil {
+		return c.activation, nil
+	}
+	global, found := c.conn.FindGlobal(activationInterface)
+	if !found {
+		return nil, ErrUnsupported
+	}
+	obj, err := c.conn.Bind(global, activationVersion, nil)
+	if err != nil {
+		return nil, err
+	}
+	c.activation = obj
+	return obj, nil
+}
+
+// ActivationEnv returns the environment variables passing an activation token
+// to a launched application, for Wayland and X11 startup notification alike.
+func ActivationEnv(token string) []string {
+	return []string{"XDG_ACTIVATION_TOKEN=" + token, "DESKTOP_STARTUP_ID=" + token}
+}
+
+// activateApp focuses the application of a toplevel by D-Bus activating it with
+// an activation token, as desktop shells do for running applications. It only
+// works for applications whose desktop file sets DBusActivatable.
+func (c *Client) activateApp(t Toplevel) error {
+	id, dfile, found := defaultResolver.Resolve(t)
+	if !found || !dfile.DBusActivatable {
+		return ErrUnsupported
+	}
+	token, err := c.ActivationToken(t.AppID)
+	if err != nil {
+		return err
+	}
+
+	conn, err := dbus.ConnectSessionBus()
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	path := "/" + strings.ReplaceAll(strings.ReplaceAll(id, ".", "/"), "-", "_")
+	platformData := map[string]dbus.Variant{
+		"activation-token":   dbus.MakeVariant(token),
+		"desktop-startup-id": dbus.MakeVariant(token),
+	}
+	call := conn.Object(id, dbus.ObjectPath(path)).Call("org.freedesktop.Application.Activate", 0, platformData)
+	if call.Err != nil {
+		return errors.Join(ErrUnsupported, call.Err)
+	}
+	return nil
+}
@@ -0,0 +1,136 @@
+/*
+	libxdg-go - An implementaion of various freedesktop specifications in go
+    Copyright (C) 2025 MiracleOS Contributors
+
+    This program is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    This program is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+*/
+
+package foreignToplevel
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// Backend lists and controls toplevels. *Client, speaking the Wayland protocols,
+// is the native one; others can be added with RegisterBackend, e.g. for compositor
+// IPC. Actions a backend can't perform fail with ErrUnsupported.
+type Backend interface {
+	// Name is the name the backend is registered under.
+	Name() string
+	Toplevels() []Toplevel
+	Watch(ctx context.Context) <-chan ToplevelEvent
+	Activate(t Toplevel) error
+	CloseToplevel(t Toplevel) error
+	SetMinimized(t Toplevel, minimized bool) error
+	SetMaximized(t Toplevel, maximized bool) error
+	SetFullscreen(t Toplevel, fullscreen bool, output string) error
+	// Close releases the backend.
+	Close() error
+}
+
+// Names of the native backends.
+const (
+	// BackendWlr uses wlr-foreign-toplevel-management, which allows every action.
+	BackendWlr = "wlr"
+	// BackendExt uses the read-only ext-foreign-toplevel-list.
+	BackendExt = "ext"
+)
+
+// Options configures Open.
+type Options struct {
+	// Backend is the name of the backend to use. If empty, the native backends
+	// are tried first, then the registered ones in registration order.
+	Backend string
+}
+
+type backendEntry struct {
+	name string
+	open func() (Backend, error)
+}
+
+var (
+	backendsMu sync.Mutex
+	backends   = []backendEntry{
+		{BackendWlr, func() (Backend, error) { return connect(wlrManagerInterface) }},
+		{BackendExt, func() (Backend, error) { return connect(extListInterface) }},
+	}
+)
+
+// RegisterBackend makes a backend available to Open under name. open should fail
+// quickly when the backend isn't usable in the current session. Registering an
+// existing name replaces it.
+func RegisterBackend(name string, open func() (Backend, error)) {
+	backendsMu.Lock()
+	defer backendsMu.Unlock()
+
+	for i, entry := range backends {
+		if entry.name == name {
+			backends[i].open = open
+			return
+		}
+	}
+	backends = append(backends, backendEntry{name, open})
+}
+
+// Backends returns the names of the available backends, in the order Open tries them.
+func Backends() []string {
+	backendsMu.Lock()
+	defer backendsMu.Unlock()
+
+	names := make([]string, len(backends))
+	for i, entry := range backends {
+		names[i] = entry.name
+	}
+	return names
+}
+
+// Open opens the backend selected by opts, or the first usable one.
+func Open(opts Options) (Backend, error) {
+	backendsMu.Lock()
+	entries := append([]backendEntry(nil), backends...)
+	backendsMu.Unlock()
+
+	var errs []error
+	for _, entry := range entries {
+		if opts.Backend != "" && entry.name != opts.Backend {
+			continue
+		}
+		backend, err := entry.open()
+		if err == nil {
+			return backend, nil
+		}
+		if opts.Backend != "" {
+			return nil, err
+		}
+		errs = append(errs, fmt.Errorf("%s: %w", entry.name, err))
+	}
+	if opts.Backend != "" {
+		return nil, fmt.Errorf("unknown toplevel backend %q", opts.Backend)
+	}
+	return nil, errors.Join(append([]error{ErrUnsupported}, errs...)...)
+}
+
+// Name returns BackendWlr or BackendExt, depending on the protocol in use.
+func (c *Client) Name() string {
+	if c.manager.Interface() == wlrManagerInterface {
+		return BackendWlr
+	}
+	return BackendExt
+}
+
+var _ Backend = (*Client)(nil)
@@ -0,0 +1,453 @@
+/*
+	libxdg-go - An implementaion of various freedesktop specifications in go
+    Copyright (C) 2025 MiracleOS Contributors
+
+    This program is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    This program is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+*/
+
+package foreignToplevel
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"time"
+
+	"github.com/MiracleOS-Team/libxdg-go/wayland"
+)
+
+// ext-image-capture-source-v1, ext-image-copy-capture-v1 and wl_shm.
+const (
+	sourceManagerInterface = "ext_foreign_toplevel_image_capture_source_manager_v1"
+	copyManagerInterface   = "ext_image_copy_capture_manager_v1"
+	shmInterface           = "wl_shm"
+
+	// Source manager requests.
+	sourceManagerCreateSource = 0
+
+	// Source requests.
+	sourceDestroy = 0
+
+	// Copy manager requests.
+	copyManagerCreateSession = 0
+
+	// Session events.
+	sessionBufferSize = 0
+	sessionShmFormat  = 1
+	sessionDone       = 4
+	sessionStopped    = 5
+
+	// Session requests.
+	sessionCreateFrame = 0
+	sessionDestroy     = 1
+
+	// Frame requests.
+	frameDestroy      = 0
+	frameAttachBuffer = 1
+	frameDamageBuffer = 2
+	frameCapture      = 3
+
+	// Frame events.
+	frameReady  = 3
+	frameFailed = 4
+
+	// Frame failure reasons.
+	frameFailedBufferConstraints = 1
+
+	// wl_shm requests, wl_shm_pool requests and wl_buffer requests.
+	shmCreatePool    = 0
+	poolCreateBuffer = 0
+	poolDestroy      = 1
+	bufferDestroy    = 0
+
+	// wl_shm formats. ARGB and XRGB have their own codes, the others are fourcc codes.
+	shmFormatARGB8888 = 0
+	shmFormatXRGB8888 = 1
+	shmFormatABGR8888 = 0x34324241
+	shmFormatXBGR8888 = 0x34324258
+
+	captureTimeout = 5 * time.Second
+)
+
+// ErrCaptureFailed is returned when the compositor could not capture a toplevel.
+var ErrCaptureFailed = errors.New("toplevel capture failed")
+
+// extMirror follows the ext-foreign-toplevel-list when the Client uses the wlr
+// protocol, since only ext handles can be captured.
+type extMirror struct {
+	list    *wayland.Object
+	handles []*mirrorHandle
+}
+
+type mirrorHandle struct {
+	obj   *wayland.Object
+	appID string
+	title string
+}
+
+// Capture grabs the current content of a toplevel. It needs a compositor
+// implementing ext-image-copy-capture-v1 with toplevel capture sources, and
+// fails with ErrUnsupported otherwise.
+func (c *Client) Capture(t Toplevel) (image.Image, error) {
+	handle, err := c.captureHandle(t)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	sourceManager, err := c.bindOnceLocked(sourceManagerInterface, 1, &c.sourceManager)
+	var copyManager, shm *wayland.Object
+	if err == nil {
+		copyManager, err = c.bindOnceLocked(copyManagerInterface, 1, &c.copyManager)
+	}
+	if err == nil {
+		shm, err = c.bindOnceLocked(shmInterface, 1, &c.shm)
+	}
+	c.mu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+
+	source := c.conn.NewObject("ext_image_capture_source_v1", nil)
+	if err := sourceManager.Request(sourceManagerCreateSource, source, handle); err != nil {
+		return nil, err
+	}
+	defer func() {
+		source.Request(sourceDestroy)
+		source.Forget()
+	}()
+
+	s := &captureSession{done: make(chan struct{}, 1), stopped: make(chan struct{})}
+	s.obj = c.conn.NewObject("ext_image_copy_capture_session_v1", s.handle)
+	if err := copyManager.Request(copyManagerCreateSession, s.obj, source, uint32(0)); err != nil {
+		return nil, err
+	}
+	defer func() {
+		s.obj.Request(sessionDestroy)
+		s.obj.Forget()
+	}()
+
+	// The buffer constraints may change while capturing; the frame then fails and
+	// the session announces new ones.
+	for attempt := 0; ; attempt++ {
+		if err := c.waitCapture(s.done, s.stopped); err != nil {
+			return nil, err
+		}
+		img, err := c.captureFrame(s, shm)
+		if errors.Is(err, errBufferConstraints) && attempt < 2 {
+			continue
+		}
+		return img, err
+	}
+}
+
+// Thumbnail captures a toplevel and scales it down to fit in a square of size
+// pixels, keeping its aspect ratio.
+func (c *Client) Thumbnail(t Toplevel, size int) (image.Image, error) {
+	img, err := c.Capture(t)
+	if err != nil {
+		return nil, err
+	}
+	return scaleDown(img, size), nil
+}
+
+// captureSession collects the buffer constraints of a capture session.
+type captureSession struct {
+	obj     *wayland.Object
+	width   uint32
+	height  uint32
+	formats []uint32
+	done    chan struct{}
+	stopped chan struct{}
+	closed  bool
+}
+
+func (s *captureSession) handle(e *wayland.Event) {
+	switch e.Opcode {
+	case sessionBufferSize:
+		s.width, s.height = e.Uint(), e.Uint()
+	case sessionShmFormat:
+		s.formats = append(s.formats, e.Uint())
+	case sessionDone:
+		select {
+		case s.done <- struct{}{}:
+		default:
+		}
+	case sessionStopped:
+		if !s.closed {
+			s.closed = true
+			close(s.stopped)
+		}
+	}
+}
+
+var errBufferConstraints = errors.New("buffer constraints changed")
+
+// captureFrame captures one frame of a session into a shared memory buffer.
+func (c *Client) captureFrame(s *captureSession, shm *wayland.Object) (image.Image, error) {
+	// The session handler writes these only before done, which was received.
+	width, height, formats := int(s.width), int(s.height), s.formats
+	format, found := uint32(0), false
+	for _, f := range formats {
+		switch f {
+		case shmFormatARGB8888, shmFormatXRGB8888, shmFormatABGR8888, shmFormatXBGR8888:
+			format, found = f, true
+		}
+		if found {
+			break
+		}
+	}
+	if !found || width <= 0 || height <= 0 {
+		return nil, fmt.Errorf("%w: no usable buffer format", ErrCaptureFailed)
+	}
+
+	stride := width * 4
+	mem, err := wayland.NewShmFile(stride * height)
+	if err != nil {
+		return nil, err
+	}
+	defer mem.Close()
+
+	pool := c.conn.NewObject("wl_shm_pool", nil)
+	if err := shm.Request(shmCreatePool, pool, mem.FD(), int32(stride*height)); err != nil {
+		return nil, err
+	}
+	buffer := c.conn.NewObject("wl_buffer", nil)
+	err = pool.Request(poolCreateBuffer, buffer, int32(0), int32(width), int32(height), int32(stride), format)
+	pool.Request(poolDestroy)
+	pool.Forget()
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		buffer.Request(bufferDestroy)
+		buffer.Forget()
+	}()
+
+	result := make(chan uint32, 1)
+	frame := c.conn.NewObject("ext_image_copy_capture_frame_v1", func(e *wayland.Event) {
+		switch e.Opcode {
+		case frameReady:
+			result <- ^uint32(0)
+		case frameFailed:
+			result <- e.Uint()
+		}
+	})
+	if err := s.obj.Request(sessionCreateFrame, frame); err != nil {
+		return nil, err
+	}
+	defer func() {
+		frame.Request(frameDestroy)
+		frame.Forget()
+	}()
+	frame.Request(frameAttachBuffer, buffer)
+	frame.Request(frameDamageBuffer, int32(0), int32(0), int32(width), int32(height))
+	if err := frame.Request(frameCapture); err != nil {
+		return nil, err
+	}
+
+	select {
+	case reason := <-result:
+		switch reason {
+		case ^uint32(0):
+		case frameFailedBufferConstraints:
+			return nil, errBufferConstraints
+		default:
+			return nil, fmt.Errorf("%w: reason %d", ErrCaptureFailed, reason)
+		}
+	case <-c.conn.Done():
+		return nil, c.conn.Err()
+	case <-time.After(captureTimeout):
+		return nil, fmt.Errorf("%w: timed out", ErrCaptureFailed)
+	}
+	return decodeShm(mem.Data, width, height, stride, format), nil
+}
+
+// waitCapture waits for the buffer constraints of a session.
+func (c *Client) waitCapture(done, stopped <-chan struct{}) error {
+	select {
+	case <-done:
+		return nil
+	case <-stopped:
+		return ErrToplevelGone
+	case <-c.conn.Done():
+		return c.conn.Err()
+	case <-time.After(captureTimeout):
+		return fmt.Errorf("%w: timed out", ErrCaptureFailed)
+	}
+}
+
+// bindOnceLocked binds a global into *obj unless it is bound already.
+// c.mu must be held.
+func (c *Client) bindOnceLocked(iface string, version uint32, obj **wayland.Object) (*wayland.Object, error) {
+	if *obj != nil {
+		return *obj, nil
+	}
+	global, found := c.conn.FindGlobal(iface)
+	if !found {
+		return nil, ErrUnsupported
+	}
+	bound, err := c.conn.Bind(global, version, nil)
+	if err != nil {
+		return nil, err
+	}
+	*obj = bound
+	return bound, nil
+}
+
+// captureHandle returns the ext toplevel handle of a toplevel. With the wlr
+// protocol, the toplevel is matched in the ext list by app ID, title and, among
+// toplevels sharing them, by order of creation.
+func (c *Client) captureHandle(t Toplevel) (*wayland.Object, error) {
+	c.mu.Lock()
+	current, exists := c.toplevels[t.handle]
+	if !exists || current.current.ID != t.ID {
+		c.mu.Unlock()
+		return nil, ErrToplevelGone
+	}
+	if c.manager.Interface() == extListInterface {
+		c.mu.Unlock()
+		return current.handle, nil
+	}
+
+	if c.mirror == nil {
+		global, found := c.conn.FindGlobal(extListInterface)
+		if !found {
+			c.mu.Unlock()
+			return nil, ErrUnsupported
+		}
+		m := &extMirror{}
+		list, err := c.conn.Bind(global, extListVersion, func(e *wayland.Event) { c.handleMirrorList(m, e) })
+		if err != nil {
+			c.mu.Unlock()
+			return nil, err
+		}
+		m.list = list
+		c.mirror = m
+		c.mu.Unlock()
+		for i := 0; i < 2; i++ {
+			if err := c.conn.Roundtrip(); err != nil {
+				return nil, err
+			}
+		}
+		c.mu.Lock()
+	}
+	defer c.mu.Unlock()
+
+	rank := 0
+	for _, id := range c.order {
+		if id == t.handle {
+			break
+		}
+		if other := c.toplevels[id]; other.current.AppID == t.AppID && other.current.Title == t.Title {
+			rank++
+		}
+	}
+	for _, h := range c.mirror.handles {
+		if h.appID != t.AppID || h.title != t.Title {
+			continue
+		}
+		if rank == 0 {
+			return h.obj, nil
+		}
+		rank--
+	}
+	return nil, ErrToplevelGone
+}
+
+func (c *Client) handleMirrorList(m *extMirror, e *wayland.Event) {
+	if e.Opcode != extListToplevel {
+		return
+	}
+	h := &mirrorHandle{}
+	h.obj = c.conn.RegisterObject(e.ObjectID(), extHandleInterface, extListVersion, func(e *wayland.Event) {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+
+		switch e.Opcode {
+		case extHandleTitle:
+			h.title = e.String()
+		case extHandleAppID:
+			h.appID = e.String()
+		case extHandleClosed:
+			for i, other := range m.handles {
+				if other == h {
+					m.handles = append(m.handles[:i], m.handles[i+1:]...)
+					break
+				}
+			}
+			h.obj.Request(extHandleDestroy)
+			h.obj.Forget()
+		}
+	})
+	c.mu.Lock()
+	m.handles = append(m.handles, h)
+	c.mu.Unlock()
+}
+
+// decodeShm converts a shared memory buffer to an image. Wayland buffers have
+// premultiplied alpha, like image.RGBA.
+func decodeShm(data []byte, width, height, stride int, format uint32) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		row := data[y*stride : y*stride+width*4]
+		out := img.Pix[y*img.Stride : y*img.Stride+width*4]
+		for x := 0; x < width*4; x += 4 {
+			switch format {
+			case shmFormatARGB8888, shmFormatXRGB8888:
+				out[x], out[x+1], out[x+2], out[x+3] = row[x+2], row[x+1], row[x], row[x+3]
+			default:
+				out[x], out[x+1], out[x+2], out[x+3] = row[x], row[x+1], row[x+2], row[x+3]
+			}
+			if format == shmFormatXRGB8888 || format == shmFormatXBGR8888 {
+				out[x+3] = 0xff
+			}
+		}
+	}
+	return img
+}
+
+// scaleDown shrinks an image to fit in a square of size pixels by averaging
+// the source pixels covered by each destination pixel.
+func scaleDown(img image.Image, size int) image.Image {
+	bounds := img.Bounds()
+	w, h := bounds.Dx(), bounds.Dy()
+	if size <= 0 || (w <= size && h <= size) {
+		return img
+	}
+	dw, dh := size, h*size/w
+	if h > w {
+		dw, dh = w*size/h, size
+	}
+	dw, dh = max(dw, 1), max(dh, 1)
+
+	out := image.NewRGBA(image.Rect(0, 0, dw, dh))
+	for y := 0; y < dh; y++ {
+		y0, y1 := y*h/dh, max((y+1)*h/dh, y*h/dh+1)
+		for x := 0; x < dw; x++ {
+			x0, x1 := x*w/dw, max((x+1)*w/dw, x*w/dw+1)
+			var r, g, b, a, n uint64
+			for sy := y0; sy < y1; sy++ {
+				for sx := x0; sx < x1; sx++ {
+					cr, cg, cb, ca := img.At(bounds.Min.X+sx, bounds.Min.Y+sy).RGBA()
+					r, g, b, a, n = r+uint64(cr), g+uint64(cg), b+uint64(cb), a+uint64(ca), n+1
+				}
+			}
+			out.SetRGBA(x, y, color.RGBA{uint8(r / n >> 8), uint8(g / n >> 8), uint8(b / n >> 8), uint8(a / n >> 8)})
+		}
+	}
+	return out
+}
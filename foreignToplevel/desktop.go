@@ -0,0 +1,142 @@
+/*
+	libxdg-go - An implementaion of various freedesktop specifications in go
+    Copyright (C) 2025 MiracleOS Contributors
+
+    This program is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    This program is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+*/
+
+package foreignToplevel
+
+import (
+	"log/slog"
+	"strings"
+	"sync"
+
+	"github.com/MiracleOS-Team/libxdg-go/desktopFiles"
+)
+
+// AppResolver matches toplevels to the desktop files of their applications.
+// The applications are indexed on first use, and results are cached per app ID
+// and title until Refresh is called. It is safe for concurrent use.
+type AppResolver struct {
+	mu      sync.Mutex
+	apps    map[string]desktopFiles.DesktopFile
+	indexed bool
+	cache   map[[2]string]appMatch
+}
+
+// appMatch is the desktop file found for an app ID and title.
+type appMatch struct {
+	id    string
+	dfile desktopFiles.DesktopFile
+	found bool
+}
+
+var defaultResolver = NewAppResolver()
+
+// NewAppResolver returns an empty AppResolver.
+func NewAppResolver() *AppResolver {
+	return &AppResolver{cache: make(map[[2]string]appMatch)}
+}
+
+// Refresh drops the index and the cache, e.g. after applications were installed.
+func (r *AppResolver) Refresh() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.apps = nil
+	r.indexed = false
+	r.cache = make(map[[2]string]appMatch)
+}
+
+// Resolve returns the desktop file ID and desktop file of the application of a
+// toplevel. In order, it tries the app ID as a desktop file ID (also lowercased),
+// StartupWMClass, the last component of reverse-DNS desktop file IDs, and
+// finally the application name against the title.
+func (r *AppResolver) Resolve(t Toplevel) (string, desktopFiles.DesktopFile, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	key := [2]string{t.AppID, t.Title}
+	m, cached := r.cache[key]
+	if !cached {
+		m = r.lookupLocked(t.AppID, t.Title)
+		r.cache[key] = m
+	}
+	return m.id, m.dfile, m.found
+}
+
+func (r *AppResolver) lookupLocked(appID, title string) appMatch {
+	if !r.indexed {
+		apps, err := desktopFiles.ListApplicationsByID()
+		if err != nil {
+			slog.Debug("Failed to index desktop files", "error", err)
+		}
+		r.apps = apps
+		r.indexed = true
+	}
+
+	appID = strings.TrimSuffix(appID, ".desktop")
+	lower := strings.ToLower(appID)
+	if appID != "" {
+		for _, id := range []string{appID, lower} {
+			if dfile, exists := r.apps[id]; exists {
+				return appMatch{id: id, dfile: dfile, found: true}
+			}
+		}
+		for id, dfile := range r.apps {
+			if strings.EqualFold(dfile.ApplicationObject.StartupWMClass, appID) {
+				return appMatch{id: id, dfile: dfile, found: true}
+			}
+		}
+		for id, dfile := range r.apps {
+			if i := strings.LastIndexByte(id, '.'); i >= 0 && strings.ToLower(id[i+1:]) == lower {
+				return appMatch{id: id, dfile: dfile, found: true}
+			}
+		}
+	}
+	if title != "" {
+		for id, dfile := range r.apps {
+			if dfile.Name != "" && strings.EqualFold(dfile.Name, title) {
+				return appMatch{id: id, dfile: dfile, found: true}
+			}
+		}
+	}
+	return appMatch{}
+}
+
+// DesktopFile returns the desktop file of the toplevel's application, if one matches.
+func (t Toplevel) DesktopFile() (desktopFiles.DesktopFile, bool) {
+	_, dfile, ok := defaultResolver.Resolve(t)
+	return dfile, ok
+}
+
+// DesktopID returns the desktop file ID of the toplevel's application, or "".
+func (t Toplevel) DesktopID() string {
+	id, _, _ := defaultResolver.Resolve(t)
+	return id
+}
+
+// DisplayName returns the application name from the desktop file, falling back
+// to the title and then the app ID.
+func (t Toplevel) DisplayName() string {
+	if dfile, ok := t.DesktopFile(); ok && dfile.Name != "" {
+		return dfile.Name
+	}
+	if t.Title != "" {
+		return t.Title
+	}
+	return t.AppID
+}
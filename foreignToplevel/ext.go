@@ -0,0 +1,74 @@
+/*
+	libxdg-go - An implementaion of various freedesktop specifications in go
+    Copyright (C) 2025 MiracleOS Contributors
+
+    This program is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    This program is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+*/
+
+package foreignToplevel
+
+import (
+	"github.com/MiracleOS-Team/libxdg-go/wayland"
+)
+
+// ext_foreign_toplevel_list_v1.
+const (
+	extListInterface   = "ext_foreign_toplevel_list_v1"
+	extHandleInterface = "ext_foreign_toplevel_handle_v1"
+	extListVersion     = 1
+
+	// List events.
+	extListToplevel = 0
+	extListFinished = 1
+
+	// Handle requests.
+	extHandleDestroy = 0
+
+	// Handle events.
+	extHandleClosed     = 0
+	extHandleDone       = 1
+	extHandleTitle      = 2
+	extHandleAppID      = 3
+	extHandleIdentifier = 4
+)
+
+func (c *Client) handleExtList(e *wayland.Event) {
+	switch e.Opcode {
+	case extListToplevel:
+		c.addToplevel(e.ObjectID(), extHandleInterface, c.handleExtToplevel)
+	case extListFinished:
+		c.manager.Forget()
+	}
+}
+
+func (c *Client) handleExtToplevel(t *toplevel, e *wayland.Event) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	switch e.Opcode {
+	case extHandleTitle:
+		t.pending.Title = e.String()
+	case extHandleAppID:
+		t.pending.AppID = e.String()
+	case extHandleIdentifier:
+		t.pending.Identifier = e.String()
+	case extHandleDone:
+		c.commitLocked(t)
+	case extHandleClosed:
+		c.removeToplevelLocked(t)
+		t.handle.Request(extHandleDestroy)
+		t.handle.Forget()
+	}
+}
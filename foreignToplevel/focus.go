@@ -0,0 +1,208 @@
+/*
+	libxdg-go - An implementaion of various freedesktop specifications in go
+    Copyright (C) 2025 MiracleOS Contributors
+
+    This program is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    This program is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+*/
+
+package foreignToplevel
+
+import (
+	"context"
+	"errors"
+	"sort"
+)
+
+// ErrUnknownSeat is returned when activating a toplevel on a seat that doesn't exist.
+var ErrUnknownSeat = errors.New("unknown seat")
+
+// FocusEvent reports a change of the selected toplevel, e.g. the focused one.
+type FocusEvent struct {
+	// Focused reports whether a toplevel is selected. It is false when, e.g., the
+	// desktop has keyboard focus.
+	Focused bool
+	// Toplevel is the selected toplevel, if any.
+	Toplevel Toplevel
+}
+
+// Seats returns the names of the seats, sorted.
+func (c *Client) Seats() []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	names := make([]string, 0, len(c.seats))
+	for _, s := range c.seats {
+		names = append(names, s.name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ActivateOnSeat focuses a toplevel on the named seat, or on the default seat if
+// seat is empty. See Activate for the fallback used without the wlr protocol.
+func (c *Client) ActivateOnSeat(t Toplevel, seatName string) error {
+	c.mu.Lock()
+	s := c.defaultSeatLocked()
+	if seatName != "" {
+		s = nil
+		for _, other := range c.seats {
+			if other.name == seatName {
+				s = other
+			}
+		}
+	}
+	c.mu.Unlock()
+
+	var err error
+	switch {
+	case s == nil && seatName != "":
+		return ErrUnknownSeat
+	case s == nil:
+		err = ErrNoSeat
+	default:
+		err = c.request(t, 1, wlrHandleActivate, s.obj)
+	}
+	if errors.Is(err, ErrUnsupported) || errors.Is(err, ErrNoSeat) {
+		if fallbackErr := c.activateApp(t); fallbackErr == nil {
+			return nil
+		}
+	}
+	if err == nil {
+		c.mu.Lock()
+		c.seatFocus[s.name] = t.ID
+		c.mu.Unlock()
+	}
+	return err
+}
+
+// Focused returns the focused toplevel. Should several be activated, as on
+// multi-seat setups, it returns the one activated last.
+func (c *Client) Focused() (Toplevel, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.focusedLocked(func(t Toplevel) bool { return true })
+}
+
+// FocusedOn returns the focused toplevel of the named seat. The protocols don't
+// tell which seat a toplevel is activated on, so with several seats this is only
+// known for toplevels activated through ActivateOnSeat; otherwise it is Focused.
+func (c *Client) FocusedOn(seatName string) (Toplevel, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if id, exists := c.seatFocus[seatName]; exists && len(c.seats) > 1 {
+		if t, found := c.focusedLocked(func(t Toplevel) bool { return t.ID == id }); found {
+			return t, true
+		}
+	}
+	return c.focusedLocked(func(t Toplevel) bool { return true })
+}
+
+// focusedLocked returns the activated toplevel matching match that was activated last.
+// c.mu must be held.
+func (c *Client) focusedLocked(match func(t Toplevel) bool) (Toplevel, bool) {
+	var found *toplevel
+	for _, id := range c.order {
+		t := c.toplevels[id]
+		if !t.ready || !t.current.Activated() || !match(t.current) {
+			continue
+		}
+		if found == nil || t.activatedAt > found.activatedAt {
+			found = t
+		}
+	}
+	if found == nil {
+		return Toplevel{}, false
+	}
+	return found.snapshot(), true
+}
+
+// WatchFocus returns a channel receiving the focused toplevel of backend, first
+// as it is now and then each time it changes. The channel is closed when ctx is
+// done or the backend's Watch channel closes.
+func WatchFocus(ctx context.Context, backend Backend) <-chan FocusEvent {
+	return watchSelected(ctx, backend, Toplevel.Activated)
+}
+
+// watchSelected follows the most recently activated toplevel for which selected
+// is true, and reports each change of it.
+func watchSelected(ctx context.Context, backend Backend, selected func(t Toplevel) bool) <-chan FocusEvent {
+	// Watching first means no change falls between listing and watching.
+	events := backend.Watch(ctx)
+	toplevels := make(map[uint64]Toplevel)
+	// order lists toplevels by the time they were last activated, oldest first.
+	var order []uint64
+	for _, t := range backend.Toplevels() {
+		toplevels[t.ID] = t
+		order = append(order, t.ID)
+	}
+	ch := make(chan FocusEvent)
+
+	go func() {
+		defer close(ch)
+
+		var last *FocusEvent
+		for {
+			current := FocusEvent{}
+			for i := len(order) - 1; i >= 0; i-- {
+				if t := toplevels[order[i]]; selected(t) {
+					current = FocusEvent{Focused: true, Toplevel: t}
+					break
+				}
+			}
+
+			if last == nil || last.Focused != current.Focused ||
+				last.Toplevel.ID != current.Toplevel.ID || last.Toplevel.AppID != current.Toplevel.AppID {
+				last = &current
+				select {
+				case ch <- current:
+				case <-ctx.Done():
+					return
+				}
+			}
+
+			event, ok := <-events
+			if !ok {
+				return
+			}
+			t := event.Toplevel
+			previous, known := toplevels[t.ID]
+			switch {
+			case event.Type == EventClosed:
+				delete(toplevels, t.ID)
+				order = removeID(order, t.ID)
+			case !known:
+				toplevels[t.ID] = t
+				order = append(order, t.ID)
+			default:
+				toplevels[t.ID] = t
+				if t.Activated() && !previous.Activated() {
+					order = append(removeID(order, t.ID), t.ID)
+				}
+			}
+		}
+	}()
+	return ch
+}
+
+func removeID(ids []uint64, id uint64) []uint64 {
+	for i, other := range ids {
+		if other == id {
+			return append(ids[:i], ids[i+1:]...)
+		}
+	}
+	return ids
+}
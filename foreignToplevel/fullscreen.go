@@ -0,0 +1,87 @@
+/*
+	libxdg-go - An implementaion of various freedesktop specifications in go
+    Copyright (C) 2025 MiracleOS Contributors
+
+    This program is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    This program is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+*/
+
+package foreignToplevel
+
+import (
+	"context"
+)
+
+// FullscreenEvent reports a change of the focused fullscreen toplevel.
+type FullscreenEvent struct {
+	// Fullscreen reports whether a focused toplevel is fullscreen.
+	Fullscreen bool
+	// Toplevel is the focused fullscreen toplevel, if any.
+	Toplevel Toplevel
+}
+
+// FullscreenToplevel returns the focused fullscreen toplevel shown on the named
+// output, or on any output if output is empty.
+func (c *Client) FullscreenToplevel(output string) (Toplevel, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.focusedLocked(func(t Toplevel) bool { return focusedFullscreen(t, output) })
+}
+
+// IsAnyToplevelFullscreen reports whether a focused toplevel is fullscreen on the
+// named output, or on any output if output is empty.
+func (c *Client) IsAnyToplevelFullscreen(output string) bool {
+	_, found := c.FullscreenToplevel(output)
+	return found
+}
+
+// WatchFullscreen calls fn with the app ID of the focused fullscreen toplevel, or ""
+// when there is none, now and each time it changes, until stop is called. It
+// makes a Client a notificationDaemon.FullscreenSource.
+func (c *Client) WatchFullscreen(fn func(appID string)) (stop func(), err error) {
+	ctx, cancel := context.WithCancel(context.Background())
+	events := WatchFullscreen(ctx, c, "")
+	go func() {
+		for event := range events {
+			fn(event.Toplevel.AppID)
+		}
+	}()
+	return cancel, nil
+}
+
+// WatchFullscreen returns a channel receiving the focused fullscreen toplevel on
+// the named output, or on any output if output is empty, first as it is now and
+// then each time it changes. The channel is closed when ctx is done or the
+// backend's Watch channel closes.
+func WatchFullscreen(ctx context.Context, backend Backend, output string) <-chan FullscreenEvent {
+	selected := watchSelected(ctx, backend, func(t Toplevel) bool { return focusedFullscreen(t, output) })
+	ch := make(chan FullscreenEvent)
+	go func() {
+		defer close(ch)
+		for event := range selected {
+			select {
+			case ch <- FullscreenEvent{Fullscreen: event.Focused, Toplevel: event.Toplevel}:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return ch
+}
+
+// focusedFullscreen reports whether a toplevel is focused and fullscreen on output.
+func focusedFullscreen(t Toplevel, output string) bool {
+	return t.State.Has(StateActivated|StateFullscreen) && (output == "" || t.OnOutput(output))
+}
@@ -0,0 +1,330 @@
+/*
+	libxdg-go - An implementaion of various freedesktop specifications in go
+    Copyright (C) 2025 MiracleOS Contributors
+
+    This program is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    This program is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+*/
+
+package foreignToplevel
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// BackendHyprland uses the IPC sockets of Hyprland.
+const BackendHyprland = "hyprland"
+
+func init() {
+	RegisterBackend(BackendHyprland, func() (Backend, error) { return OpenHyprland() })
+}
+
+// HyprlandBackend lists and controls toplevels through the Hyprland IPC. Unlike
+// the Wayland protocols, it knows the workspace, geometry and floating state of
+// windows. Hyprland has no minimized state, and moving or resizing windows isn't
+// reported until another change happens.
+type HyprlandBackend struct {
+	*ipcState
+
+	dir    string
+	events net.Conn
+}
+
+// OpenHyprland connects to the Hyprland instance given by HYPRLAND_INSTANCE_SIGNATURE.
+func OpenHyprland() (*HyprlandBackend, error) {
+	signature := os.Getenv("HYPRLAND_INSTANCE_SIGNATURE")
+	if signature == "" {
+		return nil, ErrUnsupported
+	}
+	// Hyprland moved its sockets from /tmp to the runtime directory in 0.40.
+	dir := filepath.Join(os.Getenv("XDG_RUNTIME_DIR"), "hypr", signature)
+	if _, err := os.Stat(filepath.Join(dir, ".socket.sock")); err != nil {
+		dir = filepath.Join("/tmp/hypr", signature)
+	}
+
+	events, err := net.Dial("unix", filepath.Join(dir, ".socket2.sock"))
+	if err != nil {
+		return nil, err
+	}
+	b := &HyprlandBackend{ipcState: newIPCState(), dir: dir, events: events}
+	if err := b.refresh(); err != nil {
+		b.Close()
+		return nil, err
+	}
+	go b.listen()
+	return b, nil
+}
+
+// Name returns BackendHyprland.
+func (b *HyprlandBackend) Name() string {
+	return BackendHyprland
+}
+
+// Close disconnects from Hyprland.
+func (b *HyprlandBackend) Close() error {
+	err := b.events.Close()
+	b.close()
+	return err
+}
+
+// Activate focuses a toplevel.
+func (b *HyprlandBackend) Activate(t Toplevel) error {
+	return b.dispatch(t, "focuswindow address:%s")
+}
+
+// CloseToplevel asks a toplevel to close.
+func (b *HyprlandBackend) CloseToplevel(t Toplevel) error {
+	return b.dispatch(t, "closewindow address:%s")
+}
+
+// SetMinimized fails with ErrUnsupported: Hyprland has no minimized state.
+func (b *HyprlandBackend) SetMinimized(t Toplevel, minimized bool) error {
+	return ErrUnsupported
+}
+
+// SetMaximized maximizes a toplevel or restores it. It focuses the toplevel,
+// since Hyprland only maximizes the focused window.
+func (b *HyprlandBackend) SetMaximized(t Toplevel, maximized bool) error {
+	return b.toggle(t, StateMaximized, maximized, "fullscreen 1")
+}
+
+// SetFullscreen makes a toplevel fullscreen or not, focusing it like SetMaximized.
+// Hyprland fullscreens windows on the monitor they are on, output is ignored.
+func (b *HyprlandBackend) SetFullscreen(t Toplevel, fullscreen bool, output string) error {
+	return b.toggle(t, StateFullscreen, fullscreen, "fullscreen 0")
+}
+
+// toggle focuses a toplevel and runs a dispatcher toggling state, unless the
+// toplevel already is in the requested state.
+func (b *HyprlandBackend) toggle(t Toplevel, state State, set bool, dispatcher string) error {
+	current, exists := b.find(t.ID)
+	if !exists {
+		return ErrToplevelGone
+	}
+	if current.State.Has(state) == set {
+		return nil
+	}
+	return b.run(fmt.Sprintf("[[BATCH]]dispatch focuswindow address:%s;dispatch %s", current.Identifier, dispatcher))
+}
+
+// ActivateWorkspace switches to a workspace.
+func (b *HyprlandBackend) ActivateWorkspace(w Workspace) error {
+	return b.run("dispatch workspace " + hyprlandWorkspaceArg(w))
+}
+
+// MoveToWorkspace moves a toplevel to a workspace, without following it.
+func (b *HyprlandBackend) MoveToWorkspace(t Toplevel, w Workspace) error {
+	return b.dispatch(t, "movetoworkspacesilent "+hyprlandWorkspaceArg(w)+",address:%s")
+}
+
+// hyprlandWorkspaceArg returns the dispatcher argument selecting a workspace.
+func hyprlandWorkspaceArg(w Workspace) string {
+	if _, err := strconv.Atoi(w.ID); err == nil {
+		return w.ID
+	}
+	return "name:" + w.Name
+}
+
+// dispatch runs a dispatcher on a toplevel; format gets its address.
+func (b *HyprlandBackend) dispatch(t Toplevel, format string) error {
+	current, exists := b.find(t.ID)
+	if !exists {
+		return ErrToplevelGone
+	}
+	return b.run("dispatch " + fmt.Sprintf(format, current.Identifier))
+}
+
+// run sends a command and checks that Hyprland answered "ok".
+func (b *HyprlandBackend) run(command string) error {
+	reply, err := b.request(command)
+	if err != nil {
+		return err
+	}
+	for _, line := range strings.Split(strings.TrimSpace(string(reply)), "\n\n") {
+		if line = strings.TrimSpace(line); line != "ok" && line != "" {
+			return fmt.Errorf("hyprland: %s", line)
+		}
+	}
+	return nil
+}
+
+// request sends a command on a new connection, as Hyprland closes it after replying.
+func (b *HyprlandBackend) request(command string) ([]byte, error) {
+	conn, err := net.Dial("unix", filepath.Join(b.dir, ".socket.sock"))
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	if _, err := conn.Write([]byte(command)); err != nil {
+		return nil, err
+	}
+	return io.ReadAll(conn)
+}
+
+func (b *HyprlandBackend) query(command string, reply interface{}) error {
+	data, err := b.request("j/" + command)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, reply)
+}
+
+// listen refreshes the state on every event until the connection is closed.
+func (b *HyprlandBackend) listen() {
+	defer b.close()
+
+	scanner := bufio.NewScanner(b.events)
+	for scanner.Scan() {
+		name, _, _ := strings.Cut(scanner.Text(), ">>")
+		switch strings.TrimSuffix(name, "v2") {
+		case "openwindow", "closewindow", "windowtitle", "activewindow", "movewindow",
+			"fullscreen", "changefloatingmode", "workspace", "createworkspace",
+			"destroyworkspace", "renameworkspace", "focusedmon", "moveworkspace",
+			"monitoradded", "monitorremoved", "urgent":
+			if err := b.refresh(); err != nil {
+				slog.Debug("Failed to refresh the Hyprland state", "error", err)
+			}
+		}
+	}
+}
+
+type hyprlandClient struct {
+	Address   string `json:"address"`
+	Mapped    bool   `json:"mapped"`
+	At        [2]int `json:"at"`
+	Size      [2]int `json:"size"`
+	Workspace struct {
+		ID   int    `json:"id"`
+		Name string `json:"name"`
+	} `json:"workspace"`
+	Floating bool   `json:"floating"`
+	Monitor  int    `json:"monitor"`
+	Class    string `json:"class"`
+	Title    string `json:"title"`
+	// Fullscreen is a boolean before Hyprland 0.42, with the mode in
+	// FullscreenMode, and the mode itself since.
+	Fullscreen     json.RawMessage `json:"fullscreen"`
+	FullscreenMode int             `json:"fullscreenMode"`
+	FocusHistoryID int             `json:"focusHistoryID"`
+}
+
+type hyprlandWorkspace struct {
+	ID      int    `json:"id"`
+	Name    string `json:"name"`
+	Monitor string `json:"monitor"`
+}
+
+type hyprlandMonitor struct {
+	ID              int    `json:"id"`
+	Name            string `json:"name"`
+	ActiveWorkspace struct {
+		ID int `json:"id"`
+	} `json:"activeWorkspace"`
+	SpecialWorkspace struct {
+		ID int `json:"id"`
+	} `json:"specialWorkspace"`
+}
+
+// refresh queries the windows, workspaces and monitors.
+func (b *HyprlandBackend) refresh() error {
+	var clients []hyprlandClient
+	var hyprWorkspaces []hyprlandWorkspace
+	var monitors []hyprlandMonitor
+	if err := b.query("clients", &clients); err != nil {
+		return err
+	}
+	if err := b.query("workspaces", &hyprWorkspaces); err != nil {
+		return err
+	}
+	if err := b.query("monitors", &monitors); err != nil {
+		return err
+	}
+
+	monitorNames := make(map[int]string)
+	active := make(map[int]bool)
+	for _, m := range monitors {
+		monitorNames[m.ID] = m.Name
+		active[m.ActiveWorkspace.ID] = true
+		if m.SpecialWorkspace.ID != 0 {
+			active[m.SpecialWorkspace.ID] = true
+		}
+	}
+
+	toplevels := make([]Toplevel, 0, len(clients))
+	for _, c := range clients {
+		address, err := strconv.ParseUint(strings.TrimPrefix(c.Address, "0x"), 16, 64)
+		if err != nil || !c.Mapped {
+			continue
+		}
+		t := Toplevel{
+			ID:         address,
+			AppID:      c.Class,
+			Title:      c.Title,
+			Identifier: c.Address,
+			Workspace:  c.Workspace.Name,
+			Geometry:   &Rect{c.At[0], c.At[1], c.Size[0], c.Size[1]},
+		}
+		if name, exists := monitorNames[c.Monitor]; exists {
+			t.Outputs = []string{name}
+		}
+		if c.FocusHistoryID == 0 {
+			t.State |= StateActivated
+		}
+		if c.Floating {
+			t.State |= StateFloating
+		}
+		switch mode := string(c.Fullscreen); {
+		case mode == "true" && c.FullscreenMode == 1, mode == "1":
+			t.State |= StateMaximized
+		case mode == "true", mode == "2", mode == "3":
+			t.State |= StateFullscreen
+		}
+		toplevels = append(toplevels, t)
+	}
+
+	sort.Slice(hyprWorkspaces, func(i, j int) bool { return hyprWorkspaces[i].ID < hyprWorkspaces[j].ID })
+	workspaces := make([]Workspace, 0, len(hyprWorkspaces))
+	for _, w := range hyprWorkspaces {
+		workspace := Workspace{
+			ID:      strconv.Itoa(w.ID),
+			Name:    w.Name,
+			Active:  active[w.ID],
+			Hidden:  w.ID < 0,
+			Outputs: []string{w.Monitor},
+		}
+		if w.ID > 0 {
+			workspace.Coordinates = []uint32{uint32(w.ID)}
+		}
+		workspaces = append(workspaces, workspace)
+	}
+
+	b.update(toplevels, workspaces)
+	return nil
+}
+
+var (
+	_ Backend          = (*HyprlandBackend)(nil)
+	_ WorkspaceBackend = (*HyprlandBackend)(nil)
+)
@@ -0,0 +1,57 @@
+/*
+	libxdg-go - An implementaion of various freedesktop specifications in go
+    Copyright (C) 2025 MiracleOS Contributors
+
+    This program is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    This program is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+*/
+
+package foreignToplevel
+
+import (
+	"strings"
+
+	"github.com/MiracleOS-Team/libxdg-go/icons"
+)
+
+// fallbackIcon is used for toplevels nothing better was found for.
+const fallbackIcon = "application-x-executable"
+
+// ResolveIcon returns the path of the icon of a toplevel: the icon of its desktop
+// file, else a themed icon named after the app ID, else a generic executable icon.
+// It returns "" only if not even the generic icon is installed.
+func ResolveIcon(t Toplevel, size int) string {
+	if dfile, ok := t.DesktopFile(); ok && dfile.Icon != "" {
+		return dfile.Icon
+	}
+	if t.AppID != "" {
+		if path, err := icons.FindIconDefaults(strings.ToLower(t.AppID), size, 1, ""); err == nil {
+			return path
+		}
+	}
+	path, err := icons.FindIconDefaults(fallbackIcon, size, 1, "")
+	if err != nil {
+		return ""
+	}
+	return path
+}
+
+// ResolveIcons returns the icon path of each toplevel, in the same order.
+func ResolveIcons(toplevels []Toplevel, size int) []string {
+	paths := make([]string, len(toplevels))
+	for i, t := range toplevels {
+		paths[i] = ResolveIcon(t, size)
+	}
+	return paths
+}
@@ -0,0 +1,172 @@
+/*
+	libxdg-go - An implementaion of various freedesktop specifications in go
+    Copyright (C) 2025 MiracleOS Contributors
+
+    This program is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    This program is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+*/
+
+package foreignToplevel
+
+import (
+	"context"
+	"reflect"
+	"slices"
+	"sync"
+)
+
+// ipcState keeps the toplevels and workspaces of a compositor IPC backend. The
+// backends query the full state on every change event, and ipcState turns the
+// differences into events.
+type ipcState struct {
+	mu                sync.Mutex
+	toplevels         map[uint64]Toplevel
+	order             []uint64
+	workspaces        []Workspace
+	watchers          []*watcher[ToplevelEvent]
+	workspaceWatchers []*watcher[[]Workspace]
+	done              chan struct{}
+	closeOnce         sync.Once
+}
+
+func newIPCState() *ipcState {
+	return &ipcState{
+		toplevels: make(map[uint64]Toplevel),
+		done:      make(chan struct{}),
+	}
+}
+
+// update replaces the state by a newer one and reports what changed.
+func (s *ipcState) update(toplevels []Toplevel, workspaces []Workspace) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	seen := make(map[uint64]bool, len(toplevels))
+	for _, t := range toplevels {
+		seen[t.ID] = true
+		previous, known := s.toplevels[t.ID]
+		s.toplevels[t.ID] = t
+		if !known {
+			s.order = append(s.order, t.ID)
+			s.emitLocked(ToplevelEvent{Type: EventOpened, Toplevel: t})
+			continue
+		}
+		for _, event := range changeEvents(previous, t) {
+			s.emitLocked(event)
+		}
+	}
+	for _, id := range slices.Clone(s.order) {
+		if !seen[id] {
+			s.emitLocked(ToplevelEvent{Type: EventClosed, Toplevel: s.toplevels[id]})
+			delete(s.toplevels, id)
+			s.order = removeID(s.order, id)
+		}
+	}
+
+	if !reflect.DeepEqual(workspaces, s.workspaces) {
+		s.workspaces = workspaces
+		for _, w := range s.workspaceWatchers {
+			w.push(cloneWorkspaces(workspaces))
+		}
+	}
+}
+
+func (s *ipcState) emitLocked(event ToplevelEvent) {
+	for _, w := range s.watchers {
+		w.push(event)
+	}
+}
+
+// close ends the watches, after the connection to the compositor was lost.
+func (s *ipcState) close() {
+	s.closeOnce.Do(func() { close(s.done) })
+}
+
+func (s *ipcState) find(id uint64) (Toplevel, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	t, exists := s.toplevels[id]
+	return t, exists
+}
+
+// Toplevels returns the toplevels, oldest first.
+func (s *ipcState) Toplevels() []Toplevel {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	toplevels := make([]Toplevel, 0, len(s.order))
+	for _, id := range s.order {
+		toplevels = append(toplevels, s.toplevels[id])
+	}
+	return toplevels
+}
+
+// Watch returns a channel of toplevel changes, like Client.Watch.
+func (s *ipcState) Watch(ctx context.Context) <-chan ToplevelEvent {
+	w := newWatcher[ToplevelEvent]()
+
+	s.mu.Lock()
+	for _, id := range s.order {
+		w.push(ToplevelEvent{Type: EventOpened, Toplevel: s.toplevels[id]})
+	}
+	s.watchers = append(s.watchers, w)
+	s.mu.Unlock()
+
+	go func() {
+		w.run(ctx, s.done)
+
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		s.watchers = slices.DeleteFunc(s.watchers, func(other *watcher[ToplevelEvent]) bool { return other == w })
+	}()
+	return w.ch
+}
+
+// Workspaces returns the workspaces.
+func (s *ipcState) Workspaces() []Workspace {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return cloneWorkspaces(s.workspaces)
+}
+
+// WatchWorkspaces returns a channel receiving the workspaces on each change, like
+// Client.WatchWorkspaces.
+func (s *ipcState) WatchWorkspaces(ctx context.Context) <-chan []Workspace {
+	w := newWatcher[[]Workspace]()
+
+	s.mu.Lock()
+	w.push(cloneWorkspaces(s.workspaces))
+	s.workspaceWatchers = append(s.workspaceWatchers, w)
+	s.mu.Unlock()
+
+	go func() {
+		w.run(ctx, s.done)
+
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		s.workspaceWatchers = slices.DeleteFunc(s.workspaceWatchers, func(other *watcher[[]Workspace]) bool { return other == w })
+	}()
+	return w.ch
+}
+
+func cloneWorkspaces(workspaces []Workspace) []Workspace {
+	clone := slices.Clone(workspaces)
+	for i := range clone {
+		clone[i].Coordinates = slices.Clone(clone[i].Coordinates)
+		clone[i].Outputs = slices.Clone(clone[i].Outputs)
+	}
+	return clone
+}
@@ -0,0 +1,267 @@
+/*
+	libxdg-go - An implementaion of various freedesktop specifications in go
+    Copyright (C) 2025 MiracleOS Contributors
+
+    This program is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    This program is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+*/
+
+// Package foreignToplevel lists and controls the windows (toplevels) of other
+// applications on Wayland compositors, for taskbars, docks and window switchers.
+package foreignToplevel
+
+import (
+	"errors"
+	"sync"
+
+	"github.com/MiracleOS-Team/libxdg-go/wayland"
+)
+
+var (
+	// ErrUnsupported is returned when the compositor doesn't offer a toplevel protocol,
+	// or when the protocol in use can't perform an action.
+	ErrUnsupported = errors.New("compositor does not support foreign toplevel management")
+	// ErrToplevelGone is returned for actions on toplevels that were closed.
+	ErrToplevelGone = errors.New("toplevel no longer exists")
+	// ErrWorkspaceGone is returned for actions on workspaces that were removed.
+	ErrWorkspaceGone = errors.New("workspace no longer exists")
+	// ErrNoSeat is returned when activating a toplevel without any seat to do it with.
+	ErrNoSeat = errors.New("no seat available")
+)
+
+// State is the set of states a toplevel is in.
+type State uint32
+
+// States reported by the compositor.
+const (
+	StateMaximized State = 1 << iota
+	StateMinimized
+	StateActivated
+	StateFullscreen
+	// StateFloating is only reported by compositor IPC backends of tiling compositors.
+	StateFloating
+)
+
+// Has reports whether all states in flags are set.
+func (s State) Has(flags State) bool {
+	return s&flags == flags
+}
+
+// Rect is a rectangle in compositor coordinates.
+type Rect struct {
+	X, Y, Width, Height int
+}
+
+// Toplevel is a snapshot of a window of another application.
+type Toplevel struct {
+	// ID identifies the toplevel for as long as the Client or Backend that
+	// reported it is open, even among windows with the same app ID and title.
+	ID    uint64
+	AppID string
+	Title string
+	State State
+	// Outputs are the names of the outputs the toplevel is shown on.
+	Outputs []string
+	// Identifier is a unique, stable identifier of the toplevel. Only the
+	// ext-foreign-toplevel-list protocol provides it.
+	Identifier string
+	// Parent is the ID of the toplevel this one is a dialog or child window of, or 0.
+	Parent uint64
+	// Workspace is the name of the workspace the toplevel is on. The Wayland
+	// protocols don't tell; it is "" unless the backend knows it.
+	Workspace string
+	// Geometry is the position and size of the toplevel. The Wayland protocols
+	// don't provide it; it is nil unless the backend knows it.
+	Geometry *Rect
+
+	handle uint32
+}
+
+// Client is a connection to the compositor keeping the list of toplevels up to date.
+// It uses wlr-foreign-toplevel-management when the compositor offers it, since it
+// allows controlling toplevels, and the read-only ext-foreign-toplevel-list otherwise.
+type Client struct {
+	conn       *wayland.Conn
+	manager    *wayland.Object
+	activation *wayland.Object
+
+	mu        sync.Mutex
+	toplevels map[uint32]*toplevel
+	order     []uint32
+	outputs   map[uint32]*output
+	seats     map[uint32]*seat
+	bound     map[uint32]bool
+	watchers  []*watcher[ToplevelEvent]
+	lastID    uint64
+	// activations counts activations, to order toplevels by focus.
+	activations uint64
+	seatFocus   map[string]uint64
+
+	workspaceManager  *wayland.Object
+	workspaces        []*workspace
+	workspaceWatchers []*watcher[[]Workspace]
+
+	mirror        *extMirror
+	sourceManager *wayland.Object
+	copyManager   *wayland.Object
+	shm           *wayland.Object
+}
+
+// Connect connects to the compositor and retrieves the current toplevels.
+func Connect() (*Client, error) {
+	return connect("")
+}
+
+// connect connects using the protocol interface iface, or the best one offered if empty.
+func connect(iface string) (*Client, error) {
+	conn, err := wayland.Connect()
+	if err != nil {
+		return nil, err
+	}
+	c := &Client{
+		conn:      conn,
+		toplevels: make(map[uint32]*toplevel),
+		outputs:   make(map[uint32]*output),
+		seats:     make(map[uint32]*seat),
+		bound:     make(map[uint32]bool),
+		seatFocus: make(map[string]uint64),
+	}
+
+	var global wayland.Global
+	found := false
+	version, handler := uint32(wlrManagerVersion), c.handleManager
+	if iface == "" || iface == wlrManagerInterface {
+		global, found = conn.FindGlobal(wlrManagerInterface)
+	}
+	if !found && (iface == "" || iface == extListInterface) {
+		global, found = conn.FindGlobal(extListInterface)
+		version, handler = extListVersion, c.handleExtList
+	}
+	if !found {
+		conn.Close()
+		return nil, ErrUnsupported
+	}
+
+	conn.OnGlobal(c.globalAdded, c.globalRemoved)
+	for _, g := range conn.Globals() {
+		c.globalAdded(g)
+	}
+
+	c.manager, err = conn.Bind(global, version, handler)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	if err := c.bindWorkspaces(); err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	// The first roundtrip announces the toplevels, the second delivers their state.
+	for i := 0; i < 2; i++ {
+		if err := conn.Roundtrip(); err != nil {
+			conn.Close()
+			return nil, err
+		}
+	}
+	return c, nil
+}
+
+// Close disconnects from the compositor.
+func (c *Client) Close() error {
+	return c.conn.Close()
+}
+
+// Protocol returns the name of the protocol interface in use.
+func (c *Client) Protocol() string {
+	return c.manager.Interface()
+}
+
+// Toplevels returns the current toplevels, oldest first.
+func (c *Client) Toplevels() []Toplevel {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	toplevels := make([]Toplevel, 0, len(c.order))
+	for _, id := range c.order {
+		if t := c.toplevels[id]; t.ready {
+			toplevels = append(toplevels, t.snapshot())
+		}
+	}
+	return toplevels
+}
+
+// ToplevelByID returns the toplevel with the given ID, if it is still open.
+func (c *Client) ToplevelByID(id uint64) (Toplevel, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, t := range c.toplevels {
+		if t.ready && t.current.ID == id {
+			return t.snapshot(), true
+		}
+	}
+	return Toplevel{}, false
+}
+
+// Activate focuses a toplevel on the default seat, unminimizing it if needed.
+// Without the wlr protocol or a seat, it falls back to D-Bus activating the
+// toplevel's application with an xdg-activation token, which focuses its window
+// if the application supports it.
+func (c *Client) Activate(t Toplevel) error {
+	return c.ActivateOnSeat(t, "")
+}
+
+// handleLocked returns the protocol object of a toplevel that is still open.
+// It fails with ErrUnsupported on the read-only ext protocol.
+// c.mu must be held.
+func (c *Client) handleLocked(t Toplevel) (*wayland.Object, error) {
+	if c.manager.Interface() != wlrManagerInterface {
+		return nil, ErrUnsupported
+	}
+	current, exists := c.toplevels[t.handle]
+	if !exists || current.current.ID != t.ID {
+		return nil, ErrToplevelGone
+	}
+	return current.handle, nil
+}
+
+// ListToplevels connects to the compositor just long enough to list the toplevels.
+func ListToplevels() ([]Toplevel, error) {
+	c, err := Connect()
+	if err != nil {
+		return nil, err
+	}
+	defer c.Close()
+
+	return c.Toplevels(), nil
+}
+
+// FocusToplevel focuses the first toplevel with the given app ID and title.
+// An empty title matches any.
+func FocusToplevel(appID, title string) error {
+	c, err := Connect()
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+
+	for _, t := range c.Toplevels() {
+		if t.AppID == appID && (title == "" || t.Title == title) {
+			return c.Activate(t)
+		}
+	}
+	return ErrToplevelGone
+}
@@ -0,0 +1,237 @@
+/*
+	libxdg-go - An implementaion of various freedesktop specifications in go
+    Copyright (C) 2025 MiracleOS Contributors
+
+    This program is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    This program is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+*/
+
+package foreignToplevel
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/MiracleOS-Team/libxdg-go/wayland"
+)
+
+const (
+	outputInterface = "wl_output"
+	outputVersion   = 4
+	seatInterface   = "wl_seat"
+	seatVersion     = 2
+
+	// wl_output events.
+	outputGeometry    = 0
+	outputName        = 4
+	outputDescription = 5
+
+	// wl_seat events.
+	seatName = 1
+)
+
+// Output is a monitor toplevels can be shown on.
+type Output struct {
+	// Name is the compositor's name for the output, e.g. "DP-1". Compositors
+	// implementing wl_output older than version 4 don't provide one, "make model"
+	// is used instead.
+	Name        string
+	Description string
+	Make        string
+	Model       string
+}
+
+// output is a bound wl_output.
+type output struct {
+	obj     *wayland.Object
+	global  uint32
+	version uint32
+	name    string
+	info    Output
+}
+
+// seat is a bound wl_seat.
+type seat struct {
+	obj    *wayland.Object
+	global uint32
+	name   string
+}
+
+// globalAdded binds the outputs and seats toplevels refer to.
+func (c *Client) globalAdded(g wayland.Global) {
+	if g.Interface != outputInterface && g.Interface != seatInterface {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.bound[g.Name] {
+		return
+	}
+	c.bound[g.Name] = true
+
+	switch g.Interface {
+	case outputInterface:
+		o := &output{global: g.Name, version: min(outputVersion, g.Version), name: fmt.Sprintf("output-%d", g.Name)}
+		obj, err := c.conn.Bind(g, outputVersion, func(e *wayland.Event) { c.handleOutput(o, e) })
+		if err != nil {
+			return
+		}
+		o.obj = obj
+		c.outputs[obj.ID()] = o
+	case seatInterface:
+		s := &seat{global: g.Name}
+		obj, err := c.conn.Bind(g, seatVersion, func(e *wayland.Event) { c.handleSeat(s, e) })
+		if err != nil {
+			return
+		}
+		s.obj = obj
+		c.seats[obj.ID()] = s
+	}
+}
+
+// globalRemoved forgets unplugged outputs and removed seats.
+func (c *Client) globalRemoved(g wayland.Global) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	delete(c.bound, g.Name)
+	for id, o := range c.outputs {
+		if o.global == g.Name {
+			delete(c.outputs, id)
+			o.obj.Forget()
+			c.dropOutputLocked(id)
+		}
+	}
+	for id, s := range c.seats {
+		if s.global == g.Name {
+			delete(c.seats, id)
+			s.obj.Forget()
+		}
+	}
+}
+
+func (c *Client) handleOutput(o *output, e *wayland.Event) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	switch e.Opcode {
+	case outputGeometry:
+		// Outputs older than version 4 have no name; make and model will do.
+		e.Int()
+		e.Int()
+		e.Int()
+		e.Int()
+		e.Int()
+		o.info.Make, o.info.Model = e.String(), e.String()
+		if o.version < 4 {
+			o.name = o.info.Make + " " + o.info.Model
+		}
+	case outputName:
+		o.name = e.String()
+	case outputDescription:
+		o.info.Description = e.String()
+	}
+}
+
+// Outputs returns the outputs known to the compositor, sorted by name.
+func (c *Client) Outputs() []Output {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	outputs := make([]Output, 0, len(c.outputs))
+	for _, o := range c.outputs {
+		info := o.info
+		info.Name = o.name
+		outputs = append(outputs, info)
+	}
+	sort.Slice(outputs, func(i, j int) bool { return outputs[i].Name < outputs[j].Name })
+	return outputs
+}
+
+// ToplevelsOn returns the toplevels shown on the named output, oldest first.
+func (c *Client) ToplevelsOn(output string) []Toplevel {
+	var toplevels []Toplevel
+	for _, t := range c.Toplevels() {
+		if t.OnOutput(output) {
+			toplevels = append(toplevels, t)
+		}
+	}
+	return toplevels
+}
+
+// OnOutput reports whether the toplevel is shown on the named output.
+func (t Toplevel) OnOutput(output string) bool {
+	for _, name := range t.Outputs {
+		if name == output {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *Client) handleSeat(s *seat, e *wayland.Event) {
+	if e.Opcode == seatName {
+		c.mu.Lock()
+		s.name = e.String()
+		c.mu.Unlock()
+	}
+}
+
+// dropOutputLocked removes an unplugged output from the toplevels that were on
+// it and from the workspaces.
+// c.mu must be held.
+func (c *Client) dropOutputLocked(id uint32) {
+	for _, w := range c.workspaces {
+		if w.group != nil && w.group.outputs[id] {
+			delete(w.group.outputs, id)
+			w.current.Outputs = c.outputNamesLocked(w.group.outputs)
+		}
+	}
+	for _, t := range c.toplevels {
+		if !t.outputs[id] {
+			continue
+		}
+		delete(t.outputs, id)
+		t.pending.Outputs = c.outputNamesLocked(t.outputs)
+		if t.ready {
+			c.commitLocked(t)
+		}
+	}
+}
+
+// outputNamesLocked returns the sorted names of outputs given by object ID.
+// c.mu must be held.
+func (c *Client) outputNamesLocked(ids map[uint32]bool) []string {
+	names := make([]string, 0, len(ids))
+	for id := range ids {
+		if o, exists := c.outputs[id]; exists {
+			names = append(names, o.name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// defaultSeatLocked returns the seat with the lowest global name, usually "seat0".
+// c.mu must be held.
+func (c *Client) defaultSeatLocked() *seat {
+	var found *seat
+	for _, s := range c.seats {
+		if found == nil || s.global < found.global {
+			found = s
+		}
+	}
+	return found
+}
@@ -0,0 +1,102 @@
+/*
+	libxdg-go - An implementaion of various freedesktop specifications in go
+    Copyright (C) 2025 MiracleOS Contributors
+
+    This program is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    This program is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+*/
+
+package foreignToplevel
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+var stateNames = []struct {
+	state State
+	name  string
+}{
+	{StateActivated, "activated"},
+	{StateMaximized, "maximized"},
+	{StateMinimized, "minimized"},
+	{StateFullscreen, "fullscreen"},
+	{StateFloating, "floating"},
+}
+
+// Names returns the names of the states that are set, e.g. ["activated", "maximized"].
+func (s State) Names() []string {
+	names := []string{}
+	for _, n := range stateNames {
+		if s.Has(n.state) {
+			names = append(names, n.name)
+		}
+	}
+	return names
+}
+
+// String returns the states that are set joined by "|", or "normal" if none is.
+func (s State) String() string {
+	if s == 0 {
+		return "normal"
+	}
+	return strings.Join(s.Names(), "|")
+}
+
+// MarshalJSON encodes the state as the list of its names.
+func (s State) MarshalJSON() ([]byte, error) {
+	return json.Marshal(s.Names())
+}
+
+// UnmarshalJSON decodes a state encoded by MarshalJSON.
+func (s *State) UnmarshalJSON(data []byte) error {
+	var names []string
+	if err := json.Unmarshal(data, &names); err != nil {
+		return err
+	}
+	*s = 0
+	for _, name := range names {
+		for _, n := range stateNames {
+			if n.name == name {
+				*s |= n.state
+			}
+		}
+	}
+	return nil
+}
+
+// Activated reports whether the toplevel has keyboard focus.
+func (t Toplevel) Activated() bool {
+	return t.State.Has(StateActivated)
+}
+
+// Maximized reports whether the toplevel is maximized.
+func (t Toplevel) Maximized() bool {
+	return t.State.Has(StateMaximized)
+}
+
+// Minimized reports whether the toplevel is minimized.
+func (t Toplevel) Minimized() bool {
+	return t.State.Has(StateMinimized)
+}
+
+// Fullscreen reports whether the toplevel is fullscreen.
+func (t Toplevel) Fullscreen() bool {
+	return t.State.Has(StateFullscreen)
+}
+
+// Floating reports whether the toplevel floats above the tiled ones.
+func (t Toplevel) Floating() bool {
+	return t.State.Has(StateFloating)
+}
@@ -0,0 +1,338 @@
+/*
+	libxdg-go - An implementaion of various freedesktop specifications in go
+    Copyright (C) 2025 MiracleOS Contributors
+
+    This program is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    This program is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+*/
+
+package foreignToplevel
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"os"
+	"strconv"
+	"sync"
+)
+
+// BackendSway uses the IPC socket of sway (and i3-compatible compositors).
+const BackendSway = "sway"
+
+// Sway IPC message types.
+const (
+	swayRunCommand    = 0
+	swayGetWorkspaces = 1
+	swaySubscribe     = 2
+	swayGetTree       = 4
+)
+
+var swayMagic = []byte("i3-ipc")
+
+func init() {
+	RegisterBackend(BackendSway, func() (Backend, error) { return OpenSway() })
+}
+
+// SwayBackend lists and controls toplevels through the sway IPC. Unlike the
+// Wayland protocols, it knows the workspace, geometry and floating state of
+// windows. Toplevels in the scratchpad are reported minimized, and there is no
+// maximized state.
+type SwayBackend struct {
+	*ipcState
+
+	path   string
+	mu     sync.Mutex
+	conn   net.Conn
+	events net.Conn
+}
+
+// OpenSway connects to the sway IPC socket given by SWAYSOCK.
+func OpenSway() (*SwayBackend, error) {
+	path := os.Getenv("SWAYSOCK")
+	if path == "" {
+		return nil, ErrUnsupported
+	}
+	conn, err := net.Dial("unix", path)
+	if err != nil {
+		return nil, err
+	}
+	events, err := net.Dial("unix", path)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	b := &SwayBackend{ipcState: newIPCState(), path: path, conn: conn, events: events}
+
+	if err := swayWrite(events, swaySubscribe, []byte(`["window","workspace","output"]`)); err != nil {
+		b.Close()
+		return nil, err
+	}
+	if _, _, err := swayRead(events); err != nil {
+		b.Close()
+		return nil, err
+	}
+	if err := b.refresh(); err != nil {
+		b.Close()
+		return nil, err
+	}
+	go b.listen()
+	return b, nil
+}
+
+// Name returns BackendSway.
+func (b *SwayBackend) Name() string {
+	return BackendSway
+}
+
+// Close disconnects from sway.
+func (b *SwayBackend) Close() error {
+	b.events.Close()
+	err := b.conn.Close()
+	b.close()
+	return err
+}
+
+// Activate focuses a toplevel, showing it first if it is in the scratchpad.
+func (b *SwayBackend) Activate(t Toplevel) error {
+	if t.Minimized() {
+		return b.command(t, "scratchpad show")
+	}
+	return b.command(t, "focus")
+}
+
+// CloseToplevel asks a toplevel to close.
+func (b *SwayBackend) CloseToplevel(t Toplevel) error {
+	return b.command(t, "kill")
+}
+
+// SetMinimized moves a toplevel to the scratchpad, or shows it again.
+func (b *SwayBackend) SetMinimized(t Toplevel, minimized bool) error {
+	if minimized {
+		return b.command(t, "move scratchpad")
+	}
+	return b.command(t, "scratchpad show")
+}
+
+// SetMaximized fails with ErrUnsupported: sway has no maximized state.
+func (b *SwayBackend) SetMaximized(t Toplevel, maximized bool) error {
+	return ErrUnsupported
+}
+
+// SetFullscreen makes a toplevel fullscreen or not. Sway fullscreens windows on
+// the output they are on, output is ignored.
+func (b *SwayBackend) SetFullscreen(t Toplevel, fullscreen bool, output string) error {
+	if fullscreen {
+		return b.command(t, "fullscreen enable")
+	}
+	return b.command(t, "fullscreen disable")
+}
+
+// ActivateWorkspace switches to a workspace.
+func (b *SwayBackend) ActivateWorkspace(w Workspace) error {
+	return b.run("workspace " + strconv.Quote(w.Name))
+}
+
+// MoveToWorkspace moves a toplevel to a workspace.
+func (b *SwayBackend) MoveToWorkspace(t Toplevel, w Workspace) error {
+	return b.command(t, "move container to workspace "+strconv.Quote(w.Name))
+}
+
+// command runs a command on a toplevel.
+func (b *SwayBackend) command(t Toplevel, command string) error {
+	if _, exists := b.find(t.ID); !exists {
+		return ErrToplevelGone
+	}
+	return b.run(fmt.Sprintf("[con_id=%d] %s", t.ID, command))
+}
+
+// run runs a sway command.
+func (b *SwayBackend) run(command string) error {
+	var results []struct {
+		Success bool   `json:"success"`
+		Error   string `json:"error"`
+	}
+	if err := b.request(swayRunCommand, []byte(command), &results); err != nil {
+		return err
+	}
+	for _, result := range results {
+		if !result.Success {
+			return fmt.Errorf("sway: %s", result.Error)
+		}
+	}
+	return nil
+}
+
+// request sends a message and decodes the reply.
+func (b *SwayBackend) request(msgType uint32, payload []byte, reply interface{}) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if err := swayWrite(b.conn, msgType, payload); err != nil {
+		return err
+	}
+	_, data, err := swayRead(b.conn)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, reply)
+}
+
+// listen refreshes the state on every event until the connection is closed.
+func (b *SwayBackend) listen() {
+	defer b.close()
+	for {
+		if _, _, err := swayRead(b.events); err != nil {
+			return
+		}
+		if err := b.refresh(); err != nil {
+			slog.Debug("Failed to refresh the sway state", "error", err)
+		}
+	}
+}
+
+// swayNode is a node of the sway tree.
+type swayNode struct {
+	ID             uint64 `json:"id"`
+	Type           string `json:"type"`
+	Name           string `json:"name"`
+	AppID          string `json:"app_id"`
+	PID            int    `json:"pid"`
+	Focused        bool   `json:"focused"`
+	FullscreenMode int    `json:"fullscreen_mode"`
+	Rect           struct {
+		X, Y, Width, Height int
+	} `json:"rect"`
+	WindowProperties *struct {
+		Class string `json:"class"`
+	} `json:"window_properties"`
+	Nodes         []swayNode `json:"nodes"`
+	FloatingNodes []swayNode `json:"floating_nodes"`
+}
+
+type swayWorkspace struct {
+	Num     int    `json:"num"`
+	Name    string `json:"name"`
+	Visible bool   `json:"visible"`
+	Urgent  bool   `json:"urgent"`
+	Output  string `json:"output"`
+}
+
+// refresh queries the tree and the workspaces.
+func (b *SwayBackend) refresh() error {
+	var tree swayNode
+	if err := b.request(swayGetTree, nil, &tree); err != nil {
+		return err
+	}
+	var swayWorkspaces []swayWorkspace
+	if err := b.request(swayGetWorkspaces, nil, &swayWorkspaces); err != nil {
+		return err
+	}
+
+	var toplevels []Toplevel
+	var walk func(n swayNode, output, workspace string, floating bool)
+	walk = func(n swayNode, output, workspace string, floating bool) {
+		switch n.Type {
+		case "output":
+			output = n.Name
+		case "workspace":
+			workspace = n.Name
+		}
+		if n.PID > 0 && (n.Type == "con" || n.Type == "floating_con") {
+			t := Toplevel{
+				ID:         n.ID,
+				AppID:      n.AppID,
+				Title:      n.Name,
+				Identifier: strconv.FormatUint(n.ID, 10),
+				Workspace:  workspace,
+				Geometry:   &Rect{n.Rect.X, n.Rect.Y, n.Rect.Width, n.Rect.Height},
+			}
+			if t.AppID == "" && n.WindowProperties != nil {
+				t.AppID = n.WindowProperties.Class
+			}
+			// The scratchpad lives on the hidden __i3 output.
+			if output == "__i3" {
+				t.State |= StateMinimized
+				t.Workspace = ""
+			} else if output != "" {
+				t.Outputs = []string{output}
+			}
+			if n.Focused {
+				t.State |= StateActivated
+			}
+			if n.FullscreenMode > 0 {
+				t.State |= StateFullscreen
+			}
+			if floating || n.Type == "floating_con" {
+				t.State |= StateFloating
+			}
+			toplevels = append(toplevels, t)
+		}
+		for _, child := range n.Nodes {
+			walk(child, output, workspace, floating)
+		}
+		for _, child := range n.FloatingNodes {
+			walk(child, output, workspace, true)
+		}
+	}
+	walk(tree, "", "", false)
+
+	workspaces := make([]Workspace, 0, len(swayWorkspaces))
+	for _, sw := range swayWorkspaces {
+		w := Workspace{ID: sw.Name, Name: sw.Name, Active: sw.Visible, Urgent: sw.Urgent, Outputs: []string{sw.Output}}
+		if sw.Num >= 0 {
+			w.Coordinates = []uint32{uint32(sw.Num)}
+		}
+		workspaces = append(workspaces, w)
+	}
+
+	b.update(toplevels, workspaces)
+	return nil
+}
+
+func swayWrite(w io.Writer, msgType uint32, payload []byte) error {
+	msg := make([]byte, 0, len(swayMagic)+8+len(payload))
+	msg = append(msg, swayMagic...)
+	msg = binary.NativeEndian.AppendUint32(msg, uint32(len(payload)))
+	msg = binary.NativeEndian.AppendUint32(msg, msgType)
+	msg = append(msg, payload...)
+	_, err := w.Write(msg)
+	return err
+}
+
+func swayRead(r io.Reader) (uint32, []byte, error) {
+	header := make([]byte, len(swayMagic)+8)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return 0, nil, err
+	}
+	if string(header[:len(swayMagic)]) != string(swayMagic) {
+		return 0, nil, errors.New("sway: invalid IPC message")
+	}
+	length := binary.NativeEndian.Uint32(header[len(swayMagic):])
+	msgType := binary.NativeEndian.Uint32(header[len(swayMagic)+4:])
+	payload := make([]byte, length)
+	if _, err := io.ReadFull(r, payload); err != nil {
+		return 0, nil, err
+	}
+	return msgType, payload, nil
+}
+
+var (
+	_ Backend          = (*SwayBackend)(nil)
+	_ WorkspaceBackend = (*SwayBackend)(nil)
+)
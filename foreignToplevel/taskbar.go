@@ -0,0 +1,413 @@
+/*
+	libxdg-go - An implementaion of various freedesktop specifications in go
+    Copyright (C) 2025 MiracleOS Contributors
+
+    This program is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    This program is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+*/
+
+package foreignToplevel
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"slices"
+	"sync"
+
+	"github.com/MiracleOS-Team/libxdg-go/desktopFiles"
+)
+
+// TaskbarEntry is one item of a TaskbarModel: an application, a window, or a
+// pinned application that isn't running.
+type TaskbarEntry struct {
+	// Key identifies the application of the entry: its desktop file ID when
+	// known, else its app ID, else a key unique to the window.
+	Key string
+	// DesktopID is the desktop file ID of the application, or "".
+	DesktopID string
+	Name      string
+	// Icon is the path of the icon, or "" if none was found.
+	Icon   string
+	Pinned bool
+	// Toplevels are the windows of the entry, in the order they opened. Without
+	// grouping, entries have at most one.
+	Toplevels []Toplevel
+}
+
+// Running reports whether the entry has windows.
+func (e TaskbarEntry) Running() bool {
+	return len(e.Toplevels) > 0
+}
+
+// Active reports whether one of the windows of the entry has keyboard focus.
+func (e TaskbarEntry) Active() bool {
+	for _, t := range e.Toplevels {
+		if t.Activated() {
+			return true
+		}
+	}
+	return false
+}
+
+// TaskbarChangeType is the kind of change a TaskbarChange reports.
+type TaskbarChangeType int
+
+const (
+	TaskbarEntryAdded TaskbarChangeType = iota
+	TaskbarEntryRemoved
+	TaskbarEntryChanged
+	TaskbarEntryMoved
+)
+
+// TaskbarChange is a change to the entries of a TaskbarModel. Applying the
+// changes in order to a list keeps it identical to TaskbarModel.Entries.
+type TaskbarChange struct {
+	Type TaskbarChangeType
+	// Index is the position of the entry after the change, or the position it had
+	// for TaskbarEntryRemoved.
+	Index int
+	// From is the previous position of the entry for TaskbarEntryMoved.
+	From  int
+	Entry TaskbarEntry
+}
+
+// TaskbarOptions configures a TaskbarModel.
+type TaskbarOptions struct {
+	// Group puts all windows of an application in one entry.
+	Group bool
+	// Pinned are the desktop file IDs of applications shown even when not running,
+	// in order, before the other entries.
+	Pinned []string
+	// IconSize is the size icons are looked up at. It defaults to 48.
+	IconSize int
+}
+
+// TaskbarModel is the list of entries of a taskbar or dock, kept up to date from
+// a Backend. Entries keep their position until they are removed or moved, new
+// ones are added after the entries of the same application, or at the end.
+type TaskbarModel struct {
+	backend Backend
+	opts    TaskbarOptions
+
+	mu      sync.Mutex
+	entries []*TaskbarEntry
+	changes *watcher[TaskbarChange]
+	done    chan struct{}
+}
+
+// NewTaskbarModel creates a model of the toplevels of backend. It follows the
+// backend until ctx is done or the backend's Watch channel closes.
+func NewTaskbarModel(ctx context.Context, backend Backend, opts TaskbarOptions) *TaskbarModel {
+	if opts.IconSize <= 0 {
+		opts.IconSize = 48
+	}
+	m := &TaskbarModel{
+		backend: backend,
+		opts:    opts,
+		changes: newWatcher[TaskbarChange](),
+		done:    make(chan struct{}),
+	}
+	for _, id := range opts.Pinned {
+		if !m.hasKeyLocked(id) {
+			m.entries = append(m.entries, m.pinnedEntry(id))
+		}
+	}
+	// Watching first means a toplevel closing meanwhile is either listed and then
+	// reported closed, or not listed at all.
+	events := backend.Watch(ctx)
+	for _, t := range backend.Toplevels() {
+		m.addLocked(t, false)
+	}
+	go m.changes.run(ctx, m.done)
+	go func() {
+		defer close(m.done)
+		for event := range events {
+			m.handle(event)
+		}
+	}()
+	return m
+}
+
+// Entries returns the current entries.
+func (m *TaskbarModel) Entries() []TaskbarEntry {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	entries := make([]TaskbarEntry, len(m.entries))
+	for i, e := range m.entries {
+		entries[i] = e.snapshot()
+	}
+	return entries
+}
+
+// Changes returns the channel of changes made after NewTaskbarModel returned. There
+// is a single channel per model, closed when the model stops following the backend.
+func (m *TaskbarModel) Changes() <-chan TaskbarChange {
+	return m.changes.ch
+}
+
+// Done is closed when the model stops following the backend.
+func (m *TaskbarModel) Done() <-chan struct{} {
+	return m.done
+}
+
+// Backend returns the backend of the model, to act on the toplevels of entries.
+func (m *TaskbarModel) Backend() Backend {
+	return m.backend
+}
+
+// Pinned returns the desktop file IDs of the pinned entries, in order.
+func (m *TaskbarModel) Pinned() []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var pinned []string
+	for _, e := range m.entries {
+		if e.Pinned {
+			pinned = append(pinned, e.Key)
+		}
+	}
+	return pinned
+}
+
+// Pin pins the application with the given desktop file ID. It is added at the
+// end unless it already has an entry.
+func (m *TaskbarModel) Pin(desktopID string) error {
+	if _, _, err := desktopFiles.FindDesktopFile(desktopID); err != nil {
+		return err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for i, e := range m.entries {
+		if e.Key == desktopID {
+			if !e.Pinned {
+				e.Pinned = true
+				m.emitLocked(TaskbarEntryChanged, i)
+			}
+			return nil
+		}
+	}
+	m.entries = append(m.entries, m.pinnedEntry(desktopID))
+	m.emitLocked(TaskbarEntryAdded, len(m.entries)-1)
+	return nil
+}
+
+// Unpin unpins the application with the given desktop file ID. Its entries
+// without windows are removed.
+func (m *TaskbarModel) Unpin(desktopID string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for i := 0; i < len(m.entries); i++ {
+		e := m.entries[i]
+		if e.Key != desktopID || !e.Pinned {
+			continue
+		}
+		e.Pinned = false
+		if len(e.Toplevels) == 0 {
+			m.removeEntryLocked(i)
+			i--
+		} else {
+			m.emitLocked(TaskbarEntryChanged, i)
+		}
+	}
+}
+
+// Move moves the entry at position from to position to, e.g. after dragging it.
+func (m *TaskbarModel) Move(from, to int) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if from < 0 || from >= len(m.entries) || to < 0 || to >= len(m.entries) {
+		return fmt.Errorf("taskbar position out of range: %d to %d of %d", from, to, len(m.entries))
+	}
+	if from == to {
+		return nil
+	}
+	e := m.entries[from]
+	m.entries = slices.Delete(m.entries, from, from+1)
+	m.entries = slices.Insert(m.entries, to, e)
+	m.changes.push(TaskbarChange{Type: TaskbarEntryMoved, Index: to, From: from, Entry: e.snapshot()})
+	return nil
+}
+
+func (m *TaskbarModel) handle(event ToplevelEvent) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	t := event.Toplevel
+	switch event.Type {
+	case EventOpened:
+		if i, _ := m.findLocked(t.ID); i >= 0 {
+			m.updateLocked(t)
+		} else {
+			m.addLocked(t, true)
+		}
+	case EventClosed:
+		m.removeLocked(t.ID)
+	default:
+		m.updateLocked(t)
+	}
+}
+
+// addLocked adds a toplevel to the entry of its application, or to a new entry.
+// m.mu must be held.
+func (m *TaskbarModel) addLocked(t Toplevel, emit bool) {
+	key := taskbarKey(t)
+	at := len(m.entries)
+	for i, e := range m.entries {
+		if e.Key != key {
+			continue
+		}
+		if m.opts.Group || len(e.Toplevels) == 0 {
+			e.Toplevels = append(e.Toplevels, t)
+			m.describeLocked(e)
+			if emit {
+				m.emitLocked(TaskbarEntryChanged, i)
+			}
+			return
+		}
+		at = i + 1
+	}
+
+	e := &TaskbarEntry{Key: key, Toplevels: []Toplevel{t}}
+	m.describeLocked(e)
+	m.entries = slices.Insert(m.entries, at, e)
+	if emit {
+		m.emitLocked(TaskbarEntryAdded, at)
+	}
+}
+
+// removeLocked removes a toplevel, and its entry if nothing else keeps it.
+// m.mu must be held.
+func (m *TaskbarModel) removeLocked(id uint64) {
+	i, j := m.findLocked(id)
+	if i < 0 {
+		return
+	}
+	e := m.entries[i]
+	e.Toplevels = slices.Delete(e.Toplevels, j, j+1)
+	if len(e.Toplevels) == 0 && !e.Pinned {
+		m.removeEntryLocked(i)
+		return
+	}
+	m.describeLocked(e)
+	m.emitLocked(TaskbarEntryChanged, i)
+}
+
+// updateLocked replaces a toplevel by a newer snapshot, moving it to another
+// entry if its application changed.
+// m.mu must be held.
+func (m *TaskbarModel) updateLocked(t Toplevel) {
+	i, j := m.findLocked(t.ID)
+	if i < 0 {
+		return
+	}
+	e := m.entries[i]
+	if reflect.DeepEqual(e.Toplevels[j], t) {
+		return
+	}
+	if taskbarKey(t) != e.Key {
+		m.removeLocked(t.ID)
+		m.addLocked(t, true)
+		return
+	}
+	e.Toplevels[j] = t
+	m.describeLocked(e)
+	m.emitLocked(TaskbarEntryChanged, i)
+}
+
+// removeEntryLocked removes the entry at position i.
+// m.mu must be held.
+func (m *TaskbarModel) removeEntryLocked(i int) {
+	e := m.entries[i]
+	m.entries = slices.Delete(m.entries, i, i+1)
+	m.changes.push(TaskbarChange{Type: TaskbarEntryRemoved, Index: i, Entry: e.snapshot()})
+}
+
+// findLocked returns the position of the entry holding a toplevel and the
+// position of the toplevel in it, or -1.
+// m.mu must be held.
+func (m *TaskbarModel) findLocked(id uint64) (int, int) {
+	for i, e := range m.entries {
+		for j, t := range e.Toplevels {
+			if t.ID == id {
+				return i, j
+			}
+		}
+	}
+	return -1, -1
+}
+
+// hasKeyLocked reports whether an entry has the given key.
+// m.mu must be held.
+func (m *TaskbarModel) hasKeyLocked(key string) bool {
+	for _, e := range m.entries {
+		if e.Key == key {
+			return true
+		}
+	}
+	return false
+}
+
+// emitLocked reports a change to the entry at position i.
+// m.mu must be held.
+func (m *TaskbarModel) emitLocked(change TaskbarChangeType, i int) {
+	m.changes.push(TaskbarChange{Type: change, Index: i, Entry: m.entries[i].snapshot()})
+}
+
+// pinnedEntry returns the entry of a pinned application that isn't running.
+func (m *TaskbarModel) pinnedEntry(desktopID string) *TaskbarEntry {
+	e := &TaskbarEntry{Key: desktopID, Pinned: true}
+	m.describeLocked(e)
+	return e
+}
+
+// describeLocked sets the name and icon of an entry, from the desktop file of its
+// application when there is one, else from its first window.
+// m.mu must be held.
+func (m *TaskbarModel) describeLocked(e *TaskbarEntry) {
+	if len(e.Toplevels) > 0 {
+		e.DesktopID = e.Toplevels[0].DesktopID()
+		e.Name = e.Toplevels[0].DisplayName()
+		e.Icon = ResolveIcon(e.Toplevels[0], m.opts.IconSize)
+		return
+	}
+	dfile, _, err := desktopFiles.FindDesktopFile(e.Key)
+	if err != nil {
+		e.DesktopID, e.Name, e.Icon = "", e.Key, ""
+		return
+	}
+	e.DesktopID, e.Name, e.Icon = e.Key, dfile.Name, dfile.Icon
+}
+
+// snapshot returns a copy of the entry that callers can keep.
+func (e *TaskbarEntry) snapshot() TaskbarEntry {
+	s := *e
+	s.Toplevels = slices.Clone(e.Toplevels)
+	return s
+}
+
+// taskbarKey returns the key of the entry a toplevel belongs to.
+func taskbarKey(t Toplevel) string {
+	if id := t.DesktopID(); id != "" {
+		return id
+	}
+	if t.AppID != "" {
+		return t.AppID
+	}
+	return fmt.Sprintf("toplevel-%d", t.ID)
+}
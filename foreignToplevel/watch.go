@@ -0,0 +1,224 @@
+/*
+	libxdg-go - An implementaion of various freedesktop specifications in go
+    Copyright (C) 2025 MiracleOS Contributors
+
+    This program is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    This program is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+*/
+
+package foreignToplevel
+
+import (
+	"context"
+	"slices"
+	"sync"
+)
+
+// EventType is the kind of change a ToplevelEvent reports.
+type EventType int
+
+const (
+	EventOpened EventType = iota
+	EventClosed
+	EventTitleChanged
+	EventAppIDChanged
+	EventStateChanged
+	EventOutputsChanged
+	EventParentChanged
+	EventWorkspaceChanged
+	EventGeometryChanged
+)
+
+// String returns the name of the event type.
+func (t EventType) String() string {
+	switch t {
+	case EventOpened:
+		return "opened"
+	case EventClosed:
+		return "closed"
+	case EventTitleChanged:
+		return "title-changed"
+	case EventAppIDChanged:
+		return "app-id-changed"
+	case EventStateChanged:
+		return "state-changed"
+	case EventOutputsChanged:
+		return "outputs-changed"
+	case EventParentChanged:
+		return "parent-changed"
+	case EventWorkspaceChanged:
+		return "workspace-changed"
+	case EventGeometryChanged:
+		return "geometry-changed"
+	}
+	return "unknown"
+}
+
+// ToplevelEvent is a change to a toplevel.
+type ToplevelEvent struct {
+	Type EventType
+	// Toplevel is the toplevel after the change, or as it was last for EventClosed.
+	Toplevel Toplevel
+	// Previous is the toplevel before the change. It is empty for EventOpened.
+	Previous Toplevel
+}
+
+// watcher queues events for one channel so their producer, e.g. the compositor
+// connection, never waits for a slow consumer.
+type watcher[T any] struct {
+	ch    chan T
+	wake  chan struct{}
+	mu    sync.Mutex
+	queue []T
+}
+
+func newWatcher[T any]() *watcher[T] {
+	return &watcher[T]{
+		ch:   make(chan T),
+		wake: make(chan struct{}, 1),
+	}
+}
+
+// Watch returns a channel of toplevel changes. It starts with an EventOpened for
+// every current toplevel, so no change falls between listing and watching. The
+// channel is closed when ctx is done or the connection to the compositor is lost.
+func (c *Client) Watch(ctx context.Context) <-chan ToplevelEvent {
+	w := newWatcher[ToplevelEvent]()
+
+	c.mu.Lock()
+	for _, id := range c.order {
+		if t := c.toplevels[id]; t.ready {
+			w.push(ToplevelEvent{Type: EventOpened, Toplevel: t.snapshot()})
+		}
+	}
+	c.watchers = append(c.watchers, w)
+	c.mu.Unlock()
+
+	go func() {
+		w.run(ctx, c.conn.Done())
+
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		c.watchers = slices.DeleteFunc(c.watchers, func(other *watcher[ToplevelEvent]) bool { return other == w })
+	}()
+	return w.ch
+}
+
+func (w *watcher[T]) push(event T) {
+	w.mu.Lock()
+	w.queue = append(w.queue, event)
+	w.mu.Unlock()
+
+	select {
+	case w.wake <- struct{}{}:
+	default:
+	}
+}
+
+// run delivers the queued events until ctx or done is done.
+func (w *watcher[T]) run(ctx context.Context, done <-chan struct{}) {
+	defer close(w.ch)
+
+	for {
+		w.mu.Lock()
+		if len(w.queue) == 0 {
+			w.mu.Unlock()
+			select {
+			case <-w.wake:
+				continue
+			case <-ctx.Done():
+				return
+			case <-done:
+				return
+			}
+		}
+		event := w.queue[0]
+		w.queue = w.queue[1:]
+		w.mu.Unlock()
+
+		select {
+		case w.ch <- event:
+		case <-ctx.Done():
+			return
+		case <-done:
+			return
+		}
+	}
+}
+
+// emitLocked hands an event to every watcher.
+// c.mu must be held.
+func (c *Client) emitLocked(event ToplevelEvent) {
+	for _, w := range c.watchers {
+		w.push(event)
+	}
+}
+
+// commitLocked applies the pending state of a toplevel and emits the events
+// describing what changed.
+// c.mu must be held.
+func (c *Client) commitLocked(t *toplevel) {
+	previous := t.snapshot()
+	wasReady := t.ready
+	if t.pending.Activated() && !previous.Activated() {
+		c.activations++
+		t.activatedAt = c.activations
+	}
+	t.current = t.pending
+	t.current.Outputs = append([]string(nil), t.pending.Outputs...)
+	t.ready = true
+	current := t.snapshot()
+
+	if !wasReady {
+		c.emitLocked(ToplevelEvent{Type: EventOpened, Toplevel: current})
+		return
+	}
+	for _, event := range changeEvents(previous, current) {
+		c.emitLocked(event)
+	}
+}
+
+// changeEvents returns the events describing the changes between two snapshots
+// of a toplevel.
+func changeEvents(previous, current Toplevel) []ToplevelEvent {
+	var types []EventType
+	if current.Title != previous.Title {
+		types = append(types, EventTitleChanged)
+	}
+	if current.AppID != previous.AppID {
+		types = append(types, EventAppIDChanged)
+	}
+	if current.State != previous.State {
+		types = append(types, EventStateChanged)
+	}
+	if !slices.Equal(current.Outputs, previous.Outputs) {
+		types = append(types, EventOutputsChanged)
+	}
+	if current.Parent != previous.Parent {
+		types = append(types, EventParentChanged)
+	}
+	if current.Workspace != previous.Workspace {
+		types = append(types, EventWorkspaceChanged)
+	}
+	if (current.Geometry == nil) != (previous.Geometry == nil) ||
+		current.Geometry != nil && *current.Geometry != *previous.Geometry {
+		types = append(types, EventGeometryChanged)
+	}
+
+	events := make([]ToplevelEvent, len(types))
+	for i, eventType := range types {
+		events[i] = ToplevelEvent{Type: eventType, Toplevel: current, Previous: previous}
+	}
+	return events
+}
@@ -0,0 +1,174 @@
+/*
+	libxdg-go - An implementaion of various freedesktop specifications in go
+    Copyright (C) 2025 MiracleOS Contributors
+
+    This program is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    This program is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+*/
+
+package foreignToplevel
+
+import (
+	"github.com/MiracleOS-Team/libxdg-go/wayland"
+)
+
+// zwlr_foreign_toplevel_management_unstable_v1.
+const (
+	wlrManagerInterface = "zwlr_foreign_toplevel_manager_v1"
+	wlrHandleInterface  = "zwlr_foreign_toplevel_handle_v1"
+	wlrManagerVersion   = 3
+
+	// Manager events.
+	wlrManagerToplevel = 0
+	wlrManagerFinished = 1
+
+	// Handle requests.
+	wlrHandleSetMaximized    = 0
+	wlrHandleUnsetMaximized  = 1
+	wlrHandleSetMinimized    = 2
+	wlrHandleUnsetMinimized  = 3
+	wlrHandleActivate        = 4
+	wlrHandleClose           = 5
+	wlrHandleSetRectangle    = 6
+	wlrHandleDestroy         = 7
+	wlrHandleSetFullscreen   = 8
+	wlrHandleUnsetFullscreen = 9
+
+	// Handle events.
+	wlrHandleTitle       = 0
+	wlrHandleAppID       = 1
+	wlrHandleOutputEnter = 2
+	wlrHandleOutputLeave = 3
+	wlrHandleState       = 4
+	wlrHandleDone        = 5
+	wlrHandleClosed      = 6
+	wlrHandleParent      = 7
+
+	// Values of the state array.
+	wlrStateMaximized  = 0
+	wlrStateMinimized  = 1
+	wlrStateActivated  = 2
+	wlrStateFullscreen = 3
+)
+
+// toplevel is the protocol side of a toplevel. Changes accumulate in pending and
+// are applied atomically on the done event.
+type toplevel struct {
+	handle  *wayland.Object
+	current Toplevel
+	pending Toplevel
+	outputs map[uint32]bool
+	ready   bool
+	// activatedAt orders toplevels by when they were last activated.
+	activatedAt uint64
+}
+
+// addToplevel records a toplevel announced by the compositor under a new ID.
+func (c *Client) addToplevel(id uint32, iface string, handler func(t *toplevel, e *wayland.Event)) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.lastID++
+	t := &toplevel{outputs: make(map[uint32]bool)}
+	t.current.ID, t.pending.ID = c.lastID, c.lastID
+	t.current.handle, t.pending.handle = id, id
+	t.handle = c.conn.RegisterObject(id, iface, c.manager.Version(), func(e *wayland.Event) {
+		handler(t, e)
+	})
+	c.toplevels[id] = t
+	c.order = append(c.order, id)
+}
+
+// snapshot returns a copy of the current state that callers can keep.
+func (t *toplevel) snapshot() Toplevel {
+	s := t.current
+	s.Outputs = append([]string(nil), t.current.Outputs...)
+	if s.Geometry != nil {
+		geometry := *s.Geometry
+		s.Geometry = &geometry
+	}
+	return s
+}
+
+func (c *Client) handleManager(e *wayland.Event) {
+	switch e.Opcode {
+	case wlrManagerToplevel:
+		c.addToplevel(e.ObjectID(), wlrHandleInterface, c.handleToplevel)
+	case wlrManagerFinished:
+		c.manager.Forget()
+	}
+}
+
+func (c *Client) handleToplevel(t *toplevel, e *wayland.Event) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	switch e.Opcode {
+	case wlrHandleTitle:
+		t.pending.Title = e.String()
+	case wlrHandleAppID:
+		t.pending.AppID = e.String()
+	case wlrHandleOutputEnter:
+		t.outputs[e.ObjectID()] = true
+	case wlrHandleOutputLeave:
+		delete(t.outputs, e.ObjectID())
+	case wlrHandleState:
+		t.pending.State = parseWlrState(e.Uints())
+	case wlrHandleParent:
+		t.pending.Parent = 0
+		if parent, exists := c.toplevels[e.ObjectID()]; exists {
+			t.pending.Parent = parent.current.ID
+		}
+	case wlrHandleDone:
+		t.pending.Outputs = c.outputNamesLocked(t.outputs)
+		c.commitLocked(t)
+	case wlrHandleClosed:
+		c.removeToplevelLocked(t)
+		t.handle.Request(wlrHandleDestroy)
+		t.handle.Forget()
+	}
+}
+
+// removeToplevelLocked drops a closed toplevel.
+// c.mu must be held.
+func (c *Client) removeToplevelLocked(t *toplevel) {
+	if t.ready {
+		c.emitLocked(ToplevelEvent{Type: EventClosed, Toplevel: t.snapshot()})
+	}
+	id := t.handle.ID()
+	delete(c.toplevels, id)
+	for i, other := range c.order {
+		if other == id {
+			c.order = append(c.order[:i], c.order[i+1:]...)
+			break
+		}
+	}
+}
+
+func parseWlrState(values []uint32) State {
+	var state State
+	for _, v := range values {
+		switch v {
+		case wlrStateMaximized:
+			state |= StateMaximized
+		case wlrStateMinimized:
+			state |= StateMinimized
+		case wlrStateActivated:
+			state |= StateActivated
+		case wlrStateFullscreen:
+			state |= StateFullscreen
+		}
+	}
+	return state
+}
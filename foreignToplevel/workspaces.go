@@ -0,0 +1,315 @@
+/*
+	libxdg-go - An implementaion of various freedesktop specifications in go
+    Copyright (C) 2025 MiracleOS Contributors
+
+    This program is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    This program is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+*/
+
+package foreignToplevel
+
+import (
+	"context"
+	"slices"
+
+	"github.com/MiracleOS-Team/libxdg-go/wayland"
+)
+
+// ext_workspace_v1.
+const (
+	workspaceManagerInterface = "ext_workspace_manager_v1"
+	workspaceGroupInterface   = "ext_workspace_group_handle_v1"
+	workspaceHandleInterface  = "ext_workspace_handle_v1"
+	workspaceManagerVersion   = 1
+
+	// Manager requests.
+	workspaceManagerCommit = 0
+
+	// Manager events.
+	workspaceManagerGroup     = 0
+	workspaceManagerWorkspace = 1
+	workspaceManagerDone      = 2
+	workspaceManagerFinished  = 3
+
+	// Group requests.
+	workspaceGroupDestroy = 1
+
+	// Group events.
+	workspaceGroupOutputEnter    = 1
+	workspaceGroupOutputLeave    = 2
+	workspaceGroupWorkspaceEnter = 3
+	workspaceGroupWorkspaceLeave = 4
+	workspaceGroupRemoved        = 5
+
+	// Workspace requests.
+	workspaceDestroy  = 0
+	workspaceActivate = 1
+
+	// Workspace events.
+	workspaceID           = 0
+	workspaceName         = 1
+	workspaceCoordinates  = 2
+	workspaceState        = 3
+	workspaceCapabilities = 4
+	workspaceRemoved      = 5
+
+	// Workspace state and capability bits.
+	workspaceStateActive = 1
+	workspaceStateUrgent = 2
+	workspaceStateHidden = 4
+	workspaceCanActivate = 1
+)
+
+// Workspace is a snapshot of a workspace (virtual desktop).
+type Workspace struct {
+	// ID is a stable identifier of the workspace, if the compositor provides one.
+	ID   string
+	Name string
+	// Coordinates is the position of the workspace in the compositor's layout,
+	// e.g. [column, row] for a grid. It may be empty.
+	Coordinates []uint32
+	Active      bool
+	Urgent      bool
+	Hidden      bool
+	// Outputs are the names of the outputs the workspace can be shown on.
+	Outputs []string
+
+	handle uint32
+}
+
+// WorkspaceBackend is implemented by backends that know about workspaces.
+type WorkspaceBackend interface {
+	Workspaces() []Workspace
+	// WatchWorkspaces returns a channel receiving all workspaces whenever they
+	// change, starting with the current ones.
+	WatchWorkspaces(ctx context.Context) <-chan []Workspace
+	ActivateWorkspace(w Workspace) error
+	// MoveToWorkspace moves a toplevel to a workspace.
+	MoveToWorkspace(t Toplevel, w Workspace) error
+}
+
+// workspace is the protocol side of a workspace.
+type workspace struct {
+	obj          *wayland.Object
+	current      Workspace
+	pending      Workspace
+	capabilities uint32
+	group        *workspaceGroup
+}
+
+// workspaceGroup is a set of workspaces sharing outputs.
+type workspaceGroup struct {
+	obj     *wayland.Object
+	outputs map[uint32]bool
+}
+
+// bindWorkspaces binds the ext-workspace manager, if the compositor offers it.
+func (c *Client) bindWorkspaces() error {
+	global, found := c.conn.FindGlobal(workspaceManagerInterface)
+	if !found {
+		return nil
+	}
+	obj, err := c.conn.Bind(global, workspaceManagerVersion, c.handleWorkspaceManager)
+	if err != nil {
+		return err
+	}
+	c.mu.Lock()
+	c.workspaceManager = obj
+	c.mu.Unlock()
+	return nil
+}
+
+func (c *Client) handleWorkspaceManager(e *wayland.Event) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	switch e.Opcode {
+	case workspaceManagerGroup:
+		g := &workspaceGroup{outputs: make(map[uint32]bool)}
+		g.obj = c.conn.RegisterObject(e.ObjectID(), workspaceGroupInterface, workspaceManagerVersion, func(e *wayland.Event) {
+			c.handleWorkspaceGroup(g, e)
+		})
+	case workspaceManagerWorkspace:
+		w := &workspace{}
+		w.obj = c.conn.RegisterObject(e.ObjectID(), workspaceHandleInterface, workspaceManagerVersion, func(e *wayland.Event) {
+			c.handleWorkspace(w, e)
+		})
+		w.current.handle, w.pending.handle = w.obj.ID(), w.obj.ID()
+		c.workspaces = append(c.workspaces, w)
+	case workspaceManagerDone:
+		for _, w := range c.workspaces {
+			w.current = w.pending
+			w.current.Outputs = nil
+			if w.group != nil {
+				w.current.Outputs = c.outputNamesLocked(w.group.outputs)
+			}
+		}
+		snapshot := c.workspacesLocked()
+		for _, watcher := range c.workspaceWatchers {
+			watcher.push(snapshot)
+		}
+	case workspaceManagerFinished:
+		c.workspaceManager.Forget()
+		c.workspaceManager = nil
+	}
+}
+
+func (c *Client) handleWorkspaceGroup(g *workspaceGroup, e *wayland.Event) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	switch e.Opcode {
+	case workspaceGroupOutputEnter:
+		g.outputs[e.ObjectID()] = true
+	case workspaceGroupOutputLeave:
+		delete(g.outputs, e.ObjectID())
+	case workspaceGroupWorkspaceEnter, workspaceGroupWorkspaceLeave:
+		id := e.ObjectID()
+		for _, w := range c.workspaces {
+			if w.obj.ID() != id {
+				continue
+			}
+			if e.Opcode == workspaceGroupWorkspaceEnter {
+				w.group = g
+			} else if w.group == g {
+				w.group = nil
+			}
+		}
+	case workspaceGroupRemoved:
+		for _, w := range c.workspaces {
+			if w.group == g {
+				w.group = nil
+			}
+		}
+		g.obj.Request(workspaceGroupDestroy)
+		g.obj.Forget()
+	}
+}
+
+func (c *Client) handleWorkspace(w *workspace, e *wayland.Event) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	switch e.Opcode {
+	case workspaceID:
+		w.pending.ID = e.String()
+	case workspaceName:
+		w.pending.Name = e.String()
+	case workspaceCoordinates:
+		w.pending.Coordinates = e.Uints()
+	case workspaceState:
+		state := e.Uint()
+		w.pending.Active = state&workspaceStateActive != 0
+		w.pending.Urgent = state&workspaceStateUrgent != 0
+		w.pending.Hidden = state&workspaceStateHidden != 0
+	case workspaceCapabilities:
+		w.capabilities = e.Uint()
+	case workspaceRemoved:
+		c.workspaces = slices.DeleteFunc(c.workspaces, func(other *workspace) bool { return other == w })
+		w.obj.Request(workspaceDestroy)
+		w.obj.Forget()
+	}
+}
+
+// Workspaces returns the workspaces in the order the compositor announced them.
+// It returns nil if the compositor doesn't implement ext-workspace-v1.
+func (c *Client) Workspaces() []Workspace {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.workspacesLocked()
+}
+
+// workspacesLocked returns a snapshot of the workspaces.
+// c.mu must be held.
+func (c *Client) workspacesLocked() []Workspace {
+	var workspaces []Workspace
+	for _, w := range c.workspaces {
+		s := w.current
+		s.Coordinates = slices.Clone(s.Coordinates)
+		s.Outputs = slices.Clone(s.Outputs)
+		workspaces = append(workspaces, s)
+	}
+	return workspaces
+}
+
+// WatchWorkspaces returns a channel receiving all workspaces whenever they change,
+// starting with the current ones. The channel is closed when ctx is done or the
+// connection to the compositor is lost.
+func (c *Client) WatchWorkspaces(ctx context.Context) <-chan []Workspace {
+	w := newWatcher[[]Workspace]()
+
+	c.mu.Lock()
+	w.push(c.workspacesLocked())
+	c.workspaceWatchers = append(c.workspaceWatchers, w)
+	c.mu.Unlock()
+
+	go func() {
+		w.run(ctx, c.conn.Done())
+
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		c.workspaceWatchers = slices.DeleteFunc(c.workspaceWatchers, func(other *watcher[[]Workspace]) bool { return other == w })
+	}()
+	return w.ch
+}
+
+// ActiveWorkspace returns the active workspace shown on the named output, or the
+// first active workspace if output is empty.
+func (c *Client) ActiveWorkspace(output string) (Workspace, bool) {
+	for _, w := range c.Workspaces() {
+		if w.Active && (output == "" || slices.Contains(w.Outputs, output)) {
+			return w, true
+		}
+	}
+	return Workspace{}, false
+}
+
+// ActivateWorkspace switches to a workspace.
+func (c *Client) ActivateWorkspace(w Workspace) error {
+	c.mu.Lock()
+	var found *workspace
+	for _, other := range c.workspaces {
+		if other.obj.ID() == w.handle {
+			found = other
+		}
+	}
+	manager := c.workspaceManager
+	c.mu.Unlock()
+
+	if manager == nil {
+		return ErrUnsupported
+	}
+	if found == nil {
+		return ErrWorkspaceGone
+	}
+	if found.capabilities&workspaceCanActivate == 0 {
+		return ErrUnsupported
+	}
+	if err := found.obj.Request(workspaceActivate); err != nil {
+		return err
+	}
+	if err := manager.Request(workspaceManagerCommit); err != nil {
+		return err
+	}
+	return c.conn.Roundtrip()
+}
+
+// MoveToWorkspace fails with ErrUnsupported: the Wayland protocols can't assign
+// toplevels to workspaces, only compositor IPC backends can.
+func (c *Client) MoveToWorkspace(t Toplevel, w Workspace) error {
+	return ErrUnsupported
+}
+
+var _ WorkspaceBackend = (*Client)(nil)
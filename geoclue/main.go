@@ -0,0 +1,259 @@
+/*
+	libxdg-go - An implementaion of various freedesktop specifications in go
+    Copyright (C) 2025 MiracleOS Contributors
+
+    This program is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    This program is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+*/
+
+// Package geoclue is a client of GeoClue2, org.freedesktop.GeoClue2, the
+// location service behind features such as automatic night light and time
+// zone suggestions.
+package geoclue
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/MiracleOS-Team/libxdg-go/internal/signals"
+	"github.com/godbus/dbus/v5"
+)
+
+const (
+	busName           = "org.freedesktop.GeoClue2"
+	managerPath       = dbus.ObjectPath("/org/freedesktop/GeoClue2/Manager")
+	managerInterface  = "org.freedesktop.GeoClue2.Manager"
+	clientInterface   = "org.freedesktop.GeoClue2.Client"
+	locationInterface = "org.freedesktop.GeoClue2.Location"
+)
+
+// AccuracyLevel is how precise the requested location is; coarser levels
+// need less permission and power.
+type AccuracyLevel uint32
+
+const (
+	AccuracyNone         AccuracyLevel = 0
+	AccuracyCountry      AccuracyLevel = 1
+	AccuracyCity         AccuracyLevel = 4
+	AccuracyNeighborhood AccuracyLevel = 5
+	AccuracyStreet       AccuracyLevel = 6
+	AccuracyExact        AccuracyLevel = 8
+)
+
+// ErrNoDesktopID is returned for options without a desktop ID, which GeoClue
+// requires to apply its per-application permissions.
+var ErrNoDesktopID = errors.New("geoclue client needs a desktop ID")
+
+// Options configure a Client.
+type Options struct {
+	// DesktopID is the desktop file ID of the application, without ".desktop".
+	DesktopID string
+	// Accuracy is the accuracy to request, AccuracyCity if zero.
+	Accuracy AccuracyLevel
+	// DistanceThreshold is how far, in meters, the location must move for an
+	// update; 0 reports every change.
+	DistanceThreshold uint32
+	// TimeThreshold is the minimum time between updates.
+	TimeThreshold time.Duration
+}
+
+// Location is a position reported by GeoClue. Unknown altitude, speed and
+// heading are negative or -math.MaxFloat64, as GeoClue reports them.
+type Location struct {
+	Latitude  float64
+	Longitude float64
+	// Accuracy is the radius, in meters, around the position the device is in.
+	Accuracy    float64
+	Altitude    float64
+	Speed       float64
+	Heading     float64
+	Description string
+	Timestamp   time.Time
+}
+
+// Client receives location updates from GeoClue over the system bus.
+type Client struct {
+	conn        *dbus.Conn
+	own         bool
+	obj         dbus.BusObject
+	mu          sync.Mutex
+	location    Location
+	hasLocation bool
+	subscribers signals.Subscribers[Location]
+	watch       *signals.Watch
+	closed      bool
+}
+
+// NewClient connects to the system bus and creates a GeoClue client, started
+// by Start.
+func NewClient(options Options) (*Client, error) {
+	conn, err := dbus.ConnectSystemBus()
+	if err != nil {
+		return nil, err
+	}
+	c, err := newClient(conn, true, options)
+	if err != nil {
+		conn.Close()
+	}
+	return c, err
+}
+
+// NewClientWithConn creates a GeoClue client on an existing system bus
+// connection, which is left open by Close.
+func NewClientWithConn(conn *dbus.Conn, options Options) (*Client, error) {
+	return newClient(conn, false, options)
+}
+
+func newClient(conn *dbus.Conn, own bool, options Options) (*Client, error) {
+	if options.DesktopID == "" {
+		return nil, ErrNoDesktopID
+	}
+	if options.Accuracy == AccuracyNone {
+		options.Accuracy = AccuracyCity
+	}
+
+	var path dbus.ObjectPath
+	if err := conn.Object(busName, managerPath).Call(managerInterface+".CreateClient", 0).Store(&path); err != nil {
+		return nil, err
+	}
+	c := &Client{conn: conn, own: own, obj: conn.Object(busName, path)}
+
+	props := []struct {
+		name  string
+		value any
+	}{
+		{"DesktopId", options.DesktopID},
+		{"RequestedAccuracyLevel", uint32(options.Accuracy)},
+		{"DistanceThreshold", options.DistanceThreshold},
+		{"TimeThreshold", uint32(options.TimeThreshold / time.Second)},
+	}
+	for _, p := range props {
+		if err := c.obj.SetProperty(clientInterface+"."+p.name, dbus.MakeVariant(p.value)); err != nil {
+			c.deleteClient()
+			return nil, err
+		}
+	}
+
+	watch, err := signals.NewWatch(conn, 16, c.dispatch, c.match())
+	if err != nil {
+		c.deleteClient()
+		return nil, err
+	}
+	c.watch = watch
+	return c, nil
+}
+
+func (c *Client) match() []dbus.MatchOption {
+	return []dbus.MatchOption{
+		dbus.WithMatchObjectPath(c.obj.Path()),
+		dbus.WithMatchInterface(clientInterface),
+		dbus.WithMatchMember("LocationUpdated"),
+	}
+}
+
+// deleteClient releases the client object of the service.
+func (c *Client) deleteClient() {
+	c.conn.Object(busName, managerPath).Call(managerInterface+".DeleteClient", 0, c.obj.Path())
+}
+
+// Start starts receiving locations. The user may be asked to allow it.
+func (c *Client) Start() error {
+	return c.obj.Call(clientInterface+".Start", 0).Err
+}
+
+// Stop stops receiving locations.
+func (c *Client) Stop() error {
+	return c.obj.Call(clientInterface+".Stop", 0).Err
+}
+
+// Close stops the client and releases it.
+func (c *Client) Close() error {
+	c.mu.Lock()
+	if c.closed {
+		c.mu.Unlock()
+		return nil
+	}
+	c.closed = true
+	c.mu.Unlock()
+
+	c.Stop()
+	c.deleteClient()
+	c.watch.Close()
+	c.subscribers.Close()
+	if c.own {
+		return c.conn.Close()
+	}
+	return nil
+}
+
+// Location returns the last location received, if any.
+func (c *Client) Location() (Location, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.location, c.hasLocation
+}
+
+// Subscribe registers a consumer of location updates. Updates that don't fit
+// in the buffer are dropped. The channel is closed by cancel or by Close.
+func (c *Client) Subscribe(buffer int) (<-chan Location, func()) {
+	return c.subscribers.Subscribe(buffer)
+}
+
+func (c *Client) dispatch(sig *dbus.Signal) {
+	var oldPath, newPath dbus.ObjectPath
+	if sig.Path != c.obj.Path() || dbus.Store(sig.Body, &oldPath, &newPath) != nil {
+		return
+	}
+	location, err := c.fetch(newPath)
+	if err != nil {
+		return
+	}
+
+	c.mu.Lock()
+	c.location, c.hasLocation = location, true
+	c.subscribers.Publish(location)
+	c.mu.Unlock()
+}
+
+// fetch reads a location object.
+func (c *Client) fetch(path dbus.ObjectPath) (Location, error) {
+	var props map[string]dbus.Variant
+	err := c.conn.Object(busName, path).Call("org.freedesktop.DBus.Properties.GetAll", 0, locationInterface).Store(&props)
+	if err != nil {
+		return Location{}, err
+	}
+	get := func(name string, value any) {
+		if v, found := props[name]; found {
+			v.Store(value)
+		}
+	}
+	var l Location
+	var timestamp struct {
+		Seconds      uint64
+		Microseconds uint64
+	}
+	get("Latitude", &l.Latitude)
+	get("Longitude", &l.Longitude)
+	get("Accuracy", &l.Accuracy)
+	get("Altitude", &l.Altitude)
+	get("Speed", &l.Speed)
+	get("Heading", &l.Heading)
+	get("Description", &l.Description)
+	get("Timestamp", &timestamp)
+	if timestamp.Seconds != 0 {
+		l.Timestamp = time.Unix(int64(timestamp.Seconds), int64(timestamp.Microseconds)*1000)
+	}
+	return l, nil
+}
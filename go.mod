@@ -4,7 +4,4 @@ go 1.22.2
 
 require github.com/godbus/dbus/v5 v5.1.0
 
-require (
-	golang.org/x/text v0.21.0 // indirect
-	gopkg.in/ini.v1 v1.67.0 // indirect
-)
+require golang.org/x/text v0.21.0
@@ -0,0 +1,211 @@
+/*
+	libxdg-go - An implementaion of various freedesktop specifications in go
+    Copyright (C) 2025 MiracleOS Contributors
+
+    This program is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    This program is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+*/
+
+// Package hostname is a client of systemd-hostnamed, org.freedesktop.hostname1,
+// which holds the host name, chassis and operating system information settings
+// panels show and edit.
+package hostname
+
+import (
+	"sync"
+
+	"github.com/MiracleOS-Team/libxdg-go/internal/signals"
+	"github.com/godbus/dbus/v5"
+)
+
+const (
+	busName       = "org.freedesktop.hostname1"
+	objectPath    = dbus.ObjectPath("/org/freedesktop/hostname1")
+	interfaceName = "org.freedesktop.hostname1"
+)
+
+// Info is the state of hostnamed.
+type Info struct {
+	// Hostname is the kernel host name, StaticHostname the configured one and
+	// PrettyHostname the free-form name shown to users.
+	Hostname       string
+	StaticHostname string
+	PrettyHostname string
+	IconName       string
+	// Chassis is e.g. "desktop", "laptop", "tablet" or "vm".
+	Chassis                   string
+	Deployment                string
+	Location                  string
+	KernelName                string
+	KernelRelease             string
+	KernelVersion             string
+	OperatingSystemPrettyName string
+	OperatingSystemCPEName    string
+	HomeURL                   string
+	HardwareVendor            string
+	HardwareModel             string
+}
+
+// DisplayName returns the name to show for the machine: the pretty host name,
+// else the static one, else the kernel one.
+func (i Info) DisplayName() string {
+	for _, name := range []string{i.PrettyHostname, i.StaticHostname} {
+		if name != "" {
+			return name
+		}
+	}
+	return i.Hostname
+}
+
+// Client talks to hostnamed over the system bus. Setters with interactive set
+// let polkit ask the user for authorization.
+type Client struct {
+	conn        *dbus.Conn
+	own         bool
+	obj         dbus.BusObject
+	subscribers signals.Subscribers[Info]
+	watch       *signals.Watch
+	closeOnce   sync.Once
+}
+
+// NewClient connects to the system bus.
+func NewClient() (*Client, error) {
+	conn, err := dbus.ConnectSystemBus()
+	if err != nil {
+		return nil, err
+	}
+	c, err := newClient(conn, true)
+	if err != nil {
+		conn.Close()
+	}
+	return c, err
+}
+
+// NewClientWithConn creates a client on an existing system bus connection,
+// which is left open by Close.
+func NewClientWithConn(conn *dbus.Conn) (*Client, error) {
+	return newClient(conn, false)
+}
+
+func newClient(conn *dbus.Conn, own bool) (*Client, error) {
+	c := &Client{conn: conn, own: own, obj: conn.Object(busName, objectPath)}
+	watch, err := signals.NewWatch(conn, 16, c.dispatch, c.match())
+	if err != nil {
+		return nil, err
+	}
+	c.watch = watch
+	return c, nil
+}
+
+func (c *Client) match() []dbus.MatchOption {
+	return []dbus.MatchOption{
+		dbus.WithMatchObjectPath(objectPath),
+		dbus.WithMatchInterface("org.freedesktop.DBus.Properties"),
+		dbus.WithMatchMember("PropertiesChanged"),
+		dbus.WithMatchArg(0, interfaceName),
+	}
+}
+
+// Close stops listening to hostnamed.
+func (c *Client) Close() error {
+	var err error
+	c.closeOnce.Do(func() {
+		c.watch.Close()
+		c.subscribers.Close()
+		if c.own {
+			err = c.conn.Close()
+		}
+	})
+	return err
+}
+
+// Subscribe registers a consumer of the changes to hostnamed's state. Changes
+// that don't fit in the buffer are dropped. The channel is closed by cancel or
+// by Close.
+func (c *Client) Subscribe(buffer int) (<-chan Info, func()) {
+	return c.subscribers.Subscribe(buffer)
+}
+
+// dispatch reads the state again on changes, as hostnamed only reports some of
+// the new values.
+func (c *Client) dispatch(*dbus.Signal) {
+	if info, err := c.Info(); err == nil {
+		c.subscribers.Publish(info)
+	}
+}
+
+// Info reads the state of hostnamed.
+func (c *Client) Info() (Info, error) {
+	var props map[string]dbus.Variant
+	if err := c.obj.Call("org.freedesktop.DBus.Properties.GetAll", 0, interfaceName).Store(&props); err != nil {
+		return Info{}, err
+	}
+	get := func(name string, value any) {
+		if v, found := props[name]; found {
+			v.Store(value)
+		}
+	}
+	var i Info
+	get("Hostname", &i.Hostname)
+	get("StaticHostname", &i.StaticHostname)
+	get("PrettyHostname", &i.PrettyHostname)
+	get("IconName", &i.IconName)
+	get("Chassis", &i.Chassis)
+	get("Deployment", &i.Deployment)
+	get("Location", &i.Location)
+	get("KernelName", &i.KernelName)
+	get("KernelRelease", &i.KernelRelease)
+	get("KernelVersion", &i.KernelVersion)
+	get("OperatingSystemPrettyName", &i.OperatingSystemPrettyName)
+	get("OperatingSystemCPEName", &i.OperatingSystemCPEName)
+	get("HomeURL", &i.HomeURL)
+	get("HardwareVendor", &i.HardwareVendor)
+	get("HardwareModel", &i.HardwareModel)
+	return i, nil
+}
+
+// SetHostname sets the transient kernel host name.
+func (c *Client) SetHostname(name string, interactive bool) error {
+	return c.obj.Call(interfaceName+".SetHostname", 0, name, interactive).Err
+}
+
+// SetStaticHostname sets the configured host name.
+func (c *Client) SetStaticHostname(name string, interactive bool) error {
+	return c.obj.Call(interfaceName+".SetStaticHostname", 0, name, interactive).Err
+}
+
+// SetPrettyHostname sets the host name shown to users, e.g. "Living Room PC".
+func (c *Client) SetPrettyHostname(name string, interactive bool) error {
+	return c.obj.Call(interfaceName+".SetPrettyHostname", 0, name, interactive).Err
+}
+
+// SetIconName sets the icon of the machine.
+func (c *Client) SetIconName(icon string, interactive bool) error {
+	return c.obj.Call(interfaceName+".SetIconName", 0, icon, interactive).Err
+}
+
+// SetChassis sets the chassis type; empty lets hostnamed detect it.
+func (c *Client) SetChassis(chassis string, interactive bool) error {
+	return c.obj.Call(interfaceName+".SetChassis", 0, chassis, interactive).Err
+}
+
+// SetDeployment sets the deployment environment, e.g. "production".
+func (c *Client) SetDeployment(deployment string, interactive bool) error {
+	return c.obj.Call(interfaceName+".SetDeployment", 0, deployment, interactive).Err
+}
+
+// SetLocation sets the free-form location of the machine.
+func (c *Client) SetLocation(location string, interactive bool) error {
+	return c.obj.Call(interfaceName+".SetLocation", 0, location, interactive).Err
+}
@@ -156,7 +156,7 @@ func FindIconDefaults(icon string, size, scale int, fallback string) (string, er
 
 	themeMap, err := CacheThemeMap(fmt.Sprintf("%v", basedir.GetXDGDirectory("cache")) + "/libxdg-icons.json")
 	if err != nil {
-		panic(err)
+		return "", err
 	}
 
 	iconp, err := FindIcon(icon, size, scale, themeMap["MiracleOS"], themeMap)
@@ -0,0 +1,258 @@
+/*
+	libxdg-go - An implementaion of various freedesktop specifications in go
+    Copyright (C) 2025 MiracleOS Contributors
+
+    This program is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    This program is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+*/
+
+package icons
+
+import (
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Contexts of the Icon Naming Specification, as used by the Context key of
+// index.theme directories.
+const (
+	ContextActions       = "Actions"
+	ContextAnimations    = "Animations"
+	ContextApplications  = "Applications"
+	ContextCategories    = "Categories"
+	ContextDevices       = "Devices"
+	ContextEmblems       = "Emblems"
+	ContextEmotes        = "Emotes"
+	ContextInternational = "International"
+	ContextMimeTypes     = "MimeTypes"
+	ContextPlaces        = "Places"
+	ContextStatus        = "Status"
+)
+
+// standardNames are the names of the Icon Naming Specification by context. The
+// International context is "flag-" followed by an ISO 3166 country code.
+var standardNames = map[string][]string{
+	ContextActions: {
+		"address-book-new", "application-exit", "appointment-new", "call-start", "call-stop",
+		"contact-new", "document-new", "document-open", "document-open-recent",
+		"document-page-setup", "document-print", "document-print-preview",
+		"document-properties", "document-revert", "document-save", "document-save-as",
+		"document-send", "edit-clear", "edit-copy", "edit-cut", "edit-delete", "edit-find",
+		"edit-find-replace", "edit-paste", "edit-redo", "edit-select-all", "edit-undo",
+		"folder-new", "format-indent-less", "format-indent-more", "format-justify-center",
+		"format-justify-fill", "format-justify-left", "format-justify-right",
+		"format-text-direction-ltr", "format-text-direction-rtl", "format-text-bold",
+		"format-text-italic", "format-text-underline", "format-text-strikethrough",
+		"go-bottom", "go-down", "go-first", "go-home", "go-jump", "go-last", "go-next",
+		"go-previous", "go-top", "go-up", "help-about", "help-contents", "help-faq",
+		"insert-image", "insert-link", "insert-object", "insert-text", "list-add",
+		"list-remove", "mail-forward", "mail-mark-important", "mail-mark-junk",
+		"mail-mark-notjunk", "mail-mark-read", "mail-mark-unread", "mail-message-new",
+		"mail-reply-all", "mail-reply-sender", "mail-send", "mail-send-receive",
+		"media-eject", "media-playback-pause", "media-playback-start", "media-playback-stop",
+		"media-record", "media-seek-backward", "media-seek-forward", "media-skip-backward",
+		"media-skip-forward", "object-flip-horizontal", "object-flip-vertical",
+		"object-rotate-left", "object-rotate-right", "process-stop", "system-lock-screen",
+		"system-log-out", "system-run", "system-search", "system-reboot", "system-shutdown",
+		"tools-check-spelling", "view-fullscreen", "view-refresh", "view-restore",
+		"view-sort-ascending", "view-sort-descending", "window-close", "window-new",
+		"zoom-fit-best", "zoom-in", "zoom-original", "zoom-out",
+	},
+	ContextAnimations: {
+		"process-working",
+	},
+	ContextApplications: {
+		"accessories-calculator", "accessories-character-map", "accessories-dictionary",
+		"accessories-text-editor", "help-browser", "multimedia-volume-control",
+		"preferences-desktop-accessibility", "preferences-desktop-font",
+		"preferences-desktop-keyboard", "preferences-desktop-locale",
+		"preferences-desktop-multimedia", "preferences-desktop-screensaver",
+		"preferences-desktop-theme", "preferences-desktop-wallpaper", "system-file-manager",
+		"system-software-install", "system-software-update", "utilities-system-monitor",
+		"utilities-terminal",
+	},
+	ContextCategories: {
+		"applications-accessories", "applications-development", "applications-engineering",
+		"applications-games", "applications-graphics", "applications-internet",
+		"applications-multimedia", "applications-office", "applications-other",
+		"applications-science", "applications-system", "applications-utilities",
+		"preferences-desktop", "preferences-desktop-peripherals",
+		"preferences-desktop-personal", "preferences-other", "preferences-system",
+		"preferences-system-network", "system-help",
+	},
+	ContextDevices: {
+		"audio-card", "audio-input-microphone", "battery", "camera-photo", "camera-video",
+		"camera-web", "computer", "drive-harddisk", "drive-optical", "drive-removable-media",
+		"input-gaming", "input-keyboard", "input-mouse", "input-tablet", "media-flash",
+		"media-floppy", "media-optical", "media-tape", "modem", "multimedia-player",
+		"network-wired", "network-wireless", "pda", "phone", "printer", "scanner",
+		"video-display",
+	},
+	ContextEmblems: {
+		"emblem-default", "emblem-documents", "emblem-downloads", "emblem-favorite",
+		"emblem-important", "emblem-mail", "emblem-photos", "emblem-readonly",
+		"emblem-shared", "emblem-symbolic-link", "emblem-synchronized", "emblem-system",
+		"emblem-unreadable",
+	},
+	ContextEmotes: {
+		"face-angel", "face-angry", "face-cool", "face-crying", "face-devilish",
+		"face-embarrassed", "face-kiss", "face-laugh", "face-monkey", "face-plain",
+		"face-raspberry", "face-sad", "face-sick", "face-smile", "face-smile-big",
+		"face-smirk", "face-surprise", "face-tired", "face-uncertain", "face-wink",
+		"face-worried",
+	},
+	ContextMimeTypes: {
+		"application-x-executable", "audio-x-generic", "font-x-generic", "image-x-generic",
+		"package-x-generic", "text-html", "text-x-generic", "text-x-generic-template",
+		"text-x-script", "video-x-generic", "x-office-address-book", "x-office-calendar",
+		"x-office-document", "x-office-presentation", "x-office-spreadsheet",
+	},
+	ContextPlaces: {
+		"folder", "folder-remote", "network-server", "network-workgroup", "start-here",
+		"user-bookmarks", "user-desktop", "user-home", "user-trash",
+	},
+	ContextStatus: {
+		"appointment-missed", "appointment-soon", "audio-volume-high", "audio-volume-low",
+		"audio-volume-medium", "audio-volume-muted", "battery-caution", "battery-low",
+		"dialog-error", "dialog-information", "dialog-password", "dialog-question",
+		"dialog-warning", "folder-drag-accept", "folder-open", "folder-visiting",
+		"image-loading", "image-missing", "mail-attachment", "mail-unread", "mail-read",
+		"mail-replied", "mail-signed", "mail-signed-verified", "media-playlist-repeat",
+		"media-playlist-shuffle", "network-error", "network-idle", "network-offline",
+		"network-receive", "network-transmit", "network-transmit-receive", "printer-error",
+		"printer-printing", "security-high", "security-medium", "security-low",
+		"software-update-available", "software-update-urgent", "sync-error",
+		"sync-synchronizing", "task-due", "task-past-due", "user-available", "user-away",
+		"user-idle", "user-offline", "user-trash-full", "weather-clear",
+		"weather-clear-night", "weather-few-clouds", "weather-few-clouds-night",
+		"weather-fog", "weather-overcast", "weather-severe-alert", "weather-showers",
+		"weather-showers-scattered", "weather-snow", "weather-storm",
+	},
+}
+
+var (
+	standardContextsOnce sync.Once
+	standardContexts     map[string]string
+)
+
+// contextOf returns the context of a standard name.
+func contextOf(name string) (string, bool) {
+	standardContextsOnce.Do(func() {
+		standardContexts = make(map[string]string)
+		for context, names := range standardNames {
+			for _, n := range names {
+				standardContexts[n] = context
+			}
+		}
+	})
+	if context, exists := standardContexts[name]; exists {
+		return context, true
+	}
+	if code, found := strings.CutPrefix(name, "flag-"); found && len(code) == 2 && code[0] >= 'a' && code[0] <= 'z' && code[1] >= 'a' && code[1] <= 'z' {
+		return ContextInternational, true
+	}
+	return "", false
+}
+
+// Contexts returns the contexts of the Icon Naming Specification.
+func Contexts() []string {
+	return []string{
+		ContextActions, ContextAnimations, ContextApplications, ContextCategories,
+		ContextDevices, ContextEmblems, ContextEmotes, ContextInternational,
+		ContextMimeTypes, ContextPlaces, ContextStatus,
+	}
+}
+
+// StandardNames returns the standard names of a context, sorted. The
+// International context, made of "flag-" names for every country, has none.
+func StandardNames(context string) []string {
+	names := append([]string(nil), standardNames[context]...)
+	sort.Strings(names)
+	return names
+}
+
+// StandardContext returns the context of a standard name, such as
+// ContextActions for "document-open".
+func StandardContext(name string) (string, bool) {
+	return contextOf(name)
+}
+
+// IsStandardName reports whether a name is one of the Icon Naming Specification.
+func IsStandardName(name string) bool {
+	_, standard := contextOf(name)
+	return standard
+}
+
+// StandardFallback returns the standard name a more specific name falls back to
+// by dropping dash-separated parts, as theme lookups do: "input-mouse-usb" falls
+// back to "input-mouse". A "-symbolic" suffix is ignored.
+func StandardFallback(name string) (string, bool) {
+	name = strings.TrimSuffix(name, "-symbolic")
+	for {
+		if IsStandardName(name) {
+			return name, true
+		}
+		i := strings.LastIndexByte(name, '-')
+		if i < 0 {
+			return "", false
+		}
+		name = name[:i]
+	}
+}
+
+// SuggestStandardName returns the standard name closest to a name, for checking
+// icon references: the name itself or its fallback if there is one, else the
+// standard name closest in spelling, if close enough.
+func SuggestStandardName(name string) (string, bool) {
+	name = strings.ReplaceAll(strings.ToLower(strings.TrimSpace(name)), "_", "-")
+	if standard, found := StandardFallback(name); found {
+		return standard, true
+	}
+	name = strings.TrimSuffix(name, "-symbolic")
+	if name == "" {
+		return "", false
+	}
+
+	best, bestDistance := "", len(name)/3+1
+	for _, context := range Contexts() {
+		for _, standard := range StandardNames(context) {
+			if d := editDistance(name, standard); d < bestDistance || d == bestDistance && best != "" && standard < best {
+				best, bestDistance = standard, d
+			}
+		}
+	}
+	return best, best != ""
+}
+
+// editDistance returns the Levenshtein distance between two strings.
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(b)]
+}
@@ -20,88 +20,56 @@
 package icons
 
 import (
-	"bufio"
 	"fmt"
 	"os"
 	"path/filepath"
-	"strconv"
 	"strings"
+
+	"github.com/MiracleOS-Team/libxdg-go/keyfile"
 )
 
 // parseIndexTheme parses the index.theme file and returns a Theme.
 func parseIndexTheme(themeDir string) (Theme, error) {
-	indexPath := filepath.Join(themeDir, "index.theme")
-	file, err := os.Open(indexPath)
+	kf, err := keyfile.LoadLenient(filepath.Join(themeDir, "index.theme"))
 	if err != nil {
-		return Theme{}, fmt.Errorf("failed to open index.theme: %w", err)
+		return Theme{}, fmt.Errorf("failed to read index.theme: %w", err)
 	}
-	defer file.Close()
-
-	var theme Theme
-	theme.BasePath = themeDir
-	currentSection := ""
-	subdirs := make(map[string]Subdir)
 
-	scanner := bufio.NewScanner(file)
-	for scanner.Scan() {
-		line := strings.TrimSpace(scanner.Text())
-		if line == "" || strings.HasPrefix(line, "#") {
-			continue
-		}
-
-		// Handle section headers
-		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
-			currentSection = strings.Trim(line, "[]")
-			continue
-		}
+	theme := Theme{BasePath: themeDir}
+	group := kf.Group("Icon Theme")
+	if group == nil {
+		return theme, nil
+	}
+	theme.Name = group.String("Name")
+	if group.Has("Inherits") {
+		theme.Parents = strings.Split(group.String("Inherits"), ",")
+	}
 
-		// Parse key-value pairs
-		parts := strings.SplitN(line, "=", 2)
-		if len(parts) != 2 {
+	for _, dir := range strings.Split(group.String("Directories"), ",") {
+		if dir == "" {
 			continue
 		}
-		key, value := strings.TrimSpace(parts[0]), strings.TrimSpace(parts[1])
-
-		if currentSection == "Icon Theme" {
-			switch key {
-			case "Name":
-				theme.Name = value
-			case "Inherits":
-				theme.Parents = strings.Split(value, ",")
-			case "Directories":
-				dirNames := strings.Split(value, ",")
-				for _, dir := range dirNames {
-					subdirs[dir] = Subdir{Scale: 1, Type: "Threshold"} // Initialize subdirs
+		subdir := Subdir{PathName: dir, Scale: 1, Type: "Threshold"}
+		if g := kf.Group(dir); g != nil {
+			for _, key := range []struct {
+				name  string
+				value *int
+			}{
+				{"Size", &subdir.Size},
+				{"MinSize", &subdir.MinSize},
+				{"MaxSize", &subdir.MaxSize},
+				{"Scale", &subdir.Scale},
+				{"Threshold", &subdir.Threshold},
+			} {
+				if n, err := g.Int(key.name); err == nil {
+					*key.value = n
 				}
 			}
-		} else if subdir, exists := subdirs[currentSection]; exists {
-			switch key {
-			case "Size":
-				subdir.Size, _ = strconv.Atoi(value)
-			case "MinSize":
-				subdir.MinSize, _ = strconv.Atoi(value)
-			case "MaxSize":
-				subdir.MaxSize, _ = strconv.Atoi(value)
-			case "Scale":
-				subdir.Scale, _ = strconv.Atoi(value)
-			case "Threshold":
-				subdir.Threshold, _ = strconv.Atoi(value)
-			case "Type":
-				subdir.Type = value
-			case "Context":
-				subdir.Context = value
+			if g.Has("Type") {
+				subdir.Type = g.String("Type")
 			}
-			subdir.PathName = currentSection
-			subdirs[currentSection] = subdir
+			subdir.Context = g.String("Context")
 		}
-	}
-
-	if err := scanner.Err(); err != nil {
-		return Theme{}, fmt.Errorf("error reading index.theme: %w", err)
-	}
-
-	// Convert subdirs map to slice
-	for _, subdir := range subdirs {
 		theme.Subdirs = append(theme.Subdirs, subdir)
 	}
 	return theme, nil
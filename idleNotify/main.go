@@ -0,0 +1,223 @@
+/*
+	libxdg-go - An implementaion of various freedesktop specifications in go
+    Copyright (C) 2025 MiracleOS Contributors
+
+    This program is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    This program is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+*/
+
+// Package idleNotify reports when the user has been idle for a while and when
+// they come back, on Wayland compositors implementing ext-idle-notify-v1. It
+// serves auto-away, power management and pausing notification expiration.
+package idleNotify
+
+import (
+	"errors"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/MiracleOS-Team/libxdg-go/wayland"
+)
+
+const (
+	notifierInterface = "ext_idle_notifier_v1"
+	notifierVersion   = 2
+	seatInterface     = "wl_seat"
+	seatVersion       = 1
+
+	// ext_idle_notifier_v1 requests.
+	notifierDestroy                   = 0
+	notifierGetIdleNotification       = 1
+	notifierGetInputIdleNotification  = 2
+	inputIdleNotificationSinceVersion = 2
+
+	// ext_idle_notification_v1 requests and events.
+	notificationDestroy = 0
+	notificationIdled   = 0
+	notificationResumed = 1
+)
+
+var (
+	// ErrUnsupported is returned when the compositor doesn't implement
+	// ext-idle-notify-v1, or the version needed for WatchInput.
+	ErrUnsupported = errors.New("compositor does not support ext-idle-notify-v1")
+	// ErrNoSeat is returned when the compositor has no seat to watch.
+	ErrNoSeat = errors.New("no seat available")
+)
+
+// Client is a connection to the compositor's idle notifier.
+type Client struct {
+	conn     *wayland.Conn
+	notifier *wayland.Object
+	seat     *wayland.Object
+
+	mu      sync.Mutex
+	watches map[*Watch]struct{}
+}
+
+// Connect connects to the compositor and binds its idle notifier and first seat.
+func Connect() (*Client, error) {
+	conn, err := wayland.Connect()
+	if err != nil {
+		return nil, err
+	}
+	c := &Client{conn: conn, watches: make(map[*Watch]struct{})}
+
+	global, found := conn.FindGlobal(notifierInterface)
+	if !found {
+		conn.Close()
+		return nil, ErrUnsupported
+	}
+	seatGlobal, found := conn.FindGlobal(seatInterface)
+	if !found {
+		conn.Close()
+		return nil, ErrNoSeat
+	}
+	if c.notifier, err = conn.Bind(global, notifierVersion, nil); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	if c.seat, err = conn.Bind(seatGlobal, seatVersion, nil); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return c, nil
+}
+
+// Close stops every watch and closes the connection.
+func (c *Client) Close() error {
+	c.mu.Lock()
+	watches := c.watches
+	c.watches = make(map[*Watch]struct{})
+	c.mu.Unlock()
+
+	for w := range watches {
+		w.stop()
+	}
+	c.notifier.Request(notifierDestroy)
+	return c.conn.Close()
+}
+
+// Done is closed once the connection is closed or lost, after which watches
+// report nothing more.
+func (c *Client) Done() <-chan struct{} {
+	return c.conn.Done()
+}
+
+// Err returns the error the connection failed with.
+func (c *Client) Err() error {
+	return c.conn.Err()
+}
+
+// Watch calls idled once the user has been idle for timeout, and resumed when
+// they are active again. The compositor doesn't consider the user idle while an
+// application inhibits idling, e.g. to play a video. The callbacks run on the
+// connection's reading goroutine and must not block.
+func (c *Client) Watch(timeout time.Duration, idled, resumed func()) (*Watch, error) {
+	return c.watch(notifierGetIdleNotification, timeout, idled, resumed)
+}
+
+// WatchInput is like Watch but only considers user input, ignoring idle
+// inhibitors, as auto-away features want. It needs version 2 of the protocol.
+func (c *Client) WatchInput(timeout time.Duration, idled, resumed func()) (*Watch, error) {
+	if c.notifier.Version() < inputIdleNotificationSinceVersion {
+		return nil, ErrUnsupported
+	}
+	return c.watch(notifierGetInputIdleNotification, timeout, idled, resumed)
+}
+
+func (c *Client) watch(request uint16, timeout time.Duration, idled, resumed func()) (*Watch, error) {
+	w := &Watch{c: c, timeout: timeout}
+	w.obj = c.conn.NewObject("ext_idle_notification_v1", func(e *wayland.Event) {
+		switch e.Opcode {
+		case notificationIdled:
+			if w.setIdle(true) && idled != nil {
+				idled()
+			}
+		case notificationResumed:
+			if w.setIdle(false) && resumed != nil {
+				resumed()
+			}
+		}
+	})
+	milliseconds := min(timeout.Milliseconds(), math.MaxUint32)
+	if err := c.notifier.Request(request, w.obj, uint32(max(milliseconds, 0)), c.seat); err != nil {
+		w.obj.Forget()
+		return nil, err
+	}
+
+	c.mu.Lock()
+	c.watches[w] = struct{}{}
+	c.mu.Unlock()
+	return w, nil
+}
+
+// Watch is a timeout being watched, created by Client.Watch or
+// Client.WatchInput.
+type Watch struct {
+	c       *Client
+	obj     *wayland.Object
+	timeout time.Duration
+
+	mu      sync.Mutex
+	idle    bool
+	stopped bool
+}
+
+// Timeout returns the idle time the watch waits for.
+func (w *Watch) Timeout() time.Duration {
+	return w.timeout
+}
+
+// Idle reports whether the user is currently idle for the watch's timeout.
+func (w *Watch) Idle() bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return w.idle
+}
+
+// setIdle records the idle state and reports whether the callbacks should run.
+func (w *Watch) setIdle(idle bool) bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.stopped || w.idle == idle {
+		return false
+	}
+	w.idle = idle
+	return true
+}
+
+// Stop stops watching. No callback runs after it returns, unless one was
+// already running.
+func (w *Watch) Stop() {
+	w.c.mu.Lock()
+	delete(w.c.watches, w)
+	w.c.mu.Unlock()
+	w.stop()
+}
+
+func (w *Watch) stop() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.stopped {
+		return
+	}
+	w.stopped = true
+	w.obj.Request(notificationDestroy)
+	w.obj.Forget()
+}
@@ -0,0 +1,133 @@
+/*
+	libxdg-go - An implementaion of various freedesktop specifications in go
+    Copyright (C) 2025 MiracleOS Contributors
+
+    This program is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    This program is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+*/
+
+// Package signals holds the plumbing shared by the D-Bus clients: the set of
+// consumers subscribed to a client's events, and the watch feeding a client the
+// signals it matched.
+package signals
+
+import (
+	"sync"
+
+	"github.com/godbus/dbus/v5"
+)
+
+// Subscribers is the set of consumers of events of type T. The zero value is
+// ready to use.
+type Subscribers[T any] struct {
+	mu       sync.Mutex
+	channels map[chan T]struct{}
+	closed   bool
+}
+
+// Subscribe registers a consumer. Events that don't fit in the buffer are
+// dropped. The channel is closed by cancel or by Close.
+func (s *Subscribers[T]) Subscribe(buffer int) (<-chan T, func()) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	events := make(chan T, buffer)
+	if s.closed {
+		close(events)
+		return events, func() {}
+	}
+	if s.channels == nil {
+		s.channels = make(map[chan T]struct{})
+	}
+	s.channels[events] = struct{}{}
+
+	cancel := func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+
+		if _, exists := s.channels[events]; exists {
+			delete(s.channels, events)
+			close(events)
+		}
+	}
+	return events, cancel
+}
+
+// Publish hands an event to the consumers whose buffer has room for it.
+func (s *Subscribers[T]) Publish(event T) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for events := range s.channels {
+		select {
+		case events <- event:
+		default:
+		}
+	}
+}
+
+// Close closes the channels of the consumers. Consumers subscribing afterwards
+// get a closed channel.
+func (s *Subscribers[T]) Close() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.closed = true
+	for events := range s.channels {
+		close(events)
+		delete(s.channels, events)
+	}
+}
+
+// Watch hands the signals received on a connection to a handler, on its own
+// goroutine, until it is closed.
+type Watch struct {
+	conn    *dbus.Conn
+	rules   [][]dbus.MatchOption
+	signals chan *dbus.Signal
+	once    sync.Once
+}
+
+// NewWatch adds match rules to a connection and calls handle with the signals
+// received on it, buffering up to buffer of them. godbus hands every signal of
+// the connection to every watch, so handle must ignore those it didn't match.
+func NewWatch(conn *dbus.Conn, buffer int, handle func(sig *dbus.Signal), rules ...[]dbus.MatchOption) (*Watch, error) {
+	for i, rule := range rules {
+		if err := conn.AddMatchSignal(rule...); err != nil {
+			for _, added := range rules[:i] {
+				conn.RemoveMatchSignal(added...)
+			}
+			return nil, err
+		}
+	}
+	w := &Watch{conn: conn, rules: rules, signals: make(chan *dbus.Signal, buffer)}
+	conn.Signal(w.signals)
+	go func() {
+		for sig := range w.signals {
+			handle(sig)
+		}
+	}()
+	return w, nil
+}
+
+// Close removes the match rules and stops handing signals to the handler.
+func (w *Watch) Close() {
+	w.once.Do(func() {
+		w.conn.RemoveSignal(w.signals)
+		for _, rule := range w.rules {
+			w.conn.RemoveMatchSignal(rule...)
+		}
+		close(w.signals)
+	})
+}
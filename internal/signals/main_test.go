@@ -0,0 +1,48 @@
+/*
+	libxdg-go - An implementaion of various freedesktop specifications in go
+    Copyright (C) 2025 MiracleOS Contributors
+
+    This program is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    This program is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+*/
+
+package signals
+
+import "testing"
+
+func TestSubscribers(t *testing.T) {
+	var s Subscribers[int]
+	events, cancel := s.Subscribe(1)
+	s.Publish(1)
+	s.Publish(2) // dropped: the buffer is full
+	if got := <-events; got != 1 {
+		t.Fatalf("got %d, want 1", got)
+	}
+	cancel()
+	if _, ok := <-events; ok {
+		t.Fatal("channel still open after cancel")
+	}
+	cancel()
+
+	events, _ = s.Subscribe(1)
+	s.Close()
+	if _, ok := <-events; ok {
+		t.Fatal("channel still open after Close")
+	}
+	events, _ = s.Subscribe(1)
+	if _, ok := <-events; ok {
+		t.Fatal("channel open after Close")
+	}
+	s.Publish(3)
+}
@@ -0,0 +1,134 @@
+/*
+	libxdg-go - An implementaion of various freedesktop specifications in go
+    Copyright (C) 2025 MiracleOS Contributors
+
+    This program is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    This program is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+*/
+
+package keyfile
+
+import "strings"
+
+// unescape decodes the escapes of a value. In lists, "\;" stands for a
+// separator within an item. Unknown escapes, which GKeyFile rejects, are kept
+// as they are: they are common in Exec values, which have their own quoting.
+func unescape(value string, list bool) string {
+	if !strings.Contains(value, `\`) {
+		return value
+	}
+	var b strings.Builder
+	for i := 0; i < len(value); i++ {
+		if value[i] != '\\' || i+1 == len(value) {
+			b.WriteByte(value[i])
+			continue
+		}
+		i++
+		switch value[i] {
+		case 's':
+			b.WriteByte(' ')
+		case 'n':
+			b.WriteByte('\n')
+		case 't':
+			b.WriteByte('\t')
+		case 'r':
+			b.WriteByte('\r')
+		case '\\':
+			b.WriteByte('\\')
+		case ';':
+			if list {
+				b.WriteByte(';')
+			} else {
+				b.WriteString(`\;`)
+			}
+		default:
+			b.WriteByte('\\')
+			b.WriteByte(value[i])
+		}
+	}
+	return b.String()
+}
+
+// escape encodes a value for writing: leading spaces, line breaks, tabs and
+// backslashes, and in list items separators.
+func escape(value string, list bool) string {
+	var b strings.Builder
+	leading := true
+	for i := 0; i < len(value); i++ {
+		c := value[i]
+		switch {
+		case c == ' ' && leading:
+			b.WriteString(`\s`)
+			continue
+		case c == '\n':
+			b.WriteString(`\n`)
+		case c == '\t':
+			b.WriteString(`\t`)
+		case c == '\r':
+			b.WriteString(`\r`)
+		case c == '\\':
+			b.WriteString(`\\`)
+		case c == ';' && list:
+			b.WriteString(`\;`)
+		default:
+			b.WriteByte(c)
+		}
+		leading = false
+	}
+	return b.String()
+}
+
+// splitList splits a list value on its unescaped separators and unescapes the
+// items. The separator after the last item is optional.
+func splitList(value string) []string {
+	items := []string{}
+	start := 0
+	for i := 0; i < len(value); i++ {
+		switch value[i] {
+		case '\\':
+			i++
+		case ';':
+			items = append(items, unescape(value[start:i], true))
+			start = i + 1
+		}
+	}
+	if start < len(value) {
+		items = append(items, unescape(value[start:], true))
+	}
+	return items
+}
+
+// LocaleVariants returns the locale names a value for a locale such as
+// "fr_FR.UTF-8@euro" is looked up under, most specific first: "fr_FR@euro",
+// "fr_FR", "fr@euro" and "fr". The encoding is ignored.
+func LocaleVariants(locale string) []string {
+	locale, modifier, _ := strings.Cut(locale, "@")
+	locale, _, _ = strings.Cut(locale, ".")
+	lang, country, _ := strings.Cut(locale, "_")
+	if lang == "" || lang == "C" || lang == "POSIX" {
+		return nil
+	}
+
+	var variants []string
+	if country != "" {
+		if modifier != "" {
+			variants = append(variants, lang+"_"+country+"@"+modifier)
+		}
+		variants = append(variants, lang+"_"+country)
+	}
+	if modifier != "" {
+		variants = append(variants, lang+"@"+modifier)
+	}
+	return append(variants, lang)
+}
@@ -0,0 +1,110 @@
+/*
+	libxdg-go - An implementaion of various freedesktop specifications in go
+    Copyright (C) 2025 MiracleOS Contributors
+
+    This program is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    This program is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+*/
+
+package keyfile
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestUnescape(t *testing.T) {
+	tests := []struct {
+		value, want string
+		list        bool
+	}{
+		{value: `plain`, want: "plain"},
+		{value: `\sleading`, want: " leading"},
+		{value: `a\nb\tc\rd`, want: "a\nb\tc\rd"},
+		{value: `back\\slash`, want: `back\slash`},
+		{value: `a\;b`, want: `a\;b`},
+		{value: `a\;b`, want: "a;b", list: true},
+		{value: `sh -c "echo \$HOME"`, want: `sh -c "echo \$HOME"`},
+		{value: `trailing\`, want: `trailing\`},
+	}
+	for _, test := range tests {
+		if got := unescape(test.value, test.list); got != test.want {
+			t.Errorf("unescape(%q, %v) = %q, want %q", test.value, test.list, got, test.want)
+		}
+	}
+}
+
+func TestEscape(t *testing.T) {
+	tests := []struct {
+		value, want string
+		list        bool
+	}{
+		{value: "plain", want: "plain"},
+		{value: "  two leading", want: `\s\stwo leading`},
+		{value: "inner space ", want: "inner space "},
+		{value: "a\nb\tc\rd", want: `a\nb\tc\rd`},
+		{value: `back\slash`, want: `back\\slash`},
+		{value: "a;b", want: "a;b"},
+		{value: "a;b", want: `a\;b`, list: true},
+	}
+	for _, test := range tests {
+		got := escape(test.value, test.list)
+		if got != test.want {
+			t.Errorf("escape(%q, %v) = %q, want %q", test.value, test.list, got, test.want)
+		}
+		if back := unescape(got, test.list); back != test.value {
+			t.Errorf("unescape(escape(%q, %v)) = %q", test.value, test.list, back)
+		}
+	}
+}
+
+func TestSplitList(t *testing.T) {
+	tests := []struct {
+		value string
+		want  []string
+	}{
+		{value: "", want: []string{}},
+		{value: "a;b;c;", want: []string{"a", "b", "c"}},
+		{value: "a;b;c", want: []string{"a", "b", "c"}},
+		{value: `a\;b;c`, want: []string{"a;b", "c"}},
+		{value: `a\\;b`, want: []string{`a\`, "b"}},
+		{value: "a;;b;", want: []string{"a", "", "b"}},
+		{value: `\sa;b\nc;`, want: []string{" a", "b\nc"}},
+	}
+	for _, test := range tests {
+		if got := splitList(test.value); !slices.Equal(got, test.want) {
+			t.Errorf("splitList(%q) = %q, want %q", test.value, got, test.want)
+		}
+	}
+}
+
+func TestLocaleVariants(t *testing.T) {
+	tests := []struct {
+		locale string
+		want   []string
+	}{
+		{locale: "fr_FR.UTF-8@euro", want: []string{"fr_FR@euro", "fr_FR", "fr@euro", "fr"}},
+		{locale: "fr_FR.UTF-8", want: []string{"fr_FR", "fr"}},
+		{locale: "sr@latin", want: []string{"sr@latin", "sr"}},
+		{locale: "de", want: []string{"de"}},
+		{locale: "C", want: nil},
+		{locale: "POSIX", want: nil},
+		{locale: "", want: nil},
+	}
+	for _, test := range tests {
+		if got := LocaleVariants(test.locale); !slices.Equal(got, test.want) {
+			t.Errorf("LocaleVariants(%q) = %q, want %q", test.locale, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,208 @@
+/*
+	libxdg-go - An implementaion of various freedesktop specifications in go
+    Copyright (C) 2025 MiracleOS Contributors
+
+    This program is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    This program is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+*/
+
+package keyfile
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ErrKeyNotFound is returned when reading a key a group doesn't have.
+var ErrKeyNotFound = errors.New("key not found")
+
+// Group is a group of a key file, such as [Desktop Entry].
+type Group struct {
+	name string
+	// text is the header line as parsed, empty for added groups.
+	text    string
+	entries []entry
+}
+
+// Name returns the name of the group.
+func (g *Group) Name() string {
+	return g.name
+}
+
+// Keys returns the keys of the group, localized ones included, in order.
+func (g *Group) Keys() []string {
+	var keys []string
+	seen := make(map[string]bool)
+	for _, e := range g.entries {
+		if e.key != "" && !seen[e.key] {
+			seen[e.key] = true
+			keys = append(keys, e.key)
+		}
+	}
+	return keys
+}
+
+// index returns the index of the last entry of a key, or -1.
+func (g *Group) index(key string) int {
+	for i := len(g.entries) - 1; i >= 0; i-- {
+		if g.entries[i].key == key {
+			return i
+		}
+	}
+	return -1
+}
+
+// Has reports whether the group has a key.
+func (g *Group) Has(key string) bool {
+	return g.index(key) >= 0
+}
+
+// Value returns the raw value of a key, escapes included.
+func (g *Group) Value(key string) (string, bool) {
+	i := g.index(key)
+	if i < 0 {
+		return "", false
+	}
+	return g.entries[i].value, true
+}
+
+// String returns the value of a key, unescaped, or "" if it is missing.
+func (g *Group) String(key string) string {
+	value, _ := g.Value(key)
+	return unescape(value, false)
+}
+
+// LocaleString returns the value of a key for a locale such as "fr_FR.UTF-8",
+// falling back to less specific locales and then to the unlocalized value. An
+// empty locale reads the unlocalized value.
+func (g *Group) LocaleString(key, locale string) string {
+	value, _ := g.Value(g.localeKey(key, locale))
+	return unescape(value, false)
+}
+
+// Strings returns the list value of a key, or nil if it is missing.
+func (g *Group) Strings(key string) []string {
+	value, found := g.Value(key)
+	if !found {
+		return nil
+	}
+	return splitList(value)
+}
+
+// LocaleStrings returns the list value of a key for a locale, as LocaleString.
+func (g *Group) LocaleStrings(key, locale string) []string {
+	return g.Strings(g.localeKey(key, locale))
+}
+
+// localeKey returns the key holding the value for a locale.
+func (g *Group) localeKey(key, locale string) string {
+	for _, variant := range LocaleVariants(locale) {
+		if localized := key + "[" + variant + "]"; g.Has(localized) {
+			return localized
+		}
+	}
+	return key
+}
+
+// Bool returns the boolean value of a key, "true" or "false" ("1" and "0" are
+// accepted too).
+func (g *Group) Bool(key string) (bool, error) {
+	value, found := g.Value(key)
+	if !found {
+		return false, fmt.Errorf("%s: %w", key, ErrKeyNotFound)
+	}
+	switch strings.TrimRight(value, " \t") {
+	case "true", "1":
+		return true, nil
+	case "false", "0":
+		return false, nil
+	}
+	return false, fmt.Errorf("%s: invalid boolean value %q", key, value)
+}
+
+// Int returns the integer value of a key.
+func (g *Group) Int(key string) (int, error) {
+	value, found := g.Value(key)
+	if !found {
+		return 0, fmt.Errorf("%s: %w", key, ErrKeyNotFound)
+	}
+	n, err := strconv.Atoi(strings.TrimSpace(value))
+	if err != nil {
+		return 0, fmt.Errorf("%s: invalid integer value %q", key, value)
+	}
+	return n, nil
+}
+
+// Set sets the raw value of a key, which must already be escaped. A new key is
+// added after the last key of the group.
+func (g *Group) Set(key, value string) {
+	if i := g.index(key); i >= 0 {
+		g.entries[i] = entry{key: key, value: value}
+		return
+	}
+	// Trailing comments and blank lines belong to what follows the group.
+	at := len(g.entries)
+	for at > 0 && g.entries[at-1].key == "" {
+		at--
+	}
+	g.entries = append(g.entries, entry{})
+	copy(g.entries[at+1:], g.entries[at:])
+	g.entries[at] = entry{key: key, value: value}
+}
+
+// SetString sets the value of a key, escaping it.
+func (g *Group) SetString(key, value string) {
+	g.Set(key, escape(value, false))
+}
+
+// SetLocaleString sets the value of a key for a locale, such as "fr".
+func (g *Group) SetLocaleString(key, locale, value string) {
+	g.SetString(key+"["+locale+"]", value)
+}
+
+// SetStrings sets the list value of a key.
+func (g *Group) SetStrings(key string, values []string) {
+	var b strings.Builder
+	for _, v := range values {
+		b.WriteString(escape(v, true) + ";")
+	}
+	g.Set(key, b.String())
+}
+
+// SetBool sets the boolean value of a key.
+func (g *Group) SetBool(key string, value bool) {
+	g.Set(key, strconv.FormatBool(value))
+}
+
+// SetInt sets the integer value of a key.
+func (g *Group) SetInt(key string, value int) {
+	g.Set(key, strconv.Itoa(value))
+}
+
+// Delete removes a key and reports whether the group had it. Its localized
+// values are kept.
+func (g *Group) Delete(key string) bool {
+	deleted := false
+	for i := 0; i < len(g.entries); {
+		if g.entries[i].key == key {
+			g.entries = append(g.entries[:i], g.entries[i+1:]...)
+			deleted = true
+			continue
+		}
+		i++
+	}
+	return deleted
+}
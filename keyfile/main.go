@@ -0,0 +1,267 @@
+/*
+	libxdg-go - An implementaion of various freedesktop specifications in go
+    Copyright (C) 2025 MiracleOS Contributors
+
+    This program is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    This program is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+*/
+
+// Package keyfile reads and writes key files, the format of desktop entries,
+// icon theme indexes, mimeapps.list and other XDG files, as GLib's GKeyFile does.
+//
+// A key file is a list of groups of key/value pairs. Values may be localized,
+// with the locale in brackets after the key ("Name[fr]"), escaped ("\s", "\n",
+// "\t", "\r" and "\\"), and hold lists separated by ";". A File keeps the lines
+// it was parsed from, so writing it back changes only the entries set or
+// deleted, and keeps comments, blank lines and formatting.
+package keyfile
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/MiracleOS-Team/libxdg-go/internal/atomicfile"
+)
+
+// entry is a line of a group: a key/value pair, or a comment or blank line if
+// key is empty.
+type entry struct {
+	key   string
+	value string
+	// text is the line as parsed, empty once the entry is changed.
+	text string
+}
+
+// line returns the entry as a line of the file.
+func (e entry) line() string {
+	if e.text != "" || e.key == "" {
+		return e.text
+	}
+	return e.key + "=" + e.value
+}
+
+// File is a parsed key file.
+type File struct {
+	// head are the comments and blank lines before the first group.
+	head   []entry
+	groups []*Group
+}
+
+// New returns an empty key file.
+func New() *File {
+	return &File{}
+}
+
+// Load parses the key file at path.
+func Load(path string) (*File, error) {
+	return load(path, false)
+}
+
+// LoadLenient parses the key file at path as ParseLenient does.
+func LoadLenient(path string) (*File, error) {
+	return load(path, true)
+}
+
+func load(path string, lenient bool) (*File, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	f, err := parse(data, lenient)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return f, nil
+}
+
+// Parse parses a key file. As with GKeyFile, a group appearing twice is merged
+// into the first, and the last of repeated keys wins. A malformed line is an
+// error.
+func Parse(data []byte) (*File, error) {
+	return parse(data, false)
+}
+
+// ParseLenient parses a key file like Parse, but ignores malformed lines, and
+// the keys of a group with a malformed header, instead of failing. They are
+// kept like comments, so writing the file back doesn't lose them. It suits
+// readers of files written by other programs, such as desktop entries.
+func ParseLenient(data []byte) (*File, error) {
+	return parse(data, true)
+}
+
+func parse(data []byte, lenient bool) (*File, error) {
+	f := &File{}
+	data = bytes.TrimPrefix(data, []byte("\ufeff"))
+	text := strings.TrimSuffix(string(data), "\n")
+	if text == "" {
+		return f, nil
+	}
+
+	var group *Group
+	// skipping is set after a malformed group header in lenient mode.
+	skipping := false
+	keep := func(raw string) {
+		if group == nil {
+			f.head = append(f.head, entry{text: raw})
+		} else {
+			group.entries = append(group.entries, entry{text: raw})
+		}
+	}
+	for i, raw := range strings.Split(text, "\n") {
+		line := strings.TrimLeft(strings.TrimSuffix(raw, "\r"), " \t")
+		var err error
+		switch {
+		case line == "" || line[0] == '#':
+			keep(raw)
+			continue
+		case line[0] == '[':
+			end := strings.LastIndexByte(line, ']')
+			if end < 0 {
+				err = fmt.Errorf("line %d: unterminated group name %q", i+1, line)
+				break
+			}
+			name := line[1:end]
+			if !validGroupName(name) {
+				err = fmt.Errorf("line %d: invalid group name %q", i+1, name)
+				break
+			}
+			if group = f.Group(name); group == nil {
+				group = &Group{name: name, text: raw}
+				f.groups = append(f.groups, group)
+			}
+			skipping = false
+			continue
+		default:
+			key, value, found := strings.Cut(line, "=")
+			key = strings.TrimRight(key, " \t")
+			if !found || key == "" {
+				err = fmt.Errorf("line %d: %q is not a group, key or comment", i+1, line)
+				break
+			}
+			if group == nil {
+				err = fmt.Errorf("line %d: key %q outside of a group", i+1, key)
+				break
+			}
+			if skipping {
+				keep(raw)
+				continue
+			}
+			value = strings.TrimLeft(value, " \t")
+			group.entries = append(group.entries, entry{key: key, value: value, text: raw})
+			continue
+		}
+		if !lenient {
+			return nil, err
+		}
+		if line[0] == '[' {
+			skipping = true
+		}
+		keep(raw)
+	}
+	return f, nil
+}
+
+// validGroupName reports whether a group name can be written in a header.
+func validGroupName(name string) bool {
+	if name == "" {
+		return false
+	}
+	for _, r := range name {
+		if r == '[' || r == ']' || r < ' ' {
+			return false
+		}
+	}
+	return true
+}
+
+// Groups returns the names of the groups, in order.
+func (f *File) Groups() []string {
+	names := make([]string, len(f.groups))
+	for i, g := range f.groups {
+		names[i] = g.name
+	}
+	return names
+}
+
+// Group returns a group, or nil if there is none of that name.
+func (f *File) Group(name string) *Group {
+	for _, g := range f.groups {
+		if g.name == name {
+			return g
+		}
+	}
+	return nil
+}
+
+// AddGroup returns a group, appending it to the file if there is none of that
+// name. It panics if the name can't be written in a header.
+func (f *File) AddGroup(name string) *Group {
+	if g := f.Group(name); g != nil {
+		return g
+	}
+	if !validGroupName(name) {
+		panic(fmt.Sprintf("keyfile: invalid group name %q", name))
+	}
+	// Groups are separated by a blank line.
+	if n := len(f.groups); n > 0 {
+		last := f.groups[n-1]
+		if len(last.entries) > 0 && strings.TrimSpace(last.entries[len(last.entries)-1].line()) != "" {
+			last.entries = append(last.entries, entry{})
+		}
+	}
+	g := &Group{name: name}
+	f.groups = append(f.groups, g)
+	return g
+}
+
+// RemoveGroup removes a group and reports whether there was one.
+func (f *File) RemoveGroup(name string) bool {
+	for i, g := range f.groups {
+		if g.name == name {
+			f.groups = append(f.groups[:i], f.groups[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// Bytes returns the file in the key file format.
+func (f *File) Bytes() []byte {
+	var buf bytes.Buffer
+	for _, e := range f.head {
+		buf.WriteString(e.line() + "\n")
+	}
+	for _, g := range f.groups {
+		if g.text != "" {
+			buf.WriteString(g.text + "\n")
+		} else {
+			buf.WriteString("[" + g.name + "]\n")
+		}
+		for _, e := range g.entries {
+			buf.WriteString(e.line() + "\n")
+		}
+	}
+	return buf.Bytes()
+}
+
+func (f *File) String() string {
+	return string(f.Bytes())
+}
+
+// Save atomically writes the file to path, creating its directory if needed.
+func (f *File) Save(path string) error {
+	return atomicfile.WriteFile(path, f.Bytes(), 0644)
+}
@@ -0,0 +1,176 @@
+/*
+	libxdg-go - An implementaion of various freedesktop specifications in go
+    Copyright (C) 2025 MiracleOS Contributors
+
+    This program is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    This program is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+*/
+
+package keyfile
+
+import (
+	"slices"
+	"testing"
+)
+
+const sample = `# Written by hand
+[Desktop Entry]
+# The name
+Name=Files
+Name[fr]=Fichiers
+Name[fr_CA]=Fichiers (Canada)
+Name[sr@latin]=Datoteke
+Keywords=folder;manager;
+Keywords[fr]=dossier;gestionnaire;
+  Exec = nautilus --new-window %U
+
+[Desktop Action new-window]
+Name=New Window
+`
+
+func TestRoundTripKeepsComments(t *testing.T) {
+	f, err := Parse([]byte(sample))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := f.String(); got != sample {
+		t.Errorf("unchanged file written as:\n%s\nwant:\n%s", got, sample)
+	}
+
+	g := f.Group("Desktop Entry")
+	g.SetString("Name", "Nautilus")
+	g.SetBool("Terminal", false)
+	want := `# Written by hand
+[Desktop Entry]
+# The name
+Name=Nautilus
+Name[fr]=Fichiers
+Name[fr_CA]=Fichiers (Canada)
+Name[sr@latin]=Datoteke
+Keywords=folder;manager;
+Keywords[fr]=dossier;gestionnaire;
+  Exec = nautilus --new-window %U
+Terminal=false
+
+[Desktop Action new-window]
+Name=New Window
+`
+	if got := f.String(); got != want {
+		t.Errorf("edited file written as:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestLocaleKeys(t *testing.T) {
+	f, err := Parse([]byte(sample))
+	if err != nil {
+		t.Fatal(err)
+	}
+	g := f.Group("Desktop Entry")
+	tests := []struct{ locale, want string }{
+		{"", "Files"},
+		{"C", "Files"},
+		{"fr", "Fichiers"},
+		{"fr_FR.UTF-8", "Fichiers"},
+		{"fr_CA.UTF-8", "Fichiers (Canada)"},
+		{"sr_RS@latin", "Datoteke"},
+		{"sr_RS", "Files"},
+		{"de_DE", "Files"},
+	}
+	for _, test := range tests {
+		if got := g.LocaleString("Name", test.locale); got != test.want {
+			t.Errorf("LocaleString(Name, %q) = %q, want %q", test.locale, got, test.want)
+		}
+	}
+	if got := g.LocaleStrings("Keywords", "fr_BE"); !slices.Equal(got, []string{"dossier", "gestionnaire"}) {
+		t.Errorf("LocaleStrings(Keywords, fr_BE) = %q", got)
+	}
+	if got := g.String("Exec"); got != "nautilus --new-window %U" {
+		t.Errorf("spaces around = not trimmed: got Exec %q", got)
+	}
+}
+
+func TestDuplicates(t *testing.T) {
+	f, err := Parse([]byte("[A]\nk=1\nk=2\n[B]\nx=1\n[A]\nk=3\nl=4\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := f.Groups(); !slices.Equal(got, []string{"A", "B"}) {
+		t.Errorf("got groups %q, want a repeated group merged into the first", got)
+	}
+	a := f.Group("A")
+	if got := a.String("k"); got != "3" {
+		t.Errorf("got k=%q, want the last value", got)
+	}
+	if got := a.Keys(); !slices.Equal(got, []string{"k", "l"}) {
+		t.Errorf("got keys %q", got)
+	}
+	a.SetString("k", "5")
+	if got := a.String("k"); got != "5" {
+		t.Errorf("got k=%q after setting it", got)
+	}
+	if !a.Delete("k") || a.Has("k") {
+		t.Error("Delete left a repeated key behind")
+	}
+}
+
+func TestParseMalformed(t *testing.T) {
+	for _, data := range []string{
+		"[Unterminated\nk=v\n",
+		"[Bad]Group]\n",
+		"[Group]\nnot a key\n",
+		"[Group]\n=value\n",
+		"k=v\n[Group]\n",
+	} {
+		if _, err := Parse([]byte(data)); err == nil {
+			t.Errorf("Parse(%q) succeeded", data)
+		}
+		f, err := ParseLenient([]byte(data))
+		if err != nil {
+			t.Errorf("ParseLenient(%q): %v", data, err)
+			continue
+		}
+		if got := f.String(); got != data {
+			t.Errorf("ParseLenient(%q) written back as %q", data, got)
+		}
+	}
+}
+
+func TestParseLenientSkipsBadLines(t *testing.T) {
+	f, err := ParseLenient([]byte("stray=1\n[Desktop Entry]\nName=Files\ngarbage\n[Broken\nName=Lost\n[Other]\nName=Other\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := f.Groups(); !slices.Equal(got, []string{"Desktop Entry", "Other"}) {
+		t.Errorf("got groups %q", got)
+	}
+	if got := f.Group("Desktop Entry").String("Name"); got != "Files" {
+		t.Errorf("got Name=%q, want the key before the broken group", got)
+	}
+	if got := f.Group("Desktop Entry").Keys(); !slices.Equal(got, []string{"Name"}) {
+		t.Errorf("got keys %q", got)
+	}
+	if got := f.Group("Other").String("Name"); got != "Other" {
+		t.Errorf("got Other Name=%q", got)
+	}
+}
+
+func TestParseBOMAndCRLF(t *testing.T) {
+	f, err := Parse([]byte("\ufeff[Group]\r\nk=v\r\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := f.Group("Group").String("k"); got != "v" {
+		t.Errorf("got k=%q", got)
+	}
+}
@@ -0,0 +1,195 @@
+/*
+	libxdg-go - An implementaion of various freedesktop specifications in go
+    Copyright (C) 2025 MiracleOS Contributors
+
+    This program is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    This program is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+*/
+
+// Package locale finds the user's locale and is a client of systemd-localed,
+// org.freedesktop.locale1, which holds the system locale and keyboard layouts.
+package locale
+
+import (
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/MiracleOS-Team/libxdg-go/internal/signals"
+	"github.com/godbus/dbus/v5"
+)
+
+const (
+	busName       = "org.freedesktop.locale1"
+	objectPath    = dbus.ObjectPath("/org/freedesktop/locale1")
+	interfaceName = "org.freedesktop.locale1"
+)
+
+// Info is the state of localed.
+type Info struct {
+	// Locale holds the system locale settings, e.g. "LANG=de_DE.UTF-8".
+	Locale               []string
+	VConsoleKeymap       string
+	VConsoleKeymapToggle string
+	X11Layout            string
+	X11Model             string
+	X11Variant           string
+	X11Options           string
+}
+
+// Get returns a system locale setting, such as "LANG" or "LC_TIME".
+func (i Info) Get(name string) string {
+	for _, setting := range i.Locale {
+		if value, found := strings.CutPrefix(setting, name+"="); found {
+			return value
+		}
+	}
+	return ""
+}
+
+// Messages returns the locale messages are shown in, e.g. "pt_BR.UTF-8": that
+// of the environment, else the system locale from localed, else "C".
+func Messages() string {
+	for _, name := range []string{"LC_ALL", "LC_MESSAGES", "LANG"} {
+		if locale := os.Getenv(name); locale != "" {
+			return locale
+		}
+	}
+	if c, err := NewClient(); err == nil {
+		defer c.Close()
+		if info, err := c.Info(); err == nil {
+			for _, name := range []string{"LC_MESSAGES", "LANG"} {
+				if locale := info.Get(name); locale != "" {
+					return locale
+				}
+			}
+		}
+	}
+	return "C"
+}
+
+// Client talks to localed over the system bus. Setters with interactive set
+// let polkit ask the user for authorization.
+type Client struct {
+	conn        *dbus.Conn
+	own         bool
+	obj         dbus.BusObject
+	subscribers signals.Subscribers[Info]
+	watch       *signals.Watch
+	closeOnce   sync.Once
+}
+
+// NewClient connects to the system bus.
+func NewClient() (*Client, error) {
+	conn, err := dbus.ConnectSystemBus()
+	if err != nil {
+		return nil, err
+	}
+	c, err := newClient(conn, true)
+	if err != nil {
+		conn.Close()
+	}
+	return c, err
+}
+
+// NewClientWithConn creates a client on an existing system bus connection,
+// which is left open by Close.
+func NewClientWithConn(conn *dbus.Conn) (*Client, error) {
+	return newClient(conn, false)
+}
+
+func newClient(conn *dbus.Conn, own bool) (*Client, error) {
+	c := &Client{conn: conn, own: own, obj: conn.Object(busName, objectPath)}
+	watch, err := signals.NewWatch(conn, 16, c.dispatch, c.match())
+	if err != nil {
+		return nil, err
+	}
+	c.watch = watch
+	return c, nil
+}
+
+func (c *Client) match() []dbus.MatchOption {
+	return []dbus.MatchOption{
+		dbus.WithMatchObjectPath(objectPath),
+		dbus.WithMatchInterface("org.freedesktop.DBus.Properties"),
+		dbus.WithMatchMember("PropertiesChanged"),
+		dbus.WithMatchArg(0, interfaceName),
+	}
+}
+
+// Close stops listening to localed.
+func (c *Client) Close() error {
+	var err error
+	c.closeOnce.Do(func() {
+		c.watch.Close()
+		c.subscribers.Close()
+		if c.own {
+			err = c.conn.Close()
+		}
+	})
+	return err
+}
+
+// Subscribe registers a consumer of the changes to localed's state. Changes
+// that don't fit in the buffer are dropped. The channel is closed by cancel or
+// by Close.
+func (c *Client) Subscribe(buffer int) (<-chan Info, func()) {
+	return c.subscribers.Subscribe(buffer)
+}
+
+// dispatch reads the state again on changes.
+func (c *Client) dispatch(*dbus.Signal) {
+	if info, err := c.Info(); err == nil {
+		c.subscribers.Publish(info)
+	}
+}
+
+// Info reads the state of localed.
+func (c *Client) Info() (Info, error) {
+	var props map[string]dbus.Variant
+	if err := c.obj.Call("org.freedesktop.DBus.Properties.GetAll", 0, interfaceName).Store(&props); err != nil {
+		return Info{}, err
+	}
+	get := func(name string, value any) {
+		if v, found := props[name]; found {
+			v.Store(value)
+		}
+	}
+	var i Info
+	get("Locale", &i.Locale)
+	get("VConsoleKeymap", &i.VConsoleKeymap)
+	get("VConsoleKeymapToggle", &i.VConsoleKeymapToggle)
+	get("X11Layout", &i.X11Layout)
+	get("X11Model", &i.X11Model)
+	get("X11Variant", &i.X11Variant)
+	get("X11Options", &i.X11Options)
+	return i, nil
+}
+
+// SetLocale sets the system locale settings, e.g. {"LANG=de_DE.UTF-8"}.
+func (c *Client) SetLocale(locale []string, interactive bool) error {
+	return c.obj.Call(interfaceName+".SetLocale", 0, locale, interactive).Err
+}
+
+// SetVConsoleKeyboard sets the console keymap. With convert, the X11 layout
+// is set to the closest match.
+func (c *Client) SetVConsoleKeyboard(keymap, toggle string, convert, interactive bool) error {
+	return c.obj.Call(interfaceName+".SetVConsoleKeyboard", 0, keymap, toggle, convert, interactive).Err
+}
+
+// SetX11Keyboard sets the X11 and Wayland keyboard layout. With convert, the
+// console keymap is set to the closest match.
+func (c *Client) SetX11Keyboard(layout, model, variant, options string, convert, interactive bool) error {
+	return c.obj.Call(interfaceName+".SetX11Keyboard", 0, layout, model, variant, options, convert, interactive).Err
+}
@@ -0,0 +1,105 @@
+/*
+	libxdg-go - An implementaion of various freedesktop specifications in go
+    Copyright (C) 2025 MiracleOS Contributors
+
+    This program is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    This program is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+*/
+
+package logind
+
+import (
+	"errors"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/godbus/dbus/v5"
+)
+
+// What an inhibitor lock inhibits.
+const (
+	InhibitShutdown         = "shutdown"
+	InhibitSleep            = "sleep"
+	InhibitIdle             = "idle"
+	InhibitHandlePowerKey   = "handle-power-key"
+	InhibitHandleSuspendKey = "handle-suspend-key"
+	InhibitHandleHibernate  = "handle-hibernate-key"
+	InhibitHandleLidSwitch  = "handle-lid-switch"
+)
+
+// Inhibitor lock modes. A delay lock postpones the operation until released or
+// until logind's InhibitDelayMaxSec passes, leaving time to e.g. lock the
+// screen before sleeping; a block lock prevents it.
+const (
+	ModeBlock = "block"
+	ModeDelay = "delay"
+)
+
+// Inhibitor is an inhibitor lock, held until released.
+type Inhibitor struct {
+	file *os.File
+	once sync.Once
+}
+
+// Inhibit takes an inhibitor lock. who names the application and why explains
+// the lock to the user.
+func (c *Client) Inhibit(what []string, who, why, mode string) (*Inhibitor, error) {
+	var fd dbus.UnixFD
+	err := c.manager.Call(managerInterface+".Inhibit", 0, strings.Join(what, ":"), who, why, mode).Store(&fd)
+	if err != nil {
+		return nil, err
+	}
+	return &Inhibitor{file: os.NewFile(uintptr(fd), "logind-inhibitor")}, nil
+}
+
+// Release releases the lock. Releasing it more than once is a no-op.
+func (i *Inhibitor) Release() error {
+	err := errors.New("inhibitor lock already released")
+	i.once.Do(func() { err = i.file.Close() })
+	return err
+}
+
+// InhibitorInfo describes an inhibitor lock held by some process.
+type InhibitorInfo struct {
+	What []string
+	Who  string
+	Why  string
+	Mode string
+	UID  uint32
+	PID  uint32
+}
+
+// Inhibitors lists the inhibitor locks held on the system.
+func (c *Client) Inhibitors() ([]InhibitorInfo, error) {
+	var raw []struct {
+		What, Who, Why, Mode string
+		UID, PID             uint32
+	}
+	if err := c.manager.Call(managerInterface+".ListInhibitors", 0).Store(&raw); err != nil {
+		return nil, err
+	}
+	inhibitors := make([]InhibitorInfo, 0, len(raw))
+	for _, r := range raw {
+		inhibitors = append(inhibitors, InhibitorInfo{
+			What: strings.Split(r.What, ":"),
+			Who:  r.Who,
+			Why:  r.Why,
+			Mode: r.Mode,
+			UID:  r.UID,
+			PID:  r.PID,
+		})
+	}
+	return inhibitors, nil
+}
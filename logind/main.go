@@ -0,0 +1,155 @@
+/*
+	libxdg-go - An implementaion of various freedesktop specifications in go
+    Copyright (C) 2025 MiracleOS Contributors
+
+    This program is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    This program is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+*/
+
+// Package logind wraps org.freedesktop.login1, systemd's session manager: its
+// inhibitor locks, sleep and shutdown notices, and the state of the session the
+// process runs in, for screen lockers and power management.
+package logind
+
+import (
+	"sync"
+
+	"github.com/MiracleOS-Team/libxdg-go/internal/signals"
+	"github.com/godbus/dbus/v5"
+)
+
+const (
+	busName          = "org.freedesktop.login1"
+	managerPath      = dbus.ObjectPath("/org/freedesktop/login1")
+	autoSessionPath  = dbus.ObjectPath("/org/freedesktop/login1/session/auto")
+	managerInterface = "org.freedesktop.login1.Manager"
+	sessionInterface = "org.freedesktop.login1.Session"
+)
+
+// Event reports a logind signal. For PrepareForSleep and PrepareForShutdown,
+// Start is true before the transition and false after resuming from sleep.
+type Event struct {
+	PrepareForSleep    bool
+	PrepareForShutdown bool
+	Start              bool
+	// Lock and Unlock are requests to lock or unlock the current session, e.g.
+	// from loginctl lock-session.
+	Lock   bool
+	Unlock bool
+}
+
+// Client talks to logind over the system bus on behalf of the session the
+// process runs in.
+type Client struct {
+	conn        *dbus.Conn
+	own         bool
+	manager     dbus.BusObject
+	sessionID   string
+	sessionPath dbus.ObjectPath
+	subscribers signals.Subscribers[Event]
+	watch       *signals.Watch
+	closeOnce   sync.Once
+}
+
+// NewClient connects to the system bus.
+func NewClient() (*Client, error) {
+	conn, err := dbus.ConnectSystemBus()
+	if err != nil {
+		return nil, err
+	}
+	c, err := newClient(conn, true)
+	if err != nil {
+		conn.Close()
+	}
+	return c, err
+}
+
+// NewClientWithConn creates a client on an existing system bus connection,
+// which is left open by Close.
+func NewClientWithConn(conn *dbus.Conn) (*Client, error) {
+	return newClient(conn, false)
+}
+
+func newClient(conn *dbus.Conn, own bool) (*Client, error) {
+	c := &Client{conn: conn, own: own, manager: conn.Object(busName, managerPath)}
+
+	// Signals come from the real session path, not the "auto" alias. Processes
+	// outside a session, such as system services, have none.
+	if err := conn.Object(busName, autoSessionPath).StoreProperty(sessionInterface+".Id", &c.sessionID); err == nil {
+		if err := c.manager.Call(managerInterface+".GetSession", 0, c.sessionID).Store(&c.sessionPath); err != nil {
+			return nil, err
+		}
+	}
+
+	watch, err := signals.NewWatch(conn, 16, c.dispatch, c.matches()...)
+	if err != nil {
+		return nil, err
+	}
+	c.watch = watch
+	return c, nil
+}
+
+func (c *Client) matches() [][]dbus.MatchOption {
+	matches := [][]dbus.MatchOption{
+		{dbus.WithMatchObjectPath(managerPath), dbus.WithMatchInterface(managerInterface)},
+	}
+	if c.sessionPath != "" {
+		matches = append(matches, []dbus.MatchOption{dbus.WithMatchObjectPath(c.sessionPath), dbus.WithMatchInterface(sessionInterface)})
+	}
+	return matches
+}
+
+// Close stops listening to logind. Inhibitor locks stay held until released.
+func (c *Client) Close() error {
+	var err error
+	c.closeOnce.Do(func() {
+		c.watch.Close()
+		c.subscribers.Close()
+		if c.own {
+			err = c.conn.Close()
+		}
+	})
+	return err
+}
+
+// Conn returns the D-Bus connection of the client.
+func (c *Client) Conn() *dbus.Conn {
+	return c.conn
+}
+
+// Subscribe registers a consumer of the client's events. Events that don't fit
+// in the buffer are dropped. The channel is closed by cancel or by Close.
+func (c *Client) Subscribe(buffer int) (<-chan Event, func()) {
+	return c.subscribers.Subscribe(buffer)
+}
+
+func (c *Client) dispatch(sig *dbus.Signal) {
+	var event Event
+	switch sig.Name {
+	case managerInterface + ".PrepareForSleep":
+		event.PrepareForSleep = true
+	case managerInterface + ".PrepareForShutdown":
+		event.PrepareForShutdown = true
+	case sessionInterface + ".Lock":
+		event.Lock = true
+	case sessionInterface + ".Unlock":
+		event.Unlock = true
+	default:
+		return
+	}
+	if len(sig.Body) == 1 {
+		event.Start, _ = sig.Body[0].(bool)
+	}
+	c.subscribers.Publish(event)
+}
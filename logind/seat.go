@@ -0,0 +1,301 @@
+/*
+	libxdg-go - An implementaion of various freedesktop specifications in go
+    Copyright (C) 2025 MiracleOS Contributors
+
+    This program is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    This program is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+*/
+
+package logind
+
+import (
+	"errors"
+
+	"github.com/godbus/dbus/v5"
+)
+
+const (
+	seatInterface = "org.freedesktop.login1.Seat"
+	userInterface = "org.freedesktop.login1.User"
+)
+
+// Session types.
+const (
+	SessionTypeX11     = "x11"
+	SessionTypeWayland = "wayland"
+	SessionTypeTTY     = "tty"
+	SessionTypeMir     = "mir"
+	SessionTypeOther   = "unspecified"
+)
+
+// Session describes a logind session.
+type Session struct {
+	ID     string
+	Path   dbus.ObjectPath
+	UID    uint32
+	User   string
+	Seat   string
+	Type   string
+	Class  string
+	State  string
+	Active bool
+	Remote bool
+	TTY    string
+	// Display is the X11 display of x11 sessions.
+	Display    string
+	Service    string
+	Desktop    string
+	VTNr       uint32
+	Leader     uint32
+	IdleHint   bool
+	LockedHint bool
+}
+
+// Seat describes a logind seat: a set of screens and input devices.
+type Seat struct {
+	ID              string
+	Path            dbus.ObjectPath
+	ActiveSession   string
+	Sessions        []string
+	CanGraphical    bool
+	CanTTY          bool
+	CanMultiSession bool
+}
+
+// User describes a user logged in, or lingering.
+type User struct {
+	UID  uint32
+	GID  uint32
+	Name string
+	// State is "online", "active", "lingering" or "closing".
+	State       string
+	Display     string
+	RuntimePath string
+	Sessions    []string
+	Linger      bool
+	IdleHint    bool
+}
+
+// idPath is the (so) reference to an object logind properties hold.
+type idPath struct {
+	ID   string
+	Path dbus.ObjectPath
+}
+
+func ids(refs []idPath) []string {
+	ids := make([]string, 0, len(refs))
+	for _, ref := range refs {
+		ids = append(ids, ref.ID)
+	}
+	return ids
+}
+
+// properties reads the properties of a logind object.
+func (c *Client) properties(path dbus.ObjectPath, iface string) (func(name string, value any), error) {
+	var props map[string]dbus.Variant
+	if err := c.conn.Object(busName, path).Call("org.freedesktop.DBus.Properties.GetAll", 0, iface).Store(&props); err != nil {
+		return nil, err
+	}
+	return func(name string, value any) {
+		if v, found := props[name]; found {
+			v.Store(value)
+		}
+	}, nil
+}
+
+// Sessions lists the sessions.
+func (c *Client) Sessions() ([]Session, error) {
+	var raw []struct {
+		ID   string
+		UID  uint32
+		User string
+		Seat string
+		Path dbus.ObjectPath
+	}
+	if err := c.manager.Call(managerInterface+".ListSessions", 0).Store(&raw); err != nil {
+		return nil, err
+	}
+	sessions := make([]Session, 0, len(raw))
+	for _, r := range raw {
+		// Sessions may end meanwhile.
+		if s, err := c.sessionAt(r.Path); err == nil {
+			sessions = append(sessions, s)
+		}
+	}
+	return sessions, nil
+}
+
+// Session returns a session by ID.
+func (c *Client) Session(id string) (Session, error) {
+	var path dbus.ObjectPath
+	if err := c.manager.Call(managerInterface+".GetSession", 0, id).Store(&path); err != nil {
+		return Session{}, err
+	}
+	return c.sessionAt(path)
+}
+
+// CurrentSession returns the session the process runs in.
+func (c *Client) CurrentSession() (Session, error) {
+	if c.sessionPath == "" {
+		return Session{}, ErrNoSession
+	}
+	return c.sessionAt(c.sessionPath)
+}
+
+func (c *Client) sessionAt(path dbus.ObjectPath) (Session, error) {
+	get, err := c.properties(path, sessionInterface)
+	if err != nil {
+		return Session{}, err
+	}
+	s := Session{Path: path}
+	var user struct {
+		UID  uint32
+		Path dbus.ObjectPath
+	}
+	var seat idPath
+	get("Id", &s.ID)
+	get("User", &user)
+	get("Name", &s.User)
+	get("Seat", &seat)
+	get("Type", &s.Type)
+	get("Class", &s.Class)
+	get("State", &s.State)
+	get("Active", &s.Active)
+	get("Remote", &s.Remote)
+	get("TTY", &s.TTY)
+	get("Display", &s.Display)
+	get("Service", &s.Service)
+	get("Desktop", &s.Desktop)
+	get("VTNr", &s.VTNr)
+	get("Leader", &s.Leader)
+	get("IdleHint", &s.IdleHint)
+	get("LockedHint", &s.LockedHint)
+	s.UID, s.Seat = user.UID, seat.ID
+	return s, nil
+}
+
+// ActivateSession brings a session to the foreground of its seat, switching
+// users.
+func (c *Client) ActivateSession(id string) error {
+	return c.manager.Call(managerInterface+".ActivateSession", 0, id).Err
+}
+
+// ActivateSessionOnSeat brings a session to the foreground of a seat.
+func (c *Client) ActivateSessionOnSeat(sessionID, seatID string) error {
+	return c.manager.Call(managerInterface+".ActivateSessionOnSeat", 0, sessionID, seatID).Err
+}
+
+// Seats lists the seats.
+func (c *Client) Seats() ([]Seat, error) {
+	var raw []idPath
+	if err := c.manager.Call(managerInterface+".ListSeats", 0).Store(&raw); err != nil {
+		return nil, err
+	}
+	seats := make([]Seat, 0, len(raw))
+	for _, r := range raw {
+		if s, err := c.seatAt(r.Path); err == nil {
+			seats = append(seats, s)
+		}
+	}
+	return seats, nil
+}
+
+// Seat returns a seat by ID, such as "seat0".
+func (c *Client) Seat(id string) (Seat, error) {
+	var path dbus.ObjectPath
+	if err := c.manager.Call(managerInterface+".GetSeat", 0, id).Store(&path); err != nil {
+		return Seat{}, err
+	}
+	return c.seatAt(path)
+}
+
+// CurrentSeat returns the seat of the session the process runs in. Remote
+// sessions have none.
+func (c *Client) CurrentSeat() (Seat, error) {
+	s, err := c.CurrentSession()
+	if err != nil {
+		return Seat{}, err
+	}
+	if s.Seat == "" {
+		return Seat{}, errors.New("logind session has no seat: " + s.ID)
+	}
+	return c.Seat(s.Seat)
+}
+
+func (c *Client) seatAt(path dbus.ObjectPath) (Seat, error) {
+	get, err := c.properties(path, seatInterface)
+	if err != nil {
+		return Seat{}, err
+	}
+	s := Seat{Path: path}
+	var active idPath
+	var sessions []idPath
+	get("Id", &s.ID)
+	get("ActiveSession", &active)
+	get("Sessions", &sessions)
+	get("CanGraphical", &s.CanGraphical)
+	get("CanTTY", &s.CanTTY)
+	get("CanMultiSession", &s.CanMultiSession)
+	s.ActiveSession, s.Sessions = active.ID, ids(sessions)
+	return s, nil
+}
+
+// Users lists the users logged in or lingering.
+func (c *Client) Users() ([]User, error) {
+	var raw []struct {
+		UID  uint32
+		Name string
+		Path dbus.ObjectPath
+	}
+	if err := c.manager.Call(managerInterface+".ListUsers", 0).Store(&raw); err != nil {
+		return nil, err
+	}
+	users := make([]User, 0, len(raw))
+	for _, r := range raw {
+		if u, err := c.userAt(r.Path); err == nil {
+			users = append(users, u)
+		}
+	}
+	return users, nil
+}
+
+// User returns a user by UID.
+func (c *Client) User(uid uint32) (User, error) {
+	var path dbus.ObjectPath
+	if err := c.manager.Call(managerInterface+".GetUser", 0, uid).Store(&path); err != nil {
+		return User{}, err
+	}
+	return c.userAt(path)
+}
+
+func (c *Client) userAt(path dbus.ObjectPath) (User, error) {
+	get, err := c.properties(path, userInterface)
+	if err != nil {
+		return User{}, err
+	}
+	var u User
+	var display idPath
+	var sessions []idPath
+	get("UID", &u.UID)
+	get("GID", &u.GID)
+	get("Name", &u.Name)
+	get("State", &u.State)
+	get("Display", &display)
+	get("RuntimePath", &u.RuntimePath)
+	get("Sessions", &sessions)
+	get("Linger", &u.Linger)
+	get("IdleHint", &u.IdleHint)
+	u.Display, u.Sessions = display.ID, ids(sessions)
+	return u, nil
+}
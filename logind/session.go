@@ -0,0 +1,100 @@
+/*
+	libxdg-go - An implementaion of various freedesktop specifications in go
+    Copyright (C) 2025 MiracleOS Contributors
+
+    This program is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    This program is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+*/
+
+package logind
+
+import (
+	"errors"
+
+	"github.com/godbus/dbus/v5"
+)
+
+// ErrNoSession is returned for session operations by a process outside a
+// logind session.
+var ErrNoSession = errors.New("process isn't part of a logind session")
+
+// SessionID returns the ID of the session the process runs in, empty if none.
+func (c *Client) SessionID() string {
+	return c.sessionID
+}
+
+// session returns the object of the current session.
+func (c *Client) session() (dbus.BusObject, error) {
+	if c.sessionPath == "" {
+		return nil, ErrNoSession
+	}
+	return c.conn.Object(busName, c.sessionPath), nil
+}
+
+// SetIdleHint tells logind whether the user of the session is idle, which
+// drives IdleAction and is shown by loginctl.
+func (c *Client) SetIdleHint(idle bool) error {
+	session, err := c.session()
+	if err != nil {
+		return err
+	}
+	return session.Call(sessionInterface+".SetIdleHint", 0, idle).Err
+}
+
+// SetLockedHint tells logind whether the session is locked, as screen lockers
+// do once the screen is locked and after unlocking.
+func (c *Client) SetLockedHint(locked bool) error {
+	session, err := c.session()
+	if err != nil {
+		return err
+	}
+	return session.Call(sessionInterface+".SetLockedHint", 0, locked).Err
+}
+
+// IdleHint reports whether logind considers the session idle.
+func (c *Client) IdleHint() (bool, error) {
+	return c.sessionBool("IdleHint")
+}
+
+// LockedHint reports whether logind considers the session locked.
+func (c *Client) LockedHint() (bool, error) {
+	return c.sessionBool("LockedHint")
+}
+
+func (c *Client) sessionBool(property string) (bool, error) {
+	session, err := c.session()
+	if err != nil {
+		return false, err
+	}
+	var value bool
+	err = session.StoreProperty(sessionInterface+"."+property, &value)
+	return value, err
+}
+
+// LockSession asks logind to lock the current session: the screen locker
+// receives a Lock event.
+func (c *Client) LockSession() error {
+	if c.sessionPath == "" {
+		return ErrNoSession
+	}
+	return c.manager.Call(managerInterface+".LockSession", 0, c.sessionID).Err
+}
+
+// UnlockSession asks logind to unlock the current session.
+func (c *Client) UnlockSession() error {
+	if c.sessionPath == "" {
+		return ErrNoSession
+	}
+	return c.manager.Call(managerInterface+".UnlockSession", 0, c.sessionID).Err
+}
@@ -0,0 +1,110 @@
+/*
+	libxdg-go - An implementaion of various freedesktop specifications in go
+    Copyright (C) 2025 MiracleOS Contributors
+
+    This program is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    This program is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+*/
+
+package mime
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/MiracleOS-Team/libxdg-go/desktopFiles"
+)
+
+// browserTypes are the types a web browser is made the default application of,
+// as by "xdg-settings set default-web-browser".
+var browserTypes = []string{SchemeType("http"), SchemeType("https"), "text/html"}
+
+// ErrNotInstalled is returned when making an application the default that
+// isn't installed.
+var ErrNotInstalled = errors.New("application not installed")
+
+// WebBrowser returns the desktop file ID of the default web browser, the
+// default handler of http URIs, or "" if there is none.
+func (m *MIMEApps) WebBrowser() string {
+	return m.SchemeHandler("http")
+}
+
+// IsDefaultWebBrowser reports whether an application, e.g. "firefox.desktop",
+// is the default for http and https URIs and HTML files alike.
+func (m *MIMEApps) IsDefaultWebBrowser(desktopID string) bool {
+	desktopID = desktopFileID(desktopID)
+	for _, mimeType := range browserTypes {
+		if m.Default(mimeType) != desktopID {
+			return false
+		}
+	}
+	return true
+}
+
+// IsDefaultSchemeHandler reports whether an application is the default handler
+// of a URI scheme.
+func (m *MIMEApps) IsDefaultSchemeHandler(scheme, desktopID string) bool {
+	return m.SchemeHandler(scheme) == desktopFileID(desktopID)
+}
+
+// desktopFileID adds the .desktop suffix mimeapps.list IDs have.
+func desktopFileID(id string) string {
+	if !strings.HasSuffix(id, ".desktop") {
+		id += ".desktop"
+	}
+	return id
+}
+
+// DefaultWebBrowser returns the desktop file ID and desktop file of the default
+// web browser, as "xdg-settings get default-web-browser".
+func DefaultWebBrowser() (string, desktopFiles.DesktopFile, error) {
+	return DefaultSchemeHandler("http")
+}
+
+// IsDefaultWebBrowser reports whether an application is the default web
+// browser, as "xdg-settings check default-web-browser", for browsers offering
+// to become the default.
+func IsDefaultWebBrowser(desktopID string) (bool, error) {
+	apps, err := LoadMIMEApps()
+	if err != nil {
+		return false, err
+	}
+	return apps.IsDefaultWebBrowser(desktopID), nil
+}
+
+// SetDefaultWebBrowser makes an installed application the default web browser
+// in the user's mimeapps.list: the default for http and https URIs and HTML
+// files, as "xdg-settings set default-web-browser".
+func SetDefaultWebBrowser(desktopID string) error {
+	desktopID = desktopFileID(desktopID)
+	apps, err := LoadMIMEApps()
+	if err != nil {
+		return err
+	}
+	if !apps.installed(desktopID) {
+		return fmt.Errorf("%w: %s", ErrNotInstalled, desktopID)
+	}
+	return setDefaults(browserTypes, desktopID, true)
+}
+
+// IsDefaultSchemeHandler reports whether an application is the default handler
+// of a URI scheme, as "xdg-settings check default-url-scheme-handler".
+func IsDefaultSchemeHandler(scheme, desktopID string) (bool, error) {
+	apps, err := LoadMIMEApps()
+	if err != nil {
+		return false, err
+	}
+	return apps.IsDefaultSchemeHandler(scheme, desktopID), nil
+}
@@ -0,0 +1,151 @@
+/*
+	libxdg-go - An implementaion of various freedesktop specifications in go
+    Copyright (C) 2025 MiracleOS Contributors
+
+    This program is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    This program is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+*/
+
+package mime
+
+import (
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/MiracleOS-Team/libxdg-go/icons"
+)
+
+// currentLocale returns the locale messages are shown in, e.g. "pt_BR.UTF-8".
+func currentLocale() string {
+	for _, name := range []string{"LC_ALL", "LC_MESSAGES", "LANG"} {
+		if locale := os.Getenv(name); locale != "" {
+			return locale
+		}
+	}
+	return "C"
+}
+
+// localeCandidates returns the locale names to look translations up with, most
+// specific first: lang_COUNTRY@MODIFIER, lang_COUNTRY, lang@MODIFIER, lang.
+func localeCandidates(locale string) []string {
+	locale, modifier, _ := strings.Cut(locale, "@")
+	locale, _, _ = strings.Cut(locale, ".")
+	lang, country, _ := strings.Cut(locale, "_")
+	if lang == "" || lang == "C" || lang == "POSIX" {
+		return nil
+	}
+
+	var candidates []string
+	if country != "" && modifier != "" {
+		candidates = append(candidates, lang+"_"+country+"@"+modifier)
+	}
+	if country != "" {
+		candidates = append(candidates, lang+"_"+country)
+	}
+	if modifier != "" {
+		candidates = append(candidates, lang+"@"+modifier)
+	}
+	return append(candidates, lang)
+}
+
+// LocalizedComment returns the description translated for a locale such as
+// "de_DE.UTF-8", or the untranslated one.
+func (info *TypeInfo) LocalizedComment(locale string) string {
+	for _, candidate := range localeCandidates(locale) {
+		if comment, exists := info.Comments[candidate]; exists {
+			return comment
+		}
+	}
+	return info.Comment
+}
+
+// Comment returns the description of a type in the user's language, e.g.
+// "PNG-Bild", or the type itself if it has none.
+func (db *Database) Comment(mimeType string) string {
+	info, err := db.Info(mimeType)
+	if err != nil || info.Comment == "" {
+		return mimeType
+	}
+	return info.LocalizedComment(currentLocale())
+}
+
+// Icon returns the icon name of a type, e.g. "image-png": the one given by the
+// icons file, else the type with "/" replaced by "-".
+func (db *Database) Icon(mimeType string) string {
+	mimeType = db.unalias(mimeType)
+	if icon, exists := db.icons[mimeType]; exists {
+		return icon
+	}
+	return strings.ReplaceAll(mimeType, "/", "-")
+}
+
+// GenericIcon returns the icon name of the kind of a type, e.g.
+// "image-x-generic": the one given by the generic-icons file, else the media
+// type followed by "-x-generic".
+func (db *Database) GenericIcon(mimeType string) string {
+	mimeType = db.unalias(mimeType)
+	if icon, exists := db.genericIcons[mimeType]; exists {
+		return icon
+	}
+	media, _, _ := strings.Cut(mimeType, "/")
+	return media + "-x-generic"
+}
+
+// IconNames returns the icon names to look up in the theme for a type, in order:
+// its icon, its generic icon and those of its parents, ending with
+// "application-octet-stream".
+func (db *Database) IconNames(mimeType string) []string {
+	var names []string
+	add := func(name string) {
+		if !slices.Contains(names, name) {
+			names = append(names, name)
+		}
+	}
+	add(db.Icon(mimeType))
+	add(db.GenericIcon(mimeType))
+	for _, parent := range db.Ancestors(mimeType) {
+		add(db.Icon(parent))
+	}
+	add("application-octet-stream")
+	return names
+}
+
+// FindIcon returns the path of the themed icon of a type, trying each of its
+// IconNames.
+func (db *Database) FindIcon(mimeType string, size, scale int) (string, error) {
+	var err error
+	for _, name := range db.IconNames(mimeType) {
+		var path string
+		if path, err = icons.FindIconDefaults(name, size, scale, ""); err == nil {
+			return path, nil
+		}
+	}
+	return "", err
+}
+
+// Comment returns the localized description of a type using the default database.
+func Comment(mimeType string) string {
+	return Default().Comment(mimeType)
+}
+
+// Icon returns the icon name of a type using the default database.
+func Icon(mimeType string) string {
+	return Default().Icon(mimeType)
+}
+
+// GenericIcon returns the generic icon name of a type using the default database.
+func GenericIcon(mimeType string) string {
+	return Default().GenericIcon(mimeType)
+}
@@ -0,0 +1,190 @@
+/*
+	libxdg-go - An implementaion of various freedesktop specifications in go
+    Copyright (C) 2025 MiracleOS Contributors
+
+    This program is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    This program is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+*/
+
+package mime
+
+import (
+	"bufio"
+	"encoding/xml"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// noGlobs in a globs2 file removes the globs of a type from lower precedence directories.
+const noGlobs = "__NOGLOBS__"
+
+// TypeInfo is the description of a MIME type, from its XML file.
+type TypeInfo struct {
+	Type string
+	// Comment is the untranslated description, e.g. "PNG image".
+	Comment string
+	// Comments are the translated descriptions, by locale.
+	Comments        map[string]string
+	Acronym         string
+	ExpandedAcronym string
+}
+
+// readLines calls fn with every line of a file that isn't empty or a comment.
+func readLines(path string, fn func(line string)) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line != "" && !strings.HasPrefix(line, "#") {
+			fn(line)
+		}
+	}
+	return scanner.Err()
+}
+
+// loadGlobs reads a globs2 file: "weight:type:pattern[:flags]" lines.
+func (db *Database) loadGlobs(path string) error {
+	var globs []Glob
+	err := readLines(path, func(line string) {
+		fields := strings.Split(line, ":")
+		if len(fields) < 3 {
+			return
+		}
+		weight, err := strconv.Atoi(fields[0])
+		if err != nil {
+			return
+		}
+		g := Glob{Weight: weight, MIMEType: fields[1], Pattern: fields[2]}
+		if len(fields) > 3 {
+			g.CaseSensitive = slices.Contains(strings.Split(fields[3], ","), "cs")
+		}
+		globs = append(globs, g)
+	})
+	if err != nil {
+		return err
+	}
+
+	// The types this directory defines globs for replace those of lower precedence
+	// directories only if it says so with __NOGLOBS__.
+	for _, g := range globs {
+		if g.Pattern == noGlobs {
+			db.globs = slices.DeleteFunc(db.globs, func(other Glob) bool { return other.MIMEType == g.MIMEType })
+		}
+	}
+	for _, g := range globs {
+		if g.Pattern != noGlobs {
+			db.globs = append(db.globs, g)
+		}
+	}
+	return nil
+}
+
+// loadAliases reads an aliases file: "alias type" lines.
+func (db *Database) loadAliases(path string) error {
+	return readLines(path, func(line string) {
+		if alias, target, found := strings.Cut(line, " "); found {
+			db.aliases[alias] = target
+		}
+	})
+}
+
+// loadSubclasses reads a subclasses file: "type parent" lines.
+func (db *Database) loadSubclasses(path string) error {
+	return readLines(path, func(line string) {
+		if child, parent, found := strings.Cut(line, " "); found && !slices.Contains(db.parents[child], parent) {
+			db.parents[child] = append(db.parents[child], parent)
+		}
+	})
+}
+
+// loadIcons reads an icons or generic-icons file: "type:icon" lines.
+func loadIcons(path string, icons map[string]string) error {
+	return readLines(path, func(line string) {
+		if mimeType, icon, found := strings.Cut(line, ":"); found {
+			icons[mimeType] = icon
+		}
+	})
+}
+
+// xmlType is the structure of the XML file of a MIME type.
+type xmlType struct {
+	Type     string `xml:"type,attr"`
+	Comments []struct {
+		Lang  string `xml:"http://www.w3.org/XML/1998/namespace lang,attr"`
+		Value string `xml:",chardata"`
+	} `xml:"comment"`
+	Acronym         string `xml:"acronym"`
+	ExpandedAcronym string `xml:"expanded-acronym"`
+}
+
+// Info returns the description of a MIME type, read from the first mime directory
+// that has its XML file. Aliases are resolved.
+func (db *Database) Info(mimeType string) (*TypeInfo, error) {
+	mimeType = db.unalias(mimeType)
+
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	if info, cached := db.types[mimeType]; cached {
+		if info == nil {
+			return nil, ErrUnknownType
+		}
+		return info, nil
+	}
+
+	for _, dir := range db.dirs {
+		data, err := os.ReadFile(filepath.Join(dir, mimeType+".xml"))
+		if err != nil {
+			continue
+		}
+		var parsed xmlType
+		if err := xml.Unmarshal(data, &parsed); err != nil {
+			return nil, err
+		}
+		info := &TypeInfo{
+			Type:            mimeType,
+			Comments:        make(map[string]string),
+			Acronym:         parsed.Acronym,
+			ExpandedAcronym: parsed.ExpandedAcronym,
+		}
+		for _, c := range parsed.Comments {
+			if c.Lang == "" {
+				info.Comment = c.Value
+			} else {
+				info.Comments[c.Lang] = c.Value
+			}
+		}
+		db.types[mimeType] = info
+		return info, nil
+	}
+	db.types[mimeType] = nil
+	return nil, ErrUnknownType
+}
+
+// unalias returns the type an alias stands for, or mimeType itself.
+func (db *Database) unalias(mimeType string) string {
+	mimeType = strings.ToLower(mimeType)
+	if target, exists := db.aliases[mimeType]; exists {
+		return target
+	}
+	return mimeType
+}
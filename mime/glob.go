@@ -0,0 +1,126 @@
+/*
+	libxdg-go - An implementaion of various freedesktop specifications in go
+    Copyright (C) 2025 MiracleOS Contributors
+
+    This program is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    This program is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+*/
+
+package mime
+
+import (
+	"path"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// MIMEForFilename returns the types whose globs match a filename, best first,
+// each with its best matching glob. Globs are ranked by weight, then literal
+// names before patterns, then longer patterns before shorter ones, as they are
+// more specific. Case-insensitive globs match regardless of case, but rank
+// below globs matching with the same case, so "x.C" is C++ and "x.c" C. A directory
+// part of name is ignored.
+//
+// When the first candidates tie, the name alone can't tell the type apart; see
+// DetectData for settling it with the content.
+func (db *Database) MIMEForFilename(name string) []Glob {
+	var candidates []Glob
+	for _, g := range db.globMatches(filepath.Base(name)) {
+		if !slices.ContainsFunc(candidates, func(other Glob) bool { return other.MIMEType == g.MIMEType }) {
+			candidates = append(candidates, g)
+		}
+	}
+	return candidates
+}
+
+// MIMEForFilename returns the candidate types of a filename using the default database.
+func MIMEForFilename(name string) []Glob {
+	return Default().MIMEForFilename(name)
+}
+
+// globMatches returns the globs matching a filename, best first.
+func (db *Database) globMatches(name string) []Glob {
+	type match struct {
+		Glob
+		// exact is whether the glob matches without ignoring case.
+		exact bool
+	}
+	lower := strings.ToLower(name)
+	var matches []match
+	for _, g := range db.globs {
+		if globMatch(g.Pattern, name) {
+			matches = append(matches, match{g, true})
+		} else if !g.CaseSensitive && globMatch(strings.ToLower(g.Pattern), lower) {
+			matches = append(matches, match{g, false})
+		}
+	}
+	sort.SliceStable(matches, func(i, j int) bool {
+		a, b := matches[i], matches[j]
+		if a.Weight != b.Weight {
+			return a.Weight > b.Weight
+		}
+		if a.exact != b.exact {
+			return a.exact
+		}
+		if a.literal() != b.literal() {
+			return a.literal()
+		}
+		return len(a.Pattern) > len(b.Pattern)
+	})
+
+	globs := make([]Glob, 0, len(matches))
+	for _, m := range matches {
+		globs = append(globs, m.Glob)
+	}
+	return globs
+}
+
+// literal reports whether the glob is a plain filename rather than a pattern.
+func (g Glob) literal() bool {
+	return !strings.ContainsAny(g.Pattern, "*?[")
+}
+
+// globMatch matches a filename against a pattern, with shortcuts for the common
+// literal and "*.ext" patterns.
+func globMatch(pattern, name string) bool {
+	if !strings.ContainsAny(pattern, "*?[") {
+		return pattern == name
+	}
+	if rest, found := strings.CutPrefix(pattern, "*"); found && !strings.ContainsAny(rest, "*?[") {
+		return strings.HasSuffix(name, rest)
+	}
+	matched, _ := path.Match(pattern, name)
+	return matched
+}
+
+// globTypes returns the types of the best glob matches of a filename: those with
+// the highest weight and, among them, the longest pattern.
+func (db *Database) globTypes(name string) []string {
+	name = filepath.Base(name)
+	matches := db.globMatches(name)
+	var types []string
+	for _, g := range matches {
+		best := matches[0]
+		if g.Weight != best.Weight || globMatch(g.Pattern, name) != globMatch(best.Pattern, name) ||
+			g.literal() != best.literal() || len(g.Pattern) != len(best.Pattern) {
+			break
+		}
+		if !slices.Contains(types, g.MIMEType) {
+			types = append(types, g.MIMEType)
+		}
+	}
+	return types
+}
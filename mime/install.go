@@ -0,0 +1,319 @@
+/*
+	libxdg-go - An implementaion of various freedesktop specifications in go
+    Copyright (C) 2025 MiracleOS Contributors
+
+    This program is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    This program is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+*/
+
+package mime
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	basedir "github.com/MiracleOS-Team/libxdg-go/baseDir"
+	"github.com/MiracleOS-Team/libxdg-go/internal/atomicfile"
+)
+
+// ErrInvalidPackage is returned for XML files that aren't MIME packages.
+var ErrInvalidPackage = errors.New("not a shared-mime-info package")
+
+const (
+	packagesDir = "packages"
+	// overridePackage is applied after the other packages.
+	overridePackage = "Override.xml"
+	mimeNamespace   = "http://www.freedesktop.org/standards/shared-mime-info"
+)
+
+// UserMIMEDir returns the user's mime directory, $XDG_DATA_HOME/mime, the one
+// packages are installed to.
+func UserMIMEDir() string {
+	return filepath.Join(basedir.GetXDGDirectory("data").(string), "mime")
+}
+
+// xmlPackage is a MIME package: a mime-info XML file defining types.
+type xmlPackage struct {
+	XMLName xml.Name         `xml:"mime-info"`
+	Types   []xmlPackageType `xml:"mime-type"`
+}
+
+type xmlPackageType struct {
+	Type  string `xml:"type,attr"`
+	Globs []struct {
+		Pattern       string `xml:"pattern,attr"`
+		Weight        string `xml:"weight,attr"`
+		CaseSensitive string `xml:"case-sensitive,attr"`
+	} `xml:"glob"`
+	GlobDeleteAll *struct{} `xml:"glob-deleteall"`
+	Aliases       []struct {
+		Type string `xml:"type,attr"`
+	} `xml:"alias"`
+	Parents []struct {
+		Type string `xml:"type,attr"`
+	} `xml:"sub-class-of"`
+	Icon *struct {
+		Name string `xml:"name,attr"`
+	} `xml:"icon"`
+	GenericIcon *struct {
+		Name string `xml:"name,attr"`
+	} `xml:"generic-icon"`
+	Inner string `xml:",innerxml"`
+}
+
+func readPackage(path string) (*xmlPackage, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return parsePackage(path, data)
+}
+
+// parsePackage parses a package read from path.
+func parsePackage(path string, data []byte) (*xmlPackage, error) {
+	var pkg xmlPackage
+	if err := xml.Unmarshal(data, &pkg); err != nil {
+		return nil, fmt.Errorf("%w: %s: %v", ErrInvalidPackage, path, err)
+	}
+	for _, t := range pkg.Types {
+		if !validType(t.Type) {
+			return nil, fmt.Errorf("%w: %s: invalid type %q", ErrInvalidPackage, path, t.Type)
+		}
+	}
+	return &pkg, nil
+}
+
+// validType reports whether a type is media/subtype with both parts RFC 2045
+// tokens, and neither "." nor "..", so that it is safe as a file path.
+func validType(mimeType string) bool {
+	media, sub, found := strings.Cut(mimeType, "/")
+	return found && validToken(media) && validToken(sub)
+}
+
+// validToken reports whether s is an RFC 2045 token other than "." and "..".
+func validToken(s string) bool {
+	if s == "" || s == "." || s == ".." {
+		return false
+	}
+	for _, c := range []byte(s) {
+		if c <= ' ' || c >= 0x7f || strings.IndexByte(`()<>@,;:\"/[]?=`, c) >= 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// InstallPackage installs a MIME package, an XML file like those of
+// /usr/share/mime/packages, into the user's mime directory and updates the
+// database, as "xdg-mime install" does. Packages should be named after their
+// vendor, e.g. "example-myapp.xml".
+func InstallPackage(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	if _, err := parsePackage(path, data); err != nil {
+		return err
+	}
+	dir := UserMIMEDir()
+	if err := atomicfile.WriteFile(filepath.Join(dir, packagesDir, filepath.Base(path)), data, 0644); err != nil {
+		return err
+	}
+	return updateAndReload(dir)
+}
+
+// UninstallPackage removes a package installed by InstallPackage, by file name
+// with or without ".xml", and updates the database.
+func UninstallPackage(name string) error {
+	if !strings.HasSuffix(name, ".xml") {
+		name += ".xml"
+	}
+	dir := UserMIMEDir()
+	if err := os.Remove(filepath.Join(dir, packagesDir, filepath.Base(name))); err != nil {
+		return err
+	}
+	return updateAndReload(dir)
+}
+
+func updateAndReload(dir string) error {
+	if err := UpdateDatabase(dir); err != nil {
+		return err
+	}
+	return ReloadDefault()
+}
+
+// UpdateDatabase rebuilds the lookup files of a mime directory from its packages
+// with update-mime-database, or with Reindex if it isn't installed or fails.
+func UpdateDatabase(dir string) error {
+	if _, err := exec.LookPath("update-mime-database"); err == nil {
+		output, err := exec.Command("update-mime-database", dir).CombinedOutput()
+		if err == nil {
+			return nil
+		}
+		slog.Warn("update-mime-database failed, reindexing", "dir", dir, "error", err, "output", string(output))
+	}
+	return Reindex(dir)
+}
+
+// Reindex rebuilds the globs2, aliases, subclasses, icons and generic-icons files
+// and the per-type XML files of a mime directory from its packages. It is a
+// lightweight replacement for update-mime-database: magic rules aren't
+// compiled, and the magic and mime.cache files it left are removed so that
+// readers don't use stale data.
+func Reindex(dir string) error {
+	names, err := filepath.Glob(filepath.Join(dir, packagesDir, "*.xml"))
+	if err != nil {
+		return err
+	}
+	sort.Slice(names, func(i, j int) bool {
+		// Override.xml is applied last.
+		if (filepath.Base(names[i]) == overridePackage) != (filepath.Base(names[j]) == overridePackage) {
+			return filepath.Base(names[j]) == overridePackage
+		}
+		return names[i] < names[j]
+	})
+
+	var (
+		globs        []Glob
+		aliases      = make(map[string]string)
+		parents      = make(map[string][]string)
+		icons        = make(map[string]string)
+		genericIcons = make(map[string]string)
+		inner        = make(map[string]string)
+	)
+	for _, name := range names {
+		pkg, err := readPackage(name)
+		if err != nil {
+			slog.Warn("Skipping invalid MIME package", "path", name, "error", err)
+			continue
+		}
+		for _, t := range pkg.Types {
+			inner[t.Type] += t.Inner
+			if t.GlobDeleteAll != nil {
+				globs = slices.DeleteFunc(globs, func(g Glob) bool { return g.MIMEType == t.Type })
+			}
+			for _, g := range t.Globs {
+				weight := 50
+				if g.Weight != "" {
+					if weight, err = strconv.Atoi(g.Weight); err != nil {
+						weight = 50
+					}
+				}
+				globs = append(globs, Glob{Weight: weight, MIMEType: t.Type, Pattern: g.Pattern, CaseSensitive: g.CaseSensitive == "true"})
+			}
+			for _, a := range t.Aliases {
+				aliases[a.Type] = t.Type
+			}
+			for _, p := range t.Parents {
+				if !slices.Contains(parents[t.Type], p.Type) {
+					parents[t.Type] = append(parents[t.Type], p.Type)
+				}
+			}
+			if t.Icon != nil {
+				icons[t.Type] = t.Icon.Name
+			}
+			if t.GenericIcon != nil {
+				genericIcons[t.Type] = t.GenericIcon.Name
+			}
+		}
+	}
+
+	sort.SliceStable(globs, func(i, j int) bool { return globs[i].Weight > globs[j].Weight })
+	var globsFile bytes.Buffer
+	for _, g := range globs {
+		fmt.Fprintf(&globsFile, "%d:%s:%s", g.Weight, g.MIMEType, g.Pattern)
+		if g.CaseSensitive {
+			globsFile.WriteString(":cs")
+		}
+		globsFile.WriteByte('\n')
+	}
+
+	var subclasses []string
+	for child, list := range parents {
+		for _, parent := range list {
+			subclasses = append(subclasses, child+" "+parent)
+		}
+	}
+	files := map[string][]string{
+		"aliases":       pairs(aliases, " "),
+		"subclasses":    subclasses,
+		"icons":         pairs(icons, ":"),
+		"generic-icons": pairs(genericIcons, ":"),
+	}
+	if err := atomicfile.WriteFile(filepath.Join(dir, "globs2"), globsFile.Bytes(), 0644); err != nil {
+		return err
+	}
+	for name, lines := range files {
+		sort.Strings(lines)
+		if err := atomicfile.WriteFile(filepath.Join(dir, name), []byte(strings.Join(append(lines, ""), "\n")), 0644); err != nil {
+			return err
+		}
+	}
+	for _, name := range []string{"magic", "mime.cache"} {
+		if err := os.Remove(filepath.Join(dir, name)); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+	}
+	return writeTypeFiles(dir, inner)
+}
+
+// pairs returns "key<sep>value" lines.
+func pairs(m map[string]string, sep string) []string {
+	lines := make([]string, 0, len(m))
+	for key, value := range m {
+		lines = append(lines, key+sep+value)
+	}
+	return lines
+}
+
+// writeTypeFiles writes the XML file of each type, media/subtype.xml, and removes
+// those of types no package defines anymore.
+func writeTypeFiles(dir string, inner map[string]string) error {
+	for mimeType := range inner {
+		if !validType(mimeType) {
+			return fmt.Errorf("%w: invalid type %q", ErrInvalidPackage, mimeType)
+		}
+	}
+	existing, err := filepath.Glob(filepath.Join(dir, "*", "*.xml"))
+	if err != nil {
+		return err
+	}
+	for _, path := range existing {
+		rel, _ := filepath.Rel(dir, path)
+		rel = filepath.ToSlash(rel)
+		if _, defined := inner[strings.TrimSuffix(rel, ".xml")]; !defined && !strings.HasPrefix(rel, packagesDir+"/") {
+			os.Remove(path)
+		}
+	}
+
+	for mimeType, content := range inner {
+		var buf bytes.Buffer
+		buf.WriteString(xml.Header)
+		fmt.Fprintf(&buf, "<mime-type xmlns=%q type=%q>%s</mime-type>\n", mimeNamespace, mimeType, content)
+		if err := atomicfile.WriteFile(filepath.Join(dir, filepath.FromSlash(mimeType)+".xml"), buf.Bytes(), 0644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,82 @@
+/*
+	libxdg-go - An implementaion of various freedesktop specifications in go
+    Copyright (C) 2025 MiracleOS Contributors
+
+    This program is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    This program is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+*/
+
+package mime
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestValidType(t *testing.T) {
+	valid := []string{"text/plain", "application/vnd.oasis.opendocument.text", "image/svg+xml", "x-scheme-handler/http", "application/x-7z-compressed"}
+	invalid := []string{"", "text", "text/", "/plain", "../evil", "text/..", "./x", "text/a/b", `text\plain`, "text/pl ain", "text/a:b", "text/a\x00", "tëxt/plain"}
+	for _, mimeType := range valid {
+		if !validType(mimeType) {
+			t.Errorf("validType(%q) = false, want true", mimeType)
+		}
+	}
+	for _, mimeType := range invalid {
+		if validType(mimeType) {
+			t.Errorf("validType(%q) = true, want false", mimeType)
+		}
+	}
+}
+
+func TestReindexRemovesStaleCaches(t *testing.T) {
+	dir := t.TempDir()
+	pkg := `<?xml version="1.0"?>
+<mime-info xmlns="http://www.freedesktop.org/standards/shared-mime-info">
+  <mime-type type="text/x-foo">
+    <glob pattern="*.foo"/>
+  </mime-type>
+</mime-info>
+`
+	if err := os.MkdirAll(filepath.Join(dir, packagesDir), 0755); err != nil {
+		t.Fatal(err)
+	}
+	for name, data := range map[string]string{
+		filepath.Join(packagesDir, "test.xml"): pkg,
+		"mime.cache":                           "stale",
+		"magic":                                "stale",
+	} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(data), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := Reindex(dir); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"mime.cache", "magic"} {
+		if _, err := os.Stat(filepath.Join(dir, name)); !errors.Is(err, os.ErrNotExist) {
+			t.Errorf("%s was left in place: %v", name, err)
+		}
+	}
+	globs, err := os.ReadFile(filepath.Join(dir, "globs2"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(globs), "50:text/x-foo:*.foo") {
+		t.Errorf("globs2 lacks the package's glob:\n%s", globs)
+	}
+}
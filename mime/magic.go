@@ -0,0 +1,444 @@
+/*
+	libxdg-go - An implementaion of various freedesktop specifications in go
+    Copyright (C) 2025 MiracleOS Contributors
+
+    This program is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    This program is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+*/
+
+package mime
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+)
+
+const (
+	magicHeader = "MIME-Magic\x00\n"
+	noMagic     = "__NOMAGIC__"
+
+	// maxSniffLen bounds how much of a file DetectReader reads, whatever the rules ask for.
+	maxSniffLen = 1 << 20
+	// textSniffLen is how much data is checked for control characters to tell text from binary.
+	textSniffLen = 128
+
+	// Fallback types, per the specification.
+	TypeOctetStream = "application/octet-stream"
+	TypeText        = "text/plain"
+	TypeDirectory   = "inode/directory"
+	TypeEmpty       = "application/x-zerosize"
+)
+
+// magicMatch is a section of a magic file: the rules identifying one type.
+type magicMatch struct {
+	priority int
+	mimeType string
+	rules    []*magicRule
+}
+
+// magicRule compares data at some offsets to a value. A rule matches if its value
+// matches and, if it has children, any of them matches too.
+type magicRule struct {
+	start    int
+	rangeLen int
+	value    []byte
+	mask     []byte
+	children []*magicRule
+}
+
+// extent is how far into the data the rule and its children look.
+func (r *magicRule) extent() int {
+	extent := r.start + r.rangeLen - 1 + len(r.value)
+	for _, child := range r.children {
+		extent = max(extent, child.extent())
+	}
+	return extent
+}
+
+func (r *magicRule) matches(data []byte) bool {
+	for offset := r.start; offset < r.start+r.rangeLen; offset++ {
+		if offset+len(r.value) > len(data) {
+			break
+		}
+		if r.matchesAt(data[offset : offset+len(r.value)]) {
+			if len(r.children) == 0 {
+				return true
+			}
+			for _, child := range r.children {
+				if child.matches(data) {
+					return true
+				}
+			}
+			return false
+		}
+	}
+	return false
+}
+
+func (r *magicRule) matchesAt(data []byte) bool {
+	if r.mask == nil {
+		return bytes.Equal(data, r.value)
+	}
+	for i, b := range data {
+		if b&r.mask[i] != r.value[i]&r.mask[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// loadMagic reads a magic file. Its sections come before those of lower precedence
+// directories, which __NOMAGIC__ removes for a type.
+func (db *Database) loadMagic(path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	matches, err := parseMagic(bufio.NewReader(file))
+	if err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	for _, m := range matches {
+		if m.rules == nil {
+			db.magic = deleteMagic(db.magic, m.mimeType)
+		}
+	}
+	var added []*magicMatch
+	for _, m := range matches {
+		if m.rules != nil {
+			added = append(added, m)
+		}
+	}
+	db.magic = append(added, db.magic...)
+	sort.SliceStable(db.magic, func(i, j int) bool { return db.magic[i].priority > db.magic[j].priority })
+	for _, m := range db.magic {
+		for _, r := range m.rules {
+			db.magicExtent = max(db.magicExtent, r.extent())
+		}
+	}
+	return nil
+}
+
+func deleteMagic(matches []*magicMatch, mimeType string) []*magicMatch {
+	kept := matches[:0]
+	for _, m := range matches {
+		if m.mimeType != mimeType {
+			kept = append(kept, m)
+		}
+	}
+	return kept
+}
+
+var errBadMagic = errors.New("invalid magic file")
+
+// parseMagic parses the binary magic format. Sections reduced to __NOMAGIC__ are
+// returned with nil rules.
+func parseMagic(r *bufio.Reader) ([]*magicMatch, error) {
+	header := make([]byte, len(magicHeader))
+	if _, err := io.ReadFull(r, header); err != nil || string(header) != magicHeader {
+		return nil, errBadMagic
+	}
+
+	var matches []*magicMatch
+	var current *magicMatch
+	// stack holds the last rule of each indent level of the current section.
+	var stack []*magicRule
+	for {
+		b, err := r.ReadByte()
+		if err == io.EOF {
+			return matches, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if b == '[' {
+			line, err := r.ReadString('\n')
+			if err != nil {
+				return nil, errBadMagic
+			}
+			if len(line) < 3 || line[len(line)-2] != ']' {
+				return nil, errBadMagic
+			}
+			priority, mimeType, found := bytes.Cut([]byte(line[:len(line)-2]), []byte(":"))
+			if !found {
+				return nil, errBadMagic
+			}
+			p, err := strconv.Atoi(string(priority))
+			if err != nil {
+				return nil, errBadMagic
+			}
+			current = &magicMatch{priority: p, mimeType: string(mimeType), rules: []*magicRule{}}
+			matches = append(matches, current)
+			stack = nil
+			continue
+		}
+		if current == nil {
+			return nil, errBadMagic
+		}
+		r.UnreadByte()
+
+		if peek, _ := r.Peek(len(noMagic)); string(peek) == noMagic {
+			r.ReadString('\n')
+			current.rules = nil
+			continue
+		}
+
+		rule, indent, err := parseMagicRule(r)
+		if err != nil {
+			return nil, err
+		}
+		if current.rules == nil {
+			continue
+		}
+		switch {
+		case indent == 0:
+			current.rules = append(current.rules, rule)
+		case indent <= len(stack):
+			parent := stack[indent-1]
+			parent.children = append(parent.children, rule)
+		default:
+			return nil, errBadMagic
+		}
+		stack = append(stack[:indent], rule)
+	}
+}
+
+// parseMagicRule parses "[indent]>start=value[&mask][~word-size][+range-length]\n".
+func parseMagicRule(r *bufio.Reader) (*magicRule, int, error) {
+	readNumber := func(end byte) (int, error) {
+		s, err := r.ReadString(end)
+		if err != nil {
+			return 0, errBadMagic
+		}
+		if len(s) == 1 {
+			return 0, nil
+		}
+		return parseMagicNumber(s[:len(s)-1])
+	}
+
+	indent, err := readNumber('>')
+	if err != nil {
+		return nil, 0, errBadMagic
+	}
+	rule := &magicRule{rangeLen: 1}
+	if rule.start, err = readNumber('='); err != nil {
+		return nil, 0, errBadMagic
+	}
+	var length uint16
+	if err := binary.Read(r, binary.BigEndian, &length); err != nil {
+		return nil, 0, errBadMagic
+	}
+	rule.value = make([]byte, length)
+	if _, err := io.ReadFull(r, rule.value); err != nil {
+		return nil, 0, errBadMagic
+	}
+
+	wordSize := 1
+	for {
+		b, err := r.ReadByte()
+		if err != nil {
+			return nil, 0, errBadMagic
+		}
+		switch b {
+		case '&':
+			rule.mask = make([]byte, length)
+			if _, err := io.ReadFull(r, rule.mask); err != nil {
+				return nil, 0, errBadMagic
+			}
+			continue
+		case '~', '+':
+			digits := []byte{}
+			for {
+				next, err := r.ReadByte()
+				if err != nil {
+					return nil, 0, errBadMagic
+				}
+				if next < '0' || next > '9' {
+					r.UnreadByte()
+					break
+				}
+				digits = append(digits, next)
+			}
+			n, err := parseMagicNumber(string(digits))
+			if err != nil {
+				return nil, 0, errBadMagic
+			}
+			if b == '~' {
+				wordSize = n
+			} else {
+				rule.rangeLen = n
+			}
+			continue
+		case '\n':
+		default:
+			// Unknown extensions run to the end of the line.
+			if _, err := r.ReadString('\n'); err != nil {
+				return nil, 0, errBadMagic
+			}
+		}
+		break
+	}
+
+	// Values are big endian; words are compared in host order.
+	if wordSize > 1 && binary.NativeEndian.Uint16([]byte{0, 1}) != 1 {
+		swapWords(rule.value, wordSize)
+		swapWords(rule.mask, wordSize)
+	}
+	return rule, indent, nil
+}
+
+// parseMagicNumber parses an indent, offset, word size or range length. They
+// are never negative, and are kept small enough that offset+range cannot overflow.
+func parseMagicNumber(s string) (int, error) {
+	n, err := strconv.ParseUint(s, 10, 31)
+	if err != nil {
+		return 0, errBadMagic
+	}
+	return int(n), nil
+}
+
+func swapWords(b []byte, size int) {
+	for i := 0; i+size <= len(b); i += size {
+		for j := 0; j < size/2; j++ {
+			b[i+j], b[i+size-1-j] = b[i+size-1-j], b[i+j]
+		}
+	}
+}
+
+// MatchMagic returns the type of the highest priority magic rule matching data,
+// with its priority.
+func (db *Database) MatchMagic(data []byte) (string, int, bool) {
+	for _, m := range db.magic {
+		for _, r := range m.rules {
+			if r.matches(data) {
+				return m.mimeType, m.priority, true
+			}
+		}
+	}
+	return "", 0, false
+}
+
+// SniffLen returns how many bytes of a file the magic rules look at.
+func (db *Database) SniffLen() int {
+	return min(max(db.magicExtent, textSniffLen), maxSniffLen)
+}
+
+// looksLikeText reports whether data has no control characters other than
+// whitespace at its start. Bytes with the high bit set are fine, as in UTF-8.
+func looksLikeText(data []byte) bool {
+	data = data[:min(len(data), textSniffLen)]
+	for _, b := range data {
+		if b < 0x20 && b != '\n' && b != '\r' && b != '\t' && b != '\f' && b != 0x1b || b == 0x7f {
+			return false
+		}
+	}
+	return true
+}
+
+// DetectData returns the type of data named name, which may be empty, following
+// the algorithm recommended by the specification: globs first, then magic, and
+// globs again if they name a subclass of the magic type.
+func (db *Database) DetectData(name string, data []byte) string {
+	var globTypes []string
+	if name != "" {
+		globTypes = db.globTypes(name)
+		if len(globTypes) == 1 {
+			return globTypes[0]
+		}
+	}
+	if len(data) == 0 && len(globTypes) == 0 {
+		return TypeEmpty
+	}
+
+	magicType, _, found := db.MatchMagic(data)
+	if found {
+		if len(globTypes) == 0 {
+			return magicType
+		}
+		for _, t := range globTypes {
+			if db.IsSubclassOf(t, magicType) {
+				return t
+			}
+		}
+	}
+	if len(globTypes) > 0 {
+		return globTypes[0]
+	}
+	if found {
+		return magicType
+	}
+	if looksLikeText(data) {
+		return TypeText
+	}
+	return TypeOctetStream
+}
+
+// DetectReader returns the type of the data read from r, reading no more than the
+// magic rules need. name is used for glob matching and may be empty.
+func (db *Database) DetectReader(name string, r io.Reader) (string, error) {
+	data, err := io.ReadAll(io.LimitReader(r, int64(db.SniffLen())))
+	if err != nil {
+		return "", err
+	}
+	return db.DetectData(name, data), nil
+}
+
+// DetectReaderAt is DetectReader for random access sources of the given size.
+func (db *Database) DetectReaderAt(name string, r io.ReaderAt, size int64) (string, error) {
+	return db.DetectReader(name, io.NewSectionReader(r, 0, size))
+}
+
+// DetectFile returns the type of a file, reading its content only if its name
+// isn't conclusive. Directories are inode/directory.
+func (db *Database) DetectFile(path string) (string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", err
+	}
+	if info.IsDir() {
+		return TypeDirectory, nil
+	}
+	name := info.Name()
+	if types := db.globTypes(name); len(types) == 1 {
+		return types[0], nil
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+	return db.DetectReader(name, file)
+}
+
+// DetectMIME returns the type of a file using the default database.
+func DetectMIME(path string) (string, error) {
+	return Default().DetectFile(path)
+}
+
+// DetectMIMEReader returns the type of the data read from r using the default
+// database. name is used for glob matching and may be empty.
+func DetectMIMEReader(name string, r io.Reader) (string, error) {
+	return Default().DetectReader(name, r)
+}
@@ -0,0 +1,62 @@
+/*
+	libxdg-go - An implementaion of various freedesktop specifications in go
+    Copyright (C) 2025 MiracleOS Contributors
+
+    This program is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    This program is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+*/
+
+package mime
+
+import (
+	"bufio"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestParseMagicBadSectionHeader(t *testing.T) {
+	for _, header := range []string{"[\n", "[]\n", "[50\n", "[50]\n", "[x:text/plain]\n", "[50:text/plain"} {
+		_, err := parseMagic(bufio.NewReader(strings.NewReader(magicHeader + header)))
+		if !errors.Is(err, errBadMagic) {
+			t.Errorf("section header %q: got error %v, want errBadMagic", header, err)
+		}
+	}
+}
+
+func TestParseMagicSection(t *testing.T) {
+	data := magicHeader + "[50:text/x-foo]\n>0=\x00\x03foo\n[40:text/x-bar]\n" + noMagic + "\n"
+	matches, err := parseMagic(bufio.NewReader(strings.NewReader(data)))
+	if err != nil {
+		t.Fatalf("parseMagic: %v", err)
+	}
+	if len(matches) != 2 {
+		t.Fatalf("got %d sections, want 2", len(matches))
+	}
+	if m := matches[0]; m.priority != 50 || m.mimeType != "text/x-foo" || len(m.rules) != 1 {
+		t.Errorf("first section: got priority %d, type %q, %d rules", m.priority, m.mimeType, len(m.rules))
+	}
+	if m := matches[1]; m.mimeType != "text/x-bar" || m.rules != nil {
+		t.Errorf("__NOMAGIC__ section: got type %q, rules %v", m.mimeType, m.rules)
+	}
+}
+
+func TestParseMagicNegativeNumbers(t *testing.T) {
+	for _, rule := range []string{"-3>0=\x00\x01a\n", ">-3=\x00\x01a\n", ">0=\x00\x01a+-2\n", ">0=\x00\x01a~-2\n"} {
+		_, err := parseMagic(bufio.NewReader(strings.NewReader(magicHeader + "[50:text/x-foo]\n" + rule)))
+		if !errors.Is(err, errBadMagic) {
+			t.Errorf("rule %q: got error %v, want errBadMagic", rule, err)
+		}
+	}
+}
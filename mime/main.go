@@ -0,0 +1,191 @@
+/*
+	libxdg-go - An implementaion of various freedesktop specifications in go
+    Copyright (C) 2025 MiracleOS Contributors
+
+    This program is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    This program is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+*/
+
+// Package mime implements the shared-mime-info specification: it reads the MIME
+// database compiled by update-mime-database from the XDG data directories.
+package mime
+
+import (
+	"errors"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sync"
+
+	basedir "github.com/MiracleOS-Team/libxdg-go/baseDir"
+)
+
+// ErrUnknownType is returned for MIME types missing from the database.
+var ErrUnknownType = errors.New("unknown MIME type")
+
+// Glob is a filename pattern associated to a MIME type.
+type Glob struct {
+	Weight        int
+	MIMEType      string
+	Pattern       string
+	CaseSensitive bool
+}
+
+// Database is a loaded MIME database. It is safe for concurrent use.
+type Database struct {
+	// dirs are the mime directories the database was loaded from, highest
+	// precedence first.
+	dirs         []string
+	globs        []Glob
+	aliases      map[string]string
+	parents      map[string][]string
+	icons        map[string]string
+	genericIcons map[string]string
+	magic        []*magicMatch
+	magicExtent  int
+
+	mu    sync.Mutex
+	types map[string]*TypeInfo
+}
+
+var (
+	defaultMu sync.Mutex
+	defaultDB *Database
+)
+
+// Default returns the database of the XDG data directories, loading it on first use.
+// A failure to load leaves it empty and is logged.
+func Default() *Database {
+	defaultMu.Lock()
+	defer defaultMu.Unlock()
+
+	if defaultDB == nil {
+		db, err := Load()
+		if err != nil {
+			slog.Error("Failed to load the MIME database", "error", err)
+			db = newDatabase(nil)
+		}
+		defaultDB = db
+	}
+	return defaultDB
+}
+
+// ReloadDefault reloads the database returned by Default, e.g. after installing
+// a package. On failure the previous database is kept.
+func ReloadDefault() error {
+	db, err := Load()
+	if err != nil {
+		return err
+	}
+	defaultMu.Lock()
+	defaultDB = db
+	defaultMu.Unlock()
+	return nil
+}
+
+// MIMEDirs returns the mime directories of the XDG data directories, highest
+// precedence first: $XDG_DATA_HOME/mime, then $XDG_DATA_DIRS/mime in order.
+func MIMEDirs() []string {
+	dirs := []string{filepath.Join(basedir.GetXDGDirectory("data").(string), "mime")}
+	for _, dir := range basedir.GetXDGDirectory("dataDirs").([]string) {
+		dirs = append(dirs, filepath.Join(dir, "mime"))
+	}
+	return dirs
+}
+
+// Load loads the database of the XDG data directories.
+func Load() (*Database, error) {
+	return LoadFrom(MIMEDirs()...)
+}
+
+// LoadFrom loads the database from mime directories, highest precedence first.
+// Missing directories and files are skipped.
+func LoadFrom(dirs ...string) (*Database, error) {
+	db := newDatabase(dirs)
+
+	// Lower precedence directories are loaded first, so higher ones override them.
+	for i := len(dirs) - 1; i >= 0; i-- {
+		dir := dirs[i]
+		loaders := []struct {
+			name string
+			load func(path string) error
+		}{
+			{"globs2", db.loadGlobs},
+			{"aliases", db.loadAliases},
+			{"subclasses", db.loadSubclasses},
+			{"icons", func(path string) error { return loadIcons(path, db.icons) }},
+			{"generic-icons", func(path string) error { return loadIcons(path, db.genericIcons) }},
+			{"magic", db.loadMagic},
+		}
+		for _, loader := range loaders {
+			err := loader.load(filepath.Join(dir, loader.name))
+			if err != nil && !errors.Is(err, os.ErrNotExist) {
+				return nil, err
+			}
+		}
+	}
+	return db, nil
+}
+
+func newDatabase(dirs []string) *Database {
+	return &Database{
+		dirs:         dirs,
+		aliases:      make(map[string]string),
+		parents:      make(map[string][]string),
+		icons:        make(map[string]string),
+		genericIcons: make(map[string]string),
+		types:        make(map[string]*TypeInfo),
+	}
+}
+
+// Dirs returns the mime directories of the database, highest precedence first.
+func (db *Database) Dirs() []string {
+	return append([]string(nil), db.dirs...)
+}
+
+// Globs returns the filename patterns of all MIME types.
+func (db *Database) Globs() []Glob {
+	return append([]Glob(nil), db.globs...)
+}
+
+// Types returns every MIME type with a glob, an alias target, a parent or an icon.
+func (db *Database) Types() []string {
+	seen := make(map[string]bool)
+	var types []string
+	add := func(t string) {
+		if !seen[t] {
+			seen[t] = true
+			types = append(types, t)
+		}
+	}
+	for _, g := range db.globs {
+		add(g.MIMEType)
+	}
+	for t, parents := range db.parents {
+		add(t)
+		for _, p := range parents {
+			add(p)
+		}
+	}
+	for _, t := range db.aliases {
+		add(t)
+	}
+	for t := range db.icons {
+		add(t)
+	}
+	for t := range db.genericIcons {
+		add(t)
+	}
+	return types
+}
@@ -0,0 +1,272 @@
+/*
+	libxdg-go - An implementaion of various freedesktop specifications in go
+    Copyright (C) 2025 MiracleOS Contributors
+
+    This program is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    This program is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+*/
+
+package mime
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+
+	basedir "github.com/MiracleOS-Team/libxdg-go/baseDir"
+	"github.com/MiracleOS-Team/libxdg-go/desktopFiles"
+	"github.com/MiracleOS-Team/libxdg-go/keyfile"
+)
+
+// Groups of mimeapps.list files.
+const (
+	groupDefault = "Default Applications"
+	groupAdded   = "Added Associations"
+	groupRemoved = "Removed Associations"
+)
+
+// MIMEApps is the merged view of the mimeapps.list files and of the MimeType keys
+// of installed desktop files, which tell the applications handling each type.
+type MIMEApps struct {
+	db    *Database
+	files []*mimeAppsFile
+	// apps are the installed applications, by desktop file ID.
+	apps map[string]desktopFiles.DesktopFile
+}
+
+// mimeAppsFile is one mimeapps.list file. Its lists are keyed by MIME type.
+type mimeAppsFile struct {
+	path     string
+	defaults map[string][]string
+	added    map[string][]string
+	removed  map[string][]string
+}
+
+// currentDesktops returns the lowercased names in XDG_CURRENT_DESKTOP.
+func currentDesktops() []string {
+	var desktops []string
+	for _, desktop := range strings.Split(os.Getenv("XDG_CURRENT_DESKTOP"), ":") {
+		if desktop != "" {
+			desktops = append(desktops, strings.ToLower(desktop))
+		}
+	}
+	return desktops
+}
+
+// MIMEAppsFiles returns the paths of the mimeapps.list files, highest precedence
+// first: desktop-specific then generic files in $XDG_CONFIG_HOME, $XDG_CONFIG_DIRS,
+// and the deprecated $XDG_DATA_HOME/applications and $XDG_DATA_DIRS/applications.
+func MIMEAppsFiles() []string {
+	dirs := []string{basedir.GetXDGDirectory("config").(string)}
+	dirs = append(dirs, basedir.GetXDGDirectory("configDirs").([]string)...)
+	dirs = append(dirs, filepath.Join(basedir.GetXDGDirectory("data").(string), "applications"))
+	for _, dir := range basedir.GetXDGDirectory("dataDirs").([]string) {
+		dirs = append(dirs, filepath.Join(dir, "applications"))
+	}
+
+	desktops := currentDesktops()
+	var files []string
+	for _, dir := range dirs {
+		for _, desktop := range desktops {
+			files = append(files, filepath.Join(dir, desktop+"-mimeapps.list"))
+		}
+		files = append(files, filepath.Join(dir, "mimeapps.list"))
+	}
+	return files
+}
+
+// LoadMIMEApps reads the mimeapps.list files and the installed applications,
+// resolving types with the default database.
+func LoadMIMEApps() (*MIMEApps, error) {
+	byID, err := desktopFiles.ListApplicationsByID()
+	if err != nil {
+		return nil, err
+	}
+	// mimeapps.list refers to desktop files with their ".desktop" suffix.
+	apps := make(map[string]desktopFiles.DesktopFile, len(byID))
+	for id, app := range byID {
+		apps[id+".desktop"] = app
+	}
+	return LoadMIMEAppsFrom(Default(), apps, MIMEAppsFiles()...)
+}
+
+// LoadMIMEAppsFrom reads the given mimeapps.list files, highest precedence first,
+// with apps as the installed applications keyed by desktop file ID, e.g.
+// "org.gnome.gedit.desktop". Missing files are skipped.
+func LoadMIMEAppsFrom(db *Database, apps map[string]desktopFiles.DesktopFile, paths ...string) (*MIMEApps, error) {
+	m := &MIMEApps{db: db, apps: apps}
+	for _, path := range paths {
+		file, err := readMIMEAppsFile(db, path)
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		m.files = append(m.files, file)
+	}
+	return m, nil
+}
+
+func readMIMEAppsFile(db *Database, path string) (*mimeAppsFile, error) {
+	kf, err := keyfile.LoadLenient(path)
+	if err != nil {
+		return nil, err
+	}
+
+	file := &mimeAppsFile{path: path}
+	for _, group := range []struct {
+		name string
+		list *map[string][]string
+	}{
+		{groupDefault, &file.defaults},
+		{groupAdded, &file.added},
+		{groupRemoved, &file.removed},
+	} {
+		*group.list = make(map[string][]string)
+		g := kf.Group(group.name)
+		if g == nil {
+			continue
+		}
+		for _, key := range g.Keys() {
+			mimeType := db.unalias(key)
+			(*group.list)[mimeType] = append((*group.list)[mimeType], listValue(g, key)...)
+		}
+	}
+	return file, nil
+}
+
+// listValue returns the list value of a key, dropping empty items.
+func listValue(g *keyfile.Group, key string) []string {
+	var items []string
+	for _, item := range g.Strings(key) {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// Files returns the paths of the mimeapps.list files that were read, highest
+// precedence first.
+func (m *MIMEApps) Files() []string {
+	paths := make([]string, len(m.files))
+	for i, file := range m.files {
+		paths[i] = file.path
+	}
+	return paths
+}
+
+// installed reports whether a desktop file ID is an installed application.
+func (m *MIMEApps) installed(id string) bool {
+	_, exists := m.apps[id]
+	return exists
+}
+
+// Default returns the desktop file ID of the default application for a type, or ""
+// if no application handles it. It is the first installed entry of the
+// [Default Applications] of the file with the highest precedence having one,
+// else the first candidate. Parents of the type aren't considered; see Candidates.
+func (m *MIMEApps) Default(mimeType string) string {
+	mimeType = m.db.unalias(mimeType)
+	for _, file := range m.files {
+		for _, id := range file.defaults[mimeType] {
+			if m.installed(id) {
+				return id
+			}
+		}
+	}
+	if candidates := m.associations(mimeType); len(candidates) > 0 {
+		return candidates[0]
+	}
+	return ""
+}
+
+// Associations returns the desktop file IDs of the installed applications
+// associated to exactly this type, most preferred first: the defaults, then the
+// [Added Associations] of each file, then applications listing the type in
+// their MimeType key. [Removed Associations] hide those of lower precedence files
+// and of desktop files.
+func (m *MIMEApps) Associations(mimeType string) []string {
+	mimeType = m.db.unalias(mimeType)
+	var ids []string
+	add := func(id string) {
+		if m.installed(id) && !slices.Contains(ids, id) {
+			ids = append(ids, id)
+		}
+	}
+	for _, file := range m.files {
+		for _, id := range file.defaults[mimeType] {
+			add(id)
+		}
+	}
+	for _, id := range m.associations(mimeType) {
+		add(id)
+	}
+	return ids
+}
+
+// associations returns the added and desktop file associations of a type,
+// without removed ones.
+func (m *MIMEApps) associations(mimeType string) []string {
+	removed := make(map[string]bool)
+	var ids []string
+	for _, file := range m.files {
+		for _, id := range file.added[mimeType] {
+			if !removed[id] && m.installed(id) && !slices.Contains(ids, id) {
+				ids = append(ids, id)
+			}
+		}
+		// Removals apply to lower precedence files only.
+		for _, id := range file.removed[mimeType] {
+			removed[id] = true
+		}
+	}
+
+	var declared []string
+	for id, app := range m.apps {
+		if removed[id] || slices.Contains(ids, id) {
+			continue
+		}
+		for _, t := range app.ApplicationObject.MimeType {
+			if m.db.unalias(t) == mimeType {
+				declared = append(declared, id)
+				break
+			}
+		}
+	}
+	sort.Strings(declared)
+	return append(ids, declared...)
+}
+
+// Candidates returns the desktop file IDs of the applications able to open a
+// type: those associated to it, then those associated to its ancestors, nearest
+// first, as GIO does. The default comes first.
+func (m *MIMEApps) Candidates(mimeType string) []string {
+	var ids []string
+	if id := m.Default(mimeType); id != "" {
+		ids = append(ids, id)
+	}
+	for _, t := range append([]string{mimeType}, m.db.Ancestors(mimeType)...) {
+		for _, id := range m.Associations(t) {
+			if !slices.Contains(ids, id) {
+				ids = append(ids, id)
+			}
+		}
+	}
+	return ids
+}
@@ -0,0 +1,113 @@
+/*
+	libxdg-go - An implementaion of various freedesktop specifications in go
+    Copyright (C) 2025 MiracleOS Contributors
+
+    This program is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    This program is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+*/
+
+package mime
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	basedir "github.com/MiracleOS-Team/libxdg-go/baseDir"
+	"github.com/MiracleOS-Team/libxdg-go/keyfile"
+)
+
+// UserMIMEAppsFile returns the path of the user's mimeapps.list, the one changes
+// are written to.
+func UserMIMEAppsFile() string {
+	return filepath.Join(basedir.GetXDGDirectory("config").(string), "mimeapps.list")
+}
+
+// SetDefaultApplication makes desktopID, e.g. "org.gnome.gedit.desktop", the
+// default application for a type in the user's mimeapps.list, as
+// "xdg-mime default" does. Previous defaults are kept as fallbacks after it. If
+// associate is set, the application is also added to the [Added Associations] of
+// the type and removed from its [Removed Associations].
+func SetDefaultApplication(mimeType, desktopID string, associate bool) error {
+	if !strings.Contains(mimeType, "/") {
+		return fmt.Errorf("invalid MIME type %q", mimeType)
+	}
+	if !strings.HasSuffix(desktopID, ".desktop") {
+		return fmt.Errorf("invalid desktop file ID %q", desktopID)
+	}
+	return setDefaults([]string{mimeType}, desktopID, associate)
+}
+
+// setDefaults makes desktopID the default application for several types in a
+// single edit of the user's mimeapps.list, as SetDefaultApplication does.
+func setDefaults(mimeTypes []string, desktopID string, associate bool) error {
+	db := Default()
+	return editMIMEAppsFile(UserMIMEAppsFile(), func(f *keyfile.File) {
+		for _, mimeType := range mimeTypes {
+			mimeType = db.ResolveAlias(mimeType)
+			setList(f, groupDefault, mimeType, prepend(getList(f, groupDefault, mimeType), desktopID))
+			if associate {
+				setList(f, groupAdded, mimeType, prepend(getList(f, groupAdded, mimeType), desktopID))
+				removed := getList(f, groupRemoved, mimeType)
+				if slices.Contains(removed, desktopID) {
+					setList(f, groupRemoved, mimeType, slices.DeleteFunc(removed, func(id string) bool { return id == desktopID }))
+				}
+			}
+		}
+	})
+}
+
+// prepend moves or adds id to the front of ids.
+func prepend(ids []string, id string) []string {
+	ids = slices.DeleteFunc(ids, func(other string) bool { return other == id })
+	return append([]string{id}, ids...)
+}
+
+// editMIMEAppsFile applies edit to a mimeapps.list and atomically replaces it.
+// A missing file is created. Comments and untouched entries are kept.
+func editMIMEAppsFile(path string, edit func(f *keyfile.File)) error {
+	f, err := keyfile.LoadLenient(path)
+	if errors.Is(err, os.ErrNotExist) {
+		f, err = keyfile.New(), nil
+	}
+	if err != nil {
+		return err
+	}
+	edit(f)
+	return f.Save(path)
+}
+
+// getList returns the list value of a key, or nil.
+func getList(f *keyfile.File, group, key string) []string {
+	g := f.Group(group)
+	if g == nil {
+		return nil
+	}
+	return listValue(g, key)
+}
+
+// setList sets the list value of a key, adding the group if needed. An empty
+// list removes the key.
+func setList(f *keyfile.File, group, key string, values []string) {
+	if len(values) == 0 {
+		if g := f.Group(group); g != nil {
+			g.Delete(key)
+		}
+		return
+	}
+	f.AddGroup(group).SetStrings(key, values)
+}
@@ -0,0 +1,69 @@
+/*
+	libxdg-go - An implementaion of various freedesktop specifications in go
+    Copyright (C) 2025 MiracleOS Contributors
+
+    This program is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    This program is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+*/
+
+package mime
+
+import (
+	"errors"
+
+	"github.com/MiracleOS-Team/libxdg-go/desktopFiles"
+)
+
+// ErrNoApplication is returned when no installed application handles a type.
+var ErrNoApplication = errors.New("no application handles the MIME type")
+
+// DefaultApplication returns the desktop file ID and desktop file of the
+// application to open a type with: the default one, else the preferred
+// application for the type or for one of its parent types.
+func (m *MIMEApps) DefaultApplication(mimeType string) (string, desktopFiles.DesktopFile, error) {
+	candidates := m.Candidates(mimeType)
+	if len(candidates) == 0 {
+		return "", desktopFiles.DesktopFile{}, ErrNoApplication
+	}
+	return candidates[0], m.apps[candidates[0]], nil
+}
+
+// QueryDefault returns the desktop file of the application to open a type with,
+// as "xdg-mime query default" does, falling back to applications handling a
+// parent type.
+func QueryDefault(mimeType string) (desktopFiles.DesktopFile, error) {
+	apps, err := LoadMIMEApps()
+	if err != nil {
+		return desktopFiles.DesktopFile{}, err
+	}
+	_, dfile, err := apps.DefaultApplication(mimeType)
+	return dfile, err
+}
+
+// QueryFiletype returns the MIME type of a file, as "xdg-mime query filetype"
+// does.
+func QueryFiletype(path string) (string, error) {
+	return DetectMIME(path)
+}
+
+// QueryDefaultForFile returns the MIME type of a file and the desktop file of the
+// application to open it with.
+func QueryDefaultForFile(path string) (string, desktopFiles.DesktopFile, error) {
+	mimeType, err := QueryFiletype(path)
+	if err != nil {
+		return "", desktopFiles.DesktopFile{}, err
+	}
+	dfile, err := QueryDefault(mimeType)
+	return mimeType, dfile, err
+}
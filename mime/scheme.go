@@ -0,0 +1,116 @@
+/*
+	libxdg-go - An implementaion of various freedesktop specifications in go
+    Copyright (C) 2025 MiracleOS Contributors
+
+    This program is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    This program is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+*/
+
+package mime
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/MiracleOS-Team/libxdg-go/desktopFiles"
+)
+
+// schemePrefix is the media type of the pseudo MIME types naming URI schemes,
+// e.g. "x-scheme-handler/mailto".
+const schemePrefix = "x-scheme-handler/"
+
+// SchemeType returns the pseudo MIME type applications handling a URI scheme
+// declare, e.g. "x-scheme-handler/https" for "https".
+func SchemeType(scheme string) string {
+	return schemePrefix + strings.ToLower(scheme)
+}
+
+// SchemeHandler returns the desktop file ID of the default handler of a URI
+// scheme, or "" if none is installed.
+func (m *MIMEApps) SchemeHandler(scheme string) string {
+	return m.Default(SchemeType(scheme))
+}
+
+// SchemeHandlers returns the desktop file IDs of the installed handlers of a URI
+// scheme, the default first.
+func (m *MIMEApps) SchemeHandlers(scheme string) []string {
+	return m.Associations(SchemeType(scheme))
+}
+
+// Schemes returns the URI schemes that have a handler, sorted.
+func (m *MIMEApps) Schemes() []string {
+	seen := make(map[string]bool)
+	add := func(mimeType string) {
+		if scheme, found := strings.CutPrefix(mimeType, schemePrefix); found {
+			seen[scheme] = true
+		}
+	}
+	for _, file := range m.files {
+		for mimeType := range file.defaults {
+			add(mimeType)
+		}
+		for mimeType := range file.added {
+			add(mimeType)
+		}
+	}
+	for _, app := range m.apps {
+		for _, mimeType := range app.ApplicationObject.MimeType {
+			add(strings.ToLower(mimeType))
+		}
+	}
+
+	var schemes []string
+	for scheme := range seen {
+		if len(m.SchemeHandlers(scheme)) > 0 {
+			schemes = append(schemes, scheme)
+		}
+	}
+	sort.Strings(schemes)
+	return schemes
+}
+
+// DefaultSchemeHandler returns the desktop file ID and desktop file of the
+// default handler of a URI scheme, e.g. the web browser for "https".
+func DefaultSchemeHandler(scheme string) (string, desktopFiles.DesktopFile, error) {
+	apps, err := LoadMIMEApps()
+	if err != nil {
+		return "", desktopFiles.DesktopFile{}, err
+	}
+	id := apps.SchemeHandler(scheme)
+	if id == "" {
+		return "", desktopFiles.DesktopFile{}, fmt.Errorf("%w: %s", ErrNoApplication, SchemeType(scheme))
+	}
+	return id, apps.apps[id], nil
+}
+
+// SetDefaultSchemeHandler makes desktopID the default handler of a URI scheme in
+// the user's mimeapps.list.
+func SetDefaultSchemeHandler(scheme, desktopID string) error {
+	return SetDefaultApplication(SchemeType(scheme), desktopID, true)
+}
+
+// SchemeHandlers returns the installed handlers of every URI scheme that has
+// one, by scheme, the default first.
+func SchemeHandlers() (map[string][]string, error) {
+	apps, err := LoadMIMEApps()
+	if err != nil {
+		return nil, err
+	}
+	handlers := make(map[string][]string)
+	for _, scheme := range apps.Schemes() {
+		handlers[scheme] = apps.SchemeHandlers(scheme)
+	}
+	return handlers, nil
+}
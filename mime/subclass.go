@@ -0,0 +1,115 @@
+/*
+	libxdg-go - An implementaion of various freedesktop specifications in go
+    Copyright (C) 2025 MiracleOS Contributors
+
+    This program is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    This program is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+*/
+
+package mime
+
+import (
+	"slices"
+	"strings"
+)
+
+// parentsOf returns the direct parents of a type, including the implicit ones:
+// text/* types are text/plain, and every other type but inode/* and
+// application/octet-stream itself is application/octet-stream.
+func (db *Database) parentsOf(mimeType string) []string {
+	parents := db.parents[mimeType]
+	switch {
+	case mimeType == TypeOctetStream, strings.HasPrefix(mimeType, "inode/"):
+	case strings.HasPrefix(mimeType, "text/") && mimeType != TypeText:
+		if !slices.Contains(parents, TypeText) {
+			parents = append(parents[:len(parents):len(parents)], TypeText)
+		}
+	case len(parents) == 0:
+		parents = []string{TypeOctetStream}
+	}
+	return parents
+}
+
+// ResolveAlias returns the type an alias stands for, or mimeType itself, lowercased.
+func (db *Database) ResolveAlias(mimeType string) string {
+	return db.unalias(mimeType)
+}
+
+// IsSubclassOf reports whether child is parent or one of its descendants, after
+// resolving aliases. parent may be a media wildcard such as "image/*".
+func (db *Database) IsSubclassOf(child, parent string) bool {
+	child, parent = db.unalias(child), db.unalias(parent)
+	if child == parent {
+		return true
+	}
+	if media, found := strings.CutSuffix(parent, "/*"); found && strings.HasPrefix(child, media+"/") {
+		return true
+	}
+	return slices.Contains(db.Ancestors(child), parent)
+}
+
+// Ancestors returns the supertypes of a type, nearest first, e.g. text/plain then
+// application/octet-stream for text/x-python. Aliases are resolved.
+func (db *Database) Ancestors(mimeType string) []string {
+	mimeType = db.unalias(mimeType)
+	seen := map[string]bool{mimeType: true}
+	var ancestors []string
+	queue := []string{mimeType}
+	for len(queue) > 0 {
+		t := queue[0]
+		queue = queue[1:]
+		for _, parent := range db.parentsOf(t) {
+			parent = db.unalias(parent)
+			if !seen[parent] {
+				seen[parent] = true
+				ancestors = append(ancestors, parent)
+				queue = append(queue, parent)
+			}
+		}
+	}
+	// The generic fallbacks go last, after the specific ancestors found through
+	// other branches.
+	for _, generic := range []string{TypeText, TypeOctetStream} {
+		if i := slices.Index(ancestors, generic); i >= 0 {
+			ancestors = append(slices.Delete(ancestors, i, i+1), generic)
+		}
+	}
+	return ancestors
+}
+
+// SupportsType reports whether a type is, or is a subclass of, one of the types
+// an application supports, as listed in the MimeType key of its desktop file.
+func (db *Database) SupportsType(supported []string, mimeType string) bool {
+	for _, s := range supported {
+		if db.IsSubclassOf(mimeType, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// ResolveAlias returns the type an alias stands for using the default database.
+func ResolveAlias(mimeType string) string {
+	return Default().ResolveAlias(mimeType)
+}
+
+// IsSubclassOf reports whether child is parent or a descendant using the default database.
+func IsSubclassOf(child, parent string) bool {
+	return Default().IsSubclassOf(child, parent)
+}
+
+// Ancestors returns the supertypes of a type using the default database.
+func Ancestors(mimeType string) []string {
+	return Default().Ancestors(mimeType)
+}
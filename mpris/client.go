@@ -0,0 +1,332 @@
+/*
+	libxdg-go - An implementaion of various freedesktop specifications in go
+    Copyright (C) 2025 MiracleOS Contributors
+
+    This program is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    This program is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+*/
+
+package mpris
+
+import (
+	"log/slog"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/MiracleOS-Team/libxdg-go/internal/signals"
+	"github.com/godbus/dbus/v5"
+)
+
+// Event reports a change to the media players of a Client. Seeked is set when
+// the player jumped to another position rather than playing on.
+type Event struct {
+	Player  Player
+	Added   bool
+	Updated bool
+	Removed bool
+	Seeked  bool
+}
+
+// trackedPlayer is a player tracked by a client.
+type trackedPlayer struct {
+	player Player
+	// owner is the unique name of the player's connection, which sends its signals.
+	owner string
+}
+
+// Client tracks the media players on the session bus.
+type Client struct {
+	conn        *dbus.Conn
+	own         bool
+	mu          sync.Mutex
+	players     map[string]*trackedPlayer
+	order       []string
+	subscribers signals.Subscribers[Event]
+	watch       *signals.Watch
+	closed      bool
+}
+
+// NewClient connects to the session bus and starts tracking the media players.
+func NewClient() (*Client, error) {
+	conn, err := dbus.ConnectSessionBus()
+	if err != nil {
+		return nil, err
+	}
+	c, err := newClient(conn, true)
+	if err != nil {
+		conn.Close()
+	}
+	return c, err
+}
+
+// NewClientWithConn tracks the media players using an existing connection,
+// which is left open by Close.
+func NewClientWithConn(conn *dbus.Conn) (*Client, error) {
+	return newClient(conn, false)
+}
+
+func newClient(conn *dbus.Conn, own bool) (*Client, error) {
+	c := &Client{conn: conn, own: own, players: make(map[string]*trackedPlayer)}
+	watch, err := signals.NewWatch(conn, 64, c.dispatch, c.matches()...)
+	if err != nil {
+		return nil, err
+	}
+	c.watch = watch
+
+	var names []string
+	if err := conn.BusObject().Call("org.freedesktop.DBus.ListNames", 0).Store(&names); err != nil {
+		watch.Close()
+		return nil, err
+	}
+	slices.Sort(names)
+	for _, name := range names {
+		if strings.HasPrefix(name, namePrefix) {
+			c.add(name)
+		}
+	}
+	return c, nil
+}
+
+func (c *Client) matches() [][]dbus.MatchOption {
+	return [][]dbus.MatchOption{
+		{
+			dbus.WithMatchSender("org.freedesktop.DBus"),
+			dbus.WithMatchMember("NameOwnerChanged"),
+			dbus.WithMatchArg0Namespace(rootInterface),
+		},
+		{
+			dbus.WithMatchObjectPath(objectPath),
+			dbus.WithMatchInterface("org.freedesktop.DBus.Properties"),
+			dbus.WithMatchMember("PropertiesChanged"),
+		},
+		{
+			dbus.WithMatchObjectPath(objectPath),
+			dbus.WithMatchInterface(playerInterface),
+			dbus.WithMatchMember("Seeked"),
+		},
+	}
+}
+
+// Close stops tracking the players.
+func (c *Client) Close() error {
+	c.mu.Lock()
+	if c.closed {
+		c.mu.Unlock()
+		return nil
+	}
+	c.closed = true
+	c.mu.Unlock()
+
+	c.watch.Close()
+	c.subscribers.Close()
+	if c.own {
+		return c.conn.Close()
+	}
+	return nil
+}
+
+// Conn returns the D-Bus connection of the client.
+func (c *Client) Conn() *dbus.Conn {
+	return c.conn
+}
+
+// Subscribe registers a consumer of the client's events. Events that don't fit
+// in the buffer are dropped. The channel is closed by cancel or by Close.
+func (c *Client) Subscribe(buffer int) (<-chan Event, func()) {
+	return c.subscribers.Subscribe(buffer)
+}
+
+// Players returns the media players, in the order they appeared.
+func (c *Client) Players() []Player {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	players := make([]Player, 0, len(c.order))
+	for _, name := range c.order {
+		players = append(players, c.players[name].player)
+	}
+	return players
+}
+
+// Player returns a media player by bus name.
+func (c *Client) Player(name string) (Player, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if tp, found := c.players[name]; found {
+		return tp.player, true
+	}
+	return Player{}, false
+}
+
+// Active returns the player a media widget should show: the last one to appear
+// among those playing, else among those paused, else the last one.
+func (c *Client) Active() (Player, bool) {
+	players := c.Players()
+	for _, status := range []string{Playing, Paused, ""} {
+		for i := len(players) - 1; i >= 0; i-- {
+			if status == "" || players[i].PlaybackStatus == status {
+				return players[i], true
+			}
+		}
+	}
+	return Player{}, false
+}
+
+func (c *Client) dispatch(sig *dbus.Signal) {
+	switch sig.Name {
+	case "org.freedesktop.DBus.NameOwnerChanged":
+		var name, oldOwner, newOwner string
+		if err := dbus.Store(sig.Body, &name, &oldOwner, &newOwner); err != nil || !strings.HasPrefix(name, namePrefix) {
+			return
+		}
+		if oldOwner != "" {
+			c.mu.Lock()
+			c.removeLocked(name)
+			c.mu.Unlock()
+		}
+		if newOwner != "" {
+			go c.add(name)
+		}
+	case "org.freedesktop.DBus.Properties.PropertiesChanged":
+		var iface string
+		var changed map[string]dbus.Variant
+		var invalidated []string
+		if err := dbus.Store(sig.Body, &iface, &changed, &invalidated); err != nil {
+			return
+		}
+		if iface == rootInterface || iface == playerInterface {
+			c.propertiesChanged(sig.Sender, changed, len(invalidated) > 0)
+		}
+	case playerInterface + ".Seeked":
+		if len(sig.Body) == 1 {
+			c.seeked(sig.Sender, microseconds(sig.Body[0]))
+		}
+	}
+}
+
+// add starts tracking a player.
+func (c *Client) add(name string) {
+	var owner string
+	if err := c.conn.BusObject().Call("org.freedesktop.DBus.GetNameOwner", 0, name).Store(&owner); err != nil {
+		slog.Debug("Media player has no owner", "player", name, "error", err)
+		return
+	}
+	player, err := c.fetch(name)
+	if err != nil {
+		slog.Debug("Failed to read media player", "player", name, "error", err)
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.closed || c.players[name] != nil {
+		return
+	}
+	c.players[name] = &trackedPlayer{player: player, owner: owner}
+	c.order = append(c.order, name)
+	c.subscribers.Publish(Event{Player: player, Added: true})
+}
+
+// removeLocked stops tracking a player. c.mu must be held.
+func (c *Client) removeLocked(name string) {
+	tp, found := c.players[name]
+	if !found {
+		return
+	}
+	delete(c.players, name)
+	c.order = slices.DeleteFunc(c.order, func(n string) bool { return n == name })
+	c.subscribers.Publish(Event{Player: tp.player, Removed: true})
+}
+
+// fetch reads the properties of a player.
+func (c *Client) fetch(name string) (Player, error) {
+	obj := c.conn.Object(name, objectPath)
+	player := Player{Name: name, Rate: 1}
+	for _, iface := range []string{rootInterface, playerInterface} {
+		var props map[string]dbus.Variant
+		if err := obj.Call("org.freedesktop.DBus.Properties.GetAll", 0, iface).Store(&props); err != nil {
+			return Player{}, err
+		}
+		player.apply(props)
+	}
+	return player, nil
+}
+
+// propertiesChanged updates the players of a connection. Players don't report
+// position changes, so the position is read again when the track or the
+// playback changes.
+func (c *Client) propertiesChanged(sender string, changed map[string]dbus.Variant, invalidated bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for name, tp := range c.players {
+		if tp.owner != sender {
+			continue
+		}
+		p := &tp.player
+		trackID, status := p.Metadata.TrackID, p.PlaybackStatus
+		p.setPosition(p.Position())
+		p.apply(changed)
+		if p.Metadata.TrackID != trackID || p.PlaybackStatus != status {
+			go c.syncPosition(name, tp)
+		}
+		if invalidated {
+			go c.refetch(name, tp)
+		}
+		c.subscribers.Publish(Event{Player: *p, Updated: true})
+	}
+}
+
+// refetch reads all the properties of a player again after some were invalidated.
+func (c *Client) refetch(name string, tp *trackedPlayer) {
+	player, err := c.fetch(name)
+	if err != nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.players[name] == tp {
+		tp.player = player
+		c.subscribers.Publish(Event{Player: player, Updated: true})
+	}
+}
+
+// syncPosition reads the playback position of a player.
+func (c *Client) syncPosition(name string, tp *trackedPlayer) {
+	v, err := c.conn.Object(name, objectPath).GetProperty(playerInterface + ".Position")
+	if err != nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.players[name] == tp {
+		tp.player.setPosition(microseconds(v.Value()))
+	}
+}
+
+// seeked records the new position of the players of a connection.
+func (c *Client) seeked(sender string, position time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, tp := range c.players {
+		if tp.owner == sender {
+			tp.player.setPosition(position)
+			c.subscribers.Publish(Event{Player: tp.player, Seeked: true})
+		}
+	}
+}
@@ -0,0 +1,122 @@
+/*
+	libxdg-go - An implementaion of various freedesktop specifications in go
+    Copyright (C) 2025 MiracleOS Contributors
+
+    This program is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    This program is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+*/
+
+package mpris
+
+import (
+	"time"
+
+	"github.com/godbus/dbus/v5"
+)
+
+// Play starts or resumes playback.
+func (c *Client) Play(name string) error {
+	return c.call(name, playerInterface, "Play")
+}
+
+// Pause pauses playback.
+func (c *Client) Pause(name string) error {
+	return c.call(name, playerInterface, "Pause")
+}
+
+// PlayPause toggles between playing and paused.
+func (c *Client) PlayPause(name string) error {
+	return c.call(name, playerInterface, "PlayPause")
+}
+
+// Stop stops playback.
+func (c *Client) Stop(name string) error {
+	return c.call(name, playerInterface, "Stop")
+}
+
+// Next skips to the next track.
+func (c *Client) Next(name string) error {
+	return c.call(name, playerInterface, "Next")
+}
+
+// Previous skips to the previous track.
+func (c *Client) Previous(name string) error {
+	return c.call(name, playerInterface, "Previous")
+}
+
+// Seek moves the playback position by offset, backwards if negative.
+func (c *Client) Seek(name string, offset time.Duration) error {
+	return c.call(name, playerInterface, "Seek", offset.Microseconds())
+}
+
+// SetPosition moves the playback position of the current track.
+func (c *Client) SetPosition(name string, position time.Duration) error {
+	p, found := c.Player(name)
+	if !found {
+		return ErrUnknownPlayer
+	}
+	if p.Metadata.TrackID == "" {
+		return ErrNoTrack
+	}
+	return c.call(name, playerInterface, "SetPosition", p.Metadata.TrackID, position.Microseconds())
+}
+
+// OpenURI asks a player to open and play a URI.
+func (c *Client) OpenURI(name, uri string) error {
+	return c.call(name, playerInterface, "OpenUri", uri)
+}
+
+// Raise brings the player's window to the front.
+func (c *Client) Raise(name string) error {
+	return c.call(name, rootInterface, "Raise")
+}
+
+// Quit asks the player to exit.
+func (c *Client) Quit(name string) error {
+	return c.call(name, rootInterface, "Quit")
+}
+
+// SetVolume sets the volume, from 0 to 1.
+func (c *Client) SetVolume(name string, volume float64) error {
+	return c.set(name, "Volume", volume)
+}
+
+// SetShuffle turns shuffling on or off.
+func (c *Client) SetShuffle(name string, shuffle bool) error {
+	return c.set(name, "Shuffle", shuffle)
+}
+
+// SetLoopStatus sets the loop status, one of LoopNone, LoopTrack and LoopPlaylist.
+func (c *Client) SetLoopStatus(name, status string) error {
+	return c.set(name, "LoopStatus", status)
+}
+
+// SetRate sets the playback rate, 1 being normal speed.
+func (c *Client) SetRate(name string, rate float64) error {
+	return c.set(name, "Rate", rate)
+}
+
+func (c *Client) call(name, iface, method string, args ...any) error {
+	if _, found := c.Player(name); !found {
+		return ErrUnknownPlayer
+	}
+	return c.conn.Object(name, objectPath).Call(iface+"."+method, 0, args...).Err
+}
+
+func (c *Client) set(name, property string, value any) error {
+	if _, found := c.Player(name); !found {
+		return ErrUnknownPlayer
+	}
+	return c.conn.Object(name, objectPath).SetProperty(playerInterface+"."+property, dbus.MakeVariant(value))
+}
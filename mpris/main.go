@@ -0,0 +1,223 @@
+/*
+	libxdg-go - An implementaion of various freedesktop specifications in go
+    Copyright (C) 2025 MiracleOS Contributors
+
+    This program is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    This program is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+*/
+
+// Package mpris implements the client side of the MPRIS D-Bus interface: it
+// tracks the running media players and their playback state, and controls them.
+package mpris
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/godbus/dbus/v5"
+)
+
+const (
+	namePrefix      = "org.mpris.MediaPlayer2."
+	objectPath      = dbus.ObjectPath("/org/mpris/MediaPlayer2")
+	rootInterface   = "org.mpris.MediaPlayer2"
+	playerInterface = "org.mpris.MediaPlayer2.Player"
+)
+
+// Playback statuses.
+const (
+	Playing = "Playing"
+	Paused  = "Paused"
+	Stopped = "Stopped"
+)
+
+// Loop statuses.
+const (
+	LoopNone     = "None"
+	LoopTrack    = "Track"
+	LoopPlaylist = "Playlist"
+)
+
+// ErrUnknownPlayer is returned for a player that isn't running.
+var ErrUnknownPlayer = errors.New("unknown media player")
+
+// ErrNoTrack is returned by SetPosition when the player has no current track.
+var ErrNoTrack = errors.New("media player has no track")
+
+// Metadata describes the current track of a player.
+type Metadata struct {
+	TrackID      dbus.ObjectPath
+	Title        string
+	Artists      []string
+	Album        string
+	AlbumArtists []string
+	// ArtURL is the URI of the cover art, often a file:// URI.
+	ArtURL      string
+	URL         string
+	Length      time.Duration
+	TrackNumber int
+	// Raw holds all the entries, including those not decoded above.
+	Raw map[string]dbus.Variant
+}
+
+// Player is the state of a media player.
+type Player struct {
+	// Name is the bus name of the player, e.g. "org.mpris.MediaPlayer2.vlc".
+	Name         string
+	Identity     string
+	DesktopEntry string
+	CanRaise     bool
+	CanQuit      bool
+
+	PlaybackStatus string
+	LoopStatus     string
+	Shuffle        bool
+	Volume         float64
+	Rate           float64
+	Metadata       Metadata
+
+	CanControl    bool
+	CanPlay       bool
+	CanPause      bool
+	CanGoNext     bool
+	CanGoPrevious bool
+	CanSeek       bool
+
+	// position is the playback position at positionTime.
+	position     time.Duration
+	positionTime time.Time
+}
+
+// ID returns the bus name without the MPRIS prefix, e.g. "vlc" or
+// "vlc.instance1234".
+func (p Player) ID() string {
+	return strings.TrimPrefix(p.Name, namePrefix)
+}
+
+// Position returns the playback position, extrapolated from the last one the
+// player reported while it plays.
+func (p Player) Position() time.Duration {
+	position := p.position
+	if p.PlaybackStatus == Playing && !p.positionTime.IsZero() {
+		position += time.Duration(float64(time.Since(p.positionTime)) * p.Rate)
+	}
+	if p.Metadata.Length > 0 && position > p.Metadata.Length {
+		position = p.Metadata.Length
+	}
+	return max(position, 0)
+}
+
+// setPosition anchors the playback position.
+func (p *Player) setPosition(position time.Duration) {
+	p.position = position
+	p.positionTime = time.Now()
+}
+
+// apply updates the player from MPRIS properties.
+func (p *Player) apply(props map[string]dbus.Variant) {
+	get := func(name string, value any) {
+		if v, found := props[name]; found {
+			v.Store(value)
+		}
+	}
+	get("Identity", &p.Identity)
+	get("DesktopEntry", &p.DesktopEntry)
+	get("CanRaise", &p.CanRaise)
+	get("CanQuit", &p.CanQuit)
+	get("PlaybackStatus", &p.PlaybackStatus)
+	get("LoopStatus", &p.LoopStatus)
+	get("Shuffle", &p.Shuffle)
+	get("Volume", &p.Volume)
+	get("Rate", &p.Rate)
+	get("CanControl", &p.CanControl)
+	get("CanPlay", &p.CanPlay)
+	get("CanPause", &p.CanPause)
+	get("CanGoNext", &p.CanGoNext)
+	get("CanGoPrevious", &p.CanGoPrevious)
+	get("CanSeek", &p.CanSeek)
+	if v, found := props["Metadata"]; found {
+		var raw map[string]dbus.Variant
+		v.Store(&raw)
+		p.Metadata = decodeMetadata(raw)
+	}
+	if v, found := props["Position"]; found {
+		p.setPosition(microseconds(v.Value()))
+	}
+}
+
+// decodeMetadata decodes the entries of a Metadata property. Players disagree
+// on some types, so numbers and lists are decoded leniently.
+func decodeMetadata(raw map[string]dbus.Variant) Metadata {
+	m := Metadata{Raw: raw}
+	for key, v := range raw {
+		switch key {
+		case "mpris:trackid":
+			switch id := v.Value().(type) {
+			case dbus.ObjectPath:
+				m.TrackID = id
+			case string:
+				m.TrackID = dbus.ObjectPath(id)
+			}
+		case "mpris:length":
+			m.Length = microseconds(v.Value())
+		case "mpris:artUrl":
+			m.ArtURL, _ = v.Value().(string)
+		case "xesam:title":
+			m.Title, _ = v.Value().(string)
+		case "xesam:artist":
+			m.Artists = stringList(v.Value())
+		case "xesam:album":
+			m.Album, _ = v.Value().(string)
+		case "xesam:albumArtist":
+			m.AlbumArtists = stringList(v.Value())
+		case "xesam:url":
+			m.URL, _ = v.Value().(string)
+		case "xesam:trackNumber":
+			m.TrackNumber = int(integer(v.Value()))
+		}
+	}
+	return m
+}
+
+// microseconds converts an MPRIS time to a duration.
+func microseconds(value any) time.Duration {
+	return time.Duration(integer(value)) * time.Microsecond
+}
+
+func integer(value any) int64 {
+	switch n := value.(type) {
+	case int64:
+		return n
+	case uint64:
+		return int64(n)
+	case int32:
+		return int64(n)
+	case uint32:
+		return int64(n)
+	case float64:
+		return int64(n)
+	}
+	return 0
+}
+
+func stringList(value any) []string {
+	switch s := value.(type) {
+	case []string:
+		return s
+	case string:
+		return []string{s}
+	}
+	return nil
+}
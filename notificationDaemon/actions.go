@@ -0,0 +1,133 @@
+/*
+	libxdg-go - An implementaion of various freedesktop specifications in go
+    Copyright (C) 2025 MiracleOS Contributors
+
+    This program is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    This program is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+*/
+
+package notificationDaemon
+
+import (
+	"errors"
+	"log/slog"
+	"sync"
+
+	"github.com/MiracleOS-Team/libxdg-go/icons"
+)
+
+// DefaultActionKey is the key of the action invoked when the notification itself is clicked.
+const DefaultActionKey = "default"
+
+var (
+	// ErrNotificationNotFound is returned for operations on notifications that aren't live.
+	ErrNotificationNotFound = errors.New("notification not found")
+	// ErrUnknownAction is returned when invoking an action the notification doesn't have.
+	ErrUnknownAction = errors.New("notification has no such action")
+	// ErrNoFreeID is returned when every notification ID is in use.
+	ErrNoFreeID = errors.New("no free notification ID")
+)
+
+// Action is one action of a notification. With the action-icons hint, the key is
+// also an icon name and IconPath is the file it resolved to ("" if it wasn't found).
+type Action struct {
+	Key      string `json:"key"`
+	Label    string `json:"label"`
+	IconPath string `json:"icon_path,omitempty"`
+}
+
+// parseActions turns the flat key/label list sent over D-Bus into Actions.
+// A trailing key without label is ignored.
+func parseActions(actions []string) []Action {
+	parsed := make([]Action, 0, len(actions)/2)
+	for i := 0; i+1 < len(actions); i += 2 {
+		parsed = append(parsed, Action{Key: actions[i], Label: actions[i+1]})
+	}
+	return parsed
+}
+
+// actionIconResolver caches action icon lookups, which may scan the icon themes.
+type actionIconResolver struct {
+	mu    sync.Mutex
+	cache map[string]string
+}
+
+// lookup resolves an icon name, caching both hits and misses.
+func (r *actionIconResolver) lookup(name string, size int) string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if path, cached := r.cache[name]; cached {
+		return path
+	}
+	if r.cache == nil {
+		r.cache = make(map[string]string)
+	}
+
+	path, err := icons.FindIconDefaults(name, size, 1, "")
+	if err != nil {
+		slog.Debug("Could not resolve action icon", "icon", name, "error", err)
+		path = ""
+	}
+	r.cache[name] = path
+	return path
+}
+
+// resolveActionIcons fills the IconPath of the actions when the notification
+// asks for action icons.
+func (d *Daemon) resolveActionIcons(n *Notification) {
+	if !n.ActionIcons() {
+		return
+	}
+	for i := range n.ParsedActions {
+		n.ParsedActions[i].IconPath = d.actionIcons.lookup(n.ParsedActions[i].Key, d.config.ActionIconSize)
+	}
+}
+
+// HasAction reports whether the notification has an action with the given key.
+func (n Notification) HasAction(key string) bool {
+	for _, action := range n.ParsedActions {
+		if action.Key == key {
+			return true
+		}
+	}
+	return false
+}
+
+// InvokeAction emits ActionInvoked for a live notification.
+// It fails if the notification isn't live or has no action with that key.
+func (d *Daemon) InvokeAction(id uint32, actionKey string) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.config.Monitor {
+		return errors.New("actions can't be invoked in monitor mode")
+	}
+	notification, exists := d.Notifications[id]
+	if !exists {
+		return ErrNotificationNotFound
+	}
+	if !notification.HasAction(actionKey) {
+		return ErrUnknownAction
+	}
+
+	d.emit(objectPath, interfaceName+".ActionInvoked", id, actionKey)
+	d.portalActionLocked(id, actionKey)
+	return nil
+}
+
+// InvokeDefaultAction invokes the "default" action of a live notification.
+func (d *Daemon) InvokeDefaultAction(id uint32) error {
+	return d.InvokeAction(id, DefaultActionKey)
+}
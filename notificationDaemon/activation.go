@@ -0,0 +1,126 @@
+/*
+	libxdg-go - An implementaion of various freedesktop specifications in go
+    Copyright (C) 2025 MiracleOS Contributors
+
+    This program is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    This program is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+*/
+
+package notificationDaemon
+
+import (
+	"fmt"
+	"log/slog"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+
+	basedir "github.com/MiracleOS-Team/libxdg-go/baseDir"
+)
+
+// listenFDsStart is the first file descriptor passed by systemd socket activation.
+const listenFDsStart = 3
+
+// ServiceFile returns a D-Bus service file starting execPath when a client first
+// talks to org.freedesktop.Notifications. If systemdService is not empty, the bus
+// asks systemd to start that unit instead.
+func ServiceFile(execPath, systemdService string) string {
+	var b strings.Builder
+	b.WriteString("[D-BUS Service]\n")
+	b.WriteString("Name=" + busName + "\n")
+	b.WriteString("Exec=" + execPath + "\n")
+	if systemdService != "" {
+		b.WriteString("SystemdService=" + systemdService + "\n")
+	}
+	return b.String()
+}
+
+// InstallServiceFile writes the ServiceFile to $XDG_DATA_HOME/dbus-1/services,
+// where the session bus looks for user services, and returns its path.
+func InstallServiceFile(execPath, systemdService string) (string, error) {
+	dir := filepath.Join(fmt.Sprintf("%v", basedir.GetXDGDirectory("data")), "dbus-1", "services")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", err
+	}
+	path := filepath.Join(dir, busName+".service")
+	if err := os.WriteFile(path, []byte(ServiceFile(execPath, systemdService)), 0644); err != nil {
+		return "", err
+	}
+	return path, nil
+}
+
+// sdNotify sends a state change (e.g. "READY=1") to the service manager, if the
+// daemon runs as a systemd Type=notify service.
+func sdNotify(state string) {
+	socket := os.Getenv("NOTIFY_SOCKET")
+	if socket == "" {
+		return
+	}
+	// Abstract sockets are given with a leading @.
+	if strings.HasPrefix(socket, "@") {
+		socket = "\x00" + socket[1:]
+	}
+
+	conn, err := net.DialUnix("unixgram", nil, &net.UnixAddr{Name: socket, Net: "unixgram"})
+	if err != nil {
+		slog.Debug("Failed to reach the service manager", "socket", socket, "error", err)
+		return
+	}
+	defer conn.Close()
+	if _, err := conn.Write([]byte(state)); err != nil {
+		slog.Debug("Failed to notify the service manager", "state", state, "error", err)
+	}
+}
+
+// activatedListener returns the listening socket passed by systemd socket
+// activation, or nil if the daemon wasn't socket-activated. With several sockets,
+// the one named "events" in LISTEN_FDNAMES is used.
+func activatedListener() net.Listener {
+	pid, err := strconv.Atoi(os.Getenv("LISTEN_PID"))
+	if err != nil || pid != os.Getpid() {
+		return nil
+	}
+	count, err := strconv.Atoi(os.Getenv("LISTEN_FDS"))
+	if err != nil || count < 1 {
+		return nil
+	}
+	names := strings.Split(os.Getenv("LISTEN_FDNAMES"), ":")
+
+	fd := listenFDsStart
+	for i := 0; i < count && i < len(names); i++ {
+		if names[i] == "events" {
+			fd = listenFDsStart + i
+		}
+	}
+
+	// Don't pass the sockets on to children, such as applications launched from actions.
+	for i := 0; i < count; i++ {
+		syscall.CloseOnExec(listenFDsStart + i)
+	}
+	os.Unsetenv("LISTEN_PID")
+	os.Unsetenv("LISTEN_FDS")
+	os.Unsetenv("LISTEN_FDNAMES")
+
+	file := os.NewFile(uintptr(fd), "events")
+	listener, err := net.FileListener(file)
+	file.Close()
+	if err != nil {
+		slog.Error("Ignoring the socket passed by systemd", "fd", fd, "error", err)
+		return nil
+	}
+	return listener
+}
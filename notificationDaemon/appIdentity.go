@@ -0,0 +1,103 @@
+/*
+	libxdg-go - An implementaion of various freedesktop specifications in go
+    Copyright (C) 2025 MiracleOS Contributors
+
+    This program is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    This program is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+*/
+
+package notificationDaemon
+
+import (
+	"log/slog"
+	"net/url"
+	"strings"
+	"sync"
+
+	"github.com/MiracleOS-Team/libxdg-go/desktopFiles"
+)
+
+// appIdentity is the resolved identity of the application sending a notification.
+type appIdentity struct {
+	found       bool
+	desktopFile desktopFiles.DesktopFile
+}
+
+// appResolver caches desktop-entry lookups so every notification of an
+// application doesn't hit the filesystem again.
+type appResolver struct {
+	mu    sync.Mutex
+	cache map[string]appIdentity
+}
+
+// lookup resolves a desktop file ID, caching both hits and misses.
+func (r *appResolver) lookup(id string) appIdentity {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if identity, cached := r.cache[id]; cached {
+		return identity
+	}
+	if r.cache == nil {
+		r.cache = make(map[string]appIdentity)
+	}
+
+	dfile, path, err := desktopFiles.FindDesktopFile(id)
+	if err != nil {
+		slog.Debug("Could not resolve desktop-entry hint", "id", id, "error", err)
+		r.cache[id] = appIdentity{}
+		return appIdentity{}
+	}
+	slog.Debug("Resolved desktop-entry hint", "id", id, "path", path)
+	identity := appIdentity{found: true, desktopFile: dfile}
+	r.cache[id] = identity
+	return identity
+}
+
+// resolveAppIdentity fills AppDisplayName, AppIconPath and DesktopFile from the
+// desktop-entry hint, falling back to the app_name and app_icon parameters.
+func (d *Daemon) resolveAppIdentity(notification *Notification) {
+	notification.AppDisplayName = notification.AppName
+	notification.AppIconPath = iconPathFromAppIcon(notification.AppIcon)
+
+	id := notification.DesktopEntry()
+	if id == "" {
+		return
+	}
+	identity := d.apps.lookup(id)
+	if !identity.found {
+		return
+	}
+
+	dfile := identity.desktopFile
+	notification.DesktopFile = &dfile
+	if dfile.Name != "" {
+		notification.AppDisplayName = dfile.Name
+	}
+	if notification.AppIconPath == "" {
+		notification.AppIconPath = dfile.Icon
+	}
+}
+
+// iconPathFromAppIcon returns app_icon when it refers to a file, either as an
+// absolute path or a file:// URI, and "" for themed icon names.
+func iconPathFromAppIcon(appIcon string) string {
+	if strings.HasPrefix(appIcon, "/") {
+		return appIcon
+	}
+	if u, err := url.Parse(appIcon); err == nil && u.Scheme == "file" {
+		return u.Path
+	}
+	return ""
+}
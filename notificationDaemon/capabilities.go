@@ -0,0 +1,138 @@
+/*
+	libxdg-go - An implementaion of various freedesktop specifications in go
+    Copyright (C) 2025 MiracleOS Contributors
+
+    This program is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    This program is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+*/
+
+package notificationDaemon
+
+import (
+	"html"
+	"log/slog"
+	"regexp"
+	"strings"
+
+	"github.com/godbus/dbus/v5"
+)
+
+// Capabilities defined by the Desktop Notifications spec, plus the inline-reply and
+// synchronous (OSD) extensions.
+const (
+	CapabilityActionIcons    = "action-icons"
+	CapabilityActions        = "actions"
+	CapabilityBody           = "body"
+	CapabilityBodyHyperlinks = "body-hyperlinks"
+	CapabilityBodyImages     = "body-images"
+	CapabilityBodyMarkup     = "body-markup"
+	CapabilityIconMulti      = "icon-multi"
+	CapabilityIconStatic     = "icon-static"
+	CapabilityPersistence    = "persistence"
+	CapabilitySound          = "sound"
+	CapabilityInlineReply    = "inline-reply"
+	CapabilitySynchronous    = "x-canonical-private-synchronous"
+)
+
+// DefaultSpecVersion is the spec version reported when Config.SpecVersion is empty.
+const DefaultSpecVersion = "1.2"
+
+// defaultCapabilities are advertised when Config.Capabilities is nil.
+var defaultCapabilities = []string{CapabilityBody, CapabilityActions, CapabilityActionIcons, CapabilitySynchronous}
+
+// inlineReplyAction is the action key KDE clients use to request an inline reply field.
+const inlineReplyAction = "inline-reply"
+
+var markupTag = regexp.MustCompile(`<(/?)([a-zA-Z]+)[^>]*>`)
+
+// HasCapability reports whether the daemon advertises a capability.
+func (d *Daemon) HasCapability(capability string) bool {
+	for _, c := range d.config.Capabilities {
+		if c == capability {
+			return true
+		}
+	}
+	return false
+}
+
+// applyCapabilities removes the parts of a notification that rely on capabilities
+// the daemon doesn't advertise, logging what the client asked for.
+func (d *Daemon) applyCapabilities(n *Notification) {
+	if n.Body != "" && !d.HasCapability(CapabilityBody) {
+		slog.Debug("Dropping body, body capability disabled", "app", n.AppName)
+		n.Body = ""
+	}
+	if n.Body != "" {
+		n.Body = d.filterMarkup(n.AppName, n.Body)
+	}
+
+	if len(n.Actions) > 0 && !d.HasCapability(CapabilityActions) {
+		slog.Debug("Dropping actions, actions capability disabled", "app", n.AppName)
+		n.Actions = []string{}
+	}
+	if !d.HasCapability(CapabilityInlineReply) {
+		actions := make([]string, 0, len(n.Actions))
+		for i := 0; i+1 < len(n.Actions); i += 2 {
+			if n.Actions[i] == inlineReplyAction {
+				slog.Debug("Dropping inline-reply action, inline-reply capability disabled", "app", n.AppName)
+				continue
+			}
+			actions = append(actions, n.Actions[i], n.Actions[i+1])
+		}
+		n.Actions = actions
+	}
+
+	if n.ActionIcons() && !d.HasCapability(CapabilityActionIcons) {
+		slog.Debug("Ignoring action-icons hint, action-icons capability disabled", "app", n.AppName)
+		n.Hints = copyHintsWithout(n.Hints, "action-icons")
+	}
+}
+
+// filterMarkup keeps the body markup allowed by the advertised capabilities.
+// Without body-markup all tags are removed and entities decoded to plain text.
+func (d *Daemon) filterMarkup(appName, body string) string {
+	if !markupTag.MatchString(body) && !strings.Contains(body, "&") {
+		return body
+	}
+	if !d.HasCapability(CapabilityBodyMarkup) {
+		if markupTag.MatchString(body) {
+			slog.Debug("Stripping body markup, body-markup capability disabled", "app", appName)
+		}
+		return html.UnescapeString(markupTag.ReplaceAllString(body, ""))
+	}
+
+	allowed := map[string]bool{"b": true, "i": true, "u": true}
+	allowed["a"] = d.HasCapability(CapabilityBodyHyperlinks)
+	allowed["img"] = d.HasCapability(CapabilityBodyImages)
+
+	return markupTag.ReplaceAllStringFunc(body, func(tag string) string {
+		name := strings.ToLower(markupTag.FindStringSubmatch(tag)[2])
+		if allowed[name] {
+			return tag
+		}
+		slog.Debug("Stripping unsupported body markup", "app", appName, "tag", name)
+		return ""
+	})
+}
+
+// copyHintsWithout returns a copy of hints without the given key, leaving the caller's map untouched.
+func copyHintsWithout(hints map[string]dbus.Variant, key string) map[string]dbus.Variant {
+	out := make(map[string]dbus.Variant, len(hints))
+	for k, v := range hints {
+		if k != key {
+			out[k] = v
+		}
+	}
+	return out
+}
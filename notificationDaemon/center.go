@@ -0,0 +1,505 @@
+/*
+	libxdg-go - An implementaion of various freedesktop specifications in go
+    Copyright (C) 2025 MiracleOS Contributors
+
+    This program is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    This program is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+*/
+
+package notificationDaemon
+
+import (
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/godbus/dbus/v5"
+	"github.com/godbus/dbus/v5/introspect"
+)
+
+const (
+	centerPath          = dbus.ObjectPath("/org/miracleos/NotificationCenter")
+	centerInterfaceName = "org.miracleos.NotificationCenter"
+)
+
+// centerNotification is the wire representation of a Notification on the
+// org.miracleos.NotificationCenter interface, signature (ussssasa{sv}ix).
+type centerNotification struct {
+	ID            uint32
+	AppName       string
+	AppIcon       string
+	Summary       string
+	Body          string
+	Actions       []string
+	Hints         map[string]dbus.Variant
+	ExpireTimeout int32
+	Timestamp     int64 // Unix time in milliseconds
+}
+
+func toCenterNotifications(notifications []Notification) []centerNotification {
+	out := make([]centerNotification, 0, len(notifications))
+	for _, n := range notifications {
+		hints := n.Hints
+		if hints == nil {
+			hints = map[string]dbus.Variant{}
+		}
+		actions := n.Actions
+		if actions == nil {
+			actions = []string{}
+		}
+		out = append(out, centerNotification{
+			ID:            n.ID,
+			AppName:       n.AppName,
+			AppIcon:       n.AppIcon,
+			Summary:       n.Summary,
+			Body:          n.Body,
+			Actions:       actions,
+			Hints:         hints,
+			ExpireTimeout: n.ExpireTimeout,
+			Timestamp:     n.Timestamp.UnixMilli(),
+		})
+	}
+	return out
+}
+
+// notificationCenter exports the control operations of a Daemon on the bus
+// so notification-center widgets can use them without linking this package.
+type notificationCenter struct {
+	d *Daemon
+}
+
+// GetNotifications returns the live notifications.
+func (c notificationCenter) GetNotifications() ([]centerNotification, *dbus.Error) {
+	return toCenterNotifications(c.d.LiveNotifications()), nil
+}
+
+// GetHistory returns the closed notifications kept in the history, oldest first.
+func (c notificationCenter) GetHistory() ([]centerNotification, *dbus.Error) {
+	return toCenterNotifications(c.d.History()), nil
+}
+
+// ClearAll closes every live notification and empties the history.
+func (c notificationCenter) ClearAll() *dbus.Error {
+	c.d.ClearAll()
+	return nil
+}
+
+// CloseAll closes every live notification and returns how many were closed.
+func (c notificationCenter) CloseAll() (uint32, *dbus.Error) {
+	return uint32(c.d.CloseAll()), nil
+}
+
+// CloseByApp closes the live notifications sent by an app and returns how many were closed.
+func (c notificationCenter) CloseByApp(appName string) (uint32, *dbus.Error) {
+	return uint32(c.d.CloseByApp(appName)), nil
+}
+
+// SetDoNotDisturb enables or disables do-not-disturb mode.
+func (c notificationCenter) SetDoNotDisturb(enabled bool) *dbus.Error {
+	c.d.SetDoNotDisturb(enabled)
+	return nil
+}
+
+// GetDoNotDisturb reports whether do-not-disturb mode is enabled.
+func (c notificationCenter) GetDoNotDisturb() (bool, *dbus.Error) {
+	return c.d.DoNotDisturb(), nil
+}
+
+// InvokeAction invokes an action of a live notification on behalf of the user.
+func (c notificationCenter) InvokeAction(id uint32, actionKey string) *dbus.Error {
+	if err := c.d.InvokeAction(id, actionKey); err != nil {
+		return dbus.MakeFailedError(err)
+	}
+	return nil
+}
+
+// Snooze hides a live notification for the given number of seconds.
+func (c notificationCenter) Snooze(id uint32, seconds uint32) *dbus.Error {
+	if err := c.d.Snooze(id, time.Duration(seconds)*time.Second); err != nil {
+		return dbus.MakeFailedError(err)
+	}
+	return nil
+}
+
+// Pause holds back the delivery of new notifications.
+func (c notificationCenter) Pause() *dbus.Error {
+	c.d.Pause()
+	return nil
+}
+
+// Resume delivers the held notifications and resumes delivery.
+func (c notificationCenter) Resume() *dbus.Error {
+	c.d.Resume()
+	return nil
+}
+
+// GetPaused reports whether delivery is paused.
+func (c notificationCenter) GetPaused() (bool, *dbus.Error) {
+	return c.d.Paused(), nil
+}
+
+// GetCounts returns the number of live, historic and held notifications.
+func (c notificationCenter) GetCounts() (uint32, uint32, uint32, *dbus.Error) {
+	counts := c.d.Counts()
+	return uint32(counts.Live), uint32(counts.History), uint32(counts.Held), nil
+}
+
+// GetMetrics returns the daemon's counters as a JSON document (see Metrics),
+// readable with busctl or gdbus when debugging missing notifications.
+func (c notificationCenter) GetMetrics() (string, *dbus.Error) {
+	data, err := json.Marshal(c.d.Metrics())
+	if err != nil {
+		return "", dbus.MakeFailedError(err)
+	}
+	return string(data), nil
+}
+
+var centerIntrospection = introspect.Interface{
+	Name: centerInterfaceName,
+	Methods: []introspect.Method{
+		{
+			Name: "GetNotifications",
+			Args: []introspect.Arg{
+				{Name: "notifications", Type: "a(ussssasa{sv}ix)", Direction: "out"},
+			},
+		},
+		{
+			Name: "GetHistory",
+			Args: []introspect.Arg{
+				{Name: "notifications", Type: "a(ussssasa{sv}ix)", Direction: "out"},
+			},
+		},
+		{
+			Name: "ClearAll",
+		},
+		{
+			Name: "CloseAll",
+			Args: []introspect.Arg{
+				{Name: "closed", Type: "u", Direction: "out"},
+			},
+		},
+		{
+			Name: "CloseByApp",
+			Args: []introspect.Arg{
+				{Name: "app_name", Type: "s", Direction: "in"},
+				{Name: "closed", Type: "u", Direction: "out"},
+			},
+		},
+		{
+			Name: "SetDoNotDisturb",
+			Args: []introspect.Arg{
+				{Name: "enabled", Type: "b", Direction: "in"},
+			},
+		},
+		{
+			Name: "GetDoNotDisturb",
+			Args: []introspect.Arg{
+				{Name: "enabled", Type: "b", Direction: "out"},
+			},
+		},
+		{
+			Name: "InvokeAction",
+			Args: []introspect.Arg{
+				{Name: "id", Type: "u", Direction: "in"},
+				{Name: "action_key", Type: "s", Direction: "in"},
+			},
+		},
+		{
+			Name: "Snooze",
+			Args: []introspect.Arg{
+				{Name: "id", Type: "u", Direction: "in"},
+				{Name: "seconds", Type: "u", Direction: "in"},
+			},
+		},
+		{
+			Name: "Pause",
+		},
+		{
+			Name: "Resume",
+		},
+		{
+			Name: "GetPaused",
+			Args: []introspect.Arg{
+				{Name: "paused", Type: "b", Direction: "out"},
+			},
+		},
+		{
+			Name: "GetCounts",
+			Args: []introspect.Arg{
+				{Name: "live", Type: "u", Direction: "out"},
+				{Name: "history", Type: "u", Direction: "out"},
+				{Name: "held", Type: "u", Direction: "out"},
+			},
+		},
+		{
+			Name: "GetMetrics",
+			Args: []introspect.Arg{
+				{Name: "metrics", Type: "s", Direction: "out"},
+			},
+		},
+	},
+	Signals: []introspect.Signal{
+		{
+			Name: "NotificationAdded",
+			Args: []introspect.Arg{
+				{Name: "id", Type: "u"},
+			},
+		},
+		{
+			Name: "NotificationRemoved",
+			Args: []introspect.Arg{
+				{Name: "id", Type: "u"},
+				{Name: "reason", Type: "u"},
+			},
+		},
+		{
+			Name: "NotificationSnoozed",
+			Args: []introspect.Arg{
+				{Name: "id", Type: "u"},
+				{Name: "until", Type: "x"},
+			},
+		},
+		{
+			Name: "HistoryChanged",
+		},
+		{
+			Name: "DoNotDisturbChanged",
+			Args: []introspect.Arg{
+				{Name: "enabled", Type: "b"},
+			},
+		},
+		{
+			Name: "PausedChanged",
+			Args: []introspect.Arg{
+				{Name: "paused", Type: "b"},
+			},
+		},
+	},
+}
+
+// exportCenter exports the org.miracleos.NotificationCenter interface.
+func (d *Daemon) exportCenter() error {
+	err := d.conn.Export(notificationCenter{d: d}, centerPath, centerInterfaceName)
+	if err != nil {
+		return err
+	}
+	node := &introspect.Node{
+		Name: string(centerPath),
+		Interfaces: []introspect.Interface{
+			centerIntrospection,
+			introspect.IntrospectData,
+		},
+	}
+	return d.conn.Export(introspect.NewIntrospectable(node), centerPath, "org.freedesktop.DBus.Introspectable")
+}
+
+// unexportCenter removes the org.miracleos.NotificationCenter interface from the bus.
+func (d *Daemon) unexportCenter() {
+	d.conn.Export(nil, centerPath, centerInterfaceName)
+	d.conn.Export(nil, centerPath, "org.freedesktop.DBus.Introspectable")
+}
+
+// emitCenter emits a signal on the org.miracleos.NotificationCenter interface.
+func (d *Daemon) emitCenter(signal string, args ...interface{}) {
+	d.emit(centerPath, centerInterfaceName+"."+signal, args...)
+}
+
+// LiveNotifications returns a snapshot of the notifications that are currently displayed.
+func (d *Daemon) LiveNotifications() []Notification {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	notifications := make([]Notification, 0, len(d.Notifications))
+	for _, n := range d.Notifications {
+		notifications = append(notifications, n)
+	}
+	return notifications
+}
+
+// History returns the closed notifications kept in the history, oldest first.
+// Queued changes are written first. The store is read and the applications
+// resolved without the lock, as both may hit the filesystem.
+func (d *Daemon) History() []Notification {
+	d.flushHistory()
+	history, err := d.store.History()
+	if err != nil {
+		slog.Error("Failed to read the notification history", "error", err)
+	}
+	if excess := len(history) - d.config.HistorySize; excess > 0 {
+		history = history[excess:]
+	}
+	for i := range history {
+		d.resolveAppIdentity(&history[i])
+	}
+	return history
+}
+
+// ClearAll closes every live notification as dismissed by the user and empties the history.
+func (d *Daemon) ClearAll() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.closeAllLocked()
+	if !d.stopped && (d.historyLenLocked() > 0 || d.historyQueued()) {
+		// Clearing supersedes the changes queued for the history.
+		d.queueHistory(func([]historyChange) []historyChange {
+			return []historyChange{{clear: true}}
+		})
+	}
+}
+
+// CloseAll closes every live notification as dismissed by the user, keeping the history.
+// It returns the number of closed notifications.
+func (d *Daemon) CloseAll() int {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	return d.closeAllLocked()
+}
+
+// closeAllLocked closes every live notification as dismissed by the user.
+// d.mu must be held.
+func (d *Daemon) closeAllLocked() int {
+	closed := 0
+	for id := range d.Notifications {
+		if d.closeLocked(id, CloseReasonDismissed) {
+			closed++
+		}
+	}
+	return closed
+}
+
+// CloseByApp closes every live notification sent by an app as dismissed by the user.
+// app matches either the app_name or the desktop-entry hint, with or without the
+// .desktop suffix. It returns the number of closed notifications.
+func (d *Daemon) CloseByApp(app string) int {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	closed := 0
+	for id, n := range d.Notifications {
+		if n.fromApp(app) && d.closeLocked(id, CloseReasonDismissed) {
+			closed++
+		}
+	}
+	return closed
+}
+
+// fromApp reports whether the notification was sent by app, given as an app_name
+// or a desktop file ID.
+func (n Notification) fromApp(app string) bool {
+	if app == "" {
+		return false
+	}
+	if n.AppName == app {
+		return true
+	}
+	entry := strings.TrimSuffix(n.DesktopEntry(), ".desktop")
+	return entry != "" && entry == strings.TrimSuffix(app, ".desktop")
+}
+
+// SetDoNotDisturb enables or disables do-not-disturb mode.
+// While enabled, notifications that are not critical are still stored but no
+// events are sent on NotificationsChannel for them.
+func (d *Daemon) SetDoNotDisturb(enabled bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.dnd == enabled {
+		return
+	}
+	d.dnd = enabled
+	d.emitCenter("DoNotDisturbChanged", enabled)
+	d.stateChangedLocked()
+}
+
+// DoNotDisturb reports whether do-not-disturb mode is enabled.
+func (d *Daemon) DoNotDisturb() bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	return d.dnd
+}
+
+// historyLenLocked returns the number of notifications in the history.
+// d.mu must be held.
+func (d *Daemon) historyLenLocked() int {
+	return min(d.store.HistoryLen(), d.config.HistorySize)
+}
+
+// historyChange is a change to the history waiting to be written to the store.
+type historyChange struct {
+	notification Notification
+	clear        bool
+}
+
+// recordHistory queues a closed notification for the history, trimmed to
+// Config.HistorySize.
+// d.mu must be held.
+func (d *Daemon) recordHistory(notification Notification) {
+	if d.config.HistorySize == 0 {
+		return
+	}
+	d.queueHistory(func(changes []historyChange) []historyChange {
+		return append(changes, historyChange{notification: notification})
+	})
+}
+
+// queueHistory updates the queued history changes. They are written to the
+// store in the background, since FileStore writes to disk.
+func (d *Daemon) queueHistory(update func([]historyChange) []historyChange) {
+	d.historyQueueMu.Lock()
+	defer d.historyQueueMu.Unlock()
+
+	if len(d.historyQueue) == 0 {
+		go d.flushHistory()
+	}
+	d.historyQueue = update(d.historyQueue)
+}
+
+// historyQueued reports whether history changes are waiting to be written.
+func (d *Daemon) historyQueued() bool {
+	d.historyQueueMu.Lock()
+	defer d.historyQueueMu.Unlock()
+
+	return len(d.historyQueue) > 0
+}
+
+// flushHistory writes the queued history changes to the store. It doesn't take
+// d.mu, and d.historyMu keeps the writes in the order they were queued.
+func (d *Daemon) flushHistory() {
+	d.historyMu.Lock()
+	defer d.historyMu.Unlock()
+
+	d.historyQueueMu.Lock()
+	changes := d.historyQueue
+	d.historyQueue = nil
+	d.historyQueueMu.Unlock()
+
+	for _, change := range changes {
+		if change.clear {
+			if err := d.store.ClearHistory(); err != nil {
+				slog.Error("Failed to clear the notification history", "error", err)
+			}
+			continue
+		}
+		if err := d.store.AppendHistory(change.notification, d.config.HistorySize); err != nil {
+			slog.Error("Failed to record notification history", "id", change.notification.ID, "error", err)
+		}
+	}
+	if len(changes) > 0 {
+		d.emitCenter("HistoryChanged")
+	}
+}
@@ -0,0 +1,123 @@
+/*
+	libxdg-go - An implementaion of various freedesktop specifications in go
+    Copyright (C) 2025 MiracleOS Contributors
+
+    This program is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    This program is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+*/
+
+package notificationDaemon
+
+import (
+	"testing"
+	"time"
+)
+
+func TestHistoryWithoutLock(t *testing.T) {
+	d := NewDaemon(Config{HistorySize: 10})
+	go func() {
+		for range d.NotificationsChannel {
+		}
+	}()
+	t.Cleanup(d.Stop)
+
+	id := notify(t, d, 0, "closed")
+	if err := d.CloseNotification(id); err != nil {
+		t.Fatal(err)
+	}
+
+	// Closing more notifications meanwhile must not race with reading.
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 20; i++ {
+			id, _ := d.Notify("test", 0, "", "more", "", []string{}, nil, -1)
+			d.CloseNotification(id)
+		}
+	}()
+
+	d.mu.Lock()
+	history := make(chan []Notification)
+	go func() { history <- d.History() }()
+	select {
+	case h := <-history:
+		if len(h) == 0 || h[0].Summary != "closed" {
+			t.Errorf("got history %+v", h)
+		}
+	case <-time.After(time.Second):
+		t.Error("History waited for the daemon lock")
+	}
+	d.mu.Unlock()
+	<-done
+}
+
+func TestHistoryNegativeSize(t *testing.T) {
+	d := NewDaemon(Config{HistorySize: -1})
+	go func() {
+		for range d.NotificationsChannel {
+		}
+	}()
+	t.Cleanup(d.Stop)
+
+	d.store.AppendHistory(Notification{ID: 1, Summary: "old"}, 10)
+	if h := d.History(); len(h) != 0 {
+		t.Errorf("got history %+v, want none", h)
+	}
+}
+
+// blockingStore blocks AppendHistory until release is closed.
+type blockingStore struct {
+	*MemoryStore
+	appending chan struct{}
+	release   chan struct{}
+}
+
+func (s *blockingStore) AppendHistory(n Notification, limit int) error {
+	s.appending <- struct{}{}
+	<-s.release
+	return s.MemoryStore.AppendHistory(n, limit)
+}
+
+func TestRecordHistoryWithoutLock(t *testing.T) {
+	store := &blockingStore{NewMemoryStore(), make(chan struct{}), make(chan struct{})}
+	d := NewDaemon(Config{HistorySize: 10, Store: store})
+	go func() {
+		for range d.NotificationsChannel {
+		}
+	}()
+	t.Cleanup(d.Stop)
+
+	id := notify(t, d, 0, "closed")
+	if err := d.CloseNotification(id); err != nil {
+		t.Fatal(err)
+	}
+	<-store.appending
+
+	// The daemon keeps working while the store is busy.
+	notified := make(chan struct{})
+	go func() {
+		defer close(notified)
+		d.Notify("test", 0, "", "next", "", []string{}, nil, -1)
+	}()
+	select {
+	case <-notified:
+	case <-time.After(time.Second):
+		t.Error("Notify waited for the history to be written")
+	}
+	close(store.release)
+
+	if h := d.History(); len(h) != 1 || h[0].Summary != "closed" {
+		t.Errorf("got history %+v", h)
+	}
+}
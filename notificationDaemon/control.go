@@ -0,0 +1,159 @@
+/*
+	libxdg-go - An implementaion of various freedesktop specifications in go
+    Copyright (C) 2025 MiracleOS Contributors
+
+    This program is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    This program is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+*/
+
+package notificationDaemon
+
+import (
+	"sort"
+)
+
+// Counts summarizes the notifications known to the daemon.
+type Counts struct {
+	// Live is the number of notifications currently displayed.
+	Live int
+	// History is the number of closed notifications kept in the history.
+	History int
+	// Held is the number of live notifications not delivered yet because delivery is
+	// paused or a fullscreen window is focused.
+	Held int
+}
+
+// Pause holds back Created and Modified events until Resume is called.
+// Notifications are still accepted, and closing them is still reported.
+func (d *Daemon) Pause() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.paused {
+		return
+	}
+	d.paused = true
+	d.emitCenter("PausedChanged", true)
+	d.stateChangedLocked()
+}
+
+// Resume delivers the events held back since Pause, oldest first, and resumes delivery.
+// Events still held back by a focused fullscreen window stay held.
+func (d *Daemon) Resume() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if !d.paused {
+		return
+	}
+	d.paused = false
+	d.releaseHeldLocked()
+
+	d.emitCenter("PausedChanged", false)
+	d.stateChangedLocked()
+}
+
+// Paused reports whether delivery is paused.
+func (d *Daemon) Paused() bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	return d.paused
+}
+
+// Counts returns the number of live, historic and held notifications.
+func (d *Daemon) Counts() Counts {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	return Counts{
+		Live:    len(d.Notifications),
+		History: d.historyLenLocked(),
+		Held:    len(d.held),
+	}
+}
+
+// presentLocked broadcasts a Created or Modified event unless do-not-disturb
+// silences it, or delivery is paused or a fullscreen window is focused, in which
+// case it is held until Resume or until the window goes away.
+// d.mu must be held.
+func (d *Daemon) presentLocked(event NotificationEvent) {
+	id := event.Notification.ID
+
+	if d.dnd && event.Notification.Urgency() != UrgencyCritical {
+		if !d.silenced[id] {
+			d.countLocked(event.Notification.AppName).Silenced++
+		}
+		d.silenced[id] = true
+		delete(d.held, id)
+		return
+	}
+
+	// The consumer never saw a silenced notification, so to it this is a new one.
+	if d.silenced[id] {
+		delete(d.silenced, id)
+		event.Created, event.Modified = true, false
+	}
+
+	if d.paused || d.fullscreenHoldsLocked(event.Notification) {
+		if held, exists := d.held[id]; exists && held.Created {
+			event.Created, event.Modified = true, false
+		}
+		d.held[id] = event
+		return
+	}
+	d.broadcastLocked(event)
+}
+
+// releaseHeldLocked delivers the held events that nothing holds back anymore, oldest first.
+// d.mu must be held.
+func (d *Daemon) releaseHeldLocked() {
+	if d.paused {
+		return
+	}
+	var released []NotificationEvent
+	for id, event := range d.held {
+		if !d.fullscreenHoldsLocked(event.Notification) {
+			released = append(released, event)
+			delete(d.held, id)
+		}
+	}
+	sort.Slice(released, func(i, j int) bool {
+		return released[i].Notification.Timestamp.Before(released[j].Notification.Timestamp)
+	})
+	for _, event := range released {
+		d.broadcastLocked(event)
+	}
+}
+
+// forgetLocked drops the delivery state of a notification that is going away and
+// reports whether the consumer saw it, i.e. whether a Deleted event makes sense.
+// d.mu must be held.
+func (d *Daemon) forgetLocked(id uint32) bool {
+	if d.silenced[id] {
+		delete(d.silenced, id)
+		return false
+	}
+	held, exists := d.held[id]
+	delete(d.held, id)
+	return !exists || !held.Created
+}
+
+// stateChangedLocked tells the event stream clients that do-not-disturb or pausing changed.
+// d.mu must be held.
+func (d *Daemon) stateChangedLocked() {
+	if d.stream != nil {
+		d.stream.stateChanged()
+	}
+}
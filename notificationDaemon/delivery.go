@@ -0,0 +1,185 @@
+/*
+	libxdg-go - An implementaion of various freedesktop specifications in go
+    Copyright (C) 2025 MiracleOS Contributors
+
+    This program is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    This program is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+*/
+
+package notificationDaemon
+
+import (
+	"sync"
+	"time"
+)
+
+// BackpressurePolicy decides what happens to new events when the event queue is full.
+type BackpressurePolicy int
+
+const (
+	// BackpressureBlock keeps every event. Notify calls wait for the consumer to catch
+	// up before replying, which slows clients down instead of the daemon.
+	BackpressureBlock BackpressurePolicy = iota
+	// BackpressureDropOldest discards the oldest queued event to make room.
+	BackpressureDropOldest
+	// BackpressureDropNewest discards the new event.
+	BackpressureDropNewest
+)
+
+// shutdownGrace is how long Stop waits for the consumer to take the remaining events.
+const shutdownGrace = time.Second
+
+// eventQueue buffers events between the daemon and the goroutine delivering them
+// on NotificationsChannel, so the daemon never blocks on its consumer.
+type eventQueue struct {
+	mu        sync.Mutex
+	cond      *sync.Cond
+	events    []NotificationEvent
+	size      int
+	policy    BackpressurePolicy
+	onDropped func(NotificationEvent)
+	dropped   uint64
+	closed    bool
+	closing   chan struct{}
+}
+
+func newEventQueue(size int, policy BackpressurePolicy, onDropped func(NotificationEvent)) *eventQueue {
+	q := &eventQueue{
+		size:      size,
+		policy:    policy,
+		onDropped: onDropped,
+		closing:   make(chan struct{}),
+	}
+	q.cond = sync.NewCond(&q.mu)
+	return q
+}
+
+// push queues an event, applying the backpressure policy when the queue is full.
+// It never blocks.
+func (q *eventQueue) push(event NotificationEvent) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.closed {
+		q.dropLocked(event)
+		return
+	}
+	if len(q.events) >= q.size {
+		switch q.policy {
+		case BackpressureDropOldest:
+			q.dropLocked(q.events[0])
+			q.events = q.events[1:]
+		case BackpressureDropNewest:
+			q.dropLocked(event)
+			return
+		}
+	}
+	q.events = append(q.events, event)
+	q.cond.Broadcast()
+}
+
+// pop waits for the next event. It returns false once the queue is closed and empty.
+func (q *eventQueue) pop() (NotificationEvent, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	for len(q.events) == 0 && !q.closed {
+		q.cond.Wait()
+	}
+	if len(q.events) == 0 {
+		return NotificationEvent{}, false
+	}
+	event := q.events[0]
+	q.events = q.events[1:]
+	q.cond.Broadcast()
+	return event, true
+}
+
+// waitForRoom blocks, under BackpressureBlock, until the queue is below its size.
+func (q *eventQueue) waitForRoom() {
+	if q.policy != BackpressureBlock {
+		return
+	}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	for len(q.events) >= q.size && !q.closed {
+		q.cond.Wait()
+	}
+}
+
+// close stops accepting events. Queued events are still returned by pop.
+func (q *eventQueue) close() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if !q.closed {
+		q.closed = true
+		close(q.closing)
+	}
+	q.cond.Broadcast()
+}
+
+// drop accounts for an event that couldn't be delivered.
+func (q *eventQueue) drop(event NotificationEvent) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.dropLocked(event)
+}
+
+func (q *eventQueue) dropLocked(event NotificationEvent) {
+	q.dropped++
+	if q.onDropped != nil {
+		q.onDropped(event)
+	}
+}
+
+// DroppedEvents returns the number of events that were never delivered on NotificationsChannel.
+func (d *Daemon) DroppedEvents() uint64 {
+	d.events.mu.Lock()
+	defer d.events.mu.Unlock()
+
+	return d.events.dropped
+}
+
+// deliverEvents sends queued events on NotificationsChannel until the queue is closed,
+// then closes the channel. Once Stop was called, the consumer only gets shutdownGrace
+// to take the remaining events.
+func (d *Daemon) deliverEvents() {
+	defer close(d.delivered)
+	defer close(d.NotificationsChannel)
+
+	closing := d.events.closing
+	expired := make(chan struct{})
+	for {
+		event, ok := d.events.pop()
+		if !ok {
+			return
+		}
+
+		for delivered := false; !delivered; {
+			select {
+			case d.NotificationsChannel <- event:
+				delivered = true
+			case <-closing:
+				time.AfterFunc(shutdownGrace, func() { close(expired) })
+				closing = nil
+			case <-expired:
+				d.events.drop(event)
+				delivered = true
+			}
+		}
+	}
+}
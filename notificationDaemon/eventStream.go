@@ -0,0 +1,312 @@
+/*
+	libxdg-go - An implementaion of various freedesktop specifications in go
+    Copyright (C) 2025 MiracleOS Contributors
+
+    This program is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    This program is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+*/
+
+package notificationDaemon
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"os"
+	"sync"
+	"time"
+
+	basedir "github.com/MiracleOS-Team/libxdg-go/baseDir"
+)
+
+// DefaultEventSocket returns the conventional path of the event stream socket,
+// $XDG_RUNTIME_DIR/libxdg-notifications.sock.
+func DefaultEventSocket() string {
+	runtime := fmt.Sprintf("%v", basedir.GetXDGDirectory("runtime"))
+	if runtime == "" {
+		runtime = os.TempDir()
+	}
+	return runtime + "/libxdg-notifications.sock"
+}
+
+// streamNotification is the JSON form of a notification on the event stream.
+type streamNotification struct {
+	ID             uint32     `json:"id"`
+	AppName        string     `json:"app_name"`
+	AppDisplayName string     `json:"app_display_name"`
+	AppIcon        string     `json:"app_icon"`
+	AppIconPath    string     `json:"app_icon_path"`
+	Summary        string     `json:"summary"`
+	Body           string     `json:"body"`
+	Actions        []Action   `json:"actions"`
+	Urgency        Urgency    `json:"urgency"`
+	Category       string     `json:"category,omitempty"`
+	DesktopEntry   string     `json:"desktop_entry,omitempty"`
+	Value          *int32     `json:"value,omitempty"`
+	ExpireTimeout  int32      `json:"expire_timeout"`
+	Timestamp      time.Time  `json:"timestamp"`
+	Updated        *time.Time `json:"updated,omitempty"`
+}
+
+// streamGroup is the JSON form of a group on the event stream.
+type streamGroup struct {
+	Key       string   `json:"key"`
+	Title     string   `json:"title"`
+	Count     int      `json:"count"`
+	Collapsed bool     `json:"collapsed"`
+	IDs       []uint32 `json:"ids"`
+}
+
+// streamState is the daemon state attached to every line, so status bars can
+// render a count or a do-not-disturb indicator from any single line.
+type streamState struct {
+	Live         int  `json:"live"`
+	History      int  `json:"history"`
+	Held         int  `json:"held"`
+	DoNotDisturb bool `json:"dnd"`
+	Paused       bool `json:"paused"`
+	// Fullscreen is the application whose focused fullscreen window holds
+	// notifications back, if any.
+	Fullscreen string `json:"fullscreen,omitempty"`
+}
+
+// streamLine is one line of the event stream. Type is one of "snapshot" (sent on
+// connect, with every live notification), "created", "modified", "deleted",
+// "group" and "state" (do-not-disturb or pause changed).
+type streamLine struct {
+	Type          string               `json:"type"`
+	Notification  *streamNotification  `json:"notification,omitempty"`
+	Notifications []streamNotification `json:"notifications,omitempty"`
+	Coalesced     int                  `json:"coalesced,omitempty"`
+	Group         *streamGroup         `json:"group,omitempty"`
+	State         streamState          `json:"state"`
+}
+
+func toStreamNotification(n Notification) streamNotification {
+	s := streamNotification{
+		ID:             n.ID,
+		AppName:        n.AppName,
+		AppDisplayName: n.AppDisplayName,
+		AppIcon:        n.AppIcon,
+		AppIconPath:    n.AppIconPath,
+		Summary:        n.Summary,
+		Body:           n.Body,
+		Actions:        n.ParsedActions,
+		Urgency:        n.Urgency(),
+		Category:       n.Category(),
+		DesktopEntry:   n.DesktopEntry(),
+		ExpireTimeout:  n.ExpireTimeout,
+		Timestamp:      n.Timestamp,
+	}
+	if s.Actions == nil {
+		s.Actions = []Action{}
+	}
+	if value, ok := n.Value(); ok {
+		s.Value = &value
+	}
+	if !n.Updated.IsZero() {
+		updated := n.Updated
+		s.Updated = &updated
+	}
+	return s
+}
+
+// eventStream serves the daemon's events as newline-delimited JSON on a unix socket.
+type eventStream struct {
+	d        *Daemon
+	path     string
+	listener net.Listener
+
+	mu      sync.Mutex
+	clients map[*streamClient]struct{}
+}
+
+// streamClient is one connection to the event stream.
+type streamClient struct {
+	conn   net.Conn
+	state  chan struct{}
+	done   chan struct{}
+	events <-chan NotificationEvent
+	cancel func()
+}
+
+// startEventStream listens on path, replacing a stale socket left by a previous run.
+func (d *Daemon) startEventStream(path string) (*eventStream, error) {
+	if _, err := os.Stat(path); err == nil {
+		if conn, err := net.Dial("unix", path); err == nil {
+			conn.Close()
+			return nil, fmt.Errorf("event socket %s is in use", path)
+		}
+		os.Remove(path)
+	}
+
+	listener, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.Chmod(path, 0600); err != nil {
+		listener.Close()
+		return nil, err
+	}
+	return d.serveEventStream(listener, path), nil
+}
+
+// serveEventStream serves the event stream on listener. path is the socket file
+// removed on close, "" for sockets the daemon doesn't own.
+func (d *Daemon) serveEventStream(listener net.Listener, path string) *eventStream {
+	s := &eventStream{
+		d:        d,
+		path:     path,
+		listener: listener,
+		clients:  make(map[*streamClient]struct{}),
+	}
+	go s.accept()
+	return s
+}
+
+func (s *eventStream) accept() {
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			if !errors.Is(err, net.ErrClosed) {
+				slog.Error("Event stream stopped accepting connections", "error", err)
+			}
+			return
+		}
+
+		events, cancel := s.d.Subscribe(64)
+		client := &streamClient{
+			conn:   conn,
+			state:  make(chan struct{}, 1),
+			done:   make(chan struct{}),
+			events: events,
+			cancel: cancel,
+		}
+		s.mu.Lock()
+		s.clients[client] = struct{}{}
+		s.mu.Unlock()
+
+		go s.serve(client)
+	}
+}
+
+// serve writes the snapshot and then every event to a client until it goes away.
+func (s *eventStream) serve(client *streamClient) {
+	defer func() {
+		client.cancel()
+		client.conn.Close()
+		s.mu.Lock()
+		delete(s.clients, client)
+		s.mu.Unlock()
+	}()
+
+	encoder := json.NewEncoder(client.conn)
+
+	snapshot := streamLine{Type: "snapshot", Notifications: []streamNotification{}, State: s.state()}
+	for _, n := range s.d.LiveNotifications() {
+		snapshot.Notifications = append(snapshot.Notifications, toStreamNotification(n))
+	}
+	if encoder.Encode(snapshot) != nil {
+		return
+	}
+
+	for {
+		var line streamLine
+		select {
+		case event, ok := <-client.events:
+			if !ok {
+				return
+			}
+			line = s.line(event)
+		case <-client.state:
+			line = streamLine{Type: "state", State: s.state()}
+		case <-client.done:
+			return
+		}
+		client.conn.SetWriteDeadline(time.Now().Add(5 * time.Second))
+		if encoder.Encode(line) != nil {
+			return
+		}
+	}
+}
+
+func (s *eventStream) line(event NotificationEvent) streamLine {
+	line := streamLine{Coalesced: event.Coalesced, State: s.state()}
+	switch {
+	case event.GroupChanged:
+		line.Type = "group"
+		group := streamGroup{
+			Key:       event.Group.Key,
+			Title:     event.Group.Title,
+			Count:     event.Group.Count(),
+			Collapsed: event.Group.Collapsed,
+			IDs:       []uint32{},
+		}
+		for _, n := range event.Group.Notifications {
+			group.IDs = append(group.IDs, n.ID)
+		}
+		line.Group = &group
+		return line
+	case event.Created:
+		line.Type = "created"
+	case event.Modified:
+		line.Type = "modified"
+	case event.Deleted:
+		line.Type = "deleted"
+	}
+	n := toStreamNotification(event.Notification)
+	line.Notification = &n
+	return line
+}
+
+func (s *eventStream) state() streamState {
+	counts := s.d.Counts()
+	return streamState{
+		Live:         counts.Live,
+		History:      counts.History,
+		Held:         counts.Held,
+		DoNotDisturb: s.d.DoNotDisturb(),
+		Paused:       s.d.Paused(),
+		Fullscreen:   s.d.fullscreenHolder(),
+	}
+}
+
+// stateChanged tells every client to send a state line.
+func (s *eventStream) stateChanged() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for client := range s.clients {
+		select {
+		case client.state <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// close stops listening, disconnects the clients and removes the socket.
+func (s *eventStream) close() {
+	s.listener.Close()
+	s.mu.Lock()
+	for client := range s.clients {
+		close(client.done)
+		client.conn.Close()
+	}
+	s.mu.Unlock()
+	if s.path != "" {
+		os.Remove(s.path)
+	}
+}
@@ -0,0 +1,109 @@
+/*
+	libxdg-go - An implementaion of various freedesktop specifications in go
+    Copyright (C) 2025 MiracleOS Contributors
+
+    This program is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    This program is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+*/
+
+package notificationDaemon
+
+import (
+	"time"
+)
+
+// expiration is the pending expiration of a notification. While the session is
+// idle the timer is stopped and remaining holds the time left.
+type expiration struct {
+	timer     *time.Timer
+	deadline  time.Time
+	remaining time.Duration
+}
+
+// scheduleExpiration (re)arms the expiration timer of a notification.
+// A timeout of 0 means the notification never expires, -1 uses Config.DefaultExpireTimeout.
+// d.mu must be held.
+func (d *Daemon) scheduleExpiration(id uint32, expireTimeout int32) {
+	d.stopTimer(id)
+
+	timeout := time.Duration(expireTimeout) * time.Millisecond
+	if expireTimeout < 0 {
+		timeout = d.config.DefaultExpireTimeout
+	}
+	if timeout <= 0 {
+		return
+	}
+
+	e := &expiration{remaining: timeout}
+	d.timers[id] = e
+	if !d.idleLocked() {
+		d.armLocked(id, e)
+	}
+}
+
+// armLocked starts the timer of an expiration for its remaining time.
+// d.mu must be held.
+func (d *Daemon) armLocked(id uint32, e *expiration) {
+	e.deadline = time.Now().Add(e.remaining)
+	e.timer = time.AfterFunc(e.remaining, func() {
+		d.expire(id, e)
+	})
+}
+
+// stopTimer cancels the pending expiration of a notification, if any.
+// d.mu must be held.
+func (d *Daemon) stopTimer(id uint32) {
+	if e, exists := d.timers[id]; exists {
+		if e.timer != nil {
+			e.timer.Stop()
+		}
+		delete(d.timers, id)
+	}
+}
+
+// pauseTimersLocked stops every expiration timer, keeping the time left.
+// d.mu must be held.
+func (d *Daemon) pauseTimersLocked() {
+	for _, e := range d.timers {
+		if e.timer == nil {
+			continue
+		}
+		e.timer.Stop()
+		e.timer = nil
+		e.remaining = max(time.Until(e.deadline), 0)
+	}
+}
+
+// resumeTimersLocked restarts the expiration timers stopped by pauseTimersLocked.
+// d.mu must be held.
+func (d *Daemon) resumeTimersLocked() {
+	for id, e := range d.timers {
+		if e.timer == nil {
+			d.armLocked(id, e)
+		}
+	}
+}
+
+// expire closes a notification whose timeout elapsed.
+// Expirations that were replaced or paused while waiting for the lock are ignored.
+func (d *Daemon) expire(id uint32, e *expiration) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.timers[id] != e || e.timer == nil {
+		return
+	}
+
+	d.closeLocked(id, CloseReasonExpired)
+}
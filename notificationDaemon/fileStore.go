@@ -0,0 +1,206 @@
+/*
+	libxdg-go - An implementaion of various freedesktop specifications in go
+    Copyright (C) 2025 MiracleOS Contributors
+
+    This program is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    This program is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+*/
+
+package notificationDaemon
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+
+	basedir "github.com/MiracleOS-Team/libxdg-go/baseDir"
+	"github.com/MiracleOS-Team/libxdg-go/internal/atomicfile"
+)
+
+// FileStore is a NotificationStore keeping its state in a directory, so that the
+// history and snoozed notifications survive restarts. The history is an append-only
+// JSON lines file that is only read when asked for, so it doesn't live in memory.
+type FileStore struct {
+	dir string
+	// mu guards the history file, written to without the daemon lock.
+	mu         sync.Mutex
+	history    *os.File
+	historyLen int
+}
+
+// DefaultStoreDir returns the conventional FileStore directory,
+// $XDG_STATE_HOME/libxdg-notifications.
+func DefaultStoreDir() string {
+	return filepath.Join(fmt.Sprintf("%v", basedir.GetXDGDirectory("state")), "libxdg-notifications")
+}
+
+// NewFileStore opens, or creates, a FileStore in dir.
+func NewFileStore(dir string) (*FileStore, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, err
+	}
+	s := &FileStore{dir: dir}
+
+	history, err := s.readHistory()
+	if err != nil {
+		return nil, err
+	}
+	s.historyLen = len(history)
+
+	s.history, err = os.OpenFile(s.historyPath(), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *FileStore) historyPath() string {
+	return filepath.Join(s.dir, "history.jsonl")
+}
+
+func (s *FileStore) snoozedPath() string {
+	return filepath.Join(s.dir, "snoozed.json")
+}
+
+// AppendHistory appends a closed notification to the history file. The file is
+// compacted once it holds half again as many entries as limit.
+func (s *FileStore) AppendHistory(n Notification, limit int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	line, err := json.Marshal(toStoredNotification(n))
+	if err != nil {
+		return err
+	}
+	if _, err := s.history.Write(append(line, '\n')); err != nil {
+		return err
+	}
+	s.historyLen++
+
+	if s.historyLen > limit+limit/2 {
+		return s.compactHistory(limit)
+	}
+	return nil
+}
+
+// compactHistory rewrites the history file with its last limit entries.
+func (s *FileStore) compactHistory(limit int) error {
+	history, err := s.readHistory()
+	if err != nil {
+		return err
+	}
+	if excess := len(history) - limit; excess > 0 {
+		history = history[excess:]
+	}
+	return s.rewriteHistory(history)
+}
+
+// rewriteHistory atomically replaces the history file.
+func (s *FileStore) rewriteHistory(history []storedNotification) error {
+	err := atomicfile.Write(s.historyPath(), 0600, func(w io.Writer) error {
+		encoder := json.NewEncoder(w)
+		for _, n := range history {
+			if err := encoder.Encode(n); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	s.history.Close()
+	s.history, err = os.OpenFile(s.historyPath(), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	s.historyLen = len(history)
+	return err
+}
+
+// readHistory reads the history file. Lines that can't be decoded, such as one
+// cut short by a crash, are skipped.
+func (s *FileStore) readHistory() ([]storedNotification, error) {
+	file, err := os.Open(s.historyPath())
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var history []storedNotification
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		var n storedNotification
+		if json.Unmarshal(scanner.Bytes(), &n) == nil {
+			history = append(history, n)
+		}
+	}
+	return history, scanner.Err()
+}
+
+// History reads the history, oldest first. It only reads the file, which is
+// appended to and replaced atomically, so it is safe alongside the other methods.
+func (s *FileStore) History() ([]Notification, error) {
+	stored, err := s.readHistory()
+	if err != nil {
+		return nil, err
+	}
+
+	history := make([]Notification, 0, len(stored))
+	for _, n := range stored {
+		history = append(history, n.notification())
+	}
+	return history, nil
+}
+
+// HistoryLen returns the number of entries in the history file. Between
+// compactions it can exceed the limit given to AppendHistory.
+func (s *FileStore) HistoryLen() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.historyLen
+}
+
+// ClearHistory empties the history file.
+func (s *FileStore) ClearHistory() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.rewriteHistory(nil)
+}
+
+// SaveSnoozed replaces the snoozed notifications file.
+func (s *FileStore) SaveSnoozed(snoozed []SnoozedNotification) error {
+	return writeSnoozedFile(s.snoozedPath(), snoozed)
+}
+
+// LoadSnoozed reads the snoozed notifications file.
+func (s *FileStore) LoadSnoozed() ([]SnoozedNotification, error) {
+	return readSnoozedFile(s.snoozedPath())
+}
+
+// Close closes the history file.
+func (s *FileStore) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.history.Close()
+}
@@ -0,0 +1,134 @@
+/*
+	libxdg-go - An implementaion of various freedesktop specifications in go
+    Copyright (C) 2025 MiracleOS Contributors
+
+    This program is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    This program is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+*/
+
+package notificationDaemon
+
+import (
+	"log/slog"
+	"strings"
+)
+
+// FullscreenDND configures automatic do-not-disturb while a fullscreen window, such
+// as a game or a video, is focused. Notifications that aren't critical are held back
+// and delivered once the window leaves fullscreen or loses focus.
+type FullscreenDND struct {
+	Enabled bool
+	// IgnoreApps are applications whose fullscreen windows don't hold notifications
+	// back, e.g. a terminal or an editor used fullscreen.
+	IgnoreApps []string
+	// AllowApps are applications whose notifications are delivered even while a
+	// fullscreen window is focused.
+	AllowApps []string
+	// Source reports the focused fullscreen window. Without one, the state has to be
+	// fed with Daemon.SetFullscreenApp.
+	Source FullscreenSource
+}
+
+// FullscreenSource reports the application owning the focused fullscreen window,
+// typically from the compositor's toplevel list.
+type FullscreenSource interface {
+	// WatchFullscreen calls fn with the app ID of the focused fullscreen window, or ""
+	// when there is none, each time it changes, until stop is called.
+	WatchFullscreen(fn func(appID string)) (stop func(), err error)
+}
+
+// SetFullscreenApp tells the daemon which application has a focused fullscreen
+// window, "" for none. It only has an effect with Config.FullscreenDND enabled.
+func (d *Daemon) SetFullscreenApp(appID string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	appID = strings.TrimSuffix(appID, ".desktop")
+	if d.fullscreenApp == appID {
+		return
+	}
+	wasHolding := d.fullscreenActiveLocked()
+	d.fullscreenApp = appID
+
+	switch holding := d.fullscreenActiveLocked(); {
+	case holding && !wasHolding:
+		slog.Debug("Fullscreen window focused, holding notifications back", "app", appID)
+	case !holding && wasHolding:
+		slog.Debug("Fullscreen window gone, delivering held notifications")
+		d.releaseHeldLocked()
+	}
+	d.stateChangedLocked()
+}
+
+// FullscreenApp returns the application last reported with a focused fullscreen window.
+func (d *Daemon) FullscreenApp() string {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	return d.fullscreenApp
+}
+
+// fullscreenHolder returns the application holding notifications back with a
+// fullscreen window, or "".
+func (d *Daemon) fullscreenHolder() string {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if !d.fullscreenActiveLocked() {
+		return ""
+	}
+	return d.fullscreenApp
+}
+
+// fullscreenActiveLocked reports whether a fullscreen window currently holds
+// notifications back.
+// d.mu must be held.
+func (d *Daemon) fullscreenActiveLocked() bool {
+	if !d.config.FullscreenDND.Enabled || d.fullscreenApp == "" {
+		return false
+	}
+	for _, app := range d.config.FullscreenDND.IgnoreApps {
+		if strings.TrimSuffix(app, ".desktop") == d.fullscreenApp {
+			return false
+		}
+	}
+	return true
+}
+
+// fullscreenHoldsLocked reports whether a notification is held back by a focused
+// fullscreen window.
+// d.mu must be held.
+func (d *Daemon) fullscreenHoldsLocked(n Notification) bool {
+	if !d.fullscreenActiveLocked() || n.Urgency() == UrgencyCritical {
+		return false
+	}
+	for _, app := range d.config.FullscreenDND.AllowApps {
+		if n.fromApp(app) {
+			return false
+		}
+	}
+	return true
+}
+
+// watchFullscreen subscribes to Config.FullscreenDND.Source.
+func (d *Daemon) watchFullscreen() error {
+	stop, err := d.config.FullscreenDND.Source.WatchFullscreen(d.SetFullscreenApp)
+	if err != nil {
+		return err
+	}
+	d.mu.Lock()
+	d.fullscreenStop = stop
+	d.mu.Unlock()
+	return nil
+}
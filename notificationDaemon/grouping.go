@@ -0,0 +1,154 @@
+/*
+	libxdg-go - An implementaion of various freedesktop specifications in go
+    Copyright (C) 2025 MiracleOS Contributors
+
+    This program is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    This program is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+*/
+
+package notificationDaemon
+
+import (
+	"sort"
+)
+
+// GroupingMode selects how live notifications are clustered into groups.
+type GroupingMode int
+
+const (
+	// GroupingNone disables grouping; no group events are sent.
+	GroupingNone GroupingMode = iota
+	// GroupingByApp groups notifications by sending application.
+	GroupingByApp
+	// GroupingByCategory groups notifications by their category hint,
+	// falling back to the sending application.
+	GroupingByCategory
+)
+
+// Group is the state of one group of live notifications.
+type Group struct {
+	// Key identifies the group: the x-group hint when set, otherwise the
+	// category or application depending on the GroupingMode.
+	Key string
+	// Title is a display name for the group.
+	Title string
+	// Notifications are the live notifications of the group, newest first.
+	Notifications []Notification
+	// Collapsed is the collapse state set with SetGroupCollapsed.
+	Collapsed bool
+}
+
+// Count returns the number of notifications in the group.
+func (g Group) Count() int {
+	return len(g.Notifications)
+}
+
+// groupKey returns the group a notification belongs to and the group's title.
+// The x-group hint overrides the grouping mode.
+func (d *Daemon) groupKey(n Notification) (string, string) {
+	appKey, appTitle := "app:"+n.AppName, n.AppDisplayName
+	if id := n.DesktopEntry(); id != "" {
+		appKey = "app:" + id
+	}
+	if appTitle == "" {
+		appTitle = n.AppName
+	}
+
+	if group := hintString(n.Hints, "x-group"); group != "" {
+		return "group:" + group, group
+	}
+	if d.config.Grouping == GroupingByCategory {
+		if category := n.Category(); category != "" {
+			return "category:" + category, category
+		}
+	}
+	return appKey, appTitle
+}
+
+// groupLocked builds the current state of a group.
+// d.mu must be held.
+func (d *Daemon) groupLocked(key string) Group {
+	group := Group{Key: key, Collapsed: d.collapsed[key]}
+	for _, n := range d.Notifications {
+		if k, title := d.groupKey(n); k == key {
+			group.Title = title
+			group.Notifications = append(group.Notifications, n)
+		}
+	}
+	sort.Slice(group.Notifications, func(i, j int) bool {
+		return group.Notifications[i].Timestamp.After(group.Notifications[j].Timestamp)
+	})
+	return group
+}
+
+// Groups returns the groups of live notifications, the group with the newest notification first.
+// It returns nil when grouping is disabled.
+func (d *Daemon) Groups() []Group {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.config.Grouping == GroupingNone {
+		return nil
+	}
+	keys := make(map[string]bool)
+	for _, n := range d.Notifications {
+		key, _ := d.groupKey(n)
+		keys[key] = true
+	}
+	groups := make([]Group, 0, len(keys))
+	for key := range keys {
+		groups = append(groups, d.groupLocked(key))
+	}
+	sort.Slice(groups, func(i, j int) bool {
+		return groups[i].Notifications[0].Timestamp.After(groups[j].Notifications[0].Timestamp)
+	})
+	return groups
+}
+
+// SetGroupCollapsed changes the collapse state of a group and broadcasts a GroupChanged event.
+func (d *Daemon) SetGroupCollapsed(key string, collapsed bool) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.config.Grouping == GroupingNone {
+		return nil
+	}
+	group := d.groupLocked(key)
+	if group.Count() == 0 {
+		return ErrNotificationNotFound
+	}
+	if group.Collapsed == collapsed {
+		return nil
+	}
+	d.collapsed[key] = collapsed
+	group.Collapsed = collapsed
+	d.publishLocked(NotificationEvent{GroupChanged: true, Group: &group})
+	return nil
+}
+
+// broadcastGroupLocked follows a notification event with a GroupChanged event for the
+// notification's group. Groups that become empty lose their collapse state.
+// d.mu must be held.
+func (d *Daemon) broadcastGroupLocked(event NotificationEvent) {
+	if d.config.Grouping == GroupingNone || event.GroupChanged {
+		return
+	}
+	key, title := d.groupKey(event.Notification)
+	group := d.groupLocked(key)
+	if group.Count() == 0 {
+		delete(d.collapsed, key)
+		group.Title = title
+	}
+	d.publishLocked(NotificationEvent{GroupChanged: true, Group: &group})
+}
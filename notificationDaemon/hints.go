@@ -0,0 +1,140 @@
+/*
+	libxdg-go - An implementaion of various freedesktop specifications in go
+    Copyright (C) 2025 MiracleOS Contributors
+
+    This program is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    This program is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+*/
+
+package notificationDaemon
+
+import (
+	"github.com/godbus/dbus/v5"
+)
+
+// Urgency is the urgency level of a notification.
+type Urgency byte
+
+const (
+	UrgencyLow      Urgency = 0
+	UrgencyNormal   Urgency = 1
+	UrgencyCritical Urgency = 2
+)
+
+// Urgency returns the urgency hint, defaulting to UrgencyNormal when it is missing or invalid.
+func (n Notification) Urgency() Urgency {
+	urgency, ok := hintInt(n.Hints, "urgency")
+	if !ok || urgency < int64(UrgencyLow) || urgency > int64(UrgencyCritical) {
+		return UrgencyNormal
+	}
+	return Urgency(urgency)
+}
+
+// Category returns the category hint (e.g. "email.arrived"), or "" if there is none.
+func (n Notification) Category() string {
+	return hintString(n.Hints, "category")
+}
+
+// DesktopEntry returns the desktop-entry hint, the desktop file ID of the sending application.
+func (n Notification) DesktopEntry() string {
+	return hintString(n.Hints, "desktop-entry")
+}
+
+// ImagePath returns the image-path hint (or its deprecated image_path spelling), or "" if there is none.
+func (n Notification) ImagePath() string {
+	if path := hintString(n.Hints, "image-path"); path != "" {
+		return path
+	}
+	return hintString(n.Hints, "image_path")
+}
+
+// Transient reports whether the server should bypass its persistence for this notification.
+func (n Notification) Transient() bool {
+	return hintBool(n.Hints, "transient")
+}
+
+// Resident reports whether the notification should stay around after an action is invoked.
+func (n Notification) Resident() bool {
+	return hintBool(n.Hints, "resident")
+}
+
+// ActionIcons reports whether the action keys are icon names to show instead of the labels.
+func (n Notification) ActionIcons() bool {
+	return hintBool(n.Hints, "action-icons")
+}
+
+// Value returns the value hint, used by progress bars and OSDs, and whether it is present.
+// The spec defines it as a percentage, so it is clamped to 0-100.
+func (n Notification) Value() (int32, bool) {
+	value, ok := hintInt(n.Hints, "value")
+	if !ok {
+		return 0, false
+	}
+	return int32(min(max(value, 0), 100)), true
+}
+
+// SynchronousTag returns the x-canonical-private-synchronous hint (or dunst's
+// x-dunst-stack-tag), or "" if there is none. Notifications sharing a tag replace
+// each other, which is how volume and brightness OSDs update in place.
+func (n Notification) SynchronousTag() string {
+	if tag := hintString(n.Hints, "x-canonical-private-synchronous"); tag != "" {
+		return tag
+	}
+	return hintString(n.Hints, "x-dunst-stack-tag")
+}
+
+// SenderPID returns the sender-pid hint, or 0 if there is none.
+func (n Notification) SenderPID() uint32 {
+	pid, ok := hintInt(n.Hints, "sender-pid")
+	if !ok || pid < 0 {
+		return 0
+	}
+	return uint32(pid)
+}
+
+// hintString returns a string hint, or "" if it is missing or has another type.
+func hintString(hints map[string]dbus.Variant, key string) string {
+	value, _ := hints[key].Value().(string)
+	return value
+}
+
+// hintInt returns an integer hint of any D-Bus integer type.
+func hintInt(hints map[string]dbus.Variant, key string) (int64, bool) {
+	switch value := hints[key].Value().(type) {
+	case byte:
+		return int64(value), true
+	case int16:
+		return int64(value), true
+	case uint16:
+		return int64(value), true
+	case int32:
+		return int64(value), true
+	case uint32:
+		return int64(value), true
+	case int64:
+		return value, true
+	case uint64:
+		return int64(value), true
+	}
+	return 0, false
+}
+
+// hintBool returns a boolean hint. Some clients send booleans as integers, which are accepted too.
+func hintBool(hints map[string]dbus.Variant, key string) bool {
+	if value, ok := hints[key].Value().(bool); ok {
+		return value
+	}
+	value, ok := hintInt(hints, key)
+	return ok && value != 0
+}
@@ -0,0 +1,56 @@
+/*
+	libxdg-go - An implementaion of various freedesktop specifications in go
+    Copyright (C) 2025 MiracleOS Contributors
+
+    This program is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    This program is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+*/
+
+package notificationDaemon
+
+import (
+	"log/slog"
+)
+
+// Hook inspects a notification received from a client before it is stored and
+// broadcast. It may modify the notification in place (e.g. redact the body on the
+// lock screen, translate it, set a category) and returns false to veto it.
+//
+// n.ID holds the replaces_id sent by the client, 0 for new notifications; changing
+// it has no effect. Hooks run without the daemon lock held, in registration order,
+// and the first veto stops the pipeline.
+type Hook func(n *Notification) bool
+
+// AddHook appends a hook to the pipeline run for every incoming notification.
+func (d *Daemon) AddHook(hook Hook) {
+	d.hooksMu.Lock()
+	defer d.hooksMu.Unlock()
+
+	d.hooks = append(d.hooks, hook)
+}
+
+// runHooks passes a notification through the hooks and reports whether it survived.
+func (d *Daemon) runHooks(n *Notification) bool {
+	d.hooksMu.RLock()
+	hooks := d.hooks
+	d.hooksMu.RUnlock()
+
+	for i, hook := range hooks {
+		if !hook(n) {
+			slog.Debug("Notification vetoed by hook", "app", n.AppName, "summary", n.Summary, "hook", i)
+			return false
+		}
+	}
+	return true
+}
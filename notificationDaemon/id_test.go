@@ -0,0 +1,134 @@
+/*
+	libxdg-go - An implementaion of various freedesktop specifications in go
+    Copyright (C) 2025 MiracleOS Contributors
+
+    This program is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    This program is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+*/
+
+package notificationDaemon
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/godbus/dbus/v5"
+)
+
+// newTestDaemon returns a daemon that isn't on the bus, stopped when the test ends.
+func newTestDaemon(t *testing.T) *Daemon {
+	t.Helper()
+	d := NewDaemon(Config{})
+	t.Cleanup(func() {
+		go func() {
+			for range d.NotificationsChannel {
+			}
+		}()
+		d.Stop()
+	})
+	return d
+}
+
+func nextEvent(t *testing.T, d *Daemon) NotificationEvent {
+	t.Helper()
+	select {
+	case event := <-d.NotificationsChannel:
+		return event
+	case <-time.After(time.Second):
+		t.Fatal("no notification event")
+		return NotificationEvent{}
+	}
+}
+
+func notify(t *testing.T, d *Daemon, replacesID uint32, summary string) uint32 {
+	t.Helper()
+	id, err := d.Notify("test", replacesID, "", summary, "", []string{}, map[string]dbus.Variant{}, -1)
+	if err != nil {
+		t.Fatalf("Notify: %v", err)
+	}
+	return id
+}
+
+func TestReplaceLiveNotification(t *testing.T) {
+	d := newTestDaemon(t)
+	id := notify(t, d, 0, "first")
+	created := nextEvent(t, d)
+	if !created.Created || created.Modified {
+		t.Fatalf("first event: got %+v, want Created", created)
+	}
+
+	if replaced := notify(t, d, id, "second"); replaced != id {
+		t.Fatalf("replacing %d returned ID %d", id, replaced)
+	}
+	modified := nextEvent(t, d)
+	if !modified.Modified || modified.Created {
+		t.Fatalf("replace event: got Created=%v Modified=%v, want Modified", modified.Created, modified.Modified)
+	}
+	n := modified.Notification
+	if n.ID != id || n.Summary != "second" {
+		t.Errorf("replaced notification: got ID %d summary %q", n.ID, n.Summary)
+	}
+	if !n.Timestamp.Equal(created.Notification.Timestamp) {
+		t.Errorf("Timestamp changed from %v to %v", created.Notification.Timestamp, n.Timestamp)
+	}
+	if n.Updated.IsZero() {
+		t.Error("Updated not set on replacement")
+	}
+}
+
+func TestReplaceIDNotLive(t *testing.T) {
+	d := newTestDaemon(t)
+	id := notify(t, d, 0, "first")
+	nextEvent(t, d)
+
+	const stale = 4242
+	fresh := notify(t, d, stale, "second")
+	if fresh == stale || fresh == id || fresh == 0 {
+		t.Fatalf("non-live replaces_id %d got ID %d, want a fresh one", stale, fresh)
+	}
+	event := nextEvent(t, d)
+	if !event.Created || event.Modified {
+		t.Fatalf("got Created=%v Modified=%v, want Created", event.Created, event.Modified)
+	}
+	if event.Notification.ID != fresh {
+		t.Errorf("event for ID %d, want %d", event.Notification.ID, fresh)
+	}
+}
+
+func TestAllocateIDWraparound(t *testing.T) {
+	d := newTestDaemon(t)
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.Notifications[1] = Notification{ID: 1}
+	d.snoozed[2] = &snoozedNotification{notification: Notification{ID: 2}}
+	d.Notifications[math.MaxUint32] = Notification{ID: math.MaxUint32}
+	d.nextID = math.MaxUint32 - 1
+	defer func() {
+		delete(d.Notifications, 1)
+		delete(d.Notifications, math.MaxUint32)
+		delete(d.snoozed, 2)
+	}()
+
+	for _, want := range []uint32{math.MaxUint32 - 1, 3, 4} {
+		id, err := d.allocateIDLocked()
+		if err != nil {
+			t.Fatalf("allocateIDLocked: %v", err)
+		}
+		if id != want {
+			t.Fatalf("got ID %d, want %d", id, want)
+		}
+	}
+}
@@ -0,0 +1,168 @@
+/*
+	libxdg-go - An implementaion of various freedesktop specifications in go
+    Copyright (C) 2025 MiracleOS Contributors
+
+    This program is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    This program is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+*/
+
+package notificationDaemon
+
+import (
+	"log/slog"
+
+	"github.com/MiracleOS-Team/libxdg-go/idleNotify"
+	"github.com/godbus/dbus/v5"
+)
+
+const (
+	logindBusName          = "org.freedesktop.login1"
+	logindPath             = dbus.ObjectPath("/org/freedesktop/login1")
+	logindAutoSessionPath  = dbus.ObjectPath("/org/freedesktop/login1/session/auto")
+	logindManagerInterface = "org.freedesktop.login1.Manager"
+	logindSessionInterface = "org.freedesktop.login1.Session"
+)
+
+// Idle sources, combined so that the session is idle while any of them says so.
+const (
+	idleSourceManual  = "manual"
+	idleSourceLogind  = "logind"
+	idleSourceWayland = "wayland"
+)
+
+// SetIdle tells the daemon whether the user is away. While idle, expiration timers
+// are paused and resume with the time they had left, so notifications don't expire
+// unseen. Config.PauseWhenIdle and Config.IdleTimeout feed logind's idle and lock
+// state and the compositor's idle notifications in as well.
+func (d *Daemon) SetIdle(idle bool) {
+	d.setIdleSource(idleSourceManual, idle)
+}
+
+// Idle reports whether expiration is paused because the user is away.
+func (d *Daemon) Idle() bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	return d.idleLocked()
+}
+
+// idleLocked reports whether any idle source says the user is away.
+// d.mu must be held.
+func (d *Daemon) idleLocked() bool {
+	for _, idle := range d.idle {
+		if idle {
+			return true
+		}
+	}
+	return false
+}
+
+// setIdleSource records the state of one idle source and pauses or resumes the
+// expiration timers when the combined state changes.
+func (d *Daemon) setIdleSource(source string, idle bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	wasIdle := d.idleLocked()
+	d.idle[source] = idle
+	switch isIdle := d.idleLocked(); {
+	case isIdle && !wasIdle:
+		d.pauseTimersLocked()
+		slog.Debug("Session idle, expiration paused", "source", source)
+	case !isIdle && wasIdle:
+		d.resumeTimersLocked()
+		slog.Debug("Session active, expiration resumed", "source", source)
+	}
+}
+
+// watchLogind follows the IdleHint and LockedHint of the current logind session.
+func (d *Daemon) watchLogind() error {
+	conn, err := dbus.ConnectSystemBus()
+	if err != nil {
+		return err
+	}
+
+	// Signals come from the real session path, not the "auto" alias.
+	var sessionID string
+	if err := conn.Object(logindBusName, logindAutoSessionPath).StoreProperty(logindSessionInterface+".Id", &sessionID); err != nil {
+		conn.Close()
+		return err
+	}
+	var session dbus.ObjectPath
+	if err := conn.Object(logindBusName, logindPath).Call(logindManagerInterface+".GetSession", 0, sessionID).Store(&session); err != nil {
+		conn.Close()
+		return err
+	}
+
+	if err := conn.AddMatchSignal(
+		dbus.WithMatchObjectPath(session),
+		dbus.WithMatchInterface("org.freedesktop.DBus.Properties"),
+		dbus.WithMatchMember("PropertiesChanged"),
+	); err != nil {
+		conn.Close()
+		return err
+	}
+	signals := make(chan *dbus.Signal, 10)
+	conn.Signal(signals)
+
+	d.logind = conn
+	d.setIdleSource(idleSourceLogind, logindSessionIdle(conn, session))
+
+	go func() {
+		for range signals {
+			d.setIdleSource(idleSourceLogind, logindSessionIdle(conn, session))
+		}
+	}()
+	slog.Debug("Following logind session idle state", "session", session)
+	return nil
+}
+
+// watchWaylandIdle follows the compositor's idle notifications for
+// Config.IdleTimeout.
+func (d *Daemon) watchWaylandIdle() error {
+	client, err := idleNotify.Connect()
+	if err != nil {
+		return err
+	}
+	_, err = client.Watch(d.config.IdleTimeout,
+		func() { d.setIdleSource(idleSourceWayland, true) },
+		func() { d.setIdleSource(idleSourceWayland, false) },
+	)
+	if err != nil {
+		client.Close()
+		return err
+	}
+	d.idleNotifier = client
+
+	// Don't stay paused if the compositor goes away while the user is idle.
+	go func() {
+		<-client.Done()
+		d.setIdleSource(idleSourceWayland, false)
+	}()
+	slog.Debug("Following Wayland idle notifications", "timeout", d.config.IdleTimeout)
+	return nil
+}
+
+// logindSessionIdle reports whether a logind session is idle or locked.
+func logindSessionIdle(conn *dbus.Conn, session dbus.ObjectPath) bool {
+	obj := conn.Object(logindBusName, session)
+	var idle, locked bool
+	if err := obj.StoreProperty(logindSessionInterface+".IdleHint", &idle); err != nil {
+		slog.Debug("Failed to read logind IdleHint", "session", session, "error", err)
+	}
+	if err := obj.StoreProperty(logindSessionInterface+".LockedHint", &locked); err != nil {
+		slog.Debug("Failed to read logind LockedHint", "session", session, "error", err)
+	}
+	return idle || locked
+}
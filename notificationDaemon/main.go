@@ -21,26 +21,110 @@ package notificationDaemon
 
 import (
 	"errors"
-	"fmt"
 	"log/slog"
+	"math"
 	"os"
 	"strconv"
 	"strings"
 	"sync"
-	"syscall"
 	"time"
 
+	"github.com/MiracleOS-Team/libxdg-go/desktopFiles"
+	"github.com/MiracleOS-Team/libxdg-go/idleNotify"
 	"github.com/godbus/dbus/v5"
 	"github.com/godbus/dbus/v5/introspect"
 )
 
+const (
+	busName       = "org.freedesktop.Notifications"
+	objectPath    = dbus.ObjectPath("/org/freedesktop/Notifications")
+	interfaceName = "org.freedesktop.Notifications"
+)
+
+// Reasons passed with the NotificationClosed signal, as defined by the spec.
+const (
+	CloseReasonExpired   uint32 = 1
+	CloseReasonDismissed uint32 = 2
+	CloseReasonClosed    uint32 = 3
+	CloseReasonUndefined uint32 = 4
+)
+
 // Config allows customization of the daemon.
 type Config struct {
-	// LockFilePath is used for the file lock.
-	// If empty, it defaults to $XDG_RUNTIME_DIR/notificationdaemon.lock or /tmp/notificationdaemon.lock.
+	// LockFilePath is ignored.
+	//
+	// Deprecated: only one daemon can own org.freedesktop.Notifications on a bus,
+	// which is what keeps the daemon single-instance now.
 	LockFilePath string
-	// You can add additional customization options here.
+	// Capabilities are the capabilities advertised by GetCapabilities. Features of
+	// notifications that need a capability missing from this list are dropped.
+	// If nil, "body" and "actions" are advertised.
 	Capabilities []string
+	// SpecVersion is the spec version reported by GetServerInformation.
+	// If empty, DefaultSpecVersion is used.
+	SpecVersion string
+	// BusAddress is the address of the bus to serve on. If empty, the session bus is used.
+	BusAddress string
+	// Conn is an already established bus connection to serve on instead of dialing
+	// BusAddress, e.g. one shared with the rest of a shell. Stop leaves it open.
+	// Monitor mode always dials its own connection.
+	Conn *dbus.Conn
+	// DefaultExpireTimeout is used for notifications sent with an expire_timeout of -1.
+	// If zero, such notifications never expire.
+	DefaultExpireTimeout time.Duration
+	// Portal serves the org.freedesktop.impl.portal.Notification backend, so the
+	// notifications of sandboxed applications sent through xdg-desktop-portal are
+	// shown by the daemon. See PortalFile.
+	Portal bool
+	// PortalBusName is the bus name of the portal backend. Defaults to DefaultPortalBusName.
+	PortalBusName string
+	// FullscreenDND holds non-critical notifications back while a fullscreen window is focused.
+	FullscreenDND FullscreenDND
+	// PauseWhenIdle pauses expiration while logind reports the session as idle or
+	// locked. Other idle sources can use Daemon.SetIdle.
+	PauseWhenIdle bool
+	// IdleTimeout, if set, pauses expiration once the Wayland compositor reports
+	// the user idle for that long through ext-idle-notify-v1, which doesn't
+	// depend on the compositor updating logind's idle state.
+	IdleTimeout time.Duration
+	// RateLimit configures per-application flood protection.
+	RateLimit RateLimit
+	// Monitor makes the daemon observe the notifications handled by another notification
+	// server instead of owning org.freedesktop.Notifications itself. Notifications,
+	// NotificationsChannel and the history mirror that server; closing notifications
+	// through the daemon only affects the local copy.
+	Monitor bool
+	// ActionIconSize is the size in pixels action icons are looked up at. Defaults to 24.
+	ActionIconSize int
+	// EventBuffer is the number of events queued for NotificationsChannel before
+	// Backpressure applies. If zero, 10 is used.
+	EventBuffer int
+	// Backpressure decides what happens to new events when the consumer of
+	// NotificationsChannel falls behind.
+	Backpressure BackpressurePolicy
+	// OnEventDropped, if set, is called for every event dropped because of Backpressure
+	// or because it couldn't be delivered before shutdown. It must not block or call
+	// back into the daemon.
+	OnEventDropped func(NotificationEvent)
+	// Grouping enables the grouping layer and selects how notifications are grouped.
+	Grouping GroupingMode
+	// SnoozeFile is where snoozed notifications are saved so they survive restarts
+	// when Store is nil. If both are empty, snoozed notifications are lost when the
+	// daemon stops.
+	SnoozeFile string
+	// Store keeps the history and the snoozed notifications. Defaults to a
+	// MemoryStore; a FileStore makes them survive restarts. Stop closes it.
+	Store NotificationStore
+	// Hooks are run, in order, on every notification received from a client before it
+	// is stored. More can be added with AddHook.
+	Hooks []Hook
+	// EventSocket is the path of a unix socket streaming the events as newline-delimited
+	// JSON, for consumers that don't use D-Bus or Go (e.g. waybar custom modules).
+	// DefaultEventSocket returns the conventional path. If empty, no socket is created.
+	EventSocket string
+	// HistorySize is the number of closed notifications kept for the notification center.
+	// If zero, closed notifications are not kept.
+	HistorySize int
 }
 
 // Notification represents a notification event.
@@ -53,7 +137,20 @@ type Notification struct {
 	Actions       []string
 	Hints         map[string]dbus.Variant
 	ExpireTimeout int32
-	Timestamp     time.Time
+	// Timestamp is when the notification was created. Replacing it keeps the original time.
+	Timestamp time.Time
+	// Updated is when the notification was last replaced, or the zero time if it never was.
+	Updated time.Time
+	// AppDisplayName is the name of the sending application, taken from its desktop
+	// file when the desktop-entry hint resolves, AppName otherwise.
+	AppDisplayName string
+	// AppIconPath is the icon file of the sending application, from app_icon when it is
+	// a path or file:// URI, or from the desktop file of the desktop-entry hint.
+	AppIconPath string
+	// DesktopFile is the desktop file named by the desktop-entry hint, if it was found.
+	DesktopFile *desktopFiles.DesktopFile
+	// ParsedActions holds Actions as key/label pairs.
+	ParsedActions []Action
 }
 
 type NotificationEvent struct {
@@ -61,92 +158,131 @@ type NotificationEvent struct {
 	Created      bool
 	Modified     bool
 	Deleted      bool
+	// Coalesced is the number of notifications this event stands for in addition to
+	// Notification itself, when flood protection merged or suppressed some of them.
+	Coalesced int
+	// GroupChanged marks a group event, sent when grouping is enabled after each
+	// notification event and on collapse changes. Group holds the group's new state;
+	// a group without notifications is gone.
+	GroupChanged bool
+	Group        *Group
 }
 
 // Daemon implements the org.freedesktop.Notifications interface.
+//
+// NotificationsChannel receives the notification events. It is unbuffered: events
+// wait in a queue of Config.EventBuffer, where Config.Backpressure applies, until
+// the consumer takes them. Stop closes it.
 type Daemon struct {
 	config               Config
-	lockFile             *os.File
 	conn                 *dbus.Conn
 	mu                   sync.Mutex
 	Notifications        map[uint32]Notification
 	nextID               uint32
 	NotificationsChannel chan NotificationEvent
 	Logger               slog.Logger
+	timers               map[uint32]*expiration
+	stopped              bool
+	store                NotificationStore
+	historyMu            sync.Mutex
+	historyQueueMu       sync.Mutex
+	historyQueue         []historyChange
+	dnd                  bool
+	rates                map[string]*appRate
+	apps                 appResolver
+	actionIcons          actionIconResolver
+	events               *eventQueue
+	delivered            chan struct{}
+	paused               bool
+	held                 map[uint32]NotificationEvent
+	silenced             map[uint32]bool
+	collapsed            map[string]bool
+	snoozed              map[uint32]*snoozedNotification
+	hooks                []Hook
+	hooksMu              sync.RWMutex
+	subscribers          map[*subscriber]struct{}
+	stream               *eventStream
+	idle                 map[string]bool
+	metrics              map[string]*AppMetrics
+	portalConn           *dbus.Conn
+	portalIDs            map[string]uint32
+	portalEntries        map[uint32]*portalEntry
+	fullscreenApp        string
+	fullscreenStop       func()
+	logind               *dbus.Conn
+	idleNotifier         *idleNotify.Client
 }
 
-// NewDaemon creates a new NotificationDaemon instance.
+// NewDaemon creates a new NotificationDaemon instance. It starts delivering events
+// on NotificationsChannel right away, so Stop must be called to release the daemon,
+// even if Start was never called or failed.
 func NewDaemon(config Config) *Daemon {
-	if config.LockFilePath == "" {
-		xdgRuntime := os.Getenv("XDG_RUNTIME_DIR")
-		if xdgRuntime == "" {
-			xdgRuntime = os.TempDir()
-		}
-		config.LockFilePath = fmt.Sprintf("%s/notificationdaemon.lock", xdgRuntime)
+	if config.Capabilities == nil {
+		config.Capabilities = defaultCapabilities
+	}
+	if config.SpecVersion == "" {
+		config.SpecVersion = DefaultSpecVersion
+	}
+	if config.Store == nil {
+		config.Store = &MemoryStore{snoozeFile: config.SnoozeFile}
+	}
+	if config.PortalBusName == "" {
+		config.PortalBusName = DefaultPortalBusName
+	}
+	if config.ActionIconSize <= 0 {
+		config.ActionIconSize = 24
+	}
+	if config.EventBuffer <= 0 {
+		config.EventBuffer = 10
 	}
-	return &Daemon{
+	if config.HistorySize < 0 {
+		config.HistorySize = 0
+	}
+	d := &Daemon{
 		config:               config,
 		Notifications:        make(map[uint32]Notification),
 		nextID:               1,
-		NotificationsChannel: make(chan NotificationEvent, 10),
+		NotificationsChannel: make(chan NotificationEvent),
 		Logger:               *slog.New(slog.NewTextHandler(os.Stdout, nil)),
+		timers:               make(map[uint32]*expiration),
+		store:                config.Store,
+		rates:                make(map[string]*appRate),
+		events:               newEventQueue(config.EventBuffer, config.Backpressure, config.OnEventDropped),
+		delivered:            make(chan struct{}),
+		held:                 make(map[uint32]NotificationEvent),
+		silenced:             make(map[uint32]bool),
+		collapsed:            make(map[string]bool),
+		snoozed:              make(map[uint32]*snoozedNotification),
+		hooks:                append([]Hook(nil), config.Hooks...),
+		subscribers:          make(map[*subscriber]struct{}),
+		idle:                 make(map[string]bool),
+		metrics:              make(map[string]*AppMetrics),
+		portalIDs:            make(map[string]uint32),
+		portalEntries:        make(map[uint32]*portalEntry),
 	}
-}
-
-// fileLock acquires an exclusive lock on the specified file.
-func (d *Daemon) fileLock() error {
-	f, err := os.OpenFile(d.config.LockFilePath, os.O_CREATE|os.O_RDWR, 0666)
-	if err != nil {
-		return err
-	}
-	err = syscall.Flock(int(f.Fd()), syscall.LOCK_EX|syscall.LOCK_NB)
-	if err != nil {
-		return errors.New("another instance is already running")
-	}
-	d.lockFile = f
-	return nil
-}
-
-// fileUnlock releases the file lock.
-func (d *Daemon) fileUnlock() {
-	if d.lockFile != nil {
-		syscall.Flock(int(d.lockFile.Fd()), syscall.LOCK_UN)
-		d.lockFile.Close()
-		os.Remove(d.config.LockFilePath)
-		d.lockFile = nil
-	}
+	go d.deliverEvents()
+	return d
 }
 
 // Start initializes the DBus connection and registers the Notifications service.
+// In monitor mode it only starts observing the running notification server.
 func (d *Daemon) Start() error {
-	// Acquire file lock.
-	if err := d.fileLock(); err != nil {
-		return err
+	if d.config.Monitor {
+		return d.startMonitor()
 	}
 
 	// Connect to the session bus.
-	conn, err := dbus.ConnectSessionBus()
+	conn, err := d.connectBus()
 	if err != nil {
-		d.fileUnlock()
 		return err
 	}
 	d.conn = conn
 
-	// Request the well-known name "org.freedesktop.Notifications" on the bus.
-	reply, err := d.conn.RequestName("org.freedesktop.Notifications", dbus.NameFlagDoNotQueue)
+	// Export the daemon object on the bus. Everything is exported before the name
+	// is requested, clients that got us started by activation call right away.
+	err = d.conn.Export(d, objectPath, interfaceName)
 	if err != nil {
-		d.fileUnlock()
-		return err
-	}
-	if reply != dbus.RequestNameReplyPrimaryOwner {
-		d.fileUnlock()
-		return errors.New("notification daemon is already running (bus name taken)")
-	}
-
-	// Export the daemon object on the bus.
-	err = d.conn.Export(d, "/org/freedesktop/Notifications", "org.freedesktop.Notifications")
-	if err != nil {
-		d.fileUnlock()
+		d.disconnectBus()
 		return err
 	}
 
@@ -214,52 +350,196 @@ func (d *Daemon) Start() error {
 			introspect.IntrospectData,
 		},
 	}
-	err = d.conn.Export(introspect.NewIntrospectable(node), "/org/freedesktop/Notifications", "org.freedesktop.DBus.Introspectable")
+	err = d.conn.Export(introspect.NewIntrospectable(node), objectPath, "org.freedesktop.DBus.Introspectable")
 	if err != nil {
-		d.fileUnlock()
+		d.disconnectBus()
 		return err
 	}
 
+	// Export the auxiliary notification center interface.
+	if err := d.exportCenter(); err != nil {
+		d.disconnectBus()
+		return err
+	}
+
+	// Request the well-known name "org.freedesktop.Notifications" on the bus.
+	// Owning it is what keeps the daemon single-instance.
+	reply, err := d.conn.RequestName(busName, dbus.NameFlagDoNotQueue)
+	if err != nil {
+		d.disconnectBus()
+		return err
+	}
+	if reply != dbus.RequestNameReplyPrimaryOwner {
+		d.disconnectBus()
+		return errors.New("notification daemon is already running (bus name taken)")
+	}
+
+	// Serve the JSON event stream, on the socket systemd passed us if there is one.
+	if listener := activatedListener(); listener != nil {
+		d.stream = d.serveEventStream(listener, "")
+	} else if d.config.EventSocket != "" {
+		stream, err := d.startEventStream(d.config.EventSocket)
+		if err != nil {
+			slog.Error("Failed to start the event stream", "socket", d.config.EventSocket, "error", err)
+		}
+		d.stream = stream
+	}
+
+	// Serve the notifications of sandboxed applications.
+	if d.config.Portal {
+		if err := d.exportPortal(); err != nil {
+			slog.Error("Failed to start the portal notification backend", "name", d.config.PortalBusName, "error", err)
+		}
+	}
+
+	// Follow the focused fullscreen window.
+	if d.config.FullscreenDND.Enabled && d.config.FullscreenDND.Source != nil {
+		if err := d.watchFullscreen(); err != nil {
+			slog.Error("Failed to follow fullscreen windows", "error", err)
+		}
+	}
+
+	// Pause expiration while the session is idle or locked.
+	if d.config.PauseWhenIdle {
+		if err := d.watchLogind(); err != nil {
+			slog.Error("Failed to follow the logind session idle state", "error", err)
+		}
+	}
+	if d.config.IdleTimeout > 0 {
+		if err := d.watchWaylandIdle(); err != nil {
+			slog.Error("Failed to follow Wayland idle notifications", "error", err)
+		}
+	}
+
+	// Bring back the notifications snoozed before the last shutdown.
+	if err := d.loadSnoozed(); err != nil {
+		slog.Error("Failed to load snoozed notifications", "error", err)
+	}
+
+	sdNotify("READY=1")
 	slog.Info("Notification daemon started on DBus as org.freedesktop.Notifications")
 	return nil
 }
 
+// connectBus returns Config.Conn, or connects to the bus configured by Config.BusAddress.
+func (d *Daemon) connectBus() (*dbus.Conn, error) {
+	if d.config.Conn != nil && !d.config.Monitor {
+		return d.config.Conn, nil
+	}
+	return d.dialBus()
+}
+
+// dialBus opens a new connection to the bus configured by Config.BusAddress.
+func (d *Daemon) dialBus() (*dbus.Conn, error) {
+	if d.config.BusAddress == "" {
+		return dbus.ConnectSessionBus()
+	}
+	return dbus.Connect(d.config.BusAddress)
+}
+
+// disconnectBus closes the bus connection unless it was given in Config.Conn.
+func (d *Daemon) disconnectBus() {
+	if d.conn != d.config.Conn {
+		d.conn.Close()
+	}
+}
+
 // Stop shuts down the daemon.
+// Every live notification is closed with CloseReasonUndefined so clients don't keep
+// dangling references, pending expirations are cancelled, the bus name is released
+// and NotificationsChannel is closed. Calling Stop more than once is a no-op.
 func (d *Daemon) Stop() {
-	if d.conn != nil {
+	// The fullscreen source may be waiting for the lock to report a change.
+	d.mu.Lock()
+	stopFullscreen := d.fullscreenStop
+	d.fullscreenStop = nil
+	d.mu.Unlock()
+	if stopFullscreen != nil {
+		stopFullscreen()
+	}
+
+	d.mu.Lock()
+	if d.stopped {
+		d.mu.Unlock()
+		return
+	}
+	d.stopped = true
+	sdNotify("STOPPING=1")
+
+	for _, rate := range d.rates {
+		if rate.flush != nil {
+			rate.flush.Stop()
+		}
+	}
+
+	// Snoozed notifications stay saved to be shown after a restart, so they
+	// aren't reported as closed.
+	for _, s := range d.snoozed {
+		s.timer.Stop()
+	}
+
+	for id, notification := range d.Notifications {
+		d.stopTimer(id)
+		d.emit(objectPath, interfaceName+".NotificationClosed", id, CloseReasonUndefined)
+		delete(d.Notifications, id)
+		if d.forgetLocked(id) {
+			d.broadcastLocked(NotificationEvent{Notification: notification, Deleted: true})
+		}
+	}
+	d.events.close()
+	d.mu.Unlock()
+
+	// Deliver what is still queued within a grace period, then close
+	// NotificationsChannel. Consumers may need the lock meanwhile.
+	<-d.delivered
+	// Write the history still queued before the store is closed.
+	d.flushHistory()
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.closeSubscribersLocked()
+	if d.stream != nil {
+		d.stream.close()
+	}
+
+	if d.logind != nil {
+		d.logind.Close()
+	}
+	if d.idleNotifier != nil {
+		d.idleNotifier.Close()
+	}
+	if err := d.store.Close(); err != nil {
+		slog.Error("Failed to close the notification store", "error", err)
+	}
+	if d.portalConn != nil {
+		d.portalConn.Close()
+	}
+	if d.conn != nil && d.config.Monitor {
 		d.conn.Close()
+	} else if d.conn != nil {
+		d.conn.Export(nil, objectPath, interfaceName)
+		d.conn.Export(nil, objectPath, "org.freedesktop.DBus.Introspectable")
+		d.unexportCenter()
+		d.conn.ReleaseName(busName)
+		d.disconnectBus()
 	}
-	d.fileUnlock()
 }
 
 // GetServerInformation returns static information about the notification server.
 func (d *Daemon) GetServerInformation() (string, string, string, string, *dbus.Error) {
-	// Customize these values as desired.
-	return "libxdg-go notification daemon", "MiracleOS-Team", "1.1", "1.2", nil
+	return "libxdg-go notification daemon", "MiracleOS-Team", "1.1", d.config.SpecVersion, nil
 }
 
 // GetCapabilities returns the capabilities supported by the notification server.
 func (d *Daemon) GetCapabilities() ([]string, *dbus.Error) {
-	// Example capabilities; adjust to your implementation.
-	caps := []string{"body", "actions"}
-	return caps, nil
+	return append([]string(nil), d.config.Capabilities...), nil
 }
 
 // Notify implements the Notify method as defined in the Desktop Notifications spec.
 // It creates (or replaces) a notification and returns its ID.
 func (d *Daemon) Notify(appName string, replacesID uint32, appIcon string, summary string, body string, actions []string, hints map[string]dbus.Variant, expireTimeout int32) (uint32, *dbus.Error) {
-	d.mu.Lock()
-	defer d.mu.Unlock()
-
-	// Use the provided replacesID if valid.
-	id := replacesID
-	if id == 0 || d.Notifications[id].ID == 0 {
-		id = d.nextID
-		d.nextID++
-	}
-
 	notification := Notification{
-		ID:            id,
 		AppName:       appName,
 		AppIcon:       appIcon,
 		Summary:       summary,
@@ -267,29 +547,151 @@ func (d *Daemon) Notify(appName string, replacesID uint32, appIcon string, summa
 		Actions:       actions,
 		Hints:         hints,
 		ExpireTimeout: expireTimeout,
-		Timestamp:     time.Now(),
 	}
+
+	d.applyCapabilities(&notification)
+
+	// Resolve the sender identity before locking, it may hit the filesystem.
+	d.resolveAppIdentity(&notification)
+
+	d.mu.Lock()
+	d.countLocked(appName).Received++
+	d.mu.Unlock()
+
+	notification.ID = replacesID
+	if !d.runHooks(&notification) {
+		// Vetoed notifications still get an ID, clients can't be told otherwise.
+		// A vetoed replacement leaves the notification it targets untouched.
+		d.mu.Lock()
+		defer d.mu.Unlock()
+		d.countLocked(appName).Vetoed++
+		if _, live := d.Notifications[replacesID]; live {
+			return replacesID, nil
+		}
+		id, err := d.allocateIDLocked()
+		if err != nil {
+			return 0, dbus.MakeFailedError(err)
+		}
+		return id, nil
+	}
+	notification.ParsedActions = parseActions(notification.Actions)
+	d.resolveActionIcons(&notification)
+
+	id, err := d.addNotification(replacesID, notification)
+
+	// With BackpressureBlock, slow consumers hold back the client, never the daemon lock.
+	d.events.waitForRoom()
+
+	return id, err
+}
+
+// addNotification stores a notification received from a client, replacing the
+// notification with replacesID if it is live, and broadcasts it.
+func (d *Daemon) addNotification(replacesID uint32, notification Notification) (uint32, *dbus.Error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.stopped {
+		return 0, dbus.MakeFailedError(errors.New("notification daemon is shutting down"))
+	}
+
+	if _, live := d.Notifications[replacesID]; !live {
+		if id, found := d.synchronousTargetLocked(notification); found {
+			replacesID = id
+		}
+	}
+
+	previous, replacing := d.Notifications[replacesID]
+	if !replacing {
+		if id, coalesced := d.coalesceLocked(notification.AppName, notification.Summary, notification.Body, notification.ExpireTimeout); coalesced {
+			d.countLocked(notification.AppName).Coalesced++
+			return id, nil
+		}
+		if !d.admitLocked(notification.AppName) {
+			d.countLocked(notification.AppName).RateLimited++
+			id, err := d.allocateIDLocked()
+			if err != nil {
+				return 0, dbus.MakeFailedError(err)
+			}
+			return id, nil
+		}
+	}
+
+	// Replacing keeps the ID and creation time; a replaces_id that isn't live
+	// (including 0) gets a fresh ID as if it wasn't set.
+	now := time.Now()
+	if replacing {
+		notification.ID = replacesID
+		notification.Timestamp = previous.Timestamp
+		notification.Updated = now
+	} else {
+		id, err := d.allocateIDLocked()
+		if err != nil {
+			return 0, dbus.MakeFailedError(err)
+		}
+		notification.ID = id
+		notification.Timestamp = now
+	}
+	id := notification.ID
+
 	d.Notifications[id] = notification
+	d.scheduleExpiration(id, notification.ExpireTimeout)
+	d.emitCenter("NotificationAdded", id)
+	d.rememberLocked(notification)
 
 	// In a complete daemon, you might display the notification in a UI,
 	// forward it to another handler, or log it.
 
-	slog.Debug(strings.Join([]string{"Received notification ", strconv.Itoa(int(id)), ": ", summary, " - ", body}, "\n"))
+	slog.Debug(strings.Join([]string{"Received notification ", strconv.Itoa(int(id)), ": ", notification.Summary, " - ", notification.Body}, "\n"))
 
 	notificationEvent := NotificationEvent{
 		Notification: notification,
-		Created:      replacesID == 0,
-		Modified:     replacesID != 0,
+		Created:      !replacing,
+		Modified:     replacing,
 		Deleted:      false,
 	}
 
-	d.NotificationsChannel <- notificationEvent
+	d.presentLocked(notificationEvent)
 
 	return id, nil
 }
 
-func (d *Daemon) InvokeAction(id uint32, action_key string) {
-	d.conn.Emit(dbus.ObjectPath("/org/freedesktop/Notifications"), "org.freedesktop.Notifications.ActionInvoked", id, action_key)
+// allocateIDLocked returns a fresh notification ID. Once nextID wraps around,
+// 0 and IDs that are still live or snoozed are skipped. It fails with
+// ErrNoFreeID when every ID is in use.
+// d.mu must be held.
+func (d *Daemon) allocateIDLocked() (uint32, error) {
+	// With n IDs in use, n+2 consecutive candidates include a free one.
+	used := uint64(len(d.Notifications)) + uint64(len(d.snoozed))
+	if used >= math.MaxUint32 {
+		return 0, ErrNoFreeID
+	}
+	for i := uint64(0); i < used+2; i++ {
+		id := d.nextID
+		d.nextID++
+		_, live := d.Notifications[id]
+		_, snoozed := d.snoozed[id]
+		if id != 0 && !live && !snoozed {
+			return id, nil
+		}
+	}
+	return 0, ErrNoFreeID
+}
+
+// emit sends a signal on the bus. It does nothing before Start and in monitor
+// mode, where the daemon doesn't own the interfaces.
+func (d *Daemon) emit(path dbus.ObjectPath, name string, args ...interface{}) {
+	if d.conn == nil || d.config.Monitor {
+		return
+	}
+	d.conn.Emit(path, name, args...)
+}
+
+// broadcastLocked queues an event for NotificationsChannel according to Config.Backpressure.
+// d.mu must be held.
+func (d *Daemon) broadcastLocked(event NotificationEvent) {
+	d.publishLocked(event)
+	d.broadcastGroupLocked(event)
 }
 
 // CloseNotification implements the CloseNotification method.
@@ -297,20 +699,8 @@ func (d *Daemon) CloseNotification(id uint32) *dbus.Error {
 	d.mu.Lock()
 	defer d.mu.Unlock()
 
-	if _, exists := d.Notifications[id]; exists {
-
-		d.conn.Emit(dbus.ObjectPath("/org/freedesktop/Notifications"), "org.freedesktop.Notifications.NotificationClosed", id, 3)
-		slog.Debug(strings.Join([]string{"User closed notification ", strconv.Itoa(int(id))}, "\n"))
-
-		notificationEvent := NotificationEvent{
-			Notification: d.Notifications[id],
-			Created:      false,
-			Modified:     false,
-			Deleted:      true,
-		}
-		delete(d.Notifications, id)
-
-		d.NotificationsChannel <- notificationEvent
+	if d.closeLocked(id, CloseReasonClosed) {
+		slog.Debug(strings.Join([]string{"Client closed notification ", strconv.Itoa(int(id))}, ""))
 	}
 	return nil
 }
@@ -319,20 +709,40 @@ func (d *Daemon) CloseNotificationAsUser(id uint32) error {
 	d.mu.Lock()
 	defer d.mu.Unlock()
 
-	if _, exists := d.Notifications[id]; exists {
-
-		d.conn.Emit(dbus.ObjectPath("/org/freedesktop/Notifications"), "org.freedesktop.Notifications.NotificationClosed", id, 2)
+	if d.closeLocked(id, CloseReasonDismissed) {
 		slog.Debug(strings.Join([]string{"User closed notification ", strconv.Itoa(int(id))}, ""))
+	}
+	return nil
+}
 
-		notificationEvent := NotificationEvent{
-			Notification: d.Notifications[id],
-			Created:      false,
-			Modified:     false,
-			Deleted:      true,
-		}
-		delete(d.Notifications, id)
+// closeLocked removes a live notification, emits NotificationClosed with the given reason
+// and broadcasts a Deleted event. It reports whether the notification existed.
+// d.mu must be held.
+func (d *Daemon) closeLocked(id uint32, reason uint32) bool {
+	if d.stopped {
+		return false
+	}
+	if d.closeSnoozedLocked(id, reason) {
+		return true
+	}
+	notification, exists := d.Notifications[id]
+	if !exists {
+		return false
+	}
 
-		d.NotificationsChannel <- notificationEvent
+	d.stopTimer(id)
+	d.emit(objectPath, interfaceName+".NotificationClosed", id, reason)
+	d.countCloseLocked(notification, reason)
+	d.forgetPortalLocked(id)
+	delete(d.Notifications, id)
+	d.emitCenter("NotificationRemoved", id, reason)
+	d.recordHistory(notification)
+
+	if d.forgetLocked(id) {
+		d.broadcastLocked(NotificationEvent{
+			Notification: notification,
+			Deleted:      true,
+		})
 	}
-	return nil
+	return true
 }
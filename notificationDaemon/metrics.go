@@ -0,0 +1,115 @@
+/*
+	libxdg-go - An implementaion of various freedesktop specifications in go
+    Copyright (C) 2025 MiracleOS Contributors
+
+    This program is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    This program is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+*/
+
+package notificationDaemon
+
+import (
+	"expvar"
+)
+
+// AppMetrics counts what happened to the notifications of one application since
+// the daemon started.
+type AppMetrics struct {
+	// Received counts Notify calls, replacements included.
+	Received uint64 `json:"received"`
+	// Vetoed counts notifications dropped by a Hook.
+	Vetoed uint64 `json:"vetoed"`
+	// RateLimited counts notifications dropped by RateLimit.MaxPerMinute.
+	RateLimited uint64 `json:"rate_limited"`
+	// Coalesced counts notifications merged into an identical previous one.
+	Coalesced uint64 `json:"coalesced"`
+	// Silenced counts notifications hidden by do-not-disturb.
+	Silenced uint64 `json:"silenced"`
+	// Expired, Dismissed and Closed count notifications closed for each reason.
+	Expired   uint64 `json:"expired"`
+	Dismissed uint64 `json:"dismissed"`
+	Closed    uint64 `json:"closed"`
+}
+
+// Metrics is a snapshot of the daemon's counters, meant to debug notifications
+// that didn't show up.
+type Metrics struct {
+	Apps    map[string]AppMetrics `json:"apps"`
+	Live    int                   `json:"live"`
+	Snoozed int                   `json:"snoozed"`
+	History int                   `json:"history"`
+	Held    int                   `json:"held"`
+	// DroppedEvents counts events NotificationsChannel lost to the backpressure policy.
+	DroppedEvents uint64 `json:"dropped_events"`
+	// Subscribers is the number of active Subscribe consumers, event stream clients included.
+	Subscribers int `json:"subscribers"`
+	// SubscriberDrops counts events the active subscribers were too slow to take.
+	SubscriberDrops uint64 `json:"subscriber_drops"`
+}
+
+// Metrics returns a snapshot of the daemon's counters.
+func (d *Daemon) Metrics() Metrics {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	m := Metrics{
+		Apps:        make(map[string]AppMetrics, len(d.metrics)),
+		Live:        len(d.Notifications),
+		Snoozed:     len(d.snoozed),
+		History:     d.historyLenLocked(),
+		Held:        len(d.held),
+		Subscribers: len(d.subscribers),
+	}
+	for app, counters := range d.metrics {
+		m.Apps[app] = *counters
+	}
+	for s := range d.subscribers {
+		m.SubscriberDrops += s.dropped
+	}
+	m.DroppedEvents = d.DroppedEvents()
+	return m
+}
+
+// MetricsVar returns the metrics as an expvar.Var, to be published with
+// expvar.Publish and served on /debug/vars.
+func (d *Daemon) MetricsVar() expvar.Var {
+	return expvar.Func(func() any {
+		return d.Metrics()
+	})
+}
+
+// countLocked returns the counters of an application.
+// d.mu must be held.
+func (d *Daemon) countLocked(appName string) *AppMetrics {
+	counters, exists := d.metrics[appName]
+	if !exists {
+		counters = &AppMetrics{}
+		d.metrics[appName] = counters
+	}
+	return counters
+}
+
+// countCloseLocked counts a notification closed for reason.
+// d.mu must be held.
+func (d *Daemon) countCloseLocked(n Notification, reason uint32) {
+	counters := d.countLocked(n.AppName)
+	switch reason {
+	case CloseReasonExpired:
+		counters.Expired++
+	case CloseReasonDismissed:
+		counters.Dismissed++
+	case CloseReasonClosed:
+		counters.Closed++
+	}
+}
@@ -0,0 +1,211 @@
+/*
+	libxdg-go - An implementaion of various freedesktop specifications in go
+    Copyright (C) 2025 MiracleOS Contributors
+
+    This program is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    This program is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+*/
+
+package notificationDaemon
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/godbus/dbus/v5"
+)
+
+// pendingNotify is a Notify call seen by the monitor, waiting for its reply.
+type pendingNotify struct {
+	sender       string
+	serial       uint32
+	replacesID   uint32
+	notification Notification
+}
+
+// startMonitor turns a fresh session bus connection into a monitor observing
+// the traffic of the running notification server.
+func (d *Daemon) startMonitor() error {
+	conn, err := d.connectBus()
+	if err != nil {
+		return err
+	}
+
+	// Only method returns from the server carry notification IDs; match on its unique
+	// name when it is running so the monitor doesn't receive every reply on the bus.
+	returns := "type='method_return'"
+	var owner string
+	if err := conn.BusObject().Call("org.freedesktop.DBus.GetNameOwner", 0, busName).Store(&owner); err == nil {
+		returns = fmt.Sprintf("type='method_return',sender='%s'", owner)
+	}
+	rules := []string{
+		fmt.Sprintf("type='method_call',interface='%s',member='Notify'", interfaceName),
+		fmt.Sprintf("type='signal',interface='%s',member='NotificationClosed'", interfaceName),
+		returns,
+	}
+
+	// Eavesdropping stops godbus from answering the calls we observe, which would
+	// get a monitor disconnected. It also diverts the reply to BecomeMonitor, so the
+	// call is sent asynchronously and its reply is picked from the eavesdropped messages.
+	messages := make(chan *dbus.Message, 64)
+	conn.Eavesdrop(messages)
+	conn.BusObject().Go("org.freedesktop.DBus.Monitoring.BecomeMonitor", dbus.FlagNoAutoStart, nil, rules, uint32(0))
+	if err := awaitMonitorReply(conn, messages); err != nil {
+		conn.Close()
+		return fmt.Errorf("failed to become a monitor: %w", err)
+	}
+	d.conn = conn
+
+	go d.monitor(messages)
+
+	slog.Info("Notification daemon monitoring org.freedesktop.Notifications")
+	return nil
+}
+
+// awaitMonitorReply waits for the reply to BecomeMonitor. Nothing else is addressed
+// to the connection at that point, so the first reply to it is the one.
+func awaitMonitorReply(conn *dbus.Conn, messages chan *dbus.Message) error {
+	self := conn.Names()[0]
+	timeout := time.After(5 * time.Second)
+	for {
+		select {
+		case msg, ok := <-messages:
+			if !ok {
+				return errors.New("connection closed")
+			}
+			destination, _ := msg.Headers[dbus.FieldDestination].Value().(string)
+			if destination != self {
+				continue
+			}
+			switch msg.Type {
+			case dbus.TypeMethodReply:
+				return nil
+			case dbus.TypeError:
+				name, _ := msg.Headers[dbus.FieldErrorName].Value().(string)
+				return dbus.Error{Name: name, Body: msg.Body}
+			}
+		case <-timeout:
+			return errors.New("timed out")
+		}
+	}
+}
+
+// monitor mirrors the observed notification traffic into the daemon state.
+func (d *Daemon) monitor(messages chan *dbus.Message) {
+	pending := make(map[string]pendingNotify)
+
+	for msg := range messages {
+		switch msg.Type {
+		case dbus.TypeMethodCall:
+			member, _ := msg.Headers[dbus.FieldMember].Value().(string)
+			if member != "Notify" {
+				continue
+			}
+			call, ok := parseNotifyCall(msg)
+			if !ok {
+				continue
+			}
+			pending[call.sender+"/"+fmt.Sprint(call.serial)] = call
+		case dbus.TypeMethodReply:
+			destination, _ := msg.Headers[dbus.FieldDestination].Value().(string)
+			serial, _ := msg.Headers[dbus.FieldReplySerial].Value().(uint32)
+			key := destination + "/" + fmt.Sprint(serial)
+			call, ok := pending[key]
+			if !ok {
+				continue
+			}
+			delete(pending, key)
+			if len(msg.Body) != 1 {
+				continue
+			}
+			if id, ok := msg.Body[0].(uint32); ok {
+				d.mirrorNotify(id, call)
+			}
+		case dbus.TypeSignal:
+			if len(msg.Body) != 2 {
+				continue
+			}
+			id, _ := msg.Body[0].(uint32)
+			reason, _ := msg.Body[1].(uint32)
+			d.mu.Lock()
+			d.closeLocked(id, reason)
+			d.mu.Unlock()
+		}
+
+		// Calls whose reply we missed must not pile up forever.
+		if len(pending) > 256 {
+			pending = make(map[string]pendingNotify)
+		}
+	}
+}
+
+// parseNotifyCall decodes an observed Notify method call.
+func parseNotifyCall(msg *dbus.Message) (pendingNotify, bool) {
+	var call pendingNotify
+	if len(msg.Body) != 8 {
+		return call, false
+	}
+	call.sender, _ = msg.Headers[dbus.FieldSender].Value().(string)
+	call.serial = msg.Serial()
+
+	n := &call.notification
+	var ok [8]bool
+	n.AppName, ok[0] = msg.Body[0].(string)
+	call.replacesID, ok[1] = msg.Body[1].(uint32)
+	n.AppIcon, ok[2] = msg.Body[2].(string)
+	n.Summary, ok[3] = msg.Body[3].(string)
+	n.Body, ok[4] = msg.Body[4].(string)
+	n.Actions, ok[5] = msg.Body[5].([]string)
+	n.Hints, ok[6] = msg.Body[6].(map[string]dbus.Variant)
+	n.ExpireTimeout, ok[7] = msg.Body[7].(int32)
+	for _, valid := range ok {
+		if !valid {
+			return call, false
+		}
+	}
+	return call, true
+}
+
+// mirrorNotify records a notification that the observed server accepted under id.
+func (d *Daemon) mirrorNotify(id uint32, call pendingNotify) {
+	notification := call.notification
+	notification.ID = id
+	notification.ParsedActions = parseActions(notification.Actions)
+	d.resolveAppIdentity(&notification)
+	d.resolveActionIcons(&notification)
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.stopped {
+		return
+	}
+	d.countLocked(notification.AppName).Received++
+	previous, replaced := d.Notifications[id]
+	if replaced {
+		notification.Timestamp = previous.Timestamp
+		notification.Updated = time.Now()
+	} else {
+		notification.Timestamp = time.Now()
+	}
+	d.Notifications[id] = notification
+
+	d.presentLocked(NotificationEvent{
+		Notification: notification,
+		Created:      !replaced,
+		Modified:     replaced,
+	})
+}
@@ -0,0 +1,121 @@
+/*
+	libxdg-go - An implementaion of various freedesktop specifications in go
+    Copyright (C) 2025 MiracleOS Contributors
+
+    This program is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    This program is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+*/
+
+package notificationDaemon
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+	"time"
+
+	"github.com/MiracleOS-Team/libxdg-go/internal/atomicfile"
+	"github.com/godbus/dbus/v5"
+)
+
+// storedVariant is a dbus.Variant in a JSON friendly form.
+type storedVariant struct {
+	Signature string `json:"signature"`
+	Value     string `json:"value"`
+}
+
+// storedNotification is the on-disk form of a Notification. Resolved fields
+// like AppDisplayName are recomputed when it is loaded.
+type storedNotification struct {
+	ID            uint32                   `json:"id"`
+	AppName       string                   `json:"app_name"`
+	AppIcon       string                   `json:"app_icon"`
+	Summary       string                   `json:"summary"`
+	Body          string                   `json:"body"`
+	Actions       []string                 `json:"actions"`
+	Hints         map[string]storedVariant `json:"hints"`
+	ExpireTimeout int32                    `json:"expire_timeout"`
+	Timestamp     time.Time                `json:"timestamp"`
+	Updated       time.Time                `json:"updated"`
+}
+
+func toStoredNotification(n Notification) storedNotification {
+	stored := storedNotification{
+		ID:            n.ID,
+		AppName:       n.AppName,
+		AppIcon:       n.AppIcon,
+		Summary:       n.Summary,
+		Body:          n.Body,
+		Actions:       n.Actions,
+		Hints:         make(map[string]storedVariant, len(n.Hints)),
+		ExpireTimeout: n.ExpireTimeout,
+		Timestamp:     n.Timestamp,
+		Updated:       n.Updated,
+	}
+	for key, value := range n.Hints {
+		stored.Hints[key] = storedVariant{Signature: value.Signature().String(), Value: value.String()}
+	}
+	return stored
+}
+
+// notification converts a stored notification back. Hints that can't be parsed are skipped.
+func (s storedNotification) notification() Notification {
+	n := Notification{
+		ID:            s.ID,
+		AppName:       s.AppName,
+		AppIcon:       s.AppIcon,
+		Summary:       s.Summary,
+		Body:          s.Body,
+		Actions:       s.Actions,
+		Hints:         make(map[string]dbus.Variant, len(s.Hints)),
+		ExpireTimeout: s.ExpireTimeout,
+		Timestamp:     s.Timestamp,
+		Updated:       s.Updated,
+	}
+	if n.Actions == nil {
+		n.Actions = []string{}
+	}
+	n.ParsedActions = parseActions(n.Actions)
+	for key, value := range s.Hints {
+		sig, err := dbus.ParseSignature(value.Signature)
+		if err != nil {
+			continue
+		}
+		if v, err := dbus.ParseVariant(value.Value, sig); err == nil {
+			n.Hints[key] = v
+		}
+	}
+	return n
+}
+
+// writeJSONFile atomically replaces path with the JSON encoding of v.
+func writeJSONFile(path string, v interface{}) error {
+	return atomicfile.Write(path, 0600, func(w io.Writer) error {
+		return json.NewEncoder(w).Encode(v)
+	})
+}
+
+// readJSONFile decodes the JSON file at path into v. A missing file leaves v untouched.
+func readJSONFile(path string, v interface{}) error {
+	file, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	return json.NewDecoder(file).Decode(v)
+}
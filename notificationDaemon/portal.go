@@ -0,0 +1,341 @@
+/*
+	libxdg-go - An implementaion of various freedesktop specifications in go
+    Copyright (C) 2025 MiracleOS Contributors
+
+    This program is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    This program is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+*/
+
+package notificationDaemon
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	basedir "github.com/MiracleOS-Team/libxdg-go/baseDir"
+	"github.com/godbus/dbus/v5"
+	"github.com/godbus/dbus/v5/introspect"
+	"github.com/godbus/dbus/v5/prop"
+)
+
+const (
+	// DefaultPortalBusName is the bus name of the portal backend when Config.Portal is set
+	// and Config.PortalBusName is empty.
+	DefaultPortalBusName   = "org.freedesktop.impl.portal.desktop.miracleos"
+	portalPath             = dbus.ObjectPath("/org/freedesktop/portal/desktop")
+	portalInterfaceName    = "org.freedesktop.impl.portal.Notification"
+	portalInterfaceVersion = uint32(2)
+)
+
+// portalAction is a portal action attached to a notification action key.
+type portalAction struct {
+	name   string
+	target *dbus.Variant
+}
+
+// portalEntry links a daemon notification to the portal notification it shows.
+type portalEntry struct {
+	appID   string
+	id      string
+	actions map[string]portalAction
+}
+
+// portalBackend is the org.freedesktop.impl.portal.Notification interface of a Daemon.
+// xdg-desktop-portal forwards the notifications of sandboxed applications to it.
+type portalBackend struct {
+	d *Daemon
+}
+
+// AddNotification shows a portal notification, replacing the one the application
+// sent earlier with the same id.
+func (p portalBackend) AddNotification(appID string, id string, notification map[string]dbus.Variant) *dbus.Error {
+	n, actions := p.d.fromPortal(appID, notification)
+
+	key := appID + "\x00" + id
+	p.d.mu.Lock()
+	replacesID := p.d.portalIDs[key]
+	p.d.mu.Unlock()
+
+	daemonID, err := p.d.Notify(n.AppName, replacesID, n.AppIcon, n.Summary, n.Body, n.Actions, n.Hints, n.ExpireTimeout)
+	if err != nil {
+		return err
+	}
+
+	p.d.mu.Lock()
+	defer p.d.mu.Unlock()
+	if _, live := p.d.Notifications[daemonID]; live {
+		p.d.portalIDs[key] = daemonID
+		p.d.portalEntries[daemonID] = &portalEntry{appID: appID, id: id, actions: actions}
+	}
+	return nil
+}
+
+// RemoveNotification withdraws a portal notification.
+func (p portalBackend) RemoveNotification(appID string, id string) *dbus.Error {
+	p.d.mu.Lock()
+	defer p.d.mu.Unlock()
+
+	if daemonID, exists := p.d.portalIDs[appID+"\x00"+id]; exists {
+		p.d.closeLocked(daemonID, CloseReasonClosed)
+	}
+	return nil
+}
+
+// fromPortal maps a portal notification onto a Notification. It returns the portal
+// actions behind the action keys.
+func (d *Daemon) fromPortal(appID string, notification map[string]dbus.Variant) (Notification, map[string]portalAction) {
+	n := Notification{
+		AppName:       appID,
+		Summary:       hintString(notification, "title"),
+		Actions:       []string{},
+		Hints:         map[string]dbus.Variant{"desktop-entry": dbus.MakeVariant(appID)},
+		ExpireTimeout: -1,
+	}
+	actions := make(map[string]portalAction)
+
+	if body := hintString(notification, "markup-body"); body != "" {
+		n.Body = body
+	} else {
+		n.Body = hintString(notification, "body")
+		if d.HasCapability(CapabilityBodyMarkup) {
+			n.Body = html.EscapeString(n.Body)
+		}
+	}
+
+	switch hintString(notification, "priority") {
+	case "low":
+		n.Hints["urgency"] = dbus.MakeVariant(byte(UrgencyLow))
+	case "urgent":
+		n.Hints["urgency"] = dbus.MakeVariant(byte(UrgencyCritical))
+	}
+	if category := hintString(notification, "category"); category != "" {
+		n.Hints["category"] = dbus.MakeVariant(category)
+	}
+	if icon, exists := notification["icon"]; exists {
+		d.applyPortalIcon(&n, icon)
+	}
+
+	if name := hintString(notification, "default-action"); name != "" {
+		action := portalAction{name: name}
+		if target, exists := notification["default-action-target"]; exists {
+			action.target = &target
+		}
+		actions[DefaultActionKey] = action
+		n.Actions = append(n.Actions, DefaultActionKey, "")
+	}
+	buttons, _ := notification["buttons"].Value().([]map[string]dbus.Variant)
+	for i, button := range buttons {
+		name := hintString(button, "action")
+		if name == "" {
+			continue
+		}
+		action := portalAction{name: name}
+		if target, exists := button["target"]; exists {
+			action.target = &target
+		}
+		key := "portal-" + strconv.Itoa(i)
+		actions[key] = action
+		n.Actions = append(n.Actions, key, hintString(button, "label"))
+	}
+	return n, actions
+}
+
+// applyPortalIcon maps a serialized GIcon onto the notification: themed icons
+// become app_icon, icon data is saved to a file and passed as image-path.
+func (d *Daemon) applyPortalIcon(n *Notification, icon dbus.Variant) {
+	// Some clients wrap the serialized icon in another variant.
+	if inner, ok := icon.Value().(dbus.Variant); ok {
+		icon = inner
+	}
+	serialized, ok := icon.Value().([]interface{})
+	if !ok || len(serialized) != 2 {
+		return
+	}
+	kind, _ := serialized[0].(string)
+	value, _ := serialized[1].(dbus.Variant)
+
+	var data []byte
+	switch kind {
+	case "themed":
+		if names, ok := value.Value().([]string); ok && len(names) > 0 {
+			n.AppIcon = names[0]
+		}
+		return
+	case "bytes":
+		data, _ = value.Value().([]byte)
+	case "file":
+		fd, ok := value.Value().(dbus.UnixFD)
+		if !ok {
+			return
+		}
+		file := os.NewFile(uintptr(fd), "portal-icon")
+		var err error
+		data, err = io.ReadAll(io.LimitReader(file, 8<<20))
+		file.Close()
+		if err != nil {
+			slog.Debug("Failed to read portal notification icon", "app", n.AppName, "error", err)
+			return
+		}
+	}
+	if len(data) == 0 {
+		return
+	}
+
+	path, err := savePortalIcon(data)
+	if err != nil {
+		slog.Debug("Failed to save portal notification icon", "app", n.AppName, "error", err)
+		return
+	}
+	n.Hints["image-path"] = dbus.MakeVariant(path)
+}
+
+// savePortalIcon writes icon data under $XDG_RUNTIME_DIR, named after its hash so
+// identical icons share a file.
+func savePortalIcon(data []byte) (string, error) {
+	dir := filepath.Join(fmt.Sprintf("%v", basedir.GetXDGDirectory("runtime")), "libxdg-portal-icons")
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	path := filepath.Join(dir, hex.EncodeToString(sum[:]))
+	if _, err := os.Stat(path); err == nil {
+		return path, nil
+	}
+	return path, os.WriteFile(path, data, 0600)
+}
+
+// portalActionLocked emits the portal ActionInvoked signal when a notification shown
+// for the portal has an action invoked.
+// d.mu must be held.
+func (d *Daemon) portalActionLocked(id uint32, actionKey string) {
+	entry, exists := d.portalEntries[id]
+	if !exists || d.portalConn == nil {
+		return
+	}
+	action, exists := entry.actions[actionKey]
+	if !exists {
+		return
+	}
+	parameter := []dbus.Variant{}
+	if action.target != nil {
+		parameter = append(parameter, *action.target)
+	}
+	d.portalConn.Emit(portalPath, portalInterfaceName+".ActionInvoked", entry.appID, entry.id, action.name, parameter)
+}
+
+// forgetPortalLocked drops the portal mapping of a closed notification.
+// d.mu must be held.
+func (d *Daemon) forgetPortalLocked(id uint32) {
+	if entry, exists := d.portalEntries[id]; exists {
+		delete(d.portalEntries, id)
+		delete(d.portalIDs, entry.appID+"\x00"+entry.id)
+	}
+}
+
+// exportPortal serves the portal backend under Config.PortalBusName. The backend
+// owns a name of its own, so it uses a separate connection to the same bus.
+func (d *Daemon) exportPortal() error {
+	conn, err := d.dialBus()
+	if err != nil {
+		return err
+	}
+
+	if err := conn.Export(portalBackend{d: d}, portalPath, portalInterfaceName); err != nil {
+		conn.Close()
+		return err
+	}
+	props, err := prop.Export(conn, portalPath, prop.Map{
+		portalInterfaceName: {
+			"SupportedOptions": {Value: map[string]dbus.Variant{}, Emit: prop.EmitFalse},
+			"version":          {Value: portalInterfaceVersion, Emit: prop.EmitFalse},
+		},
+	})
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	node := &introspect.Node{
+		Name: string(portalPath),
+		Interfaces: []introspect.Interface{
+			{
+				Name: portalInterfaceName,
+				Methods: []introspect.Method{
+					{
+						Name: "AddNotification",
+						Args: []introspect.Arg{
+							{Name: "app_id", Type: "s", Direction: "in"},
+							{Name: "id", Type: "s", Direction: "in"},
+							{Name: "notification", Type: "a{sv}", Direction: "in"},
+						},
+					},
+					{
+						Name: "RemoveNotification",
+						Args: []introspect.Arg{
+							{Name: "app_id", Type: "s", Direction: "in"},
+							{Name: "id", Type: "s", Direction: "in"},
+						},
+					},
+				},
+				Signals: []introspect.Signal{
+					{
+						Name: "ActionInvoked",
+						Args: []introspect.Arg{
+							{Name: "app_id", Type: "s"},
+							{Name: "id", Type: "s"},
+							{Name: "action", Type: "s"},
+							{Name: "parameter", Type: "av"},
+						},
+					},
+				},
+				Properties: props.Introspection(portalInterfaceName),
+			},
+			prop.IntrospectData,
+			introspect.IntrospectData,
+		},
+	}
+	if err := conn.Export(introspect.NewIntrospectable(node), portalPath, "org.freedesktop.DBus.Introspectable"); err != nil {
+		conn.Close()
+		return err
+	}
+
+	reply, err := conn.RequestName(d.config.PortalBusName, dbus.NameFlagDoNotQueue)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	if reply != dbus.RequestNameReplyPrimaryOwner {
+		conn.Close()
+		return errors.New("portal backend bus name taken: " + d.config.PortalBusName)
+	}
+	d.portalConn = conn
+	return nil
+}
+
+// PortalFile returns the .portal file registering the notification backend with
+// xdg-desktop-portal, to be installed in /usr/share/xdg-desktop-portal/portals.
+// The backend still has to be selected for the desktop in portals.conf.
+func PortalFile(busName string) string {
+	if busName == "" {
+		busName = DefaultPortalBusName
+	}
+	return "[portal]\nDBusName=" + busName + "\nInterfaces=" + portalInterfaceName + ";\n"
+}
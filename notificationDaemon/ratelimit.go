@@ -0,0 +1,189 @@
+/*
+	libxdg-go - An implementaion of various freedesktop specifications in go
+    Copyright (C) 2025 MiracleOS Contributors
+
+    This program is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    This program is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+*/
+
+package notificationDaemon
+
+import (
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/godbus/dbus/v5"
+)
+
+// RateLimit configures per-application flood protection.
+type RateLimit struct {
+	// MaxPerMinute is the number of new notifications an application may send per minute.
+	// Further notifications in the same minute are dropped and summarized by a single
+	// "N more from X" notification once the minute is over. If zero, there is no limit.
+	MaxPerMinute int
+	// CoalesceWindow merges a new notification into the previous live one of the same
+	// application when both have the same summary and body and arrive within this window.
+	// If zero, identical notifications are not coalesced.
+	CoalesceWindow time.Duration
+}
+
+// appRate tracks the flood protection state of one application.
+type appRate struct {
+	windowStart time.Time
+	count       int
+	suppressed  int
+	flush       *time.Timer
+
+	lastID      uint32
+	lastSummary string
+	lastBody    string
+	lastAt      time.Time
+	coalesced   int
+}
+
+// rateFor returns the flood protection state of an application, creating it if needed.
+// d.mu must be held.
+func (d *Daemon) rateFor(appName string) *appRate {
+	rate, exists := d.rates[appName]
+	if !exists {
+		rate = &appRate{}
+		d.rates[appName] = rate
+	}
+	return rate
+}
+
+// coalesceLocked merges a notification identical to the previous one of the same
+// application into it, refreshing its update time and expiration.
+// It returns the ID of the merged notification and whether coalescing happened.
+// d.mu must be held.
+func (d *Daemon) coalesceLocked(appName, summary, body string, expireTimeout int32) (uint32, bool) {
+	if d.config.RateLimit.CoalesceWindow <= 0 {
+		return 0, false
+	}
+	rate := d.rateFor(appName)
+	notification, live := d.Notifications[rate.lastID]
+	if !live || rate.lastSummary != summary || rate.lastBody != body || time.Since(rate.lastAt) > d.config.RateLimit.CoalesceWindow {
+		return 0, false
+	}
+
+	rate.lastAt = time.Now()
+	rate.coalesced++
+	notification.Updated = rate.lastAt
+	notification.ExpireTimeout = expireTimeout
+	d.Notifications[notification.ID] = notification
+	d.scheduleExpiration(notification.ID, expireTimeout)
+
+	slog.Debug("Coalesced identical notification", "app", appName, "id", notification.ID, "count", rate.coalesced)
+
+	d.presentLocked(NotificationEvent{
+		Notification: notification,
+		Modified:     true,
+		Coalesced:    rate.coalesced,
+	})
+	return notification.ID, true
+}
+
+// admitLocked counts a new notification against the per-minute limit of its application.
+// It reports whether the notification may be shown. Rejected notifications are
+// summarized once the current window is over.
+// d.mu must be held.
+func (d *Daemon) admitLocked(appName string) bool {
+	limit := d.config.RateLimit.MaxPerMinute
+	if limit <= 0 {
+		return true
+	}
+	rate := d.rateFor(appName)
+	now := time.Now()
+	if now.Sub(rate.windowStart) >= time.Minute {
+		rate.windowStart = now
+		rate.count = 0
+	}
+	if rate.count < limit {
+		rate.count++
+		return true
+	}
+
+	rate.suppressed++
+	if rate.flush == nil {
+		rate.flush = time.AfterFunc(rate.windowStart.Add(time.Minute).Sub(now), func() {
+			d.flushSuppressed(appName)
+		})
+	}
+	return false
+}
+
+// flushSuppressed emits a synthetic notification summarizing the notifications
+// dropped for an application during its last window.
+func (d *Daemon) flushSuppressed(appName string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	rate, exists := d.rates[appName]
+	if !exists || d.stopped {
+		return
+	}
+	suppressed := rate.suppressed
+	rate.suppressed = 0
+	rate.flush = nil
+	if suppressed == 0 {
+		return
+	}
+
+	slog.Debug("Summarizing suppressed notifications", "app", appName, "count", suppressed)
+
+	id, err := d.allocateIDLocked()
+	if err != nil {
+		slog.Error("Failed to summarize suppressed notifications", "app", appName, "error", err)
+		return
+	}
+	notification := Notification{
+		ID:            id,
+		AppName:       appName,
+		Summary:       fmt.Sprintf("%d more from %s", suppressed, appName),
+		Actions:       []string{},
+		ParsedActions: []Action{},
+		Hints:         map[string]dbus.Variant{},
+		ExpireTimeout: -1,
+		Timestamp:     time.Now(),
+
+		AppDisplayName: appName,
+	}
+	d.Notifications[id] = notification
+	d.scheduleExpiration(id, notification.ExpireTimeout)
+	d.emitCenter("NotificationAdded", id)
+
+	d.presentLocked(NotificationEvent{
+		Notification: notification,
+		Created:      true,
+		Coalesced:    suppressed,
+	})
+}
+
+// rememberLocked records a newly shown notification as the candidate for coalescing.
+// d.mu must be held.
+func (d *Daemon) rememberLocked(notification Notification) {
+	if d.config.RateLimit.CoalesceWindow <= 0 {
+		return
+	}
+	rate := d.rateFor(notification.AppName)
+	if rate.lastID == notification.ID && rate.lastSummary == notification.Summary && rate.lastBody == notification.Body {
+		return
+	}
+	rate.lastID = notification.ID
+	rate.lastSummary = notification.Summary
+	rate.lastBody = notification.Body
+	rate.lastAt = notification.Timestamp
+	rate.coalesced = 0
+}
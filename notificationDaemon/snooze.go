@@ -0,0 +1,183 @@
+/*
+	libxdg-go - An implementaion of various freedesktop specifications in go
+    Copyright (C) 2025 MiracleOS Contributors
+
+    This program is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    This program is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+*/
+
+package notificationDaemon
+
+import (
+	"errors"
+	"log/slog"
+	"time"
+)
+
+// snoozedNotification is a notification hidden until a point in time.
+type snoozedNotification struct {
+	notification Notification
+	until        time.Time
+	timer        *time.Timer
+}
+
+// storedSnooze is the on-disk form of a SnoozedNotification.
+type storedSnooze struct {
+	Notification storedNotification `json:"notification"`
+	Until        time.Time          `json:"until"`
+}
+
+// Snooze hides a live notification and shows it again, as a Created event, once
+// duration has elapsed. The client is not told; closing the notification while it
+// is snoozed cancels the snooze. With a persistent Config.Store, snoozed notifications
+// survive restarts.
+func (d *Daemon) Snooze(id uint32, duration time.Duration) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.config.Monitor {
+		return errors.New("notifications can't be snoozed in monitor mode")
+	}
+	notification, exists := d.Notifications[id]
+	if !exists {
+		return ErrNotificationNotFound
+	}
+
+	d.stopTimer(id)
+	delete(d.Notifications, id)
+	if d.forgetLocked(id) {
+		d.broadcastLocked(NotificationEvent{Notification: notification, Deleted: true})
+	}
+
+	until := time.Now().Add(duration)
+	d.snoozeLocked(notification, until)
+	d.emitCenter("NotificationSnoozed", id, until.UnixMilli())
+	d.saveSnoozedLocked()
+
+	slog.Debug("Snoozed notification", "id", id, "until", until)
+	return nil
+}
+
+// Unsnooze shows a snoozed notification again right away.
+func (d *Daemon) Unsnooze(id uint32) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if _, exists := d.snoozed[id]; !exists {
+		return ErrNotificationNotFound
+	}
+	d.wakeLocked(id)
+	return nil
+}
+
+// Snoozed returns the snoozed notifications.
+func (d *Daemon) Snoozed() []Notification {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	notifications := make([]Notification, 0, len(d.snoozed))
+	for _, s := range d.snoozed {
+		notifications = append(notifications, s.notification)
+	}
+	return notifications
+}
+
+// snoozeLocked arms the wake-up timer of a snoozed notification.
+// d.mu must be held.
+func (d *Daemon) snoozeLocked(notification Notification, until time.Time) {
+	id := notification.ID
+	s := &snoozedNotification{notification: notification, until: until}
+	s.timer = time.AfterFunc(time.Until(until), func() {
+		d.mu.Lock()
+		defer d.mu.Unlock()
+
+		if current, exists := d.snoozed[id]; exists && current == s && !d.stopped {
+			d.wakeLocked(id)
+		}
+	})
+	d.snoozed[id] = s
+}
+
+// wakeLocked turns a snoozed notification back into a live one.
+// d.mu must be held.
+func (d *Daemon) wakeLocked(id uint32) {
+	s := d.snoozed[id]
+	s.timer.Stop()
+	delete(d.snoozed, id)
+
+	notification := s.notification
+	d.Notifications[id] = notification
+	d.scheduleExpiration(id, notification.ExpireTimeout)
+	d.emitCenter("NotificationAdded", id)
+	d.presentLocked(NotificationEvent{Notification: notification, Created: true})
+	d.saveSnoozedLocked()
+
+	slog.Debug("Snooze elapsed", "id", id)
+}
+
+// closeSnoozedLocked closes a snoozed notification. It reports whether id was snoozed.
+// d.mu must be held.
+func (d *Daemon) closeSnoozedLocked(id uint32, reason uint32) bool {
+	s, exists := d.snoozed[id]
+	if !exists {
+		return false
+	}
+	s.timer.Stop()
+	delete(d.snoozed, id)
+	d.emit(objectPath, interfaceName+".NotificationClosed", id, reason)
+	d.countCloseLocked(s.notification, reason)
+	d.emitCenter("NotificationRemoved", id, reason)
+	d.recordHistory(s.notification)
+	d.saveSnoozedLocked()
+	return true
+}
+
+// saveSnoozedLocked saves the snoozed notifications to the store.
+// d.mu must be held.
+func (d *Daemon) saveSnoozedLocked() {
+	snoozed := make([]SnoozedNotification, 0, len(d.snoozed))
+	for _, s := range d.snoozed {
+		snoozed = append(snoozed, SnoozedNotification{Notification: s.notification, Until: s.until})
+	}
+	if err := d.store.SaveSnoozed(snoozed); err != nil {
+		slog.Error("Failed to save snoozed notifications", "error", err)
+	}
+}
+
+// loadSnoozed restores the notifications snoozed by a previous run.
+// Those whose snooze elapsed in the meantime are shown right away.
+func (d *Daemon) loadSnoozed() error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	stored, err := d.store.LoadSnoozed()
+	if err != nil {
+		return err
+	}
+	for _, s := range stored {
+		notification := s.Notification
+		d.resolveAppIdentity(&notification)
+		d.resolveActionIcons(&notification)
+		if _, live := d.Notifications[notification.ID]; live || notification.ID == 0 {
+			if notification.ID, err = d.allocateIDLocked(); err != nil {
+				return err
+			}
+		}
+		if notification.ID >= d.nextID {
+			d.nextID = notification.ID + 1
+		}
+		d.snoozeLocked(notification, s.Until)
+	}
+	return nil
+}
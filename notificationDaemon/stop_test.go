@@ -0,0 +1,81 @@
+/*
+	libxdg-go - An implementaion of various freedesktop specifications in go
+    Copyright (C) 2025 MiracleOS Contributors
+
+    This program is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    This program is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+*/
+
+package notificationDaemon_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/MiracleOS-Team/libxdg-go/notificationDaemon"
+	"github.com/MiracleOS-Team/libxdg-go/notificationDaemon/testsupport"
+)
+
+func TestStopKeepsSnoozedOpen(t *testing.T) {
+	h := testsupport.New(t, notificationDaemon.Config{SnoozeFile: t.TempDir() + "/snoozed.json"})
+
+	snoozed, err := h.Client.Notify(notificationDaemon.Notification{AppName: "test", Summary: "Later", ExpireTimeout: -1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	live, err := h.Client.Notify(notificationDaemon.Notification{AppName: "test", Summary: "Now", ExpireTimeout: -1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := h.Daemon.Snooze(snoozed, time.Hour); err != nil {
+		t.Fatal(err)
+	}
+
+	go func() {
+		for range h.Daemon.NotificationsChannel {
+		}
+	}()
+	h.Daemon.Stop()
+	if _, err := h.Client.WaitClosed(live, 2*time.Second); err != nil {
+		t.Fatalf("live notification: %v", err)
+	}
+	for _, signal := range h.Client.Signals() {
+		if signal.Name == "org.freedesktop.Notifications.NotificationClosed" && signal.Body[0] == snoozed {
+			t.Fatalf("Stop closed the snoozed notification %d", snoozed)
+		}
+	}
+}
+
+func TestStopDeliversWithoutHoldingTheLock(t *testing.T) {
+	h := testsupport.New(t, notificationDaemon.Config{})
+	if _, err := h.Client.Notify(notificationDaemon.Notification{AppName: "test", Summary: "Queued", ExpireTimeout: -1}); err != nil {
+		t.Fatal(err)
+	}
+
+	// The consumer needs the daemon lock before taking the queued events.
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		h.Daemon.Idle()
+		for range h.Daemon.NotificationsChannel {
+		}
+	}()
+	start := time.Now()
+	h.Daemon.Stop()
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("Stop took %v", elapsed)
+	}
+	if dropped := h.Daemon.DroppedEvents(); dropped != 0 {
+		t.Errorf("%d events dropped", dropped)
+	}
+}
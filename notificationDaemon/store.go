@@ -0,0 +1,151 @@
+/*
+	libxdg-go - An implementaion of various freedesktop specifications in go
+    Copyright (C) 2025 MiracleOS Contributors
+
+    This program is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    This program is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+*/
+
+package notificationDaemon
+
+import (
+	"sync"
+	"time"
+)
+
+// SnoozedNotification is a notification snoozed until a point in time.
+type SnoozedNotification struct {
+	Notification Notification
+	Until        time.Time
+}
+
+// NotificationStore keeps the state that outlives live notifications: the history
+// of closed notifications and the snoozed ones. Implementations must not call back
+// into the Daemon. The snoozed methods are called with the daemon lock held. The
+// history methods are called without it, so that disk I/O doesn't hold up the
+// daemon, and must be safe alongside the other methods.
+type NotificationStore interface {
+	// AppendHistory adds a closed notification to the history, dropping the oldest
+	// entries beyond limit. Stores may drop them lazily, the daemon only uses the
+	// last limit entries.
+	AppendHistory(n Notification, limit int) error
+	// History returns the history, oldest first.
+	History() ([]Notification, error)
+	// HistoryLen returns the number of notifications in the history.
+	HistoryLen() int
+	// ClearHistory empties the history.
+	ClearHistory() error
+	// SaveSnoozed replaces the snoozed notifications.
+	SaveSnoozed(snoozed []SnoozedNotification) error
+	// LoadSnoozed returns the snoozed notifications saved last.
+	LoadSnoozed() ([]SnoozedNotification, error)
+	// Close releases the store. The daemon closes it on Stop.
+	Close() error
+}
+
+// MemoryStore is the default NotificationStore, which keeps everything in memory.
+type MemoryStore struct {
+	// mu guards history, which History reads without the daemon lock.
+	mu      sync.Mutex
+	history []Notification
+	snoozed []SnoozedNotification
+	// snoozeFile backs the snoozed notifications for Config.SnoozeFile.
+	snoozeFile string
+}
+
+// NewMemoryStore creates an empty MemoryStore.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{}
+}
+
+// AppendHistory adds a closed notification to the history.
+func (s *MemoryStore) AppendHistory(n Notification, limit int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.history = append(s.history, n)
+	if excess := len(s.history) - limit; excess > 0 {
+		s.history = append([]Notification(nil), s.history[excess:]...)
+	}
+	return nil
+}
+
+// History returns a copy of the history, oldest first.
+func (s *MemoryStore) History() ([]Notification, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]Notification(nil), s.history...), nil
+}
+
+// HistoryLen returns the number of notifications in the history.
+func (s *MemoryStore) HistoryLen() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return len(s.history)
+}
+
+// ClearHistory empties the history.
+func (s *MemoryStore) ClearHistory() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.history = nil
+	return nil
+}
+
+// SaveSnoozed replaces the snoozed notifications.
+func (s *MemoryStore) SaveSnoozed(snoozed []SnoozedNotification) error {
+	s.snoozed = append([]SnoozedNotification(nil), snoozed...)
+	if s.snoozeFile != "" {
+		return writeSnoozedFile(s.snoozeFile, snoozed)
+	}
+	return nil
+}
+
+// LoadSnoozed returns the snoozed notifications saved last.
+func (s *MemoryStore) LoadSnoozed() ([]SnoozedNotification, error) {
+	if s.snoozeFile != "" {
+		return readSnoozedFile(s.snoozeFile)
+	}
+	return append([]SnoozedNotification(nil), s.snoozed...), nil
+}
+
+// Close does nothing.
+func (s *MemoryStore) Close() error {
+	return nil
+}
+
+// writeSnoozedFile saves snoozed notifications as JSON.
+func writeSnoozedFile(path string, snoozed []SnoozedNotification) error {
+	stored := make([]storedSnooze, 0, len(snoozed))
+	for _, s := range snoozed {
+		stored = append(stored, storedSnooze{Notification: toStoredNotification(s.Notification), Until: s.Until})
+	}
+	return writeJSONFile(path, stored)
+}
+
+// readSnoozedFile loads snoozed notifications saved by writeSnoozedFile.
+func readSnoozedFile(path string) ([]SnoozedNotification, error) {
+	var stored []storedSnooze
+	if err := readJSONFile(path, &stored); err != nil {
+		return nil, err
+	}
+	snoozed := make([]SnoozedNotification, 0, len(stored))
+	for _, s := range stored {
+		snoozed = append(snoozed, SnoozedNotification{Notification: s.Notification.notification(), Until: s.Until})
+	}
+	return snoozed, nil
+}
@@ -0,0 +1,74 @@
+/*
+	libxdg-go - An implementaion of various freedesktop specifications in go
+    Copyright (C) 2025 MiracleOS Contributors
+
+    This program is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    This program is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+*/
+
+package notificationDaemon
+
+// subscriber is an additional consumer of the daemon's events.
+type subscriber struct {
+	events  chan NotificationEvent
+	dropped uint64
+}
+
+// Subscribe registers an additional consumer of the events sent on NotificationsChannel.
+// Subscribers never slow the daemon down: events that don't fit in the buffer are
+// dropped for that subscriber. The channel is closed by cancel or by Stop.
+func (d *Daemon) Subscribe(buffer int) (<-chan NotificationEvent, func()) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	s := &subscriber{events: make(chan NotificationEvent, buffer)}
+	if d.stopped {
+		close(s.events)
+		return s.events, func() {}
+	}
+	d.subscribers[s] = struct{}{}
+
+	cancel := func() {
+		d.mu.Lock()
+		defer d.mu.Unlock()
+
+		if _, exists := d.subscribers[s]; exists {
+			delete(d.subscribers, s)
+			close(s.events)
+		}
+	}
+	return s.events, cancel
+}
+
+// publishLocked queues an event for NotificationsChannel and hands it to the subscribers.
+// d.mu must be held.
+func (d *Daemon) publishLocked(event NotificationEvent) {
+	d.events.push(event)
+	for s := range d.subscribers {
+		select {
+		case s.events <- event:
+		default:
+			s.dropped++
+		}
+	}
+}
+
+// closeSubscribersLocked closes every subscriber channel.
+// d.mu must be held.
+func (d *Daemon) closeSubscribersLocked() {
+	for s := range d.subscribers {
+		close(s.events)
+		delete(d.subscribers, s)
+	}
+}
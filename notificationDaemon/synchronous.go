@@ -0,0 +1,38 @@
+/*
+	libxdg-go - An implementaion of various freedesktop specifications in go
+    Copyright (C) 2025 MiracleOS Contributors
+
+    This program is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    This program is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+*/
+
+package notificationDaemon
+
+// synchronousTargetLocked returns the live notification that n replaces through its
+// synchronous tag, if the capability is advertised. OSD clients usually don't track
+// the ID they got back, so the tag is what makes successive updates replace each other.
+// d.mu must be held.
+func (d *Daemon) synchronousTargetLocked(n Notification) (uint32, bool) {
+	tag := n.SynchronousTag()
+	if tag == "" || !d.HasCapability(CapabilitySynchronous) {
+		return 0, false
+	}
+
+	for id, live := range d.Notifications {
+		if live.SynchronousTag() == tag {
+			return id, true
+		}
+	}
+	return 0, false
+}
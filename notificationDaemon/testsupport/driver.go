@@ -0,0 +1,175 @@
+/*
+	libxdg-go - An implementaion of various freedesktop specifications in go
+    Copyright (C) 2025 MiracleOS Contributors
+
+    This program is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    This program is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+*/
+
+package testsupport
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/MiracleOS-Team/libxdg-go/notificationDaemon"
+	"github.com/godbus/dbus/v5"
+)
+
+const (
+	busName       = "org.freedesktop.Notifications"
+	objectPath    = dbus.ObjectPath("/org/freedesktop/Notifications")
+	interfaceName = "org.freedesktop.Notifications"
+)
+
+// Signal is a signal received from the notification server.
+type Signal struct {
+	Name string
+	Body []interface{}
+}
+
+// Driver talks to the notification server like a client and records the signals it emits.
+type Driver struct {
+	conn    *dbus.Conn
+	obj     dbus.BusObject
+	signals chan *dbus.Signal
+
+	mu       sync.Mutex
+	received []Signal
+	notify   chan struct{}
+}
+
+// NewDriver creates a driver on a connection. The connection is closed by Driver.Close.
+func NewDriver(conn *dbus.Conn) (*Driver, error) {
+	d := &Driver{
+		conn:    conn,
+		obj:     conn.Object(busName, objectPath),
+		signals: make(chan *dbus.Signal, 64),
+		notify:  make(chan struct{}),
+	}
+	err := conn.AddMatchSignal(dbus.WithMatchObjectPath(objectPath), dbus.WithMatchInterface(interfaceName))
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	conn.Signal(d.signals)
+	go d.record()
+	return d, nil
+}
+
+func (d *Driver) record() {
+	for signal := range d.signals {
+		d.mu.Lock()
+		d.received = append(d.received, Signal{Name: signal.Name, Body: signal.Body})
+		close(d.notify)
+		d.notify = make(chan struct{})
+		d.mu.Unlock()
+	}
+}
+
+// Conn returns the driver's bus connection, for calls the driver doesn't wrap.
+func (d *Driver) Conn() *dbus.Conn {
+	return d.conn
+}
+
+// Notify sends a notification. A non-zero n.ID is passed as replaces_id.
+func (d *Driver) Notify(n notificationDaemon.Notification) (uint32, error) {
+	actions := n.Actions
+	if actions == nil {
+		actions = []string{}
+	}
+	hints := n.Hints
+	if hints == nil {
+		hints = map[string]dbus.Variant{}
+	}
+	var id uint32
+	err := d.obj.Call(interfaceName+".Notify", 0, n.AppName, n.ID, n.AppIcon, n.Summary, n.Body, actions, hints, n.ExpireTimeout).Store(&id)
+	return id, err
+}
+
+// CloseNotification calls CloseNotification on the server.
+func (d *Driver) CloseNotification(id uint32) error {
+	return d.obj.Call(interfaceName+".CloseNotification", 0, id).Err
+}
+
+// GetCapabilities calls GetCapabilities on the server.
+func (d *Driver) GetCapabilities() ([]string, error) {
+	var caps []string
+	err := d.obj.Call(interfaceName+".GetCapabilities", 0).Store(&caps)
+	return caps, err
+}
+
+// Signals returns every signal received so far.
+func (d *Driver) Signals() []Signal {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	return append([]Signal(nil), d.received...)
+}
+
+// WaitSignal waits until a signal satisfying match has been received and returns it.
+// Signals received before the call are considered too.
+func (d *Driver) WaitSignal(match func(Signal) bool, timeout time.Duration) (Signal, error) {
+	deadline := time.After(timeout)
+	seen := 0
+	for {
+		d.mu.Lock()
+		for _, signal := range d.received[seen:] {
+			if match(signal) {
+				d.mu.Unlock()
+				return signal, nil
+			}
+		}
+		seen = len(d.received)
+		notify := d.notify
+		d.mu.Unlock()
+
+		select {
+		case <-notify:
+		case <-deadline:
+			return Signal{}, fmt.Errorf("no matching signal within %v", timeout)
+		}
+	}
+}
+
+// WaitClosed waits for the NotificationClosed signal of id and returns its reason.
+func (d *Driver) WaitClosed(id uint32, timeout time.Duration) (uint32, error) {
+	signal, err := d.WaitSignal(func(s Signal) bool {
+		return s.Name == interfaceName+".NotificationClosed" && len(s.Body) == 2 && s.Body[0] == id
+	}, timeout)
+	if err != nil {
+		return 0, err
+	}
+	reason, _ := signal.Body[1].(uint32)
+	return reason, nil
+}
+
+// WaitActionInvoked waits for the ActionInvoked signal of id and returns the action key.
+func (d *Driver) WaitActionInvoked(id uint32, timeout time.Duration) (string, error) {
+	signal, err := d.WaitSignal(func(s Signal) bool {
+		return s.Name == interfaceName+".ActionInvoked" && len(s.Body) == 2 && s.Body[0] == id
+	}, timeout)
+	if err != nil {
+		return "", err
+	}
+	key, _ := signal.Body[1].(string)
+	return key, nil
+}
+
+// Close disconnects the driver.
+func (d *Driver) Close() error {
+	d.conn.RemoveSignal(d.signals)
+	return d.conn.Close()
+}
@@ -0,0 +1,89 @@
+/*
+	libxdg-go - An implementaion of various freedesktop specifications in go
+    Copyright (C) 2025 MiracleOS Contributors
+
+    This program is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    This program is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+*/
+
+package testsupport_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/MiracleOS-Team/libxdg-go/notificationDaemon"
+	"github.com/MiracleOS-Team/libxdg-go/notificationDaemon/testsupport"
+)
+
+const timeout = 2 * time.Second
+
+func TestNotifyAndClose(t *testing.T) {
+	h := testsupport.New(t, notificationDaemon.Config{})
+
+	id, err := h.Client.Notify(notificationDaemon.Notification{AppName: "test", Summary: "Hello", ExpireTimeout: -1})
+	if err != nil {
+		t.Fatalf("Notify: %v", err)
+	}
+	event, err := h.NextEvent(timeout)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !event.Created || event.Notification.ID != id || event.Notification.Summary != "Hello" {
+		t.Fatalf("got event %+v, want the creation of %d", event, id)
+	}
+
+	if err := h.Client.CloseNotification(id); err != nil {
+		t.Fatalf("CloseNotification: %v", err)
+	}
+	reason, err := h.Client.WaitClosed(id, timeout)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reason != notificationDaemon.CloseReasonClosed {
+		t.Errorf("closed with reason %d, want %d", reason, notificationDaemon.CloseReasonClosed)
+	}
+}
+
+func TestActionInvoked(t *testing.T) {
+	h := testsupport.New(t, notificationDaemon.Config{})
+
+	id, err := h.Client.Notify(notificationDaemon.Notification{
+		AppName:       "test",
+		Summary:       "Update available",
+		Actions:       []string{"default", "Open", "install", "Install"},
+		ExpireTimeout: -1,
+	})
+	if err != nil {
+		t.Fatalf("Notify: %v", err)
+	}
+	if _, err := h.NextEvent(timeout); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := h.Daemon.InvokeAction(id, "install"); err != nil {
+		t.Fatalf("InvokeAction: %v", err)
+	}
+	key, err := h.Client.WaitActionInvoked(id, timeout)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if key != "install" {
+		t.Errorf("got action %q, want %q", key, "install")
+	}
+
+	if err := h.Daemon.InvokeAction(id, "missing"); err != notificationDaemon.ErrUnknownAction {
+		t.Errorf("invoking an unknown action: got %v, want ErrUnknownAction", err)
+	}
+}
@@ -0,0 +1,187 @@
+/*
+	libxdg-go - An implementaion of various freedesktop specifications in go
+    Copyright (C) 2025 MiracleOS Contributors
+
+    This program is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    This program is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+*/
+
+// Package testsupport runs a notification Daemon on a private D-Bus instance
+// and drives it like a client would, so daemon behavior can be exercised in
+// CI without touching the developer's session bus.
+package testsupport
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/MiracleOS-Team/libxdg-go/notificationDaemon"
+	"github.com/godbus/dbus/v5"
+)
+
+// Bus is a private dbus-daemon instance.
+type Bus struct {
+	Address string
+	cmd     *exec.Cmd
+	dir     string
+}
+
+// StartBus launches a private dbus-daemon with the session bus configuration.
+func StartBus() (*Bus, error) {
+	path, err := exec.LookPath("dbus-daemon")
+	if err != nil {
+		return nil, err
+	}
+	dir, err := os.MkdirTemp("", "libxdg-dbus-*")
+	if err != nil {
+		return nil, err
+	}
+
+	cmd := exec.Command(path, "--session", "--nofork", "--nosyslog", "--print-address=1",
+		"--address=unix:path="+filepath.Join(dir, "bus"))
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		os.RemoveAll(dir)
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		os.RemoveAll(dir)
+		return nil, err
+	}
+
+	address := make(chan string, 1)
+	go func() {
+		line, _ := bufio.NewReader(stdout).ReadString('\n')
+		address <- strings.TrimSpace(line)
+	}()
+
+	bus := &Bus{cmd: cmd, dir: dir}
+	select {
+	case bus.Address = <-address:
+	case <-time.After(5 * time.Second):
+	}
+	if bus.Address == "" {
+		bus.Close()
+		return nil, errors.New("dbus-daemon did not report its address")
+	}
+	return bus, nil
+}
+
+// Connect opens a new connection to the bus.
+func (b *Bus) Connect() (*dbus.Conn, error) {
+	return dbus.Connect(b.Address)
+}
+
+// Close stops the dbus-daemon and removes its socket.
+func (b *Bus) Close() error {
+	b.cmd.Process.Kill()
+	b.cmd.Wait()
+	return os.RemoveAll(b.dir)
+}
+
+// Harness is a Daemon running on a private bus together with a client Driver.
+type Harness struct {
+	Bus    *Bus
+	Daemon *notificationDaemon.Daemon
+	Client *Driver
+}
+
+// Start launches a private bus and starts a Daemon on it with the given config.
+// BusAddress is overridden and Conn cleared to stay on the private instance.
+func Start(config notificationDaemon.Config) (*Harness, error) {
+	bus, err := StartBus()
+	if err != nil {
+		return nil, err
+	}
+
+	config.BusAddress = bus.Address
+	config.Conn = nil
+	daemon := notificationDaemon.NewDaemon(config)
+	if err := daemon.Start(); err != nil {
+		// Stop also ends the event delivery NewDaemon started.
+		daemon.Stop()
+		bus.Close()
+		return nil, err
+	}
+
+	conn, err := bus.Connect()
+	if err != nil {
+		daemon.Stop()
+		bus.Close()
+		return nil, err
+	}
+	client, err := NewDriver(conn)
+	if err != nil {
+		daemon.Stop()
+		bus.Close()
+		return nil, err
+	}
+
+	return &Harness{Bus: bus, Daemon: daemon, Client: client}, nil
+}
+
+// TB is the part of testing.TB the harness uses, so that the package doesn't
+// depend on the testing package.
+type TB interface {
+	Helper()
+	Skip(args ...any)
+	Fatalf(format string, args ...any)
+	Cleanup(fn func())
+}
+
+// New is Start for tests: it skips the test when dbus-daemon isn't installed,
+// fails it on other errors and closes the harness when the test ends.
+func New(tb TB, config notificationDaemon.Config) *Harness {
+	tb.Helper()
+	if _, err := exec.LookPath("dbus-daemon"); err != nil {
+		tb.Skip("dbus-daemon is not installed")
+	}
+	h, err := Start(config)
+	if err != nil {
+		tb.Fatalf("failed to start the notification daemon harness: %v", err)
+	}
+	tb.Cleanup(h.Close)
+	return h
+}
+
+// NextEvent waits for the next event on the daemon's NotificationsChannel.
+func (h *Harness) NextEvent(timeout time.Duration) (notificationDaemon.NotificationEvent, error) {
+	select {
+	case event, ok := <-h.Daemon.NotificationsChannel:
+		if !ok {
+			return event, errors.New("notifications channel closed")
+		}
+		return event, nil
+	case <-time.After(timeout):
+		return notificationDaemon.NotificationEvent{}, fmt.Errorf("no event within %v", timeout)
+	}
+}
+
+// Close stops the client, the daemon and the bus. Events left on
+// NotificationsChannel are discarded, so Stop doesn't wait for a consumer.
+func (h *Harness) Close() {
+	h.Client.Close()
+	go func() {
+		for range h.Daemon.NotificationsChannel {
+		}
+	}()
+	h.Daemon.Stop()
+	h.Bus.Close()
+}
@@ -0,0 +1,227 @@
+/*
+	libxdg-go - An implementaion of various freedesktop specifications in go
+    Copyright (C) 2025 MiracleOS Contributors
+
+    This program is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    This program is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+*/
+
+// Package notify is a client for org.freedesktop.Notifications, the sender side
+// of the Desktop Notifications specification.
+package notify
+
+import (
+	"errors"
+	"sync"
+
+	"github.com/MiracleOS-Team/libxdg-go/notificationDaemon"
+	"github.com/godbus/dbus/v5"
+)
+
+const (
+	busName       = "org.freedesktop.Notifications"
+	objectPath    = dbus.ObjectPath("/org/freedesktop/Notifications")
+	interfaceName = "org.freedesktop.Notifications"
+)
+
+// Callbacks are invoked for signals concerning a notification sent with SendWithCallbacks.
+// They run on the client's signal goroutine and must not block.
+type Callbacks struct {
+	// OnAction is called when the user invokes one of the notification's actions.
+	OnAction func(id uint32, actionKey string)
+	// OnClosed is called once the notification is closed, with one of the
+	// notificationDaemon.CloseReason* values.
+	OnClosed func(id uint32, reason uint32)
+}
+
+// ServerInformation describes the running notification server.
+type ServerInformation struct {
+	Name        string
+	Vendor      string
+	Version     string
+	SpecVersion string
+}
+
+// Client sends notifications over the session bus and tracks their lifetime.
+type Client struct {
+	conn      *dbus.Conn
+	obj       dbus.BusObject
+	mu        sync.Mutex
+	callbacks map[uint32]Callbacks
+	live      map[uint32]bool
+	signals   chan *dbus.Signal
+}
+
+// NewClient connects to the session bus and subscribes to the notification signals.
+func NewClient() (*Client, error) {
+	conn, err := dbus.ConnectSessionBus()
+	if err != nil {
+		return nil, err
+	}
+	return newClient(conn)
+}
+
+// NewClientWithConn creates a client on an existing connection.
+// The connection is closed by Client.Close.
+func NewClientWithConn(conn *dbus.Conn) (*Client, error) {
+	return newClient(conn)
+}
+
+func newClient(conn *dbus.Conn) (*Client, error) {
+	c := &Client{
+		conn:      conn,
+		obj:       conn.Object(busName, objectPath),
+		callbacks: make(map[uint32]Callbacks),
+		live:      make(map[uint32]bool),
+		signals:   make(chan *dbus.Signal, 16),
+	}
+
+	err := conn.AddMatchSignal(
+		dbus.WithMatchObjectPath(objectPath),
+		dbus.WithMatchInterface(interfaceName),
+	)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	conn.Signal(c.signals)
+	go c.dispatch()
+
+	return c, nil
+}
+
+// Close disconnects the client. Pending callbacks are never called.
+func (c *Client) Close() error {
+	c.conn.RemoveSignal(c.signals)
+	return c.conn.Close()
+}
+
+// Send sends a notification and returns its ID.
+// A non-zero n.ID replaces the notification with that ID. Note that an ExpireTimeout
+// of 0 means the notification never expires; use -1 for the server default.
+func (c *Client) Send(n notificationDaemon.Notification) (uint32, error) {
+	return c.SendWithCallbacks(n, Callbacks{})
+}
+
+// SendWithCallbacks sends a notification and registers callbacks for its actions and closing.
+func (c *Client) SendWithCallbacks(n notificationDaemon.Notification, callbacks Callbacks) (uint32, error) {
+	actions := n.Actions
+	if actions == nil {
+		actions = []string{}
+	}
+	hints := n.Hints
+	if hints == nil {
+		hints = map[string]dbus.Variant{}
+	}
+
+	// Hold the lock across the call so signals for the new ID can't be
+	// dispatched before its callbacks are registered.
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var id uint32
+	err := c.obj.Call(interfaceName+".Notify", 0, n.AppName, n.ID, n.AppIcon, n.Summary, n.Body, actions, hints, n.ExpireTimeout).Store(&id)
+	if err != nil {
+		return 0, err
+	}
+	if id == 0 {
+		return 0, errors.New("notification server returned an invalid ID")
+	}
+
+	c.live[id] = true
+	if callbacks.OnAction != nil || callbacks.OnClosed != nil {
+		c.callbacks[id] = callbacks
+	} else {
+		delete(c.callbacks, id)
+	}
+	return id, nil
+}
+
+// CloseNotification asks the server to close a notification.
+func (c *Client) CloseNotification(id uint32) error {
+	return c.obj.Call(interfaceName+".CloseNotification", 0, id).Err
+}
+
+// IsLive reports whether a notification sent by this client hasn't been closed yet.
+func (c *Client) IsLive(id uint32) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.live[id]
+}
+
+// GetCapabilities returns the capabilities of the notification server.
+func (c *Client) GetCapabilities() ([]string, error) {
+	var caps []string
+	err := c.obj.Call(interfaceName+".GetCapabilities", 0).Store(&caps)
+	return caps, err
+}
+
+// GetServerInformation returns the identity of the notification server.
+func (c *Client) GetServerInformation() (ServerInformation, error) {
+	var info ServerInformation
+	err := c.obj.Call(interfaceName+".GetServerInformation", 0).Store(&info.Name, &info.Vendor, &info.Version, &info.SpecVersion)
+	return info, err
+}
+
+// dispatch routes ActionInvoked and NotificationClosed signals to the registered callbacks.
+func (c *Client) dispatch() {
+	for signal := range c.signals {
+		if signal.Path != objectPath || len(signal.Body) < 2 {
+			continue
+		}
+		id, ok := signal.Body[0].(uint32)
+		if !ok {
+			continue
+		}
+
+		switch signal.Name {
+		case interfaceName + ".ActionInvoked":
+			actionKey, _ := signal.Body[1].(string)
+			c.mu.Lock()
+			callbacks, tracked := c.callbacks[id]
+			c.mu.Unlock()
+			if tracked && callbacks.OnAction != nil {
+				callbacks.OnAction(id, actionKey)
+			}
+		case interfaceName + ".NotificationClosed":
+			reason, _ := signal.Body[1].(uint32)
+			c.mu.Lock()
+			callbacks, tracked := c.callbacks[id]
+			delete(c.callbacks, id)
+			delete(c.live, id)
+			c.mu.Unlock()
+			if tracked && callbacks.OnClosed != nil {
+				callbacks.OnClosed(id, reason)
+			}
+		}
+	}
+}
+
+var (
+	defaultClient    *Client
+	defaultClientErr error
+	defaultOnce      sync.Once
+)
+
+// Send sends a notification using a shared client connected to the session bus.
+func Send(n notificationDaemon.Notification) (uint32, error) {
+	defaultOnce.Do(func() {
+		defaultClient, defaultClientErr = NewClient()
+	})
+	if defaultClientErr != nil {
+		return 0, defaultClientErr
+	}
+	return defaultClient.Send(n)
+}
@@ -0,0 +1,277 @@
+/*
+	libxdg-go - An implementaion of various freedesktop specifications in go
+    Copyright (C) 2025 MiracleOS Contributors
+
+    This program is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    This program is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+*/
+
+package portal
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"syscall"
+
+	"github.com/godbus/dbus/v5"
+)
+
+const (
+	documentsBusName   = "org.freedesktop.portal.Documents"
+	documentsPath      = dbus.ObjectPath("/org/freedesktop/portal/documents")
+	documentsInterface = "org.freedesktop.portal.Documents"
+)
+
+// oPath is O_PATH, missing from package syscall.
+const oPath = 0x200000
+
+// ErrNotDocument is returned for paths outside the document store.
+var ErrNotDocument = errors.New("not a document portal path")
+
+// Permission is a permission an application can have on a document.
+type Permission string
+
+const (
+	PermissionRead             Permission = "read"
+	PermissionWrite            Permission = "write"
+	PermissionGrantPermissions Permission = "grant-permissions"
+	PermissionDelete           Permission = "delete"
+)
+
+// DocumentFlags is a bit mask of options for adding documents.
+type DocumentFlags uint32
+
+const (
+	// DocumentReuseExisting returns the existing document of a file already in
+	// the store instead of adding it again.
+	DocumentReuseExisting DocumentFlags = 1 << iota
+	// DocumentPersistent keeps the document after the caller disconnects.
+	DocumentPersistent
+	// DocumentAsNeededByApp only adds the file if the application can't already
+	// read it through its sandbox.
+	DocumentAsNeededByApp
+	// DocumentExportDirectory allows exporting directories. Documents.Add sets it
+	// for directories.
+	DocumentExportDirectory
+)
+
+// Documents is a client for the document store, the FUSE file system through
+// which sandboxed applications see the host files they were given access to.
+type Documents struct {
+	c     *Client
+	obj   dbus.BusObject
+	mu    sync.Mutex
+	mount string
+}
+
+// Documents returns a document store client using the client's connection.
+func (c *Client) Documents() *Documents {
+	return &Documents{c: c, obj: c.conn.Object(documentsBusName, documentsPath)}
+}
+
+// Version returns the version of the document store interface.
+func (d *Documents) Version() (uint32, error) {
+	return d.c.version(d.obj, documentsInterface)
+}
+
+// MountPoint returns where the document store is mounted, usually
+// $XDG_RUNTIME_DIR/doc.
+func (d *Documents) MountPoint() (string, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.mount != "" {
+		return d.mount, nil
+	}
+	var mount []byte
+	if err := d.obj.Call(documentsInterface+".GetMountPoint", 0).Store(&mount); err != nil {
+		return "", err
+	}
+	d.mount = pathFromBytes(mount)
+	return d.mount, nil
+}
+
+// Add adds files to the store and returns their document IDs. If appID is not
+// empty, the application is granted perms on the documents. An empty ID is
+// returned for a file DocumentAsNeededByApp didn't need to add.
+func (d *Documents) Add(paths []string, flags DocumentFlags, appID string, perms ...Permission) ([]string, error) {
+	if err := d.c.requireVersion(d.obj, documentsInterface, 2); err != nil {
+		return nil, err
+	}
+	fds := make([]dbus.UnixFD, 0, len(paths))
+	defer func() {
+		for _, fd := range fds {
+			syscall.Close(int(fd))
+		}
+	}()
+	for _, path := range paths {
+		if info, err := os.Stat(path); err == nil && info.IsDir() && flags&DocumentExportDirectory == 0 {
+			if err := d.c.requireVersion(d.obj, documentsInterface, 4); err != nil {
+				return nil, err
+			}
+			flags |= DocumentExportDirectory
+		}
+		// The store only accepts O_PATH descriptors.
+		fd, err := syscall.Open(path, oPath|syscall.O_CLOEXEC, 0)
+		if err != nil {
+			return nil, &os.PathError{Op: "open", Path: path, Err: err}
+		}
+		fds = append(fds, dbus.UnixFD(fd))
+	}
+
+	var ids []string
+	var extra map[string]dbus.Variant
+	err := d.obj.Call(documentsInterface+".AddFull", 0, fds, uint32(flags), appID, permissionStrings(perms)).Store(&ids, &extra)
+	return ids, err
+}
+
+// Export makes a file available to a sandboxed application and returns the path
+// under which the application sees it. The document persists and is reused if
+// the file was exported before.
+func (d *Documents) Export(path, appID string, perms ...Permission) (string, error) {
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return "", err
+	}
+	ids, err := d.Add([]string{path}, DocumentReuseExisting|DocumentPersistent, appID, perms...)
+	if err != nil {
+		return "", err
+	}
+	if len(ids) == 0 || ids[0] == "" {
+		// The application can already reach the file.
+		return path, nil
+	}
+	mount, err := d.MountPoint()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(mount, ids[0], filepath.Base(path)), nil
+}
+
+// Grant grants an application permissions on a document.
+func (d *Documents) Grant(id, appID string, perms ...Permission) error {
+	return d.obj.Call(documentsInterface+".GrantPermissions", 0, id, appID, permissionStrings(perms)).Err
+}
+
+// Revoke revokes permissions of an application on a document.
+func (d *Documents) Revoke(id, appID string, perms ...Permission) error {
+	return d.obj.Call(documentsInterface+".RevokePermissions", 0, id, appID, permissionStrings(perms)).Err
+}
+
+// Delete removes a document from the store, revoking every application's access.
+func (d *Documents) Delete(id string) error {
+	return d.obj.Call(documentsInterface+".Delete", 0, id).Err
+}
+
+// Lookup returns the ID of the document of a host file. found is false if the
+// file is not in the store.
+func (d *Documents) Lookup(hostPath string) (id string, found bool, err error) {
+	err = d.obj.Call(documentsInterface+".Lookup", 0, bytePath(hostPath)).Store(&id)
+	return id, id != "", err
+}
+
+// Info returns the host path of a document and the permissions applications have
+// on it, by application ID.
+func (d *Documents) Info(id string) (string, map[string][]Permission, error) {
+	var path []byte
+	var apps map[string][]string
+	if err := d.obj.Call(documentsInterface+".Info", 0, id).Store(&path, &apps); err != nil {
+		return "", nil, err
+	}
+	permissions := make(map[string][]Permission, len(apps))
+	for app, perms := range apps {
+		for _, p := range perms {
+			permissions[app] = append(permissions[app], Permission(p))
+		}
+	}
+	return pathFromBytes(path), permissions, nil
+}
+
+// List returns the host paths of the documents an application has access to, by
+// document ID. An empty appID lists every document.
+func (d *Documents) List(appID string) (map[string]string, error) {
+	var docs map[string][]byte
+	if err := d.obj.Call(documentsInterface+".List", 0, appID).Store(&docs); err != nil {
+		return nil, err
+	}
+	paths := make(map[string]string, len(docs))
+	for id, path := range docs {
+		paths[id] = pathFromBytes(path)
+	}
+	return paths, nil
+}
+
+// HostPath translates a path in the document store, as seen by the host or in
+// the by-app view of an application, to the path of the host file.
+func (d *Documents) HostPath(docPath string) (string, error) {
+	mount, err := d.MountPoint()
+	if err != nil {
+		return "", err
+	}
+	rel, err := filepath.Rel(mount, filepath.Clean(docPath))
+	if err != nil || rel == "." || strings.HasPrefix(rel, "..") {
+		return "", ErrNotDocument
+	}
+	parts := strings.Split(rel, string(filepath.Separator))
+	if parts[0] == "by-app" {
+		parts = parts[min(2, len(parts)):]
+	}
+	if len(parts) == 0 {
+		return "", ErrNotDocument
+	}
+
+	host, _, err := d.Info(parts[0])
+	if err != nil {
+		return "", err
+	}
+	// The document directory holds the file, or the exported directory, under its
+	// own name; deeper components are inside an exported directory.
+	if len(parts) > 2 {
+		host = filepath.Join(append([]string{host}, parts[2:]...)...)
+	}
+	return host, nil
+}
+
+// DocumentPath translates the path of a host file to its path in the document
+// store. found is false if the file is not in the store.
+func (d *Documents) DocumentPath(hostPath string) (path string, found bool, err error) {
+	hostPath, err = filepath.Abs(hostPath)
+	if err != nil {
+		return "", false, err
+	}
+	id, found, err := d.Lookup(hostPath)
+	if err != nil || !found {
+		return "", false, err
+	}
+	mount, err := d.MountPoint()
+	if err != nil {
+		return "", false, err
+	}
+	return filepath.Join(mount, id, filepath.Base(hostPath)), true, nil
+}
+
+func permissionStrings(perms []Permission) []string {
+	s := make([]string, len(perms))
+	for i, p := range perms {
+		s[i] = string(p)
+	}
+	return s
+}
+
+// pathFromBytes decodes a nul-terminated byte string path.
+func pathFromBytes(b []byte) string {
+	return strings.TrimRight(string(b), "\x00")
+}
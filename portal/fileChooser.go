@@ -0,0 +1,259 @@
+/*
+	libxdg-go - An implementaion of various freedesktop specifications in go
+    Copyright (C) 2025 MiracleOS Contributors
+
+    This program is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    This program is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+*/
+
+package portal
+
+import (
+	"context"
+	"net/url"
+
+	"github.com/godbus/dbus/v5"
+)
+
+const fileChooserInterface = "org.freedesktop.portal.FileChooser"
+
+// Kinds of FilterRule.
+const (
+	RuleGlob     = uint32(0)
+	RuleMIMEType = uint32(1)
+)
+
+// FilterRule is a pattern of a Filter: a glob such as "*.png", or a MIME type such
+// as "image/png", depending on Kind.
+type FilterRule struct {
+	Kind    uint32
+	Pattern string
+}
+
+// Filter is a named set of patterns the user can restrict the listed files to.
+type Filter struct {
+	Name  string
+	Rules []FilterRule
+}
+
+// GlobFilter returns a filter matching files by name.
+func GlobFilter(name string, globs ...string) Filter {
+	f := Filter{Name: name}
+	for _, g := range globs {
+		f.Rules = append(f.Rules, FilterRule{RuleGlob, g})
+	}
+	return f
+}
+
+// MIMEFilter returns a filter matching files by MIME type. Types may be media
+// wildcards such as "image/*".
+func MIMEFilter(name string, mimeTypes ...string) Filter {
+	f := Filter{Name: name}
+	for _, t := range mimeTypes {
+		f.Rules = append(f.Rules, FilterRule{RuleMIMEType, t})
+	}
+	return f
+}
+
+// ChoiceOption is one of the values of a Choice.
+type ChoiceOption struct {
+	ID    string
+	Label string
+}
+
+// Choice is an extra control shown in the dialog: a combo box of Options, or a
+// check box if there are none, in which case Default is "true" or "false".
+type Choice struct {
+	ID      string
+	Label   string
+	Options []ChoiceOption
+	Default string
+}
+
+// OpenFileOptions are the options of FileChooser.OpenFile.
+type OpenFileOptions struct {
+	// AcceptLabel replaces the label of the accept button, e.g. "_Open".
+	AcceptLabel string
+	// NonModal makes the dialog independent of the parent window.
+	NonModal bool
+	// Multiple lets the user select several files.
+	Multiple bool
+	// Directory selects directories instead of files.
+	Directory     bool
+	Filters       []Filter
+	CurrentFilter *Filter
+	Choices       []Choice
+	// CurrentFolder is the path of the folder shown first.
+	CurrentFolder string
+}
+
+// SaveFileOptions are the options of FileChooser.SaveFile.
+type SaveFileOptions struct {
+	AcceptLabel   string
+	NonModal      bool
+	Filters       []Filter
+	CurrentFilter *Filter
+	Choices       []Choice
+	// CurrentName is the suggested file name.
+	CurrentName string
+	// CurrentFolder is the path of the folder shown first.
+	CurrentFolder string
+	// CurrentFile is the path of a file being saved again, selected first.
+	CurrentFile string
+}
+
+// SaveFilesOptions are the options of FileChooser.SaveFiles.
+type SaveFilesOptions struct {
+	AcceptLabel string
+	NonModal    bool
+	Choices     []Choice
+	// CurrentFolder is the path of the folder shown first.
+	CurrentFolder string
+	// Files are the names of the files to save, which the portal may change to
+	// avoid overwriting existing files.
+	Files []string
+}
+
+// FileChooserResult is what the user chose in a file chooser dialog.
+type FileChooserResult struct {
+	// URIs are the chosen files, usually file:// URIs, or document portal paths
+	// for sandboxed applications.
+	URIs []string
+	// Choices maps the IDs of the dialog's choices to the selected option IDs.
+	Choices map[string]string
+	// Filter is the filter selected when the dialog was accepted, if any.
+	Filter *Filter
+	// Writable is whether the files were opened for writing; only set by portals
+	// from version 3.
+	Writable bool
+}
+
+// Paths returns the local paths of the chosen files, skipping non-file URIs.
+func (r FileChooserResult) Paths() []string {
+	var paths []string
+	for _, uri := range r.URIs {
+		u, err := url.Parse(uri)
+		if err != nil || u.Scheme != "file" {
+			continue
+		}
+		paths = append(paths, u.Path)
+	}
+	return paths
+}
+
+// OpenFileDialog shows a dialog for choosing files to open, through
+// FileChooser.OpenFile. parentWindow is as described for OpenOptions.
+func (c *Client) OpenFileDialog(ctx context.Context, parentWindow, title string, opts OpenFileOptions) (FileChooserResult, error) {
+	options := dialogOptions(opts.AcceptLabel, opts.NonModal, opts.Filters, opts.CurrentFilter, opts.Choices)
+	if opts.Multiple {
+		options["multiple"] = dbus.MakeVariant(true)
+	}
+	if opts.Directory {
+		options["directory"] = dbus.MakeVariant(true)
+	}
+	if opts.CurrentFolder != "" {
+		options["current_folder"] = dbus.MakeVariant(bytePath(opts.CurrentFolder))
+	}
+	return c.chooseFiles(ctx, "OpenFile", parentWindow, title, options)
+}
+
+// SaveFileDialog shows a dialog for choosing where to save a file, through
+// FileChooser.SaveFile.
+func (c *Client) SaveFileDialog(ctx context.Context, parentWindow, title string, opts SaveFileOptions) (FileChooserResult, error) {
+	options := dialogOptions(opts.AcceptLabel, opts.NonModal, opts.Filters, opts.CurrentFilter, opts.Choices)
+	if opts.CurrentName != "" {
+		options["current_name"] = dbus.MakeVariant(opts.CurrentName)
+	}
+	if opts.CurrentFolder != "" {
+		options["current_folder"] = dbus.MakeVariant(bytePath(opts.CurrentFolder))
+	}
+	if opts.CurrentFile != "" {
+		options["current_file"] = dbus.MakeVariant(bytePath(opts.CurrentFile))
+	}
+	return c.chooseFiles(ctx, "SaveFile", parentWindow, title, options)
+}
+
+// SaveFilesDialog shows a dialog for choosing a folder to save several files in,
+// through FileChooser.SaveFiles. The result holds a URI for each of opts.Files.
+func (c *Client) SaveFilesDialog(ctx context.Context, parentWindow, title string, opts SaveFilesOptions) (FileChooserResult, error) {
+	options := dialogOptions(opts.AcceptLabel, opts.NonModal, nil, nil, opts.Choices)
+	if opts.CurrentFolder != "" {
+		options["current_folder"] = dbus.MakeVariant(bytePath(opts.CurrentFolder))
+	}
+	if len(opts.Files) > 0 {
+		files := make([][]byte, len(opts.Files))
+		for i, name := range opts.Files {
+			files[i] = bytePath(name)
+		}
+		options["files"] = dbus.MakeVariant(files)
+	}
+	return c.chooseFiles(ctx, "SaveFiles", parentWindow, title, options)
+}
+
+func (c *Client) chooseFiles(ctx context.Context, method, parentWindow, title string, options map[string]dbus.Variant) (FileChooserResult, error) {
+	results, err := c.Request(ctx, fileChooserInterface, method, options, parentWindow, title)
+	if err != nil {
+		return FileChooserResult{}, err
+	}
+
+	var r FileChooserResult
+	if v, found := results["uris"]; found {
+		v.Store(&r.URIs)
+	}
+	if v, found := results["choices"]; found {
+		var choices []struct{ ID, Value string }
+		if v.Store(&choices) == nil {
+			r.Choices = make(map[string]string, len(choices))
+			for _, choice := range choices {
+				r.Choices[choice.ID] = choice.Value
+			}
+		}
+	}
+	if v, found := results["current_filter"]; found {
+		var filter Filter
+		if v.Store(&filter) == nil {
+			r.Filter = &filter
+		}
+	}
+	if v, found := results["writable"]; found {
+		v.Store(&r.Writable)
+	}
+	return r, nil
+}
+
+// dialogOptions returns the options shared by the file chooser methods.
+func dialogOptions(acceptLabel string, nonModal bool, filters []Filter, current *Filter, choices []Choice) map[string]dbus.Variant {
+	options := make(map[string]dbus.Variant)
+	if acceptLabel != "" {
+		options["accept_label"] = dbus.MakeVariant(acceptLabel)
+	}
+	if nonModal {
+		options["modal"] = dbus.MakeVariant(false)
+	}
+	if len(filters) > 0 {
+		options["filters"] = dbus.MakeVariant(filters)
+	}
+	if current != nil {
+		options["current_filter"] = dbus.MakeVariant(*current)
+	}
+	if len(choices) > 0 {
+		options["choices"] = dbus.MakeVariant(choices)
+	}
+	return options
+}
+
+// bytePath encodes a path as the portals expect it: a nul-terminated byte string.
+func bytePath(path string) []byte {
+	return append([]byte(path), 0)
+}
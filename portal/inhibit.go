@@ -0,0 +1,149 @@
+/*
+	libxdg-go - An implementaion of various freedesktop specifications in go
+    Copyright (C) 2025 MiracleOS Contributors
+
+    This program is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    This program is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+*/
+
+package portal
+
+import (
+	"context"
+
+	"github.com/godbus/dbus/v5"
+)
+
+const inhibitInterface = "org.freedesktop.portal.Inhibit"
+
+// InhibitFlags is a bit mask of the actions an inhibitor blocks.
+type InhibitFlags uint32
+
+const (
+	InhibitLogout InhibitFlags = 1 << iota
+	InhibitUserSwitch
+	InhibitSuspend
+	// InhibitIdle keeps the session from going idle, which blanks or locks the
+	// screen.
+	InhibitIdle
+)
+
+// SessionState is the state of the user's login session.
+type SessionState uint32
+
+const (
+	SessionRunning SessionState = iota + 1
+	// SessionQueryEnd is when the session is about to end and applications
+	// may inhibit it; see InhibitMonitor.QueryEndResponse.
+	SessionQueryEnd
+	SessionEnding
+)
+
+func (s SessionState) String() string {
+	switch s {
+	case SessionRunning:
+		return "running"
+	case SessionQueryEnd:
+		return "query-end"
+	case SessionEnding:
+		return "ending"
+	default:
+		return "unknown"
+	}
+}
+
+// SessionStateChange is a change of the session state sent to an InhibitMonitor.
+type SessionStateChange struct {
+	ScreensaverActive bool
+	State             SessionState
+}
+
+// Inhibitor blocks actions of the session until released.
+type Inhibitor struct {
+	r *pendingRequest
+}
+
+// Inhibit blocks the actions in flags, giving reason to the user. parentWindow is
+// as described for OpenOptions; the portal may only honor inhibitors of visible
+// windows. The inhibition lasts until Release is called or the client closes.
+func (c *Client) Inhibit(parentWindow string, flags InhibitFlags, reason string) (*Inhibitor, error) {
+	options := make(map[string]dbus.Variant)
+	if reason != "" {
+		options["reason"] = dbus.MakeVariant(reason)
+	}
+	r, err := c.startRequest(context.Background(), inhibitInterface, "Inhibit", options, parentWindow, uint32(flags))
+	if err != nil {
+		return nil, err
+	}
+	// Unlike other requests, the portal doesn't answer with a response; the
+	// request object stands for the inhibition.
+	r.forget()
+	return &Inhibitor{r}, nil
+}
+
+// Release ends the inhibition.
+func (i *Inhibitor) Release() error {
+	return i.r.close()
+}
+
+// InhibitMonitor is a session of the Inhibit portal reporting the session state.
+type InhibitMonitor struct {
+	*Session
+	// States receives the session state changes until the monitor's context is done.
+	States <-chan SessionStateChange
+}
+
+// MonitorSession starts monitoring the session state. Monitoring stops, and the
+// portal session is closed, when ctx is done.
+func (c *Client) MonitorSession(ctx context.Context, parentWindow string) (*InhibitMonitor, error) {
+	// The first state change may follow the response immediately.
+	sub, err := c.subscribe(objectPath, inhibitInterface, "StateChanged")
+	if err != nil {
+		return nil, err
+	}
+	s, _, err := c.CreateSession(ctx, inhibitInterface, "CreateMonitor", nil, parentWindow)
+	if err != nil {
+		c.unsubscribe(sub)
+		return nil, err
+	}
+
+	decode := func(sig *dbus.Signal) (SessionStateChange, bool) {
+		if len(sig.Body) < 2 {
+			return SessionStateChange{}, false
+		}
+		if path, _ := sig.Body[0].(dbus.ObjectPath); path != s.Path {
+			return SessionStateChange{}, false
+		}
+		state, _ := sig.Body[1].(map[string]dbus.Variant)
+		change := SessionStateChange{}
+		if v, found := state["screensaver-active"]; found {
+			v.Store(&change.ScreensaverActive)
+		}
+		if v, found := state["session-state"]; found {
+			var state uint32
+			v.Store(&state)
+			change.State = SessionState(state)
+		}
+		return change, true
+	}
+	stop := func() { s.Close() }
+	return &InhibitMonitor{s, forward(ctx, c, sub, decode, stop)}, nil
+}
+
+// QueryEndResponse acknowledges a SessionQueryEnd state, after adding any
+// inhibitors that should keep the session from ending. The session ends
+// regardless after a while if the application doesn't respond.
+func (m *InhibitMonitor) QueryEndResponse() error {
+	return m.c.obj.Call(inhibitInterface+".QueryEndResponse", 0, m.Path).Err
+}
@@ -0,0 +1,175 @@
+/*
+	libxdg-go - An implementaion of various freedesktop specifications in go
+    Copyright (C) 2025 MiracleOS Contributors
+
+    This program is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    This program is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+*/
+
+// Package portal is a client for the XDG desktop portals, the D-Bus interfaces
+// sandboxed applications use to reach the desktop, served by xdg-desktop-portal on
+// the session bus. They work for unsandboxed applications as well.
+//
+// Each portal has typed methods on Client. Portals without them can be used
+// through the same plumbing: Request calls methods answering through a Request
+// object, CreateSession starts sessions, and Version tells which methods and
+// options a portal supports.
+package portal
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/godbus/dbus/v5"
+)
+
+const (
+	busName    = "org.freedesktop.portal.Desktop"
+	objectPath = dbus.ObjectPath("/org/freedesktop/portal/desktop")
+
+	errUnknownMethod    = "org.freedesktop.DBus.Error.UnknownMethod"
+	errUnknownInterface = "org.freedesktop.DBus.Error.UnknownInterface"
+	errUnknownProperty  = "org.freedesktop.DBus.Error.UnknownProperty"
+	errInvalidArgs      = "org.freedesktop.DBus.Error.InvalidArgs"
+)
+
+var (
+	// ErrCancelled is returned when the user cancelled an interaction, or the
+	// request was cancelled through its context.
+	ErrCancelled = errors.New("portal request cancelled")
+	// ErrFailed is returned when a portal ended a request in some other way than
+	// success or cancellation.
+	ErrFailed = errors.New("portal request failed")
+	// ErrUnsupported is returned when the portal is missing, or too old for a
+	// method or option.
+	ErrUnsupported = errors.New("portal unsupported")
+)
+
+// Client talks to xdg-desktop-portal over the session bus.
+type Client struct {
+	conn     *dbus.Conn
+	obj      dbus.BusObject
+	sender   string
+	mu       sync.Mutex
+	pending  map[dbus.ObjectPath]chan response
+	subs     map[*subscription]bool
+	versions map[string]uint32
+	signals  chan *dbus.Signal
+}
+
+// NewClient connects to the session bus.
+func NewClient() (*Client, error) {
+	conn, err := dbus.ConnectSessionBus()
+	if err != nil {
+		return nil, err
+	}
+	return newClient(conn)
+}
+
+// NewClientWithConn creates a client on an existing connection.
+// The connection is closed by Client.Close.
+func NewClientWithConn(conn *dbus.Conn) (*Client, error) {
+	return newClient(conn)
+}
+
+func newClient(conn *dbus.Conn) (*Client, error) {
+	names := conn.Names()
+	if len(names) == 0 {
+		conn.Close()
+		return nil, errors.New("connection has no unique name")
+	}
+	c := &Client{
+		conn:     conn,
+		obj:      conn.Object(busName, objectPath),
+		sender:   strings.ReplaceAll(strings.TrimPrefix(names[0], ":"), ".", "_"),
+		pending:  make(map[dbus.ObjectPath]chan response),
+		subs:     make(map[*subscription]bool),
+		versions: make(map[string]uint32),
+		signals:  make(chan *dbus.Signal, 16),
+	}
+
+	err := conn.AddMatchSignal(
+		dbus.WithMatchInterface(requestInterface),
+		dbus.WithMatchMember("Response"),
+		dbus.WithMatchPathNamespace(dbus.ObjectPath(requestPrefix+c.sender)),
+	)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	conn.Signal(c.signals)
+	go c.dispatch()
+
+	return c, nil
+}
+
+// Close disconnects the client. Requests in progress fail, and sessions and
+// inhibitors end.
+func (c *Client) Close() error {
+	c.conn.RemoveSignal(c.signals)
+	return c.conn.Close()
+}
+
+// Conn returns the client's D-Bus connection.
+func (c *Client) Conn() *dbus.Conn {
+	return c.conn
+}
+
+// Version returns the version of a portal interface, such as
+// "org.freedesktop.portal.Settings", or 0 if the portal doesn't implement it.
+// Versions are cached for the life of the client.
+func (c *Client) Version(iface string) (uint32, error) {
+	return c.version(c.obj, iface)
+}
+
+func (c *Client) version(obj dbus.BusObject, iface string) (uint32, error) {
+	key := obj.Destination() + " " + iface
+	c.mu.Lock()
+	version, found := c.versions[key]
+	c.mu.Unlock()
+	if found {
+		return version, nil
+	}
+
+	v, err := obj.GetProperty(iface + ".version")
+	var dbusErr dbus.Error
+	switch {
+	case err == nil:
+		v.Store(&version)
+	case errors.As(err, &dbusErr) && (dbusErr.Name == errUnknownInterface || dbusErr.Name == errUnknownProperty ||
+		dbusErr.Name == errInvalidArgs || dbusErr.Name == errUnknownMethod):
+		// The portal doesn't implement the interface.
+	default:
+		return 0, err
+	}
+
+	c.mu.Lock()
+	c.versions[key] = version
+	c.mu.Unlock()
+	return version, nil
+}
+
+// requireVersion fails with ErrUnsupported if a portal interface is older than min.
+func (c *Client) requireVersion(obj dbus.BusObject, iface string, min uint32) error {
+	version, err := c.version(obj, iface)
+	if err != nil {
+		return err
+	}
+	if version < min {
+		return fmt.Errorf("%w: %s is version %d, version %d is needed", ErrUnsupported, iface, version, min)
+	}
+	return nil
+}
@@ -0,0 +1,159 @@
+/*
+	libxdg-go - An implementaion of various freedesktop specifications in go
+    Copyright (C) 2025 MiracleOS Contributors
+
+    This program is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    This program is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+*/
+
+package portal
+
+import (
+	"context"
+	"io"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/MiracleOS-Team/libxdg-go/notificationDaemon"
+	"github.com/godbus/dbus/v5"
+)
+
+const notificationInterface = "org.freedesktop.portal.Notification"
+
+// maxIconSize bounds the icon files sent inline with a notification.
+const maxIconSize = 4 << 20
+
+// NotificationAction is an action the user invoked on a notification sent
+// through the portal.
+type NotificationAction struct {
+	// ID is the ID the notification was added with.
+	ID string
+	// Action is the action key, notificationDaemon.DefaultActionKey for the
+	// notification itself.
+	Action string
+	// Parameter holds the target of the action, if the portal passed one.
+	Parameter []dbus.Variant
+}
+
+// serializedIcon is a GIcon serialized as the portals expect it.
+type serializedIcon struct {
+	Kind  string
+	Value dbus.Variant
+}
+
+// AddNotification sends a notification through the Notification portal, replacing
+// the one the application added earlier with the same id.
+//
+// Summary, Body, the urgency and category hints, the actions and the icon, from
+// the image-path hint or AppIcon, are passed on. Body is shown as plain text.
+// Other fields and hints have no portal equivalent and are ignored.
+func (c *Client) AddNotification(id string, n notificationDaemon.Notification) error {
+	return c.obj.Call(notificationInterface+".AddNotification", 0, id, toPortal(n)).Err
+}
+
+// RemoveNotification withdraws a notification sent with AddNotification.
+func (c *Client) RemoveNotification(id string) error {
+	return c.obj.Call(notificationInterface+".RemoveNotification", 0, id).Err
+}
+
+// WatchNotificationActions sends the actions invoked on the application's
+// notifications until ctx is done.
+func (c *Client) WatchNotificationActions(ctx context.Context) (<-chan NotificationAction, error) {
+	return watch(ctx, c, notificationInterface, "ActionInvoked", func(sig *dbus.Signal) (NotificationAction, bool) {
+		// The body is the application ID, the notification ID, the action and its
+		// parameter.
+		if len(sig.Body) < 3 {
+			return NotificationAction{}, false
+		}
+		action := NotificationAction{}
+		action.ID, _ = sig.Body[1].(string)
+		action.Action, _ = sig.Body[2].(string)
+		if len(sig.Body) > 3 {
+			action.Parameter, _ = sig.Body[3].([]dbus.Variant)
+		}
+		return action, true
+	})
+}
+
+// toPortal converts a notification to the vardict of AddNotification.
+func toPortal(n notificationDaemon.Notification) map[string]dbus.Variant {
+	notification := map[string]dbus.Variant{
+		"title": dbus.MakeVariant(n.Summary),
+	}
+	if n.Body != "" {
+		notification["body"] = dbus.MakeVariant(n.Body)
+	}
+
+	switch n.Urgency() {
+	case notificationDaemon.UrgencyLow:
+		notification["priority"] = dbus.MakeVariant("low")
+	case notificationDaemon.UrgencyCritical:
+		notification["priority"] = dbus.MakeVariant("urgent")
+	}
+	if category := n.Category(); category != "" {
+		notification["category"] = dbus.MakeVariant(category)
+	}
+
+	icon := n.ImagePath()
+	if icon == "" {
+		icon = n.AppIcon
+	}
+	if serialized, ok := portalIcon(icon); ok {
+		notification["icon"] = dbus.MakeVariant(serialized)
+	}
+
+	var buttons []map[string]dbus.Variant
+	for i := 0; i+1 < len(n.Actions); i += 2 {
+		key, label := n.Actions[i], n.Actions[i+1]
+		if key == notificationDaemon.DefaultActionKey {
+			notification["default-action"] = dbus.MakeVariant(key)
+			continue
+		}
+		buttons = append(buttons, map[string]dbus.Variant{
+			"label":  dbus.MakeVariant(label),
+			"action": dbus.MakeVariant(key),
+		})
+	}
+	if len(buttons) > 0 {
+		notification["buttons"] = dbus.MakeVariant(buttons)
+	}
+	return notification
+}
+
+// portalIcon serializes an icon name, path or file:// URI. Files are sent inline
+// since the portal can't read the application's files.
+func portalIcon(icon string) (serializedIcon, bool) {
+	if icon == "" {
+		return serializedIcon{}, false
+	}
+	path := icon
+	if u, err := url.Parse(icon); err == nil && u.Scheme == "file" {
+		path = u.Path
+	}
+	if !strings.HasPrefix(path, "/") {
+		return serializedIcon{"themed", dbus.MakeVariant([]string{icon})}, true
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return serializedIcon{}, false
+	}
+	defer f.Close()
+	data, err := io.ReadAll(io.LimitReader(f, maxIconSize))
+	if err != nil || len(data) == 0 {
+		return serializedIcon{}, false
+	}
+	return serializedIcon{"bytes", dbus.MakeVariant(data)}, true
+}
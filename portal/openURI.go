@@ -0,0 +1,94 @@
+/*
+	libxdg-go - An implementaion of various freedesktop specifications in go
+    Copyright (C) 2025 MiracleOS Contributors
+
+    This program is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    This program is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+*/
+
+package portal
+
+import (
+	"context"
+	"os"
+
+	"github.com/godbus/dbus/v5"
+)
+
+const openURIInterface = "org.freedesktop.portal.OpenURI"
+
+// OpenOptions are the options of the OpenURI portal methods.
+//
+// The parentWindow argument of the methods identifies the window the portal's
+// dialogs are transient for: "x11:" followed by a hexadecimal XID, "wayland:"
+// followed by an exported xdg-foreign handle, or empty.
+type OpenOptions struct {
+	// Writable asks for the application opening a file to be given write access.
+	// It is ignored by OpenURI for non-file URIs and by OpenDirectory.
+	Writable bool
+	// Ask makes the portal let the user choose the application, even if a
+	// default one is set. It is ignored by OpenDirectory.
+	Ask bool
+	// ActivationToken is an xdg-activation token for focusing the application.
+	ActivationToken string
+}
+
+func (o OpenOptions) variants(directory bool) map[string]dbus.Variant {
+	options := make(map[string]dbus.Variant)
+	if o.Writable && !directory {
+		options["writable"] = dbus.MakeVariant(true)
+	}
+	if o.Ask && !directory {
+		options["ask"] = dbus.MakeVariant(true)
+	}
+	if o.ActivationToken != "" {
+		options["activation_token"] = dbus.MakeVariant(o.ActivationToken)
+	}
+	return options
+}
+
+// OpenURI opens a URI with the application the user chooses or has set as default.
+// Local files are better opened with OpenFile, which works for files the sandbox
+// can't name.
+func (c *Client) OpenURI(ctx context.Context, parentWindow, uri string, opts OpenOptions) error {
+	_, err := c.Request(ctx, openURIInterface, "OpenURI", opts.variants(false), parentWindow, uri)
+	return err
+}
+
+// OpenFile opens a local file, passing it to the portal as a file descriptor.
+func (c *Client) OpenFile(ctx context.Context, parentWindow, path string, opts OpenOptions) error {
+	return c.openFD(ctx, "OpenFile", parentWindow, path, opts.variants(false))
+}
+
+// OpenDirectory opens the directory containing a local file in the file manager,
+// with the file selected if the file manager supports it.
+func (c *Client) OpenDirectory(ctx context.Context, parentWindow, path string, opts OpenOptions) error {
+	if err := c.requireVersion(c.obj, openURIInterface, 3); err != nil {
+		return err
+	}
+	return c.openFD(ctx, "OpenDirectory", parentWindow, path, opts.variants(true))
+}
+
+func (c *Client) openFD(ctx context.Context, method, parentWindow, path string, options map[string]dbus.Variant) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	// The descriptor is duplicated into the message, so the file may be closed
+	// once the call returns.
+	defer f.Close()
+
+	_, err = c.Request(ctx, openURIInterface, method, options, parentWindow, dbus.UnixFD(f.Fd()))
+	return err
+}
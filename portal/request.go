@@ -0,0 +1,141 @@
+/*
+	libxdg-go - An implementaion of various freedesktop specifications in go
+    Copyright (C) 2025 MiracleOS Contributors
+
+    This program is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    This program is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+*/
+
+package portal
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+
+	"github.com/godbus/dbus/v5"
+)
+
+const (
+	requestInterface = "org.freedesktop.portal.Request"
+	requestPrefix    = "/org/freedesktop/portal/desktop/request/"
+)
+
+// Response codes of org.freedesktop.portal.Request.Response.
+const (
+	responseSuccess   = 0
+	responseCancelled = 1
+)
+
+// response is the outcome of a request, as sent by the Response signal.
+type response struct {
+	code    uint32
+	results map[string]dbus.Variant
+}
+
+// pendingRequest is a Request object awaiting its response.
+type pendingRequest struct {
+	c    *Client
+	path dbus.ObjectPath
+	ch   chan response
+}
+
+// newToken returns a handle token, unique enough for the requests and sessions
+// of a connection.
+func newToken() string {
+	b := make([]byte, 8)
+	rand.Read(b)
+	return "libxdg_go_" + hex.EncodeToString(b)
+}
+
+// Request calls a method of a portal interface that answers through a Request
+// object, and waits for the response. options, the method's vardict, must be its
+// last argument and follow args; a handle_token is added to it. The results of the
+// response are returned, along with ErrCancelled or ErrFailed if the request
+// didn't succeed. Cancelling ctx closes the request.
+func (c *Client) Request(ctx context.Context, iface, method string, options map[string]dbus.Variant, args ...any) (map[string]dbus.Variant, error) {
+	r, err := c.startRequest(ctx, iface, method, options, args...)
+	if err != nil {
+		return nil, err
+	}
+	return r.wait(ctx)
+}
+
+// startRequest calls a method answering through a Request object.
+func (c *Client) startRequest(ctx context.Context, iface, method string, options map[string]dbus.Variant, args ...any) (*pendingRequest, error) {
+	token := newToken()
+	r := &pendingRequest{
+		c:    c,
+		path: dbus.ObjectPath(requestPrefix + c.sender + "/" + token),
+		ch:   make(chan response, 1),
+	}
+	if options == nil {
+		options = make(map[string]dbus.Variant)
+	}
+	options["handle_token"] = dbus.MakeVariant(token)
+
+	// The Response signal may follow the method return immediately, so the request
+	// is registered before calling.
+	c.mu.Lock()
+	c.pending[r.path] = r.ch
+	c.mu.Unlock()
+
+	var handle dbus.ObjectPath
+	err := c.obj.CallWithContext(ctx, iface+"."+method, 0, append(args, options)...).Store(&handle)
+	if err != nil {
+		r.forget()
+		return nil, err
+	}
+	if handle != r.path {
+		// Portals older than version 0.9 ignore handle_token.
+		c.mu.Lock()
+		delete(c.pending, r.path)
+		c.pending[handle] = r.ch
+		c.mu.Unlock()
+		r.path = handle
+	}
+	return r, nil
+}
+
+// wait waits for the response of a request.
+func (r *pendingRequest) wait(ctx context.Context) (map[string]dbus.Variant, error) {
+	defer r.forget()
+	select {
+	case resp := <-r.ch:
+		switch resp.code {
+		case responseSuccess:
+			return resp.results, nil
+		case responseCancelled:
+			return resp.results, ErrCancelled
+		default:
+			return resp.results, ErrFailed
+		}
+	case <-ctx.Done():
+		r.close()
+		return nil, errors.Join(ErrCancelled, ctx.Err())
+	}
+}
+
+// forget stops waiting for the response of a request.
+func (r *pendingRequest) forget() {
+	r.c.mu.Lock()
+	delete(r.c.pending, r.path)
+	r.c.mu.Unlock()
+}
+
+// close closes the Request object, ending the interaction it stands for.
+func (r *pendingRequest) close() error {
+	return r.c.conn.Object(busName, r.path).Call(requestInterface+".Close", 0).Err
+}
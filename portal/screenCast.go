@@ -0,0 +1,232 @@
+/*
+	libxdg-go - An implementaion of various freedesktop specifications in go
+    Copyright (C) 2025 MiracleOS Contributors
+
+    This program is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    This program is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+*/
+
+package portal
+
+import (
+	"context"
+	"image"
+	"os"
+
+	"github.com/godbus/dbus/v5"
+)
+
+const (
+	screenCastInterface = "org.freedesktop.portal.ScreenCast"
+)
+
+// SourceType is a bit mask of the kinds of content a screen cast can capture.
+type SourceType uint32
+
+const (
+	SourceMonitor SourceType = 1 << iota
+	SourceWindow
+	SourceVirtual
+)
+
+// CursorMode is a bit mask of the ways a screen cast can show the cursor.
+type CursorMode uint32
+
+const (
+	// CursorHidden leaves the cursor out of the stream.
+	CursorHidden CursorMode = 1 << iota
+	// CursorEmbedded draws the cursor into the stream.
+	CursorEmbedded
+	// CursorMetadata sends the cursor as stream metadata.
+	CursorMetadata
+)
+
+// PersistMode is how long the portal remembers the sources the user selected.
+type PersistMode uint32
+
+const (
+	// PersistNone forgets them when the session ends.
+	PersistNone PersistMode = iota
+	// PersistTransient remembers them while the application runs.
+	PersistTransient
+	// PersistUntilRevoked remembers them until the user revokes the permission.
+	PersistUntilRevoked
+)
+
+// ScreenCastOptions are the options of ScreenCastSession.SelectSources.
+type ScreenCastOptions struct {
+	// Types are the kinds of sources to offer, SourceMonitor if zero.
+	Types SourceType
+	// Multiple lets the user select several sources.
+	Multiple bool
+	// CursorMode is how to show the cursor; zero leaves it to the portal.
+	CursorMode CursorMode
+	// RestoreToken restores the sources of an earlier session, from
+	// ScreenCastStart.RestoreToken, without asking the user again.
+	RestoreToken string
+	PersistMode  PersistMode
+}
+
+// Stream is a PipeWire stream of a started screen cast.
+type Stream struct {
+	// NodeID is the PipeWire node to connect to, on the remote returned by
+	// ScreenCastSession.OpenPipeWireRemote.
+	NodeID uint32
+	// ID identifies the stream across sessions restored with the same token.
+	ID string
+	// Position is the position of a monitor in the compositor space, and Size the
+	// size of the stream; either is zero if the portal didn't send it.
+	Position image.Point
+	Size     image.Point
+	// SourceType is the kind of the source, a single bit.
+	SourceType SourceType
+	// MappingID identifies the source for input devices of a remote desktop
+	// session.
+	MappingID string
+}
+
+// ScreenCastStart is the result of starting a screen cast.
+type ScreenCastStart struct {
+	Streams []Stream
+	// RestoreToken restores the same sources in a later session, when a
+	// PersistMode was requested and the portal granted it.
+	RestoreToken string
+}
+
+// ScreenCastSession is a session of the ScreenCast portal.
+type ScreenCastSession struct {
+	*Session
+}
+
+// CreateScreenCastSession starts a screen cast session. Sources are then chosen
+// with SelectSources and streamed after Start.
+func (c *Client) CreateScreenCastSession(ctx context.Context) (*ScreenCastSession, error) {
+	s, _, err := c.CreateSession(ctx, screenCastInterface, "CreateSession", nil)
+	if err != nil {
+		return nil, err
+	}
+	return &ScreenCastSession{s}, nil
+}
+
+// SelectSources asks the user for the sources to capture, unless the restore
+// token in opts is still valid.
+func (s *ScreenCastSession) SelectSources(ctx context.Context, opts ScreenCastOptions) error {
+	options := make(map[string]dbus.Variant)
+	if opts.Types != 0 {
+		options["types"] = dbus.MakeVariant(uint32(opts.Types))
+	}
+	if opts.Multiple {
+		options["multiple"] = dbus.MakeVariant(true)
+	}
+	if opts.CursorMode != 0 {
+		options["cursor_mode"] = dbus.MakeVariant(uint32(opts.CursorMode))
+	}
+	if opts.RestoreToken != "" || opts.PersistMode != PersistNone {
+		if err := s.c.requireVersion(s.c.obj, screenCastInterface, 4); err != nil {
+			return err
+		}
+	}
+	if opts.RestoreToken != "" {
+		options["restore_token"] = dbus.MakeVariant(opts.RestoreToken)
+	}
+	if opts.PersistMode != PersistNone {
+		options["persist_mode"] = dbus.MakeVariant(uint32(opts.PersistMode))
+	}
+	_, err := s.c.Request(ctx, screenCastInterface, "SelectSources", options, s.Path)
+	return err
+}
+
+// Start starts streaming the selected sources. parentWindow is as described for
+// OpenOptions.
+func (s *ScreenCastSession) Start(ctx context.Context, parentWindow string) (ScreenCastStart, error) {
+	results, err := s.c.Request(ctx, screenCastInterface, "Start", nil, s.Path, parentWindow)
+	if err != nil {
+		return ScreenCastStart{}, err
+	}
+
+	var start ScreenCastStart
+	if v, found := results["restore_token"]; found {
+		v.Store(&start.RestoreToken)
+	}
+	var streams []struct {
+		Node       uint32
+		Properties map[string]dbus.Variant
+	}
+	if v, found := results["streams"]; found {
+		v.Store(&streams)
+	}
+	for _, raw := range streams {
+		stream := Stream{NodeID: raw.Node}
+		props := raw.Properties
+		if v, found := props["id"]; found {
+			v.Store(&stream.ID)
+		}
+		if v, found := props["position"]; found {
+			stream.Position = decodePoint(v)
+		}
+		if v, found := props["size"]; found {
+			stream.Size = decodePoint(v)
+		}
+		if v, found := props["source_type"]; found {
+			var t uint32
+			v.Store(&t)
+			stream.SourceType = SourceType(t)
+		}
+		if v, found := props["mapping_id"]; found {
+			v.Store(&stream.MappingID)
+		}
+		start.Streams = append(start.Streams, stream)
+	}
+	return start, nil
+}
+
+// OpenPipeWireRemote returns a connection to the PipeWire remote the session's
+// streams can be consumed from. The caller owns the file.
+func (s *ScreenCastSession) OpenPipeWireRemote() (*os.File, error) {
+	var fd dbus.UnixFD
+	err := s.c.obj.Call(screenCastInterface+".OpenPipeWireRemote", 0, s.Path, map[string]dbus.Variant{}).Store(&fd)
+	if err != nil {
+		return nil, err
+	}
+	return os.NewFile(uintptr(fd), "pipewire-remote"), nil
+}
+
+// ScreenCastSources returns the kinds of sources the portal can capture.
+func (c *Client) ScreenCastSources() (SourceType, error) {
+	v, err := c.obj.GetProperty(screenCastInterface + ".AvailableSourceTypes")
+	if err != nil {
+		return 0, err
+	}
+	var types uint32
+	err = v.Store(&types)
+	return SourceType(types), err
+}
+
+// ScreenCastCursorModes returns the cursor modes the portal supports.
+func (c *Client) ScreenCastCursorModes() (CursorMode, error) {
+	v, err := c.obj.GetProperty(screenCastInterface + ".AvailableCursorModes")
+	if err != nil {
+		return 0, err
+	}
+	var modes uint32
+	err = v.Store(&modes)
+	return CursorMode(modes), err
+}
+
+// decodePoint decodes an (ii) pair.
+func decodePoint(v dbus.Variant) image.Point {
+	var p struct{ X, Y int32 }
+	v.Store(&p)
+	return image.Pt(int(p.X), int(p.Y))
+}
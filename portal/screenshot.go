@@ -0,0 +1,80 @@
+/*
+	libxdg-go - An implementaion of various freedesktop specifications in go
+    Copyright (C) 2025 MiracleOS Contributors
+
+    This program is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    This program is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+*/
+
+package portal
+
+import (
+	"context"
+	"errors"
+	"image/color"
+	"math"
+
+	"github.com/godbus/dbus/v5"
+)
+
+const screenshotInterface = "org.freedesktop.portal.Screenshot"
+
+// ScreenshotOptions are the options of Screenshot.
+type ScreenshotOptions struct {
+	// Interactive lets the user choose what to capture, such as a window or an
+	// area, before taking the screenshot.
+	Interactive bool
+	// NonModal makes the portal's dialog independent of the parent window.
+	NonModal bool
+}
+
+// Screenshot takes a screenshot and returns the URI of the image, usually a PNG
+// file the caller may move elsewhere. parentWindow is as described for OpenOptions.
+func (c *Client) Screenshot(ctx context.Context, parentWindow string, opts ScreenshotOptions) (string, error) {
+	options := make(map[string]dbus.Variant)
+	if opts.Interactive {
+		options["interactive"] = dbus.MakeVariant(true)
+	}
+	if opts.NonModal {
+		options["modal"] = dbus.MakeVariant(false)
+	}
+	results, err := c.Request(ctx, screenshotInterface, "Screenshot", options, parentWindow)
+	if err != nil {
+		return "", err
+	}
+	uri, _ := results["uri"].Value().(string)
+	if uri == "" {
+		return "", errors.Join(ErrFailed, errors.New("no screenshot URI in the response"))
+	}
+	return uri, nil
+}
+
+// PickColor lets the user pick the color of a pixel on the screen.
+func (c *Client) PickColor(ctx context.Context, parentWindow string) (color.RGBA64, error) {
+	results, err := c.Request(ctx, screenshotInterface, "PickColor", nil, parentWindow)
+	if err != nil {
+		return color.RGBA64{}, err
+	}
+	var rgb struct{ R, G, B float64 }
+	v, found := results["color"]
+	if !found || v.Store(&rgb) != nil {
+		return color.RGBA64{}, errors.Join(ErrFailed, errors.New("no color in the response"))
+	}
+	return color.RGBA64{R: component(rgb.R), G: component(rgb.G), B: component(rgb.B), A: 0xffff}, nil
+}
+
+// component converts a color component in [0, 1] to 16 bits.
+func component(v float64) uint16 {
+	return uint16(math.Round(min(max(v, 0), 1) * 0xffff))
+}
@@ -0,0 +1,104 @@
+/*
+	libxdg-go - An implementaion of various freedesktop specifications in go
+    Copyright (C) 2025 MiracleOS Contributors
+
+    This program is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    This program is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+*/
+
+package portal
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/godbus/dbus/v5"
+)
+
+const sessionInterface = "org.freedesktop.portal.Session"
+
+// Session is a portal session, which lasts until it is closed by either side or
+// the client disconnects.
+type Session struct {
+	c         *Client
+	Path      dbus.ObjectPath
+	closeOnce sync.Once
+	closing   chan struct{}
+	closed    chan struct{}
+}
+
+// CreateSession calls a method of a portal interface creating a session, such as
+// ScreenCast.CreateSession, as Request does. A session_handle_token is added to
+// options. The results of the response are returned with the session.
+func (c *Client) CreateSession(ctx context.Context, iface, method string, options map[string]dbus.Variant, args ...any) (*Session, map[string]dbus.Variant, error) {
+	if options == nil {
+		options = make(map[string]dbus.Variant)
+	}
+	options["session_handle_token"] = dbus.MakeVariant(newToken())
+	results, err := c.Request(ctx, iface, method, options, args...)
+	if err != nil {
+		return nil, results, err
+	}
+
+	// The handle is a string rather than an object path in the response.
+	var path dbus.ObjectPath
+	switch value := results["session_handle"].Value().(type) {
+	case string:
+		path = dbus.ObjectPath(value)
+	case dbus.ObjectPath:
+		path = value
+	}
+	if !path.IsValid() {
+		return nil, results, errors.Join(ErrFailed, errors.New("no session handle in the response"))
+	}
+
+	s := &Session{
+		c:       c,
+		Path:    path,
+		closing: make(chan struct{}),
+		closed:  make(chan struct{}),
+	}
+	sub, err := c.subscribe(path, sessionInterface, "Closed")
+	if err != nil {
+		s.Close()
+		close(s.closed)
+		return nil, results, err
+	}
+	go func() {
+		select {
+		case <-sub.ch:
+		case <-s.closing:
+		}
+		c.unsubscribe(sub)
+		close(s.closed)
+	}()
+	return s, results, nil
+}
+
+// Close ends the session.
+func (s *Session) Close() error {
+	var err error
+	s.closeOnce.Do(func() {
+		close(s.closing)
+		err = s.c.conn.Object(busName, s.Path).Call(sessionInterface+".Close", 0).Err
+	})
+	return err
+}
+
+// Closed is closed once the session has ended, whether through Close or by the
+// portal.
+func (s *Session) Closed() <-chan struct{} {
+	return s.closed
+}
@@ -0,0 +1,254 @@
+/*
+	libxdg-go - An implementaion of various freedesktop specifications in go
+    Copyright (C) 2025 MiracleOS Contributors
+
+    This program is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    This program is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+*/
+
+package portal
+
+import (
+	"context"
+	"errors"
+	"image/color"
+	"strings"
+
+	"github.com/godbus/dbus/v5"
+)
+
+const (
+	settingsInterface = "org.freedesktop.portal.Settings"
+	// AppearanceNamespace is the settings namespace of the desktop-agnostic
+	// appearance preferences.
+	AppearanceNamespace = "org.freedesktop.appearance"
+
+	errNotFound = "org.freedesktop.portal.Error.NotFound"
+)
+
+// ErrNotFound is returned when reading a setting the portal doesn't know.
+var ErrNotFound = errors.New("portal setting not found")
+
+// ColorScheme is the user's preferred color scheme.
+type ColorScheme uint32
+
+const (
+	ColorSchemeDefault ColorScheme = iota
+	ColorSchemePreferDark
+	ColorSchemePreferLight
+)
+
+func (s ColorScheme) String() string {
+	switch s {
+	case ColorSchemePreferDark:
+		return "prefer-dark"
+	case ColorSchemePreferLight:
+		return "prefer-light"
+	default:
+		return "default"
+	}
+}
+
+// Contrast is the user's preferred contrast level.
+type Contrast uint32
+
+const (
+	ContrastNormal Contrast = iota
+	ContrastHigh
+)
+
+func (c Contrast) String() string {
+	if c == ContrastHigh {
+		return "high"
+	}
+	return "normal"
+}
+
+// AccentColor is the user's preferred accent color, with components in [0, 1].
+type AccentColor struct {
+	R, G, B float64
+}
+
+// RGBA implements color.Color.
+func (a AccentColor) RGBA() (r, g, b, alpha uint32) {
+	return uint32(component(a.R)), uint32(component(a.G)), uint32(component(a.B)), 0xffff
+}
+
+var _ color.Color = AccentColor{}
+
+// valid reports whether the components are in range; the portal sends out of
+// range values when no accent color is set.
+func (a AccentColor) valid() bool {
+	for _, v := range []float64{a.R, a.G, a.B} {
+		if v < 0 || v > 1 {
+			return false
+		}
+	}
+	return true
+}
+
+// SettingChange is a setting changed while watching settings.
+type SettingChange struct {
+	Namespace string
+	Key       string
+	Value     dbus.Variant
+}
+
+// ColorScheme returns the new color scheme if the change is to color-scheme.
+func (s SettingChange) ColorScheme() (ColorScheme, bool) {
+	if s.Namespace != AppearanceNamespace || s.Key != "color-scheme" {
+		return 0, false
+	}
+	return decodeColorScheme(s.Value), true
+}
+
+// AccentColor returns the new accent color if the change is to accent-color. The
+// color is not valid when the user unset it.
+func (s SettingChange) AccentColor() (color AccentColor, valid bool, changed bool) {
+	if s.Namespace != AppearanceNamespace || s.Key != "accent-color" {
+		return AccentColor{}, false, false
+	}
+	color, valid = decodeAccentColor(s.Value)
+	return color, valid, true
+}
+
+// Contrast returns the new contrast if the change is to contrast.
+func (s SettingChange) Contrast() (Contrast, bool) {
+	if s.Namespace != AppearanceNamespace || s.Key != "contrast" {
+		return 0, false
+	}
+	return decodeContrast(s.Value), true
+}
+
+// ReadAllSettings returns the settings of the given namespaces, by namespace then
+// key. A namespace ending in "*" matches those it is a prefix of, and no namespace
+// at all matches every one.
+func (c *Client) ReadAllSettings(namespaces ...string) (map[string]map[string]dbus.Variant, error) {
+	if namespaces == nil {
+		namespaces = []string{}
+	}
+	var settings map[string]map[string]dbus.Variant
+	err := c.obj.Call(settingsInterface+".ReadAll", 0, namespaces).Store(&settings)
+	return settings, err
+}
+
+// ReadSetting returns the value of a setting, or ErrNotFound.
+func (c *Client) ReadSetting(namespace, key string) (dbus.Variant, error) {
+	version, err := c.Version(settingsInterface)
+	if err != nil {
+		return dbus.Variant{}, err
+	}
+	var value dbus.Variant
+	if version >= 2 {
+		err = c.obj.Call(settingsInterface+".ReadOne", 0, namespace, key).Store(&value)
+	} else {
+		// Read, the only method of version 1, wraps the value in a second variant.
+		err = c.obj.Call(settingsInterface+".Read", 0, namespace, key).Store(&value)
+		if inner, ok := value.Value().(dbus.Variant); ok {
+			value = inner
+		}
+	}
+	var dbusErr dbus.Error
+	if errors.As(err, &dbusErr) && dbusErr.Name == errNotFound {
+		return dbus.Variant{}, ErrNotFound
+	}
+	return value, err
+}
+
+// ColorScheme returns the preferred color scheme, ColorSchemeDefault if there is none.
+func (c *Client) ColorScheme() (ColorScheme, error) {
+	v, err := c.ReadSetting(AppearanceNamespace, "color-scheme")
+	if errors.Is(err, ErrNotFound) {
+		return ColorSchemeDefault, nil
+	}
+	if err != nil {
+		return ColorSchemeDefault, err
+	}
+	return decodeColorScheme(v), nil
+}
+
+// AccentColor returns the preferred accent color. found is false if there is none.
+func (c *Client) AccentColor() (color AccentColor, found bool, err error) {
+	v, err := c.ReadSetting(AppearanceNamespace, "accent-color")
+	if errors.Is(err, ErrNotFound) {
+		return AccentColor{}, false, nil
+	}
+	if err != nil {
+		return AccentColor{}, false, err
+	}
+	color, found = decodeAccentColor(v)
+	return color, found, nil
+}
+
+// Contrast returns the preferred contrast, ContrastNormal if there is none.
+func (c *Client) Contrast() (Contrast, error) {
+	v, err := c.ReadSetting(AppearanceNamespace, "contrast")
+	if errors.Is(err, ErrNotFound) {
+		return ContrastNormal, nil
+	}
+	if err != nil {
+		return ContrastNormal, err
+	}
+	return decodeContrast(v), nil
+}
+
+// WatchSettings sends the changes to settings of the given namespaces, which are
+// matched as by ReadAllSettings, until ctx is done.
+func (c *Client) WatchSettings(ctx context.Context, namespaces ...string) (<-chan SettingChange, error) {
+	return watch(ctx, c, settingsInterface, "SettingChanged", func(sig *dbus.Signal) (SettingChange, bool) {
+		if len(sig.Body) < 3 {
+			return SettingChange{}, false
+		}
+		change := SettingChange{}
+		change.Namespace, _ = sig.Body[0].(string)
+		change.Key, _ = sig.Body[1].(string)
+		change.Value, _ = sig.Body[2].(dbus.Variant)
+		return change, matchNamespace(namespaces, change.Namespace)
+	})
+}
+
+func matchNamespace(patterns []string, namespace string) bool {
+	if len(patterns) == 0 {
+		return true
+	}
+	for _, p := range patterns {
+		if prefix, found := strings.CutSuffix(p, "*"); found && strings.HasPrefix(namespace, prefix) || p == namespace {
+			return true
+		}
+	}
+	return false
+}
+
+func decodeColorScheme(v dbus.Variant) ColorScheme {
+	var s uint32
+	v.Store(&s)
+	if s > uint32(ColorSchemePreferLight) {
+		return ColorSchemeDefault
+	}
+	return ColorScheme(s)
+}
+
+func decodeContrast(v dbus.Variant) Contrast {
+	var c uint32
+	v.Store(&c)
+	return Contrast(min(c, uint32(ContrastHigh)))
+}
+
+func decodeAccentColor(v dbus.Variant) (AccentColor, bool) {
+	var a AccentColor
+	if v.Store(&a) != nil || !a.valid() {
+		return AccentColor{}, false
+	}
+	return a, true
+}
@@ -0,0 +1,148 @@
+/*
+	libxdg-go - An implementaion of various freedesktop specifications in go
+    Copyright (C) 2025 MiracleOS Contributors
+
+    This program is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    This program is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+*/
+
+package portal
+
+import (
+	"context"
+
+	"github.com/godbus/dbus/v5"
+)
+
+// subscription receives a signal of a portal object for as long as done is open.
+type subscription struct {
+	path    dbus.ObjectPath
+	name    string
+	options []dbus.MatchOption
+	ch      chan *dbus.Signal
+	done    chan struct{}
+}
+
+// subscribe starts receiving a signal of a portal object.
+func (c *Client) subscribe(path dbus.ObjectPath, iface, member string) (*subscription, error) {
+	s := &subscription{
+		path: path,
+		name: iface + "." + member,
+		options: []dbus.MatchOption{
+			dbus.WithMatchObjectPath(path),
+			dbus.WithMatchInterface(iface),
+			dbus.WithMatchMember(member),
+		},
+		ch:   make(chan *dbus.Signal, 16),
+		done: make(chan struct{}),
+	}
+	if err := c.conn.AddMatchSignal(s.options...); err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	c.subs[s] = true
+	c.mu.Unlock()
+	return s, nil
+}
+
+// unsubscribe stops a subscription.
+func (c *Client) unsubscribe(s *subscription) {
+	c.mu.Lock()
+	delete(c.subs, s)
+	c.mu.Unlock()
+	close(s.done)
+	c.conn.RemoveMatchSignal(s.options...)
+}
+
+// dispatch hands Response signals to the requests waiting for them, and other
+// signals to their subscriptions.
+func (c *Client) dispatch() {
+	for sig := range c.signals {
+		if sig.Name != requestInterface+".Response" {
+			c.publish(sig)
+			continue
+		}
+		if len(sig.Body) < 2 {
+			continue
+		}
+		code, _ := sig.Body[0].(uint32)
+		results, _ := sig.Body[1].(map[string]dbus.Variant)
+
+		c.mu.Lock()
+		ch, found := c.pending[sig.Path]
+		delete(c.pending, sig.Path)
+		c.mu.Unlock()
+		if found {
+			ch <- response{code, results}
+		}
+	}
+}
+
+func (c *Client) publish(sig *dbus.Signal) {
+	c.mu.Lock()
+	var subs []*subscription
+	for s := range c.subs {
+		if s.path == sig.Path && s.name == sig.Name {
+			subs = append(subs, s)
+		}
+	}
+	c.mu.Unlock()
+
+	for _, s := range subs {
+		select {
+		case s.ch <- sig:
+		case <-s.done:
+		}
+	}
+}
+
+// watch sends a signal of the portal object, decoded, until ctx is done. Signals
+// decode rejects are skipped.
+func watch[T any](ctx context.Context, c *Client, iface, member string, decode func(*dbus.Signal) (T, bool)) (<-chan T, error) {
+	sub, err := c.subscribe(objectPath, iface, member)
+	if err != nil {
+		return nil, err
+	}
+	return forward(ctx, c, sub, decode, nil), nil
+}
+
+// forward sends the signals of a subscription, decoded, until ctx is done, then
+// ends the subscription and calls stop if it is not nil.
+func forward[T any](ctx context.Context, c *Client, sub *subscription, decode func(*dbus.Signal) (T, bool), stop func()) <-chan T {
+	values := make(chan T)
+	go func() {
+		defer close(values)
+		defer c.unsubscribe(sub)
+		if stop != nil {
+			defer stop()
+		}
+		for {
+			select {
+			case sig := <-sub.ch:
+				value, ok := decode(sig)
+				if !ok {
+					continue
+				}
+				select {
+				case values <- value:
+				case <-ctx.Done():
+					return
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return values
+}
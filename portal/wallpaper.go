@@ -0,0 +1,76 @@
+/*
+	libxdg-go - An implementaion of various freedesktop specifications in go
+    Copyright (C) 2025 MiracleOS Contributors
+
+    This program is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    This program is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+*/
+
+package portal
+
+import (
+	"context"
+	"os"
+
+	"github.com/godbus/dbus/v5"
+)
+
+const wallpaperInterface = "org.freedesktop.portal.Wallpaper"
+
+// Where a wallpaper is set.
+const (
+	WallpaperBackground = "background"
+	WallpaperLockscreen = "lockscreen"
+	WallpaperBoth       = "both"
+)
+
+// WallpaperOptions are the options of the Wallpaper portal methods.
+type WallpaperOptions struct {
+	// ShowPreview lets the user preview the wallpaper and confirm it.
+	ShowPreview bool
+	// SetOn is WallpaperBackground, WallpaperLockscreen or WallpaperBoth, the
+	// latter if empty.
+	SetOn string
+}
+
+func (o WallpaperOptions) variants() map[string]dbus.Variant {
+	options := make(map[string]dbus.Variant)
+	if o.ShowPreview {
+		options["show-preview"] = dbus.MakeVariant(true)
+	}
+	if o.SetOn != "" {
+		options["set-on"] = dbus.MakeVariant(o.SetOn)
+	}
+	return options
+}
+
+// SetWallpaperURI sets the wallpaper to the image at a URI. parentWindow is as
+// described for OpenOptions.
+func (c *Client) SetWallpaperURI(ctx context.Context, parentWindow, uri string, opts WallpaperOptions) error {
+	_, err := c.Request(ctx, wallpaperInterface, "SetWallpaperURI", opts.variants(), parentWindow, uri)
+	return err
+}
+
+// SetWallpaperFile sets the wallpaper to a local image, passing it to the portal
+// as a file descriptor.
+func (c *Client) SetWallpaperFile(ctx context.Context, parentWindow, path string, opts WallpaperOptions) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	_, err = c.Request(ctx, wallpaperInterface, "SetWallpaperFile", opts.variants(), parentWindow, dbus.UnixFD(f.Fd()))
+	return err
+}
@@ -0,0 +1,115 @@
+/*
+	libxdg-go - An implementaion of various freedesktop specifications in go
+    Copyright (C) 2025 MiracleOS Contributors
+
+    This program is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    This program is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+*/
+
+package portalBackend
+
+import (
+	"github.com/MiracleOS-Team/libxdg-go/portal"
+	"github.com/godbus/dbus/v5"
+	"github.com/godbus/dbus/v5/introspect"
+)
+
+const accessInterface = "org.freedesktop.impl.portal.Access"
+
+// AccessDialog is a dialog asking the user to grant an application access to
+// something, which other portals show through the Access interface.
+type AccessDialog struct {
+	Title    string
+	Subtitle string
+	Body     string
+	// DenyLabel and GrantLabel are the labels of the buttons, if not the defaults.
+	DenyLabel  string
+	GrantLabel string
+	// Icon is the name of an icon to show.
+	Icon string
+	// Modal is whether the dialog should be modal for the parent window.
+	Modal bool
+	// Choices are extra controls to show, as in file chooser dialogs.
+	Choices []portal.Choice
+	// Options are the options of the call, for the ones not decoded above.
+	Options Options
+}
+
+// AccessHandler shows an access dialog. It returns ResponseSuccess if the user
+// granted access, and the IDs of the selected options by choice ID.
+type AccessHandler func(r *Request, dialog AccessDialog) (Response, map[string]string)
+
+// accessObject holds the methods of the Access interface.
+type accessObject struct {
+	b *Backend
+}
+
+func (o accessObject) AccessDialog(handle dbus.ObjectPath, appID, parentWindow, title, subtitle, body string, options map[string]dbus.Variant) (uint32, map[string]dbus.Variant, *dbus.Error) {
+	opts := Options(options)
+	dialog := AccessDialog{
+		Title:      title,
+		Subtitle:   subtitle,
+		Body:       body,
+		DenyLabel:  opts.String("deny_label"),
+		GrantLabel: opts.String("grant_label"),
+		Icon:       opts.String("icon"),
+		Modal:      opts.Bool("modal", true),
+		Options:    opts,
+	}
+	opts.Store("choices", &dialog.Choices)
+
+	return o.b.ServeRequest(handle, appID, parentWindow, func(r *Request) (Response, map[string]dbus.Variant) {
+		response, choices := o.b.config.Access(r, dialog)
+		results := make(map[string]dbus.Variant)
+		if len(choices) > 0 {
+			results["choices"] = dbus.MakeVariant(choiceResults(choices))
+		}
+		return response, results
+	})
+}
+
+// choiceResult is a selected choice, as returned in results.
+type choiceResult struct {
+	ID    string
+	Value string
+}
+
+// choiceResults encodes the selected choices for results.
+func choiceResults(choices map[string]string) []choiceResult {
+	list := make([]choiceResult, 0, len(choices))
+	for id, value := range choices {
+		list = append(list, choiceResult{id, value})
+	}
+	return list
+}
+
+var accessIntrospection = introspect.Interface{
+	Name: accessInterface,
+	Methods: []introspect.Method{
+		{
+			Name: "AccessDialog",
+			Args: []introspect.Arg{
+				{Name: "handle", Type: "o", Direction: "in"},
+				{Name: "app_id", Type: "s", Direction: "in"},
+				{Name: "parent_window", Type: "s", Direction: "in"},
+				{Name: "title", Type: "s", Direction: "in"},
+				{Name: "subtitle", Type: "s", Direction: "in"},
+				{Name: "body", Type: "s", Direction: "in"},
+				{Name: "options", Type: "a{sv}", Direction: "in"},
+				{Name: "response", Type: "u", Direction: "out"},
+				{Name: "results", Type: "a{sv}", Direction: "out"},
+			},
+		},
+	},
+}
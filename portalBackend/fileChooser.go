@@ -0,0 +1,176 @@
+/*
+	libxdg-go - An implementaion of various freedesktop specifications in go
+    Copyright (C) 2025 MiracleOS Contributors
+
+    This program is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    This program is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+*/
+
+package portalBackend
+
+import (
+	"github.com/MiracleOS-Team/libxdg-go/portal"
+	"github.com/godbus/dbus/v5"
+	"github.com/godbus/dbus/v5/introspect"
+)
+
+const fileChooserInterface = "org.freedesktop.impl.portal.FileChooser"
+
+// FileDialogKind is the method a file chooser dialog was requested through.
+type FileDialogKind int
+
+const (
+	// OpenFile chooses files, or folders if Directory is set, to open.
+	OpenFile FileDialogKind = iota
+	// SaveFile chooses where to save a file.
+	SaveFile
+	// SaveFiles chooses a folder to save Files in.
+	SaveFiles
+)
+
+func (k FileDialogKind) String() string {
+	switch k {
+	case SaveFile:
+		return "SaveFile"
+	case SaveFiles:
+		return "SaveFiles"
+	default:
+		return "OpenFile"
+	}
+}
+
+// FileDialog is a file chooser dialog to show.
+type FileDialog struct {
+	Kind  FileDialogKind
+	Title string
+	// AcceptLabel is the label of the accept button, if not the default.
+	AcceptLabel string
+	// Modal is whether the dialog should be modal for the parent window.
+	Modal bool
+	// Multiple allows choosing more than one file; OpenFile only.
+	Multiple bool
+	// Directory chooses folders instead of files; OpenFile only.
+	Directory bool
+	// Filters are the filters the user can restrict the listed files to, and
+	// CurrentFilter the one to select first.
+	Filters       []portal.Filter
+	CurrentFilter *portal.Filter
+	// Choices are extra controls to show.
+	Choices []portal.Choice
+	// CurrentName is the suggested file name; SaveFile only.
+	CurrentName string
+	// CurrentFolder is the path of the folder to show first.
+	CurrentFolder string
+	// CurrentFile is the path of the file being saved, if it exists; SaveFile only.
+	CurrentFile string
+	// Files are the names of the files to save; SaveFiles only.
+	Files []string
+	// Options are the options of the call, for the ones not decoded above.
+	Options Options
+}
+
+// FileChooserHandler shows a file chooser dialog and returns what the user
+// chose. For SaveFiles, the URIs are those of the files in the chosen folder.
+type FileChooserHandler func(r *Request, dialog FileDialog) (Response, portal.FileChooserResult)
+
+// fileChooserObject holds the methods of the FileChooser interface.
+type fileChooserObject struct {
+	b *Backend
+}
+
+func (o fileChooserObject) OpenFile(handle dbus.ObjectPath, appID, parentWindow, title string, options map[string]dbus.Variant) (uint32, map[string]dbus.Variant, *dbus.Error) {
+	return o.serve(OpenFile, handle, appID, parentWindow, title, options)
+}
+
+func (o fileChooserObject) SaveFile(handle dbus.ObjectPath, appID, parentWindow, title string, options map[string]dbus.Variant) (uint32, map[string]dbus.Variant, *dbus.Error) {
+	return o.serve(SaveFile, handle, appID, parentWindow, title, options)
+}
+
+func (o fileChooserObject) SaveFiles(handle dbus.ObjectPath, appID, parentWindow, title string, options map[string]dbus.Variant) (uint32, map[string]dbus.Variant, *dbus.Error) {
+	return o.serve(SaveFiles, handle, appID, parentWindow, title, options)
+}
+
+// serve decodes the options of a file chooser method and runs the handler.
+func (o fileChooserObject) serve(kind FileDialogKind, handle dbus.ObjectPath, appID, parentWindow, title string, options map[string]dbus.Variant) (uint32, map[string]dbus.Variant, *dbus.Error) {
+	opts := Options(options)
+	dialog := FileDialog{
+		Kind:          kind,
+		Title:         title,
+		AcceptLabel:   opts.String("accept_label"),
+		Modal:         opts.Bool("modal", true),
+		CurrentFolder: opts.Path("current_folder"),
+		Options:       opts,
+	}
+	opts.Store("choices", &dialog.Choices)
+	switch kind {
+	case OpenFile:
+		dialog.Multiple = opts.Bool("multiple", false)
+		dialog.Directory = opts.Bool("directory", false)
+	case SaveFile:
+		dialog.CurrentName = opts.String("current_name")
+		dialog.CurrentFile = opts.Path("current_file")
+	case SaveFiles:
+		dialog.Files = opts.Paths("files")
+	}
+	if kind != SaveFiles {
+		opts.Store("filters", &dialog.Filters)
+		var current portal.Filter
+		if opts.Store("current_filter", &current) {
+			dialog.CurrentFilter = &current
+		}
+	}
+
+	return o.b.ServeRequest(handle, appID, parentWindow, func(r *Request) (Response, map[string]dbus.Variant) {
+		response, result := o.b.config.FileChooser(r, dialog)
+		results := map[string]dbus.Variant{
+			"uris": dbus.MakeVariant(append([]string{}, result.URIs...)),
+		}
+		if len(result.Choices) > 0 {
+			results["choices"] = dbus.MakeVariant(choiceResults(result.Choices))
+		}
+		if result.Filter != nil {
+			results["current_filter"] = dbus.MakeVariant(*result.Filter)
+		}
+		if kind == OpenFile {
+			results["writable"] = dbus.MakeVariant(result.Writable)
+		}
+		return response, results
+	})
+}
+
+var fileChooserIntrospection = introspect.Interface{
+	Name: fileChooserInterface,
+	Methods: []introspect.Method{
+		fileChooserMethod("OpenFile"),
+		fileChooserMethod("SaveFile"),
+		fileChooserMethod("SaveFiles"),
+	},
+}
+
+// fileChooserMethod describes a method of the FileChooser interface, which all
+// take the same arguments.
+func fileChooserMethod(name string) introspect.Method {
+	return introspect.Method{
+		Name: name,
+		Args: []introspect.Arg{
+			{Name: "handle", Type: "o", Direction: "in"},
+			{Name: "app_id", Type: "s", Direction: "in"},
+			{Name: "parent_window", Type: "s", Direction: "in"},
+			{Name: "title", Type: "s", Direction: "in"},
+			{Name: "options", Type: "a{sv}", Direction: "in"},
+			{Name: "response", Type: "u", Direction: "out"},
+			{Name: "results", Type: "a{sv}", Direction: "out"},
+		},
+	}
+}
@@ -0,0 +1,288 @@
+/*
+	libxdg-go - An implementaion of various freedesktop specifications in go
+    Copyright (C) 2025 MiracleOS Contributors
+
+    This program is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    This program is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+*/
+
+// Package portalBackend is a framework for writing xdg-desktop-portal backends,
+// the services implementing the org.freedesktop.impl.portal.* interfaces that
+// xdg-desktop-portal forwards the requests of applications to.
+//
+// A Backend serves the Access, FileChooser and Settings interfaces from the
+// handlers of its Config. Other interfaces can be added with Export, building on
+// the same plumbing: ServeRequest runs a method answering through a Request
+// object, NewSession creates Session objects, and Options decodes vardicts.
+//
+// The backend still has to be listed in a .portal file and portals.conf for
+// xdg-desktop-portal to use it.
+package portalBackend
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+
+	"github.com/godbus/dbus/v5"
+	"github.com/godbus/dbus/v5/introspect"
+	"github.com/godbus/dbus/v5/prop"
+)
+
+const (
+	// DefaultBusName is the bus name of the backend when Config.BusName is empty.
+	DefaultBusName = "org.freedesktop.impl.portal.desktop.miracleos"
+	objectPath     = dbus.ObjectPath("/org/freedesktop/portal/desktop")
+)
+
+// Response is the outcome of a request, as returned to xdg-desktop-portal.
+type Response uint32
+
+const (
+	// ResponseSuccess means the interaction ended as the application asked.
+	ResponseSuccess Response = iota
+	// ResponseCancelled means the user cancelled the interaction.
+	ResponseCancelled
+	// ResponseOther means the interaction ended in some other way, such as an error.
+	ResponseOther
+)
+
+func (r Response) String() string {
+	switch r {
+	case ResponseSuccess:
+		return "success"
+	case ResponseCancelled:
+		return "cancelled"
+	default:
+		return "other"
+	}
+}
+
+// Config configures a Backend. The interfaces without a handler are not served.
+type Config struct {
+	// Conn is the connection to serve on, left open by Stop. The session bus is
+	// used if nil.
+	Conn *dbus.Conn
+	// BusAddress is the address of the bus to connect to if Conn is nil, the
+	// session bus if empty.
+	BusAddress string
+	// BusName is the name to take, DefaultBusName if empty. It must match the
+	// DBusName of the backend's .portal file.
+	BusName string
+
+	// Access shows the dialogs of the org.freedesktop.impl.portal.Access interface.
+	Access AccessHandler
+	// FileChooser shows the dialogs of the org.freedesktop.impl.portal.FileChooser
+	// interface.
+	FileChooser FileChooserHandler
+	// Settings are the initial settings served by the
+	// org.freedesktop.impl.portal.Settings interface, by namespace and key. The
+	// interface is served if it is not nil, even if empty; see SetSetting.
+	Settings map[string]map[string]dbus.Variant
+}
+
+// export is an interface added with Export.
+type export struct {
+	v     any
+	iface introspect.Interface
+}
+
+// Backend serves portal backend interfaces on the bus. Handlers run on their own
+// goroutine and may block until the user answers.
+type Backend struct {
+	config   Config
+	conn     *dbus.Conn
+	mu       sync.Mutex
+	started  bool
+	stopped  bool
+	exports  []export
+	requests map[dbus.ObjectPath]*Request
+	sessions map[dbus.ObjectPath]*Session
+	settings map[string]map[string]dbus.Variant
+}
+
+// NewBackend creates a backend, served by Start.
+func NewBackend(config Config) *Backend {
+	b := &Backend{
+		config:   config,
+		requests: make(map[dbus.ObjectPath]*Request),
+		sessions: make(map[dbus.ObjectPath]*Session),
+	}
+	if config.Settings != nil {
+		b.settings = make(map[string]map[string]dbus.Variant)
+		for namespace, values := range config.Settings {
+			b.settings[namespace] = make(map[string]dbus.Variant)
+			for key, value := range values {
+				b.settings[namespace][key] = value
+			}
+		}
+	}
+	return b
+}
+
+// Export adds an interface to the portal object, served from the exported
+// methods of v as by dbus.Conn.Export. iface describes it for introspection and
+// names it. Export must be called before Start.
+func (b *Backend) Export(v any, iface introspect.Interface) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.started {
+		return errors.New("portal backend already started")
+	}
+	b.exports = append(b.exports, export{v: v, iface: iface})
+	return nil
+}
+
+// Conn returns the connection the backend serves on, nil before Start.
+func (b *Backend) Conn() *dbus.Conn {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.conn
+}
+
+// interfaces returns the interfaces the backend serves: those added with Export
+// and those of the configured handlers.
+func (b *Backend) interfaces() []export {
+	exports := append([]export(nil), b.exports...)
+	if b.config.Access != nil {
+		exports = append(exports, export{v: accessObject{b}, iface: accessIntrospection})
+	}
+	if b.config.FileChooser != nil {
+		exports = append(exports, export{v: fileChooserObject{b}, iface: fileChooserIntrospection})
+	}
+	if b.settings != nil {
+		exports = append(exports, export{v: settingsObject{b}, iface: settingsIntrospection})
+	}
+	return exports
+}
+
+// busName returns the name the backend takes.
+func (b *Backend) busName() string {
+	if b.config.BusName != "" {
+		return b.config.BusName
+	}
+	return DefaultBusName
+}
+
+// Start serves the interfaces and takes the bus name.
+func (b *Backend) Start() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.started {
+		return errors.New("portal backend already started")
+	}
+
+	conn := b.config.Conn
+	if conn == nil {
+		var err error
+		if b.config.BusAddress == "" {
+			conn, err = dbus.ConnectSessionBus()
+		} else {
+			conn, err = dbus.Connect(b.config.BusAddress)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	b.conn = conn
+
+	exports := b.interfaces()
+	props := prop.Map{}
+	if b.settings != nil {
+		props[settingsInterface] = map[string]*prop.Prop{
+			"version": {Value: settingsVersion, Emit: prop.EmitFalse},
+		}
+	}
+
+	node := &introspect.Node{Name: string(objectPath)}
+	for _, e := range exports {
+		if err := conn.Export(e.v, objectPath, e.iface.Name); err != nil {
+			b.unexport(exports)
+			return fmt.Errorf("exporting %s: %w", e.iface.Name, err)
+		}
+		node.Interfaces = append(node.Interfaces, e.iface)
+	}
+	node.Interfaces = append(node.Interfaces, introspect.IntrospectData)
+	if len(props) > 0 {
+		if _, err := prop.Export(conn, objectPath, props); err != nil {
+			b.unexport(exports)
+			return err
+		}
+		node.Interfaces = append(node.Interfaces, prop.IntrospectData)
+	}
+	if err := conn.Export(introspect.NewIntrospectable(node), objectPath, "org.freedesktop.DBus.Introspectable"); err != nil {
+		b.unexport(exports)
+		return err
+	}
+
+	reply, err := conn.RequestName(b.busName(), dbus.NameFlagDoNotQueue)
+	if err == nil && reply != dbus.RequestNameReplyPrimaryOwner {
+		err = errors.New("portal backend is already running (bus name taken)")
+	}
+	if err != nil {
+		b.unexport(exports)
+		return err
+	}
+
+	b.started = true
+	slog.Info("Portal backend started on DBus as " + b.busName())
+	return nil
+}
+
+// unexport undoes Start.
+func (b *Backend) unexport(exports []export) {
+	for _, e := range exports {
+		b.conn.Export(nil, objectPath, e.iface.Name)
+	}
+	b.conn.Export(nil, objectPath, "org.freedesktop.DBus.Properties")
+	b.conn.Export(nil, objectPath, "org.freedesktop.DBus.Introspectable")
+	if b.conn != b.config.Conn {
+		b.conn.Close()
+	}
+}
+
+// Stop closes the pending requests and open sessions and releases the name.
+// Calling Stop more than once is a no-op.
+func (b *Backend) Stop() {
+	b.mu.Lock()
+	if !b.started || b.stopped {
+		b.mu.Unlock()
+		return
+	}
+	b.stopped = true
+	requests := make([]*Request, 0, len(b.requests))
+	for _, r := range b.requests {
+		requests = append(requests, r)
+	}
+	sessions := make([]*Session, 0, len(b.sessions))
+	for _, s := range b.sessions {
+		sessions = append(sessions, s)
+	}
+	b.mu.Unlock()
+
+	for _, r := range requests {
+		r.cancel()
+	}
+	for _, s := range sessions {
+		s.Close()
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.conn.ReleaseName(b.busName())
+	b.unexport(b.interfaces())
+}
@@ -0,0 +1,110 @@
+/*
+	libxdg-go - An implementaion of various freedesktop specifications in go
+    Copyright (C) 2025 MiracleOS Contributors
+
+    This program is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    This program is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+*/
+
+package portalBackend
+
+import (
+	"strings"
+
+	"github.com/godbus/dbus/v5"
+)
+
+// Options is the vardict of options a portal method receives. Its getters return
+// the zero value for missing options and options of the wrong type.
+type Options map[string]dbus.Variant
+
+// value returns an option, unwrapped from the extra variant some clients add.
+func (o Options) value(key string) (dbus.Variant, bool) {
+	v, exists := o[key]
+	if !exists {
+		return dbus.Variant{}, false
+	}
+	for {
+		inner, ok := v.Value().(dbus.Variant)
+		if !ok {
+			return v, true
+		}
+		v = inner
+	}
+}
+
+// Has reports whether an option is set.
+func (o Options) Has(key string) bool {
+	_, exists := o[key]
+	return exists
+}
+
+// String returns a string option.
+func (o Options) String(key string) string {
+	v, _ := o.value(key)
+	s, _ := v.Value().(string)
+	return s
+}
+
+// Bool returns a boolean option, or def if it is unset.
+func (o Options) Bool(key string, def bool) bool {
+	v, _ := o.value(key)
+	if b, ok := v.Value().(bool); ok {
+		return b
+	}
+	return def
+}
+
+// Uint32 returns an unsigned integer option.
+func (o Options) Uint32(key string) uint32 {
+	v, _ := o.value(key)
+	u, _ := v.Value().(uint32)
+	return u
+}
+
+// Strings returns a string list option.
+func (o Options) Strings(key string) []string {
+	v, _ := o.value(key)
+	s, _ := v.Value().([]string)
+	return s
+}
+
+// Path returns a path option, sent as a nul-terminated byte string.
+func (o Options) Path(key string) string {
+	v, _ := o.value(key)
+	if b, ok := v.Value().([]byte); ok {
+		return strings.TrimRight(string(b), "\x00")
+	}
+	// Some clients send paths as strings.
+	s, _ := v.Value().(string)
+	return s
+}
+
+// Paths returns a path list option.
+func (o Options) Paths(key string) []string {
+	v, _ := o.value(key)
+	list, _ := v.Value().([][]byte)
+	var paths []string
+	for _, b := range list {
+		paths = append(paths, strings.TrimRight(string(b), "\x00"))
+	}
+	return paths
+}
+
+// Store stores an option in dest, as dbus.Variant.Store does, and reports
+// whether it was set and of a compatible type.
+func (o Options) Store(key string, dest any) bool {
+	v, exists := o.value(key)
+	return exists && v.Store(dest) == nil
+}
@@ -0,0 +1,213 @@
+/*
+	libxdg-go - An implementaion of various freedesktop specifications in go
+    Copyright (C) 2025 MiracleOS Contributors
+
+    This program is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    This program is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+*/
+
+package portalBackend
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/godbus/dbus/v5"
+	"github.com/godbus/dbus/v5/prop"
+)
+
+const (
+	requestInterface = "org.freedesktop.impl.portal.Request"
+	sessionInterface = "org.freedesktop.impl.portal.Session"
+	sessionVersion   = uint32(1)
+)
+
+// Request is a pending request of an application. xdg-desktop-portal closes it
+// when the application cancels the request or goes away, which cancels its
+// context; the interaction should then end promptly.
+type Request struct {
+	// Handle is the object path of the request.
+	Handle dbus.ObjectPath
+	// AppID is the application ID of the application, empty for unsandboxed
+	// applications without one.
+	AppID string
+	// ParentWindow identifies the application window to make dialogs transient
+	// for: "x11:" followed by a hexadecimal XID, "wayland:" followed by an
+	// exported xdg_foreign handle, or empty.
+	ParentWindow string
+
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+// Context returns a context cancelled when the request is closed.
+func (r *Request) Context() context.Context {
+	return r.ctx
+}
+
+// Closed reports whether the request was closed.
+func (r *Request) Closed() bool {
+	return r.ctx.Err() != nil
+}
+
+// requestObject holds the methods of a Request object.
+type requestObject struct {
+	r *Request
+}
+
+func (o requestObject) Close() *dbus.Error {
+	o.r.cancel()
+	return nil
+}
+
+// ServeRequest runs fn on a request of a method answering through a Request
+// object, which is exported at handle while fn runs, and returns what the
+// method must return. If the request was closed the response is
+// ResponseCancelled, whatever fn returned.
+func (b *Backend) ServeRequest(handle dbus.ObjectPath, appID, parentWindow string, fn func(r *Request) (Response, map[string]dbus.Variant)) (uint32, map[string]dbus.Variant, *dbus.Error) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	r := &Request{Handle: handle, AppID: appID, ParentWindow: parentWindow, ctx: ctx, cancel: cancel}
+
+	b.mu.Lock()
+	if b.stopped {
+		b.mu.Unlock()
+		return 0, nil, dbus.MakeFailedError(errors.New("portal backend stopped"))
+	}
+	if _, exists := b.requests[handle]; exists {
+		b.mu.Unlock()
+		return 0, nil, dbus.MakeFailedError(errors.New("request handle already in use"))
+	}
+	if err := b.conn.Export(requestObject{r}, handle, requestInterface); err != nil {
+		b.mu.Unlock()
+		return 0, nil, dbus.MakeFailedError(err)
+	}
+	b.requests[handle] = r
+	b.mu.Unlock()
+
+	defer func() {
+		b.mu.Lock()
+		delete(b.requests, handle)
+		b.conn.Export(nil, handle, requestInterface)
+		b.mu.Unlock()
+	}()
+
+	response, results := fn(r)
+	if r.Closed() {
+		response, results = ResponseCancelled, nil
+	}
+	if results == nil {
+		results = make(map[string]dbus.Variant)
+	}
+	return uint32(response), results, nil
+}
+
+// Session is a session of an application, lasting over several requests until
+// either side closes it.
+type Session struct {
+	// Handle is the object path of the session.
+	Handle dbus.ObjectPath
+	// AppID is the application ID of the application.
+	AppID string
+
+	b      *Backend
+	ctx    context.Context
+	cancel context.CancelFunc
+	once   sync.Once
+}
+
+// sessionObject holds the methods of a Session object.
+type sessionObject struct {
+	s *Session
+}
+
+func (o sessionObject) Close() *dbus.Error {
+	o.s.close(false)
+	return nil
+}
+
+// NewSession exports a Session object at handle, as the methods creating
+// sessions must before returning.
+func (b *Backend) NewSession(handle dbus.ObjectPath, appID string) (*Session, error) {
+	ctx, cancel := context.WithCancel(context.Background())
+	s := &Session{Handle: handle, AppID: appID, b: b, ctx: ctx, cancel: cancel}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.stopped {
+		cancel()
+		return nil, errors.New("portal backend stopped")
+	}
+	if _, exists := b.sessions[handle]; exists {
+		cancel()
+		return nil, errors.New("session handle already in use")
+	}
+	if err := b.conn.Export(sessionObject{s}, handle, sessionInterface); err != nil {
+		cancel()
+		return nil, err
+	}
+	_, err := prop.Export(b.conn, handle, prop.Map{
+		sessionInterface: {
+			"version": {Value: sessionVersion, Emit: prop.EmitFalse},
+		},
+	})
+	if err != nil {
+		b.conn.Export(nil, handle, sessionInterface)
+		cancel()
+		return nil, err
+	}
+	b.sessions[handle] = s
+	return s, nil
+}
+
+// Session returns the open session at handle, or nil.
+func (b *Backend) Session(handle dbus.ObjectPath) *Session {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.sessions[handle]
+}
+
+// Context returns a context cancelled when the session is closed.
+func (s *Session) Context() context.Context {
+	return s.ctx
+}
+
+// Closed reports whether the session was closed.
+func (s *Session) Closed() bool {
+	return s.ctx.Err() != nil
+}
+
+// Close closes the session from the backend side, emitting the Closed signal.
+// Closing a closed session is a no-op.
+func (s *Session) Close() {
+	s.close(true)
+}
+
+// close removes the session, emitting Closed if the backend closed it.
+func (s *Session) close(emit bool) {
+	s.once.Do(func() {
+		s.cancel()
+		b := s.b
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		delete(b.sessions, s.Handle)
+		if emit {
+			b.conn.Emit(s.Handle, sessionInterface+".Closed")
+		}
+		b.conn.Export(nil, s.Handle, sessionInterface)
+		b.conn.Export(nil, s.Handle, "org.freedesktop.DBus.Properties")
+	})
+}
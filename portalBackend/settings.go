@@ -0,0 +1,166 @@
+/*
+	libxdg-go - An implementaion of various freedesktop specifications in go
+    Copyright (C) 2025 MiracleOS Contributors
+
+    This program is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    This program is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+*/
+
+package portalBackend
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+
+	"github.com/MiracleOS-Team/libxdg-go/portal"
+	"github.com/godbus/dbus/v5"
+	"github.com/godbus/dbus/v5/introspect"
+)
+
+const (
+	settingsInterface = "org.freedesktop.impl.portal.Settings"
+	settingsVersion   = uint32(1)
+)
+
+// ErrNoSettings is returned when changing settings of a backend not serving the
+// Settings interface.
+var ErrNoSettings = errors.New("portal backend has no settings")
+
+// settingsObject holds the methods of the Settings interface.
+type settingsObject struct {
+	b *Backend
+}
+
+func (o settingsObject) ReadAll(namespaces []string) (map[string]map[string]dbus.Variant, *dbus.Error) {
+	o.b.mu.Lock()
+	defer o.b.mu.Unlock()
+
+	all := make(map[string]map[string]dbus.Variant)
+	for namespace, values := range o.b.settings {
+		if !matchNamespace(namespaces, namespace) {
+			continue
+		}
+		all[namespace] = make(map[string]dbus.Variant, len(values))
+		for key, value := range values {
+			all[namespace][key] = value
+		}
+	}
+	return all, nil
+}
+
+func (o settingsObject) Read(namespace, key string) (dbus.Variant, *dbus.Error) {
+	o.b.mu.Lock()
+	defer o.b.mu.Unlock()
+
+	value, exists := o.b.settings[namespace][key]
+	if !exists {
+		return dbus.Variant{}, &dbus.Error{Name: "org.freedesktop.portal.Error.NotFound", Body: []any{"requested setting not found"}}
+	}
+	return value, nil
+}
+
+// matchNamespace reports whether a namespace matches the patterns of ReadAll,
+// which may end with "*". No pattern, or an empty one, matches every namespace.
+func matchNamespace(patterns []string, namespace string) bool {
+	if len(patterns) == 0 {
+		return true
+	}
+	for _, p := range patterns {
+		if prefix, found := strings.CutSuffix(p, "*"); found && strings.HasPrefix(namespace, prefix) || p == namespace || p == "" {
+			return true
+		}
+	}
+	return false
+}
+
+// Setting returns the value of a setting.
+func (b *Backend) Setting(namespace, key string) (dbus.Variant, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	value, exists := b.settings[namespace][key]
+	return value, exists
+}
+
+// SetSetting changes a setting, emitting SettingChanged if the backend is
+// started and the value differs.
+func (b *Backend) SetSetting(namespace, key string, value dbus.Variant) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.settings == nil {
+		return ErrNoSettings
+	}
+	if old, exists := b.settings[namespace][key]; exists && old.Signature() == value.Signature() && reflect.DeepEqual(old.Value(), value.Value()) {
+		return nil
+	}
+	if b.settings[namespace] == nil {
+		b.settings[namespace] = make(map[string]dbus.Variant)
+	}
+	b.settings[namespace][key] = value
+
+	if !b.started || b.stopped {
+		return nil
+	}
+	return b.conn.Emit(objectPath, settingsInterface+".SettingChanged", namespace, key, value)
+}
+
+// SetColorScheme sets the preferred color scheme of the appearance namespace.
+func (b *Backend) SetColorScheme(scheme portal.ColorScheme) error {
+	return b.SetSetting(portal.AppearanceNamespace, "color-scheme", dbus.MakeVariant(uint32(scheme)))
+}
+
+// SetAccentColor sets the preferred accent color of the appearance namespace.
+func (b *Backend) SetAccentColor(color portal.AccentColor) error {
+	return b.SetSetting(portal.AppearanceNamespace, "accent-color", dbus.MakeVariant(color))
+}
+
+// SetContrast sets the preferred contrast of the appearance namespace.
+func (b *Backend) SetContrast(contrast portal.Contrast) error {
+	return b.SetSetting(portal.AppearanceNamespace, "contrast", dbus.MakeVariant(uint32(contrast)))
+}
+
+var settingsIntrospection = introspect.Interface{
+	Name: settingsInterface,
+	Methods: []introspect.Method{
+		{
+			Name: "ReadAll",
+			Args: []introspect.Arg{
+				{Name: "namespaces", Type: "as", Direction: "in"},
+				{Name: "value", Type: "a{sa{sv}}", Direction: "out"},
+			},
+		},
+		{
+			Name: "Read",
+			Args: []introspect.Arg{
+				{Name: "namespace", Type: "s", Direction: "in"},
+				{Name: "key", Type: "s", Direction: "in"},
+				{Name: "value", Type: "v", Direction: "out"},
+			},
+		},
+	},
+	Signals: []introspect.Signal{
+		{
+			Name: "SettingChanged",
+			Args: []introspect.Arg{
+				{Name: "namespace", Type: "s"},
+				{Name: "key", Type: "s"},
+				{Name: "value", Type: "v"},
+			},
+		},
+	},
+	Properties: []introspect.Property{
+		{Name: "version", Type: "u", Access: "read"},
+	},
+}
@@ -0,0 +1,299 @@
+/*
+	libxdg-go - An implementaion of various freedesktop specifications in go
+    Copyright (C) 2025 MiracleOS Contributors
+
+    This program is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    This program is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+*/
+
+// Package recent implements the Desktop Bookmark spec's recent file storage:
+// the list of recently used files kept in $XDG_DATA_HOME/recently-used.xbel.
+package recent
+
+import (
+	"errors"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"time"
+
+	basedir "github.com/MiracleOS-Team/libxdg-go/baseDir"
+	"github.com/MiracleOS-Team/libxdg-go/internal/atomicfile"
+	"github.com/MiracleOS-Team/libxdg-go/mime"
+)
+
+// Bookmark is a recently used file.
+type Bookmark struct {
+	URI         string
+	Title       string
+	Description string
+	MIMEType    string
+	Added       time.Time
+	Modified    time.Time
+	Visited     time.Time
+	// Groups are the groups the file belongs to, e.g. the application that
+	// registered it or "Graphics".
+	Groups []string
+	// Applications are the applications that used the file.
+	Applications []Application
+	// Private bookmarks are only shown to the applications that registered them.
+	Private bool
+	// Icon is the URI of an icon for the file, and IconType its MIME type.
+	Icon     string
+	IconType string
+
+	unknown bookmarkXML
+}
+
+// Application is an application that used a recent file.
+type Application struct {
+	Name string
+	// Exec is the command line to open the file with, with "%u" or "%f" standing
+	// for it, e.g. "gedit %u".
+	Exec     string
+	Modified time.Time
+	// Count is how many times the application registered the file.
+	Count int
+}
+
+// Entry describes a use of a file to record with Add.
+type Entry struct {
+	// URI is the URI of the file. An absolute path is turned into a file URI.
+	URI string
+	// MIMEType is the type of the file. It is detected for local files if empty.
+	MIMEType string
+	// AppName and AppExec are the name of the application using the file and the
+	// command line to open it with, e.g. "gedit" and "gedit %u".
+	AppName string
+	AppExec string
+	Title   string
+	Groups  []string
+	Private bool
+}
+
+// Store is a loaded recently-used.xbel file.
+type Store struct {
+	path      string
+	bookmarks []Bookmark
+	policy    Policy
+	unknown   fileXML
+	// changes are the modifications made since the store was loaded, replayed by
+	// Save on the file as it is then.
+	changes []func(s *Store)
+}
+
+// Path returns the path of the user's recent files, $XDG_DATA_HOME/recently-used.xbel.
+func Path() string {
+	return filepath.Join(basedir.GetXDGDirectory("data").(string), "recently-used.xbel")
+}
+
+// Load loads the user's recent files.
+func Load() (*Store, error) {
+	return LoadFrom(Path())
+}
+
+// LoadFrom loads recent files from an XBEL file. A missing file is an empty
+// store. Files listed more than once are merged. The store saves with
+// DefaultPolicy.
+func LoadFrom(path string) (*Store, error) {
+	s := &Store{path: path, policy: DefaultPolicy}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	bookmarks, unknown, err := parseXBEL(data)
+	if err != nil {
+		return nil, err
+	}
+	s.bookmarks = dedupe(bookmarks)
+	s.unknown = unknown
+	return s, nil
+}
+
+// Save atomically writes the store back to its file, pruned with its policy.
+// Other applications write the file too, so it is read again first and the
+// changes made to the store since it was loaded are applied to that. What this
+// package doesn't understand, such as other applications' metadata, is kept.
+func (s *Store) Save() error {
+	current, err := LoadFrom(s.path)
+	if err != nil {
+		return err
+	}
+	for _, change := range s.changes {
+		change(current)
+	}
+	current.prune(s.policy)
+
+	if err := atomicfile.WriteFile(s.path, writeXBEL(current.bookmarks, current.unknown), 0600); err != nil {
+		return err
+	}
+	s.bookmarks, s.unknown, s.changes = current.bookmarks, current.unknown, nil
+	return nil
+}
+
+// change applies a modification to the store and records it for Save.
+func (s *Store) change(apply func(s *Store)) {
+	apply(s)
+	s.changes = append(s.changes, apply)
+}
+
+// fileURI turns an absolute path into a file URI and leaves URIs untouched.
+func fileURI(uri string) string {
+	if filepath.IsAbs(uri) {
+		return (&url.URL{Scheme: "file", Path: uri}).String()
+	}
+	return uri
+}
+
+// localPath returns the path of a file URI, or "".
+func localPath(uri string) string {
+	u, err := url.Parse(uri)
+	if err != nil || u.Scheme != "file" {
+		return ""
+	}
+	return u.Path
+}
+
+// Add records a use of a file. A file already in the store is updated: its
+// application's count is incremented and new groups are added.
+func (s *Store) Add(e Entry) {
+	now := time.Now()
+	s.change(func(s *Store) { s.add(e, now) })
+}
+
+func (s *Store) add(e Entry, now time.Time) {
+	uri := fileURI(e.URI)
+
+	i := slices.IndexFunc(s.bookmarks, func(b Bookmark) bool { return b.URI == uri })
+	if i < 0 {
+		s.bookmarks = append(s.bookmarks, Bookmark{URI: uri, Added: now})
+		i = len(s.bookmarks) - 1
+	}
+	b := &s.bookmarks[i]
+	b.Modified, b.Visited = now, now
+	b.Private = b.Private || e.Private
+	if e.Title != "" {
+		b.Title = e.Title
+	}
+	if e.MIMEType != "" {
+		b.MIMEType = e.MIMEType
+	} else if path := localPath(uri); path != "" && b.MIMEType == "" {
+		b.MIMEType, _ = mime.DetectMIME(path)
+	}
+	for _, group := range e.Groups {
+		if !slices.Contains(b.Groups, group) {
+			b.Groups = append(b.Groups, group)
+		}
+	}
+
+	if e.AppName == "" {
+		return
+	}
+	j := slices.IndexFunc(b.Applications, func(a Application) bool { return a.Name == e.AppName })
+	if j < 0 {
+		b.Applications = append(b.Applications, Application{Name: e.AppName})
+		j = len(b.Applications) - 1
+	}
+	app := &b.Applications[j]
+	app.Modified = now
+	app.Count++
+	if e.AppExec != "" {
+		app.Exec = e.AppExec
+	}
+}
+
+// Remove removes a file from the store and reports whether it was there.
+func (s *Store) Remove(uri string) bool {
+	uri = fileURI(uri)
+	_, found := s.Lookup(uri)
+	s.change(func(s *Store) {
+		s.bookmarks = slices.DeleteFunc(s.bookmarks, func(b Bookmark) bool { return b.URI == uri })
+	})
+	return found
+}
+
+// Lookup returns the bookmark of a file.
+func (s *Store) Lookup(uri string) (Bookmark, bool) {
+	uri = fileURI(uri)
+	for _, b := range s.bookmarks {
+		if b.URI == uri {
+			return b, true
+		}
+	}
+	return Bookmark{}, false
+}
+
+// Bookmarks returns the recent files, most recently modified first.
+func (s *Store) Bookmarks() []Bookmark {
+	return s.filter(func(Bookmark) bool { return true })
+}
+
+// ByApplication returns the recent files used by an application, most recently
+// modified first.
+func (s *Store) ByApplication(name string) []Bookmark {
+	return s.filter(func(b Bookmark) bool { return b.HasApplication(name) })
+}
+
+// ByGroup returns the recent files of a group, most recently modified first.
+func (s *Store) ByGroup(group string) []Bookmark {
+	return s.filter(func(b Bookmark) bool { return slices.Contains(b.Groups, group) })
+}
+
+// ByMIMEType returns the recent files of a MIME type or of a subclass of it,
+// most recently modified first. A pattern like "image/*" matches a whole media
+// type.
+func (s *Store) ByMIMEType(mimeType string) []Bookmark {
+	return s.filter(func(b Bookmark) bool { return b.MIMEType != "" && mime.IsSubclassOf(b.MIMEType, mimeType) })
+}
+
+func (s *Store) filter(keep func(b Bookmark) bool) []Bookmark {
+	var bookmarks []Bookmark
+	for _, b := range s.bookmarks {
+		if keep(b) {
+			bookmarks = append(bookmarks, b)
+		}
+	}
+	sort.SliceStable(bookmarks, func(i, j int) bool { return bookmarks[i].Modified.After(bookmarks[j].Modified) })
+	return bookmarks
+}
+
+// HasApplication reports whether an application used the file.
+func (b Bookmark) HasApplication(name string) bool {
+	return slices.ContainsFunc(b.Applications, func(a Application) bool { return a.Name == name })
+}
+
+// Application returns the registration of an application.
+func (b Bookmark) Application(name string) (Application, bool) {
+	for _, a := range b.Applications {
+		if a.Name == name {
+			return a, true
+		}
+	}
+	return Application{}, false
+}
+
+// Add records a use of a file in the user's recent files.
+func Add(e Entry) error {
+	s, err := Load()
+	if err != nil {
+		return err
+	}
+	s.Add(e)
+	return s.Save()
+}
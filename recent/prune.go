@@ -0,0 +1,188 @@
+/*
+	libxdg-go - An implementaion of various freedesktop specifications in go
+    Copyright (C) 2025 MiracleOS Contributors
+
+    This program is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    This program is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+*/
+
+package recent
+
+import (
+	"errors"
+	"os"
+	"slices"
+	"sort"
+	"time"
+)
+
+// Policy limits what the store keeps. Zero fields don't limit anything.
+type Policy struct {
+	// MaxItems is the number of most recently modified files kept.
+	MaxItems int
+	// MaxAge drops the files not used for longer.
+	MaxAge time.Duration
+	// DropMissing drops the local files that don't exist anymore.
+	DropMissing bool
+	// MaxPerApplication is the number of files kept per application. An
+	// application is forgotten from the files beyond it, and files no
+	// application used anymore are dropped.
+	MaxPerApplication int
+}
+
+// DefaultPolicy is the policy of loaded stores: it caps the file at 1000 items.
+var DefaultPolicy = Policy{MaxItems: 1000}
+
+// SetPolicy sets the policy applied when the store is saved.
+func (s *Store) SetPolicy(p Policy) {
+	s.policy = p
+}
+
+// lastUsed returns the last time a file was used.
+func (b Bookmark) lastUsed() time.Time {
+	if b.Visited.After(b.Modified) {
+		return b.Visited
+	}
+	return b.Modified
+}
+
+// Prune drops the files the policy doesn't keep and returns how many were dropped.
+func (s *Store) Prune(p Policy) int {
+	n := len(s.bookmarks)
+	s.change(func(s *Store) { s.prune(p) })
+	return n - len(s.bookmarks)
+}
+
+func (s *Store) prune(p Policy) {
+
+	if p.MaxAge > 0 {
+		limit := time.Now().Add(-p.MaxAge)
+		s.bookmarks = slices.DeleteFunc(s.bookmarks, func(b Bookmark) bool { return b.lastUsed().Before(limit) })
+	}
+	if p.DropMissing {
+		s.bookmarks = slices.DeleteFunc(s.bookmarks, func(b Bookmark) bool {
+			path := localPath(b.URI)
+			if path == "" {
+				return false
+			}
+			_, err := os.Lstat(path)
+			return errors.Is(err, os.ErrNotExist)
+		})
+	}
+	if p.MaxPerApplication > 0 {
+		s.pruneApplications(p.MaxPerApplication)
+	}
+	if p.MaxItems > 0 && len(s.bookmarks) > p.MaxItems {
+		sort.SliceStable(s.bookmarks, func(i, j int) bool { return s.bookmarks[i].lastUsed().After(s.bookmarks[j].lastUsed()) })
+		s.bookmarks = s.bookmarks[:p.MaxItems]
+	}
+}
+
+// pruneApplications forgets every application from the files beyond its max
+// most recent ones, and drops the files left without applications.
+func (s *Store) pruneApplications(max int) {
+	byApp := make(map[string][]int)
+	for i, b := range s.bookmarks {
+		for _, a := range b.Applications {
+			byApp[a.Name] = append(byApp[a.Name], i)
+		}
+	}
+	for name, indexes := range byApp {
+		if len(indexes) <= max {
+			continue
+		}
+		sort.SliceStable(indexes, func(i, j int) bool {
+			a, _ := s.bookmarks[indexes[i]].Application(name)
+			b, _ := s.bookmarks[indexes[j]].Application(name)
+			return a.Modified.After(b.Modified)
+		})
+		for _, i := range indexes[max:] {
+			b := &s.bookmarks[i]
+			b.Applications = slices.DeleteFunc(b.Applications, func(a Application) bool { return a.Name == name })
+		}
+	}
+	s.bookmarks = slices.DeleteFunc(s.bookmarks, func(b Bookmark) bool { return len(b.Applications) == 0 })
+}
+
+// Visit records that a file was opened without being registered again, bumping
+// its visit time. It reports whether the file is in the store.
+func (s *Store) Visit(uri string) bool {
+	uri = fileURI(uri)
+	_, found := s.Lookup(uri)
+	now := time.Now()
+	s.change(func(s *Store) {
+		for i := range s.bookmarks {
+			if s.bookmarks[i].URI == uri {
+				s.bookmarks[i].Visited = now
+			}
+		}
+	})
+	return found
+}
+
+// dedupe merges the bookmarks with the same URI, which other writers may leave:
+// groups and applications are united, application counts summed and the latest
+// timestamps kept, except for the earliest Added.
+func dedupe(bookmarks []Bookmark) []Bookmark {
+	index := make(map[string]int)
+	var merged []Bookmark
+	for _, b := range bookmarks {
+		i, exists := index[b.URI]
+		if !exists {
+			index[b.URI] = len(merged)
+			merged = append(merged, b)
+			continue
+		}
+		m := &merged[i]
+		if !b.Added.IsZero() && (m.Added.IsZero() || b.Added.Before(m.Added)) {
+			m.Added = b.Added
+		}
+		if b.Modified.After(m.Modified) {
+			m.Modified = b.Modified
+			if b.MIMEType != "" {
+				m.MIMEType = b.MIMEType
+			}
+			if b.Title != "" {
+				m.Title = b.Title
+			}
+		}
+		if b.Visited.After(m.Visited) {
+			m.Visited = b.Visited
+		}
+		m.Private = m.Private || b.Private
+		if m.Icon == "" {
+			m.Icon, m.IconType = b.Icon, b.IconType
+		}
+		m.unknown.merge(b.unknown)
+		for _, group := range b.Groups {
+			if !slices.Contains(m.Groups, group) {
+				m.Groups = append(m.Groups, group)
+			}
+		}
+		for _, a := range b.Applications {
+			j := slices.IndexFunc(m.Applications, func(other Application) bool { return other.Name == a.Name })
+			if j < 0 {
+				m.Applications = append(m.Applications, a)
+				continue
+			}
+			existing := &m.Applications[j]
+			existing.Count += a.Count
+			if a.Modified.After(existing.Modified) {
+				existing.Modified = a.Modified
+				existing.Exec = a.Exec
+			}
+		}
+	}
+	return merged
+}
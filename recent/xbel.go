@@ -0,0 +1,331 @@
+/*
+	libxdg-go - An implementaion of various freedesktop specifications in go
+    Copyright (C) 2025 MiracleOS Contributors
+
+    This program is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    This program is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+*/
+
+package recent
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	nsBookmark = "http://www.freedesktop.org/standards/desktop-bookmarks"
+	nsMIME     = "http://www.freedesktop.org/standards/shared-mime-info"
+	// metadataOwner is the owner of the metadata the spec defines.
+	metadataOwner = "http://freedesktop.org"
+	// timeLayout is the format of timestamps, always in UTC.
+	timeLayout = "2006-01-02T15:04:05.000000Z"
+)
+
+// xbelFile is the structure of an XBEL file, limited to what the spec uses.
+type xbelFile struct {
+	XMLName   xml.Name       `xml:"xbel"`
+	Attrs     []xml.Attr     `xml:",any,attr"`
+	Bookmarks []xbelBookmark `xml:"bookmark"`
+	Inner     string         `xml:",innerxml"`
+}
+
+type xbelBookmark struct {
+	Href     string `xml:"href,attr"`
+	Added    string `xml:"added,attr"`
+	Modified string `xml:"modified,attr"`
+	Visited  string `xml:"visited,attr"`
+	Title    string `xml:"title"`
+	Desc     string `xml:"desc"`
+	Info     struct {
+		Metadata []xbelMetadata `xml:"metadata"`
+		Inner    string         `xml:",innerxml"`
+	} `xml:"info"`
+	Inner string `xml:",innerxml"`
+}
+
+type xbelMetadata struct {
+	Owner    string `xml:"owner,attr"`
+	MIMEType struct {
+		Type string `xml:"type,attr"`
+	} `xml:"http://www.freedesktop.org/standards/shared-mime-info mime-type"`
+	Icon struct {
+		Href string `xml:"href,attr"`
+		Type string `xml:"type,attr"`
+	} `xml:"http://www.freedesktop.org/standards/desktop-bookmarks icon"`
+	Groups struct {
+		Groups []string `xml:"http://www.freedesktop.org/standards/desktop-bookmarks group"`
+	} `xml:"http://www.freedesktop.org/standards/desktop-bookmarks groups"`
+	Applications struct {
+		Applications []xbelApplication `xml:"http://www.freedesktop.org/standards/desktop-bookmarks application"`
+	} `xml:"http://www.freedesktop.org/standards/desktop-bookmarks applications"`
+	Private *struct{} `xml:"http://www.freedesktop.org/standards/desktop-bookmarks private"`
+	Inner   string    `xml:",innerxml"`
+}
+
+type xbelApplication struct {
+	Name     string `xml:"name,attr"`
+	Exec     string `xml:"exec,attr"`
+	Modified string `xml:"modified,attr"`
+	// Timestamp is the modification time in seconds written by older
+	// implementations instead of Modified.
+	Timestamp string `xml:"timestamp,attr"`
+	Count     string `xml:"count,attr"`
+}
+
+// fileXML holds the parts of an XBEL file this package doesn't understand, such
+// as folders, so that saving writes them back verbatim.
+type fileXML struct {
+	// namespaces are the prefixes declared on the root, which the elements may use.
+	namespaces []xml.Attr
+	elements   []string
+}
+
+// bookmarkXML holds the elements of a bookmark this package doesn't understand,
+// such as metadata of other owners, so that saving writes them back verbatim.
+type bookmarkXML struct {
+	// bookmark, info and metadata are unknown children of <bookmark>, <info>
+	// and the freedesktop.org <metadata>.
+	bookmark []string
+	info     []string
+	metadata []string
+}
+
+// merge adds the elements of other that x doesn't have yet.
+func (x *bookmarkXML) merge(other bookmarkXML) {
+	add := func(to []string, from []string) []string {
+		for _, element := range from {
+			if !slices.Contains(to, element) {
+				to = append(to, element)
+			}
+		}
+		return to
+	}
+	x.bookmark = add(x.bookmark, other.bookmark)
+	x.info = add(x.info, other.info)
+	x.metadata = add(x.metadata, other.metadata)
+}
+
+// rawElement is an element of XML content with its source text.
+type rawElement struct {
+	name  xml.Name
+	attrs []xml.Attr
+	raw   string
+}
+
+// children returns the top-level elements of XML content, with their source text.
+func children(content string) []rawElement {
+	var elements []rawElement
+	decoder := xml.NewDecoder(strings.NewReader(content))
+	for {
+		start := decoder.InputOffset()
+		token, err := decoder.Token()
+		if err != nil {
+			return elements
+		}
+		element, ok := token.(xml.StartElement)
+		if !ok {
+			continue
+		}
+		if decoder.Skip() != nil {
+			return elements
+		}
+		elements = append(elements, rawElement{
+			name:  element.Name,
+			attrs: element.Attr,
+			raw:   content[start:decoder.InputOffset()],
+		})
+	}
+}
+
+func (e rawElement) attr(name string) string {
+	for _, attr := range e.attrs {
+		if attr.Name.Local == name && attr.Name.Space == "" {
+			return attr.Value
+		}
+	}
+	return ""
+}
+
+// unknownChildren returns the source text of the top-level elements of XML
+// content whose local name isn't one of known.
+func unknownChildren(content string, known ...string) []string {
+	var unknown []string
+	for _, e := range children(content) {
+		if !slices.Contains(known, e.name.Local) {
+			unknown = append(unknown, e.raw)
+		}
+	}
+	return unknown
+}
+
+// timeLayouts are the timestamp formats read besides RFC 3339: without a time
+// zone, taken as UTC, as some implementations write them.
+var timeLayouts = []string{time.RFC3339Nano, "2006-01-02T15:04:05.999999999"}
+
+// parseTime parses a timestamp, tolerating a missing time zone and the seconds
+// since the epoch written by older implementations. Invalid timestamps are the
+// zero time.
+func parseTime(value string) time.Time {
+	for _, layout := range timeLayouts {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t
+		}
+	}
+	if seconds, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return time.Unix(seconds, 0)
+	}
+	return time.Time{}
+}
+
+func formatTime(t time.Time) string {
+	return t.UTC().Format(timeLayout)
+}
+
+// parseXBEL decodes the bookmarks of an XBEL file, and what it doesn't understand.
+func parseXBEL(data []byte) ([]Bookmark, fileXML, error) {
+	var file xbelFile
+	if err := xml.Unmarshal(data, &file); err != nil {
+		return nil, fileXML{}, err
+	}
+
+	var unknown fileXML
+	for _, attr := range file.Attrs {
+		if attr.Name.Space == "xmlns" && attr.Name.Local != "bookmark" && attr.Name.Local != "mime" {
+			unknown.namespaces = append(unknown.namespaces, attr)
+		}
+	}
+	unknown.elements = unknownChildren(file.Inner, "bookmark")
+
+	bookmarks := make([]Bookmark, 0, len(file.Bookmarks))
+	for _, b := range file.Bookmarks {
+		bookmark := Bookmark{
+			URI:         b.Href,
+			Title:       b.Title,
+			Description: b.Desc,
+			Added:       parseTime(b.Added),
+			Modified:    parseTime(b.Modified),
+			Visited:     parseTime(b.Visited),
+		}
+		bookmark.unknown.bookmark = unknownChildren(b.Inner, "title", "desc", "info")
+		for _, e := range children(b.Info.Inner) {
+			if e.name.Local != "metadata" || e.attr("owner") != metadataOwner {
+				bookmark.unknown.info = append(bookmark.unknown.info, e.raw)
+			}
+		}
+		for _, m := range b.Info.Metadata {
+			if m.Owner != metadataOwner {
+				continue
+			}
+			bookmark.MIMEType = m.MIMEType.Type
+			bookmark.Icon, bookmark.IconType = m.Icon.Href, m.Icon.Type
+			bookmark.Groups = append(bookmark.Groups, m.Groups.Groups...)
+			bookmark.Private = bookmark.Private || m.Private != nil
+			for _, a := range m.Applications.Applications {
+				app := Application{Name: a.Name, Exec: a.Exec, Modified: parseTime(a.Modified)}
+				if app.Modified.IsZero() && a.Timestamp != "" {
+					if seconds, err := strconv.ParseInt(a.Timestamp, 10, 64); err == nil {
+						app.Modified = time.Unix(seconds, 0)
+					}
+				}
+				app.Count, _ = strconv.Atoi(a.Count)
+				bookmark.Applications = append(bookmark.Applications, app)
+			}
+			bookmark.unknown.metadata = append(bookmark.unknown.metadata,
+				unknownChildren(m.Inner, "mime-type", "icon", "groups", "applications", "private")...)
+		}
+		bookmarks = append(bookmarks, bookmark)
+	}
+	return bookmarks, unknown, nil
+}
+
+// escape returns text escaped for XML content and attribute values.
+func escape(text string) string {
+	var buf bytes.Buffer
+	xml.EscapeText(&buf, []byte(text))
+	return buf.String()
+}
+
+// writeRaw writes elements kept verbatim, one per line.
+func writeRaw(w io.Writer, indent string, elements []string) {
+	for _, element := range elements {
+		fmt.Fprintf(w, "%s%s\n", indent, element)
+	}
+}
+
+// writeXBEL encodes bookmarks as an XBEL file, along with the parts of the file
+// they were read from that this package doesn't understand. It is written by
+// hand rather than with encoding/xml so the elements of the spec use the
+// bookmark: and mime: prefixes other implementations expect.
+func writeXBEL(bookmarks []Bookmark, unknown fileXML) []byte {
+	var buf bytes.Buffer
+	buf.WriteString(xml.Header)
+	fmt.Fprintf(&buf, "<xbel version=\"1.0\"\n      xmlns:bookmark=\"%s\"\n      xmlns:mime=\"%s\"", nsBookmark, nsMIME)
+	for _, ns := range unknown.namespaces {
+		fmt.Fprintf(&buf, "\n      xmlns:%s=\"%s\"", ns.Name.Local, escape(ns.Value))
+	}
+	buf.WriteString("\n>\n")
+	writeRaw(&buf, "  ", unknown.elements)
+
+	for _, b := range bookmarks {
+		fmt.Fprintf(&buf, "  <bookmark href=\"%s\" added=\"%s\" modified=\"%s\" visited=\"%s\">\n",
+			escape(b.URI), formatTime(b.Added), formatTime(b.Modified), formatTime(b.Visited))
+		if b.Title != "" {
+			fmt.Fprintf(&buf, "    <title>%s</title>\n", escape(b.Title))
+		}
+		if b.Description != "" {
+			fmt.Fprintf(&buf, "    <desc>%s</desc>\n", escape(b.Description))
+		}
+		writeRaw(&buf, "    ", b.unknown.bookmark)
+		buf.WriteString("    <info>\n")
+		fmt.Fprintf(&buf, "      <metadata owner=\"%s\">\n", metadataOwner)
+		if b.MIMEType != "" {
+			fmt.Fprintf(&buf, "        <mime:mime-type type=\"%s\"/>\n", escape(b.MIMEType))
+		}
+		if b.Icon != "" {
+			fmt.Fprintf(&buf, "        <bookmark:icon href=\"%s\" type=\"%s\"/>\n", escape(b.Icon), escape(b.IconType))
+		}
+		if len(b.Groups) > 0 {
+			buf.WriteString("        <bookmark:groups>\n")
+			for _, group := range b.Groups {
+				fmt.Fprintf(&buf, "          <bookmark:group>%s</bookmark:group>\n", escape(group))
+			}
+			buf.WriteString("        </bookmark:groups>\n")
+		}
+		if len(b.Applications) > 0 {
+			buf.WriteString("        <bookmark:applications>\n")
+			for _, app := range b.Applications {
+				fmt.Fprintf(&buf, "          <bookmark:application name=\"%s\" exec=\"%s\" modified=\"%s\" count=\"%d\"/>\n",
+					escape(app.Name), escape(app.Exec), formatTime(app.Modified), app.Count)
+			}
+			buf.WriteString("        </bookmark:applications>\n")
+		}
+		if b.Private {
+			buf.WriteString("        <bookmark:private/>\n")
+		}
+		writeRaw(&buf, "        ", b.unknown.metadata)
+		buf.WriteString("      </metadata>\n")
+		writeRaw(&buf, "      ", b.unknown.info)
+		buf.WriteString("    </info>\n")
+		buf.WriteString("  </bookmark>\n")
+	}
+	buf.WriteString("</xbel>\n")
+	return buf.Bytes()
+}
@@ -0,0 +1,148 @@
+/*
+	libxdg-go - An implementaion of various freedesktop specifications in go
+    Copyright (C) 2025 MiracleOS Contributors
+
+    This program is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    This program is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+*/
+
+package recent
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+const foreignXBEL = `<?xml version="1.0" encoding="UTF-8"?>
+<xbel version="1.0"
+      xmlns:bookmark="http://www.freedesktop.org/standards/desktop-bookmarks"
+      xmlns:mime="http://www.freedesktop.org/standards/shared-mime-info"
+      xmlns:kde="http://www.kde.org"
+>
+  <separator/>
+  <bookmark href="file:///tmp/a.txt" added="2024-01-02T03:04:05.000000Z" modified="2024-01-02T03:04:05Z" visited="2024-01-02T03:04:05">
+    <title>A</title>
+    <kde:note>keep me</kde:note>
+    <info>
+      <metadata owner="http://freedesktop.org">
+        <mime:mime-type type="text/plain"/>
+        <bookmark:icon href="file:///icons/a.png" type="image/png"/>
+        <bookmark:applications>
+          <bookmark:application name="gedit" exec="&apos;gedit %u&apos;" modified="2024-01-02T03:04:05Z" count="2"/>
+        </bookmark:applications>
+        <bookmark:future attribute="x"/>
+      </metadata>
+      <metadata owner="http://www.kde.org">
+        <kde:state zoom="2">open</kde:state>
+      </metadata>
+    </info>
+  </bookmark>
+</xbel>
+`
+
+func TestXBELRoundTrip(t *testing.T) {
+	bookmarks, unknown, err := parseXBEL([]byte(foreignXBEL))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(bookmarks) != 1 {
+		t.Fatalf("got %d bookmarks, want 1", len(bookmarks))
+	}
+	b := bookmarks[0]
+	if b.Icon != "file:///icons/a.png" || b.IconType != "image/png" {
+		t.Errorf("got icon %q of type %q", b.Icon, b.IconType)
+	}
+	if b.Visited.IsZero() || b.Modified.IsZero() {
+		t.Errorf("timestamps not parsed: modified %v, visited %v", b.Modified, b.Visited)
+	}
+
+	written := string(writeXBEL(bookmarks, unknown))
+	for _, want := range []string{
+		`xmlns:kde="http://www.kde.org"`,
+		`<separator/>`,
+		`<kde:note>keep me</kde:note>`,
+		`<bookmark:icon href="file:///icons/a.png" type="image/png"/>`,
+		`<bookmark:future attribute="x"/>`,
+		"<metadata owner=\"http://www.kde.org\">\n        <kde:state zoom=\"2\">open</kde:state>\n      </metadata>",
+	} {
+		if !strings.Contains(written, want) {
+			t.Errorf("written file lacks %q:\n%s", want, written)
+		}
+	}
+
+	again, _, err := parseXBEL([]byte(written))
+	if err != nil {
+		t.Fatalf("parsing the written file: %v", err)
+	}
+	if len(again) != 1 || again[0].unknown.info[0] != b.unknown.info[0] || again[0].Applications[0] != b.Applications[0] {
+		t.Errorf("round trip changed the bookmark: got %+v, want %+v", again, bookmarks)
+	}
+}
+
+func TestSaveMergesConcurrentChanges(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "recently-used.xbel")
+	if err := os.WriteFile(path, []byte(foreignXBEL), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	first, err := LoadFrom(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := LoadFrom(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	first.Add(Entry{URI: "file:///tmp/b.txt", MIMEType: "text/plain", AppName: "first"})
+	second.Add(Entry{URI: "file:///tmp/c.txt", MIMEType: "text/plain", AppName: "second"})
+	second.Remove("file:///tmp/a.txt")
+	if err := first.Save(); err != nil {
+		t.Fatal(err)
+	}
+	if err := second.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	s, err := LoadFrom(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := s.Lookup("file:///tmp/b.txt"); !ok {
+		t.Error("the first writer's entry was lost")
+	}
+	if _, ok := s.Lookup("file:///tmp/c.txt"); !ok {
+		t.Error("the second writer's entry is missing")
+	}
+	if _, ok := s.Lookup("file:///tmp/a.txt"); ok {
+		t.Error("the removed entry is still there")
+	}
+	if len(s.unknown.elements) != 1 {
+		t.Errorf("got unknown elements %q, want the separator", s.unknown.elements)
+	}
+}
+
+func TestParseTime(t *testing.T) {
+	want := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	for _, value := range []string{"2024-01-02T03:04:05.000000Z", "2024-01-02T03:04:05Z", "2024-01-02T04:04:05+01:00", "2024-01-02T03:04:05", "1704164645"} {
+		if got := parseTime(value); !got.Equal(want) {
+			t.Errorf("parseTime(%q) = %v, want %v", value, got, want)
+		}
+	}
+	if got := parseTime("yesterday"); !got.IsZero() {
+		t.Errorf("parseTime of an invalid timestamp = %v, want the zero time", got)
+	}
+}
@@ -0,0 +1,185 @@
+/*
+	libxdg-go - An implementaion of various freedesktop specifications in go
+    Copyright (C) 2025 MiracleOS Contributors
+
+    This program is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    This program is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+*/
+
+package screenSaver
+
+import (
+	"errors"
+	"log/slog"
+	"strings"
+	"sync"
+
+	"github.com/godbus/dbus/v5"
+)
+
+// ErrReleased is returned when releasing an inhibition twice.
+var ErrReleased = errors.New("inhibition already released")
+
+// Inhibition keeps the screen saver from activating until released.
+type Inhibition struct {
+	c       *Client
+	appName string
+	reason  string
+	// path is where the service was reached, cookie what it returned; both
+	// change when the service restarts.
+	path     dbus.ObjectPath
+	cookie   uint32
+	released bool
+}
+
+// Cookie returns the cookie the service identifies the inhibition with.
+func (i *Inhibition) Cookie() uint32 {
+	i.c.mu.Lock()
+	defer i.c.mu.Unlock()
+	return i.cookie
+}
+
+// Release lifts the inhibition.
+func (i *Inhibition) Release() error {
+	c := i.c
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if i.released {
+		return ErrReleased
+	}
+	i.released = true
+	delete(c.inhibitions, i)
+	return c.conn.Object(busName, i.path).Call(interfaceName+".UnInhibit", 0, i.cookie).Err
+}
+
+// Client inhibits the screen saver over the session bus. Its inhibitions are
+// taken again when the service restarts, and lifted when the client closes.
+type Client struct {
+	conn        *dbus.Conn
+	mu          sync.Mutex
+	inhibitions map[*Inhibition]struct{}
+	signals     chan *dbus.Signal
+}
+
+// NewClient connects to the session bus.
+func NewClient() (*Client, error) {
+	conn, err := dbus.ConnectSessionBus()
+	if err != nil {
+		return nil, err
+	}
+	return newClient(conn)
+}
+
+// NewClientWithConn creates a client on an existing connection.
+// The connection is closed by Client.Close.
+func NewClientWithConn(conn *dbus.Conn) (*Client, error) {
+	return newClient(conn)
+}
+
+func newClient(conn *dbus.Conn) (*Client, error) {
+	c := &Client{
+		conn:        conn,
+		inhibitions: make(map[*Inhibition]struct{}),
+		signals:     make(chan *dbus.Signal, 16),
+	}
+
+	err := conn.AddMatchSignal(
+		dbus.WithMatchSender("org.freedesktop.DBus"),
+		dbus.WithMatchMember("NameOwnerChanged"),
+		dbus.WithMatchArg(0, busName),
+	)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	conn.Signal(c.signals)
+	go c.dispatch()
+
+	return c, nil
+}
+
+// Close releases the inhibitions and disconnects the client.
+func (c *Client) Close() error {
+	c.mu.Lock()
+	for i := range c.inhibitions {
+		i.released = true
+		c.conn.Object(busName, i.path).Call(interfaceName+".UnInhibit", 0, i.cookie)
+	}
+	clear(c.inhibitions)
+	c.mu.Unlock()
+
+	c.conn.RemoveSignal(c.signals)
+	return c.conn.Close()
+}
+
+// Conn returns the D-Bus connection of the client.
+func (c *Client) Conn() *dbus.Conn {
+	return c.conn
+}
+
+// Inhibit keeps the screen saver from activating, e.g. while a video plays.
+// appName and reason are shown to the user by some desktops.
+func (c *Client) Inhibit(appName, reason string) (*Inhibition, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	path, cookie, err := c.inhibit(appName, reason)
+	if err != nil {
+		return nil, err
+	}
+	i := &Inhibition{c: c, appName: appName, reason: reason, path: path, cookie: cookie}
+	c.inhibitions[i] = struct{}{}
+	return i, nil
+}
+
+// inhibit calls Inhibit, at the legacy path if the service doesn't export the
+// standard one.
+func (c *Client) inhibit(appName, reason string) (dbus.ObjectPath, uint32, error) {
+	var cookie uint32
+	err := c.conn.Object(busName, objectPath).Call(interfaceName+".Inhibit", 0, appName, reason).Store(&cookie)
+	var dbusErr dbus.Error
+	if errors.As(err, &dbusErr) && strings.HasPrefix(dbusErr.Name, "org.freedesktop.DBus.Error.Unknown") {
+		err = c.conn.Object(busName, legacyPath).Call(interfaceName+".Inhibit", 0, appName, reason).Store(&cookie)
+		return legacyPath, cookie, err
+	}
+	return objectPath, cookie, err
+}
+
+// Inhibited reports whether the client holds inhibitions.
+func (c *Client) Inhibited() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.inhibitions) > 0
+}
+
+// dispatch takes the inhibitions again when a new service starts.
+func (c *Client) dispatch() {
+	for sig := range c.signals {
+		var name, oldOwner, newOwner string
+		if err := dbus.Store(sig.Body, &name, &oldOwner, &newOwner); err != nil || name != busName || newOwner == "" {
+			continue
+		}
+		c.mu.Lock()
+		for i := range c.inhibitions {
+			path, cookie, err := c.inhibit(i.appName, i.reason)
+			if err != nil {
+				slog.Warn("Failed to inhibit the screen saver again", "app", i.appName, "error", err)
+				continue
+			}
+			i.path, i.cookie = path, cookie
+		}
+		c.mu.Unlock()
+	}
+}
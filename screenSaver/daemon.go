@@ -0,0 +1,446 @@
+/*
+	libxdg-go - An implementaion of various freedesktop specifications in go
+    Copyright (C) 2025 MiracleOS Contributors
+
+    This program is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    This program is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+*/
+
+package screenSaver
+
+import (
+	"errors"
+	"log/slog"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/godbus/dbus/v5"
+	"github.com/godbus/dbus/v5/introspect"
+)
+
+// DaemonConfig configures a Daemon.
+type DaemonConfig struct {
+	// Conn is the connection to serve on, left open by Stop. The session bus is
+	// used if nil.
+	Conn *dbus.Conn
+	// BusAddress is the address of the bus to connect to if Conn is nil, the
+	// session bus if empty.
+	BusAddress string
+}
+
+// Inhibitor is an inhibition held by a client of the daemon.
+type Inhibitor struct {
+	Cookie  uint32
+	AppName string
+	Reason  string
+	// Sender is the unique bus name of the client, whose inhibitions are dropped
+	// when it leaves the bus.
+	Sender string
+	Since  time.Time
+}
+
+// Event reports a change to the daemon's state. Inhibited is the aggregate
+// state after the change, and InhibitedChanged whether it flipped.
+type Event struct {
+	Inhibitor        Inhibitor
+	Added            bool
+	Removed          bool
+	Inhibited        bool
+	InhibitedChanged bool
+	// ActiveChanged is set when SetActive changed whether the screen saver runs.
+	ActiveChanged bool
+	Active        bool
+	// ActivationRequested is set when a client asked for the screen saver to be
+	// turned on or off, as told by Active; the consumer decides and calls SetActive.
+	ActivationRequested bool
+	// LockRequested is set when a client asked for the session to be locked.
+	LockRequested bool
+	// ActivitySimulated is set when a client reported user activity, which
+	// should reset the idle timer.
+	ActivitySimulated bool
+}
+
+// daemonSubscriber is a consumer of the daemon's events.
+type daemonSubscriber struct {
+	events chan Event
+}
+
+// Daemon implements the org.freedesktop.ScreenSaver interface for the
+// compositor or power manager, which follows the inhibitions and tells the
+// daemon when the screen saver runs.
+type Daemon struct {
+	config      DaemonConfig
+	conn        *dbus.Conn
+	mu          sync.Mutex
+	inhibitors  map[uint32]Inhibitor
+	nextCookie  uint32
+	active      bool
+	activeSince time.Time
+	subscribers map[*daemonSubscriber]struct{}
+	signals     chan *dbus.Signal
+	started     bool
+	stopped     bool
+}
+
+// screenSaverObject holds the methods the daemon exports.
+type screenSaverObject struct {
+	d *Daemon
+}
+
+// NewDaemon creates a daemon, served by Start.
+func NewDaemon(config DaemonConfig) *Daemon {
+	return &Daemon{
+		config:      config,
+		inhibitors:  make(map[uint32]Inhibitor),
+		nextCookie:  1,
+		subscribers: make(map[*daemonSubscriber]struct{}),
+		signals:     make(chan *dbus.Signal, 64),
+	}
+}
+
+// Start serves the interface and takes the org.freedesktop.ScreenSaver name.
+func (d *Daemon) Start() error {
+	conn := d.config.Conn
+	if conn == nil {
+		var err error
+		if d.config.BusAddress == "" {
+			conn, err = dbus.ConnectSessionBus()
+		} else {
+			conn, err = dbus.Connect(d.config.BusAddress)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	d.conn = conn
+
+	// Clients leaving the bus lose their inhibitions.
+	err := conn.AddMatchSignal(
+		dbus.WithMatchSender("org.freedesktop.DBus"),
+		dbus.WithMatchMember("NameOwnerChanged"),
+	)
+	if err != nil {
+		d.disconnectBus()
+		return err
+	}
+	conn.Signal(d.signals)
+	go d.dispatch()
+
+	for _, path := range []dbus.ObjectPath{objectPath, legacyPath} {
+		if err := d.export(path); err != nil {
+			d.unexport()
+			return err
+		}
+	}
+
+	reply, err := conn.RequestName(busName, dbus.NameFlagDoNotQueue)
+	if err == nil && reply != dbus.RequestNameReplyPrimaryOwner {
+		err = errors.New("screen saver service is already running (bus name taken)")
+	}
+	if err != nil {
+		d.unexport()
+		return err
+	}
+
+	d.mu.Lock()
+	d.started = true
+	d.mu.Unlock()
+	slog.Info("Screen saver service started on DBus as org.freedesktop.ScreenSaver")
+	return nil
+}
+
+func (d *Daemon) export(path dbus.ObjectPath) error {
+	if err := d.conn.Export(screenSaverObject{d}, path, interfaceName); err != nil {
+		return err
+	}
+	node := &introspect.Node{
+		Name: string(path),
+		Interfaces: []introspect.Interface{
+			{
+				Name: interfaceName,
+				Methods: []introspect.Method{
+					{
+						Name: "Inhibit",
+						Args: []introspect.Arg{
+							{Name: "application_name", Type: "s", Direction: "in"},
+							{Name: "reason_for_inhibit", Type: "s", Direction: "in"},
+							{Name: "cookie", Type: "u", Direction: "out"},
+						},
+					},
+					{
+						Name: "UnInhibit",
+						Args: []introspect.Arg{
+							{Name: "cookie", Type: "u", Direction: "in"},
+						},
+					},
+					{
+						Name: "GetActive",
+						Args: []introspect.Arg{
+							{Name: "active", Type: "b", Direction: "out"},
+						},
+					},
+					{
+						Name: "SetActive",
+						Args: []introspect.Arg{
+							{Name: "active", Type: "b", Direction: "in"},
+							{Name: "success", Type: "b", Direction: "out"},
+						},
+					},
+					{
+						Name: "GetActiveTime",
+						Args: []introspect.Arg{
+							{Name: "seconds", Type: "u", Direction: "out"},
+						},
+					},
+					{Name: "Lock"},
+					{Name: "SimulateUserActivity"},
+				},
+				Signals: []introspect.Signal{
+					{
+						Name: "ActiveChanged",
+						Args: []introspect.Arg{
+							{Name: "active", Type: "b"},
+						},
+					},
+				},
+			},
+			introspect.IntrospectData,
+		},
+	}
+	return d.conn.Export(introspect.NewIntrospectable(node), path, "org.freedesktop.DBus.Introspectable")
+}
+
+// unexport undoes Start.
+func (d *Daemon) unexport() {
+	for _, path := range []dbus.ObjectPath{objectPath, legacyPath} {
+		d.conn.Export(nil, path, interfaceName)
+		d.conn.Export(nil, path, "org.freedesktop.DBus.Introspectable")
+	}
+	d.conn.RemoveSignal(d.signals)
+	d.conn.RemoveMatchSignal(
+		dbus.WithMatchSender("org.freedesktop.DBus"),
+		dbus.WithMatchMember("NameOwnerChanged"),
+	)
+	close(d.signals)
+	d.disconnectBus()
+}
+
+// disconnectBus closes the bus connection unless it was given in DaemonConfig.Conn.
+func (d *Daemon) disconnectBus() {
+	if d.conn != d.config.Conn {
+		d.conn.Close()
+	}
+}
+
+// Stop releases the name and closes the subscriber channels. Calling Stop more
+// than once is a no-op.
+func (d *Daemon) Stop() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.stopped {
+		return
+	}
+	d.stopped = true
+	for s := range d.subscribers {
+		close(s.events)
+		delete(d.subscribers, s)
+	}
+	if d.started {
+		d.conn.ReleaseName(busName)
+		d.unexport()
+	}
+}
+
+// Subscribe registers a consumer of the daemon's events. Events that don't fit
+// in the buffer are dropped. The channel is closed by cancel or by Stop.
+func (d *Daemon) Subscribe(buffer int) (<-chan Event, func()) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	s := &daemonSubscriber{events: make(chan Event, buffer)}
+	if d.stopped {
+		close(s.events)
+		return s.events, func() {}
+	}
+	d.subscribers[s] = struct{}{}
+
+	cancel := func() {
+		d.mu.Lock()
+		defer d.mu.Unlock()
+
+		if _, exists := d.subscribers[s]; exists {
+			delete(d.subscribers, s)
+			close(s.events)
+		}
+	}
+	return s.events, cancel
+}
+
+// publishLocked hands an event to the subscribers. d.mu must be held.
+func (d *Daemon) publishLocked(event Event) {
+	for s := range d.subscribers {
+		select {
+		case s.events <- event:
+		default:
+		}
+	}
+}
+
+// Inhibitors returns the inhibitions held, oldest first.
+func (d *Daemon) Inhibitors() []Inhibitor {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	inhibitors := make([]Inhibitor, 0, len(d.inhibitors))
+	for _, i := range d.inhibitors {
+		inhibitors = append(inhibitors, i)
+	}
+	slices.SortFunc(inhibitors, func(a, b Inhibitor) int { return int(a.Cookie) - int(b.Cookie) })
+	return inhibitors
+}
+
+// Inhibited reports whether any client inhibits the screen saver.
+func (d *Daemon) Inhibited() bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return len(d.inhibitors) > 0
+}
+
+// Active reports whether the screen saver runs, as last set by SetActive.
+func (d *Daemon) Active() bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.active
+}
+
+// SetActive records whether the screen saver runs, telling the clients.
+func (d *Daemon) SetActive(active bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.active == active {
+		return
+	}
+	d.active = active
+	if active {
+		d.activeSince = time.Now()
+	}
+	if d.started && !d.stopped {
+		for _, path := range []dbus.ObjectPath{objectPath, legacyPath} {
+			d.conn.Emit(path, interfaceName+".ActiveChanged", active)
+		}
+	}
+	d.publishLocked(Event{ActiveChanged: true, Active: active, Inhibited: len(d.inhibitors) > 0})
+}
+
+// addLocked records an inhibition. d.mu must be held.
+func (d *Daemon) addLocked(sender, appName, reason string) uint32 {
+	for d.nextCookie == 0 || d.inhibitors[d.nextCookie].Cookie != 0 {
+		d.nextCookie++
+	}
+	i := Inhibitor{Cookie: d.nextCookie, AppName: appName, Reason: reason, Sender: sender, Since: time.Now()}
+	d.nextCookie++
+	d.inhibitors[i.Cookie] = i
+	d.publishLocked(Event{Inhibitor: i, Added: true, Inhibited: true, InhibitedChanged: len(d.inhibitors) == 1})
+	return i.Cookie
+}
+
+// removeLocked drops an inhibition. d.mu must be held.
+func (d *Daemon) removeLocked(cookie uint32) bool {
+	i, found := d.inhibitors[cookie]
+	if !found {
+		return false
+	}
+	delete(d.inhibitors, cookie)
+	inhibited := len(d.inhibitors) > 0
+	d.publishLocked(Event{Inhibitor: i, Removed: true, Inhibited: inhibited, InhibitedChanged: !inhibited})
+	return true
+}
+
+// dispatch drops the inhibitions of clients that left the bus.
+func (d *Daemon) dispatch() {
+	for sig := range d.signals {
+		var name, oldOwner, newOwner string
+		if err := dbus.Store(sig.Body, &name, &oldOwner, &newOwner); err != nil || newOwner != "" || name != oldOwner {
+			continue
+		}
+		d.mu.Lock()
+		for cookie, i := range d.inhibitors {
+			if i.Sender == name {
+				slog.Debug("Dropping the screen saver inhibition of a vanished client", "app", i.AppName, "cookie", cookie)
+				d.removeLocked(cookie)
+			}
+		}
+		d.mu.Unlock()
+	}
+}
+
+func (o screenSaverObject) Inhibit(sender dbus.Sender, appName, reason string) (uint32, *dbus.Error) {
+	d := o.d
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.addLocked(string(sender), appName, reason), nil
+}
+
+func (o screenSaverObject) UnInhibit(sender dbus.Sender, cookie uint32) *dbus.Error {
+	d := o.d
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if i, found := d.inhibitors[cookie]; !found || i.Sender != string(sender) {
+		return dbus.MakeFailedError(errors.New("unknown inhibition cookie"))
+	}
+	d.removeLocked(cookie)
+	return nil
+}
+
+func (o screenSaverObject) GetActive() (bool, *dbus.Error) {
+	return o.d.Active(), nil
+}
+
+func (o screenSaverObject) SetActive(active bool) (bool, *dbus.Error) {
+	d := o.d
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.publishLocked(Event{ActivationRequested: true, Active: active, Inhibited: len(d.inhibitors) > 0})
+	return true, nil
+}
+
+func (o screenSaverObject) GetActiveTime() (uint32, *dbus.Error) {
+	d := o.d
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if !d.active {
+		return 0, nil
+	}
+	return uint32(time.Since(d.activeSince).Seconds()), nil
+}
+
+func (o screenSaverObject) Lock() *dbus.Error {
+	d := o.d
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.publishLocked(Event{LockRequested: true, Active: d.active, Inhibited: len(d.inhibitors) > 0})
+	return nil
+}
+
+func (o screenSaverObject) SimulateUserActivity() *dbus.Error {
+	d := o.d
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.publishLocked(Event{ActivitySimulated: true, Active: d.active, Inhibited: len(d.inhibitors) > 0})
+	return nil
+}
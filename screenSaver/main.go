@@ -0,0 +1,34 @@
+/*
+	libxdg-go - An implementaion of various freedesktop specifications in go
+    Copyright (C) 2025 MiracleOS Contributors
+
+    This program is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    This program is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+*/
+
+// Package screenSaver implements the org.freedesktop.ScreenSaver idle
+// inhibition interface, used by applications such as video players to keep
+// the screen on.
+package screenSaver
+
+import "github.com/godbus/dbus/v5"
+
+const (
+	busName       = "org.freedesktop.ScreenSaver"
+	objectPath    = dbus.ObjectPath("/org/freedesktop/ScreenSaver")
+	interfaceName = "org.freedesktop.ScreenSaver"
+)
+
+// legacyPath is the path older implementations export the interface at only.
+const legacyPath = dbus.ObjectPath("/ScreenSaver")
@@ -0,0 +1,110 @@
+/*
+	libxdg-go - An implementaion of various freedesktop specifications in go
+    Copyright (C) 2025 MiracleOS Contributors
+
+    This program is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    This program is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+*/
+
+package secrets
+
+import (
+	"context"
+
+	"github.com/godbus/dbus/v5"
+)
+
+// Collection is a set of items, unlocked together, such as a keyring.
+type Collection struct {
+	c    *Client
+	Path dbus.ObjectPath
+}
+
+func (c *Client) collection(path dbus.ObjectPath) *Collection {
+	return &Collection{c: c, Path: path}
+}
+
+// ObjectPath returns the path of the collection.
+func (col *Collection) ObjectPath() dbus.ObjectPath {
+	return col.Path
+}
+
+// Label returns the name of the collection shown to the user.
+func (col *Collection) Label() (string, error) {
+	var label string
+	err := col.c.conn.Object(busName, col.Path).StoreProperty(collectionInterface+".Label", &label)
+	return label, err
+}
+
+// Locked reports whether the collection must be unlocked to read its secrets.
+func (col *Collection) Locked() (bool, error) {
+	return col.c.locked(col.Path, collectionInterface)
+}
+
+// Items lists the items of the collection.
+func (col *Collection) Items() ([]*Item, error) {
+	var paths []dbus.ObjectPath
+	if err := col.c.conn.Object(busName, col.Path).StoreProperty(collectionInterface+".Items", &paths); err != nil {
+		return nil, err
+	}
+	return col.c.items(paths), nil
+}
+
+// SearchItems returns the items of the collection whose attributes include
+// attributes.
+func (col *Collection) SearchItems(attributes map[string]string) ([]*Item, error) {
+	var paths []dbus.ObjectPath
+	if err := col.c.conn.Object(busName, col.Path).Call(collectionInterface+".SearchItems", 0, attributes).Store(&paths); err != nil {
+		return nil, err
+	}
+	return col.c.items(paths), nil
+}
+
+// CreateItem stores a secret, found later by its attributes. With replace, an
+// item with the same attributes is updated instead of adding another one.
+// contentType is usually "text/plain". The collection must be unlocked.
+func (col *Collection) CreateItem(ctx context.Context, label string, attributes map[string]string, value []byte, contentType string, replace bool) (*Item, error) {
+	s, err := col.c.currentSession()
+	if err != nil {
+		return nil, err
+	}
+	sec, err := s.encode(value, contentType)
+	if err != nil {
+		return nil, err
+	}
+	props := map[string]dbus.Variant{
+		itemInterface + ".Label":      dbus.MakeVariant(label),
+		itemInterface + ".Attributes": dbus.MakeVariant(attributes),
+	}
+	var path, prompt dbus.ObjectPath
+	err = col.c.conn.Object(busName, col.Path).Call(collectionInterface+".CreateItem", 0, props, sec, replace).Store(&path, &prompt)
+	if err != nil {
+		return nil, err
+	}
+	if prompt != noPath {
+		result, err := col.c.prompt(ctx, prompt)
+		if err != nil {
+			return nil, err
+		}
+		if err := result.Store(&path); err != nil {
+			return nil, err
+		}
+	}
+	return col.c.item(path), nil
+}
+
+// Delete deletes the collection and its items.
+func (col *Collection) Delete(ctx context.Context) error {
+	return col.c.delete(ctx, col.Path, collectionInterface)
+}
@@ -0,0 +1,111 @@
+/*
+	libxdg-go - An implementaion of various freedesktop specifications in go
+    Copyright (C) 2025 MiracleOS Contributors
+
+    This program is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    This program is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+*/
+
+package secrets
+
+import (
+	"context"
+
+	"github.com/godbus/dbus/v5"
+)
+
+// Item is a secret with its label and attributes.
+type Item struct {
+	c    *Client
+	Path dbus.ObjectPath
+}
+
+func (c *Client) item(path dbus.ObjectPath) *Item {
+	return &Item{c: c, Path: path}
+}
+
+func (c *Client) items(paths []dbus.ObjectPath) []*Item {
+	items := make([]*Item, 0, len(paths))
+	for _, path := range paths {
+		items = append(items, c.item(path))
+	}
+	return items
+}
+
+// ObjectPath returns the path of the item.
+func (i *Item) ObjectPath() dbus.ObjectPath {
+	return i.Path
+}
+
+// Label returns the name of the item shown to the user.
+func (i *Item) Label() (string, error) {
+	var label string
+	err := i.c.conn.Object(busName, i.Path).StoreProperty(itemInterface+".Label", &label)
+	return label, err
+}
+
+// SetLabel renames the item.
+func (i *Item) SetLabel(label string) error {
+	return i.c.conn.Object(busName, i.Path).SetProperty(itemInterface+".Label", dbus.MakeVariant(label))
+}
+
+// Attributes returns the attributes the item is found by.
+func (i *Item) Attributes() (map[string]string, error) {
+	var attributes map[string]string
+	err := i.c.conn.Object(busName, i.Path).StoreProperty(itemInterface+".Attributes", &attributes)
+	return attributes, err
+}
+
+// SetAttributes replaces the attributes of the item.
+func (i *Item) SetAttributes(attributes map[string]string) error {
+	return i.c.conn.Object(busName, i.Path).SetProperty(itemInterface+".Attributes", dbus.MakeVariant(attributes))
+}
+
+// Locked reports whether the item must be unlocked to read its secret.
+func (i *Item) Locked() (bool, error) {
+	return i.c.locked(i.Path, itemInterface)
+}
+
+// Secret returns the secret of the item and its content type. The item must
+// be unlocked.
+func (i *Item) Secret() ([]byte, string, error) {
+	s, err := i.c.currentSession()
+	if err != nil {
+		return nil, "", err
+	}
+	var sec secret
+	if err := i.c.conn.Object(busName, i.Path).Call(itemInterface+".GetSecret", 0, s.path).Store(&sec); err != nil {
+		return nil, "", err
+	}
+	value, err := s.decode(sec)
+	return value, sec.ContentType, err
+}
+
+// SetSecret replaces the secret of the item.
+func (i *Item) SetSecret(value []byte, contentType string) error {
+	s, err := i.c.currentSession()
+	if err != nil {
+		return err
+	}
+	sec, err := s.encode(value, contentType)
+	if err != nil {
+		return err
+	}
+	return i.c.conn.Object(busName, i.Path).Call(itemInterface+".SetSecret", 0, sec).Err
+}
+
+// Delete deletes the item.
+func (i *Item) Delete(ctx context.Context) error {
+	return i.c.delete(ctx, i.Path, itemInterface)
+}
@@ -0,0 +1,210 @@
+/*
+	libxdg-go - An implementaion of various freedesktop specifications in go
+    Copyright (C) 2025 MiracleOS Contributors
+
+    This program is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    This program is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+*/
+
+// Package secrets is a client of the Secret Service API, org.freedesktop.secrets,
+// implemented by keyrings such as GNOME Keyring and KWallet: it stores secrets
+// like passwords in collections, found by their attributes.
+package secrets
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/godbus/dbus/v5"
+)
+
+const (
+	busName             = "org.freedesktop.secrets"
+	servicePath         = dbus.ObjectPath("/org/freedesktop/secrets")
+	serviceInterface    = "org.freedesktop.Secret.Service"
+	collectionInterface = "org.freedesktop.Secret.Collection"
+	itemInterface       = "org.freedesktop.Secret.Item"
+	promptInterface     = "org.freedesktop.Secret.Prompt"
+)
+
+// noPath is the path methods return when there is no object, or no prompt.
+const noPath = dbus.ObjectPath("/")
+
+// DefaultAlias is the alias of the collection secrets go to by default, the
+// login keyring.
+const DefaultAlias = "default"
+
+var (
+	// ErrDismissed is returned when the user dismissed a prompt.
+	ErrDismissed = errors.New("secret service prompt dismissed")
+	// ErrNotFound is returned for a missing collection or item.
+	ErrNotFound = errors.New("secret not found")
+)
+
+// Client talks to the secret service over the session bus.
+type Client struct {
+	conn    *dbus.Conn
+	obj     dbus.BusObject
+	mu      sync.Mutex
+	session *session
+}
+
+// NewClient connects to the session bus.
+func NewClient() (*Client, error) {
+	conn, err := dbus.ConnectSessionBus()
+	if err != nil {
+		return nil, err
+	}
+	return NewClientWithConn(conn)
+}
+
+// NewClientWithConn creates a client on an existing connection.
+// The connection is closed by Client.Close.
+func NewClientWithConn(conn *dbus.Conn) (*Client, error) {
+	return &Client{conn: conn, obj: conn.Object(busName, servicePath)}, nil
+}
+
+// Close closes the session and disconnects the client.
+func (c *Client) Close() error {
+	c.mu.Lock()
+	if c.session != nil {
+		c.conn.Object(busName, c.session.path).Call("org.freedesktop.Secret.Session.Close", 0)
+		c.session = nil
+	}
+	c.mu.Unlock()
+	return c.conn.Close()
+}
+
+// Conn returns the D-Bus connection of the client.
+func (c *Client) Conn() *dbus.Conn {
+	return c.conn
+}
+
+// DefaultCollection returns the default collection, creating it if the
+// service has none, which may prompt the user.
+func (c *Client) DefaultCollection(ctx context.Context) (*Collection, error) {
+	collection, err := c.CollectionByAlias(DefaultAlias)
+	if errors.Is(err, ErrNotFound) {
+		return c.CreateCollection(ctx, "Default keyring", DefaultAlias)
+	}
+	return collection, err
+}
+
+// CollectionByAlias returns the collection an alias points to.
+func (c *Client) CollectionByAlias(alias string) (*Collection, error) {
+	var path dbus.ObjectPath
+	if err := c.obj.Call(serviceInterface+".ReadAlias", 0, alias).Store(&path); err != nil {
+		return nil, err
+	}
+	if path == noPath {
+		return nil, ErrNotFound
+	}
+	return c.collection(path), nil
+}
+
+// Collections lists the collections.
+func (c *Client) Collections() ([]*Collection, error) {
+	var paths []dbus.ObjectPath
+	if err := c.obj.StoreProperty(serviceInterface+".Collections", &paths); err != nil {
+		return nil, err
+	}
+	collections := make([]*Collection, 0, len(paths))
+	for _, path := range paths {
+		collections = append(collections, c.collection(path))
+	}
+	return collections, nil
+}
+
+// CreateCollection creates a collection, which usually prompts the user for
+// its password. alias may be empty.
+func (c *Client) CreateCollection(ctx context.Context, label, alias string) (*Collection, error) {
+	props := map[string]dbus.Variant{collectionInterface + ".Label": dbus.MakeVariant(label)}
+	var path, prompt dbus.ObjectPath
+	if err := c.obj.Call(serviceInterface+".CreateCollection", 0, props, alias).Store(&path, &prompt); err != nil {
+		return nil, err
+	}
+	if prompt != noPath {
+		result, err := c.prompt(ctx, prompt)
+		if err != nil {
+			return nil, err
+		}
+		if err := result.Store(&path); err != nil {
+			return nil, err
+		}
+	}
+	return c.collection(path), nil
+}
+
+// SearchItems returns the items of all collections whose attributes include
+// attributes, unlocked ones first.
+func (c *Client) SearchItems(attributes map[string]string) (unlocked, locked []*Item, err error) {
+	var unlockedPaths, lockedPaths []dbus.ObjectPath
+	if err := c.obj.Call(serviceInterface+".SearchItems", 0, attributes).Store(&unlockedPaths, &lockedPaths); err != nil {
+		return nil, nil, err
+	}
+	return c.items(unlockedPaths), c.items(lockedPaths), nil
+}
+
+// Unlock unlocks collections or items, prompting the user if needed.
+func (c *Client) Unlock(ctx context.Context, objects ...Object) error {
+	return c.setLocked(ctx, "Unlock", objects)
+}
+
+// Lock locks collections or items.
+func (c *Client) Lock(ctx context.Context, objects ...Object) error {
+	return c.setLocked(ctx, "Lock", objects)
+}
+
+func (c *Client) setLocked(ctx context.Context, method string, objects []Object) error {
+	paths := make([]dbus.ObjectPath, 0, len(objects))
+	for _, o := range objects {
+		paths = append(paths, o.ObjectPath())
+	}
+	var done []dbus.ObjectPath
+	var prompt dbus.ObjectPath
+	if err := c.obj.Call(serviceInterface+"."+method, 0, paths).Store(&done, &prompt); err != nil {
+		return err
+	}
+	if prompt != noPath {
+		_, err := c.prompt(ctx, prompt)
+		return err
+	}
+	return nil
+}
+
+// Object is a collection or an item.
+type Object interface {
+	ObjectPath() dbus.ObjectPath
+}
+
+// locked reads the Locked property of a collection or item.
+func (c *Client) locked(path dbus.ObjectPath, iface string) (bool, error) {
+	var locked bool
+	err := c.conn.Object(busName, path).StoreProperty(iface+".Locked", &locked)
+	return locked, err
+}
+
+// delete deletes a collection or item, prompting the user if needed.
+func (c *Client) delete(ctx context.Context, path dbus.ObjectPath, iface string) error {
+	var prompt dbus.ObjectPath
+	if err := c.conn.Object(busName, path).Call(iface+".Delete", 0).Store(&prompt); err != nil {
+		return err
+	}
+	if prompt != noPath {
+		_, err := c.prompt(ctx, prompt)
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,68 @@
+/*
+	libxdg-go - An implementaion of various freedesktop specifications in go
+    Copyright (C) 2025 MiracleOS Contributors
+
+    This program is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    This program is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+*/
+
+package secrets
+
+import (
+	"context"
+
+	"github.com/godbus/dbus/v5"
+)
+
+// prompt shows a prompt and waits for the user to complete it, returning its
+// result. The prompt is dismissed if ctx is done first.
+func (c *Client) prompt(ctx context.Context, path dbus.ObjectPath) (dbus.Variant, error) {
+	match := []dbus.MatchOption{
+		dbus.WithMatchObjectPath(path),
+		dbus.WithMatchInterface(promptInterface),
+		dbus.WithMatchMember("Completed"),
+	}
+	if err := c.conn.AddMatchSignal(match...); err != nil {
+		return dbus.Variant{}, err
+	}
+	defer c.conn.RemoveMatchSignal(match...)
+	signals := make(chan *dbus.Signal, 4)
+	c.conn.Signal(signals)
+	defer c.conn.RemoveSignal(signals)
+
+	obj := c.conn.Object(busName, path)
+	if err := obj.Call(promptInterface+".Prompt", 0, "").Err; err != nil {
+		return dbus.Variant{}, err
+	}
+	for {
+		select {
+		case sig := <-signals:
+			if sig.Path != path || sig.Name != promptInterface+".Completed" {
+				continue
+			}
+			var dismissed bool
+			var result dbus.Variant
+			if err := dbus.Store(sig.Body, &dismissed, &result); err != nil {
+				return dbus.Variant{}, err
+			}
+			if dismissed {
+				return dbus.Variant{}, ErrDismissed
+			}
+			return result, nil
+		case <-ctx.Done():
+			obj.Call(promptInterface+".Dismiss", 0)
+			return dbus.Variant{}, ctx.Err()
+		}
+	}
+}
@@ -0,0 +1,187 @@
+/*
+	libxdg-go - An implementaion of various freedesktop specifications in go
+    Copyright (C) 2025 MiracleOS Contributors
+
+    This program is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    This program is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+*/
+
+package secrets
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"errors"
+	"math/big"
+
+	"github.com/godbus/dbus/v5"
+)
+
+// Session algorithms.
+const (
+	algorithmPlain = "plain"
+	algorithmDH    = "dh-ietf1024-sha256-aes128-cbc-pkcs7"
+)
+
+// dhPrime is the 1024-bit MODP group of RFC 2409, section 6.2, with generator 2.
+var dhPrime, _ = new(big.Int).SetString(
+	"FFFFFFFFFFFFFFFFC90FDAA22168C234C4C6628B80DC1CD1"+
+		"29024E088A67CC74020BBEA63B139B22514A08798E3404DD"+
+		"EF9519B3CD3A431B302B0A6DF25F14374FE1356D6D51C245"+
+		"E485B576625E7EC6F44C42E9A637ED6B0BFF5CB6F406B7ED"+
+		"EE386BFB5A899FA5AE9F24117C4B1FE649286651ECE65381"+
+		"FFFFFFFFFFFFFFFF", 16)
+
+// session is an open secret service session. Secrets are sent in the clear
+// over plain sessions, which have no key.
+type session struct {
+	path dbus.ObjectPath
+	key  []byte
+}
+
+// secret is the (oayays) wire form of a secret.
+type secret struct {
+	Session     dbus.ObjectPath
+	Parameters  []byte
+	Value       []byte
+	ContentType string
+}
+
+// OpenSession opens the session secrets are transferred in, replacing the
+// current one. An encrypted session keeps secrets out of bus monitors; some
+// services only support plain ones. Clients open an encrypted session on first
+// use, falling back to a plain one.
+func (c *Client) OpenSession(encrypted bool) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	s, err := c.openSession(encrypted)
+	if err != nil {
+		return err
+	}
+	if c.session != nil {
+		c.conn.Object(busName, c.session.path).Call("org.freedesktop.Secret.Session.Close", 0)
+	}
+	c.session = s
+	return nil
+}
+
+// currentSession returns the session, opening one on first use.
+func (c *Client) currentSession() (*session, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.session != nil {
+		return c.session, nil
+	}
+	s, err := c.openSession(true)
+	var dbusErr dbus.Error
+	if errors.As(err, &dbusErr) && dbusErr.Name == "org.freedesktop.DBus.Error.NotSupported" {
+		s, err = c.openSession(false)
+	}
+	if err != nil {
+		return nil, err
+	}
+	c.session = s
+	return s, nil
+}
+
+func (c *Client) openSession(encrypted bool) (*session, error) {
+	var output dbus.Variant
+	var path dbus.ObjectPath
+	if !encrypted {
+		err := c.obj.Call(serviceInterface+".OpenSession", 0, algorithmPlain, dbus.MakeVariant("")).Store(&output, &path)
+		return &session{path: path}, err
+	}
+
+	private, err := rand.Int(rand.Reader, new(big.Int).Sub(dhPrime, big.NewInt(2)))
+	if err != nil {
+		return nil, err
+	}
+	private.Add(private, big.NewInt(1))
+	public := new(big.Int).Exp(big.NewInt(2), private, dhPrime)
+	if err := c.obj.Call(serviceInterface+".OpenSession", 0, algorithmDH, dbus.MakeVariant(public.Bytes())).Store(&output, &path); err != nil {
+		return nil, err
+	}
+	serverPublic, ok := output.Value().([]byte)
+	if !ok {
+		return nil, errors.New("secret service returned an invalid public key")
+	}
+
+	shared := new(big.Int).Exp(new(big.Int).SetBytes(serverPublic), private, dhPrime)
+	sharedBytes := shared.FillBytes(make([]byte, (dhPrime.BitLen()+7)/8))
+	return &session{path: path, key: hkdf(sharedBytes, 16)}, nil
+}
+
+// hkdf derives a key from a shared secret with HKDF-SHA256 (RFC 5869), with no
+// salt and no info.
+func hkdf(secret []byte, length int) []byte {
+	extract := hmac.New(sha256.New, make([]byte, sha256.Size))
+	extract.Write(secret)
+	prk := extract.Sum(nil)
+
+	var okm, block []byte
+	for i := byte(1); len(okm) < length; i++ {
+		expand := hmac.New(sha256.New, prk)
+		expand.Write(block)
+		expand.Write([]byte{i})
+		block = expand.Sum(nil)
+		okm = append(okm, block...)
+	}
+	return okm[:length]
+}
+
+// encode prepares a secret for the session.
+func (s *session) encode(value []byte, contentType string) (secret, error) {
+	if s.key == nil {
+		return secret{s.path, []byte{}, value, contentType}, nil
+	}
+	block, err := aes.NewCipher(s.key)
+	if err != nil {
+		return secret{}, err
+	}
+	iv := make([]byte, aes.BlockSize)
+	if _, err := rand.Read(iv); err != nil {
+		return secret{}, err
+	}
+	padding := aes.BlockSize - len(value)%aes.BlockSize
+	padded := append(bytes.Clone(value), bytes.Repeat([]byte{byte(padding)}, padding)...)
+	cipher.NewCBCEncrypter(block, iv).CryptBlocks(padded, padded)
+	return secret{s.path, iv, padded, contentType}, nil
+}
+
+// decode reads a secret received in the session.
+func (s *session) decode(sec secret) ([]byte, error) {
+	if s.key == nil {
+		return sec.Value, nil
+	}
+	block, err := aes.NewCipher(s.key)
+	if err != nil {
+		return nil, err
+	}
+	if len(sec.Parameters) != aes.BlockSize || len(sec.Value) == 0 || len(sec.Value)%aes.BlockSize != 0 {
+		return nil, errors.New("secret service returned an invalid encrypted secret")
+	}
+	value := bytes.Clone(sec.Value)
+	cipher.NewCBCDecrypter(block, sec.Parameters).CryptBlocks(value, value)
+	padding := int(value[len(value)-1])
+	if padding == 0 || padding > aes.BlockSize || !bytes.Equal(value[len(value)-padding:], bytes.Repeat([]byte{byte(padding)}, padding)) {
+		return nil, errors.New("secret service returned an invalid encrypted secret")
+	}
+	return value[:len(value)-padding], nil
+}
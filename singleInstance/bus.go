@@ -0,0 +1,118 @@
+/*
+	libxdg-go - An implementaion of various freedesktop specifications in go
+    Copyright (C) 2025 MiracleOS Contributors
+
+    This program is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    This program is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+*/
+
+package singleInstance
+
+import (
+	"strings"
+
+	"github.com/godbus/dbus/v5"
+	"github.com/godbus/dbus/v5/introspect"
+)
+
+// instanceObject holds the methods the primary instance exports.
+type instanceObject struct {
+	i *Instance
+}
+
+// Invoke hands a later invocation to the application, on the D-Bus goroutine.
+func (o instanceObject) Invoke(args []string, workDir, activationToken string) *dbus.Error {
+	o.i.invoked(Invocation{Args: args, WorkDir: workDir, ActivationToken: activationToken})
+	return nil
+}
+
+// objectPath returns the path of the exported object, derived from the name
+// like D-Bus activatable applications do.
+func objectPath(name string) dbus.ObjectPath {
+	return dbus.ObjectPath("/" + strings.ReplaceAll(strings.ReplaceAll(name, ".", "/"), "-", "_"))
+}
+
+// claimBus claims the bus name. Instances allow replacement, so that a later
+// one with Options.Replace takes the name over and the current one is told by
+// NameLost.
+func (i *Instance) claimBus(invocation Invocation) error {
+	conn, err := dbus.ConnectSessionBus()
+	if err != nil {
+		return err
+	}
+	name, path := i.options.Name, objectPath(i.options.Name)
+
+	if err := conn.Export(instanceObject{i}, path, interfaceName); err != nil {
+		conn.Close()
+		return err
+	}
+	node := &introspect.Node{
+		Name: string(path),
+		Interfaces: []introspect.Interface{
+			{
+				Name: interfaceName,
+				Methods: []introspect.Method{
+					{
+						Name: "Invoke",
+						Args: []introspect.Arg{
+							{Name: "args", Type: "as", Direction: "in"},
+							{Name: "work_dir", Type: "s", Direction: "in"},
+							{Name: "activation_token", Type: "s", Direction: "in"},
+						},
+					},
+				},
+			},
+			introspect.IntrospectData,
+		},
+	}
+	if err := conn.Export(introspect.NewIntrospectable(node), path, "org.freedesktop.DBus.Introspectable"); err != nil {
+		conn.Close()
+		return err
+	}
+
+	signals := make(chan *dbus.Signal, 4)
+	conn.Signal(signals)
+
+	flags := dbus.NameFlagAllowReplacement | dbus.NameFlagDoNotQueue
+	if i.options.Replace {
+		flags |= dbus.NameFlagReplaceExisting
+	}
+	reply, err := conn.RequestName(name, flags)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	if reply == dbus.RequestNameReplyPrimaryOwner || reply == dbus.RequestNameReplyAlreadyOwner {
+		i.conn = conn
+		go i.watchNameLost(signals)
+		return nil
+	}
+
+	defer conn.Close()
+	err = conn.Object(name, path).Call(interfaceName+".Invoke", 0, invocation.Args, invocation.WorkDir, invocation.ActivationToken).Err
+	if err != nil {
+		return err
+	}
+	return ErrForwarded
+}
+
+// watchNameLost reports the loss of the bus name, which the bus tells the owner
+// directly.
+func (i *Instance) watchNameLost(signals chan *dbus.Signal) {
+	for sig := range signals {
+		if sig.Name == "org.freedesktop.DBus.NameLost" && len(sig.Body) == 1 && sig.Body[0] == i.options.Name {
+			i.replaced()
+		}
+	}
+}
@@ -0,0 +1,156 @@
+/*
+	libxdg-go - An implementaion of various freedesktop specifications in go
+    Copyright (C) 2025 MiracleOS Contributors
+
+    This program is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    This program is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+*/
+
+// Package singleInstance keeps an application to a single instance per
+// session: the first instance claims a D-Bus name, or a socket in the runtime
+// directory, and later invocations forward their arguments to it and exit.
+package singleInstance
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	basedir "github.com/MiracleOS-Team/libxdg-go/baseDir"
+	"github.com/godbus/dbus/v5"
+)
+
+// interfaceName is the interface the primary instance exports on the bus.
+const interfaceName = "org.miracleos.SingleInstance"
+
+// ErrForwarded is returned by Claim when another instance runs and received
+// the invocation; the caller should exit.
+var ErrForwarded = errors.New("invocation forwarded to the running instance")
+
+// Invocation is what a later invocation of the application forwards to the
+// primary instance.
+type Invocation struct {
+	Args    []string `json:"args"`
+	WorkDir string   `json:"work_dir"`
+	// ActivationToken lets the primary instance focus its window, from
+	// XDG_ACTIVATION_TOKEN or DESKTOP_STARTUP_ID.
+	ActivationToken string `json:"activation_token,omitempty"`
+}
+
+// Options configure Claim.
+type Options struct {
+	// Name identifies the application, as a well-known bus name such as
+	// "org.miracleos.Files".
+	Name string
+	// Socket claims $XDG_RUNTIME_DIR/<Name>.sock instead of the bus name, for
+	// applications that can run without a session bus.
+	Socket bool
+	// Replace takes over from a running instance instead of forwarding to it,
+	// as --replace options do.
+	Replace bool
+	// OnInvocation is called in the primary instance for each later invocation.
+	OnInvocation func(Invocation)
+	// OnReplaced is called in the primary instance when another one replaced
+	// it; it should exit.
+	OnReplaced func()
+}
+
+// Instance is the claim of the primary instance.
+type Instance struct {
+	options Options
+	// conn or socket holds the claim, depending on Options.Socket.
+	conn   *dbus.Conn
+	socket *socketServer
+	mu     sync.Mutex
+	closed bool
+}
+
+// Claim makes the process the primary instance of the application, or forwards
+// args to the running one and returns ErrForwarded.
+func Claim(options Options, args []string) (*Instance, error) {
+	if options.Name == "" {
+		return nil, errors.New("single instance needs a name")
+	}
+	invocation, err := newInvocation(args)
+	if err != nil {
+		return nil, err
+	}
+	i := &Instance{options: options}
+	if options.Socket {
+		err = i.claimSocket(invocation)
+	} else {
+		err = i.claimBus(invocation)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return i, nil
+}
+
+// newInvocation describes the invocation of the current process.
+func newInvocation(args []string) (Invocation, error) {
+	workDir, err := os.Getwd()
+	if err != nil {
+		return Invocation{}, err
+	}
+	token := os.Getenv("XDG_ACTIVATION_TOKEN")
+	if token == "" {
+		token = os.Getenv("DESKTOP_STARTUP_ID")
+	}
+	return Invocation{Args: args, WorkDir: workDir, ActivationToken: token}, nil
+}
+
+// Close gives up the claim.
+func (i *Instance) Close() error {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	if i.closed {
+		return nil
+	}
+	i.closed = true
+	if i.socket != nil {
+		return i.socket.close()
+	}
+	return i.conn.Close()
+}
+
+// replaced reports the loss of the claim to another instance.
+func (i *Instance) replaced() {
+	i.mu.Lock()
+	closed := i.closed
+	i.mu.Unlock()
+	if !closed && i.options.OnReplaced != nil {
+		i.options.OnReplaced()
+	}
+}
+
+// invoked hands an invocation to the application.
+func (i *Instance) invoked(invocation Invocation) {
+	if i.options.OnInvocation != nil {
+		i.options.OnInvocation(invocation)
+	}
+}
+
+// socketPath returns the socket of an application in the runtime directory.
+func socketPath(name string) string {
+	runtime := fmt.Sprintf("%v", basedir.GetXDGDirectory("runtime"))
+	if runtime == "" {
+		runtime = os.TempDir()
+	}
+	return filepath.Join(runtime, strings.ReplaceAll(name, "/", "_")+".sock")
+}
@@ -0,0 +1,125 @@
+/*
+	libxdg-go - An implementaion of various freedesktop specifications in go
+    Copyright (C) 2025 MiracleOS Contributors
+
+    This program is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    This program is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+*/
+
+package singleInstance
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net"
+	"os"
+	"syscall"
+	"time"
+)
+
+// socketRequest is the JSON message a later instance sends on the socket.
+type socketRequest struct {
+	Invocation
+	Replace bool `json:"replace,omitempty"`
+}
+
+// socketServer serves the socket of the primary instance.
+type socketServer struct {
+	listener net.Listener
+}
+
+// claimSocket claims the socket, removing it if stale.
+func (i *Instance) claimSocket(invocation Invocation) error {
+	path := socketPath(i.options.Name)
+	for attempt := 0; attempt < 3; attempt++ {
+		listener, err := net.Listen("unix", path)
+		if err == nil {
+			i.socket = &socketServer{listener: listener}
+			go i.serve(listener)
+			return nil
+		}
+		if !errors.Is(err, syscall.EADDRINUSE) {
+			return err
+		}
+
+		conn, err := net.DialTimeout("unix", path, time.Second)
+		if err != nil {
+			// Nobody listens: the previous instance died without removing it.
+			os.Remove(path)
+			continue
+		}
+		err = sendRequest(conn, socketRequest{Invocation: invocation, Replace: i.options.Replace})
+		conn.Close()
+		if err != nil {
+			return err
+		}
+		if !i.options.Replace {
+			return ErrForwarded
+		}
+	}
+	return errors.New("failed to claim the single instance socket: " + path)
+}
+
+// sendRequest sends a request and waits for the primary instance to handle it.
+func sendRequest(conn net.Conn, request socketRequest) error {
+	conn.SetDeadline(time.Now().Add(5 * time.Second))
+	if err := json.NewEncoder(conn).Encode(request); err != nil {
+		return err
+	}
+	ack, err := bufio.NewReader(conn).ReadString('\n')
+	if err != nil {
+		return err
+	}
+	if ack != "ok\n" {
+		return errors.New("unexpected reply from the running instance")
+	}
+	return nil
+}
+
+// serve handles the requests of later instances.
+func (i *Instance) serve(listener net.Listener) {
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		var request socketRequest
+		conn.SetDeadline(time.Now().Add(5 * time.Second))
+		if err := json.NewDecoder(conn).Decode(&request); err != nil {
+			slog.Debug("Invalid single instance request", "error", err)
+			conn.Close()
+			continue
+		}
+		if request.Replace {
+			// Closing the listener removes the socket for the new instance.
+			listener.Close()
+			conn.Write([]byte("ok\n"))
+			conn.Close()
+			i.replaced()
+			return
+		}
+		conn.Write([]byte("ok\n"))
+		conn.Close()
+		i.invoked(request.Invocation)
+	}
+}
+
+func (s *socketServer) close() error {
+	if err := s.listener.Close(); !errors.Is(err, net.ErrClosed) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,281 @@
+/*
+	libxdg-go - An implementaion of various freedesktop specifications in go
+    Copyright (C) 2025 MiracleOS Contributors
+
+    This program is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    This program is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+*/
+
+// Package startupNotification implements the X11 side of the Startup
+// Notification Protocol: launchers announce the applications they start with
+// "new:" messages on the root window, so that window managers and task bars can
+// show busy cursors and give the first window of the application focus, and
+// the sequence ends with a "remove:" message once the application has mapped
+// its window or failed to start. On Wayland, foreignToplevel.ActivationToken
+// takes its place.
+package startupNotification
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/MiracleOS-Team/libxdg-go/x11"
+)
+
+const (
+	atomBegin = "_NET_STARTUP_INFO_BEGIN"
+	atomInfo  = "_NET_STARTUP_INFO"
+
+	// EnvID is the environment variable passing the startup ID to a launched
+	// application.
+	EnvID = "DESKTOP_STARTUP_ID"
+)
+
+// ErrCompleted is returned when changing a sequence that has been completed.
+var ErrCompleted = errors.New("startup sequence completed")
+
+// Info describes an application being launched. Every field is optional.
+type Info struct {
+	Name          string // Shown in task bars while the application starts
+	Description   string // What is being launched, e.g. "Opening report.pdf"
+	Bin           string // Name of the executable
+	Icon          string // Icon name or path
+	WMClass       string // WM_CLASS the application's window will have
+	ApplicationID string // Path of the application's desktop file
+	Desktop       int    // Workspace to open the window on, starting at 1; 0 for the current one
+	Timestamp     uint32 // X server time of the event causing the launch; 0 for now
+}
+
+// Sequence is a startup sequence started by Initiate.
+type Sequence struct {
+	id string
+
+	mu     sync.Mutex
+	conn   *x11.Conn
+	window uint32
+	begin  uint32
+	info   uint32
+	done   bool
+}
+
+var sequenceNumber atomic.Uint32
+
+// Initiate starts a startup sequence for an application about to be launched,
+// on the display named by $DISPLAY. The launcher passes the sequence's ID to the
+// application through Env, and calls Complete if the application fails to start,
+// exits, or hasn't completed the sequence itself after a timeout.
+func Initiate(info Info) (*Sequence, error) {
+	conn, err := x11.Connect()
+	if err != nil {
+		return nil, err
+	}
+	s, err := initiate(conn, info)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return s, nil
+}
+
+func initiate(conn *x11.Conn, info Info) (*Sequence, error) {
+	s := &Sequence{conn: conn}
+	var err error
+	if s.begin, err = conn.InternAtom(atomBegin); err != nil {
+		return nil, err
+	}
+	if s.info, err = conn.InternAtom(atomInfo); err != nil {
+		return nil, err
+	}
+	if s.window, err = conn.CreateWindow(x11.PropertyChangeMask); err != nil {
+		return nil, err
+	}
+	timestamp := info.Timestamp
+	if timestamp == 0 {
+		if timestamp, err = conn.ServerTime(s.window); err != nil {
+			return nil, err
+		}
+	}
+	s.id = newID(info.Bin, timestamp)
+
+	values := map[string]string{
+		"SCREEN": strconv.Itoa(conn.Screen()),
+		"NAME":   info.Name,
+	}
+	if values["NAME"] == "" {
+		values["NAME"] = info.Bin
+	}
+	optional := map[string]string{
+		"DESCRIPTION":    info.Description,
+		"BIN":            info.Bin,
+		"ICON":           info.Icon,
+		"WMCLASS":        info.WMClass,
+		"APPLICATION_ID": info.ApplicationID,
+	}
+	for key, value := range optional {
+		if value != "" {
+			values[key] = value
+		}
+	}
+	if info.Desktop > 0 {
+		values["DESKTOP"] = strconv.Itoa(info.Desktop - 1)
+	}
+	if err := s.sendLocked(formatMessage("new", s.id, values)); err != nil {
+		return nil, err
+	}
+	if err := conn.Sync(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// newID returns a startup ID unique to this launch, ending in the _TIME suffix
+// applications take the timestamp for focus stealing prevention from.
+func newID(bin string, timestamp uint32) string {
+	launcher := filepath.Base(os.Args[0])
+	launchee := filepath.Base(bin)
+	if bin == "" {
+		launchee = "application"
+	}
+	hostname, _ := os.Hostname()
+	return fmt.Sprintf("%s/%s/%d-%d-%s_TIME%d", launcher, launchee, os.Getpid(), sequenceNumber.Add(1), hostname, timestamp)
+}
+
+// ID returns the startup ID of the sequence.
+func (s *Sequence) ID() string {
+	return s.id
+}
+
+// Env returns the environment variable passing the startup ID to the launched
+// application, to be added to its environment.
+func (s *Sequence) Env() []string {
+	return []string{EnvID + "=" + s.id}
+}
+
+// Change updates properties of the sequence announced by Initiate, such as NAME
+// or DESKTOP, with a "change:" message.
+func (s *Sequence) Change(values map[string]string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.done {
+		return ErrCompleted
+	}
+	return s.sendLocked(formatMessage("change", s.id, values))
+}
+
+// Complete ends the sequence with a "remove:" message and closes its connection.
+// Calling it again does nothing.
+func (s *Sequence) Complete() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.done {
+		return nil
+	}
+	s.done = true
+	err := s.sendLocked(formatMessage("remove", s.id, nil))
+	s.conn.DestroyWindow(s.window)
+	return errors.Join(err, s.conn.Close())
+}
+
+// sendLocked broadcasts a message to the root window in 20-byte client
+// messages, the first of type _NET_STARTUP_INFO_BEGIN. s.mu must be held, or
+// the sequence not yet shared.
+func (s *Sequence) sendLocked(message string) error {
+	return send(s.conn, s.window, s.begin, s.info, message)
+}
+
+func send(conn *x11.Conn, window, begin, info uint32, message string) error {
+	data := append([]byte(message), 0)
+	messageType := begin
+	for len(data) > 0 {
+		var chunk [20]byte
+		n := copy(chunk[:], data)
+		data = data[n:]
+		if err := conn.SendClientMessage(conn.Root(), window, messageType, x11.PropertyChangeMask, chunk); err != nil {
+			return err
+		}
+		messageType = info
+	}
+	return nil
+}
+
+// Complete ends the startup sequence of id, as applications do once they have
+// mapped their window when their toolkit doesn't. id is usually the value of
+// DESKTOP_STARTUP_ID, which the application should then unset so that its own
+// children don't inherit it.
+func Complete(id string) error {
+	conn, err := x11.Connect()
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	begin, err := conn.InternAtom(atomBegin)
+	if err != nil {
+		return err
+	}
+	info, err := conn.InternAtom(atomInfo)
+	if err != nil {
+		return err
+	}
+	window, err := conn.CreateWindow(0)
+	if err != nil {
+		return err
+	}
+	defer conn.DestroyWindow(window)
+	if err := send(conn, window, begin, info, formatMessage("remove", id, nil)); err != nil {
+		return err
+	}
+	return conn.Sync()
+}
+
+// formatMessage encodes a message, its ID first and the other keys sorted.
+func formatMessage(kind, id string, values map[string]string) string {
+	var b strings.Builder
+	b.WriteString(kind)
+	b.WriteString(": ID=")
+	b.WriteString(quote(id))
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		b.WriteString(" " + key + "=" + quote(values[key]))
+	}
+	return b.String()
+}
+
+// quote quotes a value containing spaces, quotes or backslashes, escaping the
+// quotes and backslashes.
+func quote(value string) string {
+	if !strings.ContainsAny(value, " \"\\") {
+		return value
+	}
+	var b strings.Builder
+	b.WriteByte('"')
+	for _, r := range value {
+		if r == '"' || r == '\\' {
+			b.WriteByte('\\')
+		}
+		b.WriteRune(r)
+	}
+	b.WriteByte('"')
+	return b.String()
+}
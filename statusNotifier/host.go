@@ -0,0 +1,410 @@
+/*
+	libxdg-go - An implementaion of various freedesktop specifications in go
+    Copyright (C) 2025 MiracleOS Contributors
+
+    This program is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    This program is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+*/
+
+package statusNotifier
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/MiracleOS-Team/libxdg-go/dbusMenu"
+	"github.com/MiracleOS-Team/libxdg-go/internal/signals"
+	"github.com/godbus/dbus/v5"
+)
+
+// hostCount numbers the hosts of the process for their bus names.
+var hostCount atomic.Int32
+
+// Event reports a change to the items of a Host.
+type Event struct {
+	Item    Item
+	Added   bool
+	Updated bool
+	Removed bool
+}
+
+// hostItem is an item tracked by a host.
+type hostItem struct {
+	item Item
+	// owner is the unique name of the item's connection, which sends its signals.
+	owner string
+	// fetching is set while the properties are being read, again when they changed
+	// meanwhile.
+	fetching bool
+	again    bool
+}
+
+// Host implements org.kde.StatusNotifierHost: it registers with the watcher and
+// tracks the registered items and their properties, for a panel to show them.
+// When no watcher runs, the host starts one on its connection.
+type Host struct {
+	conn        *dbus.Conn
+	own         bool
+	name        string
+	watcher     *Watcher
+	mu          sync.Mutex
+	items       map[string]*hostItem
+	order       []string
+	subscribers signals.Subscribers[Event]
+	watch       *signals.Watch
+	closed      bool
+}
+
+// NewHost connects to the session bus and starts a host.
+func NewHost() (*Host, error) {
+	conn, err := dbus.ConnectSessionBus()
+	if err != nil {
+		return nil, err
+	}
+	h, err := newHost(conn, true)
+	if err != nil {
+		conn.Close()
+	}
+	return h, err
+}
+
+// NewHostWithConn starts a host on an existing connection, which is left open
+// by Close.
+func NewHostWithConn(conn *dbus.Conn) (*Host, error) {
+	return newHost(conn, false)
+}
+
+func newHost(conn *dbus.Conn, own bool) (*Host, error) {
+	h := &Host{
+		conn:  conn,
+		own:   own,
+		name:  fmt.Sprintf("org.kde.StatusNotifierHost-%d-%d", os.Getpid(), hostCount.Add(1)),
+		items: make(map[string]*hostItem),
+	}
+	watch, err := signals.NewWatch(conn, 64, h.dispatch, h.matches()...)
+	if err != nil {
+		return nil, err
+	}
+	h.watch = watch
+
+	reply, err := conn.RequestName(h.name, dbus.NameFlagDoNotQueue)
+	if err == nil && reply != dbus.RequestNameReplyPrimaryOwner {
+		err = errors.New("host bus name taken: " + h.name)
+	}
+	if err != nil {
+		watch.Close()
+		return nil, err
+	}
+
+	var running bool
+	err = conn.BusObject().Call("org.freedesktop.DBus.NameHasOwner", 0, watcherName).Store(&running)
+	if err == nil && !running {
+		h.watcher, err = newWatcher(conn, false)
+		if errors.Is(err, ErrWatcherRunning) {
+			err = nil
+		}
+	}
+	if err == nil {
+		err = h.register()
+	}
+	if err != nil {
+		watch.Close()
+		return nil, err
+	}
+	return h, nil
+}
+
+func (h *Host) matches() [][]dbus.MatchOption {
+	return [][]dbus.MatchOption{
+		{dbus.WithMatchInterface(watcherInterface), dbus.WithMatchObjectPath(watcherPath)},
+		{dbus.WithMatchInterface(itemInterface)},
+		{
+			dbus.WithMatchSender("org.freedesktop.DBus"),
+			dbus.WithMatchMember("NameOwnerChanged"),
+			dbus.WithMatchArg(0, watcherName),
+		},
+	}
+}
+
+// register registers the host with the watcher and synchronizes the items with
+// the registered ones.
+func (h *Host) register() error {
+	watcher := h.conn.Object(watcherName, watcherPath)
+	if err := watcher.Call(watcherInterface+".RegisterStatusNotifierHost", 0, h.name).Err; err != nil {
+		return err
+	}
+	v, err := watcher.GetProperty(watcherInterface + ".RegisteredStatusNotifierItems")
+	if err != nil {
+		return err
+	}
+	var keys []string
+	v.Store(&keys)
+
+	h.mu.Lock()
+	for _, key := range slices.Clone(h.order) {
+		if !slices.Contains(keys, key) {
+			h.removeLocked(key)
+		}
+	}
+	h.mu.Unlock()
+	for _, key := range keys {
+		go h.add(key)
+	}
+	return nil
+}
+
+// Close unregisters the host, stopping the watcher it started if any.
+func (h *Host) Close() error {
+	h.mu.Lock()
+	if h.closed {
+		h.mu.Unlock()
+		return nil
+	}
+	h.closed = true
+	h.mu.Unlock()
+
+	if h.watcher != nil {
+		h.watcher.Close()
+	}
+	h.conn.ReleaseName(h.name)
+	h.watch.Close()
+	h.subscribers.Close()
+	if h.own {
+		return h.conn.Close()
+	}
+	return nil
+}
+
+// Subscribe registers a consumer of the host's events. Events that don't fit in
+// the buffer are dropped. The channel is closed by cancel or by Close.
+func (h *Host) Subscribe(buffer int) (<-chan Event, func()) {
+	return h.subscribers.Subscribe(buffer)
+}
+
+// Items returns the items, in registration order.
+func (h *Host) Items() []Item {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	items := make([]Item, 0, len(h.order))
+	for _, key := range h.order {
+		items = append(items, h.items[key].item)
+	}
+	return items
+}
+
+// Item returns an item by key.
+func (h *Host) Item(key string) (Item, bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if hi, found := h.items[key]; found {
+		return hi.item, true
+	}
+	return Item{}, false
+}
+
+func (h *Host) dispatch(sig *dbus.Signal) {
+	switch sig.Name {
+	case watcherInterface + ".StatusNotifierItemRegistered":
+		if key, ok := firstString(sig); ok {
+			go h.add(key)
+		}
+	case watcherInterface + ".StatusNotifierItemUnregistered":
+		if key, ok := firstString(sig); ok {
+			h.mu.Lock()
+			h.removeLocked(key)
+			h.mu.Unlock()
+		}
+	case "org.freedesktop.DBus.NameOwnerChanged":
+		// A new watcher doesn't know about the host.
+		if len(sig.Body) == 3 && sig.Body[0] == watcherName && sig.Body[2] != "" {
+			go func() {
+				if err := h.register(); err != nil {
+					slog.Warn("Failed to register with the new StatusNotifierWatcher", "error", err)
+				}
+			}()
+		}
+	default:
+		if strings.HasPrefix(sig.Name, itemInterface+".") {
+			h.itemChanged(sig.Sender, sig.Path)
+		}
+	}
+}
+
+func firstString(sig *dbus.Signal) (string, bool) {
+	if len(sig.Body) == 0 {
+		return "", false
+	}
+	s, ok := sig.Body[0].(string)
+	return s, ok
+}
+
+// add starts tracking a registered item.
+func (h *Host) add(key string) {
+	service, _ := splitKey(key)
+	var owner string
+	if err := h.conn.BusObject().Call("org.freedesktop.DBus.GetNameOwner", 0, service).Store(&owner); err != nil {
+		slog.Debug("Status notifier item has no owner", "item", key, "error", err)
+		return
+	}
+	item, err := h.fetch(key)
+	if err != nil {
+		slog.Debug("Failed to read status notifier item", "item", key, "error", err)
+		return
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.closed || h.items[key] != nil {
+		return
+	}
+	h.items[key] = &hostItem{item: item, owner: owner}
+	h.order = append(h.order, key)
+	h.subscribers.Publish(Event{Item: item, Added: true})
+}
+
+// removeLocked stops tracking an item. h.mu must be held.
+func (h *Host) removeLocked(key string) {
+	hi, found := h.items[key]
+	if !found {
+		return
+	}
+	delete(h.items, key)
+	h.order = slices.DeleteFunc(h.order, func(k string) bool { return k == key })
+	h.subscribers.Publish(Event{Item: hi.item, Removed: true})
+}
+
+// itemChanged refreshes the items of a connection at a path after one of their
+// change signals.
+func (h *Host) itemChanged(sender string, path dbus.ObjectPath) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for key, hi := range h.items {
+		if hi.owner != sender || hi.item.Path != path {
+			continue
+		}
+		if hi.fetching {
+			hi.again = true
+			continue
+		}
+		hi.fetching = true
+		go h.refresh(key, hi)
+	}
+}
+
+// refresh reads the properties of an item again until they stop changing meanwhile.
+func (h *Host) refresh(key string, hi *hostItem) {
+	for {
+		item, err := h.fetch(key)
+
+		h.mu.Lock()
+		if h.items[key] != hi {
+			h.mu.Unlock()
+			return
+		}
+		if err == nil {
+			hi.item = item
+			h.subscribers.Publish(Event{Item: item, Updated: true})
+		}
+		if !hi.again {
+			hi.fetching = false
+			h.mu.Unlock()
+			return
+		}
+		hi.again = false
+		h.mu.Unlock()
+	}
+}
+
+// fetch reads the properties of an item.
+func (h *Host) fetch(key string) (Item, error) {
+	service, path := splitKey(key)
+	var props map[string]dbus.Variant
+	err := h.conn.Object(service, path).Call("org.freedesktop.DBus.Properties.GetAll", 0, itemInterface).Store(&props)
+	if err != nil {
+		return Item{}, err
+	}
+
+	item := Item{Key: key, Service: service, Path: path}
+	get := func(name string, value any) {
+		if v, found := props[name]; found {
+			v.Store(value)
+		}
+	}
+	get("Id", &item.ID)
+	get("Category", &item.Category)
+	get("Title", &item.Title)
+	get("Status", &item.Status)
+	get("WindowId", &item.WindowID)
+	get("IconName", &item.IconName)
+	get("IconPixmap", &item.IconPixmaps)
+	get("OverlayIconName", &item.OverlayIconName)
+	get("OverlayIconPixmap", &item.OverlayIconPixmaps)
+	get("AttentionIconName", &item.AttentionIconName)
+	get("AttentionIconPixmap", &item.AttentionPixmaps)
+	get("AttentionMovieName", &item.AttentionMovieName)
+	get("IconThemePath", &item.IconThemePath)
+	get("ToolTip", &item.ToolTip)
+	get("ItemIsMenu", &item.ItemIsMenu)
+	get("Menu", &item.Menu)
+	return item, nil
+}
+
+// Activate asks an item for its primary action, usually on a left click, at
+// screen coordinates x and y.
+func (h *Host) Activate(key string, x, y int32) error {
+	return h.call(key, "Activate", x, y)
+}
+
+// SecondaryActivate asks an item for its secondary action, usually on a middle click.
+func (h *Host) SecondaryActivate(key string, x, y int32) error {
+	return h.call(key, "SecondaryActivate", x, y)
+}
+
+// ContextMenu asks an item to show its own context menu. Items exporting a Menu
+// expect the host to show it instead.
+func (h *Host) ContextMenu(key string, x, y int32) error {
+	return h.call(key, "ContextMenu", x, y)
+}
+
+// Scroll reports a scroll on an item; orientation is "vertical" or "horizontal".
+func (h *Host) Scroll(key string, delta int32, orientation string) error {
+	return h.call(key, "Scroll", delta, orientation)
+}
+
+func (h *Host) call(key, method string, args ...any) error {
+	service, path := splitKey(key)
+	return h.conn.Object(service, path).Call(itemInterface+"."+method, 0, args...).Err
+}
+
+// Menu returns a client mirroring the dbusmenu of an item, to be closed by the
+// caller. It fails if the item has no menu.
+func (h *Host) Menu(key string) (*dbusMenu.Client, error) {
+	item, found := h.Item(key)
+	if !found {
+		return nil, errors.New("unknown status notifier item: " + key)
+	}
+	if item.Menu == "" || item.Menu == "/" {
+		return nil, errors.New("status notifier item has no menu: " + key)
+	}
+	return dbusMenu.NewClient(h.conn, item.Service, item.Menu)
+}
@@ -0,0 +1,367 @@
+/*
+	libxdg-go - An implementaion of various freedesktop specifications in go
+    Copyright (C) 2025 MiracleOS Contributors
+
+    This program is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    This program is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+*/
+
+package statusNotifier
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"sync"
+	"sync/atomic"
+
+	"github.com/godbus/dbus/v5"
+	"github.com/godbus/dbus/v5/introspect"
+	"github.com/godbus/dbus/v5/prop"
+)
+
+// itemCount numbers the items of the process for their bus names.
+var itemCount atomic.Int32
+
+// ItemOptions describe a tray item to publish.
+type ItemOptions struct {
+	// ID is a name for the item unique to the application, such as its name.
+	ID string
+	// Category is one of the Category* constants, CategoryApplicationStatus if empty.
+	Category string
+	Title    string
+	// Status is one of the Status* constants, StatusActive if empty.
+	Status string
+	// WindowID is the X11 window of the application, or 0.
+	WindowID uint32
+
+	// IconName is a themed icon name, or IconPixmaps the icon in several sizes for
+	// hosts that can't find it.
+	IconName          string
+	IconPixmaps       []Pixmap
+	AttentionIconName string
+	AttentionPixmaps  []Pixmap
+	OverlayIconName   string
+	// IconThemePath is an extra directory for hosts to look icons up in.
+	IconThemePath string
+	ToolTip       ToolTip
+
+	// Menu is the path of a com.canonical.dbusmenu object exported on the item's
+	// connection, or empty. ItemIsMenu makes activation show the menu.
+	Menu       dbus.ObjectPath
+	ItemIsMenu bool
+
+	// The callbacks are called when the user acts on the item in the tray, with the
+	// screen coordinates of the click. They run on a D-Bus goroutine and may be nil.
+	OnActivate          func(x, y int32)
+	OnSecondaryActivate func(x, y int32)
+	OnContextMenu       func(x, y int32)
+	// OnScroll receives scroll steps; orientation is "vertical" or "horizontal".
+	OnScroll func(delta int32, orientation string)
+}
+
+// PublishedItem is a tray item published by the application. It registers with
+// the watcher again whenever a new watcher starts.
+type PublishedItem struct {
+	conn    *dbus.Conn
+	own     bool
+	name    string
+	mu      sync.Mutex
+	options ItemOptions
+	props   *prop.Properties
+	signals chan *dbus.Signal
+	closed  bool
+}
+
+// itemObject holds the exported methods of a PublishedItem.
+type itemObject struct {
+	p *PublishedItem
+}
+
+// Publish connects to the session bus and publishes a tray item. The item stays
+// published, waiting for a watcher, if none runs.
+func Publish(options ItemOptions) (*PublishedItem, error) {
+	conn, err := dbus.ConnectSessionBus()
+	if err != nil {
+		return nil, err
+	}
+	p, err := publish(conn, true, options)
+	if err != nil {
+		conn.Close()
+	}
+	return p, err
+}
+
+// PublishWithConn publishes a tray item on an existing connection, which is left
+// open by Close.
+func PublishWithConn(conn *dbus.Conn, options ItemOptions) (*PublishedItem, error) {
+	return publish(conn, false, options)
+}
+
+func publish(conn *dbus.Conn, own bool, options ItemOptions) (*PublishedItem, error) {
+	if options.Category == "" {
+		options.Category = CategoryApplicationStatus
+	}
+	if options.Status == "" {
+		options.Status = StatusActive
+	}
+	if options.Menu == "" {
+		options.Menu = "/"
+	}
+	p := &PublishedItem{
+		conn:    conn,
+		own:     own,
+		name:    fmt.Sprintf("org.kde.StatusNotifierItem-%d-%d", os.Getpid(), itemCount.Add(1)),
+		options: options,
+		signals: make(chan *dbus.Signal, 16),
+	}
+
+	if err := conn.Export(itemObject{p}, itemPath, itemInterface); err != nil {
+		return nil, err
+	}
+	// Hosts learn about changes from the New* signals rather than PropertiesChanged.
+	property := func(value any) *prop.Prop {
+		return &prop.Prop{Value: value, Emit: prop.EmitFalse}
+	}
+	props, err := prop.Export(conn, itemPath, prop.Map{
+		itemInterface: {
+			"Id":                  property(options.ID),
+			"Category":            property(options.Category),
+			"Title":               property(options.Title),
+			"Status":              property(options.Status),
+			"WindowId":            property(options.WindowID),
+			"IconName":            property(options.IconName),
+			"IconPixmap":          property(options.IconPixmaps),
+			"OverlayIconName":     property(options.OverlayIconName),
+			"OverlayIconPixmap":   property([]Pixmap{}),
+			"AttentionIconName":   property(options.AttentionIconName),
+			"AttentionIconPixmap": property(options.AttentionPixmaps),
+			"AttentionMovieName":  property(""),
+			"IconThemePath":       property(options.IconThemePath),
+			"ToolTip":             property(options.ToolTip),
+			"ItemIsMenu":          property(options.ItemIsMenu),
+			"Menu":                property(options.Menu),
+		},
+	})
+	if err != nil {
+		p.unexport()
+		return nil, err
+	}
+	p.props = props
+	if err := p.exportIntrospection(); err != nil {
+		p.unexport()
+		return nil, err
+	}
+
+	reply, err := conn.RequestName(p.name, dbus.NameFlagDoNotQueue)
+	if err == nil && reply != dbus.RequestNameReplyPrimaryOwner {
+		err = errors.New("item bus name taken: " + p.name)
+	}
+	if err != nil {
+		p.unexport()
+		return nil, err
+	}
+
+	// A watcher starting later, or restarting, doesn't know about the item.
+	err = conn.AddMatchSignal(
+		dbus.WithMatchSender("org.freedesktop.DBus"),
+		dbus.WithMatchMember("NameOwnerChanged"),
+		dbus.WithMatchArg(0, watcherName),
+	)
+	if err != nil {
+		conn.ReleaseName(p.name)
+		p.unexport()
+		return nil, err
+	}
+	conn.Signal(p.signals)
+	go p.watchWatcher()
+
+	if err := p.register(); err != nil {
+		slog.Debug("No StatusNotifierWatcher to register the item with yet", "item", options.ID, "error", err)
+	}
+	return p, nil
+}
+
+func (p *PublishedItem) exportIntrospection() error {
+	position := []introspect.Arg{{Name: "x", Type: "i", Direction: "in"}, {Name: "y", Type: "i", Direction: "in"}}
+	node := &introspect.Node{
+		Name: string(itemPath),
+		Interfaces: []introspect.Interface{
+			{
+				Name: itemInterface,
+				Methods: []introspect.Method{
+					{Name: "Activate", Args: position},
+					{Name: "SecondaryActivate", Args: position},
+					{Name: "ContextMenu", Args: position},
+					{
+						Name: "Scroll",
+						Args: []introspect.Arg{
+							{Name: "delta", Type: "i", Direction: "in"},
+							{Name: "orientation", Type: "s", Direction: "in"},
+						},
+					},
+				},
+				Signals: []introspect.Signal{
+					{Name: "NewTitle"},
+					{Name: "NewIcon"},
+					{Name: "NewAttentionIcon"},
+					{Name: "NewOverlayIcon"},
+					{Name: "NewToolTip"},
+					{Name: "NewMenu"},
+					{Name: "NewStatus", Args: []introspect.Arg{{Name: "status", Type: "s"}}},
+					{Name: "NewIconThemePath", Args: []introspect.Arg{{Name: "icon_theme_path", Type: "s"}}},
+				},
+				Properties: p.props.Introspection(itemInterface),
+			},
+			prop.IntrospectData,
+			introspect.IntrospectData,
+		},
+	}
+	return p.conn.Export(introspect.NewIntrospectable(node), itemPath, "org.freedesktop.DBus.Introspectable")
+}
+
+func (p *PublishedItem) unexport() {
+	p.conn.Export(nil, itemPath, itemInterface)
+	p.conn.Export(nil, itemPath, "org.freedesktop.DBus.Properties")
+	p.conn.Export(nil, itemPath, "org.freedesktop.DBus.Introspectable")
+}
+
+// register registers the item with the watcher.
+func (p *PublishedItem) register() error {
+	return p.conn.Object(watcherName, watcherPath).Call(watcherInterface+".RegisterStatusNotifierItem", 0, p.name).Err
+}
+
+func (p *PublishedItem) watchWatcher() {
+	for sig := range p.signals {
+		if sig.Name != "org.freedesktop.DBus.NameOwnerChanged" || len(sig.Body) < 3 {
+			continue
+		}
+		if sig.Body[0] == watcherName && sig.Body[2] != "" {
+			if err := p.register(); err != nil {
+				slog.Warn("Failed to register the item with the new StatusNotifierWatcher", "item", p.options.ID, "error", err)
+			}
+		}
+	}
+}
+
+// Close removes the item from the tray.
+func (p *PublishedItem) Close() error {
+	p.mu.Lock()
+	if p.closed {
+		p.mu.Unlock()
+		return nil
+	}
+	p.closed = true
+	p.mu.Unlock()
+
+	p.conn.RemoveSignal(p.signals)
+	p.conn.RemoveMatchSignal(
+		dbus.WithMatchSender("org.freedesktop.DBus"),
+		dbus.WithMatchMember("NameOwnerChanged"),
+		dbus.WithMatchArg(0, watcherName),
+	)
+	close(p.signals)
+	// Releasing the name makes the watcher unregister the item.
+	p.conn.ReleaseName(p.name)
+	p.unexport()
+	if p.own {
+		return p.conn.Close()
+	}
+	return nil
+}
+
+// Conn returns the connection the item is published on, for exporting its menu.
+func (p *PublishedItem) Conn() *dbus.Conn {
+	return p.conn
+}
+
+// set updates properties and emits the signal telling hosts about them.
+func (p *PublishedItem) set(signal string, args []any, values map[string]any) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.closed {
+		return
+	}
+	for name, value := range values {
+		p.props.SetMust(itemInterface, name, value)
+	}
+	p.conn.Emit(itemPath, itemInterface+"."+signal, args...)
+}
+
+// SetTitle changes the title of the item.
+func (p *PublishedItem) SetTitle(title string) {
+	p.set("NewTitle", nil, map[string]any{"Title": title})
+}
+
+// SetStatus changes the status of the item to one of the Status* constants.
+func (p *PublishedItem) SetStatus(status string) {
+	p.set("NewStatus", []any{status}, map[string]any{"Status": status})
+}
+
+// SetIcon changes the icon of the item, by name, pixmaps or both.
+func (p *PublishedItem) SetIcon(name string, pixmaps []Pixmap) {
+	p.set("NewIcon", nil, map[string]any{"IconName": name, "IconPixmap": pixmaps})
+}
+
+// SetAttentionIcon changes the icon shown when the item needs attention.
+func (p *PublishedItem) SetAttentionIcon(name string, pixmaps []Pixmap) {
+	p.set("NewAttentionIcon", nil, map[string]any{"AttentionIconName": name, "AttentionIconPixmap": pixmaps})
+}
+
+// SetOverlayIcon changes the icon drawn over the item's icon.
+func (p *PublishedItem) SetOverlayIcon(name string) {
+	p.set("NewOverlayIcon", nil, map[string]any{"OverlayIconName": name})
+}
+
+// SetToolTip changes the tooltip of the item.
+func (p *PublishedItem) SetToolTip(toolTip ToolTip) {
+	p.set("NewToolTip", nil, map[string]any{"ToolTip": toolTip})
+}
+
+// SetMenu changes the dbusmenu object of the item.
+func (p *PublishedItem) SetMenu(menu dbus.ObjectPath, itemIsMenu bool) {
+	if menu == "" {
+		menu = "/"
+	}
+	p.set("NewMenu", nil, map[string]any{"Menu": menu, "ItemIsMenu": itemIsMenu})
+}
+
+func (o itemObject) Activate(x, y int32) *dbus.Error {
+	if f := o.p.options.OnActivate; f != nil {
+		f(x, y)
+	}
+	return nil
+}
+
+func (o itemObject) SecondaryActivate(x, y int32) *dbus.Error {
+	if f := o.p.options.OnSecondaryActivate; f != nil {
+		f(x, y)
+	}
+	return nil
+}
+
+func (o itemObject) ContextMenu(x, y int32) *dbus.Error {
+	if f := o.p.options.OnContextMenu; f != nil {
+		f(x, y)
+	}
+	return nil
+}
+
+func (o itemObject) Scroll(delta int32, orientation string) *dbus.Error {
+	if f := o.p.options.OnScroll; f != nil {
+		f(delta, orientation)
+	}
+	return nil
+}
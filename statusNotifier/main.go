@@ -0,0 +1,206 @@
+/*
+	libxdg-go - An implementaion of various freedesktop specifications in go
+    Copyright (C) 2025 MiracleOS Contributors
+
+    This program is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    This program is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+*/
+
+// Package statusNotifier implements the StatusNotifierItem specification, the
+// D-Bus system tray: the watcher registry, the host side panels use to show tray
+// items, and the item side applications publish them with.
+package statusNotifier
+
+import (
+	"errors"
+	"image"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/MiracleOS-Team/libxdg-go/icons"
+	"github.com/godbus/dbus/v5"
+)
+
+const (
+	watcherName      = "org.kde.StatusNotifierWatcher"
+	watcherPath      = dbus.ObjectPath("/StatusNotifierWatcher")
+	watcherInterface = "org.kde.StatusNotifierWatcher"
+	itemInterface    = "org.kde.StatusNotifierItem"
+	// itemPath is where items are expected when registered by bus name only.
+	itemPath = dbus.ObjectPath("/StatusNotifierItem")
+	// protocolVersion is the ProtocolVersion property of the watcher.
+	protocolVersion = int32(0)
+)
+
+// Item statuses.
+const (
+	StatusPassive        = "Passive"
+	StatusActive         = "Active"
+	StatusNeedsAttention = "NeedsAttention"
+)
+
+// Item categories.
+const (
+	CategoryApplicationStatus = "ApplicationStatus"
+	CategoryCommunications    = "Communications"
+	CategorySystemServices    = "SystemServices"
+	CategoryHardware          = "Hardware"
+)
+
+// ErrWatcherRunning is returned when starting a watcher while another one owns
+// the watcher name.
+var ErrWatcherRunning = errors.New("a StatusNotifierWatcher is already running")
+
+// Pixmap is an icon image sent over the bus, as ARGB32 pixels in network byte
+// order.
+type Pixmap struct {
+	Width  int32
+	Height int32
+	Data   []byte
+}
+
+// Image converts the pixmap to an image, or returns nil if the data doesn't match
+// the size.
+func (p Pixmap) Image() *image.NRGBA {
+	if p.Width <= 0 || p.Height <= 0 || len(p.Data) < int(p.Width*p.Height*4) {
+		return nil
+	}
+	img := image.NewNRGBA(image.Rect(0, 0, int(p.Width), int(p.Height)))
+	for i := 0; i < int(p.Width*p.Height); i++ {
+		a, r, g, b := p.Data[i*4], p.Data[i*4+1], p.Data[i*4+2], p.Data[i*4+3]
+		copy(img.Pix[i*4:], []byte{r, g, b, a})
+	}
+	return img
+}
+
+// PixmapFromImage converts an image to a pixmap.
+func PixmapFromImage(img image.Image) Pixmap {
+	bounds := img.Bounds()
+	p := Pixmap{Width: int32(bounds.Dx()), Height: int32(bounds.Dy())}
+	p.Data = make([]byte, 0, bounds.Dx()*bounds.Dy()*4)
+	nrgba := image.NewNRGBA(bounds)
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			nrgba.Set(x, y, img.At(x, y))
+			c := nrgba.NRGBAAt(x, y)
+			p.Data = append(p.Data, c.A, c.R, c.G, c.B)
+		}
+	}
+	return p
+}
+
+// bestPixmap returns the smallest pixmap at least size pixels wide, or the largest
+// one if none is.
+func bestPixmap(pixmaps []Pixmap, size int) (Pixmap, bool) {
+	var best Pixmap
+	found := false
+	for _, p := range pixmaps {
+		switch {
+		case !found:
+		case int(best.Width) >= size && int(p.Width) >= size && p.Width < best.Width:
+		case int(best.Width) < size && p.Width > best.Width:
+		default:
+			continue
+		}
+		best, found = p, true
+	}
+	return best, found
+}
+
+// ToolTip is the tooltip of an item.
+type ToolTip struct {
+	IconName    string
+	IconPixmaps []Pixmap
+	Title       string
+	// Description may contain the markup of the notification specification.
+	Description string
+}
+
+// Item is a tray item as seen by a host.
+type Item struct {
+	// Key identifies the item in the watcher: its bus name followed by its object path.
+	Key     string
+	Service string
+	Path    dbus.ObjectPath
+
+	ID       string
+	Category string
+	Title    string
+	Status   string
+	WindowID uint32
+
+	IconName           string
+	IconPixmaps        []Pixmap
+	OverlayIconName    string
+	OverlayIconPixmaps []Pixmap
+	AttentionIconName  string
+	AttentionPixmaps   []Pixmap
+	AttentionMovieName string
+	// IconThemePath is an extra directory to look icons up in.
+	IconThemePath string
+	ToolTip       ToolTip
+
+	// ItemIsMenu means the item only has a menu, which activation should show.
+	ItemIsMenu bool
+	// Menu is the path of the item's com.canonical.dbusmenu object on Service.
+	Menu dbus.ObjectPath
+}
+
+// IconPath returns the file of the item's icon, looked up in IconThemePath then in
+// the icon theme. Use Pixmap when it fails.
+func (it Item) IconPath(size, scale int) (string, error) {
+	return lookupIcon(it.IconName, it.IconThemePath, size, scale)
+}
+
+// AttentionIconPath is IconPath for the icon shown when the item needs attention.
+func (it Item) AttentionIconPath(size, scale int) (string, error) {
+	return lookupIcon(it.AttentionIconName, it.IconThemePath, size, scale)
+}
+
+// Pixmap returns the pixmap of the item's icon closest to size, if it has any.
+func (it Item) Pixmap(size int) (Pixmap, bool) {
+	return bestPixmap(it.IconPixmaps, size)
+}
+
+// AttentionPixmap is Pixmap for the icon shown when the item needs attention.
+func (it Item) AttentionPixmap(size int) (Pixmap, bool) {
+	return bestPixmap(it.AttentionPixmaps, size)
+}
+
+func lookupIcon(name, themePath string, size, scale int) (string, error) {
+	if name == "" {
+		return "", errors.New("item has no icon name")
+	}
+	if filepath.IsAbs(name) {
+		return name, nil
+	}
+	if themePath != "" {
+		for _, ext := range []string{".png", ".svg", ".xpm"} {
+			path := filepath.Join(themePath, name+ext)
+			if _, err := os.Stat(path); err == nil {
+				return path, nil
+			}
+		}
+	}
+	return icons.FindIconDefaults(strings.TrimSuffix(name, ".png"), size, scale, "")
+}
+
+// splitKey splits an item key into its bus name and object path.
+func splitKey(key string) (string, dbus.ObjectPath) {
+	if i := strings.Index(key, "/"); i >= 0 {
+		return key[:i], dbus.ObjectPath(key[i:])
+	}
+	return key, itemPath
+}
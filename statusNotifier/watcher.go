@@ -0,0 +1,269 @@
+/*
+	libxdg-go - An implementaion of various freedesktop specifications in go
+    Copyright (C) 2025 MiracleOS Contributors
+
+    This program is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    This program is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+*/
+
+package statusNotifier
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/godbus/dbus/v5"
+	"github.com/godbus/dbus/v5/introspect"
+	"github.com/godbus/dbus/v5/prop"
+)
+
+// Watcher implements org.kde.StatusNotifierWatcher, the registry of the tray
+// items and hosts of a session. Items and hosts are dropped when their bus name
+// disappears.
+type Watcher struct {
+	conn    *dbus.Conn
+	own     bool
+	mu      sync.Mutex
+	items   []string
+	hosts   map[string]bool
+	props   *prop.Properties
+	signals chan *dbus.Signal
+}
+
+// watcherObject holds the exported methods of a Watcher.
+type watcherObject struct {
+	w *Watcher
+}
+
+// NewWatcher connects to the session bus and starts a watcher. It fails with
+// ErrWatcherRunning if another watcher owns the name.
+func NewWatcher() (*Watcher, error) {
+	conn, err := dbus.ConnectSessionBus()
+	if err != nil {
+		return nil, err
+	}
+	w, err := newWatcher(conn, true)
+	if err != nil {
+		conn.Close()
+	}
+	return w, err
+}
+
+// NewWatcherWithConn starts a watcher on an existing connection, which is left
+// open by Close.
+func NewWatcherWithConn(conn *dbus.Conn) (*Watcher, error) {
+	return newWatcher(conn, false)
+}
+
+func newWatcher(conn *dbus.Conn, own bool) (*Watcher, error) {
+	w := &Watcher{
+		conn:    conn,
+		own:     own,
+		hosts:   make(map[string]bool),
+		signals: make(chan *dbus.Signal, 16),
+	}
+
+	if err := conn.Export(watcherObject{w}, watcherPath, watcherInterface); err != nil {
+		return nil, err
+	}
+	props, err := prop.Export(conn, watcherPath, prop.Map{
+		watcherInterface: {
+			"RegisteredStatusNotifierItems":  {Value: []string{}, Emit: prop.EmitTrue},
+			"IsStatusNotifierHostRegistered": {Value: false, Emit: prop.EmitTrue},
+			"ProtocolVersion":                {Value: protocolVersion, Emit: prop.EmitFalse},
+		},
+	})
+	if err != nil {
+		w.unexport()
+		return nil, err
+	}
+	w.props = props
+	node := &introspect.Node{
+		Name: string(watcherPath),
+		Interfaces: []introspect.Interface{
+			{
+				Name: watcherInterface,
+				Methods: []introspect.Method{
+					{
+						Name: "RegisterStatusNotifierItem",
+						Args: []introspect.Arg{{Name: "service", Type: "s", Direction: "in"}},
+					},
+					{
+						Name: "RegisterStatusNotifierHost",
+						Args: []introspect.Arg{{Name: "service", Type: "s", Direction: "in"}},
+					},
+				},
+				Signals: []introspect.Signal{
+					{Name: "StatusNotifierItemRegistered", Args: []introspect.Arg{{Name: "service", Type: "s"}}},
+					{Name: "StatusNotifierItemUnregistered", Args: []introspect.Arg{{Name: "service", Type: "s"}}},
+					{Name: "StatusNotifierHostRegistered"},
+					{Name: "StatusNotifierHostUnregistered"},
+				},
+				Properties: props.Introspection(watcherInterface),
+			},
+			prop.IntrospectData,
+			introspect.IntrospectData,
+		},
+	}
+	if err := conn.Export(introspect.NewIntrospectable(node), watcherPath, "org.freedesktop.DBus.Introspectable"); err != nil {
+		w.unexport()
+		return nil, err
+	}
+
+	// Registrations are dropped when their owner leaves the bus.
+	err = conn.AddMatchSignal(
+		dbus.WithMatchSender("org.freedesktop.DBus"),
+		dbus.WithMatchInterface("org.freedesktop.DBus"),
+		dbus.WithMatchMember("NameOwnerChanged"),
+	)
+	if err != nil {
+		w.unexport()
+		return nil, err
+	}
+	conn.Signal(w.signals)
+	go w.watchOwners()
+
+	reply, err := conn.RequestName(watcherName, dbus.NameFlagDoNotQueue)
+	if err == nil && reply != dbus.RequestNameReplyPrimaryOwner {
+		err = ErrWatcherRunning
+	}
+	if err != nil {
+		w.stop()
+		return nil, err
+	}
+	return w, nil
+}
+
+// Close releases the watcher name and stops serving the watcher.
+func (w *Watcher) Close() error {
+	w.conn.ReleaseName(watcherName)
+	w.stop()
+	if w.own {
+		return w.conn.Close()
+	}
+	return nil
+}
+
+func (w *Watcher) stop() {
+	w.conn.RemoveSignal(w.signals)
+	w.conn.RemoveMatchSignal(
+		dbus.WithMatchSender("org.freedesktop.DBus"),
+		dbus.WithMatchInterface("org.freedesktop.DBus"),
+		dbus.WithMatchMember("NameOwnerChanged"),
+	)
+	close(w.signals)
+	w.unexport()
+}
+
+func (w *Watcher) unexport() {
+	w.conn.Export(nil, watcherPath, watcherInterface)
+	w.conn.Export(nil, watcherPath, "org.freedesktop.DBus.Properties")
+	w.conn.Export(nil, watcherPath, "org.freedesktop.DBus.Introspectable")
+}
+
+// Items returns the keys of the registered items: bus names followed by object paths.
+func (w *Watcher) Items() []string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return slices.Clone(w.items)
+}
+
+// RegisterStatusNotifierItem registers an item given by bus name, using the
+// default object path, or by object path on the caller's connection.
+func (o watcherObject) RegisterStatusNotifierItem(sender dbus.Sender, service string) *dbus.Error {
+	key := service
+	switch {
+	case strings.HasPrefix(service, "/"):
+		key = string(sender) + service
+	case !strings.Contains(service, "/"):
+		key = service + string(itemPath)
+	}
+	if _, path := splitKey(key); !path.IsValid() {
+		return dbus.MakeFailedError(fmt.Errorf("invalid status notifier item: %q", service))
+	}
+
+	w := o.w
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if slices.Contains(w.items, key) {
+		return nil
+	}
+	w.items = append(w.items, key)
+	w.props.SetMust(watcherInterface, "RegisteredStatusNotifierItems", slices.Clone(w.items))
+	w.conn.Emit(watcherPath, watcherInterface+".StatusNotifierItemRegistered", key)
+	return nil
+}
+
+// RegisterStatusNotifierHost registers a host. The service is only informative;
+// the host is tracked by the caller's connection.
+func (o watcherObject) RegisterStatusNotifierHost(sender dbus.Sender, service string) *dbus.Error {
+	w := o.w
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.hosts[string(sender)] {
+		return nil
+	}
+	w.hosts[string(sender)] = true
+	if len(w.hosts) == 1 {
+		w.props.SetMust(watcherInterface, "IsStatusNotifierHostRegistered", true)
+	}
+	w.conn.Emit(watcherPath, watcherInterface+".StatusNotifierHostRegistered")
+	return nil
+}
+
+// watchOwners drops the items and hosts of names leaving the bus.
+func (w *Watcher) watchOwners() {
+	for sig := range w.signals {
+		if sig.Name != "org.freedesktop.DBus.NameOwnerChanged" || len(sig.Body) < 3 {
+			continue
+		}
+		name, _ := sig.Body[0].(string)
+		newOwner, _ := sig.Body[2].(string)
+		if newOwner == "" {
+			w.dropName(name)
+		}
+	}
+}
+
+func (w *Watcher) dropName(name string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	kept := w.items[:0]
+	var removed []string
+	for _, key := range w.items {
+		if service, _ := splitKey(key); service == name {
+			removed = append(removed, key)
+		} else {
+			kept = append(kept, key)
+		}
+	}
+	w.items = kept
+	if len(removed) > 0 {
+		w.props.SetMust(watcherInterface, "RegisteredStatusNotifierItems", slices.Clone(w.items))
+	}
+	for _, key := range removed {
+		w.conn.Emit(watcherPath, watcherInterface+".StatusNotifierItemUnregistered", key)
+	}
+
+	if w.hosts[name] {
+		delete(w.hosts, name)
+		if len(w.hosts) == 0 {
+			w.props.SetMust(watcherInterface, "IsStatusNotifierHostRegistered", false)
+		}
+		w.conn.Emit(watcherPath, watcherInterface+".StatusNotifierHostUnregistered")
+	}
+}
@@ -0,0 +1,186 @@
+/*
+	libxdg-go - An implementaion of various freedesktop specifications in go
+    Copyright (C) 2025 MiracleOS Contributors
+
+    This program is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    This program is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+*/
+
+package thumbnails
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/MiracleOS-Team/libxdg-go/internal/atomicfile"
+	"github.com/MiracleOS-Team/libxdg-go/mime"
+)
+
+// nativeTypes are the MIME types thumbnailed with the image decoders of the
+// standard library.
+var nativeTypes = []string{"image/png", "image/jpeg", "image/gif"}
+
+// software is the Software key of the thumbnails this package writes.
+const software = "libxdg-go"
+
+// Generate returns an up to date thumbnail of a file of the given size,
+// creating it if needed. Images the standard library decodes are thumbnailed
+// natively, other files with the first installed .thumbnailer handling them. A file that can't be thumbnailed is recorded in the
+// fail directory and ErrFailed is returned for it until it changes.
+func Generate(path string, size Size) (string, error) {
+	if size.Dir() == "" {
+		return "", fmt.Errorf("invalid thumbnail size %d", size)
+	}
+	src, err := newSource(path)
+	if err != nil {
+		return "", err
+	}
+	// Thumbnails of thumbnails would fill the cache.
+	if strings.HasPrefix(src.path, CacheDir()+string(filepath.Separator)) {
+		return "", fmt.Errorf("%w: %s is in the thumbnail cache", ErrUnsupported, src.path)
+	}
+	if thumbnail := Path(src.uri, size); src.valid(thumbnail) {
+		return thumbnail, nil
+	}
+	if src.valid(failPath(src.uri)) {
+		return "", fmt.Errorf("%w: %s", ErrFailed, src.path)
+	}
+
+	mimeType, err := mime.DetectMIME(src.path)
+	if err != nil {
+		return "", err
+	}
+	if !slices.Contains(nativeTypes, mimeType) {
+		t, found := thumbnailerFor(mimeType)
+		if !found {
+			return "", fmt.Errorf("%w: %s (%s)", ErrUnsupported, src.path, mimeType)
+		}
+		thumbnail, err := t.run(src, size, mimeType)
+		if err != nil {
+			src.recordFailure()
+			return "", fmt.Errorf("%w: %s: %v", ErrFailed, src.path, err)
+		}
+		return thumbnail, nil
+	}
+
+	img, err := decodeImage(src.path)
+	if err != nil {
+		src.recordFailure()
+		return "", fmt.Errorf("%w: %s: %v", ErrFailed, src.path, err)
+	}
+	return src.save(size, img, mimeType)
+}
+
+func decodeImage(path string) (image.Image, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	img, _, err := image.Decode(file)
+	return img, err
+}
+
+// Save stores an image rendered by the caller as the thumbnail of a file, scaled
+// down to the size, and returns the thumbnail path.
+func Save(path string, size Size, img image.Image) (string, error) {
+	if size.Dir() == "" {
+		return "", fmt.Errorf("invalid thumbnail size %d", size)
+	}
+	src, err := newSource(path)
+	if err != nil {
+		return "", err
+	}
+	mimeType, _ := mime.DetectMIME(src.path)
+	return src.save(size, img, mimeType)
+}
+
+// save writes the thumbnail of the source.
+func (src source) save(size Size, img image.Image, mimeType string) (string, error) {
+	text := src.text()
+	if mimeType != "" {
+		text[keyMIME] = mimeType
+	}
+	thumbnail := Path(src.uri, size)
+	return thumbnail, writePNG(thumbnail, scaleDown(img, int(size)), text)
+}
+
+// recordFailure writes the fail marker of the source: an empty thumbnail with
+// its metadata.
+func (src source) recordFailure() error {
+	return writePNG(failPath(src.uri), image.NewNRGBA(image.Rect(0, 0, 1, 1)), src.text())
+}
+
+// text returns the metadata of a thumbnail of the source.
+func (src source) text() map[string]string {
+	return map[string]string{
+		keyURI:      src.uri,
+		keyMTime:    strconv.FormatInt(src.mtime, 10),
+		keySize:     strconv.FormatInt(src.size, 10),
+		keySoftware: software,
+	}
+}
+
+// writePNG atomically writes a thumbnail, readable only by the user as the spec
+// requires.
+func writePNG(path string, img image.Image, text map[string]string) error {
+	data, err := encodePNG(img, text)
+	if err != nil {
+		return err
+	}
+	// Other processes must never read a partial thumbnail.
+	return atomicfile.WriteFile(path, data, 0600)
+}
+
+// scaleDown scales an image to fit in a size x size square, keeping its aspect
+// ratio, by averaging the source pixels. Smaller images are kept as they are.
+func scaleDown(img image.Image, size int) image.Image {
+	bounds := img.Bounds()
+	w, h := bounds.Dx(), bounds.Dy()
+	if size <= 0 || (w <= size && h <= size) {
+		return img
+	}
+	dw, dh := size, h*size/w
+	if h > w {
+		dw, dh = w*size/h, size
+	}
+	dw, dh = max(dw, 1), max(dh, 1)
+
+	out := image.NewRGBA(image.Rect(0, 0, dw, dh))
+	for y := 0; y < dh; y++ {
+		y0, y1 := y*h/dh, max((y+1)*h/dh, y*h/dh+1)
+		for x := 0; x < dw; x++ {
+			x0, x1 := x*w/dw, max((x+1)*w/dw, x*w/dw+1)
+			var r, g, b, a, n uint64
+			for sy := y0; sy < y1; sy++ {
+				for sx := x0; sx < x1; sx++ {
+					cr, cg, cb, ca := img.At(bounds.Min.X+sx, bounds.Min.Y+sy).RGBA()
+					r, g, b, a, n = r+uint64(cr), g+uint64(cg), b+uint64(cb), a+uint64(ca), n+1
+				}
+			}
+			out.SetRGBA(x, y, color.RGBA{uint8(r / n >> 8), uint8(g / n >> 8), uint8(b / n >> 8), uint8(a / n >> 8)})
+		}
+	}
+	return out
+}
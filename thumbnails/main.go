@@ -0,0 +1,170 @@
+/*
+	libxdg-go - An implementaion of various freedesktop specifications in go
+    Copyright (C) 2025 MiracleOS Contributors
+
+    This program is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    This program is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+*/
+
+// Package thumbnails implements the freedesktop Thumbnail Managing Standard:
+// looking up, generating and caching thumbnails in $XDG_CACHE_HOME/thumbnails.
+package thumbnails
+
+import (
+	"crypto/md5"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	basedir "github.com/MiracleOS-Team/libxdg-go/baseDir"
+)
+
+var (
+	// ErrUnsupported is returned for files no thumbnailer handles.
+	ErrUnsupported = errors.New("no thumbnailer for the file")
+	// ErrFailed is returned for files whose thumbnail failed before.
+	ErrFailed = errors.New("thumbnailing the file failed before")
+)
+
+// Program names the fail directory of this library, fail/<Program>, where the
+// files it can't thumbnail are recorded. Applications may set their own.
+var Program = "libxdg-go-1.0"
+
+// Size is the maximum width and height of a thumbnail, in pixels.
+type Size int
+
+const (
+	SizeNormal  Size = 128
+	SizeLarge   Size = 256
+	SizeXLarge  Size = 512
+	SizeXXLarge Size = 1024
+)
+
+// Sizes are the sizes of the standard cache directories, smallest first.
+var Sizes = []Size{SizeNormal, SizeLarge, SizeXLarge, SizeXXLarge}
+
+// Dir returns the name of the cache directory of the size, e.g. "large".
+func (s Size) Dir() string {
+	switch s {
+	case SizeNormal:
+		return "normal"
+	case SizeLarge:
+		return "large"
+	case SizeXLarge:
+		return "x-large"
+	case SizeXXLarge:
+		return "xx-large"
+	}
+	return ""
+}
+
+// CacheDir returns the thumbnail cache directory, $XDG_CACHE_HOME/thumbnails.
+func CacheDir() string {
+	return filepath.Join(basedir.GetXDGDirectory("cache").(string), "thumbnails")
+}
+
+// uriSafe are the bytes kept unescaped in file URIs, the same as GLib's, so that
+// thumbnails are shared with GLib applications.
+const uriSafe = "!$&'()*+,;=:@/-._~"
+
+// FileURI returns the file URI of an absolute path, escaped as the spec's
+// reference implementation does. The thumbnail file name is a hash of it.
+func FileURI(path string) string {
+	var b strings.Builder
+	b.WriteString("file://")
+	for i := 0; i < len(path); i++ {
+		c := path[i]
+		if c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || strings.IndexByte(uriSafe, c) >= 0 {
+			b.WriteByte(c)
+		} else {
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
+
+// fileName returns the name of the thumbnail of a URI: the MD5 of the URI.
+func fileName(uri string) string {
+	sum := md5.Sum([]byte(uri))
+	return hex.EncodeToString(sum[:]) + ".png"
+}
+
+// Path returns where the thumbnail of a URI of the given size is stored.
+func Path(uri string, size Size) string {
+	return filepath.Join(CacheDir(), size.Dir(), fileName(uri))
+}
+
+// failPath returns where the failure to thumbnail a URI is recorded.
+func failPath(uri string) string {
+	return filepath.Join(CacheDir(), "fail", Program, fileName(uri))
+}
+
+// source is a file to thumbnail.
+type source struct {
+	path  string
+	uri   string
+	mtime int64
+	size  int64
+}
+
+func newSource(path string) (source, error) {
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return source{}, err
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return source{}, err
+	}
+	return source{path: path, uri: FileURI(path), mtime: info.ModTime().Unix(), size: info.Size()}, nil
+}
+
+// valid reports whether a thumbnail file is up to date for the source: its
+// Thumb::URI and Thumb::MTime match.
+func (src source) valid(thumbnail string) bool {
+	text, err := readText(thumbnail)
+	if err != nil {
+		return false
+	}
+	return text[keyURI] == src.uri && text[keyMTime] == strconv.FormatInt(src.mtime, 10)
+}
+
+// Lookup returns the path of an up to date thumbnail of a file of the given size,
+// or of a larger one if there is none.
+func Lookup(path string, size Size) (string, bool) {
+	src, err := newSource(path)
+	if err != nil {
+		return "", false
+	}
+	for _, s := range Sizes {
+		if s < size {
+			continue
+		}
+		if thumbnail := Path(src.uri, s); src.valid(thumbnail) {
+			return thumbnail, true
+		}
+	}
+	return "", false
+}
+
+// HasFailed reports whether thumbnailing the file in its current version
+// failed before.
+func HasFailed(path string) bool {
+	src, err := newSource(path)
+	return err == nil && src.valid(failPath(src.uri))
+}
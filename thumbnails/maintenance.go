@@ -0,0 +1,203 @@
+/*
+	libxdg-go - An implementaion of various freedesktop specifications in go
+    Copyright (C) 2025 MiracleOS Contributors
+
+    This program is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    This program is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+*/
+
+package thumbnails
+
+import (
+	"errors"
+	"io/fs"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"syscall"
+	"time"
+
+	"github.com/MiracleOS-Team/libxdg-go/internal/atomicfile"
+)
+
+// cachedFile is a thumbnail or fail marker of the cache.
+type cachedFile struct {
+	path string
+	size int64
+	// used is the last time the thumbnail was read or written.
+	used time.Time
+}
+
+// cachedFiles returns the thumbnails and fail markers of the cache.
+func cachedFiles() ([]cachedFile, error) {
+	var files []cachedFile
+	err := filepath.WalkDir(CacheDir(), func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			if path == CacheDir() && errors.Is(err, fs.ErrNotExist) {
+				return filepath.SkipDir
+			}
+			return err
+		}
+		if entry.IsDir() || filepath.Ext(path) != ".png" {
+			return nil
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return nil
+		}
+		used := info.ModTime()
+		if stat, ok := info.Sys().(*syscall.Stat_t); ok {
+			if atime := time.Unix(stat.Atim.Unix()); atime.After(used) {
+				used = atime
+			}
+		}
+		files = append(files, cachedFile{path: path, size: info.Size(), used: used})
+		return nil
+	})
+	return files, err
+}
+
+// stale reports whether a cached thumbnail is of a local file that doesn't exist
+// anymore or changed since.
+func stale(path string) bool {
+	text, err := readText(path)
+	if err != nil {
+		// Not a thumbnail, or a broken one.
+		return true
+	}
+	u, err := url.Parse(text[keyURI])
+	if err != nil || u.Scheme != "file" {
+		return false
+	}
+	info, err := os.Stat(u.Path)
+	if err != nil {
+		return errors.Is(err, fs.ErrNotExist)
+	}
+	mtime, err := strconv.ParseInt(text[keyMTime], 10, 64)
+	return err == nil && mtime != info.ModTime().Unix()
+}
+
+// PurgeStale deletes the thumbnails and fail markers of local files that were
+// deleted or changed since, and returns how many were deleted.
+func PurgeStale() (int, error) {
+	files, err := cachedFiles()
+	if err != nil {
+		return 0, err
+	}
+	var errs []error
+	n := 0
+	for _, file := range files {
+		if !stale(file.path) {
+			continue
+		}
+		if err := os.Remove(file.path); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		n++
+	}
+	return n, errors.Join(errs...)
+}
+
+// CacheSize returns the total size of the thumbnail cache, in bytes.
+func CacheSize() (int64, error) {
+	files, err := cachedFiles()
+	var total int64
+	for _, file := range files {
+		total += file.size
+	}
+	return total, err
+}
+
+// EnforceBudget deletes the least recently used thumbnails until the cache holds
+// at most maxBytes, and returns how many were deleted.
+func EnforceBudget(maxBytes int64) (int, error) {
+	files, err := cachedFiles()
+	if err != nil {
+		return 0, err
+	}
+	var total int64
+	for _, file := range files {
+		total += file.size
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].used.Before(files[j].used) })
+
+	var errs []error
+	n := 0
+	for _, file := range files {
+		if total <= maxBytes {
+			break
+		}
+		if err := os.Remove(file.path); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		total -= file.size
+		n++
+	}
+	return n, errors.Join(errs...)
+}
+
+// Clear deletes the whole thumbnail cache.
+func Clear() error {
+	return os.RemoveAll(CacheDir())
+}
+
+// LegacyCacheDir returns the thumbnail cache location of older versions of the
+// spec, ~/.thumbnails.
+func LegacyCacheDir() string {
+	home, _ := os.UserHomeDir()
+	return filepath.Join(home, ".thumbnails")
+}
+
+// MigrateLegacy moves the thumbnails of ~/.thumbnails into the cache, keeping
+// those already there, then deletes ~/.thumbnails.
+func MigrateLegacy() error {
+	legacy := LegacyCacheDir()
+	if _, err := os.Stat(legacy); errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+
+	err := filepath.WalkDir(legacy, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil || entry.IsDir() || filepath.Ext(path) != ".png" {
+			return err
+		}
+		rel, err := filepath.Rel(legacy, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(CacheDir(), rel)
+		if _, err := os.Lstat(target); err == nil {
+			return nil
+		}
+		if err := os.MkdirAll(filepath.Dir(target), 0700); err != nil {
+			return err
+		}
+		if err := os.Rename(path, target); err == nil {
+			return nil
+		}
+		// Across filesystems, copy the file.
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		return atomicfile.WriteFile(target, data, 0600)
+	})
+	if err != nil {
+		return err
+	}
+	return os.RemoveAll(legacy)
+}
@@ -0,0 +1,120 @@
+/*
+	libxdg-go - An implementaion of various freedesktop specifications in go
+    Copyright (C) 2025 MiracleOS Contributors
+
+    This program is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    This program is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+*/
+
+package thumbnails
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"image"
+	"image/png"
+	"io"
+	"os"
+	"sort"
+)
+
+// Thumbnail metadata keys, stored as PNG tEXt chunks.
+const (
+	keyURI      = "Thumb::URI"
+	keyMTime    = "Thumb::MTime"
+	keySize     = "Thumb::Size"
+	keyMIME     = "Thumb::Mime"
+	keySoftware = "Software"
+)
+
+var pngSignature = []byte("\x89PNG\r\n\x1a\n")
+
+var errNotPNG = errors.New("not a PNG file")
+
+// readText returns the tEXt chunks of a PNG file, stopping at the image data
+// where thumbnail metadata ends.
+func readText(path string) (map[string]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	signature := make([]byte, len(pngSignature))
+	if _, err := io.ReadFull(file, signature); err != nil || !bytes.Equal(signature, pngSignature) {
+		return nil, errNotPNG
+	}
+
+	text := make(map[string]string)
+	header := make([]byte, 8)
+	for {
+		if _, err := io.ReadFull(file, header); err != nil {
+			return text, nil
+		}
+		length := binary.BigEndian.Uint32(header)
+		kind := string(header[4:])
+		if kind == "IDAT" || kind == "IEND" {
+			return text, nil
+		}
+		if kind != "tEXt" || length > 1<<20 {
+			if _, err := file.Seek(int64(length)+4, io.SeekCurrent); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		data := make([]byte, length+4)
+		if _, err := io.ReadFull(file, data); err != nil {
+			return nil, err
+		}
+		if key, value, found := bytes.Cut(data[:length], []byte{0}); found {
+			text[string(key)] = string(value)
+		}
+	}
+}
+
+// encodePNG encodes an image as PNG with tEXt chunks, inserted after the IHDR
+// chunk as image/png doesn't write them.
+func encodePNG(img image.Image, text map[string]string) ([]byte, error) {
+	var encoded bytes.Buffer
+	if err := png.Encode(&encoded, img); err != nil {
+		return nil, err
+	}
+	data := encoded.Bytes()
+	// The signature is followed by IHDR: length, type, 13 bytes of data and CRC.
+	ihdrEnd := len(pngSignature) + 8 + 13 + 4
+
+	keys := make([]string, 0, len(text))
+	for key := range text {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var out bytes.Buffer
+	out.Write(data[:ihdrEnd])
+	for _, key := range keys {
+		writeChunk(&out, "tEXt", append(append([]byte(key), 0), text[key]...))
+	}
+	out.Write(data[ihdrEnd:])
+	return out.Bytes(), nil
+}
+
+func writeChunk(w *bytes.Buffer, kind string, data []byte) {
+	binary.Write(w, binary.BigEndian, uint32(len(data)))
+	start := w.Len()
+	w.WriteString(kind)
+	w.Write(data)
+	binary.Write(w, binary.BigEndian, crc32.ChecksumIEEE(w.Bytes()[start:]))
+}
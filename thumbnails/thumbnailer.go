@@ -0,0 +1,173 @@
+/*
+	libxdg-go - An implementaion of various freedesktop specifications in go
+    Copyright (C) 2025 MiracleOS Contributors
+
+    This program is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    This program is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+*/
+
+package thumbnails
+
+import (
+	"context"
+	"fmt"
+	"image/png"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	basedir "github.com/MiracleOS-Team/libxdg-go/baseDir"
+	"github.com/MiracleOS-Team/libxdg-go/desktopFiles"
+	"github.com/MiracleOS-Team/libxdg-go/keyfile"
+	"github.com/MiracleOS-Team/libxdg-go/mime"
+)
+
+// thumbnailerTimeout bounds the run of an external thumbnailer.
+const thumbnailerTimeout = 30 * time.Second
+
+// Thumbnailer is an external thumbnailer, described by a .thumbnailer file of a
+// thumbnailers data directory.
+type Thumbnailer struct {
+	// ID is the name of the .thumbnailer file; the first one found wins.
+	ID   string
+	Path string
+	// Exec is the command line, with %u, %i, %o and %s standing for the URI
+	// and path of the input, the output path and the size.
+	Exec      string
+	TryExec   string
+	MIMETypes []string
+}
+
+// thumbnailerDirs returns the thumbnailers directories, highest precedence first.
+func thumbnailerDirs() []string {
+	dirs := []string{filepath.Join(basedir.GetXDGDirectory("data").(string), "thumbnailers")}
+	for _, dir := range basedir.GetXDGDirectory("dataDirs").([]string) {
+		dirs = append(dirs, filepath.Join(dir, "thumbnailers"))
+	}
+	return dirs
+}
+
+// Thumbnailers returns the installed thumbnailers whose program is available.
+func Thumbnailers() []Thumbnailer {
+	seen := make(map[string]bool)
+	var thumbnailers []Thumbnailer
+	for _, dir := range thumbnailerDirs() {
+		paths, _ := filepath.Glob(filepath.Join(dir, "*.thumbnailer"))
+		for _, path := range paths {
+			id := filepath.Base(path)
+			if seen[id] {
+				continue
+			}
+			seen[id] = true
+
+			t, err := readThumbnailer(path)
+			if err != nil || !t.available() {
+				continue
+			}
+			thumbnailers = append(thumbnailers, t)
+		}
+	}
+	return thumbnailers
+}
+
+func readThumbnailer(path string) (Thumbnailer, error) {
+	kf, err := keyfile.LoadLenient(path)
+	if err != nil {
+		return Thumbnailer{}, err
+	}
+	group := kf.Group("Thumbnailer Entry")
+	if group == nil {
+		return Thumbnailer{}, fmt.Errorf("%s: no Thumbnailer Entry group", path)
+	}
+	t := Thumbnailer{
+		ID:      filepath.Base(path),
+		Path:    path,
+		Exec:    group.String("Exec"),
+		TryExec: group.String("TryExec"),
+	}
+	for _, mimeType := range group.Strings("MimeType") {
+		if mimeType = strings.TrimSpace(mimeType); mimeType != "" {
+			t.MIMETypes = append(t.MIMETypes, mimeType)
+		}
+	}
+	if t.Exec == "" {
+		return Thumbnailer{}, fmt.Errorf("%s: no Exec key", path)
+	}
+	return t, nil
+}
+
+// available reports whether the program of the thumbnailer is installed.
+func (t Thumbnailer) available() bool {
+	program := t.TryExec
+	if program == "" {
+		if args := desktopFiles.SplitExec(t.Exec); len(args) > 0 {
+			program = args[0]
+		}
+	}
+	_, err := exec.LookPath(program)
+	return err == nil
+}
+
+// Supports reports whether the thumbnailer handles a type or one of its
+// supertypes.
+func (t Thumbnailer) Supports(mimeType string) bool {
+	return mime.Default().SupportsType(t.MIMETypes, mimeType)
+}
+
+// thumbnailerFor returns the first installed thumbnailer handling a type.
+func thumbnailerFor(mimeType string) (Thumbnailer, bool) {
+	for _, t := range Thumbnailers() {
+		if t.Supports(mimeType) {
+			return t, true
+		}
+	}
+	return Thumbnailer{}, false
+}
+
+// run renders the thumbnail of the source with the thumbnailer and stores it in
+// the cache.
+func (t Thumbnailer) run(src source, size Size, mimeType string) (string, error) {
+	output, err := os.CreateTemp("", "libxdg-thumbnail-*.png")
+	if err != nil {
+		return "", err
+	}
+	output.Close()
+	defer os.Remove(output.Name())
+
+	replacer := strings.NewReplacer("%u", src.uri, "%i", src.path, "%o", output.Name(), "%s", strconv.Itoa(int(size)), "%%", "%")
+	args := desktopFiles.SplitExec(t.Exec)
+	for i, arg := range args {
+		args[i] = replacer.Replace(arg)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), thumbnailerTimeout)
+	defer cancel()
+	if out, err := exec.CommandContext(ctx, args[0], args[1:]...).CombinedOutput(); err != nil {
+		return "", fmt.Errorf("%s: %v: %s", t.ID, err, strings.TrimSpace(string(out)))
+	}
+
+	file, err := os.Open(output.Name())
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+	img, err := png.Decode(file)
+	if err != nil {
+		return "", fmt.Errorf("%s: invalid output: %w", t.ID, err)
+	}
+	return src.save(size, img, mimeType)
+}
@@ -0,0 +1,196 @@
+/*
+	libxdg-go - An implementaion of various freedesktop specifications in go
+    Copyright (C) 2025 MiracleOS Contributors
+
+    This program is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    This program is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+*/
+
+// Package timedate is a client of systemd-timedated, org.freedesktop.timedate1,
+// which sets the system clock, time zone and network time synchronization.
+package timedate
+
+import (
+	"sync"
+	"time"
+
+	"github.com/MiracleOS-Team/libxdg-go/internal/signals"
+	"github.com/godbus/dbus/v5"
+)
+
+const (
+	busName       = "org.freedesktop.timedate1"
+	objectPath    = dbus.ObjectPath("/org/freedesktop/timedate1")
+	interfaceName = "org.freedesktop.timedate1"
+)
+
+// Info is the state of timedated.
+type Info struct {
+	// Timezone is e.g. "Europe/Berlin".
+	Timezone string
+	// LocalRTC is set when the hardware clock keeps local time rather than UTC.
+	LocalRTC        bool
+	CanNTP          bool
+	NTP             bool
+	NTPSynchronized bool
+	Time            time.Time
+	RTCTime         time.Time
+}
+
+// Location returns the time zone of the system, or UTC if it is unknown.
+func (i Info) Location() *time.Location {
+	if loc, err := time.LoadLocation(i.Timezone); err == nil && i.Timezone != "" {
+		return loc
+	}
+	return time.UTC
+}
+
+// Client talks to timedated over the system bus. Setters with interactive set
+// let polkit ask the user for authorization.
+type Client struct {
+	conn        *dbus.Conn
+	own         bool
+	obj         dbus.BusObject
+	subscribers signals.Subscribers[Info]
+	watch       *signals.Watch
+	closeOnce   sync.Once
+}
+
+// NewClient connects to the system bus.
+func NewClient() (*Client, error) {
+	conn, err := dbus.ConnectSystemBus()
+	if err != nil {
+		return nil, err
+	}
+	c, err := newClient(conn, true)
+	if err != nil {
+		conn.Close()
+	}
+	return c, err
+}
+
+// NewClientWithConn creates a client on an existing system bus connection,
+// which is left open by Close.
+func NewClientWithConn(conn *dbus.Conn) (*Client, error) {
+	return newClient(conn, false)
+}
+
+func newClient(conn *dbus.Conn, own bool) (*Client, error) {
+	c := &Client{conn: conn, own: own, obj: conn.Object(busName, objectPath)}
+	watch, err := signals.NewWatch(conn, 16, c.dispatch, c.match())
+	if err != nil {
+		return nil, err
+	}
+	c.watch = watch
+	return c, nil
+}
+
+func (c *Client) match() []dbus.MatchOption {
+	return []dbus.MatchOption{
+		dbus.WithMatchObjectPath(objectPath),
+		dbus.WithMatchInterface("org.freedesktop.DBus.Properties"),
+		dbus.WithMatchMember("PropertiesChanged"),
+		dbus.WithMatchArg(0, interfaceName),
+	}
+}
+
+// Close stops listening to timedated.
+func (c *Client) Close() error {
+	var err error
+	c.closeOnce.Do(func() {
+		c.watch.Close()
+		c.subscribers.Close()
+		if c.own {
+			err = c.conn.Close()
+		}
+	})
+	return err
+}
+
+// Subscribe registers a consumer of the changes to timedated's state, such as
+// a new time zone. Changes that don't fit in the buffer are dropped. The
+// channel is closed by cancel or by Close.
+func (c *Client) Subscribe(buffer int) (<-chan Info, func()) {
+	return c.subscribers.Subscribe(buffer)
+}
+
+// dispatch reads the state again on changes.
+func (c *Client) dispatch(*dbus.Signal) {
+	if info, err := c.Info(); err == nil {
+		c.subscribers.Publish(info)
+	}
+}
+
+// Info reads the state of timedated.
+func (c *Client) Info() (Info, error) {
+	var props map[string]dbus.Variant
+	if err := c.obj.Call("org.freedesktop.DBus.Properties.GetAll", 0, interfaceName).Store(&props); err != nil {
+		return Info{}, err
+	}
+	get := func(name string, value any) {
+		if v, found := props[name]; found {
+			v.Store(value)
+		}
+	}
+	var i Info
+	var timeUSec, rtcTimeUSec uint64
+	get("Timezone", &i.Timezone)
+	get("LocalRTC", &i.LocalRTC)
+	get("CanNTP", &i.CanNTP)
+	get("NTP", &i.NTP)
+	get("NTPSynchronized", &i.NTPSynchronized)
+	get("TimeUSec", &timeUSec)
+	get("RTCTimeUSec", &rtcTimeUSec)
+	if timeUSec != 0 {
+		i.Time = time.UnixMicro(int64(timeUSec))
+	}
+	if rtcTimeUSec != 0 {
+		i.RTCTime = time.UnixMicro(int64(rtcTimeUSec))
+	}
+	return i, nil
+}
+
+// SetTime sets the system clock. It fails while NTP is on.
+func (c *Client) SetTime(t time.Time, interactive bool) error {
+	return c.obj.Call(interfaceName+".SetTime", 0, t.UnixMicro(), false, interactive).Err
+}
+
+// AdjustTime moves the system clock by offset.
+func (c *Client) AdjustTime(offset time.Duration, interactive bool) error {
+	return c.obj.Call(interfaceName+".SetTime", 0, offset.Microseconds(), true, interactive).Err
+}
+
+// SetTimezone sets the system time zone, one of ListTimezones.
+func (c *Client) SetTimezone(timezone string, interactive bool) error {
+	return c.obj.Call(interfaceName+".SetTimezone", 0, timezone, interactive).Err
+}
+
+// SetLocalRTC sets whether the hardware clock keeps local time. With
+// fixSystem, the system clock is set from the hardware clock, else the other
+// way around.
+func (c *Client) SetLocalRTC(local, fixSystem, interactive bool) error {
+	return c.obj.Call(interfaceName+".SetLocalRTC", 0, local, fixSystem, interactive).Err
+}
+
+// SetNTP turns network time synchronization on or off.
+func (c *Client) SetNTP(enabled, interactive bool) error {
+	return c.obj.Call(interfaceName+".SetNTP", 0, enabled, interactive).Err
+}
+
+// ListTimezones lists the time zones SetTimezone accepts.
+func (c *Client) ListTimezones() ([]string, error) {
+	var timezones []string
+	err := c.obj.Call(interfaceName+".ListTimezones", 0).Store(&timezones)
+	return timezones, err
+}
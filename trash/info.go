@@ -0,0 +1,85 @@
+/*
+	libxdg-go - An implementaion of various freedesktop specifications in go
+    Copyright (C) 2025 MiracleOS Contributors
+
+    This program is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    This program is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+*/
+
+package trash
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const (
+	infoGroup = "[Trash Info]"
+	// dateLayout is the format of DeletionDate, in local time.
+	dateLayout = "2006-01-02T15:04:05"
+)
+
+var errBadInfo = errors.New("invalid .trashinfo file")
+
+// info returns the content of the .trashinfo file of the item.
+func (i Item) info() []byte {
+	path := i.Path
+	if i.dir.topdir != "" {
+		if rel, err := filepath.Rel(i.dir.topdir, path); err == nil {
+			path = rel
+		}
+	}
+	return []byte(fmt.Sprintf("%s\nPath=%s\nDeletionDate=%s\n", infoGroup, (&url.URL{Path: path}).EscapedPath(), i.DeletionDate.Format(dateLayout)))
+}
+
+// parseInfo reads the original path and deletion date from a .trashinfo file.
+func (i *Item) parseInfo(data []byte) error {
+	inGroup := false
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if strings.HasPrefix(line, "[") {
+			inGroup = line == infoGroup
+			continue
+		}
+		key, value, found := strings.Cut(line, "=")
+		if !inGroup || !found {
+			continue
+		}
+		switch strings.TrimSpace(key) {
+		case "Path":
+			path, err := url.PathUnescape(strings.TrimSpace(value))
+			if err != nil {
+				return fmt.Errorf("%w: %v", errBadInfo, err)
+			}
+			if !filepath.IsAbs(path) && i.dir.topdir != "" {
+				path = filepath.Join(i.dir.topdir, path)
+			}
+			i.Path = path
+		case "DeletionDate":
+			// A missing or invalid date is tolerated, as other implementations do.
+			i.DeletionDate, _ = time.ParseInLocation(dateLayout, strings.TrimSpace(value), time.Local)
+		}
+	}
+	if i.Path == "" {
+		return errBadInfo
+	}
+	return nil
+}
@@ -0,0 +1,378 @@
+/*
+	libxdg-go - An implementaion of various freedesktop specifications in go
+    Copyright (C) 2025 MiracleOS Contributors
+
+    This program is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    This program is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+*/
+
+// Package trash implements the freedesktop Trash specification: moving files to
+// the trash, listing, restoring and deleting trashed items.
+package trash
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	basedir "github.com/MiracleOS-Team/libxdg-go/baseDir"
+)
+
+var (
+	// ErrExists is returned when restoring an item whose original path is taken.
+	ErrExists = errors.New("a file already exists at the original path")
+	// ErrNoTrash is returned when no trash directory can hold a file.
+	ErrNoTrash = errors.New("no trash directory available for the file")
+)
+
+const (
+	filesDir   = "files"
+	infoDir    = "info"
+	infoSuffix = ".trashinfo"
+	// reserveGrace is how long an info file may lack its file before it is
+	// stray: a Trash in progress creates the info file first.
+	reserveGrace = time.Minute
+)
+
+// Item is a trashed file.
+type Item struct {
+	// Name is the name of the item in the trash, unique in its trash directory.
+	Name string
+	// Path is the absolute path the file was trashed from.
+	Path         string
+	DeletionDate time.Time
+	// IsDir tells whether the item is a directory.
+	IsDir bool
+	// Size is the size of the file, or the total size of the files of a
+	// directory, in bytes.
+	Size int64
+
+	dir *dir
+}
+
+// FilePath returns the path of the trashed file.
+func (i Item) FilePath() string {
+	return filepath.Join(i.dir.path, filesDir, i.Name)
+}
+
+// InfoPath returns the path of the .trashinfo file of the item.
+func (i Item) InfoPath() string {
+	return filepath.Join(i.dir.path, infoDir, i.Name+infoSuffix)
+}
+
+// TrashDir returns the trash directory holding the item.
+func (i Item) TrashDir() string {
+	return i.dir.path
+}
+
+// dir is a trash directory.
+type dir struct {
+	path string
+	// topdir is the directory paths in .trashinfo files are relative to, or ""
+	// if they are absolute.
+	topdir string
+}
+
+// HomeTrash returns the path of the user's trash directory, $XDG_DATA_HOME/Trash.
+func HomeTrash() string {
+	return filepath.Join(basedir.GetXDGDirectory("data").(string), "Trash")
+}
+
+func homeDir() *dir {
+	return &dir{path: HomeTrash()}
+}
+
+// create creates the files and info directories of the trash directory.
+func (d *dir) create() error {
+	for _, sub := range []string{filesDir, infoDir} {
+		if err := os.MkdirAll(filepath.Join(d.path, sub), 0700); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Options tune how files are trashed.
+type Options struct {
+	// CopyToHome allows trashing files of volumes without a usable trash
+	// directory by copying them to the home trash, then deleting them.
+	CopyToHome bool
+}
+
+// Trash moves a file or directory to the trash and returns the trashed item.
+// Files of other volumes than the home directory's go to the trash directory of
+// their volume.
+func Trash(path string) (Item, error) {
+	return TrashWith(path, Options{})
+}
+
+// TrashWith is Trash with options.
+func TrashWith(path string, opts Options) (Item, error) {
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return Item{}, err
+	}
+	info, err := os.Lstat(path)
+	if err != nil {
+		return Item{}, err
+	}
+
+	home := homeDir()
+	if sameDevice(info, home.path) {
+		return home.trash(path, false)
+	}
+	d, err := volumeDir(path, info)
+	if err == nil {
+		return d.trash(path, false)
+	}
+	if !opts.CopyToHome {
+		return Item{}, fmt.Errorf("%w: %s: %v", ErrNoTrash, path, err)
+	}
+	return home.trash(path, true)
+}
+
+// trash moves an absolute path into the trash directory, or copies it then
+// deletes it if copy is set. The .trashinfo file is created first, exclusively,
+// which reserves the name of the item.
+func (d *dir) trash(path string, copy bool) (Item, error) {
+	item, err := d.reserve(path)
+	if err != nil {
+		return Item{}, err
+	}
+
+	if copy {
+		if err := copyTree(path, item.FilePath()); err != nil {
+			os.RemoveAll(item.FilePath())
+			os.Remove(item.InfoPath())
+			return Item{}, err
+		}
+		// The item is in the trash even if the original couldn't be fully deleted.
+		err = os.RemoveAll(path)
+	} else if err := os.Rename(path, item.FilePath()); err != nil {
+		os.Remove(item.InfoPath())
+		return Item{}, err
+	}
+
+	if trashed, statErr := d.item(item.Name); statErr == nil {
+		items := []Item{trashed}
+		d.fillSizes(items)
+		item = items[0]
+	}
+	return item, err
+}
+
+// reserve creates the .trashinfo file of a new item for path, under a name
+// free in both the files and info directories.
+func (d *dir) reserve(path string) (Item, error) {
+	if err := d.create(); err != nil {
+		return Item{}, err
+	}
+
+	item := Item{Path: path, DeletionDate: time.Now(), dir: d}
+	base := filepath.Base(path)
+	ext := filepath.Ext(base)
+	for n := 1; ; n++ {
+		item.Name = base
+		if n > 1 {
+			item.Name = strings.TrimSuffix(base, ext) + "." + strconv.Itoa(n) + ext
+		}
+		file, err := os.OpenFile(item.InfoPath(), os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+		if errors.Is(err, os.ErrExist) {
+			continue
+		}
+		if err != nil {
+			return Item{}, err
+		}
+		// A stray file of that name would be replaced by the rename.
+		if _, err := os.Lstat(item.FilePath()); !errors.Is(err, os.ErrNotExist) {
+			file.Close()
+			os.Remove(item.InfoPath())
+			if err != nil {
+				return Item{}, err
+			}
+			continue
+		}
+		_, err = file.Write(item.info())
+		if closeErr := file.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			os.Remove(item.InfoPath())
+			return Item{}, err
+		}
+		return item, nil
+	}
+}
+
+// List returns the items of the home trash and of the trash directories of
+// mounted volumes, most recently deleted first.
+func List() ([]Item, error) {
+	var items []Item
+	for _, d := range trashDirs() {
+		dirItems, err := d.list()
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, dirItems...)
+	}
+	sortItems(items)
+	return items, nil
+}
+
+func sortItems(items []Item) {
+	sort.SliceStable(items, func(i, j int) bool { return items[i].DeletionDate.After(items[j].DeletionDate) })
+}
+
+// list returns the items of the trash directory. Items with an unreadable info
+// file or whose file is missing are skipped.
+func (d *dir) list() ([]Item, error) {
+	entries, err := os.ReadDir(filepath.Join(d.path, infoDir))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var items []Item
+	for _, entry := range entries {
+		name, found := strings.CutSuffix(entry.Name(), infoSuffix)
+		if !found || entry.IsDir() {
+			continue
+		}
+		item, err := d.item(name)
+		if err != nil {
+			continue
+		}
+		items = append(items, item)
+	}
+	d.fillSizes(items)
+	return items, nil
+}
+
+// item reads the item of the given name.
+func (d *dir) item(name string) (Item, error) {
+	item := Item{Name: name, dir: d}
+	data, err := os.ReadFile(item.InfoPath())
+	if err != nil {
+		return Item{}, err
+	}
+	if err := item.parseInfo(data); err != nil {
+		return Item{}, err
+	}
+	info, err := os.Lstat(item.FilePath())
+	if err != nil {
+		return Item{}, err
+	}
+	item.IsDir = info.IsDir()
+	if !item.IsDir {
+		item.Size = info.Size()
+	}
+	return item, nil
+}
+
+// Restore moves a trashed item back to its original path, recreating its parent
+// directories. It fails with ErrExists if the path is taken.
+func Restore(item Item) error {
+	if item.dir == nil {
+		return fmt.Errorf("%s: not a trashed item", item.Name)
+	}
+	if _, err := os.Lstat(item.Path); err == nil {
+		return fmt.Errorf("%w: %s", ErrExists, item.Path)
+	}
+	if err := os.MkdirAll(filepath.Dir(item.Path), 0755); err != nil {
+		return err
+	}
+	if err := move(item.FilePath(), item.Path); err != nil {
+		return err
+	}
+	return os.Remove(item.InfoPath())
+}
+
+// Delete permanently deletes a trashed item.
+func Delete(item Item) error {
+	if item.dir == nil {
+		return fmt.Errorf("%s: not a trashed item", item.Name)
+	}
+	// The file goes first: an info file without its file is garbage, the reverse
+	// would be an item that can't be restored.
+	if err := os.RemoveAll(item.FilePath()); err != nil {
+		return err
+	}
+	return os.Remove(item.InfoPath())
+}
+
+// Empty permanently deletes the items trashed more than olderThan ago, or all of
+// them, along with stray files, if olderThan is 0. All known trash directories
+// are emptied.
+func Empty(olderThan time.Duration) error {
+	var errs []error
+	for _, d := range trashDirs() {
+		errs = append(errs, d.empty(olderThan))
+	}
+	return errors.Join(errs...)
+}
+
+func (d *dir) empty(olderThan time.Duration) error {
+	items, err := d.list()
+	if err != nil {
+		return err
+	}
+	limit := time.Now().Add(-olderThan)
+	var errs []error
+	for _, item := range items {
+		if olderThan == 0 || item.DeletionDate.Before(limit) {
+			if err := Delete(item); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+	if olderThan == 0 {
+		errs = append(errs, d.removeStray())
+	}
+	return errors.Join(errs...)
+}
+
+// removeStray removes the files without an info file and the info files without
+// a file, left behind by interrupted operations. Recent info files are kept, as
+// they may belong to items being trashed.
+func (d *dir) removeStray() error {
+	var errs []error
+	files, _ := os.ReadDir(filepath.Join(d.path, filesDir))
+	for _, file := range files {
+		if _, err := os.Lstat(filepath.Join(d.path, infoDir, file.Name()+infoSuffix)); errors.Is(err, os.ErrNotExist) {
+			errs = append(errs, os.RemoveAll(filepath.Join(d.path, filesDir, file.Name())))
+		}
+	}
+	infos, _ := os.ReadDir(filepath.Join(d.path, infoDir))
+	for _, info := range infos {
+		name, found := strings.CutSuffix(info.Name(), infoSuffix)
+		if !found {
+			continue
+		}
+		if _, err := os.Lstat(filepath.Join(d.path, filesDir, name)); !errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if stat, err := info.Info(); err != nil || time.Since(stat.ModTime()) < reserveGrace {
+			continue
+		}
+		errs = append(errs, os.Remove(filepath.Join(d.path, infoDir, info.Name())))
+	}
+	return errors.Join(errs...)
+}
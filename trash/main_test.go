@@ -0,0 +1,79 @@
+/*
+	libxdg-go - An implementaion of various freedesktop specifications in go
+    Copyright (C) 2025 MiracleOS Contributors
+
+    This program is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    This program is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+*/
+
+package trash
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestReserveSkipsStrayFiles(t *testing.T) {
+	d := &dir{path: t.TempDir()}
+	if err := d.create(); err != nil {
+		t.Fatal(err)
+	}
+	stray := filepath.Join(d.path, filesDir, "a.txt")
+	if err := os.WriteFile(stray, []byte("stray"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	item, err := d.reserve("/home/user/a.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if item.Name != "a.2.txt" {
+		t.Errorf("got name %q, want a.2.txt", item.Name)
+	}
+	if _, err := os.Lstat(filepath.Join(d.path, infoDir, "a.txt"+infoSuffix)); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("the info file of the taken name was left: %v", err)
+	}
+}
+
+func TestRemoveStrayKeepsReservations(t *testing.T) {
+	d := &dir{path: t.TempDir()}
+	if err := d.create(); err != nil {
+		t.Fatal(err)
+	}
+	reserved, err := d.reserve("/home/user/new.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	old, err := d.reserve("/home/user/old.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	past := time.Now().Add(-2 * reserveGrace)
+	if err := os.Chtimes(old.InfoPath(), past, past); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := d.removeStray(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Lstat(reserved.InfoPath()); err != nil {
+		t.Errorf("the info file of an item being trashed was removed: %v", err)
+	}
+	if _, err := os.Lstat(old.InfoPath()); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("the stray info file was kept: %v", err)
+	}
+}
@@ -0,0 +1,150 @@
+/*
+	libxdg-go - An implementaion of various freedesktop specifications in go
+    Copyright (C) 2025 MiracleOS Contributors
+
+    This program is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    This program is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+*/
+
+package trash
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"io/fs"
+	"log/slog"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/MiracleOS-Team/libxdg-go/internal/atomicfile"
+)
+
+// sizesFile caches the sizes of the trashed directories, which are costly to
+// compute. Its lines are "size mtime name", mtime being the modification time of
+// the item's info file in seconds and name being percent-encoded.
+const sizesFile = "directorysizes"
+
+type sizeEntry struct {
+	size  int64
+	mtime int64
+}
+
+// readSizes reads the directorysizes file of the trash directory.
+func (d *dir) readSizes() map[string]sizeEntry {
+	sizes := make(map[string]sizeEntry)
+	file, err := os.Open(filepath.Join(d.path, sizesFile))
+	if err != nil {
+		return sizes
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		fields := strings.SplitN(scanner.Text(), " ", 3)
+		if len(fields) != 3 {
+			continue
+		}
+		size, err1 := strconv.ParseInt(fields[0], 10, 64)
+		mtime, err2 := strconv.ParseInt(fields[1], 10, 64)
+		name, err3 := url.PathUnescape(fields[2])
+		if err1 != nil || err2 != nil || err3 != nil {
+			continue
+		}
+		sizes[name] = sizeEntry{size: size, mtime: mtime}
+	}
+	return sizes
+}
+
+// writeSizes atomically replaces the directorysizes file of the trash directory.
+func (d *dir) writeSizes(sizes map[string]sizeEntry) error {
+	return atomicfile.Write(filepath.Join(d.path, sizesFile), 0600, func(w io.Writer) error {
+		for name, entry := range sizes {
+			if _, err := fmt.Fprintf(w, "%d %d %s\n", entry.size, entry.mtime, url.PathEscape(name)); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// fillSizes sets the size of the directory items from the directorysizes cache,
+// computing and caching those that are missing or outdated. Entries of items
+// that left the trash are dropped from the cache.
+func (d *dir) fillSizes(items []Item) {
+	sizes := d.readSizes()
+	changed := false
+
+	for i := range items {
+		if !items[i].IsDir {
+			continue
+		}
+		info, err := os.Stat(items[i].InfoPath())
+		if err != nil {
+			continue
+		}
+		mtime := info.ModTime().Unix()
+		if entry, exists := sizes[items[i].Name]; exists && entry.mtime == mtime {
+			items[i].Size = entry.size
+			continue
+		}
+		items[i].Size = treeSize(items[i].FilePath())
+		sizes[items[i].Name] = sizeEntry{size: items[i].Size, mtime: mtime}
+		changed = true
+	}
+	for name := range sizes {
+		if _, err := os.Lstat(filepath.Join(d.path, infoDir, name+infoSuffix)); err != nil {
+			delete(sizes, name)
+			changed = true
+		}
+	}
+
+	if changed {
+		if err := d.writeSizes(sizes); err != nil {
+			slog.Warn("Failed to update the trash directory sizes", "dir", d.path, "error", err)
+		}
+	}
+}
+
+// treeSize returns the total size of the files of a directory tree.
+func treeSize(root string) int64 {
+	var size int64
+	filepath.WalkDir(root, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil || entry.IsDir() {
+			return nil
+		}
+		if info, err := entry.Info(); err == nil {
+			size += info.Size()
+		}
+		return nil
+	})
+	return size
+}
+
+// TotalSize returns the total size of the items of all trash directories, in
+// bytes.
+func TotalSize() (int64, error) {
+	items, err := List()
+	if err != nil {
+		return 0, err
+	}
+	var total int64
+	for _, item := range items {
+		total += item.Size
+	}
+	return total, nil
+}
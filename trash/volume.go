@@ -0,0 +1,293 @@
+/*
+	libxdg-go - An implementaion of various freedesktop specifications in go
+    Copyright (C) 2025 MiracleOS Contributors
+
+    This program is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    This program is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+*/
+
+package trash
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+// virtualFilesystems are the filesystem types that never hold a trash directory.
+var virtualFilesystems = map[string]bool{
+	"autofs": true, "binfmt_misc": true, "bpf": true, "cgroup": true, "cgroup2": true,
+	"configfs": true, "debugfs": true, "devpts": true, "devtmpfs": true, "efivarfs": true,
+	"fusectl": true, "hugetlbfs": true, "mqueue": true, "nsfs": true, "proc": true,
+	"pstore": true, "securityfs": true, "sysfs": true, "tracefs": true,
+}
+
+// device returns the device of a file.
+func device(info os.FileInfo) (uint64, bool) {
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return 0, false
+	}
+	return uint64(stat.Dev), true
+}
+
+// sameDevice reports whether a file is on the device of path, or of its nearest
+// existing ancestor if path doesn't exist yet.
+func sameDevice(info os.FileInfo, path string) bool {
+	dev, ok := device(info)
+	if !ok {
+		return true
+	}
+	for {
+		if other, err := os.Stat(path); err == nil {
+			otherDev, _ := device(other)
+			return dev == otherDev
+		}
+		parent := filepath.Dir(path)
+		if parent == path {
+			return false
+		}
+		path = parent
+	}
+}
+
+// topdir returns the mount point of the volume of a file: its highest ancestor
+// on the same device.
+func topdir(path string, info os.FileInfo) string {
+	dev, _ := device(info)
+	top := path
+	for dir := filepath.Dir(path); ; dir = filepath.Dir(dir) {
+		parent, err := os.Stat(dir)
+		if err != nil {
+			break
+		}
+		if parentDev, _ := device(parent); parentDev != dev {
+			break
+		}
+		top = dir
+		if dir == "/" {
+			break
+		}
+	}
+	return top
+}
+
+// volumeDir returns the trash directory of the volume of a file, creating it
+// if needed: $topdir/.Trash/$uid if $topdir/.Trash is a sticky directory, else
+// $topdir/.Trash-$uid.
+func volumeDir(path string, info os.FileInfo) (*dir, error) {
+	return topdirTrash(topdir(path, info))
+}
+
+// topdirTrash returns the trash directory of the volume mounted at top, creating
+// it if needed.
+func topdirTrash(top string) (*dir, error) {
+	if d := sharedDir(top); d != nil {
+		// Another user may have created $topdir/.Trash/$uid to receive our
+		// files: it is only used if it is ours.
+		if err := os.Mkdir(d.path, 0700); err == nil || errors.Is(err, os.ErrExist) {
+			if d.checkOwned() == nil && d.create() == nil {
+				return d, nil
+			}
+		}
+	}
+	d := &dir{path: filepath.Join(top, ".Trash-"+strconv.Itoa(os.Getuid())), topdir: top}
+	if err := os.Mkdir(d.path, 0700); err != nil && !errors.Is(err, os.ErrExist) {
+		return nil, err
+	}
+	if err := d.checkOwned(); err != nil {
+		return nil, err
+	}
+	if err := d.create(); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+// sharedDir returns the user's directory in the administrator-created
+// $topdir/.Trash, or nil if that isn't a real sticky directory, as the spec
+// requires to prevent other users from tampering with it.
+func sharedDir(top string) *dir {
+	info, err := os.Lstat(filepath.Join(top, ".Trash"))
+	if err != nil || !info.IsDir() || info.Mode()&os.ModeSticky == 0 {
+		return nil
+	}
+	return &dir{path: filepath.Join(top, ".Trash", strconv.Itoa(os.Getuid())), topdir: top}
+}
+
+// checkOwned checks that a volume trash directory, $topdir/.Trash/$uid or
+// $topdir/.Trash-$uid, is a real directory owned by the user that other users
+// can't write to.
+func (d *dir) checkOwned() error {
+	info, err := os.Lstat(d.path)
+	if err != nil {
+		return err
+	}
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !info.IsDir() || (ok && int(stat.Uid) != os.Getuid()) {
+		return fmt.Errorf("%s isn't a directory owned by the user", d.path)
+	}
+	if info.Mode().Perm()&0022 != 0 {
+		return fmt.Errorf("%s is writable by other users", d.path)
+	}
+	return nil
+}
+
+// mountPoints returns the mount points of the mounted volumes that can hold a
+// trash directory.
+func mountPoints() ([]string, error) {
+	file, err := os.Open("/proc/self/mountinfo")
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var points []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		// "id parent major:minor root mountpoint options [optional...] - fstype source superoptions"
+		fields := strings.Fields(scanner.Text())
+		sep := -1
+		for i, field := range fields {
+			if field == "-" {
+				sep = i
+				break
+			}
+		}
+		if len(fields) < 5 || sep < 0 || sep+1 >= len(fields) || virtualFilesystems[fields[sep+1]] {
+			continue
+		}
+		points = append(points, unescapeMount(fields[4]))
+	}
+	return points, scanner.Err()
+}
+
+// unescapeMount decodes the octal escapes of mountinfo paths, e.g. "\040" for a
+// space.
+func unescapeMount(path string) string {
+	if !strings.Contains(path, `\`) {
+		return path
+	}
+	var b strings.Builder
+	for i := 0; i < len(path); i++ {
+		if path[i] == '\\' && i+3 < len(path) {
+			if n, err := strconv.ParseUint(path[i+1:i+4], 8, 8); err == nil {
+				b.WriteByte(byte(n))
+				i += 3
+				continue
+			}
+		}
+		b.WriteByte(path[i])
+	}
+	return b.String()
+}
+
+// trashDirs returns the existing trash directories: the home trash, then those of
+// the mounted volumes.
+func trashDirs() []*dir {
+	home := homeDir()
+	dirs := []*dir{home}
+	points, _ := mountPoints()
+	homeInfo, homeErr := os.Stat(home.path)
+	seen := make(map[string]bool)
+	for _, top := range points {
+		if seen[top] {
+			continue
+		}
+		seen[top] = true
+		candidates := []*dir{{path: filepath.Join(top, ".Trash-"+strconv.Itoa(os.Getuid())), topdir: top}}
+		if d := sharedDir(top); d != nil {
+			candidates = append([]*dir{d}, candidates...)
+		}
+		for _, d := range candidates {
+			if d.checkOwned() != nil {
+				continue
+			}
+			info, err := os.Stat(d.path)
+			if err != nil || (homeErr == nil && os.SameFile(info, homeInfo)) {
+				continue
+			}
+			dirs = append(dirs, d)
+		}
+	}
+	return dirs
+}
+
+// move renames a file, or copies it then deletes it if it is moved to another
+// volume, as items copied to the home trash are when restored.
+func move(src, dst string) error {
+	err := os.Rename(src, dst)
+	if !errors.Is(err, syscall.EXDEV) {
+		return err
+	}
+	if err := copyTree(src, dst); err != nil {
+		os.RemoveAll(dst)
+		return err
+	}
+	return os.RemoveAll(src)
+}
+
+// copyTree copies a file, symbolic link or directory tree.
+func copyTree(src, dst string) error {
+	info, err := os.Lstat(src)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case info.Mode()&os.ModeSymlink != 0:
+		target, err := os.Readlink(src)
+		if err != nil {
+			return err
+		}
+		return os.Symlink(target, dst)
+	case info.IsDir():
+		if err := os.Mkdir(dst, info.Mode().Perm()|0700); err != nil {
+			return err
+		}
+		entries, err := os.ReadDir(src)
+		if err != nil {
+			return err
+		}
+		for _, entry := range entries {
+			if err := copyTree(filepath.Join(src, entry.Name()), filepath.Join(dst, entry.Name())); err != nil {
+				return err
+			}
+		}
+		return os.Chmod(dst, info.Mode().Perm())
+	case info.Mode().IsRegular():
+		in, err := os.Open(src)
+		if err != nil {
+			return err
+		}
+		defer in.Close()
+		out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_EXCL, info.Mode().Perm())
+		if err != nil {
+			return err
+		}
+		if _, err := io.Copy(out, in); err != nil {
+			out.Close()
+			return err
+		}
+		return out.Close()
+	default:
+		return fmt.Errorf("%s: cannot copy special file", src)
+	}
+}
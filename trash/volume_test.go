@@ -0,0 +1,73 @@
+/*
+	libxdg-go - An implementaion of various freedesktop specifications in go
+    Copyright (C) 2025 MiracleOS Contributors
+
+    This program is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    This program is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+*/
+
+package trash
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+)
+
+func TestTopdirTrashSharedDir(t *testing.T) {
+	top := t.TempDir()
+	shared := filepath.Join(top, ".Trash")
+	if err := os.Mkdir(shared, 0777); err != nil {
+		t.Fatal(err)
+	}
+	os.Chmod(shared, 0777|os.ModeSticky)
+
+	d, err := topdirTrash(top)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := filepath.Join(shared, strconv.Itoa(os.Getuid())); d.path != want {
+		t.Errorf("got trash %s, want %s", d.path, want)
+	}
+}
+
+func TestTopdirTrashRejectsForeignSharedDir(t *testing.T) {
+	top := t.TempDir()
+	shared := filepath.Join(top, ".Trash")
+	if err := os.Mkdir(shared, 0777); err != nil {
+		t.Fatal(err)
+	}
+	os.Chmod(shared, 0777|os.ModeSticky)
+	// Another user pre-created our directory, writable by everyone.
+	planted := filepath.Join(shared, strconv.Itoa(os.Getuid()))
+	if err := os.Mkdir(planted, 0777); err != nil {
+		t.Fatal(err)
+	}
+	os.Chmod(planted, 0777)
+	if os.Getuid() == 0 {
+		os.Chown(planted, 1, 1)
+	}
+
+	d, err := topdirTrash(top)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := filepath.Join(top, ".Trash-"+strconv.Itoa(os.Getuid())); d.path != want {
+		t.Errorf("got trash %s, want the fallback %s", d.path, want)
+	}
+	if _, err := os.Stat(filepath.Join(planted, filesDir)); err == nil {
+		t.Error("the planted directory was used")
+	}
+}
@@ -0,0 +1,175 @@
+/*
+	libxdg-go - An implementaion of various freedesktop specifications in go
+    Copyright (C) 2025 MiracleOS Contributors
+
+    This program is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    This program is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+*/
+
+package trash
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"syscall"
+	"time"
+)
+
+// rescanInterval is how often Watch looks for trash directories of newly mounted
+// volumes.
+const rescanInterval = 5 * time.Second
+
+// watchMask are the inotify events of the files and info directories that may
+// add or remove an item.
+const watchMask = syscall.IN_CREATE | syscall.IN_DELETE | syscall.IN_MOVED_FROM | syscall.IN_MOVED_TO |
+	syscall.IN_CLOSE_WRITE | syscall.IN_DELETE_SELF | syscall.IN_MOVE_SELF
+
+// EventType is the kind of change an Event reports.
+type EventType int
+
+const (
+	EventAdded EventType = iota
+	EventRemoved
+)
+
+// String returns the name of the event type.
+func (t EventType) String() string {
+	switch t {
+	case EventAdded:
+		return "added"
+	case EventRemoved:
+		return "removed"
+	}
+	return "unknown"
+}
+
+// Event is an item added to or removed from a trash directory.
+type Event struct {
+	Type EventType
+	// Item is the added item, or the removed item as it was.
+	Item Item
+	// Empty tells whether all trash directories are empty after the change.
+	Empty bool
+}
+
+// itemKey identifies an item across trash directories.
+type itemKey struct {
+	dir, name string
+}
+
+// Watch returns a channel of the items added to and removed from the home trash
+// and the trash directories of mounted volumes. It starts with an EventAdded for
+// every current item, so no change falls between listing and watching, and is
+// closed when ctx is done. The home trash is created if missing so it can be
+// watched.
+func Watch(ctx context.Context) (<-chan Event, error) {
+	fd, err := syscall.InotifyInit1(syscall.IN_CLOEXEC | syscall.IN_NONBLOCK)
+	if err != nil {
+		return nil, os.NewSyscallError("inotify_init1", err)
+	}
+	// A non-blocking descriptor is handled by the runtime poller, so reads can
+	// time out and be interrupted by Close.
+	file := os.NewFile(uintptr(fd), "inotify")
+	if err := homeDir().create(); err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	conn, err := file.SyscallConn()
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	ch := make(chan Event)
+	go func() {
+		defer close(ch)
+		defer file.Close()
+
+		stop := context.AfterFunc(ctx, func() { file.Close() })
+		defer stop()
+
+		known := make(map[itemKey]Item)
+		buf := make([]byte, 64*1024)
+		for {
+			// Control fails once the descriptor is closed, rather than using a
+			// reused number.
+			if err := conn.Control(func(fd uintptr) { addWatches(int(fd)) }); err != nil {
+				return
+			}
+			if !emitChanges(ctx, ch, known) {
+				return
+			}
+
+			file.SetReadDeadline(time.Now().Add(rescanInterval))
+			if _, err := file.Read(buf); err != nil && !errors.Is(err, os.ErrDeadlineExceeded) {
+				return
+			}
+		}
+	}()
+	return ch, nil
+}
+
+// addWatches watches the files and info directories of the existing trash
+// directories. Watching a directory twice is harmless.
+func addWatches(fd int) {
+	for _, d := range trashDirs() {
+		for _, sub := range []string{filesDir, infoDir} {
+			syscall.InotifyAddWatch(fd, filepath.Join(d.path, sub), watchMask)
+		}
+	}
+}
+
+// emitChanges lists the items, sends the events telling how they differ from
+// known and updates it. It returns false if ctx is done.
+func emitChanges(ctx context.Context, ch chan<- Event, known map[itemKey]Item) bool {
+	items, err := List()
+	if err != nil {
+		return true
+	}
+	current := make(map[itemKey]Item, len(items))
+	for _, item := range items {
+		current[itemKey{item.dir.path, item.Name}] = item
+	}
+
+	var events []Event
+	for key, item := range known {
+		if _, exists := current[key]; !exists {
+			events = append(events, Event{Type: EventRemoved, Item: item})
+		}
+	}
+	// Items are listed most recently deleted first; announce them oldest first.
+	for i := len(items) - 1; i >= 0; i-- {
+		if _, exists := known[itemKey{items[i].dir.path, items[i].Name}]; !exists {
+			events = append(events, Event{Type: EventAdded, Item: items[i]})
+		}
+	}
+
+	for i := range events {
+		if events[i].Type == EventRemoved {
+			delete(known, itemKey{events[i].Item.dir.path, events[i].Item.Name})
+		} else {
+			known[itemKey{events[i].Item.dir.path, events[i].Item.Name}] = events[i].Item
+		}
+		events[i].Empty = len(known) == 0
+		select {
+		case ch <- events[i]:
+		case <-ctx.Done():
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,205 @@
+/*
+	libxdg-go - An implementaion of various freedesktop specifications in go
+    Copyright (C) 2025 MiracleOS Contributors
+
+    This program is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    This program is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+*/
+
+package upower
+
+import (
+	"time"
+
+	"github.com/godbus/dbus/v5"
+)
+
+// DeviceType is the kind of a power device.
+type DeviceType uint32
+
+const (
+	DeviceUnknown DeviceType = iota
+	DeviceLinePower
+	DeviceBattery
+	DeviceUPS
+	DeviceMonitor
+	DeviceMouse
+	DeviceKeyboard
+	DevicePDA
+	DevicePhone
+	DeviceMediaPlayer
+	DeviceTablet
+	DeviceComputer
+	DeviceGamingInput
+	DevicePen
+	DeviceTouchpad
+	DeviceModem
+	DeviceNetwork
+	DeviceHeadset
+	DeviceSpeakers
+	DeviceHeadphones
+	DeviceVideo
+	DeviceOtherAudio
+	DeviceRemoteControl
+	DevicePrinter
+	DeviceScanner
+	DeviceCamera
+	DeviceWearable
+	DeviceToy
+	DeviceBluetoothGeneric
+)
+
+var deviceTypeNames = []string{
+	"unknown", "line-power", "battery", "ups", "monitor", "mouse", "keyboard", "pda",
+	"phone", "media-player", "tablet", "computer", "gaming-input", "pen", "touchpad",
+	"modem", "network", "headset", "speakers", "headphones", "video", "other-audio",
+	"remote-control", "printer", "scanner", "camera", "wearable", "toy",
+	"bluetooth-generic",
+}
+
+func (t DeviceType) String() string {
+	if int(t) < len(deviceTypeNames) {
+		return deviceTypeNames[t]
+	}
+	return "unknown"
+}
+
+// DeviceState is the charge state of a battery.
+type DeviceState uint32
+
+const (
+	StateUnknown DeviceState = iota
+	StateCharging
+	StateDischarging
+	StateEmpty
+	StateFullyCharged
+	StatePendingCharge
+	StatePendingDischarge
+)
+
+var deviceStateNames = []string{
+	"unknown", "charging", "discharging", "empty", "fully-charged",
+	"pending-charge", "pending-discharge",
+}
+
+func (s DeviceState) String() string {
+	if int(s) < len(deviceStateNames) {
+		return deviceStateNames[s]
+	}
+	return "unknown"
+}
+
+// WarningLevel is how urgently a device needs power.
+type WarningLevel uint32
+
+const (
+	WarningUnknown WarningLevel = iota
+	WarningNone
+	WarningDischarging
+	WarningLow
+	WarningCritical
+	WarningAction
+)
+
+// Device is the state of a power device. The display device is a composite of
+// the batteries, meant for the panel indicator.
+type Device struct {
+	Path           dbus.ObjectPath
+	NativePath     string
+	Vendor         string
+	Model          string
+	Serial         string
+	Type           DeviceType
+	PowerSupply    bool
+	Online         bool
+	IsPresent      bool
+	IsRechargeable bool
+	State          DeviceState
+	// Percentage is the charge level, from 0 to 100.
+	Percentage  float64
+	Energy      float64
+	EnergyEmpty float64
+	EnergyFull  float64
+	EnergyRate  float64
+	Voltage     float64
+	// TimeToEmpty and TimeToFull are zero when unknown.
+	TimeToEmpty  time.Duration
+	TimeToFull   time.Duration
+	Capacity     float64
+	Technology   uint32
+	Temperature  float64
+	WarningLevel WarningLevel
+	IconName     string
+	UpdateTime   time.Time
+}
+
+// IsBattery reports whether the device is a battery powering the computer,
+// rather than a peripheral.
+func (d Device) IsBattery() bool {
+	return d.Type == DeviceBattery && d.PowerSupply
+}
+
+// apply updates the device from UPower properties.
+func (d *Device) apply(props map[string]dbus.Variant) {
+	get := func(name string, value any) {
+		if v, found := props[name]; found {
+			v.Store(value)
+		}
+	}
+	var kind, state, warning uint32
+	var timeToEmpty, timeToFull int64
+	var updateTime uint64
+	get("NativePath", &d.NativePath)
+	get("Vendor", &d.Vendor)
+	get("Model", &d.Model)
+	get("Serial", &d.Serial)
+	get("PowerSupply", &d.PowerSupply)
+	get("Online", &d.Online)
+	get("IsPresent", &d.IsPresent)
+	get("IsRechargeable", &d.IsRechargeable)
+	get("Percentage", &d.Percentage)
+	get("Energy", &d.Energy)
+	get("EnergyEmpty", &d.EnergyEmpty)
+	get("EnergyFull", &d.EnergyFull)
+	get("EnergyRate", &d.EnergyRate)
+	get("Voltage", &d.Voltage)
+	get("Capacity", &d.Capacity)
+	get("Technology", &d.Technology)
+	get("Temperature", &d.Temperature)
+	get("IconName", &d.IconName)
+	if _, found := props["Type"]; found {
+		get("Type", &kind)
+		d.Type = DeviceType(kind)
+	}
+	if _, found := props["State"]; found {
+		get("State", &state)
+		d.State = DeviceState(state)
+	}
+	if _, found := props["WarningLevel"]; found {
+		get("WarningLevel", &warning)
+		d.WarningLevel = WarningLevel(warning)
+	}
+	if _, found := props["TimeToEmpty"]; found {
+		get("TimeToEmpty", &timeToEmpty)
+		d.TimeToEmpty = time.Duration(timeToEmpty) * time.Second
+	}
+	if _, found := props["TimeToFull"]; found {
+		get("TimeToFull", &timeToFull)
+		d.TimeToFull = time.Duration(timeToFull) * time.Second
+	}
+	if _, found := props["UpdateTime"]; found {
+		get("UpdateTime", &updateTime)
+		d.UpdateTime = time.Unix(int64(updateTime), 0)
+	}
+}
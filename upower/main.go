@@ -0,0 +1,302 @@
+/*
+	libxdg-go - An implementaion of various freedesktop specifications in go
+    Copyright (C) 2025 MiracleOS Contributors
+
+    This program is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    This program is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+*/
+
+// Package upower is a client of org.freedesktop.UPower, the power device
+// service: batteries, AC adapters and the charge of peripherals, for battery
+// indicators and power management.
+package upower
+
+import (
+	"log/slog"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/MiracleOS-Team/libxdg-go/internal/signals"
+	"github.com/godbus/dbus/v5"
+)
+
+const (
+	busName         = "org.freedesktop.UPower"
+	objectPath      = dbus.ObjectPath("/org/freedesktop/UPower")
+	interfaceName   = "org.freedesktop.UPower"
+	deviceInterface = "org.freedesktop.UPower.Device"
+	displayPath     = dbus.ObjectPath("/org/freedesktop/UPower/devices/DisplayDevice")
+)
+
+// Event reports a change to the power devices or to the daemon's state.
+// OnBatteryChanged events carry no device.
+type Event struct {
+	Device  Device
+	Added   bool
+	Updated bool
+	Removed bool
+	// Display is set for the display device.
+	Display          bool
+	OnBatteryChanged bool
+	OnBattery        bool
+}
+
+// Client tracks the power devices known to UPower.
+type Client struct {
+	conn        *dbus.Conn
+	own         bool
+	mu          sync.Mutex
+	devices     map[dbus.ObjectPath]*Device
+	order       []dbus.ObjectPath
+	display     Device
+	onBattery   bool
+	lidClosed   bool
+	subscribers signals.Subscribers[Event]
+	watch       *signals.Watch
+	closed      bool
+}
+
+// NewClient connects to the system bus and starts tracking the devices.
+func NewClient() (*Client, error) {
+	conn, err := dbus.ConnectSystemBus()
+	if err != nil {
+		return nil, err
+	}
+	c, err := newClient(conn, true)
+	if err != nil {
+		conn.Close()
+	}
+	return c, err
+}
+
+// NewClientWithConn tracks the devices using an existing system bus
+// connection, which is left open by Close.
+func NewClientWithConn(conn *dbus.Conn) (*Client, error) {
+	return newClient(conn, false)
+}
+
+func newClient(conn *dbus.Conn, own bool) (*Client, error) {
+	c := &Client{conn: conn, own: own, devices: make(map[dbus.ObjectPath]*Device)}
+	watch, err := signals.NewWatch(conn, 64, c.dispatch, c.matches()...)
+	if err != nil {
+		return nil, err
+	}
+	c.watch = watch
+
+	if err := c.load(); err != nil {
+		watch.Close()
+		return nil, err
+	}
+	return c, nil
+}
+
+func (c *Client) matches() [][]dbus.MatchOption {
+	return [][]dbus.MatchOption{
+		{dbus.WithMatchObjectPath(objectPath), dbus.WithMatchInterface(interfaceName)},
+		{
+			dbus.WithMatchPathNamespace(objectPath),
+			dbus.WithMatchInterface("org.freedesktop.DBus.Properties"),
+			dbus.WithMatchMember("PropertiesChanged"),
+		},
+	}
+}
+
+// load reads the daemon's state and devices.
+func (c *Client) load() error {
+	obj := c.conn.Object(busName, objectPath)
+	var props map[string]dbus.Variant
+	if err := obj.Call("org.freedesktop.DBus.Properties.GetAll", 0, interfaceName).Store(&props); err != nil {
+		return err
+	}
+	var paths []dbus.ObjectPath
+	if err := obj.Call(interfaceName+".EnumerateDevices", 0).Store(&paths); err != nil {
+		return err
+	}
+	display, err := c.fetch(displayPath)
+	if err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.applyDaemonLocked(props)
+	c.display = display
+	for _, path := range paths {
+		if d, err := c.fetch(path); err == nil {
+			c.devices[path] = &d
+			c.order = append(c.order, path)
+		}
+	}
+	return nil
+}
+
+// Close stops tracking the devices.
+func (c *Client) Close() error {
+	c.mu.Lock()
+	if c.closed {
+		c.mu.Unlock()
+		return nil
+	}
+	c.closed = true
+	c.mu.Unlock()
+
+	c.watch.Close()
+	c.subscribers.Close()
+	if c.own {
+		return c.conn.Close()
+	}
+	return nil
+}
+
+// Subscribe registers a consumer of the client's events. Events that don't fit
+// in the buffer are dropped. The channel is closed by cancel or by Close.
+func (c *Client) Subscribe(buffer int) (<-chan Event, func()) {
+	return c.subscribers.Subscribe(buffer)
+}
+
+// DisplayDevice returns the composite device battery indicators show: its
+// Percentage, State and times summarize all the batteries.
+func (c *Client) DisplayDevice() Device {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.display
+}
+
+// Devices returns the power devices, in the order UPower reported them.
+func (c *Client) Devices() []Device {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	devices := make([]Device, 0, len(c.order))
+	for _, path := range c.order {
+		devices = append(devices, *c.devices[path])
+	}
+	return devices
+}
+
+// OnBattery reports whether the computer runs on battery.
+func (c *Client) OnBattery() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.onBattery
+}
+
+// LidIsClosed reports whether the laptop lid is closed.
+func (c *Client) LidIsClosed() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.lidClosed
+}
+
+// applyDaemonLocked updates the daemon's state. c.mu must be held.
+func (c *Client) applyDaemonLocked(props map[string]dbus.Variant) {
+	if v, found := props["OnBattery"]; found {
+		v.Store(&c.onBattery)
+	}
+	if v, found := props["LidIsClosed"]; found {
+		v.Store(&c.lidClosed)
+	}
+}
+
+// fetch reads the properties of a device.
+func (c *Client) fetch(path dbus.ObjectPath) (Device, error) {
+	var props map[string]dbus.Variant
+	err := c.conn.Object(busName, path).Call("org.freedesktop.DBus.Properties.GetAll", 0, deviceInterface).Store(&props)
+	if err != nil {
+		return Device{}, err
+	}
+	d := Device{Path: path}
+	d.apply(props)
+	return d, nil
+}
+
+func (c *Client) dispatch(sig *dbus.Signal) {
+	switch sig.Name {
+	case interfaceName + ".DeviceAdded":
+		if len(sig.Body) == 1 {
+			if path, ok := sig.Body[0].(dbus.ObjectPath); ok {
+				go c.add(path)
+			}
+		}
+	case interfaceName + ".DeviceRemoved":
+		if len(sig.Body) == 1 {
+			if path, ok := sig.Body[0].(dbus.ObjectPath); ok {
+				c.remove(path)
+			}
+		}
+	case "org.freedesktop.DBus.Properties.PropertiesChanged":
+		var iface string
+		var changed map[string]dbus.Variant
+		var invalidated []string
+		if err := dbus.Store(sig.Body, &iface, &changed, &invalidated); err != nil {
+			return
+		}
+		c.propertiesChanged(sig.Path, iface, changed)
+	}
+}
+
+// add starts tracking a device.
+func (c *Client) add(path dbus.ObjectPath) {
+	d, err := c.fetch(path)
+	if err != nil {
+		slog.Debug("Failed to read power device", "device", path, "error", err)
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.closed || c.devices[path] != nil {
+		return
+	}
+	c.devices[path] = &d
+	c.order = append(c.order, path)
+	c.subscribers.Publish(Event{Device: d, Added: true})
+}
+
+// remove stops tracking a device.
+func (c *Client) remove(path dbus.ObjectPath) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	d, found := c.devices[path]
+	if !found {
+		return
+	}
+	delete(c.devices, path)
+	c.order = slices.DeleteFunc(c.order, func(p dbus.ObjectPath) bool { return p == path })
+	c.subscribers.Publish(Event{Device: *d, Removed: true})
+}
+
+func (c *Client) propertiesChanged(path dbus.ObjectPath, iface string, changed map[string]dbus.Variant) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	switch {
+	case path == objectPath && iface == interfaceName:
+		onBattery := c.onBattery
+		c.applyDaemonLocked(changed)
+		if c.onBattery != onBattery {
+			c.subscribers.Publish(Event{OnBatteryChanged: true, OnBattery: c.onBattery})
+		}
+	case path == displayPath && iface == deviceInterface:
+		c.display.apply(changed)
+		c.subscribers.Publish(Event{Device: c.display, Updated: true, Display: true, OnBattery: c.onBattery})
+	case strings.HasPrefix(string(path), string(objectPath)+"/devices/") && iface == deviceInterface:
+		if d, found := c.devices[path]; found {
+			d.apply(changed)
+			c.subscribers.Publish(Event{Device: *d, Updated: true, OnBattery: c.onBattery})
+		}
+	}
+}
@@ -0,0 +1,475 @@
+/*
+	libxdg-go - An implementaion of various freedesktop specifications in go
+    Copyright (C) 2025 MiracleOS Contributors
+
+    This program is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    This program is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+*/
+
+// Package wayland is a minimal Wayland client speaking the wire protocol directly,
+// without libwayland. Protocol packages build on it by describing their requests
+// and decoding their events by hand.
+package wayland
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"syscall"
+)
+
+const (
+	displayID = 1
+
+	// wl_display requests and events.
+	displaySync        = 0
+	displayGetRegistry = 1
+	displayError       = 0
+	displayDeleteID    = 1
+
+	// wl_registry requests and events.
+	registryBind         = 0
+	registryGlobal       = 0
+	registryGlobalRemove = 1
+
+	// wl_callback events.
+	callbackDone = 0
+
+	headerSize = 8
+	// maxFDs is the most file descriptors a single read accepts.
+	maxFDs = 28
+)
+
+// ErrClosed is returned by requests on a closed connection.
+var ErrClosed = errors.New("wayland connection closed")
+
+// ProtocolError is a fatal error sent by the compositor.
+type ProtocolError struct {
+	ObjectID  uint32
+	Interface string
+	Code      uint32
+	Message   string
+}
+
+func (e *ProtocolError) Error() string {
+	return fmt.Sprintf("wayland protocol error on %s@%d (code %d): %s", e.Interface, e.ObjectID, e.Code, e.Message)
+}
+
+// Global is an object advertised by the compositor through wl_registry.
+type Global struct {
+	Name      uint32
+	Interface string
+	Version   uint32
+}
+
+// Handler receives the events of an object. It runs on the connection's reading
+// goroutine and must decode the arguments in order.
+type Handler func(e *Event)
+
+// Conn is a connection to a Wayland compositor. Events are read and dispatched to
+// object handlers on a background goroutine.
+type Conn struct {
+	sock *net.UnixConn
+
+	mu      sync.Mutex
+	writeMu sync.Mutex
+	objects map[uint32]*Object
+	nextID  uint32
+	freeIDs []uint32
+	globals map[uint32]Global
+	err     error
+
+	onGlobal       func(g Global)
+	onGlobalRemove func(g Global)
+
+	registry *Object
+	fds      []int
+	done     chan struct{}
+}
+
+// Connect connects to the compositor named by $WAYLAND_SOCKET or $WAYLAND_DISPLAY
+// and retrieves the globals.
+func Connect() (*Conn, error) {
+	if fdString := os.Getenv("WAYLAND_SOCKET"); fdString != "" {
+		fd, err := strconv.Atoi(fdString)
+		if err != nil {
+			return nil, fmt.Errorf("invalid WAYLAND_SOCKET: %w", err)
+		}
+		os.Unsetenv("WAYLAND_SOCKET")
+		file := os.NewFile(uintptr(fd), "wayland")
+		defer file.Close()
+		conn, err := net.FileConn(file)
+		if err != nil {
+			return nil, err
+		}
+		sock, ok := conn.(*net.UnixConn)
+		if !ok {
+			conn.Close()
+			return nil, errors.New("WAYLAND_SOCKET is not a unix socket")
+		}
+		return newConn(sock)
+	}
+
+	display := os.Getenv("WAYLAND_DISPLAY")
+	if display == "" {
+		display = "wayland-0"
+	}
+	if !filepath.IsAbs(display) {
+		runtime := os.Getenv("XDG_RUNTIME_DIR")
+		if runtime == "" {
+			return nil, errors.New("XDG_RUNTIME_DIR is not set")
+		}
+		display = filepath.Join(runtime, display)
+	}
+	return Dial(display)
+}
+
+// Dial connects to the compositor socket at path and retrieves the globals.
+func Dial(path string) (*Conn, error) {
+	sock, err := net.DialUnix("unix", nil, &net.UnixAddr{Name: path, Net: "unix"})
+	if err != nil {
+		return nil, err
+	}
+	return newConn(sock)
+}
+
+func newConn(sock *net.UnixConn) (*Conn, error) {
+	c := &Conn{
+		sock:    sock,
+		objects: make(map[uint32]*Object),
+		nextID:  displayID + 1,
+		globals: make(map[uint32]Global),
+		done:    make(chan struct{}),
+	}
+	display := &Object{conn: c, id: displayID, iface: "wl_display"}
+	display.handler = c.handleDisplay
+	c.objects[displayID] = display
+	go c.readLoop()
+
+	c.registry = c.NewObject("wl_registry", c.handleRegistry)
+	if err := display.Request(displayGetRegistry, c.registry); err != nil {
+		c.Close()
+		return nil, err
+	}
+	if err := c.Roundtrip(); err != nil {
+		c.Close()
+		return nil, err
+	}
+	return c, nil
+}
+
+// Close closes the connection.
+func (c *Conn) Close() error {
+	c.fail(ErrClosed)
+	return nil
+}
+
+// Done is closed once the connection is closed or failed.
+func (c *Conn) Done() <-chan struct{} {
+	return c.done
+}
+
+// Err returns the error the connection failed with, ErrClosed after Close.
+func (c *Conn) Err() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.err
+}
+
+// fail closes the connection with err, keeping the first error.
+func (c *Conn) fail(err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.err != nil {
+		return
+	}
+	c.err = err
+	c.sock.Close()
+	for _, fd := range c.fds {
+		syscall.Close(fd)
+	}
+	c.fds = nil
+	close(c.done)
+}
+
+// Globals returns the globals currently advertised by the compositor.
+func (c *Conn) Globals() []Global {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	globals := make([]Global, 0, len(c.globals))
+	for _, g := range c.globals {
+		globals = append(globals, g)
+	}
+	return globals
+}
+
+// FindGlobal returns the first advertised global implementing iface.
+func (c *Conn) FindGlobal(iface string) (Global, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var found Global
+	for _, g := range c.globals {
+		if g.Interface == iface && (found.Name == 0 || g.Name < found.Name) {
+			found = g
+		}
+	}
+	return found, found.Name != 0
+}
+
+// OnGlobal sets callbacks for globals advertised or removed after Connect returned,
+// such as outputs being plugged in. They run on the reading goroutine.
+func (c *Conn) OnGlobal(added, removed func(g Global)) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.onGlobal = added
+	c.onGlobalRemove = removed
+}
+
+// Bind binds a global at version, capped to the version the compositor advertises.
+func (c *Conn) Bind(g Global, version uint32, handler Handler) (*Object, error) {
+	version = min(version, g.Version)
+	obj := c.NewObject(g.Interface, handler)
+	obj.version = version
+	if err := c.registry.Request(registryBind, g.Name, g.Interface, version, obj); err != nil {
+		return nil, err
+	}
+	return obj, nil
+}
+
+// NewObject allocates a client object, to be passed as the new_id argument of a request.
+func (c *Conn) NewObject(iface string, handler Handler) *Object {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var id uint32
+	if n := len(c.freeIDs); n > 0 {
+		id = c.freeIDs[n-1]
+		c.freeIDs = c.freeIDs[:n-1]
+	} else {
+		id = c.nextID
+		c.nextID++
+	}
+	obj := &Object{conn: c, id: id, iface: iface, handler: handler}
+	c.objects[id] = obj
+	return obj
+}
+
+// RegisterObject records an object created by the compositor through a new_id
+// event argument, so its events reach handler.
+func (c *Conn) RegisterObject(id uint32, iface string, version uint32, handler Handler) *Object {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	obj := &Object{conn: c, id: id, iface: iface, version: version, handler: handler}
+	c.objects[id] = obj
+	return obj
+}
+
+// Object returns the live object with the given ID, or nil.
+func (c *Conn) Object(id uint32) *Object {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.objects[id]
+}
+
+// Roundtrip waits until the compositor processed every request sent so far, and
+// the events they caused were dispatched.
+func (c *Conn) Roundtrip() error {
+	done := make(chan struct{})
+	callback := c.NewObject("wl_callback", func(e *Event) {
+		if e.Opcode == callbackDone {
+			close(done)
+		}
+	})
+	if err := c.Object(displayID).Request(displaySync, callback); err != nil {
+		return err
+	}
+	select {
+	case <-done:
+		return nil
+	case <-c.done:
+		return c.Err()
+	}
+}
+
+func (c *Conn) handleDisplay(e *Event) {
+	switch e.Opcode {
+	case displayError:
+		id := e.Uint()
+		code := e.Uint()
+		message := e.String()
+		iface := ""
+		if obj := c.Object(id); obj != nil {
+			iface = obj.iface
+		}
+		c.fail(&ProtocolError{ObjectID: id, Interface: iface, Code: code, Message: message})
+	case displayDeleteID:
+		id := e.Uint()
+		c.mu.Lock()
+		delete(c.objects, id)
+		if id < 0xff000000 {
+			c.freeIDs = append(c.freeIDs, id)
+		}
+		c.mu.Unlock()
+	}
+}
+
+func (c *Conn) handleRegistry(e *Event) {
+	switch e.Opcode {
+	case registryGlobal:
+		g := Global{Name: e.Uint(), Interface: e.String(), Version: e.Uint()}
+		c.mu.Lock()
+		c.globals[g.Name] = g
+		added := c.onGlobal
+		c.mu.Unlock()
+		if added != nil {
+			added(g)
+		}
+	case registryGlobalRemove:
+		name := e.Uint()
+		c.mu.Lock()
+		g, exists := c.globals[name]
+		delete(c.globals, name)
+		removed := c.onGlobalRemove
+		c.mu.Unlock()
+		if exists && removed != nil {
+			removed(g)
+		}
+	}
+}
+
+// send writes a message, passing fds along with it.
+func (c *Conn) send(msg []byte, fds []int) error {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+
+	if err := c.Err(); err != nil {
+		return err
+	}
+	var oob []byte
+	if len(fds) > 0 {
+		oob = syscall.UnixRights(fds...)
+	}
+	_, _, err := c.sock.WriteMsgUnix(msg, oob, nil)
+	if err != nil {
+		c.fail(err)
+	}
+	return err
+}
+
+// readLoop reads and dispatches events until the connection fails.
+func (c *Conn) readLoop() {
+	buf := make([]byte, 0, 4096)
+	chunk := make([]byte, 4096)
+	oob := make([]byte, syscall.CmsgSpace(maxFDs*4))
+
+	for {
+		n, oobn, _, _, err := c.sock.ReadMsgUnix(chunk, oob)
+		if err != nil {
+			c.fail(err)
+			return
+		}
+		if n == 0 {
+			c.fail(errors.New("compositor closed the connection"))
+			return
+		}
+		if oobn > 0 {
+			c.queueFDs(oob[:oobn])
+		}
+		buf = append(buf, chunk[:n]...)
+
+		for len(buf) >= headerSize {
+			id := binary.NativeEndian.Uint32(buf[0:4])
+			word := binary.NativeEndian.Uint32(buf[4:8])
+			size := int(word >> 16)
+			if size < headerSize {
+				c.fail(errors.New("malformed wayland message"))
+				return
+			}
+			if len(buf) < size {
+				break
+			}
+			c.dispatch(id, uint16(word&0xffff), buf[headerSize:size])
+			buf = buf[size:]
+		}
+		buf = append(chunk[:0:0], buf...)
+	}
+}
+
+func (c *Conn) queueFDs(oob []byte) {
+	messages, err := syscall.ParseSocketControlMessage(oob)
+	if err != nil {
+		return
+	}
+	for _, m := range messages {
+		fds, err := syscall.ParseUnixRights(&m)
+		if err != nil {
+			continue
+		}
+		c.mu.Lock()
+		c.fds = append(c.fds, fds...)
+		c.mu.Unlock()
+	}
+}
+
+// popFD takes the next file descriptor received from the compositor.
+func (c *Conn) popFD() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if len(c.fds) == 0 {
+		return -1
+	}
+	fd := c.fds[0]
+	c.fds = c.fds[1:]
+	return fd
+}
+
+// discardFDs closes the next n file descriptors received from the compositor.
+func (c *Conn) discardFDs(n int) {
+	for ; n > 0; n-- {
+		if fd := c.popFD(); fd >= 0 {
+			syscall.Close(fd)
+		}
+	}
+}
+
+func (c *Conn) dispatch(id uint32, opcode uint16, data []byte) {
+	var handler Handler
+	var iface string
+	c.mu.Lock()
+	if obj := c.objects[id]; obj != nil {
+		handler, iface = obj.handler, obj.iface
+	}
+	c.mu.Unlock()
+
+	e := &Event{Opcode: opcode, conn: c, data: data, fds: eventFDs(iface, opcode)}
+	if handler != nil {
+		handler(e)
+	}
+	// Descriptors the handler didn't take, or carried by an event nobody
+	// handles, would otherwise be taken by a later event.
+	c.discardFDs(e.fds)
+}
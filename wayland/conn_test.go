@@ -0,0 +1,141 @@
+/*
+	libxdg-go - An implementaion of various freedesktop specifications in go
+    Copyright (C) 2025 MiracleOS Contributors
+
+    This program is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    This program is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+*/
+
+package wayland
+
+import (
+	"encoding/binary"
+	"net"
+	"os"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// testPair returns a connection whose compositor side is driven by the test.
+func testPair(t *testing.T) (*Conn, *net.UnixConn) {
+	t.Helper()
+	fds, err := syscall.Socketpair(syscall.AF_UNIX, syscall.SOCK_STREAM, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	unixConn := func(fd int) *net.UnixConn {
+		file := os.NewFile(uintptr(fd), "wayland-test")
+		defer file.Close()
+		conn, err := net.FileConn(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return conn.(*net.UnixConn)
+	}
+	client, server := unixConn(fds[0]), unixConn(fds[1])
+	t.Cleanup(func() { server.Close() })
+
+	result := make(chan *Conn)
+	go func() {
+		c, err := newConn(client)
+		if err != nil {
+			t.Error(err)
+		}
+		result <- c
+	}()
+
+	// Answer the wl_display.sync of the initial roundtrip, after get_registry.
+	buf := make([]byte, 12)
+	for i := 0; i < 2; i++ {
+		if _, err := server.Read(buf); err != nil {
+			t.Fatal(err)
+		}
+	}
+	sendEvent(t, server, binary.NativeEndian.Uint32(buf[8:]), callbackDone, nil, 0)
+	c := <-result
+	t.Cleanup(func() { c.Close() })
+	return c, server
+}
+
+// sendEvent sends an event with a uint argument, and a file descriptor if fd >= 0.
+func sendEvent(t *testing.T, server *net.UnixConn, id uint32, opcode uint16, fd *os.File, arg uint32) {
+	t.Helper()
+	msg := make([]byte, 12)
+	binary.NativeEndian.PutUint32(msg, id)
+	binary.NativeEndian.PutUint32(msg[4:], uint32(len(msg))<<16|uint32(opcode))
+	binary.NativeEndian.PutUint32(msg[8:], arg)
+	var oob []byte
+	if fd != nil {
+		oob = syscall.UnixRights(int(fd.Fd()))
+	}
+	if _, _, err := server.WriteMsgUnix(msg, oob, nil); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func inode(t *testing.T, fd int) uint64 {
+	t.Helper()
+	var st syscall.Stat_t
+	if err := syscall.Fstat(fd, &st); err != nil {
+		t.Fatal(err)
+	}
+	return st.Ino
+}
+
+func TestDroppedEventFDs(t *testing.T) {
+	c, server := testPair(t)
+
+	got := make(chan int, 1)
+	keyboard := c.NewObject("wl_keyboard", nil)
+	keyboard.SetHandler(func(e *Event) {
+		e.Uint()
+		got <- int(e.FD())
+	})
+	forgotten := c.NewObject("wl_keyboard", func(e *Event) {})
+	forgotten.Forget()
+	ignoring := c.NewObject("wl_keyboard", func(e *Event) {})
+
+	first, err := os.CreateTemp(t.TempDir(), "first")
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := os.CreateTemp(t.TempDir(), "second")
+	if err != nil {
+		t.Fatal(err)
+	}
+	third, err := os.CreateTemp(t.TempDir(), "third")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// keymap events for a forgotten object and for a handler not taking the fd
+	// must not shift their descriptors onto the next keymap event.
+	sendEvent(t, server, forgotten.ID(), 0, first, 1)
+	sendEvent(t, server, ignoring.ID(), 0, second, 1)
+	sendEvent(t, server, keyboard.ID(), 0, third, 1)
+
+	select {
+	case fd := <-got:
+		if fd < 0 {
+			t.Fatal("keymap event received no descriptor")
+		}
+		defer syscall.Close(fd)
+		if inode(t, fd) != inode(t, int(third.Fd())) {
+			t.Error("keymap event received the descriptor of an earlier event")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("keymap event not dispatched")
+	}
+}
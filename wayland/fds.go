@@ -0,0 +1,60 @@
+/*
+	libxdg-go - An implementaion of various freedesktop specifications in go
+    Copyright (C) 2025 MiracleOS Contributors
+
+    This program is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    This program is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+*/
+
+package wayland
+
+import "sync"
+
+var (
+	fdEventsMu sync.RWMutex
+	// fdEvents holds the events carrying file descriptors, by interface and
+	// opcode, with the number of descriptors they carry. The descriptors of such
+	// events are closed when nobody takes them, so that they aren't handed to a
+	// later event.
+	fdEvents = map[string]map[uint16]int{
+		"wl_keyboard":                     {0: 1}, // keymap
+		"wl_data_source":                  {1: 1}, // send
+		"zwp_primary_selection_source_v1": {0: 1}, // send
+		"zwlr_data_control_source_v1":     {0: 1}, // send
+		"ext_data_control_source_v1":      {0: 1}, // send
+		"zwp_linux_dmabuf_feedback_v1":    {1: 1}, // format_table
+		"zwlr_export_dmabuf_frame_v1":     {1: 1}, // object
+	}
+)
+
+// RegisterFDEvent declares that an event of an interface carries fds file
+// descriptors, for protocols whose fd events the package doesn't know. Events of
+// objects the client doesn't know the interface of can't be accounted for.
+func RegisterFDEvent(iface string, opcode uint16, fds int) {
+	fdEventsMu.Lock()
+	defer fdEventsMu.Unlock()
+
+	if fdEvents[iface] == nil {
+		fdEvents[iface] = make(map[uint16]int)
+	}
+	fdEvents[iface][opcode] = fds
+}
+
+// eventFDs returns the number of file descriptors an event carries.
+func eventFDs(iface string, opcode uint16) int {
+	fdEventsMu.RLock()
+	defer fdEventsMu.RUnlock()
+
+	return fdEvents[iface][opcode]
+}
@@ -0,0 +1,223 @@
+/*
+	libxdg-go - An implementaion of various freedesktop specifications in go
+    Copyright (C) 2025 MiracleOS Contributors
+
+    This program is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    This program is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+*/
+
+package wayland
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+// Fixed is a wl_fixed_t, a signed 24.8 fixed-point number.
+type Fixed int32
+
+// Float returns the value of f.
+func (f Fixed) Float() float64 {
+	return float64(f) / 256
+}
+
+// FixedFrom converts v to a Fixed.
+func FixedFrom(v float64) Fixed {
+	return Fixed(v * 256)
+}
+
+// FD is a file descriptor argument.
+type FD int
+
+// Object is a protocol object on a connection.
+type Object struct {
+	conn    *Conn
+	id      uint32
+	iface   string
+	version uint32
+	handler Handler
+}
+
+// ID returns the object ID.
+func (o *Object) ID() uint32 {
+	return o.id
+}
+
+// Interface returns the interface name of the object.
+func (o *Object) Interface() string {
+	return o.iface
+}
+
+// Version returns the version the object was bound or created with.
+func (o *Object) Version() uint32 {
+	return o.version
+}
+
+// Conn returns the connection the object belongs to.
+func (o *Object) Conn() *Conn {
+	return o.conn
+}
+
+// SetHandler replaces the event handler of the object.
+func (o *Object) SetHandler(handler Handler) {
+	o.conn.mu.Lock()
+	defer o.conn.mu.Unlock()
+
+	o.handler = handler
+}
+
+// Request sends a request. Arguments are encoded by type: uint32, int32, Fixed,
+// string, []byte (array), FD, and *Object for object and new_id arguments (a nil
+// *Object is a null object). Objects created by a request inherit its version.
+func (o *Object) Request(opcode uint16, args ...interface{}) error {
+	msg := make([]byte, headerSize, 64)
+	var fds []int
+
+	for _, arg := range args {
+		switch v := arg.(type) {
+		case uint32:
+			msg = binary.NativeEndian.AppendUint32(msg, v)
+		case int32:
+			msg = binary.NativeEndian.AppendUint32(msg, uint32(v))
+		case Fixed:
+			msg = binary.NativeEndian.AppendUint32(msg, uint32(v))
+		case string:
+			msg = binary.NativeEndian.AppendUint32(msg, uint32(len(v)+1))
+			msg = append(msg, v...)
+			msg = append(msg, 0)
+			msg = pad(msg)
+		case []byte:
+			msg = binary.NativeEndian.AppendUint32(msg, uint32(len(v)))
+			msg = append(msg, v...)
+			msg = pad(msg)
+		case FD:
+			fds = append(fds, int(v))
+		case *Object:
+			var id uint32
+			if v != nil {
+				id = v.id
+				if v.version == 0 {
+					v.version = o.version
+				}
+			}
+			msg = binary.NativeEndian.AppendUint32(msg, id)
+		default:
+			return fmt.Errorf("unsupported wayland argument type %T", arg)
+		}
+	}
+
+	binary.NativeEndian.PutUint32(msg[0:4], o.id)
+	binary.NativeEndian.PutUint32(msg[4:8], uint32(len(msg))<<16|uint32(opcode))
+	return o.conn.send(msg, fds)
+}
+
+// Forget drops the object locally, after a destructor request was sent or the
+// compositor destroyed it. Its further events are ignored.
+func (o *Object) Forget() {
+	o.conn.mu.Lock()
+	defer o.conn.mu.Unlock()
+
+	if o.conn.objects[o.id] == o {
+		o.conn.objects[o.id] = &Object{conn: o.conn, id: o.id, iface: o.iface}
+	}
+}
+
+func pad(b []byte) []byte {
+	for len(b)%4 != 0 {
+		b = append(b, 0)
+	}
+	return b
+}
+
+// Event is an event received for an object. Its arguments are decoded in order
+// with the typed accessors; decoding past the end yields zero values.
+type Event struct {
+	Opcode uint16
+	conn   *Conn
+	data   []byte
+	// fds is the number of file descriptors the event carries that weren't
+	// taken yet.
+	fds int
+}
+
+func (e *Event) word() uint32 {
+	if len(e.data) < 4 {
+		e.data = nil
+		return 0
+	}
+	v := binary.NativeEndian.Uint32(e.data)
+	e.data = e.data[4:]
+	return v
+}
+
+// Uint decodes a uint argument.
+func (e *Event) Uint() uint32 {
+	return e.word()
+}
+
+// Int decodes an int argument.
+func (e *Event) Int() int32 {
+	return int32(e.word())
+}
+
+// Fixed decodes a fixed argument.
+func (e *Event) Fixed() Fixed {
+	return Fixed(e.word())
+}
+
+// ObjectID decodes an object or new_id argument. 0 is the null object.
+func (e *Event) ObjectID() uint32 {
+	return e.word()
+}
+
+// String decodes a string argument.
+func (e *Event) String() string {
+	b := e.Array()
+	if n := len(b); n > 0 && b[n-1] == 0 {
+		b = b[:n-1]
+	}
+	return string(b)
+}
+
+// Array decodes an array argument.
+func (e *Event) Array() []byte {
+	size := int(e.word())
+	padded := (size + 3) &^ 3
+	if size > len(e.data) || padded > len(e.data) {
+		e.data = nil
+		return nil
+	}
+	b := append([]byte(nil), e.data[:size]...)
+	e.data = e.data[padded:]
+	return b
+}
+
+// Uints decodes an array argument holding uint values, such as a state array.
+func (e *Event) Uints() []uint32 {
+	b := e.Array()
+	values := make([]uint32, 0, len(b)/4)
+	for i := 0; i+4 <= len(b); i += 4 {
+		values = append(values, binary.NativeEndian.Uint32(b[i:]))
+	}
+	return values
+}
+
+// FD takes an fd argument. The caller owns the returned descriptor; -1 means none
+// was received.
+func (e *Event) FD() FD {
+	if e.fds > 0 {
+		e.fds--
+	}
+	return FD(e.conn.popFD())
+}
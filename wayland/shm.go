@@ -0,0 +1,67 @@
+/*
+	libxdg-go - An implementaion of various freedesktop specifications in go
+    Copyright (C) 2025 MiracleOS Contributors
+
+    This program is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    This program is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+*/
+
+package wayland
+
+import (
+	"os"
+	"syscall"
+)
+
+// ShmFile is shared memory to back wl_shm buffers with.
+type ShmFile struct {
+	File *os.File
+	Data []byte
+}
+
+// NewShmFile creates an unlinked file of size bytes in XDG_RUNTIME_DIR and maps
+// it. Its descriptor can be passed to wl_shm.create_pool as an FD.
+func NewShmFile(size int) (*ShmFile, error) {
+	dir := os.Getenv("XDG_RUNTIME_DIR")
+	if dir == "" {
+		dir = os.TempDir()
+	}
+	file, err := os.CreateTemp(dir, "libxdg-shm-*")
+	if err != nil {
+		return nil, err
+	}
+	os.Remove(file.Name())
+
+	if err := file.Truncate(int64(size)); err != nil {
+		file.Close()
+		return nil, err
+	}
+	data, err := syscall.Mmap(int(file.Fd()), 0, size, syscall.PROT_READ|syscall.PROT_WRITE, syscall.MAP_SHARED)
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	return &ShmFile{File: file, Data: data}, nil
+}
+
+// FD returns the descriptor of the file.
+func (s *ShmFile) FD() FD {
+	return FD(s.File.Fd())
+}
+
+// Close unmaps and closes the file.
+func (s *ShmFile) Close() error {
+	syscall.Munmap(s.Data)
+	return s.File.Close()
+}
@@ -0,0 +1,91 @@
+/*
+	libxdg-go - An implementaion of various freedesktop specifications in go
+    Copyright (C) 2025 MiracleOS Contributors
+
+    This program is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    This program is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+*/
+
+package x11
+
+import (
+	"encoding/binary"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+const (
+	familyLocal = 256
+	familyWild  = 65535
+
+	authCookie = "MIT-MAGIC-COOKIE-1"
+)
+
+// findAuth returns the MIT-MAGIC-COOKIE-1 of a display from the Xauthority file,
+// or nothing if there is none and the connection should be attempted without
+// authorization. Only local entries are considered.
+func findAuth(local bool, number string) (string, []byte) {
+	if !local {
+		return "", nil
+	}
+	path := os.Getenv("XAUTHORITY")
+	if path == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", nil
+		}
+		path = filepath.Join(home, ".Xauthority")
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", nil
+	}
+	hostname, _ := os.Hostname()
+
+	for len(data) > 0 {
+		if len(data) < 2 {
+			return "", nil
+		}
+		family := binary.BigEndian.Uint16(data)
+		data = data[2:]
+		var fields [4][]byte
+		for i := range fields {
+			fields[i], data, err = authField(data)
+			if err != nil {
+				return "", nil
+			}
+		}
+		address, display, name, cookie := string(fields[0]), string(fields[1]), string(fields[2]), fields[3]
+		if family != familyWild && (family != familyLocal || address != hostname) {
+			continue
+		}
+		if (display == "" || display == number) && name == authCookie {
+			return name, cookie
+		}
+	}
+	return "", nil
+}
+
+// authField reads a length-prefixed field of an Xauthority entry.
+func authField(data []byte) ([]byte, []byte, error) {
+	if len(data) < 2 {
+		return nil, nil, io.ErrUnexpectedEOF
+	}
+	n := int(binary.BigEndian.Uint16(data))
+	if len(data) < 2+n {
+		return nil, nil, io.ErrUnexpectedEOF
+	}
+	return data[2 : 2+n], data[2+n:], nil
+}
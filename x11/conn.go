@@ -0,0 +1,427 @@
+/*
+	libxdg-go - An implementaion of various freedesktop specifications in go
+    Copyright (C) 2025 MiracleOS Contributors
+
+    This program is free software: you can redistribute it and/or modify
+    it under the terms of the GNU General Public License as published by
+    the Free Software Foundation, either version 3 of the License, or
+    (at your option) any later version.
+
+    This program is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU General Public License for more details.
+
+    You should have received a copy of the GNU General Public License
+    along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+*/
+
+// Package x11 is a minimal X11 client speaking the core protocol directly,
+// without Xlib or XCB. It only implements the requests the rest of the module
+// needs, and reads replies synchronously on the calling goroutine.
+package x11
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// Request opcodes.
+	opCreateWindow   = 1
+	opDestroyWindow  = 4
+	opInternAtom     = 16
+	opChangeProperty = 18
+	opSendEvent      = 25
+	opGetInputFocus  = 43
+
+	// Event codes.
+	eventPropertyNotify = 28
+	eventClientMessage  = 33
+
+	windowClassInputOnly = 2
+	cwOverrideRedirect   = 0x200
+	cwEventMask          = 0x800
+
+	propModeAppend = 2
+	atomString     = 31
+
+	// replyTimeout bounds how long a request waits for its reply.
+	replyTimeout = 5 * time.Second
+)
+
+// PropertyChangeMask selects PropertyNotify events, and is the event mask client
+// messages to the root window are usually sent with.
+const PropertyChangeMask = 0x400000
+
+// ErrClosed is returned by requests on a closed connection.
+var ErrClosed = errors.New("x11 connection closed")
+
+// ProtocolError is an error sent by the X server in response to a request.
+type ProtocolError struct {
+	Code     uint8
+	Major    uint8
+	Minor    uint16
+	BadValue uint32
+}
+
+func (e *ProtocolError) Error() string {
+	return fmt.Sprintf("x11 error %d for request %d.%d (value %#x)", e.Code, e.Major, e.Minor, e.BadValue)
+}
+
+// Conn is a connection to an X server.
+type Conn struct {
+	sock net.Conn
+
+	mu     sync.Mutex
+	seq    uint16
+	idBase uint32
+	idMask uint32
+	nextID uint32
+	root   uint32
+	screen int
+	closed bool
+}
+
+// Connect connects to the X server named by $DISPLAY.
+func Connect() (*Conn, error) {
+	display := os.Getenv("DISPLAY")
+	if display == "" {
+		return nil, errors.New("DISPLAY is not set")
+	}
+	return Dial(display)
+}
+
+// Dial connects to the X server of a display name such as ":0", ":1.0" or
+// "host:0".
+func Dial(display string) (*Conn, error) {
+	host, number, screen, err := parseDisplay(display)
+	if err != nil {
+		return nil, err
+	}
+
+	var sock net.Conn
+	local := host == "" || host == "unix" || strings.HasSuffix(host, "/unix")
+	if local {
+		path := "/tmp/.X11-unix/X" + number
+		// Prefer the abstract socket, which works across mount namespaces.
+		sock, err = net.Dial("unix", "@"+path)
+		if err != nil {
+			sock, err = net.Dial("unix", path)
+		}
+	} else {
+		n, _ := strconv.Atoi(number)
+		sock, err = net.Dial("tcp", net.JoinHostPort(host, strconv.Itoa(6000+n)))
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	c := &Conn{sock: sock, screen: screen}
+	authName, authData := findAuth(local || host == "localhost", number)
+	if err := c.setup(authName, authData); err != nil {
+		sock.Close()
+		return nil, err
+	}
+	return c, nil
+}
+
+// parseDisplay splits a display name into its host, display number and screen.
+func parseDisplay(display string) (host, number string, screen int, err error) {
+	colon := strings.LastIndex(display, ":")
+	if colon < 0 {
+		return "", "", 0, fmt.Errorf("invalid display %q", display)
+	}
+	host, number = display[:colon], display[colon+1:]
+	if dot := strings.Index(number, "."); dot >= 0 {
+		screen, err = strconv.Atoi(number[dot+1:])
+		if err != nil {
+			return "", "", 0, fmt.Errorf("invalid display %q", display)
+		}
+		number = number[:dot]
+	}
+	if _, err := strconv.Atoi(number); err != nil {
+		return "", "", 0, fmt.Errorf("invalid display %q", display)
+	}
+	return host, number, screen, nil
+}
+
+// setup performs the connection handshake and finds the root window of the
+// screen.
+func (c *Conn) setup(authName string, authData []byte) error {
+	req := make([]byte, 12)
+	req[0] = 'l'
+	binary.LittleEndian.PutUint16(req[2:], 11)
+	binary.LittleEndian.PutUint16(req[6:], uint16(len(authName)))
+	binary.LittleEndian.PutUint16(req[8:], uint16(len(authData)))
+	req = append(req, pad([]byte(authName))...)
+	req = append(req, pad(authData)...)
+	if _, err := c.sock.Write(req); err != nil {
+		return err
+	}
+
+	c.sock.SetReadDeadline(time.Now().Add(replyTimeout))
+	defer c.sock.SetReadDeadline(time.Time{})
+	header := make([]byte, 8)
+	if _, err := io.ReadFull(c.sock, header); err != nil {
+		return err
+	}
+	data := make([]byte, int(binary.LittleEndian.Uint16(header[6:]))*4)
+	if _, err := io.ReadFull(c.sock, data); err != nil {
+		return err
+	}
+	switch header[0] {
+	case 0:
+		reason := data[:min(int(header[1]), len(data))]
+		return fmt.Errorf("x11 connection refused: %s", reason)
+	case 1:
+	default:
+		return errors.New("x11 connection refused: further authentication required")
+	}
+
+	if len(data) < 32 {
+		return errors.New("x11 setup reply too short")
+	}
+	c.idBase = binary.LittleEndian.Uint32(data[4:])
+	c.idMask = binary.LittleEndian.Uint32(data[8:])
+	vendorLength := int(binary.LittleEndian.Uint16(data[16:]))
+	screens := int(data[20])
+	formats := int(data[21])
+	offset := 32 + len(pad(make([]byte, vendorLength))) + formats*8
+	if c.screen >= screens {
+		return fmt.Errorf("x11 screen %d does not exist", c.screen)
+	}
+	for i := 0; ; i++ {
+		if offset+40 > len(data) {
+			return errors.New("x11 setup reply too short")
+		}
+		if i == c.screen {
+			c.root = binary.LittleEndian.Uint32(data[offset:])
+			return nil
+		}
+		depths := int(data[offset+39])
+		offset += 40
+		for j := 0; j < depths; j++ {
+			if offset+8 > len(data) {
+				return errors.New("x11 setup reply too short")
+			}
+			offset += 8 + int(binary.LittleEndian.Uint16(data[offset+2:]))*24
+		}
+	}
+}
+
+// Close closes the connection.
+func (c *Conn) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.closed {
+		return nil
+	}
+	c.closed = true
+	return c.sock.Close()
+}
+
+// Root returns the root window of the connection's screen.
+func (c *Conn) Root() uint32 {
+	return c.root
+}
+
+// Screen returns the number of the connection's screen.
+func (c *Conn) Screen() int {
+	return c.screen
+}
+
+// NewID allocates a resource ID, for windows and other resources created by the
+// client.
+func (c *Conn) NewID() uint32 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.nextID++
+	return c.idBase | (c.nextID & c.idMask)
+}
+
+// InternAtom returns the atom of a name, creating it if needed.
+func (c *Conn) InternAtom(name string) (uint32, error) {
+	req := request(opInternAtom, 0, 8+len(pad([]byte(name))))
+	binary.LittleEndian.PutUint16(req[4:], uint16(len(name)))
+	copy(req[8:], name)
+	reply, err := c.roundtrip(req)
+	if err != nil {
+		return 0, err
+	}
+	return binary.LittleEndian.Uint32(reply[8:]), nil
+}
+
+// CreateWindow creates an unmapped, input-only window on the root window, as
+// used to own selections or send client messages. It selects the events of
+// eventMask.
+func (c *Conn) CreateWindow(eventMask uint32) (uint32, error) {
+	id := c.NewID()
+	req := request(opCreateWindow, 0, 40)
+	binary.LittleEndian.PutUint32(req[4:], id)
+	binary.LittleEndian.PutUint32(req[8:], c.root)
+	binary.LittleEndian.PutUint16(req[16:], 1)
+	binary.LittleEndian.PutUint16(req[18:], 1)
+	binary.LittleEndian.PutUint16(req[22:], windowClassInputOnly)
+	binary.LittleEndian.PutUint32(req[28:], cwOverrideRedirect|cwEventMask)
+	binary.LittleEndian.PutUint32(req[32:], 1)
+	binary.LittleEndian.PutUint32(req[36:], eventMask)
+	return id, c.send(req)
+}
+
+// DestroyWindow destroys a window created by the client.
+func (c *Conn) DestroyWindow(window uint32) error {
+	req := request(opDestroyWindow, 0, 8)
+	binary.LittleEndian.PutUint32(req[4:], window)
+	return c.send(req)
+}
+
+// SendClientMessage sends a ClientMessage event with 8-bit data to a window,
+// usually the root window, on behalf of window. Receivers see it if they select
+// one of the events of eventMask.
+func (c *Conn) SendClientMessage(destination, window, messageType, eventMask uint32, data [20]byte) error {
+	req := request(opSendEvent, 0, 44)
+	binary.LittleEndian.PutUint32(req[4:], destination)
+	binary.LittleEndian.PutUint32(req[8:], eventMask)
+	event := req[12:]
+	event[0] = eventClientMessage
+	event[1] = 8
+	binary.LittleEndian.PutUint32(event[4:], window)
+	binary.LittleEndian.PutUint32(event[8:], messageType)
+	copy(event[12:], data[:])
+	return c.send(req)
+}
+
+// ServerTime returns the current X server time, for timestamps of requests not
+// caused by an input event. window must have been created with
+// PropertyChangeMask: the time is that of a PropertyNotify event caused by an
+// empty change to one of its properties.
+func (c *Conn) ServerTime(window uint32) (uint32, error) {
+	property, err := c.InternAtom("_LIBXDG_TIMESTAMP")
+	if err != nil {
+		return 0, err
+	}
+
+	req := request(opChangeProperty, propModeAppend, 24)
+	binary.LittleEndian.PutUint32(req[4:], window)
+	binary.LittleEndian.PutUint32(req[8:], property)
+	binary.LittleEndian.PutUint32(req[12:], atomString)
+	req[16] = 8
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err := c.sendLocked(req); err != nil {
+		return 0, err
+	}
+	var timestamp uint32
+	err = c.readUntil(func(packet []byte) bool {
+		if packet[0]&0x7f == eventPropertyNotify && binary.LittleEndian.Uint32(packet[4:]) == window && binary.LittleEndian.Uint32(packet[8:]) == property {
+			timestamp = binary.LittleEndian.Uint32(packet[12:])
+			return true
+		}
+		return false
+	})
+	return timestamp, err
+}
+
+// Sync waits until the server has processed every request sent so far, and
+// returns the error caused by one of them if any.
+func (c *Conn) Sync() error {
+	_, err := c.roundtrip(request(opGetInputFocus, 0, 4))
+	return err
+}
+
+// request returns a request of length bytes with its header filled in.
+func request(opcode, data uint8, length int) []byte {
+	req := make([]byte, length)
+	req[0] = opcode
+	req[1] = data
+	binary.LittleEndian.PutUint16(req[2:], uint16(length/4))
+	return req
+}
+
+// pad pads b with zeros to a multiple of four bytes.
+func pad(b []byte) []byte {
+	if n := len(b) % 4; n != 0 {
+		b = append(b, make([]byte, 4-n)...)
+	}
+	return b
+}
+
+func (c *Conn) send(req []byte) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.sendLocked(req)
+}
+
+// sendLocked writes a request and counts its sequence number. c.mu must be held.
+func (c *Conn) sendLocked(req []byte) error {
+	if c.closed {
+		return ErrClosed
+	}
+	if _, err := c.sock.Write(req); err != nil {
+		return err
+	}
+	c.seq++
+	return nil
+}
+
+// roundtrip sends a request and returns its reply.
+func (c *Conn) roundtrip(req []byte) ([]byte, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err := c.sendLocked(req); err != nil {
+		return nil, err
+	}
+	seq := c.seq
+	var reply []byte
+	err := c.readUntil(func(packet []byte) bool {
+		if packet[0] == 1 && binary.LittleEndian.Uint16(packet[2:]) == seq {
+			reply = packet
+			return true
+		}
+		return false
+	})
+	return reply, err
+}
+
+// readUntil reads replies and events until match accepts one, discarding the
+// others. Errors sent by the server are returned. c.mu must be held.
+func (c *Conn) readUntil(match func(packet []byte) bool) error {
+	c.sock.SetReadDeadline(time.Now().Add(replyTimeout))
+	defer c.sock.SetReadDeadline(time.Time{})
+	for {
+		packet := make([]byte, 32)
+		if _, err := io.ReadFull(c.sock, packet); err != nil {
+			return err
+		}
+		switch packet[0] {
+		case 0:
+			return &ProtocolError{
+				Code:     packet[1],
+				BadValue: binary.LittleEndian.Uint32(packet[4:]),
+				Minor:    binary.LittleEndian.Uint16(packet[8:]),
+				Major:    packet[10],
+			}
+		case 1:
+			if extra := binary.LittleEndian.Uint32(packet[4:]); extra > 0 {
+				packet = append(packet, make([]byte, int(extra)*4)...)
+				if _, err := io.ReadFull(c.sock, packet[32:]); err != nil {
+					return err
+				}
+			}
+		}
+		if match(packet) {
+			return nil
+		}
+	}
+}